@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kfilin/massage-bot/internal/adapters/caldav"
 	"github.com/kfilin/massage-bot/internal/adapters/googlecalendar"
 	"github.com/kfilin/massage-bot/internal/adapters/transcription"
 	"github.com/kfilin/massage-bot/internal/config"
@@ -16,7 +17,15 @@ import (
 	"github.com/kfilin/massage-bot/internal/delivery/web"
 	"github.com/kfilin/massage-bot/internal/domain"
 	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
 	"github.com/kfilin/massage-bot/internal/services/appointment"
+	"github.com/kfilin/massage-bot/internal/services/calendarsync"
+	"github.com/kfilin/massage-bot/internal/services/packages"
+	"github.com/kfilin/massage-bot/internal/services/payments"
+	"github.com/kfilin/massage-bot/internal/services/promo"
+	"github.com/kfilin/massage-bot/internal/services/reconcile"
+	"github.com/kfilin/massage-bot/internal/services/waitlist"
 	"github.com/kfilin/massage-bot/internal/storage"
 	"github.com/kfilin/massage-bot/internal/version"
 )
@@ -56,20 +65,84 @@ func main() {
 		logging.Errorf("ERROR during migration: %v", err)
 	}
 
-	// 2. Initialize Google Calendar Client
-	googleCalendarClient, err := googlecalendar.NewGoogleCalendarClient()
-	if err != nil {
-		logging.Fatalf("Error initializing Google Calendar client: %v", err)
+	// 2-3. Initialize the AppointmentRepository: Google Calendar by default,
+	// any CalDAV server, or Postgres alone for setups without an external
+	// calendar. calendarRepo opens the same provider for therapists' own
+	// calendars.
+	var appointmentRepo ports.AppointmentRepository
+	var calendarRepo func(calendarID string) ports.AppointmentRepository
+	switch cfg.CalendarProvider {
+	case "postgres":
+		// Optionally copy every booking to Google Calendar as well
+		mirror := func(calendarID string) ports.AppointmentRepository { return nil }
+		if cfg.MirrorToGoogle {
+			googleCalendarClient, err := googlecalendar.NewGoogleCalendarClient()
+			if err != nil {
+				logging.Fatalf("Error initializing Google Calendar client: %v", err)
+			}
+			mirror = func(calendarID string) ports.AppointmentRepository {
+				return googlecalendar.NewAdapter(googleCalendarClient, calendarID)
+			}
+		}
+		calendarRepo = func(calendarID string) ports.AppointmentRepository {
+			return storage.NewPostgresAppointmentRepository(db, calendarID, mirror(calendarID))
+		}
+		appointmentRepo = storage.NewPostgresAppointmentRepository(db, storage.LocalCalendarID, mirror(cfg.GoogleCalendarID))
+		logging.Infof("Appointment repository (Postgres, mirrored to Google: %v) initialized.", cfg.MirrorToGoogle)
+	case "caldav":
+		caldavClient, err := caldav.NewClient(cfg.CalDAVURL, cfg.CalDAVUsername, cfg.CalDAVPassword)
+		if err != nil {
+			logging.Fatalf("Error initializing CalDAV client: %v", err)
+		}
+		calendarRepo = func(calendarID string) ports.AppointmentRepository {
+			return caldav.NewAdapter(caldavClient, calendarID)
+		}
+		appointmentRepo = calendarRepo(cfg.CalDAVCalendar)
+		logging.Infof("Appointment repository (CalDAV adapter, %s) initialized.", cfg.CalDAVURL)
+	default:
+		googleCalendarClient, err := googlecalendar.NewGoogleCalendarClient()
+		if err != nil {
+			logging.Fatalf("Error initializing Google Calendar client: %v", err)
+		}
+		logging.Info("Google Calendar client initialized.")
+		calendarRepo = func(calendarID string) ports.AppointmentRepository {
+			return googlecalendar.NewAdapter(googleCalendarClient, calendarID)
+		}
+		appointmentRepo = calendarRepo(cfg.GoogleCalendarID)
+		logging.Info("Appointment repository (Google Calendar adapter) initialized.")
 	}
-	logging.Info("Google Calendar client initialized.")
-
-	// 3. Initialize AppointmentRepository (Google Calendar Adapter implements this)
-	// Pass the client and the calendar ID from config
-	appointmentRepo := googlecalendar.NewAdapter(googleCalendarClient, cfg.GoogleCalendarID)
-	logging.Info("Appointment repository (Google Calendar adapter) initialized.")
 
 	// 4. Initialize AppointmentService (business logic)
 	appointmentService := appointment.NewService(appointmentRepo, patientRepo)
+	appointmentService.SetServiceCatalog(patientRepo)
+	if err := appointmentService.SeedServiceCatalog(context.Background()); err != nil {
+		logging.Warnf("Warning: failed to seed service catalog: %v", err)
+	}
+	appointmentService.SetScheduleRepository(patientRepo)
+	if err := appointmentService.SeedSchedule(context.Background()); err != nil {
+		logging.Warnf("Warning: failed to seed working schedule: %v", err)
+	}
+	appointmentService.SetSeriesRepository(patientRepo)
+	appointmentService.SetStatusRepository(patientRepo)
+	// Slot checks are serialized through Postgres so replicas cannot double-book
+	appointmentService.SetBookingLocker(patientRepo)
+	appointmentService.SetSlotHoldRepository(patientRepo, time.Duration(cfg.SlotHoldMinutes)*time.Minute)
+	// Therapists registered via /therapist_save each get their own calendar;
+	// with none registered the bot keeps booking into the default calendar.
+	appointmentService.SetTherapistRegistry(patientRepo, calendarRepo)
+	slotPolicy := domain.SlotPolicy{
+		StepMinutes:         cfg.SlotStepMinutes,
+		BufferBeforeMinutes: cfg.SlotBufferBeforeMinutes,
+		BufferAfterMinutes:  cfg.SlotBufferAfterMinutes,
+		PackToBookings:      cfg.SlotPackToBookings,
+	}
+	if err := appointmentService.SetSlotPolicy(slotPolicy); err != nil {
+		logging.Warnf("Warning: invalid slot settings, using hourly slots: %v", err)
+	}
+	cancelPolicy := domain.CancellationPolicy{NoticeHours: cfg.CancelNoticeHours, BlockLate: cfg.BlockLateCancel}
+	if err := appointmentService.SetCancellationPolicy(cancelPolicy); err != nil {
+		logging.Warnf("Warning: invalid cancellation settings, using 72 hours notice: %v", err)
+	}
 	logging.Info("Appointment service initialized.")
 
 	// 5. Initialize SessionStorage (using PostgreSQL persistence)
@@ -106,13 +179,64 @@ func main() {
 	// Set metadata in repository for dynamic link generation
 	patientRepo.BotUsername = botUsername
 
+	// Keep the coming days' Free/Busy cached so slot lists rarely wait on Google
+	if cfg.FreeBusyPrewarmDays > 0 {
+		appointmentService.StartCacheWarmer(ctx, cfg.FreeBusyPrewarmDays)
+	}
+
+	// Offer slots freed by cancellations to the waitlist
+	waitlistService := waitlist.NewService(patientRepo, appointmentService, bot, presentation.NewBotPresenter(), time.Duration(cfg.WaitlistOfferMinutes)*time.Minute)
+	appointmentService.SetSlotFreedHook(waitlistService.SlotFreed)
+	waitlistService.Start(ctx)
+
+	// Charge completed visits to prepaid packages, give the session back for
+	// visits later marked a no-show or cancelled, and warn admins
+	allAdmins := config.ResolveAdminIDs(cfg.AdminTelegramID, cfg.AllowedTelegramIDs, cfg.TherapistIDs)
+	packageService := packages.NewService(patientRepo, appointmentService, bot, allAdmins, presentation.NewBotPresenter(), cfg.PackageAlertSessions, cfg.PackageAlertDays)
+	packageService.Start(ctx)
+	appointmentService.SetStatusChangedHook(packageService.StatusChanged)
+
+	// Record visit payments and keep the revenue gauges current
+	paymentService := payments.NewService(patientRepo, appointmentService, cfg.Currency)
+	paymentService.Start(ctx)
+
+	// Promo codes entered at booking confirmation
+	promoService := promo.NewService(patientRepo)
+
+	// Keep the appointments table in step with Google Calendar: a full sync
+	// once, then only changed events, pulled on Google's pings when the web
+	// server can receive them and every few minutes otherwise. With Postgres
+	// as the calendar the table is complete already, so history is read from
+	// it in both cases.
+	var calendarSync ports.CalendarSyncService
+	if cfg.CalendarProvider == "google" {
+		webhookURL := cfg.CalendarWebhookURL
+		if webhookURL != "" && cfg.WebAppSecret == "" {
+			logging.Warn("Warning: CALENDAR_WEBHOOK_URL needs the Web App server, calendar sync falls back to polling.")
+			webhookURL = ""
+		}
+		syncService := calendarsync.NewService(patientRepo, appointmentService, webhookURL, calendarsync.DefaultChannelTTL)
+		// Tell patients about visits moved or deleted directly in the calendar
+		reconcileService := reconcile.NewService(patientRepo, appointmentService, bot, allAdmins, presentation.NewBotPresenter())
+		syncService.SetChangeHook(reconcileService.HandleChange)
+		if webhookURL != "" {
+			calendarSync = syncService
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-syncService.Start(ctx)
+		}()
+	}
+	appointmentService.SetHistorySynced(cfg.CalendarProvider != "caldav")
+	appointmentService.SetCalendarInDatabase(cfg.CalendarProvider == "postgres")
+
 	// 8. Start Web App server
 	if cfg.WebAppSecret != "" {
-		allAdmins := config.ResolveAdminIDs(cfg.AdminTelegramID, cfg.AllowedTelegramIDs, cfg.TherapistIDs)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			web.StartServer(ctx, cfg.WebAppPort, cfg.WebAppSecret, cfg.TgBotToken, allAdmins, patientRepo, appointmentService, transcriptionAdapter, os.Getenv("DATA_DIR"), botUsername)
+			web.StartServer(ctx, cfg.WebAppPort, cfg.WebAppSecret, cfg.TgBotToken, allAdmins, patientRepo, appointmentService, transcriptionAdapter, os.Getenv("DATA_DIR"), botUsername, packageService, paymentService, calendarSync)
 		}()
 	} else {
 		logging.Warn("Warning: WEBAPP_SECRET not set, Web App server not started.")
@@ -133,6 +257,10 @@ func main() {
 			cfg.WebAppURL,
 			cfg.WebAppSecret,
 			cfg.TherapistIDs,
+			waitlistService,
+			packageService,
+			paymentService,
+			promoService,
 		)
 	}()
 
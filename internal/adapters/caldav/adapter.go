@@ -0,0 +1,404 @@
+package caldav
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+type adapter struct {
+	client     *Client
+	calendarID string
+	collection string // Collection URL, with a trailing slash
+}
+
+// NewAdapter creates a CalDAV adapter that implements
+// ports.AppointmentRepository for one calendar collection. calendarID is
+// the collection's path, relative to the client's base URL or absolute on
+// the server (see ListCalendars).
+func NewAdapter(client *Client, calendarID string) ports.AppointmentRepository {
+	return &adapter{
+		client:     client,
+		calendarID: calendarID,
+		collection: client.collectionURL(calendarID),
+	}
+}
+
+// eventURL is where the event with the given ID is stored.
+func (a *adapter) eventURL(id string) string {
+	return a.collection + url.PathEscape(id) + ".ics"
+}
+
+// Create stores a new appointment as an event named after a fresh UID.
+func (a *adapter) Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if appt.StartTime.IsZero() || appt.EndTime.IsZero() {
+		return nil, fmt.Errorf("appointment StartTime or EndTime is zero; ensure set by service layer")
+	}
+
+	id, err := newUID()
+	if err != nil {
+		return nil, err
+	}
+	description := appt.Notes
+	if appt.CustomerTgID != "" {
+		description = fmt.Sprintf("TGID:%s\n%s", appt.CustomerTgID, appt.Notes)
+	}
+	body := renderEvent(icalEvent{
+		UID:         id,
+		Summary:     fmt.Sprintf("%s - %s", appt.Service.Name, appt.CustomerName),
+		Description: description,
+		Status:      "CONFIRMED",
+		Start:       appt.StartTime,
+		End:         appt.EndTime,
+	}, time.Now())
+
+	_, _, err = a.client.do(ctx, "insert_event", http.MethodPut, a.eventURL(id), map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"If-None-Match": "*",
+	}, body, http.StatusCreated, http.StatusNoContent, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar event in '%s': %w", a.calendarID, err)
+	}
+
+	appt.ID = id
+	logging.Infof("SUCCESS: Event created in '%s' (ID: %s)", a.calendarID, id)
+	return appt, nil
+}
+
+// GetAccountInfo returns the calendar's display name.
+func (a *adapter) GetAccountInfo(ctx context.Context) (string, error) {
+	responses, err := a.propfind(ctx, a.collection, "0")
+	if err != nil {
+		return "", err
+	}
+	for _, r := range responses {
+		if name := r.props().DisplayName; name != "" {
+			return name, nil
+		}
+	}
+	return a.client.username, nil
+}
+
+func (a *adapter) GetCalendarID() string {
+	return a.calendarID
+}
+
+// ListCalendars lists the calendar collections in the client's base URL as
+// "Display name (/path/)"; the path can be used as a calendar ID.
+func (a *adapter) ListCalendars(ctx context.Context) ([]string, error) {
+	responses, err := a.propfind(ctx, a.client.base.String(), "1")
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, r := range responses {
+		props := r.props()
+		if props.ResourceType.Calendar == nil {
+			continue
+		}
+		name := props.DisplayName
+		if name == "" {
+			name = path.Base(strings.TrimSuffix(r.Href, "/"))
+		}
+		res = append(res, fmt.Sprintf("%s (%s)", name, r.Href))
+	}
+	return res, nil
+}
+
+func (a *adapter) propfind(ctx context.Context, target, depth string) ([]davResponse, error) {
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop><D:displayname/><D:resourcetype/></D:prop></D:propfind>`
+	_, data, err := a.client.do(ctx, "propfind", "PROPFIND", target, map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        depth,
+	}, body, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := parseMultistatus(data)
+	if err != nil {
+		return nil, err
+	}
+	return ms.Responses, nil
+}
+
+// GetFreeBusy derives busy intervals from the events in the range, since
+// free-busy-query support varies between servers. Transparent and
+// cancelled events do not block time; intervals are clipped to the range.
+func (a *adapter) GetFreeBusy(ctx context.Context, timeMin, timeMax time.Time) ([]domain.TimeSlot, error) {
+	logging.Debugf("DEBUG: Querying CalDAV busy time from %s to %s for calendar %s", timeMin.Format(time.RFC3339), timeMax.Format(time.RFC3339), a.calendarID)
+	events, err := a.query(ctx, "free_busy_query", &timeMin, &timeMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query busy time: %w", err)
+	}
+
+	var busy []domain.TimeSlot
+	for _, ev := range events {
+		if !blocksTime(ev.icalEvent) {
+			continue
+		}
+		start, end := ev.Start, ev.End
+		if start.Before(timeMin) {
+			start = timeMin
+		}
+		if end.After(timeMax) {
+			end = timeMax
+		}
+		busy = append(busy, domain.TimeSlot{Start: start, End: end})
+	}
+	logging.Debugf("DEBUG: CalDAV busy query for %s returned %d busy intervals", a.calendarID, len(busy))
+	return busy, nil
+}
+
+// FindAll fetches all events starting from 24 hours ago.
+func (a *adapter) FindAll(ctx context.Context) ([]domain.Appointment, error) {
+	timeMin := time.Now().Add(-24 * time.Hour)
+	return a.FindEvents(ctx, &timeMin, nil)
+}
+
+// FindEvents fetches events overlapping the optional time range, ordered by
+// start. Recurring events are expanded when both bounds are given.
+func (a *adapter) FindEvents(ctx context.Context, timeMin, timeMax *time.Time) ([]domain.Appointment, error) {
+	events, err := a.query(ctx, "list_events_full", timeMin, timeMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar events from '%s': %w", a.calendarID, err)
+	}
+
+	var appointments []domain.Appointment
+	for _, ev := range events {
+		if !blocksTime(ev.icalEvent) {
+			logging.Debugf("DEBUG: Skipping transparent or cancelled event: %s", ev.Summary)
+			continue
+		}
+		appointments = append(appointments, toAppointment(ev.id, ev.icalEvent))
+	}
+	return appointments, nil
+}
+
+// storedEvent is an event with the ID of the resource it is stored in.
+type storedEvent struct {
+	icalEvent
+	id string
+}
+
+// query runs a calendar-query REPORT and returns the events overlapping
+// [timeMin, timeMax), sorted by start. Servers are asked to filter, and the
+// result is filtered again in case one ignores the time range.
+func (a *adapter) query(ctx context.Context, op string, timeMin, timeMax *time.Time) ([]storedEvent, error) {
+	rangeAttrs := ""
+	if timeMin != nil {
+		rangeAttrs += fmt.Sprintf(` start="%s"`, timeMin.UTC().Format(icalUTCLayout))
+	}
+	if timeMax != nil {
+		rangeAttrs += fmt.Sprintf(` end="%s"`, timeMax.UTC().Format(icalUTCLayout))
+	}
+	calendarData := "<C:calendar-data/>"
+	if timeMin != nil && timeMax != nil {
+		calendarData = "<C:calendar-data><C:expand" + rangeAttrs + "/></C:calendar-data>"
+	}
+	timeRange := ""
+	if rangeAttrs != "" {
+		timeRange = "<C:time-range" + rangeAttrs + "/>"
+	}
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/>` + calendarData + `</D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">` + timeRange + `</C:comp-filter></C:comp-filter></C:filter>
+</C:calendar-query>`
+
+	_, data, err := a.client.do(ctx, op, "REPORT", a.collection, map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        "1",
+	}, body, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := parseMultistatus(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []storedEvent
+	for _, r := range ms.Responses {
+		props := r.props()
+		if props.CalendarData == "" {
+			continue
+		}
+		parsed, err := parseEvents(props.CalendarData)
+		if err != nil {
+			logging.Warnf("Warning: failed to parse CalDAV event %s: %v", r.Href, err)
+			continue
+		}
+		for _, ev := range parsed {
+			if timeMin != nil && !ev.End.After(*timeMin) && !ev.Start.Equal(*timeMin) {
+				continue
+			}
+			if timeMax != nil && !ev.Start.Before(*timeMax) {
+				continue
+			}
+			events = append(events, storedEvent{icalEvent: ev, id: resourceID(r.Href)})
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events, nil
+}
+
+// FindByID retrieves the event stored under id.
+func (a *adapter) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	_, data, err := a.client.do(ctx, "get_event", http.MethodGet, a.eventURL(id), nil, "", http.StatusOK)
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) || hasStatus(err, http.StatusGone) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get calendar event by ID %s: %w", id, err)
+	}
+	events, err := parseEvents(string(data))
+	if err != nil || len(events) == 0 {
+		return nil, fmt.Errorf("failed to convert event %s to appointment: %v", id, err)
+	}
+	appt := toAppointment(id, events[0])
+	return &appt, nil
+}
+
+// Delete deletes the event stored under id. An event that is already gone
+// (410) counts as deleted.
+func (a *adapter) Delete(ctx context.Context, id string) error {
+	_, _, err := a.client.do(ctx, "delete_event", http.MethodDelete, a.eventURL(id), nil, "",
+		http.StatusNoContent, http.StatusOK, http.StatusGone)
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) {
+			return domain.ErrAppointmentNotFound
+		}
+		return fmt.Errorf("failed to delete calendar event: %w", err)
+	}
+	return nil
+}
+
+// Update moves an existing event to the appointment's start and end time.
+// Only DTSTART and DTEND are rewritten, so everything else in the event
+// stays; the write is conditional on the event not having changed since
+// it was read.
+func (a *adapter) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if appt.ID == "" || appt.StartTime.IsZero() || appt.EndTime.IsZero() {
+		return nil, fmt.Errorf("appointment ID, StartTime or EndTime is empty; ensure set by service layer")
+	}
+
+	target := a.eventURL(appt.ID)
+	header, data, err := a.client.do(ctx, "get_event", http.MethodGet, target, nil, "", http.StatusOK)
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) || hasStatus(err, http.StatusGone) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("failed to read calendar event %s: %w", appt.ID, err)
+	}
+
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	if etag := header.Get("ETag"); etag != "" {
+		headers["If-Match"] = etag
+	}
+	body := retimeEvent(string(data), appt.StartTime, appt.EndTime)
+	_, _, err = a.client.do(ctx, "patch_event", http.MethodPut, target, headers, body,
+		http.StatusNoContent, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		if hasStatus(err, http.StatusNotFound) || hasStatus(err, http.StatusGone) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("failed to update calendar event %s: %w", appt.ID, err)
+	}
+
+	logging.Infof("SUCCESS: Event %s moved to %s in '%s'", appt.ID, appt.StartTime.Format(time.RFC3339), a.calendarID)
+	return appt, nil
+}
+
+// retimeEvent replaces the start and end of the first VEVENT in data.
+func retimeEvent(data string, start, end time.Time) string {
+	var b strings.Builder
+	inEvent, done, nested := false, false, 0
+	for _, line := range unfoldLines(data) {
+		prop, _ := parseProperty(line)
+		switch {
+		case done:
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			inEvent = true
+		case inEvent && prop.Name == "BEGIN":
+			nested++
+		case inEvent && prop.Name == "END" && nested > 0:
+			nested--
+		case inEvent && prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			inEvent, done = false, true
+		case inEvent && nested == 0 && prop.Name == "DTSTART":
+			line = "DTSTART:" + start.UTC().Format(icalUTCLayout)
+		case inEvent && nested == 0 && prop.Name == "DTEND":
+			line = "DTEND:" + end.UTC().Format(icalUTCLayout)
+		case inEvent && nested == 0 && prop.Name == "DURATION":
+			line = "DTEND:" + end.UTC().Format(icalUTCLayout)
+		}
+		writeFolded(&b, line)
+	}
+	return b.String()
+}
+
+// blocksTime tells whether an event occupies its time.
+func blocksTime(ev icalEvent) bool {
+	return ev.Transp != "TRANSPARENT" && ev.Status != "CANCELLED"
+}
+
+// resourceID is the event ID for a resource href: its name without ".ics".
+func resourceID(href string) string {
+	name := path.Base(href)
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return strings.TrimSuffix(name, ".ics")
+}
+
+// newUID returns a random event UID, also used as the resource name.
+func newUID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate event UID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// toAppointment maps an event the way the Google adapter does: the summary
+// is "Service - Customer" and the description may start with "TGID:<id>".
+func toAppointment(id string, ev icalEvent) domain.Appointment {
+	duration := int(ev.End.Sub(ev.Start).Minutes())
+
+	customerTgID := ""
+	notes := ev.Description
+	if rest, ok := strings.CutPrefix(ev.Description, "TGID:"); ok {
+		customerTgID, notes, _ = strings.Cut(rest, "\n")
+		customerTgID = strings.TrimSpace(customerTgID)
+	}
+
+	serviceName, customerName := ev.Summary, ""
+	if parts := domain.SplitSummary(ev.Summary); len(parts) >= 2 {
+		serviceName, customerName = parts[0], parts[1]
+	}
+
+	return domain.Appointment{
+		ID:           id,
+		ClientID:     id,
+		StartTime:    ev.Start,
+		EndTime:      ev.End,
+		Duration:     duration,
+		CustomerName: customerName,
+		CustomerTgID: customerTgID,
+		Notes:        notes,
+		Service:      domain.Service{Name: serviceName, DurationMinutes: duration},
+		Status:       strings.ToLower(ev.Status),
+	}
+}
@@ -0,0 +1,249 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// fakeServer is an in-memory CalDAV server with one calendar at
+// /cal/massage/. REPORT returns every event, so the adapter's own range
+// filtering is exercised.
+type fakeServer struct {
+	mu        sync.Mutex
+	resources map[string]string // Path -> iCalendar data
+	etags     map[string]int
+	requests  []string
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *adapter) {
+	t.Helper()
+	fake := &fakeServer{resources: map[string]string{}, etags: map[string]int{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(server.URL+"/cal/", "vera", "secret")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return fake, NewAdapter(client, "massage").(*adapter)
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	body, _ := io.ReadAll(r.Body)
+	etag := func(p string) string { return fmt.Sprintf(`"%d"`, f.etags[p]) }
+
+	switch r.Method {
+	case http.MethodPut:
+		_, exists := f.resources[r.URL.Path]
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != etag(r.URL.Path)) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		f.resources[r.URL.Path] = string(body)
+		f.etags[r.URL.Path]++
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case http.MethodGet:
+		data, ok := f.resources[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag(r.URL.Path))
+		_, _ = io.WriteString(w, data)
+	case http.MethodDelete:
+		if _, ok := f.resources[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.resources, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case "REPORT":
+		var b strings.Builder
+		b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">`)
+		for p, data := range f.resources {
+			fmt.Fprintf(&b, `<d:response><d:href>%s</d:href><d:propstat><d:prop><d:getetag>%s</d:getetag><c:calendar-data>%s</c:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`,
+				p, xmlEscape(etag(p)), xmlEscape(data))
+		}
+		b.WriteString(`</d:multistatus>`)
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = io.WriteString(w, b.String())
+	case "PROPFIND":
+		w.WriteHeader(http.StatusMultiStatus)
+		if r.Header.Get("Depth") == "0" {
+			_, _ = io.WriteString(w, `<d:multistatus xmlns:d="DAV:"><d:response><d:href>/cal/massage/</d:href><d:propstat><d:prop><d:displayname>Массаж</d:displayname></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response></d:multistatus>`)
+			return
+		}
+		_, _ = io.WriteString(w, `<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+<d:response><d:href>/cal/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+<d:response><d:href>/cal/massage/</d:href><d:propstat><d:prop><d:displayname>Массаж</d:displayname><d:resourcetype><d:collection/><c:calendar/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+<d:response><d:href>/cal/personal/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/><c:calendar/></d:resourcetype></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>
+</d:multistatus>`)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// put stores an event created outside the bot.
+func (f *fakeServer) put(id string, ev icalEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ev.UID = id
+	f.resources["/cal/massage/"+id+".ics"] = renderEvent(ev, ev.Start)
+}
+
+func testAppointment(start time.Time) *domain.Appointment {
+	return &domain.Appointment{
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+		CustomerName: "Иван",
+		CustomerTgID: "42",
+		Notes:        "Первый визит",
+		Service:      domain.Service{Name: "Массаж спины"},
+	}
+}
+
+func TestAdapter_CreateAndFindByID(t *testing.T) {
+	ctx := context.Background()
+	fake, a := newFakeServer(t)
+	start := time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	created, err := a.Create(ctx, testAppointment(start))
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.ID == "" {
+		t.Fatal("expected an ID")
+	}
+	if fake.requests[0] != "PUT /cal/massage/"+created.ID+".ics" {
+		t.Errorf("unexpected request %q", fake.requests[0])
+	}
+
+	found, err := a.FindByID(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("FindByID failed: %v", err)
+	}
+	if found.CustomerName != "Иван" || found.CustomerTgID != "42" || found.Notes != "Первый визит" ||
+		found.Service.Name != "Массаж спины" || found.Duration != 60 || !found.StartTime.Equal(start) {
+		t.Errorf("unexpected appointment %+v", found)
+	}
+
+	if _, err := a.FindByID(ctx, "missing"); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+	if _, err := a.Create(ctx, &domain.Appointment{}); err == nil {
+		t.Error("expected an error for an appointment without times")
+	}
+}
+
+func TestAdapter_FindEventsAndFreeBusy(t *testing.T) {
+	ctx := context.Background()
+	fake, a := newFakeServer(t)
+	day := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	fake.put("late", icalEvent{Summary: "Массаж - Пётр", Start: day.Add(15 * time.Hour), End: day.Add(16 * time.Hour)})
+	fake.put("early", icalEvent{Summary: "Массаж - Анна", Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour)})
+	fake.put("night", icalEvent{Summary: "Перелёт", Start: day.Add(-2 * time.Hour), End: day.Add(time.Hour)})
+	fake.put("free", icalEvent{Summary: "Заметка", Transp: "TRANSPARENT", Start: day.Add(12 * time.Hour), End: day.Add(13 * time.Hour)})
+	fake.put("cancelled", icalEvent{Summary: "Отмена", Status: "CANCELLED", Start: day.Add(12 * time.Hour), End: day.Add(13 * time.Hour)})
+	fake.put("tomorrow", icalEvent{Summary: "Массаж - Олег", Start: day.Add(33 * time.Hour), End: day.Add(34 * time.Hour)})
+
+	timeMax := day.AddDate(0, 0, 1)
+	events, err := a.FindEvents(ctx, &day, &timeMax)
+	if err != nil {
+		t.Fatalf("FindEvents failed: %v", err)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	if strings.Join(ids, ",") != "night,early,late" {
+		t.Errorf("expected the day's busy events by start, got %v", ids)
+	}
+
+	busy, err := a.GetFreeBusy(ctx, day, timeMax)
+	if err != nil {
+		t.Fatalf("GetFreeBusy failed: %v", err)
+	}
+	if len(busy) != 3 || !busy[0].Start.Equal(day) || !busy[0].End.Equal(day.Add(time.Hour)) {
+		t.Errorf("expected 3 busy intervals, the first clipped to the range, got %v", busy)
+	}
+}
+
+func TestAdapter_Update(t *testing.T) {
+	ctx := context.Background()
+	fake, a := newFakeServer(t)
+	start := time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC)
+	created, _ := a.Create(ctx, testAppointment(start))
+
+	moved := *created
+	moved.StartTime, moved.EndTime = start.AddDate(0, 0, 1), start.AddDate(0, 0, 1).Add(90*time.Minute)
+	if _, err := a.Update(ctx, &moved); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	found, _ := a.FindByID(ctx, created.ID)
+	if !found.StartTime.Equal(moved.StartTime) || found.Duration != 90 || found.CustomerTgID != "42" {
+		t.Errorf("expected only the times changed, got %+v", found)
+	}
+	if got := fake.requests[len(fake.requests)-2]; got != "PUT /cal/massage/"+created.ID+".ics" {
+		t.Errorf("expected a conditional PUT, got %q", got)
+	}
+
+	missing := *created
+	missing.ID = "missing"
+	if _, err := a.Update(ctx, &missing); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+}
+
+func TestAdapter_Delete(t *testing.T) {
+	ctx := context.Background()
+	_, a := newFakeServer(t)
+	created, _ := a.Create(ctx, testAppointment(time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC)))
+
+	if err := a.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := a.Delete(ctx, created.ID); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+}
+
+func TestAdapter_CalendarInfo(t *testing.T) {
+	ctx := context.Background()
+	_, a := newFakeServer(t)
+
+	if a.GetCalendarID() != "massage" {
+		t.Errorf("GetCalendarID() = %q", a.GetCalendarID())
+	}
+	name, err := a.GetAccountInfo(ctx)
+	if err != nil || name != "Массаж" {
+		t.Errorf("GetAccountInfo() = %q, %v", name, err)
+	}
+	calendars, err := a.ListCalendars(ctx)
+	if err != nil {
+		t.Fatalf("ListCalendars failed: %v", err)
+	}
+	if strings.Join(calendars, "; ") != "Массаж (/cal/massage/); personal (/cal/personal/)" {
+		t.Errorf("unexpected calendars %v", calendars)
+	}
+}
@@ -0,0 +1,200 @@
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/monitoring"
+)
+
+// Client talks WebDAV/CalDAV to one server (Nextcloud, Radicale, Baïkal)
+// with HTTP basic auth. Adapters for individual calendars share it.
+type Client struct {
+	base     *url.URL
+	username string
+	password string
+	http     *http.Client
+}
+
+// NewClient creates a client for the server at baseURL, usually the
+// user's calendar home (e.g. https://cloud.example.com/remote.php/dav/calendars/vera/).
+func NewClient(baseURL, username, password string) (*Client, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid CalDAV URL %q", baseURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	return &Client{
+		base:     base,
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// collectionURL resolves a calendar ID to its collection URL: an absolute
+// URL as is, a path starting with "/" on the server, anything else as a
+// collection inside the base URL.
+func (c *Client) collectionURL(calendarID string) string {
+	ref, err := url.Parse(strings.TrimPrefix(calendarID, "./"))
+	if err != nil {
+		ref = &url.URL{Path: calendarID}
+	}
+	u := c.base.ResolveReference(ref)
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.String()
+}
+
+// statusError is a DAV request answered with an unexpected status.
+type statusError struct {
+	Method string
+	URL    string
+	Code   int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("caldav %s %s: %d %s", e.Method, e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// hasStatus reports whether err is a statusError with code.
+func hasStatus(err error, code int) bool {
+	se, ok := err.(*statusError)
+	return ok && se.Code == code
+}
+
+// do sends one request and returns the response headers and body. Any
+// status outside ok is returned as a *statusError. op labels the request in
+// the API metrics.
+func (c *Client) do(ctx context.Context, op, method, target string, headers map[string]string, body string, ok ...int) (http.Header, []byte, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	duration := time.Since(start).Seconds()
+
+	status := "success"
+	if err != nil || resp.StatusCode >= 500 {
+		status = "error"
+	}
+	monitoring.ApiRequestsTotal.WithLabelValues("caldav", op, status).Inc()
+	monitoring.ApiLatency.WithLabelValues("caldav", op).Observe(duration)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("caldav %s %s: %w", method, target, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("caldav %s %s: reading response: %w", method, target, err)
+	}
+	for _, code := range ok {
+		if resp.StatusCode == code {
+			return resp.Header, data, nil
+		}
+	}
+	return nil, nil, &statusError{Method: method, URL: target, Code: resp.StatusCode}
+}
+
+// MakeCalendar creates the calendar collection calendarID (MKCALENDAR).
+// An existing collection is not an error.
+func (c *Client) MakeCalendar(ctx context.Context, calendarID, displayName string) error {
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:mkcalendar xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:set><D:prop>
+    <D:displayname>` + xmlEscape(displayName) + `</D:displayname>
+    <C:supported-calendar-component-set><C:comp name="VEVENT"/></C:supported-calendar-component-set>
+  </D:prop></D:set>
+</C:mkcalendar>`
+	_, _, err := c.do(ctx, "make_calendar", "MKCALENDAR", c.collectionURL(calendarID),
+		map[string]string{"Content-Type": "application/xml; charset=utf-8"}, body, http.StatusCreated)
+	if hasStatus(err, http.StatusMethodNotAllowed) {
+		return nil // Already exists
+	}
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// multistatus is a WebDAV 207 response.
+type multistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Status    string        `xml:"DAV: status"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Status string  `xml:"DAV: status"`
+	Prop   davProp `xml:"DAV: prop"`
+}
+
+type davProp struct {
+	DisplayName  string `xml:"DAV: displayname"`
+	ETag         string `xml:"DAV: getetag"`
+	CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+	ResourceType struct {
+		Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+	} `xml:"DAV: resourcetype"`
+}
+
+// props merges the successful propstats of a response.
+func (r davResponse) props() davProp {
+	var merged davProp
+	for _, ps := range r.Propstats {
+		if ps.Status != "" && !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+		if ps.Prop.DisplayName != "" {
+			merged.DisplayName = ps.Prop.DisplayName
+		}
+		if ps.Prop.ETag != "" {
+			merged.ETag = ps.Prop.ETag
+		}
+		if ps.Prop.CalendarData != "" {
+			merged.CalendarData = ps.Prop.CalendarData
+		}
+		if ps.Prop.ResourceType.Calendar != nil {
+			merged.ResourceType.Calendar = ps.Prop.ResourceType.Calendar
+		}
+	}
+	return merged
+}
+
+func parseMultistatus(data []byte) (*multistatus, error) {
+	var ms multistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("invalid multistatus response: %w", err)
+	}
+	return &ms, nil
+}
@@ -0,0 +1,92 @@
+package caldav
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient(t *testing.T) {
+	if _, err := NewClient("not a url", "", ""); err == nil {
+		t.Error("expected an error for a URL without scheme and host")
+	}
+	c, err := NewClient("https://dav.example.com/calendars/vera", "vera", "secret")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	if c.base.Path != "/calendars/vera/" {
+		t.Errorf("expected a trailing slash on the base path, got %q", c.base.Path)
+	}
+}
+
+func TestClient_CollectionURL(t *testing.T) {
+	c, _ := NewClient("https://dav.example.com/calendars/vera/", "", "")
+	tests := map[string]string{
+		"massage":                           "https://dav.example.com/calendars/vera/massage/",
+		"massage/":                          "https://dav.example.com/calendars/vera/massage/",
+		"/other/cal":                        "https://dav.example.com/other/cal/",
+		"https://cloud.example.com/dav/cal": "https://cloud.example.com/dav/cal/",
+	}
+	for id, want := range tests {
+		if got := c.collectionURL(id); got != want {
+			t.Errorf("collectionURL(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	var gotUser, gotPass, gotDepth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		gotDepth = r.Header.Get("Depth")
+		if r.Method == "MKCALENDAR" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	c, _ := NewClient(server.URL, "vera", "secret")
+	_, _, err := c.do(context.Background(), "propfind", "PROPFIND", server.URL, map[string]string{"Depth": "0"}, "", http.StatusMultiStatus)
+	if !hasStatus(err, http.StatusForbidden) {
+		t.Errorf("expected a 403 status error, got %v", err)
+	}
+	if gotUser != "vera" || gotPass != "secret" || gotDepth != "0" {
+		t.Errorf("expected basic auth and headers sent, got %q/%q depth %q", gotUser, gotPass, gotDepth)
+	}
+
+	if err := c.MakeCalendar(context.Background(), "massage", "Massage"); err != nil {
+		t.Errorf("an existing calendar should not be an error, got %v", err)
+	}
+}
+
+func TestParseMultistatus(t *testing.T) {
+	data := `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/calendars/vera/massage/</d:href>
+    <d:propstat>
+      <d:prop><d:displayname>Massage</d:displayname><d:resourcetype><d:collection/><cal:calendar/></d:resourcetype></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+    <d:propstat>
+      <d:prop><d:getetag/></d:prop>
+      <d:status>HTTP/1.1 404 Not Found</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`
+
+	ms, err := parseMultistatus([]byte(data))
+	if err != nil || len(ms.Responses) != 1 {
+		t.Fatalf("parseMultistatus failed: %v", err)
+	}
+	props := ms.Responses[0].props()
+	if props.DisplayName != "Massage" || props.ResourceType.Calendar == nil {
+		t.Errorf("unexpected props %+v", props)
+	}
+	if _, err := parseMultistatus([]byte("<oops")); err == nil {
+		t.Error("expected an error for invalid XML")
+	}
+}
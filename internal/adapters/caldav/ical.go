@@ -0,0 +1,274 @@
+package caldav
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// icalEvent is the part of a VEVENT the bot cares about.
+type icalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Status      string // CONFIRMED, TENTATIVE, CANCELLED
+	Transp      string // OPAQUE (default) or TRANSPARENT
+	Start       time.Time
+	End         time.Time
+}
+
+const (
+	icalUTCLayout   = "20060102T150405Z"
+	icalLocalLayout = "20060102T150405"
+	icalDateLayout  = "20060102"
+)
+
+// icalProperty is one unfolded content line: NAME;PARAM=VALUE:value.
+type icalProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// unfoldLines splits iCalendar text into content lines, joining folded
+// continuation lines (RFC 5545 §3.1).
+func unfoldLines(data string) []string {
+	raw := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+	var lines []string
+	for _, line := range raw {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseProperty splits a content line into name, parameters and value.
+// Colons and semicolons inside quoted parameter values are kept.
+func parseProperty(line string) (icalProperty, bool) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return icalProperty{}, false
+	}
+
+	head := strings.Split(line[:colon], ";")
+	prop := icalProperty{Name: strings.ToUpper(head[0]), Params: make(map[string]string), Value: line[colon+1:]}
+	for _, param := range head[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+// parseEvents returns the VEVENTs of a VCALENDAR. Nested components such as
+// VALARM are skipped; events without a start are dropped.
+func parseEvents(data string) ([]icalEvent, error) {
+	var events []icalEvent
+	var current *icalEvent
+	var duration string
+	nested := 0
+
+	for _, line := range unfoldLines(data) {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			current, duration, nested = &icalEvent{}, "", 0
+			continue
+		case current == nil:
+			continue
+		case prop.Name == "BEGIN":
+			nested++
+			continue
+		case prop.Name == "END" && nested > 0:
+			nested--
+			continue
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			if current.End.IsZero() {
+				d, err := parseDuration(duration)
+				if err != nil {
+					return nil, fmt.Errorf("event %s: %w", current.UID, err)
+				}
+				current.End = current.Start.Add(d)
+			}
+			if !current.Start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		case nested > 0:
+			continue
+		}
+
+		var err error
+		switch prop.Name {
+		case "UID":
+			current.UID = prop.Value
+		case "SUMMARY":
+			current.Summary = unescapeText(prop.Value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(prop.Value)
+		case "STATUS":
+			current.Status = strings.ToUpper(prop.Value)
+		case "TRANSP":
+			current.Transp = strings.ToUpper(prop.Value)
+		case "DTSTART":
+			current.Start, err = parseTime(prop)
+		case "DTEND":
+			current.End, err = parseTime(prop)
+		case "DURATION":
+			duration = prop.Value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", current.UID, err)
+		}
+	}
+	return events, nil
+}
+
+// parseTime reads a DATE-TIME in UTC, with a TZID or floating (taken as
+// ApptTimeZone), or a whole DATE starting at midnight in ApptTimeZone.
+func parseTime(prop icalProperty) (time.Time, error) {
+	loc := domain.ApptTimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(prop.Value, "Z"):
+		return time.Parse(icalUTCLayout, prop.Value)
+	case prop.Params["VALUE"] == "DATE" || len(prop.Value) == len(icalDateLayout):
+		return time.ParseInLocation(icalDateLayout, prop.Value, loc)
+	default:
+		return time.ParseInLocation(icalLocalLayout, prop.Value, loc)
+	}
+}
+
+// parseDuration reads the day and time parts of an iCalendar DURATION
+// (e.g. PT1H30M, P1D). Empty means zero, as for a DTSTART-only event.
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	sign := time.Duration(1)
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+	}
+	rest := strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(rest, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	var total time.Duration
+	number := ""
+	inTime := false
+	for _, r := range rest[1:] {
+		if r >= '0' && r <= '9' {
+			number += string(r)
+			continue
+		}
+		if r == 'T' {
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		switch {
+		case r == 'W':
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	return sign * total, nil
+}
+
+// renderEvent writes a VCALENDAR holding ev, with times in UTC.
+func renderEvent(ev icalEvent, stamp time.Time) string {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeFolded(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//massage-bot//CalDAV adapter//RU")
+	line("BEGIN", "VEVENT")
+	line("UID", ev.UID)
+	line("DTSTAMP", stamp.UTC().Format(icalUTCLayout))
+	line("DTSTART", ev.Start.UTC().Format(icalUTCLayout))
+	line("DTEND", ev.End.UTC().Format(icalUTCLayout))
+	line("SUMMARY", escapeText(ev.Summary))
+	if ev.Description != "" {
+		line("DESCRIPTION", escapeText(ev.Description))
+	}
+	if ev.Status != "" {
+		line("STATUS", ev.Status)
+	}
+	if ev.Transp != "" {
+		line("TRANSP", ev.Transp)
+	}
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+// writeFolded writes a content line folded at 75 octets without splitting
+// UTF-8 sequences.
+func writeFolded(b *strings.Builder, line string) {
+	const limit = 75
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
+
+var (
+	textEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeText(s string) string   { return textEscaper.Replace(s) }
+func unescapeText(s string) string { return textUnescaper.Replace(s) }
@@ -0,0 +1,124 @@
+package caldav
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEvents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:a1\r\n" +
+		"DTSTART;TZID=Europe/Istanbul:20300110T100000\r\n" +
+		"DURATION:PT1H30M\r\n" +
+		"SUMMARY:Массаж спины - Иван\r\n" +
+		"DESCRIPTION:TGID:42\\nЛюбит\\, когда\r\n" +
+		"  тихо\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"DESCRIPTION:Reminder\r\n" +
+		"END:VALARM\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:a2\r\n" +
+		"DTSTART:20300110T120000Z\r\n" +
+		"DTEND:20300110T130000Z\r\n" +
+		"TRANSP:TRANSPARENT\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := parseEvents(data)
+	if err != nil {
+		t.Fatalf("parseEvents failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+
+	first := events[0]
+	wantStart := time.Date(2030, 1, 10, 7, 0, 0, 0, time.UTC) // 10:00 in Istanbul
+	if !first.Start.Equal(wantStart) || !first.End.Equal(wantStart.Add(90*time.Minute)) {
+		t.Errorf("unexpected times %v - %v", first.Start, first.End)
+	}
+	if first.Summary != "Массаж спины - Иван" {
+		t.Errorf("unexpected summary %q", first.Summary)
+	}
+	if first.Description != "TGID:42\nЛюбит, когда тихо" {
+		t.Errorf("expected the alarm's description ignored and the text unescaped, got %q", first.Description)
+	}
+	if events[1].Transp != "TRANSPARENT" || events[1].End.Sub(events[1].Start) != time.Hour {
+		t.Errorf("unexpected second event %+v", events[1])
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":          0,
+		"PT45M":     45 * time.Minute,
+		"P1D":       24 * time.Hour,
+		"P1W":       7 * 24 * time.Hour,
+		"P1DT2H":    26 * time.Hour,
+		"-PT15M":    -15 * time.Minute,
+		"PT1H0M30S": time.Hour + 30*time.Second,
+	}
+	for value, want := range tests {
+		got, err := parseDuration(value)
+		if err != nil || got != want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"1H", "PT1X", "P1H"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("parseDuration(%q) should fail", value)
+		}
+	}
+}
+
+func TestRenderEvent_RoundTrip(t *testing.T) {
+	start := time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC)
+	ev := icalEvent{
+		UID:         "abc",
+		Summary:     "Массаж; спины, шеи - Иван",
+		Description: "TGID:42\n" + strings.Repeat("длинная заметка ", 10),
+		Status:      "CONFIRMED",
+		Start:       start,
+		End:         start.Add(time.Hour),
+	}
+
+	data := renderEvent(ev, start)
+	for _, line := range strings.Split(data, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	events, err := parseEvents(data)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("parseEvents failed: %v, %d events", err, len(events))
+	}
+	if events[0] != ev {
+		t.Errorf("round trip changed the event:\n got %+v\nwant %+v", events[0], ev)
+	}
+}
+
+func TestRetimeEvent(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:a1\r\n" +
+		"DTSTART;TZID=Europe/Istanbul:20300110T100000\r\nDURATION:PT1H\r\n" +
+		"SUMMARY:Массаж - Иван\r\nBEGIN:VALARM\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\n" +
+		"END:VEVENT\r\nEND:VCALENDAR\r\n"
+	start := time.Date(2030, 1, 11, 12, 0, 0, 0, time.UTC)
+
+	events, err := parseEvents(retimeEvent(data, start, start.Add(2*time.Hour)))
+	if err != nil || len(events) != 1 {
+		t.Fatalf("parseEvents failed: %v", err)
+	}
+	if !events[0].Start.Equal(start) || !events[0].End.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("unexpected times %v - %v", events[0].Start, events[0].End)
+	}
+	if events[0].Summary != "Массаж - Иван" {
+		t.Errorf("expected the rest of the event kept, got %+v", events[0])
+	}
+	if !strings.Contains(retimeEvent(data, start, start), "TRIGGER:-PT15M") {
+		t.Error("expected the alarm kept")
+	}
+}
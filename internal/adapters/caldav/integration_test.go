@@ -0,0 +1,153 @@
+//go:build integration
+
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// radicaleConfig runs Radicale without authentication, so any basic auth
+// user owns the collections under /<user>/.
+const radicaleConfig = `[server]
+hosts = 0.0.0.0:5232
+
+[auth]
+type = none
+
+[storage]
+filesystem_folder = /data/collections
+`
+
+type RadicaleTestSuite struct {
+	suite.Suite
+	ctx       context.Context
+	container testcontainers.Container
+	client    *Client
+	repo      ports.AppointmentRepository
+}
+
+func TestRadicaleSuite(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	suite.Run(t, new(RadicaleTestSuite))
+}
+
+func (s *RadicaleTestSuite) SetupSuite() {
+	s.ctx = context.Background()
+
+	container, err := testcontainers.GenericContainer(s.ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "tomsquest/docker-radicale:3.2.3.0",
+			ExposedPorts: []string{"5232/tcp"},
+			Files: []testcontainers.ContainerFile{{
+				Reader:            strings.NewReader(radicaleConfig),
+				ContainerFilePath: "/config/config",
+				FileMode:          0o644,
+			}},
+			WaitingFor: wait.ForHTTP("/.web/").WithPort("5232/tcp").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	s.Require().NoError(err)
+	s.container = container
+
+	host, err := container.Host(s.ctx)
+	s.Require().NoError(err)
+	port, err := container.MappedPort(s.ctx, "5232/tcp")
+	s.Require().NoError(err)
+
+	s.client, err = NewClient(fmt.Sprintf("http://%s:%s/vera/", host, port.Port()), "vera", "secret")
+	s.Require().NoError(err)
+	s.Require().NoError(s.client.MakeCalendar(s.ctx, "massage", "Массаж"))
+	s.Require().NoError(s.client.MakeCalendar(s.ctx, "massage", "Массаж"), "creating it again should be a no-op")
+	s.repo = NewAdapter(s.client, "massage")
+}
+
+func (s *RadicaleTestSuite) TearDownSuite() {
+	if s.container != nil {
+		s.NoError(testcontainers.TerminateContainer(s.container))
+	}
+}
+
+func (s *RadicaleTestSuite) TestAppointmentLifecycle() {
+	start := time.Date(2030, 3, 12, 10, 0, 0, 0, time.UTC)
+	created, err := s.repo.Create(s.ctx, &domain.Appointment{
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+		CustomerName: "Иван",
+		CustomerTgID: "42",
+		Notes:        "Первый визит",
+		Service:      domain.Service{Name: "Массаж спины"},
+	})
+	s.Require().NoError(err)
+	s.Require().NotEmpty(created.ID)
+
+	found, err := s.repo.FindByID(s.ctx, created.ID)
+	s.Require().NoError(err)
+	s.Equal("Иван", found.CustomerName)
+	s.Equal("42", found.CustomerTgID)
+	s.Equal("Массаж спины", found.Service.Name)
+	s.True(found.StartTime.Equal(start))
+
+	dayStart, dayEnd := start.Truncate(24*time.Hour), start.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	events, err := s.repo.FindEvents(s.ctx, &dayStart, &dayEnd)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(created.ID, events[0].ID)
+
+	busy, err := s.repo.GetFreeBusy(s.ctx, dayStart, dayEnd)
+	s.Require().NoError(err)
+	s.Require().Len(busy, 1)
+	s.True(busy[0].Start.Equal(start))
+	s.True(busy[0].End.Equal(start.Add(time.Hour)))
+
+	moved := *found
+	moved.StartTime, moved.EndTime = start.Add(2*time.Hour), start.Add(3*time.Hour)
+	_, err = s.repo.Update(s.ctx, &moved)
+	s.Require().NoError(err)
+	found, err = s.repo.FindByID(s.ctx, created.ID)
+	s.Require().NoError(err)
+	s.True(found.StartTime.Equal(moved.StartTime))
+
+	s.Require().NoError(s.repo.Delete(s.ctx, created.ID))
+	_, err = s.repo.FindByID(s.ctx, created.ID)
+	s.ErrorIs(err, domain.ErrAppointmentNotFound)
+	s.ErrorIs(s.repo.Delete(s.ctx, created.ID), domain.ErrAppointmentNotFound)
+}
+
+func (s *RadicaleTestSuite) TestOtherEventsBlockTime() {
+	// An event the therapist adds in their own calendar app, in local time
+	start := time.Date(2030, 3, 14, 14, 0, 0, 0, time.UTC)
+	ev := icalEvent{UID: "personal-1", Summary: "Стоматолог", Start: start, End: start.Add(time.Hour)}
+	_, _, err := s.client.do(s.ctx, "insert_event", "PUT", s.client.collectionURL("massage")+"personal-1.ics",
+		map[string]string{"Content-Type": "text/calendar; charset=utf-8"}, renderEvent(ev, start), 201, 204)
+	s.Require().NoError(err)
+
+	busy, err := s.repo.GetFreeBusy(s.ctx, start.Add(-time.Hour), start.Add(30*time.Minute))
+	s.Require().NoError(err)
+	s.Require().Len(busy, 1)
+	s.True(busy[0].End.Equal(start.Add(30*time.Minute)), "busy time should be clipped to the range")
+}
+
+func (s *RadicaleTestSuite) TestListCalendarsAndAccountInfo() {
+	calendars, err := s.repo.ListCalendars(s.ctx)
+	s.Require().NoError(err)
+	s.Contains(calendars, "Массаж (/vera/massage/)")
+
+	name, err := s.repo.GetAccountInfo(s.ctx)
+	s.Require().NoError(err)
+	s.Equal("Массаж", name)
+	s.Equal("massage", s.repo.GetCalendarID())
+}
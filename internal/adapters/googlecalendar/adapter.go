@@ -47,6 +47,9 @@ func (a *adapter) Create(ctx context.Context, appt *domain.Appointment) (*domain
 	event := &calendar.Event{
 		Summary:     fmt.Sprintf("%s - %s", appt.Service.Name, appt.CustomerName),
 		Description: description,
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: eventProperties(appt),
+		},
 		Start: &calendar.EventDateTime{
 			DateTime: appt.StartTime.Format(time.RFC3339),
 			TimeZone: appt.StartTime.Location().String(),
@@ -289,6 +292,46 @@ func (a *adapter) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// Update moves an existing event to the appointment's start and end time.
+// Only the times are patched, so the summary, description and Meet link stay.
+func (a *adapter) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if appt.ID == "" || appt.StartTime.IsZero() || appt.EndTime.IsZero() {
+		return nil, fmt.Errorf("appointment ID, StartTime or EndTime is empty; ensure set by service layer")
+	}
+
+	patch := &calendar.Event{
+		Start: &calendar.EventDateTime{
+			DateTime: appt.StartTime.Format(time.RFC3339),
+			TimeZone: appt.StartTime.Location().String(),
+		},
+		End: &calendar.EventDateTime{
+			DateTime: appt.EndTime.Format(time.RFC3339),
+			TimeZone: appt.EndTime.Location().String(),
+		},
+	}
+
+	start := time.Now()
+	_, err := a.client.Events.Patch(a.calendarID, appt.ID, patch).Context(ctx).Do()
+	duration := time.Since(start).Seconds()
+
+	status := "success"
+	if err != nil && !isNotFound(err) {
+		status = "error"
+	}
+	monitoring.ApiRequestsTotal.WithLabelValues("google", "patch_event", status).Inc()
+	monitoring.ApiLatency.WithLabelValues("google", "patch_event").Observe(duration)
+
+	if err != nil {
+		if isNotFound(err) || isGone(err) {
+			return nil, domain.ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("failed to update calendar event %s: %w", appt.ID, err)
+	}
+
+	logging.Infof("SUCCESS: Event %s moved to %s in '%s'", appt.ID, appt.StartTime.Format(time.RFC3339), a.calendarID)
+	return appt, nil
+}
+
 // Helper to check if an error indicates "not found"
 func isNotFound(err error) bool {
 	// Google API errors are often of type *googleapi.Error
@@ -339,7 +382,8 @@ func eventToAppointment(event *calendar.Event) (*domain.Appointment, error) {
 
 	duration := int(endTime.Sub(startTime).Minutes())
 
-	// Populate other fields by parsing event.Summary and event.Description
+	// Populate other fields by parsing event.Summary and event.Description;
+	// only events created before extended properties were written need it
 	customerTgID := ""
 	notes := event.Description
 
@@ -381,7 +425,7 @@ func eventToAppointment(event *calendar.Event) (*domain.Appointment, error) {
 		serviceName = parts[0]
 	}
 
-	return &domain.Appointment{
+	appt := &domain.Appointment{
 		ID:           event.Id,
 		ClientID:     event.Id, // Assuming ClientID is the same as Google Event ID
 		StartTime:    startTime,
@@ -392,5 +436,8 @@ func eventToAppointment(event *calendar.Event) (*domain.Appointment, error) {
 		Notes:        notes,
 		Service:      domain.Service{Name: serviceName, DurationMinutes: duration}, // Populate service details
 		Status:       event.Status,
-	}, nil
+	}
+	// Metadata in extended properties wins over the parsed text
+	applyProperties(appt, event)
+	return appt, nil
 }
@@ -241,6 +241,66 @@ func TestAdapter_Delete(t *testing.T) {
 	}
 }
 
+func TestAdapter_Update(t *testing.T) {
+	start := time.Date(2030, 1, 9, 14, 0, 0, 0, time.UTC)
+	appt := &domain.Appointment{ID: "event123", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	tests := []struct {
+		name        string
+		mockHandler http.HandlerFunc
+		wantErr     error
+	}{
+		{
+			name: "Success",
+			mockHandler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "PATCH" || r.URL.Path != "/calendars/primary/events/event123" {
+					http.Error(w, "Expected PATCH of event123", http.StatusBadRequest)
+					return
+				}
+				var ev calendar.Event
+				if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.Start == nil || ev.Start.DateTime != start.Format(time.RFC3339) {
+					http.Error(w, "Bad patch body", http.StatusBadRequest)
+					return
+				}
+				if ev.Summary != "" {
+					http.Error(w, "Only times should be patched", http.StatusBadRequest)
+					return
+				}
+				if err := json.NewEncoder(w).Encode(&ev); err != nil {
+					t.Errorf("Failed to encode response: %v", err)
+				}
+			},
+		},
+		{
+			name: "Not Found",
+			mockHandler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Not Found", http.StatusNotFound)
+			},
+			wantErr: domain.ErrAppointmentNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAdapter(t, tt.mockHandler)
+			got, err := a.Update(context.Background(), appt)
+
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Errorf("Update() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update() unexpected error: %v", err)
+			}
+			if !got.StartTime.Equal(start) {
+				t.Errorf("Update() StartTime = %v, want %v", got.StartTime, start)
+			}
+		})
+	}
+}
+
 // TestAdapter_FindAll tests fetching all events
 func TestAdapter_FindAll(t *testing.T) {
 	tests := []struct {
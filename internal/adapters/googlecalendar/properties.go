@@ -0,0 +1,181 @@
+package googlecalendar
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Keys of the booking metadata kept in an event's private extended
+// properties. Unlike the summary and description, these survive the
+// therapist editing the event by hand.
+const (
+	propServiceID    = "serviceId"
+	propServiceName  = "serviceName"
+	propCustomerTgID = "customerTgId"
+	propCustomerName = "customerName"
+	propStatus       = "status"
+	propSource       = "source"
+	propPromoCode    = "promoCode"
+	propDiscount     = "discount"
+)
+
+var _ ports.EventStatusWriter = (*adapter)(nil)
+
+// eventProperties returns the booking metadata of appt as private extended
+// properties. Empty values are left out; a new booking is booked.
+func eventProperties(appt *domain.Appointment) map[string]string {
+	serviceID := appt.ServiceID
+	if serviceID == "" {
+		serviceID = appt.Service.ID
+	}
+	status := appt.LifecycleStatus
+	if status == "" {
+		status = domain.StatusBooked
+	}
+	discount := ""
+	if appt.Discount > 0 {
+		discount = strconv.FormatFloat(appt.Discount, 'f', -1, 64)
+	}
+
+	props := make(map[string]string)
+	for key, value := range map[string]string{
+		propServiceID:    serviceID,
+		propServiceName:  appt.Service.Name,
+		propCustomerTgID: appt.CustomerTgID,
+		propCustomerName: appt.CustomerName,
+		propStatus:       string(status),
+		propSource:       appt.Source,
+		propPromoCode:    appt.PromoCode,
+		propDiscount:     discount,
+	} {
+		if value != "" {
+			props[key] = value
+		}
+	}
+	return props
+}
+
+// applyProperties overrides what was parsed from the summary and description
+// of event with its private extended properties, where it has them.
+func applyProperties(appt *domain.Appointment, event *calendar.Event) {
+	if event.ExtendedProperties == nil || len(event.ExtendedProperties.Private) == 0 {
+		return
+	}
+	props := event.ExtendedProperties.Private
+
+	if id := props[propServiceID]; id != "" {
+		appt.ServiceID = id
+		appt.Service.ID = id
+	}
+	if name := props[propServiceName]; name != "" {
+		appt.Service.Name = name
+	}
+	if id := props[propCustomerTgID]; id != "" {
+		appt.CustomerTgID = id
+	}
+	if name := props[propCustomerName]; name != "" {
+		appt.CustomerName = name
+	}
+	if status := domain.AppointmentStatus(props[propStatus]); status.Valid() {
+		appt.LifecycleStatus = status
+	}
+	appt.Source = props[propSource]
+	appt.PromoCode = props[propPromoCode]
+	if discount, err := strconv.ParseFloat(props[propDiscount], 64); err == nil {
+		appt.Discount = discount
+	}
+}
+
+// hasProperties reports whether event already carries booking metadata.
+func hasProperties(event *calendar.Event) bool {
+	return event.ExtendedProperties != nil && event.ExtendedProperties.Private[propCustomerTgID] != ""
+}
+
+// SetEventStatus records a new lifecycle status on the event. Patching
+// extended properties merges keys, so the other metadata stays.
+func (a *adapter) SetEventStatus(ctx context.Context, id string, status domain.AppointmentStatus) error {
+	patch := &calendar.Event{
+		ExtendedProperties: &calendar.EventExtendedProperties{
+			Private: map[string]string{propStatus: string(status)},
+		},
+	}
+	return a.patchProperties(ctx, id, patch)
+}
+
+// patchProperties applies patch to the event id, recording metrics.
+func (a *adapter) patchProperties(ctx context.Context, id string, patch *calendar.Event) error {
+	start := time.Now()
+	_, err := a.client.Events.Patch(a.calendarID, id, patch).Context(ctx).Do()
+	duration := time.Since(start).Seconds()
+
+	status := "success"
+	if err != nil && !isNotFound(err) {
+		status = "error"
+	}
+	monitoring.ApiRequestsTotal.WithLabelValues("google", "patch_properties", status).Inc()
+	monitoring.ApiLatency.WithLabelValues("google", "patch_properties").Observe(duration)
+
+	if err != nil {
+		if isNotFound(err) || isGone(err) {
+			return domain.ErrAppointmentNotFound
+		}
+		return fmt.Errorf("failed to update properties of calendar event %s: %w", id, err)
+	}
+	return nil
+}
+
+// BackfillProperties writes booking metadata onto the events of calendarID
+// that were created before it was kept in extended properties, as parsed
+// from their summary and description. resolve fills in what text cannot
+// carry, such as the service ID and lifecycle status, and returns false to
+// skip an event. It returns how many events were updated.
+func BackfillProperties(ctx context.Context, client *calendar.Service, calendarID string, resolve func(appt *domain.Appointment) bool) (int, error) {
+	a := &adapter{client: client, calendarID: calendarID}
+
+	updated := 0
+	pageToken := ""
+	for {
+		call := client.Events.List(calendarID).SingleEvents(true).MaxResults(2500).Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		events, err := call.Do()
+		if err != nil {
+			return updated, fmt.Errorf("failed to list events of %s: %w", calendarID, err)
+		}
+
+		for _, event := range events.Items {
+			if event.Transparency == "transparent" || hasProperties(event) {
+				continue
+			}
+			appt, err := eventToAppointment(event)
+			if err != nil || appt.CustomerTgID == "" {
+				continue
+			}
+			if resolve != nil && !resolve(appt) {
+				continue
+			}
+			patch := &calendar.Event{
+				ExtendedProperties: &calendar.EventExtendedProperties{Private: eventProperties(appt)},
+			}
+			if err := a.patchProperties(ctx, event.Id, patch); err != nil {
+				logging.Warnf("WARNING: Failed to backfill properties of event %s: %v", event.Id, err)
+				continue
+			}
+			updated++
+		}
+
+		if events.NextPageToken == "" {
+			return updated, nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
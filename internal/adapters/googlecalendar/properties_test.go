@@ -0,0 +1,207 @@
+package googlecalendar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestEventProperties(t *testing.T) {
+	props := eventProperties(&domain.Appointment{
+		Service:      domain.Service{ID: "svc-1", Name: "Massage"},
+		CustomerTgID: "42",
+		CustomerName: "Anna",
+		Source:       domain.SourceBot,
+		PromoCode:    "SPRING10",
+		Discount:     200,
+	})
+
+	want := map[string]string{
+		propServiceID:    "svc-1",
+		propServiceName:  "Massage",
+		propCustomerTgID: "42",
+		propCustomerName: "Anna",
+		propStatus:       string(domain.StatusBooked),
+		propSource:       domain.SourceBot,
+		propPromoCode:    "SPRING10",
+		propDiscount:     "200",
+	}
+	if len(props) != len(want) {
+		t.Fatalf("eventProperties() = %v, want %v", props, want)
+	}
+	for key, value := range want {
+		if props[key] != value {
+			t.Errorf("%s = %q, want %q", key, props[key], value)
+		}
+	}
+
+	// Empty values are left out
+	if props := eventProperties(&domain.Appointment{CustomerTgID: "42"}); len(props) != 2 {
+		t.Errorf("expected only customer and status, got %v", props)
+	}
+}
+
+func TestEventToAppointment_PrefersProperties(t *testing.T) {
+	// The therapist renamed the event and dropped the TGID line by hand
+	event := &calendar.Event{
+		Id:          "event1",
+		Summary:     "Anna, back pain",
+		Description: "Call before",
+		Start:       &calendar.EventDateTime{DateTime: "2030-01-09T10:00:00Z"},
+		End:         &calendar.EventDateTime{DateTime: "2030-01-09T11:00:00Z"},
+		ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{
+			propServiceID:    "svc-1",
+			propServiceName:  "Massage",
+			propCustomerTgID: "42",
+			propCustomerName: "Anna",
+			propStatus:       string(domain.StatusConfirmed),
+			propSource:       domain.SourceAdmin,
+			propPromoCode:    "SPRING10",
+			propDiscount:     "200",
+		}},
+	}
+
+	appt, err := eventToAppointment(event)
+	if err != nil {
+		t.Fatalf("eventToAppointment() error = %v", err)
+	}
+	if appt.ServiceID != "svc-1" || appt.Service.ID != "svc-1" || appt.Service.Name != "Massage" {
+		t.Errorf("service = %q %+v, want svc-1 Massage", appt.ServiceID, appt.Service)
+	}
+	if appt.CustomerTgID != "42" || appt.CustomerName != "Anna" {
+		t.Errorf("customer = %q %q, want 42 Anna", appt.CustomerTgID, appt.CustomerName)
+	}
+	if appt.LifecycleStatus != domain.StatusConfirmed || appt.Source != domain.SourceAdmin {
+		t.Errorf("status, source = %q, %q", appt.LifecycleStatus, appt.Source)
+	}
+	if appt.PromoCode != "SPRING10" || appt.Discount != 200 {
+		t.Errorf("promo = %q %v, want SPRING10 200", appt.PromoCode, appt.Discount)
+	}
+	if appt.Notes != "Call before" {
+		t.Errorf("Notes = %q, want the description", appt.Notes)
+	}
+}
+
+func TestAdapter_CreateWritesProperties(t *testing.T) {
+	var got calendar.Event
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, "Bad body", http.StatusBadRequest)
+			return
+		}
+		got.Id = "created"
+		_ = json.NewEncoder(w).Encode(&got)
+	}))
+
+	start := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	_, err := a.Create(context.Background(), &domain.Appointment{
+		ServiceID:    "svc-1",
+		Service:      domain.Service{Name: "Massage"},
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+		CustomerTgID: "42",
+		CustomerName: "Anna",
+		Source:       domain.SourceWaitlist,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.ExtendedProperties == nil {
+		t.Fatal("Create() sent no extended properties")
+	}
+	props := got.ExtendedProperties.Private
+	if props[propServiceID] != "svc-1" || props[propCustomerTgID] != "42" || props[propSource] != domain.SourceWaitlist {
+		t.Errorf("unexpected properties %v", props)
+	}
+}
+
+func TestAdapter_SetEventStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		wantErr error
+	}{
+		{"Success", http.StatusOK, nil},
+		{"Deleted", http.StatusGone, domain.ErrAppointmentNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var ev calendar.Event
+				if r.Method != "PATCH" || json.NewDecoder(r.Body).Decode(&ev) != nil || ev.ExtendedProperties == nil {
+					http.Error(w, "Expected a properties patch", http.StatusBadRequest)
+					return
+				}
+				if ev.Summary != "" || ev.ExtendedProperties.Private[propStatus] != string(domain.StatusCompleted) {
+					http.Error(w, "Only the status should be patched", http.StatusBadRequest)
+					return
+				}
+				if tt.code != http.StatusOK {
+					http.Error(w, "Gone", tt.code)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(&ev)
+			}))
+
+			err := a.SetEventStatus(context.Background(), "event1", domain.StatusCompleted)
+			if err != tt.wantErr {
+				t.Errorf("SetEventStatus() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackfillProperties(t *testing.T) {
+	events := []*calendar.Event{
+		{Id: "legacy", Summary: "Massage - Anna", Description: "TGID:42\nNotes",
+			Start: &calendar.EventDateTime{DateTime: "2030-01-09T10:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2030-01-09T11:00:00Z"}},
+		{Id: "done", Summary: "Massage - Bob",
+			Start: &calendar.EventDateTime{DateTime: "2030-01-09T12:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2030-01-09T13:00:00Z"},
+			ExtendedProperties: &calendar.EventExtendedProperties{Private: map[string]string{propCustomerTgID: "7"}}},
+		{Id: "personal", Summary: "Dentist",
+			Start: &calendar.EventDateTime{DateTime: "2030-01-09T14:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2030-01-09T15:00:00Z"}},
+		{Id: "skipped", Summary: "Massage - Carl", Description: "TGID:9",
+			Start: &calendar.EventDateTime{DateTime: "2030-01-09T16:00:00Z"}, End: &calendar.EventDateTime{DateTime: "2030-01-09T17:00:00Z"}},
+	}
+
+	patched := make(map[string]map[string]string)
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			_ = json.NewEncoder(w).Encode(&calendar.Events{Items: events})
+		case "PATCH":
+			var ev calendar.Event
+			if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.ExtendedProperties == nil {
+				http.Error(w, "Bad patch", http.StatusBadRequest)
+				return
+			}
+			id := r.URL.Path[len("/calendars/primary/events/"):]
+			patched[id] = ev.ExtendedProperties.Private
+			_ = json.NewEncoder(w).Encode(&ev)
+		}
+	}))
+
+	updated, err := BackfillProperties(context.Background(), a.client, "primary", func(appt *domain.Appointment) bool {
+		if appt.ID == "skipped" {
+			return false
+		}
+		appt.ServiceID = "svc-1"
+		appt.LifecycleStatus = domain.StatusCompleted
+		return true
+	})
+	if err != nil {
+		t.Fatalf("BackfillProperties() error = %v", err)
+	}
+	if updated != 1 || len(patched) != 1 {
+		t.Fatalf("expected only the legacy event updated, got %d: %v", updated, patched)
+	}
+	props := patched["legacy"]
+	if props[propCustomerTgID] != "42" || props[propCustomerName] != "Anna" || props[propServiceID] != "svc-1" || props[propStatus] != string(domain.StatusCompleted) {
+		t.Errorf("unexpected backfilled properties %v", props)
+	}
+}
@@ -0,0 +1,131 @@
+package googlecalendar
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"google.golang.org/api/calendar/v3"
+)
+
+var _ ports.CalendarWatcher = (*adapter)(nil)
+
+// Watch opens an events.watch channel that pings channel.Address whenever an
+// event in the calendar changes. Google caps ttl (about a week for events).
+func (a *adapter) Watch(ctx context.Context, channel domain.WatchChannel, ttl time.Duration) (*domain.WatchChannel, error) {
+	req := &calendar.Channel{
+		Id:      channel.ID,
+		Type:    "web_hook",
+		Address: channel.Address,
+		Token:   channel.Token,
+		Params:  map[string]string{"ttl": strconv.Itoa(int(ttl.Seconds()))},
+	}
+
+	start := time.Now()
+	res, err := a.client.Events.Watch(a.calendarID, req).Context(ctx).Do()
+	duration := time.Since(start).Seconds()
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	monitoring.ApiRequestsTotal.WithLabelValues("google", "watch_events", status).Inc()
+	monitoring.ApiLatency.WithLabelValues("google", "watch_events").Observe(duration)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch calendar '%s': %w", a.calendarID, err)
+	}
+
+	channel.ResourceID = res.ResourceId
+	channel.CalendarID = a.calendarID
+	channel.Expiration = time.UnixMilli(res.Expiration)
+	logging.Infof("SUCCESS: Watching calendar '%s' on channel %s until %s", a.calendarID, channel.ID, channel.Expiration.Format(time.RFC3339))
+	return &channel, nil
+}
+
+// StopWatch closes a channel opened by Watch. A channel that is already gone
+// counts as stopped.
+func (a *adapter) StopWatch(ctx context.Context, channel domain.WatchChannel) error {
+	start := time.Now()
+	err := a.client.Channels.Stop(&calendar.Channel{Id: channel.ID, ResourceId: channel.ResourceID}).Context(ctx).Do()
+	duration := time.Since(start).Seconds()
+
+	status := "success"
+	if err != nil && !isNotFound(err) {
+		status = "error"
+	}
+	monitoring.ApiRequestsTotal.WithLabelValues("google", "stop_channel", status).Inc()
+	monitoring.ApiLatency.WithLabelValues("google", "stop_channel").Observe(duration)
+
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to stop watch channel %s: %w", channel.ID, err)
+	}
+	return nil
+}
+
+// SyncChanges lists the events of the calendar changed since syncToken was
+// issued, deleted ones included, and returns the token for the next call.
+// Without a token every event is listed. Events that no longer block time
+// (deleted or marked free) come back with status "cancelled". Google
+// answers 410 Gone once a token is too old.
+func (a *adapter) SyncChanges(ctx context.Context, syncToken string) ([]domain.Appointment, string, error) {
+	var appointments []domain.Appointment
+	pageToken := ""
+	for {
+		call := a.client.Events.List(a.calendarID).
+			SingleEvents(true).
+			MaxResults(2500)
+		if syncToken != "" {
+			// Deleted events are always included with a sync token
+			call = call.SyncToken(syncToken)
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		start := time.Now()
+		events, err := call.Context(ctx).Do()
+		duration := time.Since(start).Seconds()
+
+		status := "success"
+		if err != nil && !isGone(err) {
+			status = "error"
+		}
+		monitoring.ApiRequestsTotal.WithLabelValues("google", "sync_events", status).Inc()
+		monitoring.ApiLatency.WithLabelValues("google", "sync_events").Observe(duration)
+
+		if err != nil {
+			if isGone(err) {
+				return nil, "", domain.ErrSyncTokenExpired
+			}
+			return nil, "", fmt.Errorf("failed to sync events from '%s': %w", a.calendarID, err)
+		}
+
+		for _, event := range events.Items {
+			appointments = append(appointments, changedEvent(event))
+		}
+		if events.NextPageToken == "" {
+			logging.Debugf("DEBUG: %d events changed in calendar '%s' (full sync: %v)", len(appointments), a.calendarID, syncToken == "")
+			return appointments, events.NextSyncToken, nil
+		}
+		pageToken = events.NextPageToken
+	}
+}
+
+// changedEvent converts an event from SyncChanges. Deleted events often
+// carry nothing but their ID.
+func changedEvent(event *calendar.Event) domain.Appointment {
+	appt, err := eventToAppointment(event)
+	if err != nil {
+		return domain.Appointment{ID: event.Id, Status: "cancelled"}
+	}
+	if event.Transparency == "transparent" {
+		appt.Status = "cancelled"
+	}
+	return *appt
+}
@@ -0,0 +1,155 @@
+package googlecalendar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestAdapter_Watch(t *testing.T) {
+	expiration := time.Date(2030, 1, 17, 10, 0, 0, 0, time.UTC)
+	var got calendar.Channel
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || !strings.HasSuffix(r.URL.Path, "/calendars/primary/events/watch") {
+			http.Error(w, "unexpected request "+r.Method+" "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(&calendar.Channel{Id: got.Id, ResourceId: "res-1", Expiration: expiration.UnixMilli()})
+	}))
+
+	channel, err := a.Watch(context.Background(), domain.WatchChannel{
+		ID: "ch-1", Token: "secret", Address: "https://bot.example.com/api/calendar/notify",
+	}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if got.Type != "web_hook" || got.Token != "secret" || got.Address != "https://bot.example.com/api/calendar/notify" || got.Params["ttl"] != "86400" {
+		t.Errorf("unexpected watch request %+v", got)
+	}
+	if channel.ResourceID != "res-1" || channel.CalendarID != "primary" || !channel.Expiration.Equal(expiration) {
+		t.Errorf("unexpected channel %+v", channel)
+	}
+}
+
+func TestAdapter_Watch_Error(t *testing.T) {
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "push not allowed", http.StatusBadRequest)
+	}))
+	if _, err := a.Watch(context.Background(), domain.WatchChannel{ID: "ch-1"}, time.Hour); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAdapter_StopWatch(t *testing.T) {
+	status := http.StatusNoContent
+	var got calendar.Channel
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/channels/stop") {
+			http.Error(w, "unexpected path "+r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	channel := domain.WatchChannel{ID: "ch-1", ResourceID: "res-1"}
+
+	if err := a.StopWatch(context.Background(), channel); err != nil {
+		t.Fatalf("StopWatch failed: %v", err)
+	}
+	if got.Id != "ch-1" || got.ResourceId != "res-1" {
+		t.Errorf("unexpected stop request %+v", got)
+	}
+
+	status = http.StatusNotFound
+	if err := a.StopWatch(context.Background(), channel); err != nil {
+		t.Errorf("expected a missing channel to count as stopped, got %v", err)
+	}
+	status = http.StatusInternalServerError
+	if err := a.StopWatch(context.Background(), channel); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestAdapter_SyncChanges(t *testing.T) {
+	start := time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC)
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("syncToken") == "" && q.Get("pageToken") == "":
+			_ = json.NewEncoder(w).Encode(&calendar.Events{
+				Items: []*calendar.Event{{
+					Id:          "e1",
+					Summary:     "Массаж - Иван",
+					Description: "TGID:42\n",
+					Status:      "confirmed",
+					Start:       &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+					End:         &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+				}},
+				NextPageToken: "page-2",
+			})
+		case q.Get("pageToken") == "page-2":
+			_ = json.NewEncoder(w).Encode(&calendar.Events{NextSyncToken: "token-1"})
+		case q.Get("syncToken") == "token-1":
+			_ = json.NewEncoder(w).Encode(&calendar.Events{
+				Items: []*calendar.Event{
+					{Id: "e1", Status: "cancelled"},
+					{
+						Id:           "e2",
+						Summary:      "Заметка",
+						Status:       "confirmed",
+						Transparency: "transparent",
+						Start:        &calendar.EventDateTime{DateTime: start.Format(time.RFC3339)},
+						End:          &calendar.EventDateTime{DateTime: start.Add(time.Hour).Format(time.RFC3339)},
+					},
+				},
+				NextSyncToken: "token-2",
+			})
+		default:
+			http.Error(w, "sync token expired", http.StatusGone)
+		}
+	}))
+	ctx := context.Background()
+
+	changes, token, err := a.SyncChanges(ctx, "")
+	if err != nil {
+		t.Fatalf("full sync failed: %v", err)
+	}
+	if token != "token-1" || len(changes) != 1 {
+		t.Fatalf("expected every event over both pages and a token, got %d events, %q", len(changes), token)
+	}
+	if changes[0].ID != "e1" || changes[0].CustomerTgID != "42" || changes[0].Status != "confirmed" || !changes[0].StartTime.Equal(start) {
+		t.Errorf("unexpected event %+v", changes[0])
+	}
+
+	changes, token, err = a.SyncChanges(ctx, "token-1")
+	if err != nil {
+		t.Fatalf("incremental sync failed: %v", err)
+	}
+	if token != "token-2" || len(changes) != 2 {
+		t.Fatalf("expected 2 changes and the next token, got %d, %q", len(changes), token)
+	}
+	if changes[0].Status != "cancelled" || changes[1].Status != "cancelled" {
+		t.Errorf("expected the deleted and the free event as cancelled, got %+v", changes)
+	}
+
+	if _, _, err := a.SyncChanges(ctx, "stale"); !errors.Is(err, domain.ErrSyncTokenExpired) {
+		t.Errorf("expected ErrSyncTokenExpired, got %v", err)
+	}
+}
+
+func TestAdapter_SyncChanges_Error(t *testing.T) {
+	a := newTestAdapter(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	if _, _, err := a.SyncChanges(context.Background(), ""); err == nil || errors.Is(err, domain.ErrSyncTokenExpired) {
+		t.Errorf("expected a plain error, got %v", err)
+	}
+}
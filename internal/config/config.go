@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/kfilin/massage-bot/internal/logging"
@@ -20,6 +21,47 @@ type Config struct {
 	WebAppURL                     string
 	WebAppSecret                  string
 	WebAppPort                    string
+
+	// Where appointments live: "google" (default), "caldav" or "postgres"
+	CalendarProvider string
+	CalDAVURL        string
+	CalDAVUsername   string
+	CalDAVPassword   string
+	CalDAVCalendar   string
+
+	// With "postgres", also copy appointments to GoogleCalendarID
+	MirrorToGoogle bool
+
+	// Public HTTPS URL of /api/calendar/notify. With "google" it enables
+	// push notifications from the calendars; empty disables them.
+	CalendarWebhookURL string
+
+	// Slot engine layout (see domain.SlotPolicy)
+	SlotStepMinutes         int
+	SlotBufferBeforeMinutes int
+	SlotBufferAfterMinutes  int
+	SlotPackToBookings      bool
+
+	// How long a time picked in the booking conversation stays reserved
+	SlotHoldMinutes int
+
+	// How many days ahead the FreeBusy cache is kept warm (0 disables)
+	FreeBusyPrewarmDays int
+
+	// How long a waitlisted patient has to claim a freed slot
+	WaitlistOfferMinutes int
+
+	// When admins are warned about a session package running out
+	PackageAlertSessions int
+	PackageAlertDays     int
+
+	// ISO code of the currency prices and payments are kept in; empty means
+	// the default (TRY)
+	Currency string
+
+	// Notice window for patients' own cancellations (see domain.CancellationPolicy)
+	CancelNoticeHours int
+	BlockLateCancel   bool
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -57,14 +99,51 @@ func LoadConfigWithFatal(fatal func(...interface{})) *Config {
 	// PROFESSIONAL FIX: Support both file path and environment variable
 	googleCredsPath := os.Getenv("GOOGLE_CREDENTIALS_PATH")
 	googleCredsJSON := os.Getenv("GOOGLE_CREDENTIALS_JSON")
+	hasGoogleCreds := googleCredsPath != "" || googleCredsJSON != ""
+
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("CALENDAR_PROVIDER")))
+	if provider == "" {
+		provider = "google"
+	}
+	caldavURL := os.Getenv("CALDAV_URL")
+	caldavCalendar := os.Getenv("CALDAV_CALENDAR")
+	mirrorToGoogle := boolEnv("CALENDAR_MIRROR_GOOGLE", false)
+	switch provider {
+	case "google":
+		if !hasGoogleCreds {
+			fatal("Set either GOOGLE_CREDENTIALS_PATH (for Docker) or GOOGLE_CREDENTIALS_JSON (for Kubernetes)")
+		}
+	case "caldav":
+		if caldavURL == "" || caldavCalendar == "" {
+			fatal("CALENDAR_PROVIDER=caldav needs CALDAV_URL and CALDAV_CALENDAR")
+		}
+	case "postgres":
+		if mirrorToGoogle && !hasGoogleCreds {
+			fatal("CALENDAR_MIRROR_GOOGLE needs GOOGLE_CREDENTIALS_PATH or GOOGLE_CREDENTIALS_JSON")
+		}
+	default:
+		fatal("CALENDAR_PROVIDER must be google, caldav or postgres, got " + provider)
+	}
+	if provider != "postgres" {
+		mirrorToGoogle = false
+	}
 
-	if googleCredsPath == "" && googleCredsJSON == "" {
-		fatal("Set either GOOGLE_CREDENTIALS_PATH (for Docker) or GOOGLE_CREDENTIALS_JSON (for Kubernetes)")
+	webhookURL := strings.TrimSpace(os.Getenv("CALENDAR_WEBHOOK_URL"))
+	if webhookURL != "" {
+		if !strings.HasPrefix(webhookURL, "https://") {
+			fatal("CALENDAR_WEBHOOK_URL must be an https:// URL, got " + webhookURL)
+		}
+		if provider != "google" {
+			logging.Warn("Warning: CALENDAR_WEBHOOK_URL only applies to CALENDAR_PROVIDER=google. Ignoring it.")
+			webhookURL = ""
+		}
 	}
 
 	googleCalendarID := os.Getenv("GOOGLE_CALENDAR_ID")
 	if googleCalendarID == "" {
-		logging.Warn("Warning: GOOGLE_CALENDAR_ID not set. Defaulting to 'primary'.")
+		if provider == "google" || mirrorToGoogle {
+			logging.Warn("Warning: GOOGLE_CALENDAR_ID not set. Defaulting to 'primary'.")
+		}
 		googleCalendarID = "primary"
 	}
 
@@ -92,5 +171,53 @@ func LoadConfigWithFatal(fatal func(...interface{})) *Config {
 		WebAppURL:                     os.Getenv("WEBAPP_URL"),
 		WebAppSecret:                  os.Getenv("WEBAPP_SECRET"),
 		WebAppPort:                    os.Getenv("WEBAPP_PORT"),
+		CalendarProvider:              provider,
+		MirrorToGoogle:                mirrorToGoogle,
+		CalendarWebhookURL:            webhookURL,
+		CalDAVURL:                     caldavURL,
+		CalDAVUsername:                os.Getenv("CALDAV_USERNAME"),
+		CalDAVPassword:                os.Getenv("CALDAV_PASSWORD"),
+		CalDAVCalendar:                caldavCalendar,
+		SlotStepMinutes:               intEnv("SLOT_STEP_MINUTES", 60),
+		SlotBufferBeforeMinutes:       intEnv("SLOT_BUFFER_BEFORE_MINUTES", 0),
+		SlotBufferAfterMinutes:        intEnv("SLOT_BUFFER_AFTER_MINUTES", 0),
+		SlotPackToBookings:            boolEnv("SLOT_PACK_TO_BOOKINGS", false),
+		SlotHoldMinutes:               intEnv("SLOT_HOLD_MINUTES", 10),
+		FreeBusyPrewarmDays:           intEnv("FREEBUSY_PREWARM_DAYS", 14),
+		WaitlistOfferMinutes:          intEnv("WAITLIST_OFFER_MINUTES", 30),
+		PackageAlertSessions:          intEnv("PACKAGE_ALERT_SESSIONS", 1),
+		PackageAlertDays:              intEnv("PACKAGE_ALERT_DAYS", 7),
+		Currency:                      strings.ToUpper(strings.TrimSpace(os.Getenv("CURRENCY"))),
+		CancelNoticeHours:             intEnv("CANCEL_NOTICE_HOURS", 72),
+		BlockLateCancel:               boolEnv("BLOCK_LATE_CANCEL", true),
+	}
+}
+
+// intEnv reads an integer environment variable, falling back to def when it
+// is unset or malformed.
+func intEnv(key string, def int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		logging.Warnf("Warning: %s=%q is not a number. Using %d.", key, raw, def)
+		return def
+	}
+	return v
+}
+
+// boolEnv reads a boolean environment variable (true/false, 1/0).
+func boolEnv(key string, def bool) bool {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		logging.Warnf("Warning: %s=%q is not a boolean. Using %t.", key, raw, def)
+		return def
 	}
+	return v
 }
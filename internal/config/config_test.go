@@ -76,6 +76,9 @@ func clearConfigEnv(t *testing.T) {
 	_ = os.Unsetenv("WEBAPP_SECRET")
 	_ = os.Unsetenv("WEBAPP_PORT")
 	_ = os.Unsetenv("WHISPER_BASE_URL")
+	for _, key := range []string{"SLOT_STEP_MINUTES", "SLOT_BUFFER_BEFORE_MINUTES", "SLOT_BUFFER_AFTER_MINUTES", "SLOT_PACK_TO_BOOKINGS", "SLOT_HOLD_MINUTES", "FREEBUSY_PREWARM_DAYS", "WAITLIST_OFFER_MINUTES", "PACKAGE_ALERT_SESSIONS", "PACKAGE_ALERT_DAYS", "CURRENCY", "CANCEL_NOTICE_HOURS", "BLOCK_LATE_CANCEL", "CALENDAR_PROVIDER", "CALDAV_URL", "CALDAV_USERNAME", "CALDAV_PASSWORD", "CALDAV_CALENDAR", "CALENDAR_MIRROR_GOOGLE", "CALENDAR_WEBHOOK_URL"} {
+		t.Setenv(key, "")
+	}
 }
 
 func TestLoadConfigDefaults(t *testing.T) {
@@ -221,3 +224,226 @@ func TestLoadConfigGooglePathCredential(t *testing.T) {
 		t.Errorf("Expected credentials path /tmp/creds.json, got %s", cfg.GoogleCalendarCredentialsPath)
 	}
 }
+
+func TestLoadConfigCalDAVProvider(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	t.Setenv("CALENDAR_PROVIDER", "CalDAV")
+	t.Setenv("CALDAV_URL", "https://dav.example.com/vera/")
+	t.Setenv("CALDAV_USERNAME", "vera")
+	t.Setenv("CALDAV_PASSWORD", "secret")
+	t.Setenv("CALDAV_CALENDAR", "massage")
+
+	cfg := LoadConfigWithFatal(func(args ...interface{}) {
+		t.Fatalf("Google credentials should not be required with CalDAV: %v", args[0])
+	})
+	if cfg.CalendarProvider != "caldav" || cfg.CalDAVURL != "https://dav.example.com/vera/" ||
+		cfg.CalDAVUsername != "vera" || cfg.CalDAVPassword != "secret" || cfg.CalDAVCalendar != "massage" {
+		t.Errorf("unexpected CalDAV settings: %+v", cfg)
+	}
+
+	var fatalCalled bool
+	t.Setenv("CALDAV_CALENDAR", "")
+	func() {
+		defer func() { _ = recover() }()
+		_ = LoadConfigWithFatal(func(args ...interface{}) {
+			fatalCalled = true
+			panic(args[0])
+		})
+	}()
+	if !fatalCalled {
+		t.Error("expected a fatal error without CALDAV_CALENDAR")
+	}
+}
+
+func TestLoadConfigPostgresProvider(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+
+	noFatal := func(args ...interface{}) { t.Fatalf("unexpected fatal: %v", args[0]) }
+	t.Setenv("CALENDAR_PROVIDER", "postgres")
+	if cfg := LoadConfigWithFatal(noFatal); cfg.CalendarProvider != "postgres" || cfg.MirrorToGoogle {
+		t.Errorf("expected Postgres without Google credentials, got %q (mirror %v)", cfg.CalendarProvider, cfg.MirrorToGoogle)
+	}
+
+	t.Setenv("CALENDAR_MIRROR_GOOGLE", "true")
+	t.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+	if cfg := LoadConfigWithFatal(noFatal); cfg.CalendarProvider != "postgres" || !cfg.MirrorToGoogle || cfg.GoogleCalendarID != "primary" {
+		t.Errorf("expected Postgres mirrored to the primary calendar, got %+v", cfg)
+	}
+
+	var fatalCalled bool
+	t.Setenv("GOOGLE_CREDENTIALS_JSON", "")
+	func() {
+		defer func() { _ = recover() }()
+		_ = LoadConfigWithFatal(func(args ...interface{}) {
+			fatalCalled = true
+			panic(args[0])
+		})
+	}()
+	if !fatalCalled {
+		t.Error("expected a fatal error when mirroring without Google credentials")
+	}
+}
+
+func TestLoadConfigCalendarWebhook(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	t.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	noFatal := func(args ...interface{}) { t.Fatalf("unexpected fatal: %v", args[0]) }
+	if cfg := LoadConfigWithFatal(noFatal); cfg.CalendarWebhookURL != "" {
+		t.Errorf("expected push sync off by default, got %q", cfg.CalendarWebhookURL)
+	}
+
+	t.Setenv("CALENDAR_WEBHOOK_URL", "https://bot.example.com/api/calendar/notify")
+	if cfg := LoadConfigWithFatal(noFatal); cfg.CalendarWebhookURL != "https://bot.example.com/api/calendar/notify" {
+		t.Errorf("unexpected webhook URL %q", cfg.CalendarWebhookURL)
+	}
+
+	t.Setenv("CALENDAR_PROVIDER", "postgres")
+	if cfg := LoadConfigWithFatal(noFatal); cfg.CalendarWebhookURL != "" {
+		t.Errorf("expected the webhook ignored without Google as the calendar, got %q", cfg.CalendarWebhookURL)
+	}
+
+	var fatalCalled bool
+	t.Setenv("CALENDAR_WEBHOOK_URL", "http://bot.example.com/api/calendar/notify")
+	func() {
+		defer func() { _ = recover() }()
+		_ = LoadConfigWithFatal(func(args ...interface{}) {
+			fatalCalled = true
+			panic(args[0])
+		})
+	}()
+	if !fatalCalled {
+		t.Error("expected a fatal error for a plain HTTP webhook")
+	}
+}
+
+func TestLoadConfigSlotPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantStep   int
+		wantBefore int
+		wantAfter  int
+		wantPack   bool
+	}{
+		{"defaults", nil, 60, 0, 0, false},
+		{"configured", map[string]string{
+			"SLOT_STEP_MINUTES":          "15",
+			"SLOT_BUFFER_BEFORE_MINUTES": "5",
+			"SLOT_BUFFER_AFTER_MINUTES":  "10",
+			"SLOT_PACK_TO_BOOKINGS":      "true",
+		}, 15, 5, 10, true},
+		{"malformed falls back", map[string]string{
+			"SLOT_STEP_MINUTES":     "half-hour",
+			"SLOT_PACK_TO_BOOKINGS": "sometimes",
+		}, 60, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+			_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg := LoadConfig()
+
+			if cfg.SlotStepMinutes != tt.wantStep || cfg.SlotBufferBeforeMinutes != tt.wantBefore ||
+				cfg.SlotBufferAfterMinutes != tt.wantAfter || cfg.SlotPackToBookings != tt.wantPack {
+				t.Errorf("got step=%d before=%d after=%d pack=%t", cfg.SlotStepMinutes,
+					cfg.SlotBufferBeforeMinutes, cfg.SlotBufferAfterMinutes, cfg.SlotPackToBookings)
+			}
+		})
+	}
+}
+
+func TestLoadConfigSlotHoldMinutes(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.SlotHoldMinutes != 10 {
+		t.Errorf("expected default of 10 minutes, got %d", cfg.SlotHoldMinutes)
+	}
+	t.Setenv("SLOT_HOLD_MINUTES", "5")
+	if cfg := LoadConfig(); cfg.SlotHoldMinutes != 5 {
+		t.Errorf("expected 5 minutes, got %d", cfg.SlotHoldMinutes)
+	}
+}
+
+func TestLoadConfigFreeBusyPrewarmDays(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.FreeBusyPrewarmDays != 14 {
+		t.Errorf("expected default of 14 days, got %d", cfg.FreeBusyPrewarmDays)
+	}
+	t.Setenv("FREEBUSY_PREWARM_DAYS", "0")
+	if cfg := LoadConfig(); cfg.FreeBusyPrewarmDays != 0 {
+		t.Errorf("expected warming disabled, got %d", cfg.FreeBusyPrewarmDays)
+	}
+}
+
+func TestLoadConfigWaitlistOfferMinutes(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.WaitlistOfferMinutes != 30 {
+		t.Errorf("expected default of 30 minutes, got %d", cfg.WaitlistOfferMinutes)
+	}
+	t.Setenv("WAITLIST_OFFER_MINUTES", "90")
+	if cfg := LoadConfig(); cfg.WaitlistOfferMinutes != 90 {
+		t.Errorf("expected 90 minutes, got %d", cfg.WaitlistOfferMinutes)
+	}
+}
+
+func TestLoadConfigPackageAlerts(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.PackageAlertSessions != 1 || cfg.PackageAlertDays != 7 {
+		t.Errorf("expected defaults of 1 session / 7 days, got %d / %d", cfg.PackageAlertSessions, cfg.PackageAlertDays)
+	}
+	t.Setenv("PACKAGE_ALERT_SESSIONS", "2")
+	t.Setenv("PACKAGE_ALERT_DAYS", "14")
+	if cfg := LoadConfig(); cfg.PackageAlertSessions != 2 || cfg.PackageAlertDays != 14 {
+		t.Errorf("expected 2 sessions / 14 days, got %d / %d", cfg.PackageAlertSessions, cfg.PackageAlertDays)
+	}
+}
+
+func TestLoadConfigCurrency(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.Currency != "" {
+		t.Errorf("expected no currency by default, got %q", cfg.Currency)
+	}
+	t.Setenv("CURRENCY", " eur ")
+	if cfg := LoadConfig(); cfg.Currency != "EUR" {
+		t.Errorf("expected EUR, got %q", cfg.Currency)
+	}
+}
+
+func TestLoadConfigCancellationPolicy(t *testing.T) {
+	clearConfigEnv(t)
+	_ = os.Setenv("TG_BOT_TOKEN", "test_token")
+	_ = os.Setenv("GOOGLE_CREDENTIALS_JSON", "{}")
+
+	if cfg := LoadConfig(); cfg.CancelNoticeHours != 72 || !cfg.BlockLateCancel {
+		t.Errorf("expected defaults of 72 hours / blocking, got %d / %v", cfg.CancelNoticeHours, cfg.BlockLateCancel)
+	}
+	t.Setenv("CANCEL_NOTICE_HOURS", "24")
+	t.Setenv("BLOCK_LATE_CANCEL", "false")
+	if cfg := LoadConfig(); cfg.CancelNoticeHours != 24 || cfg.BlockLateCancel {
+		t.Errorf("expected 24 hours / warning only, got %d / %v", cfg.CancelNoticeHours, cfg.BlockLateCancel)
+	}
+}
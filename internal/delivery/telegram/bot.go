@@ -28,17 +28,29 @@ import (
 const (
 	CallbackPrefixCategory        = "select_category|"
 	CallbackPrefixService         = "select_service|"
+	CallbackPrefixTherapist       = "select_therapist|"
 	CallbackPrefixDate            = "select_date|"
 	CallbackPrefixNavigateMonth   = "navigate_month|"
 	CallbackPrefixTime            = "select_time|"
 	CallbackPrefixCancelAppt      = "cancel_appt|"
+	CallbackPrefixRescheduleAppt  = "reschedule_appt|"
+	CallbackPrefixCancelSeries    = "cancel_series|"
+	CallbackPrefixApptOutcome     = "appt_outcome|"
+	CallbackPrefixWaitlistJoin    = "waitlist_join|"
+	CallbackPrefixWaitlistAccept  = "waitlist_accept|"
+	CallbackPrefixWaitlistDecline = "waitlist_decline|"
+	CallbackPrefixWaitlistLeave   = "waitlist_leave|"
 	CallbackPrefixConfirmReminder = "confirm_appt_reminder|"
 	CallbackPrefixCancelReminder  = "cancel_appt_reminder|"
 	CallbackPrefixAdminReply      = "admin_reply|"
+	CallbackPrefixNextSlot        = "next_slot|"
 	CallbackConfirmBooking        = "confirm_booking"
 	CallbackCancelBooking         = "cancel_booking"
+	CallbackEnterPromo            = "enter_promo"
+	CallbackKeepAppointment       = "keep_appt"
 	CallbackBackToServices        = "back_to_services"
 	CallbackBackToDate            = "back_to_date"
+	CallbackNextAvailable         = "next_available"
 	CallbackApproveDraft          = "approve_draft"
 	CallbackDiscardDraft          = "discard_draft"
 	CallbackIgnore                = "ignore"
@@ -146,6 +158,10 @@ func RunBot(
 	webAppURL string,
 	webAppSecret string,
 	therapistIDs []string,
+	waitlist ports.WaitlistService,
+	packages ports.PackageService,
+	payments ports.PaymentService,
+	promos ports.PromoService,
 ) {
 	// Set menu button for quick TWA access. The raw API call is wrapped
 	// by setupMenuButton so this behaviour is unit-testable.
@@ -157,6 +173,18 @@ func RunBot(
 
 	botPresenter := presentation.NewBotPresenter()
 	bookingHandler := handlers.NewBookingHandler(appointmentService, sessionStorage, finalAdminIDs, therapistIDs, trans, repo, botPresenter, webAppURL, webAppSecret)
+	if waitlist != nil {
+		bookingHandler.SetWaitlist(waitlist)
+	}
+	if packages != nil {
+		bookingHandler.SetPackages(packages)
+	}
+	if payments != nil {
+		bookingHandler.SetPayments(payments)
+	}
+	if promos != nil {
+		bookingHandler.SetPromos(promos)
+	}
 
 	// Initialize and start Reminder Service
 	reminderService := reminder.NewService(appointmentService, repo, b, finalAdminIDs, botPresenter)
@@ -227,6 +255,8 @@ func RunBot(
 	b.Handle("/cancel", bookingHandler.HandleCancel)
 	b.Handle("/myrecords", bookingHandler.HandleMyRecords)
 	b.Handle("/myappointments", bookingHandler.HandleMyAppointments)
+	b.Handle("/waitlist", bookingHandler.HandleMyWaitlist)
+	b.Handle("/calendar", bookingHandler.HandleCalendarFeed)
 	b.Handle("/upload", bookingHandler.HandleUploadCommand)
 	b.Handle("/backup", bookingHandler.HandleBackup)
 	b.Handle("/ban", bookingHandler.HandleBan)
@@ -238,6 +268,31 @@ func RunBot(
 	b.Handle("/create_appointment", bookingHandler.HandleManualAppointment)
 	b.Handle("/manual", bookingHandler.HandleManualAppointment)
 	b.Handle("/book", bookingHandler.HandleStart)
+	b.Handle("/services", bookingHandler.HandleListServices)
+	b.Handle("/service_add", bookingHandler.HandleAddService)
+	b.Handle("/service_edit", bookingHandler.HandleEditService)
+	b.Handle("/service_archive", bookingHandler.HandleArchiveService)
+	b.Handle("/service_restore", bookingHandler.HandleRestoreService)
+	b.Handle("/service_order", bookingHandler.HandleReorderServices)
+	b.Handle("/category_save", bookingHandler.HandleSaveCategory)
+	b.Handle("/schedule", bookingHandler.HandleShowSchedule)
+	b.Handle("/schedule_day", bookingHandler.HandleSetScheduleDay)
+	b.Handle("/day_off", bookingHandler.HandleDayOff)
+	b.Handle("/day_hours", bookingHandler.HandleDayHours)
+	b.Handle("/day_reset", bookingHandler.HandleDayReset)
+	b.Handle("/therapists", bookingHandler.HandleListTherapists)
+	b.Handle("/therapist_save", bookingHandler.HandleSaveTherapist)
+	b.Handle("/therapist_archive", bookingHandler.HandleArchiveTherapist)
+	b.Handle("/therapist_restore", bookingHandler.HandleRestoreTherapist)
+	b.Handle("/series", bookingHandler.HandleBookSeries)
+	b.Handle("/package_add", bookingHandler.HandleAddPackage)
+	b.Handle("/packages", bookingHandler.HandleListPackages)
+	b.Handle("/paid", bookingHandler.HandlePaid)
+	b.Handle("/unpaid", bookingHandler.HandleUnpaid)
+	b.Handle("/revenue", bookingHandler.HandleRevenue)
+	b.Handle("/promo_add", bookingHandler.HandleAddPromo)
+	b.Handle("/promos", bookingHandler.HandleListPromos)
+	b.Handle("/promo", bookingHandler.HandlePromoUsages)
 
 	// Register file/media handlers
 	b.Handle(telebot.OnDocument, bookingHandler.HandleFileMessage)
@@ -277,16 +332,38 @@ func RunBot(
 			return bookingHandler.HandleCategorySelection(c)
 		case CallbackPrefixService:
 			return bookingHandler.HandleServiceSelection(c)
+		case CallbackPrefixTherapist:
+			return bookingHandler.HandleTherapistSelection(c)
 		case CallbackPrefixDate, CallbackPrefixNavigateMonth, CallbackBackToServices:
 			return bookingHandler.HandleDateSelection(c)
 		case CallbackPrefixTime, CallbackBackToDate:
 			return bookingHandler.HandleTimeSelection(c)
+		case CallbackNextAvailable:
+			return bookingHandler.HandleNextAvailable(c)
+		case CallbackPrefixNextSlot:
+			return bookingHandler.HandleNextSlotSelection(c)
 		case CallbackConfirmBooking:
 			return bookingHandler.HandleConfirmBooking(c)
 		case CallbackCancelBooking:
 			return bookingHandler.HandleCancel(c)
+		case CallbackEnterPromo:
+			return bookingHandler.HandleEnterPromo(c)
 		case CallbackPrefixCancelAppt:
 			return bookingHandler.HandleCancelAppointmentCallback(c)
+		case CallbackPrefixRescheduleAppt:
+			return bookingHandler.HandleRescheduleAppointmentCallback(c)
+		case CallbackPrefixCancelSeries:
+			return bookingHandler.HandleCancelSeriesCallback(c)
+		case CallbackKeepAppointment:
+			return bookingHandler.HandleKeepAppointment(c)
+		case CallbackPrefixApptOutcome:
+			return bookingHandler.HandleAppointmentOutcome(c)
+		case CallbackPrefixWaitlistJoin:
+			return bookingHandler.HandleWaitlistJoin(c)
+		case CallbackPrefixWaitlistAccept, CallbackPrefixWaitlistDecline:
+			return bookingHandler.HandleWaitlistOffer(c)
+		case CallbackPrefixWaitlistLeave:
+			return bookingHandler.HandleWaitlistLeave(c)
 		case CallbackPrefixConfirmReminder:
 			return bookingHandler.HandleReminderConfirmation(c)
 		case CallbackPrefixCancelReminder:
@@ -312,6 +389,7 @@ func RunBot(
 		session := sessionStorage.Get(userID)
 		view := SessionView{
 			AdminReplyingTo:      sessionString(session, handlers.SessionKeyAdminReplyingTo),
+			AwaitingPromo:        sessionBool(session, handlers.SessionKeyAwaitingPromo),
 			AwaitingConfirmation: sessionBool(session, handlers.SessionKeyAwaitingConfirmation),
 			HasService:           sessionHasKey(session, handlers.SessionKeyService),
 			HasName:              sessionHasKey(session, handlers.SessionKeyName),
@@ -330,6 +408,8 @@ func RunBot(
 			return bookingHandler.HandleUploadCommand(c)
 		case TextActionAdminReply:
 			return handleAdminReply(c, b, repo, sessionStorage, userID, text)
+		case TextActionPromoCode:
+			return bookingHandler.HandlePromoInput(c)
 		case TextActionConfirmBooking:
 			return bookingHandler.HandleConfirmBooking(c)
 		case TextActionCancel:
@@ -16,8 +16,20 @@ import (
 type mockAppointmentService struct {
 	getAvailableServicesFunc       func(ctx context.Context) ([]domain.Service, error)
 	getAvailableTimeSlotsFunc      func(ctx context.Context, date time.Time, durationMinutes int) ([]domain.TimeSlot, error)
+	holdSlotFunc                   func(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error
+	releaseSlotHoldFunc            func(ctx context.Context, holderID string) error
+	findNextAvailableFunc          func(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error)
+	getMonthAvailabilityFunc       func(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error)
 	createAppointmentFunc          func(ctx context.Context, appointment *domain.Appointment) (*domain.Appointment, error)
 	cancelAppointmentFunc          func(ctx context.Context, appointmentID string) error
+	rescheduleAppointmentFunc      func(ctx context.Context, appointmentID string, newStart time.Time) (*domain.Appointment, error)
+	bookSeriesFunc                 func(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error)
+	getAppointmentSeriesFunc       func(ctx context.Context, appointmentID string) (*domain.AppointmentSeries, error)
+	cancelSeriesFunc               func(ctx context.Context, appointmentID string) ([]domain.Appointment, error)
+	getAppointmentStatusFunc       func(ctx context.Context, appointmentID string) (domain.AppointmentStatus, error)
+	setAppointmentStatusFunc       func(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error
+	getPatientStatusCountsFunc     func(ctx context.Context, customerTgID string) (map[domain.AppointmentStatus]int, error)
+	cancellationPolicy             *domain.CancellationPolicy
 	getUpcomingAppointmentsFunc    func(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error)
 	getCustomerAppointmentsFunc    func(ctx context.Context, customerTgID string) ([]domain.Appointment, error)
 	getCustomerHistoryFunc         func(ctx context.Context, customerTgID string) ([]domain.Appointment, error)
@@ -27,6 +39,20 @@ type mockAppointmentService struct {
 	getCalendarAccountInfoFunc     func(ctx context.Context) (string, error)
 	getCalendarIDFunc              func() string
 	listCalendarsFunc              func(ctx context.Context) ([]string, error)
+	getAllServicesFunc             func(ctx context.Context) ([]domain.Service, error)
+	saveServiceFunc                func(ctx context.Context, svc domain.Service) (*domain.Service, error)
+	setServiceArchivedFunc         func(ctx context.Context, id string, archived bool) error
+	reorderServicesFunc            func(ctx context.Context, orderedIDs []string) error
+	getServiceCategoriesFunc       func(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error)
+	saveServiceCategoryFunc        func(ctx context.Context, cat domain.ServiceCategory) error
+	getScheduleFunc                func(ctx context.Context) (domain.WeeklySchedule, error)
+	getWorkingHoursFunc            func(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error)
+	setWeekdayHoursFunc            func(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error
+	saveScheduleExceptionFunc      func(ctx context.Context, exc domain.ScheduleException) error
+	deleteScheduleExceptionFunc    func(ctx context.Context, therapistID string, date time.Time) error
+	getTherapistsFunc              func(ctx context.Context, includeInactive bool) ([]domain.Therapist, error)
+	saveTherapistFunc              func(ctx context.Context, t domain.Therapist) error
+	getTherapistTimeSlotsFunc      func(ctx context.Context, therapistID string, date time.Time, durationMinutes int) ([]domain.TimeSlot, error)
 }
 
 func (m *mockAppointmentService) GetAvailableServices(ctx context.Context) ([]domain.Service, error) {
@@ -43,6 +69,34 @@ func (m *mockAppointmentService) GetAvailableTimeSlots(ctx context.Context, date
 	return []domain.TimeSlot{}, nil
 }
 
+func (m *mockAppointmentService) HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+	if m.holdSlotFunc != nil {
+		return m.holdSlotFunc(ctx, holderID, therapistID, start, durationMinutes)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) ReleaseSlotHold(ctx context.Context, holderID string) error {
+	if m.releaseSlotHoldFunc != nil {
+		return m.releaseSlotHoldFunc(ctx, holderID)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+	if m.findNextAvailableFunc != nil {
+		return m.findNextAvailableFunc(ctx, serviceID, from, horizon, limit)
+	}
+	return nil, nil
+}
+
+func (m *mockAppointmentService) GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+	if m.getMonthAvailabilityFunc != nil {
+		return m.getMonthAvailabilityFunc(ctx, therapistID, month, durationMinutes)
+	}
+	return nil, nil
+}
+
 func (m *mockAppointmentService) CreateAppointment(ctx context.Context, appointment *domain.Appointment) (*domain.Appointment, error) {
 	if m.createAppointmentFunc != nil {
 		return m.createAppointmentFunc(ctx, appointment)
@@ -57,6 +111,62 @@ func (m *mockAppointmentService) CancelAppointment(ctx context.Context, appointm
 	return nil
 }
 
+func (m *mockAppointmentService) RescheduleAppointment(ctx context.Context, appointmentID string, newStart time.Time) (*domain.Appointment, error) {
+	if m.rescheduleAppointmentFunc != nil {
+		return m.rescheduleAppointmentFunc(ctx, appointmentID, newStart)
+	}
+	return &domain.Appointment{ID: appointmentID, StartTime: newStart}, nil
+}
+
+func (m *mockAppointmentService) BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+	if m.bookSeriesFunc != nil {
+		return m.bookSeriesFunc(ctx, series)
+	}
+	return &series, nil
+}
+
+func (m *mockAppointmentService) GetAppointmentSeries(ctx context.Context, appointmentID string) (*domain.AppointmentSeries, error) {
+	if m.getAppointmentSeriesFunc != nil {
+		return m.getAppointmentSeriesFunc(ctx, appointmentID)
+	}
+	return nil, domain.ErrSeriesNotFound
+}
+
+func (m *mockAppointmentService) CancelSeries(ctx context.Context, appointmentID string) ([]domain.Appointment, error) {
+	if m.cancelSeriesFunc != nil {
+		return m.cancelSeriesFunc(ctx, appointmentID)
+	}
+	return nil, nil
+}
+
+func (m *mockAppointmentService) GetAppointmentStatus(ctx context.Context, appointmentID string) (domain.AppointmentStatus, error) {
+	if m.getAppointmentStatusFunc != nil {
+		return m.getAppointmentStatusFunc(ctx, appointmentID)
+	}
+	return domain.StatusBooked, nil
+}
+
+func (m *mockAppointmentService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	if m.setAppointmentStatusFunc != nil {
+		return m.setAppointmentStatusFunc(ctx, appt, status)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) GetPatientStatusCounts(ctx context.Context, customerTgID string) (map[domain.AppointmentStatus]int, error) {
+	if m.getPatientStatusCountsFunc != nil {
+		return m.getPatientStatusCountsFunc(ctx, customerTgID)
+	}
+	return nil, nil
+}
+
+func (m *mockAppointmentService) GetCancellationPolicy() domain.CancellationPolicy {
+	if m.cancellationPolicy != nil {
+		return *m.cancellationPolicy
+	}
+	return domain.DefaultCancellationPolicy()
+}
+
 func (m *mockAppointmentService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
 	if m.getUpcomingAppointmentsFunc != nil {
 		return m.getUpcomingAppointmentsFunc(ctx, timeMin, timeMax)
@@ -78,6 +188,10 @@ func (m *mockAppointmentService) GetCustomerHistory(ctx context.Context, custome
 	return []domain.Appointment{}, nil
 }
 
+func (m *mockAppointmentService) HistorySynced() bool {
+	return false
+}
+
 func (m *mockAppointmentService) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
 	if m.getAllUpcomingAppointmentsFunc != nil {
 		return m.getAllUpcomingAppointmentsFunc(ctx)
@@ -120,6 +234,108 @@ func (m *mockAppointmentService) ListCalendars(ctx context.Context) ([]string, e
 	return []string{"calendar1", "calendar2"}, nil
 }
 
+func (m *mockAppointmentService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	if m.getAllServicesFunc != nil {
+		return m.getAllServicesFunc(ctx)
+	}
+	return []domain.Service{}, nil
+}
+
+func (m *mockAppointmentService) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	if m.saveServiceFunc != nil {
+		return m.saveServiceFunc(ctx, svc)
+	}
+	return &svc, nil
+}
+
+func (m *mockAppointmentService) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	if m.setServiceArchivedFunc != nil {
+		return m.setServiceArchivedFunc(ctx, id, archived)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) ReorderServices(ctx context.Context, orderedIDs []string) error {
+	if m.reorderServicesFunc != nil {
+		return m.reorderServicesFunc(ctx, orderedIDs)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	if m.getServiceCategoriesFunc != nil {
+		return m.getServiceCategoriesFunc(ctx, includeEmpty)
+	}
+	return []domain.ServiceCategory{
+		{ID: "massages", Label: "Массаж", Icon: "💆", SortOrder: 1},
+		{ID: "consultations", Label: "Консультация", Icon: "👥", SortOrder: 2},
+		{ID: "other", Label: "Другие услуги", Icon: "✨", SortOrder: 3},
+	}, nil
+}
+
+func (m *mockAppointmentService) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	if m.saveServiceCategoryFunc != nil {
+		return m.saveServiceCategoryFunc(ctx, cat)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) GetSchedule(ctx context.Context) (domain.WeeklySchedule, error) {
+	if m.getScheduleFunc != nil {
+		return m.getScheduleFunc(ctx)
+	}
+	return domain.DefaultWeeklySchedule(), nil
+}
+
+func (m *mockAppointmentService) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	if m.getWorkingHoursFunc != nil {
+		return m.getWorkingHoursFunc(ctx, therapistID, date)
+	}
+	return domain.DefaultWeeklySchedule().OpenIntervals(date), nil
+}
+
+func (m *mockAppointmentService) SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+	if m.setWeekdayHoursFunc != nil {
+		return m.setWeekdayHoursFunc(ctx, therapistID, weekday, work, breaks)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error {
+	if m.saveScheduleExceptionFunc != nil {
+		return m.saveScheduleExceptionFunc(ctx, exc)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error {
+	if m.deleteScheduleExceptionFunc != nil {
+		return m.deleteScheduleExceptionFunc(ctx, therapistID, date)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+	if m.getTherapistsFunc != nil {
+		return m.getTherapistsFunc(ctx, includeInactive)
+	}
+	return nil, nil
+}
+
+func (m *mockAppointmentService) SaveTherapist(ctx context.Context, t domain.Therapist) error {
+	if m.saveTherapistFunc != nil {
+		return m.saveTherapistFunc(ctx, t)
+	}
+	return nil
+}
+
+func (m *mockAppointmentService) GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, durationMinutes int) ([]domain.TimeSlot, error) {
+	if m.getTherapistTimeSlotsFunc != nil {
+		return m.getTherapistTimeSlotsFunc(ctx, therapistID, date, durationMinutes)
+	}
+	return m.GetAvailableTimeSlots(ctx, date, durationMinutes)
+}
+
 // mockSessionStorage implements ports.SessionStorage
 type mockSessionStorage struct {
 	sessions map[int64]map[string]interface{}
@@ -287,14 +287,7 @@ func (h *BookingHandler) GenerateWebAppURL(telegramID string) string {
 	if h.WebAppURL == "" || h.webAppSecret == "" {
 		return ""
 	}
-
-	// Rigidly enforce HTTPS for Telegram compatibility
-	url := h.WebAppURL
-	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
-		url = "https://" + url
-	} else if strings.HasPrefix(url, "http://") {
-		url = strings.Replace(url, "http://", "https://", 1)
-	}
+	url := h.webAppBaseURL()
 
 	ts := strconv.FormatInt(time.Now().Unix(), 10)
 	mac := hmac.New(sha256.New, []byte(h.webAppSecret))
@@ -306,6 +299,21 @@ func (h *BookingHandler) GenerateWebAppURL(telegramID string) string {
 	return fmt.Sprintf("%s/card?id=%s&ts=%s&token=%s", url, telegramID, ts, token)
 }
 
+// webAppBaseURL returns WebAppURL with HTTPS enforced, as Telegram
+// requires, or "" when the web app is not configured.
+func (h *BookingHandler) webAppBaseURL() string {
+	url := h.WebAppURL
+	if url == "" {
+		return ""
+	}
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		url = "https://" + url
+	} else if strings.HasPrefix(url, "http://") {
+		url = strings.Replace(url, "http://", "https://", 1)
+	}
+	return strings.TrimSuffix(url, "/")
+}
+
 func (h *BookingHandler) HandleListPatients(c telebot.Context) error {
 	if !h.IsAdmin(c.Sender().ID) {
 		return c.Send("⛔ Доступ запрещен.")
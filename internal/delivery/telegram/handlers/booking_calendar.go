@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// Calendar files and feeds: confirmations and reminders carry an .ics file
+// of the booking, cancellations one with METHOD:CANCEL, and /calendar gives
+// the patient (and admins, the clinic) a feed URL their calendar app can
+// subscribe to. The feeds are served by the web package.
+
+// icsDocument builds the .ics attachment for appt.
+func icsDocument(appt *domain.Appointment, method string) *telebot.Document {
+	data := presentation.FormatICS(presentation.ICSCalendar{Method: method}, []domain.Appointment{*appt}, time.Now())
+	return &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(data)),
+		FileName: presentation.ICSFileName(appt),
+		MIME:     "text/calendar",
+	}
+}
+
+// sendICS sends the .ics file of appt to a patient. Appointments without an
+// ID (so without a stable event UID) and patients without a numeric
+// Telegram ID are skipped.
+func sendICS(b ports.BotAPI, to string, appt *domain.Appointment, method string) {
+	patientID, err := strconv.ParseInt(to, 10, 64)
+	if err != nil || appt == nil || appt.ID == "" {
+		return
+	}
+	if _, err := b.Send(&telebot.User{ID: patientID}, icsDocument(appt, method)); err != nil {
+		logging.Warnf("Failed to send calendar file for appointment %s to %d: %v", appt.ID, patientID, err)
+	}
+}
+
+// GenerateCalendarFeedURL returns the signed feed URL of a patient's
+// upcoming sessions, or of all bookings for an admin.
+func (h *BookingHandler) GenerateCalendarFeedURL(telegramID string, admin bool) string {
+	base := h.webAppBaseURL()
+	if base == "" || h.webAppSecret == "" {
+		return ""
+	}
+	path := "/calendar/feed.ics"
+	if admin {
+		path = "/calendar/admin.ics"
+	}
+	params := url.Values{}
+	params.Set("id", telegramID)
+	params.Set("token", presentation.ICSFeedToken(telegramID, admin, h.webAppSecret))
+	return base + path + "?" + params.Encode()
+}
+
+// HandleCalendarFeed sends the user their calendar subscription link.
+func (h *BookingHandler) HandleCalendarFeed(c telebot.Context) error {
+	userID := strconv.FormatInt(c.Sender().ID, 10)
+	feed := h.GenerateCalendarFeedURL(userID, false)
+	if feed == "" {
+		return c.Send("📅 Подписка на календарь сейчас недоступна.")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📅 <b>Ваши записи в календаре телефона</b>\n\n")
+	sb.WriteString("Добавьте ссылку как подписку на календарь (iPhone: Настройки → Календарь → Учётные записи → Подписной календарь; Google Календарь: «Добавить по URL»):\n")
+	sb.WriteString(fmt.Sprintf("<code>%s</code>\n", feed))
+	if h.IsAdmin(c.Sender().ID) {
+		sb.WriteString("\n🗂 <b>Все записи клиники (для администратора):</b>\n")
+		sb.WriteString(fmt.Sprintf("<code>%s</code>\n", h.GenerateCalendarFeedURL(userID, true)))
+	}
+	sb.WriteString("\n<i>Не пересылайте ссылку: по ней видны ваши записи.</i>")
+	return c.Send(sb.String(), telebot.ModeHTML)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// mockFileBot records the documents sent through ports.BotAPI.
+type mockFileBot struct {
+	ports.BotAPI
+	to   []int64
+	docs []*telebot.Document
+	err  error
+}
+
+func (m *mockFileBot) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
+	m.to = append(m.to, to.(*telebot.User).ID)
+	if doc, ok := what.(*telebot.Document); ok {
+		m.docs = append(m.docs, doc)
+	}
+	return &telebot.Message{}, m.err
+}
+
+func TestSendICS(t *testing.T) {
+	appt := &domain.Appointment{
+		ID:        "evt1",
+		Service:   domain.Service{Name: "Massage", DurationMinutes: 60},
+		StartTime: time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Cancel file", func(t *testing.T) {
+		bot := &mockFileBot{}
+		sendICS(bot, "42", appt, presentation.ICSCancel)
+
+		if len(bot.docs) != 1 || bot.to[0] != 42 {
+			t.Fatalf("expected one file for 42, got %v", bot.to)
+		}
+		doc := bot.docs[0]
+		if !strings.HasSuffix(doc.FileName, ".ics") || doc.MIME != "text/calendar" {
+			t.Errorf("unexpected document %q %q", doc.FileName, doc.MIME)
+		}
+		data, _ := io.ReadAll(doc.FileReader)
+		if !strings.Contains(string(data), "METHOD:CANCEL") || !strings.Contains(string(data), "UID:evt1@massage-bot") {
+			t.Errorf("unexpected calendar:\n%s", data)
+		}
+	})
+
+	t.Run("Skipped", func(t *testing.T) {
+		bot := &mockFileBot{}
+		sendICS(bot, "manual_anna", appt, presentation.ICSPublish)
+		sendICS(bot, "42", &domain.Appointment{}, presentation.ICSPublish)
+		sendICS(bot, "42", nil, presentation.ICSPublish)
+		if len(bot.to) != 0 {
+			t.Errorf("expected nothing sent, got %v", bot.to)
+		}
+	})
+
+	t.Run("Send failure is logged", func(t *testing.T) {
+		bot := &mockFileBot{err: errors.New("blocked")}
+		sendICS(bot, "42", appt, presentation.ICSPublish)
+		if len(bot.to) != 1 {
+			t.Errorf("expected one attempt, got %v", bot.to)
+		}
+	})
+}
+
+func TestBookingHandler_GenerateCalendarFeedURL(t *testing.T) {
+	h := NewBookingHandler(nil, nil, []string{"111"}, nil, nil, nil, &presentation.BotPresenter{}, "http://example.com/", "secret123")
+
+	patient, err := url.Parse(h.GenerateCalendarFeedURL("42", false))
+	if err != nil || patient.Scheme != "https" || patient.Path != "/calendar/feed.ics" || patient.Query().Get("id") != "42" {
+		t.Fatalf("unexpected patient feed URL %v (%v)", patient, err)
+	}
+	admin, _ := url.Parse(h.GenerateCalendarFeedURL("42", true))
+	if admin.Path != "/calendar/admin.ics" {
+		t.Errorf("unexpected admin feed URL %v", admin)
+	}
+	if patient.Query().Get("token") == admin.Query().Get("token") {
+		t.Error("patient and admin feeds must be signed differently")
+	}
+	if patient.Query().Get("token") != presentation.ICSFeedToken("42", false, "secret123") {
+		t.Error("feed URL token does not match ICSFeedToken")
+	}
+
+	hEmpty := NewBookingHandler(nil, nil, nil, nil, nil, nil, &presentation.BotPresenter{}, "", "")
+	if u := hEmpty.GenerateCalendarFeedURL("42", false); u != "" {
+		t.Errorf("expected no URL without web app config, got %s", u)
+	}
+}
+
+func TestBookingHandler_HandleCalendarFeed(t *testing.T) {
+	h := NewBookingHandler(nil, nil, []string{"111"}, nil, nil, nil, &presentation.BotPresenter{}, "example.com", "secret123")
+
+	patient := &mockContext{sender: &telebot.User{ID: 42}}
+	if err := h.HandleCalendarFeed(patient); err != nil {
+		t.Fatalf("HandleCalendarFeed() error = %v", err)
+	}
+	if !strings.Contains(patient.sentMsg, "/calendar/feed.ics?id=42") || strings.Contains(patient.sentMsg, "admin.ics") {
+		t.Errorf("unexpected patient message: %s", patient.sentMsg)
+	}
+
+	admin := &mockContext{sender: &telebot.User{ID: 111}}
+	_ = h.HandleCalendarFeed(admin)
+	if !strings.Contains(admin.sentMsg, "/calendar/admin.ics?id=111") {
+		t.Errorf("expected the admin feed for admins: %s", admin.sentMsg)
+	}
+
+	hEmpty := NewBookingHandler(nil, nil, nil, nil, nil, nil, &presentation.BotPresenter{}, "", "")
+	off := &mockContext{sender: &telebot.User{ID: 42}}
+	_ = hEmpty.HandleCalendarFeed(off)
+	if !strings.Contains(off.sentMsg, "недоступна") {
+		t.Errorf("expected the feed reported unavailable, got %s", off.sentMsg)
+	}
+}
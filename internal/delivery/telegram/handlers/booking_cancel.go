@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Patients' own cancellations follow the appointment service's
+// CancellationPolicy. Inside the notice window they are either refused (the
+// patient is sent to the therapist) or allowed after a warning and recorded
+// as late cancels. Admins are not bound by the window.
+
+// lateCancelFlag marks a cancel callback the patient confirmed after the
+// late-cancel warning ("cancel_appt|id|late").
+const lateCancelFlag = "late"
+
+// hasCallbackFlag reports whether the callback parts after the ID include flag.
+func hasCallbackFlag(parts []string, flag string) bool {
+	for i := 2; i < len(parts); i++ {
+		if parts[i] == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLateCancel applies the cancellation policy to a patient cancelling
+// appt through the callback split into parts. When handled is true it has
+// already answered (refusal or warning) and the caller must stop; late
+// tells whether the cancellation goes ahead inside the notice window.
+func (h *BookingHandler) checkLateCancel(c telebot.Context, appt *domain.Appointment, parts []string) (late, handled bool, err error) {
+	if appt == nil || h.IsAdmin(c.Sender().ID) {
+		return false, false, nil
+	}
+	policy := h.appointmentService.GetCancellationPolicy()
+	if !policy.IsLate(appt.StartTime, time.Now()) {
+		return false, false, nil
+	}
+
+	if policy.BlockLate {
+		logging.Infof("BLOCKED: Late cancellation attempt for user %s, appt %s", appt.CustomerTgID, appt.ID)
+		return true, true, c.Respond(&telebot.CallbackResponse{
+			Text:      h.presenter.FormatLateCancelBlocked(policy, false),
+			ShowAlert: true,
+		})
+	}
+	if hasCallbackFlag(parts, lateCancelFlag) {
+		return true, false, nil
+	}
+
+	// Warn first; confirming repeats the same callback with the late flag
+	selector := &telebot.ReplyMarkup{}
+	confirmData := append(append([]string{}, parts[1:]...), lateCancelFlag)
+	selector.Inline(
+		selector.Row(selector.Data("❌ Да, отменить", parts[0], confirmData...)),
+		selector.Row(selector.Data("↩️ Не отменять", "keep_appt")),
+	)
+	local := *appt
+	local.StartTime = local.StartTime.In(domain.ApptTimeZone)
+	return true, true, c.EditOrSend(h.presenter.FormatLateCancelWarning(&local, policy), telebot.ModeHTML, selector)
+}
+
+// cancelStatusFor tells how a cancellation by userID is recorded: late
+// cancels by patients are marked as such.
+func (h *BookingHandler) cancelStatusFor(userID int64, late bool) domain.AppointmentStatus {
+	if late && !h.IsAdmin(userID) {
+		return domain.StatusLateCancel
+	}
+	return h.cancellationStatus(userID)
+}
+
+// withCancellationPolicy appends the cancellation rules to a patient's
+// booking confirmation.
+func (h *BookingHandler) withCancellationPolicy(msg string) string {
+	if policy := h.presenter.FormatCancellationPolicy(h.appointmentService.GetCancellationPolicy()); policy != "" {
+		return msg + "\n\n" + policy
+	}
+	return msg
+}
+
+// HandleKeepAppointment answers "Не отменять" on the late-cancel warning.
+func (h *BookingHandler) HandleKeepAppointment(c telebot.Context) error {
+	return c.Edit("👍 Запись сохранена. Ждём вас!")
+}
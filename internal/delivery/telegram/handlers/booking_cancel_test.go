@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func newLateCancelTestHandler(policy domain.CancellationPolicy, appt *domain.Appointment, cancelled *[]string, status *domain.AppointmentStatus) *BookingHandler {
+	mock := &mockAppointmentService{
+		cancellationPolicy: &policy,
+		findByIDFunc:       func(ctx context.Context, id string) (*domain.Appointment, error) { return appt, nil },
+		getAppointmentSeriesFunc: func(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+			return nil, domain.ErrSeriesNotFound
+		},
+		cancelAppointmentFunc: func(ctx context.Context, id string) error {
+			*cancelled = append(*cancelled, id)
+			return nil
+		},
+		setAppointmentStatusFunc: func(ctx context.Context, a *domain.Appointment, s domain.AppointmentStatus) error {
+			*status = s
+			return nil
+		},
+	}
+	return NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+}
+
+func TestHandleCancelAppointmentCallback_LateCancelPolicy(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	appt := &domain.Appointment{ID: "a1", CustomerTgID: "100", Service: domain.Service{Name: "Массаж"}, StartTime: time.Now().Add(5 * time.Hour)}
+	warn := domain.CancellationPolicy{NoticeHours: 24}
+
+	t.Run("warns before a late cancel", func(t *testing.T) {
+		var cancelled []string
+		var status domain.AppointmentStatus
+		h := newLateCancelTestHandler(warn, appt, &cancelled, &status)
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a1"}, bot: bot}
+
+		if err := h.HandleCancelAppointmentCallback(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if len(cancelled) != 0 {
+			t.Fatal("nothing should be cancelled before the patient confirms")
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "ПОЗДНЯЯ ОТМЕНА") || !contains(msg, "меньше суток") {
+			t.Errorf("expected the late-cancel warning, got %q", msg)
+		}
+	})
+
+	t.Run("confirmed late cancel is recorded", func(t *testing.T) {
+		var cancelled []string
+		var status domain.AppointmentStatus
+		h := newLateCancelTestHandler(warn, appt, &cancelled, &status)
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a1|late"}, bot: bot}
+
+		_ = h.HandleCancelAppointmentCallback(ctx)
+		if len(cancelled) != 1 || status != domain.StatusLateCancel {
+			t.Errorf("expected a recorded late cancel, got %v / %q", cancelled, status)
+		}
+	})
+
+	t.Run("blocked when the policy says so", func(t *testing.T) {
+		var cancelled []string
+		var status domain.AppointmentStatus
+		h := newLateCancelTestHandler(domain.CancellationPolicy{NoticeHours: 24, BlockLate: true}, appt, &cancelled, &status)
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a1|late"}, bot: bot}
+
+		_ = h.HandleCancelAppointmentCallback(ctx)
+		if len(cancelled) != 0 || ctx.response == nil || !ctx.response.ShowAlert || !contains(ctx.response.Text, "напишите терапевту") {
+			t.Errorf("expected the patient to be sent to the therapist, got %v / %+v", cancelled, ctx.response)
+		}
+	})
+
+	t.Run("admins are not bound by the window", func(t *testing.T) {
+		var cancelled []string
+		var status domain.AppointmentStatus
+		h := newLateCancelTestHandler(domain.DefaultCancellationPolicy(), appt, &cancelled, &status)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, callback: &telebot.Callback{Data: "cancel_appt|a1"}, bot: bot}
+
+		_ = h.HandleCancelAppointmentCallback(ctx)
+		if len(cancelled) != 1 || status != domain.StatusCancelledByAdmin {
+			t.Errorf("expected an admin cancellation, got %v / %q", cancelled, status)
+		}
+	})
+
+	t.Run("outside the window nothing changes", func(t *testing.T) {
+		var cancelled []string
+		var status domain.AppointmentStatus
+		early := *appt
+		early.StartTime = time.Now().Add(48 * time.Hour)
+		h := newLateCancelTestHandler(warn, &early, &cancelled, &status)
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a1"}, bot: bot}
+
+		_ = h.HandleCancelAppointmentCallback(ctx)
+		if len(cancelled) != 1 || status != domain.StatusCancelledByPatient {
+			t.Errorf("expected a regular cancellation, got %v / %q", cancelled, status)
+		}
+	})
+}
+
+func TestWithCancellationPolicy(t *testing.T) {
+	h := NewBookingHandler(&mockAppointmentService{}, newMockSessionStorage(), nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	if got := h.withCancellationPolicy("Запись"); !contains(got, "не позднее чем за 3 дня") {
+		t.Errorf("expected the policy in the confirmation, got %q", got)
+	}
+
+	none := domain.CancellationPolicy{}
+	h = NewBookingHandler(&mockAppointmentService{cancellationPolicy: &none}, newMockSessionStorage(), nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	if got := h.withCancellationPolicy("Запись"); got != "Запись" {
+		t.Errorf("expected no policy text without a window, got %q", got)
+	}
+}
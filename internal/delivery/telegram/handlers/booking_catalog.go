@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Admin commands for the service catalog. Fields are passed pipe-separated
+// so names and descriptions may contain spaces:
+//
+//	/service_add Название | минуты | цена | категория | описание
+//	/service_edit {id} Название | минуты | цена | категория | описание
+//	/category_save {ключ} | Название | иконка | порядок
+const (
+	serviceAddUsage   = "Использование: /service_add Название | минуты | цена | категория | описание"
+	serviceEditUsage  = "Использование: /service_edit {id} Название | минуты | цена | категория | описание\nПустое поле оставляет текущее значение."
+	categorySaveUsage = "Использование: /category_save {ключ} | Название | иконка | порядок"
+)
+
+// HandleListServices shows the full catalog, including archived services.
+func (h *BookingHandler) HandleListServices(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	services, err := h.appointmentService.GetAllServices(context.Background())
+	if err != nil {
+		logging.Errorf(": Failed to list services: %v", err)
+		return c.Send("❌ Ошибка при получении списка услуг.")
+	}
+	if len(services) == 0 {
+		return c.Send("Каталог услуг пуст.")
+	}
+
+	labels := make(map[string]string)
+	if categories, err := h.appointmentService.GetServiceCategories(context.Background(), true); err == nil {
+		for _, cat := range categories {
+			labels[cat.ID] = cat.ButtonText()
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("💆 <b>Каталог услуг:</b>\n\n")
+	for _, s := range services {
+		category := labels[s.Category()]
+		if category == "" {
+			category = s.Category()
+		}
+		status := ""
+		if s.Archived {
+			status = " 🗄 <i>(в архиве)</i>"
+		}
+		sb.WriteString(fmt.Sprintf("<b>%s.</b> %s — %d мин, %.0f ₺ [%s]%s\n", s.ID, s.Name, s.DurationMinutes, s.Price, category, status))
+		if s.Description != "" {
+			sb.WriteString(fmt.Sprintf("    <i>%s</i>\n", s.Description))
+		}
+	}
+	sb.WriteString("\n/service_add, /service_edit, /service_archive, /service_restore, /service_order, /category_save")
+	return c.Send(sb.String(), telebot.ModeHTML)
+}
+
+// HandleAddService creates a new catalog entry.
+func (h *BookingHandler) HandleAddService(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	fields := splitServiceFields(c.Args())
+	if len(fields) < 3 {
+		return c.Send(serviceAddUsage)
+	}
+
+	var svc domain.Service
+	if err := applyServiceFields(&svc, fields); err != nil {
+		return c.Send(fmt.Sprintf("❌ %v\n%s", err, serviceAddUsage))
+	}
+
+	saved, err := h.appointmentService.SaveService(context.Background(), svc)
+	if err != nil {
+		return c.Send(serviceErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Service created by %d: %s (%s)", c.Sender().ID, saved.Name, saved.ID)
+	return c.Send(fmt.Sprintf("✅ Услуга добавлена: <b>%s</b> (ID %s)", saved.Name, saved.ID), telebot.ModeHTML)
+}
+
+// HandleEditService updates an existing catalog entry. Blank fields keep
+// their current value. Past appointments are unaffected because each one
+// stores its own copy of the service.
+func (h *BookingHandler) HandleEditService(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	args := c.Args()
+	if len(args) < 2 {
+		return c.Send(serviceEditUsage)
+	}
+
+	current := h.findCatalogService(args[0])
+	if current == nil {
+		return c.Send("❌ Услуга не найдена.")
+	}
+
+	svc := *current
+	if err := applyServiceFields(&svc, splitServiceFields(args[1:])); err != nil {
+		return c.Send(fmt.Sprintf("❌ %v\n%s", err, serviceEditUsage))
+	}
+
+	saved, err := h.appointmentService.SaveService(context.Background(), svc)
+	if err != nil {
+		return c.Send(serviceErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Service %s updated by %d", saved.ID, c.Sender().ID)
+	return c.Send(fmt.Sprintf("✅ Услуга обновлена: <b>%s</b> — %d мин, %.0f ₺", saved.Name, saved.DurationMinutes, saved.Price), telebot.ModeHTML)
+}
+
+// HandleArchiveService hides a service from the booking menu.
+func (h *BookingHandler) HandleArchiveService(c telebot.Context) error {
+	return h.setServiceArchived(c, true)
+}
+
+// HandleRestoreService returns an archived service to the booking menu.
+func (h *BookingHandler) HandleRestoreService(c telebot.Context) error {
+	return h.setServiceArchived(c, false)
+}
+
+func (h *BookingHandler) setServiceArchived(c telebot.Context, archived bool) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	args := c.Args()
+	if len(args) < 1 {
+		if archived {
+			return c.Send("Использование: /service_archive {id}")
+		}
+		return c.Send("Использование: /service_restore {id}")
+	}
+
+	if err := h.appointmentService.SetServiceArchived(context.Background(), args[0], archived); err != nil {
+		return c.Send(serviceErrorMessage(err))
+	}
+
+	if archived {
+		return c.Send(fmt.Sprintf("🗄 Услуга %s перенесена в архив.", args[0]))
+	}
+	return c.Send(fmt.Sprintf("✅ Услуга %s снова доступна для записи.", args[0]))
+}
+
+// HandleReorderServices sets the menu order: /service_order 2 1 3 ...
+func (h *BookingHandler) HandleReorderServices(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	args := c.Args()
+	if len(args) < 1 {
+		return c.Send("Использование: /service_order {id1} {id2} ...")
+	}
+
+	if err := h.appointmentService.ReorderServices(context.Background(), args); err != nil {
+		return c.Send(serviceErrorMessage(err))
+	}
+	return c.Send("✅ Порядок услуг обновлён.")
+}
+
+// HandleSaveCategory creates or updates a booking menu category.
+func (h *BookingHandler) HandleSaveCategory(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	fields := splitServiceFields(c.Args())
+	if len(fields) < 2 {
+		return c.Send(categorySaveUsage)
+	}
+
+	cat := domain.ServiceCategory{ID: fields[0], Label: fields[1]}
+	if len(fields) > 2 {
+		cat.Icon = fields[2]
+	}
+	if len(fields) > 3 && fields[3] != "" {
+		order, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return c.Send(fmt.Sprintf("❌ неверный порядок: %s\n%s", fields[3], categorySaveUsage))
+		}
+		cat.SortOrder = order
+	}
+
+	if err := h.appointmentService.SaveServiceCategory(context.Background(), cat); err != nil {
+		if errors.Is(err, domain.ErrInvalidService) {
+			return c.Send("❌ Неверные данные категории: нужны ключ (до 32 символов, без \"|\") и название.")
+		}
+		return c.Send(serviceErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Service category %s saved by %d", cat.ID, c.Sender().ID)
+	return c.Send(fmt.Sprintf("✅ Категория сохранена: %s", cat.ButtonText()))
+}
+
+func (h *BookingHandler) findCatalogService(id string) *domain.Service {
+	services, err := h.appointmentService.GetAllServices(context.Background())
+	if err != nil {
+		logging.Errorf(": Failed to load services: %v", err)
+		return nil
+	}
+	for i := range services {
+		if services[i].ID == id {
+			return &services[i]
+		}
+	}
+	return nil
+}
+
+// splitServiceFields rejoins command args and splits them on "|".
+func splitServiceFields(args []string) []string {
+	raw := strings.Split(strings.Join(args, " "), "|")
+	fields := make([]string, len(raw))
+	for i, f := range raw {
+		fields[i] = strings.TrimSpace(f)
+	}
+	return fields
+}
+
+// applyServiceFields overlays non-empty fields (name, duration, price,
+// category, description) onto svc.
+func applyServiceFields(svc *domain.Service, fields []string) error {
+	for i, f := range fields {
+		if f == "" {
+			continue
+		}
+		switch i {
+		case 0:
+			svc.Name = f
+		case 1:
+			d, err := strconv.Atoi(f)
+			if err != nil {
+				return fmt.Errorf("неверная длительность: %s", f)
+			}
+			svc.DurationMinutes = d
+		case 2:
+			p, err := strconv.ParseFloat(strings.ReplaceAll(f, ",", "."), 64)
+			if err != nil {
+				return fmt.Errorf("неверная цена: %s", f)
+			}
+			svc.Price = p
+		case 3:
+			svc.CategoryID = f
+		case 4:
+			svc.Description = f
+		}
+	}
+	return nil
+}
+
+func serviceErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidService):
+		return "❌ Неверные данные услуги: нужны название, длительность (мин) и цена."
+	case errors.Is(err, domain.ErrServiceNotFound):
+		return "❌ Услуга не найдена."
+	case errors.Is(err, domain.ErrCatalogUnavailable):
+		return "❌ Каталог услуг не подключён к базе данных."
+	default:
+		logging.Errorf(": Service catalog operation failed: %v", err)
+		return "❌ Ошибка при сохранении каталога услуг."
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func TestCatalogHandlers(t *testing.T) {
+	adminID := "999999"
+	catalog := []domain.Service{
+		{ID: "1", Name: "Массаж", DurationMinutes: 40, Price: 2000, CategoryID: "massages", SortOrder: 1},
+		{ID: "2", Name: "Старая услуга", DurationMinutes: 30, Price: 900, SortOrder: 2, Archived: true},
+	}
+
+	tests := []struct {
+		name          string
+		handlerMethod func(h *BookingHandler, c telebot.Context) error
+		userID        int64
+		args          []string
+		wantMsg       string
+		wantSaved     *domain.Service
+	}{
+		{
+			name:          "List - Not Admin",
+			handlerMethod: (*BookingHandler).HandleListServices,
+			userID:        123,
+			wantMsg:       "Доступ запрещен",
+		},
+		{
+			name:          "List - Shows Archived",
+			handlerMethod: (*BookingHandler).HandleListServices,
+			userID:        999999,
+			wantMsg:       "в архиве",
+		},
+		{
+			name:          "List - Shows Category Label",
+			handlerMethod: (*BookingHandler).HandleListServices,
+			userID:        999999,
+			wantMsg:       "[💆 Массаж]",
+		},
+		{
+			name:          "Add - Missing Fields",
+			handlerMethod: (*BookingHandler).HandleAddService,
+			userID:        999999,
+			args:          []string{"Массаж"},
+			wantMsg:       "Использование",
+		},
+		{
+			name:          "Add - Bad Duration",
+			handlerMethod: (*BookingHandler).HandleAddService,
+			userID:        999999,
+			args:          []string{"Массаж", "|", "час", "|", "2000"},
+			wantMsg:       "неверная длительность",
+		},
+		{
+			name:          "Add - Success",
+			handlerMethod: (*BookingHandler).HandleAddService,
+			userID:        999999,
+			args:          []string{"Массаж", "стоп", "|", "50", "|", "2500,5", "|", "massages", "|", "Для", "ног"},
+			wantMsg:       "Услуга добавлена",
+			wantSaved:     &domain.Service{Name: "Массаж стоп", DurationMinutes: 50, Price: 2500.5, CategoryID: "massages", Description: "Для ног"},
+		},
+		{
+			name:          "Edit - Not Found",
+			handlerMethod: (*BookingHandler).HandleEditService,
+			userID:        999999,
+			args:          []string{"42", "Новое"},
+			wantMsg:       "не найдена",
+		},
+		{
+			name:          "Edit - Blank Fields Keep Values",
+			handlerMethod: (*BookingHandler).HandleEditService,
+			userID:        999999,
+			args:          []string{"1", "|", "|", "2200"},
+			wantMsg:       "Услуга обновлена",
+			wantSaved:     &domain.Service{ID: "1", Name: "Массаж", DurationMinutes: 40, Price: 2200, CategoryID: "massages", SortOrder: 1},
+		},
+		{
+			name:          "Archive - Missing Args",
+			handlerMethod: (*BookingHandler).HandleArchiveService,
+			userID:        999999,
+			wantMsg:       "/service_archive",
+		},
+		{
+			name:          "Archive - Success",
+			handlerMethod: (*BookingHandler).HandleArchiveService,
+			userID:        999999,
+			args:          []string{"1"},
+			wantMsg:       "в архив",
+		},
+		{
+			name:          "Restore - Success",
+			handlerMethod: (*BookingHandler).HandleRestoreService,
+			userID:        999999,
+			args:          []string{"2"},
+			wantMsg:       "снова доступна",
+		},
+		{
+			name:          "Order - Success",
+			handlerMethod: (*BookingHandler).HandleReorderServices,
+			userID:        999999,
+			args:          []string{"2", "1"},
+			wantMsg:       "Порядок услуг обновлён",
+		},
+		{
+			name:          "Category - Missing Label",
+			handlerMethod: (*BookingHandler).HandleSaveCategory,
+			userID:        999999,
+			args:          []string{"spa"},
+			wantMsg:       "/category_save",
+		},
+		{
+			name:          "Category - Bad Order",
+			handlerMethod: (*BookingHandler).HandleSaveCategory,
+			userID:        999999,
+			args:          []string{"spa", "|", "SPA", "|", "🧖", "|", "first"},
+			wantMsg:       "неверный порядок",
+		},
+		{
+			name:          "Category - Success",
+			handlerMethod: (*BookingHandler).HandleSaveCategory,
+			userID:        999999,
+			args:          []string{"spa", "|", "SPA", "процедуры", "|", "🧖", "|", "4"},
+			wantMsg:       "🧖 SPA процедуры",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *domain.Service
+			mockApptService := &mockAppointmentService{
+				getAllServicesFunc: func(ctx context.Context) ([]domain.Service, error) {
+					return append([]domain.Service(nil), catalog...), nil
+				},
+				saveServiceFunc: func(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+					captured := svc
+					saved = &captured
+					if svc.ID == "" {
+						svc.ID = "3"
+					}
+					return &svc, nil
+				},
+			}
+
+			handler := NewBookingHandler(
+				mockApptService,
+				newMockSessionStorage(),
+				[]string{adminID},
+				nil,
+				nil,
+				newMockRepository(),
+				&presentation.BotPresenter{},
+				"",
+				"",
+			)
+
+			ctx := &mockContext{
+				sender: &telebot.User{ID: tt.userID},
+				args:   tt.args,
+			}
+
+			if err := tt.handlerMethod(handler, ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if !contains(ctx.sentMsg, tt.wantMsg) {
+				t.Errorf("Expected msg containing %q, got %q", tt.wantMsg, ctx.sentMsg)
+			}
+			if tt.wantSaved != nil {
+				if saved == nil {
+					t.Fatal("Expected SaveService to be called")
+				}
+				if *saved != *tt.wantSaved {
+					t.Errorf("Saved service = %+v, want %+v", *saved, *tt.wantSaved)
+				}
+			}
+		})
+	}
+}
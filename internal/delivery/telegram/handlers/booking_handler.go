@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kfilin/massage-bot/internal/delivery/telegram/keyboards"
 	"github.com/kfilin/massage-bot/internal/domain"
 	"github.com/kfilin/massage-bot/internal/logging"
 	"github.com/kfilin/massage-bot/internal/monitoring"
@@ -17,9 +18,13 @@ import (
 )
 
 // BookingHandler is the central handler for booking-related commands and
-// callbacks. Methods on this struct are split across this and three sibling
-// files (booking_admin.go, booking_file.go, booking_session.go) for
-// navigability — they all belong to the same struct.
+// callbacks. Methods on this struct are split across this and seventeen sibling
+// files (booking_admin.go, booking_calendar.go, booking_cancel.go,
+// booking_catalog.go, booking_file.go, booking_hold.go, booking_next.go,
+// booking_package.go, booking_payment.go, booking_promo.go,
+// booking_reschedule.go, booking_schedule.go, booking_series.go,
+// booking_session.go, booking_status.go, booking_therapist.go,
+// booking_waitlist.go) for navigability — they all belong to the same struct.
 type BookingHandler struct {
 	appointmentService   ports.AppointmentService
 	sessionStorage       ports.SessionStorage
@@ -30,6 +35,10 @@ type BookingHandler struct {
 	presenter            *presentation.BotPresenter
 	WebAppURL            string
 	webAppSecret         string
+	waitlist             ports.WaitlistService
+	packages             ports.PackageService
+	payments             ports.PaymentService
+	promos               ports.PromoService
 }
 
 func NewBookingHandler(as ports.AppointmentService, ss ports.SessionStorage, admins []string, therapistIDs []string, trans ports.TranscriptionService, repo ports.Repository, presenter *presentation.BotPresenter, webAppURL string, webAppSecret string) *BookingHandler {
@@ -49,6 +58,7 @@ func NewBookingHandler(as ports.AppointmentService, ss ports.SessionStorage, adm
 func (h *BookingHandler) HandleStart(c telebot.Context) error {
 	userID := c.Sender().ID
 	logging.Debugf(": Entered HandleStart for user %d", userID)
+	h.releaseSlotHold(userID)
 	h.sessionStorage.ClearSession(userID)
 
 	// 1. Handle deep links
@@ -131,18 +141,23 @@ func (h *BookingHandler) HandleStart(c telebot.Context) error {
 }
 
 func (h *BookingHandler) showCategories(c telebot.Context) error {
+	categories, err := h.appointmentService.GetServiceCategories(context.Background(), false)
+	if err != nil {
+		logging.Errorf(": Failed to load service categories: %v", err)
+		return c.Send("Ошибка загрузки услуг.")
+	}
+
 	selector := &telebot.ReplyMarkup{}
-	btnMassages := selector.Data("💆 Массаж", "select_category", "massages")
-	btnConsultations := selector.Data("👥 Консультация", "select_category", "consultations")
-	btnOther := selector.Data("✨ Другие услуги", "select_category", "other")
-
-	selector.Inline(
-		selector.Row(btnMassages),
-		selector.Row(btnConsultations),
-		selector.Row(btnOther),
-	)
+	var rows []telebot.Row
+	for _, cat := range categories {
+		rows = append(rows, selector.Row(selector.Data(cat.ButtonText(), "select_category", cat.ID)))
+	}
+	selector.Inline(rows...)
 
 	msg := "Выберите категорию услуг:"
+	if len(categories) == 0 {
+		msg = "Сейчас нет доступных услуг для записи."
+	}
 	if c.Callback() != nil {
 		return c.Edit(msg, selector)
 	}
@@ -174,26 +189,8 @@ func (h *BookingHandler) HandleCategorySelection(c telebot.Context) error {
 	var rows []telebot.Row
 
 	for _, svc := range services {
-		include := false
-		name := svc.Name
-
-		switch category {
-		case "massages":
-			if name == "Массаж Спина + Шея" || name == "Общий массаж" || name == "Лимфодренаж" {
-				include = true
-			}
-		case "consultations":
-			if name == "Консультация офлайн" || name == "Консультация онлайн" {
-				include = true
-			}
-		case "other":
-			if name == "Иглоукалывание" || name == "Реабилитационные программы" {
-				include = true
-			}
-		}
-
-		if include {
-			label := fmt.Sprintf("%s · %.0f₺", name, svc.Price)
+		if svc.Category() == category {
+			label := fmt.Sprintf("%s · %.0f₺", svc.Name, svc.Price)
 			rows = append(rows, selector.Row(selector.Data(label, "select_service", svc.ID)))
 		}
 	}
@@ -274,7 +271,7 @@ func (h *BookingHandler) HandleServiceSelection(c telebot.Context) error {
 		// Store service struct directly in session (consistent with normal services)
 		h.sessionStorage.Set(userID, SessionKeyService, fakeService)
 
-		return h.askForDate(c, fakeService.Name) // Proceed to date selection directly
+		return h.askForTherapist(c, fakeService.Name) // Pick whose time to block, then the date
 	}
 
 	services, err := h.appointmentService.GetAvailableServices(context.Background())
@@ -311,8 +308,8 @@ func (h *BookingHandler) HandleServiceSelection(c telebot.Context) error {
 		}
 	}()
 
-	// Ask for date
-	return h.askForDate(c, chosenService.Name)
+	// Ask for therapist (skipped unless several are bookable), then date
+	return h.askForTherapist(c, chosenService.Name)
 }
 
 func (h *BookingHandler) askForDate(c telebot.Context, serviceName string) error {
@@ -323,18 +320,58 @@ func (h *BookingHandler) askForDate(c telebot.Context, serviceName string) error
 	// Use domain.ApptTimeZone for consistency across the application
 	currentMonth := time.Date(year, month, 1, 0, 0, 0, 0, domain.ApptTimeZone)
 
-	calendarKeyboard := h.generateCalendar(currentMonth)
+	therapistID := h.sessionTherapist(c.Sender().ID)
+	calendarKeyboard := h.dateKeyboard(c.Sender().ID, currentMonth)
 
+	chosen := fmt.Sprintf("услуга '%s' выбрана", serviceName)
+	if therapistID != "" {
+		chosen = fmt.Sprintf("услуга '%s' у специалиста %s выбрана", serviceName, h.therapistName(therapistID))
+	}
+	prompt := fmt.Sprintf("Отлично, %s. Теперь выберите дату:", chosen)
+	if h.rescheduleID(c.Sender().ID) != "" {
+		prompt = fmt.Sprintf("🔄 Перенос записи '%s'. Выберите новую дату:", serviceName)
+	}
 	return c.EditOrSend(
-		fmt.Sprintf("Отлично, услуга '%s' выбрана. Теперь выберите дату:\n\n<i>░X░ — дата недоступна</i>", serviceName),
+		prompt+"\n\n<i>"+keyboards.AvailabilityLegend+"</i>",
 		calendarKeyboard,
 		telebot.ModeHTML,
 	)
 }
 
-func (h *BookingHandler) generateCalendar(month time.Time) *telebot.ReplyMarkup {
+// generateCalendar builds the month picker. therapistID limits open days to
+// that therapist's schedule; empty means any active therapist (or the shared
+// schedule when no therapists are registered). With durationMinutes set the
+// days are marked from the month's availability (limited, full); without it,
+// or when the overview fails, only the schedule is used.
+func (h *BookingHandler) generateCalendar(month time.Time, therapistID string, durationMinutes int) *telebot.ReplyMarkup {
 	logging.Debugf(": Generating calendar for month: %s", month.Format("2006-01"))
 	selector := &telebot.ReplyMarkup{}
+
+	// Days without working hours (regular days off, holidays) are not selectable
+	schedule, err := h.appointmentService.GetSchedule(context.Background())
+	if err != nil {
+		logging.Errorf(": Failed to load working schedule, using defaults: %v", err)
+		schedule = domain.DefaultWeeklySchedule()
+	}
+	schedules := []domain.WeeklySchedule{schedule.ForTherapist(therapistID)}
+	if therapistID == "" {
+		if therapists, err := h.appointmentService.GetTherapists(context.Background(), false); err == nil && len(therapists) > 0 {
+			schedules = schedules[:0]
+			for _, t := range therapists {
+				schedules = append(schedules, schedule.ForTherapist(t.ID))
+			}
+		}
+	}
+	statuses := make(map[int]domain.DayStatus)
+	if durationMinutes > 0 {
+		days, err := h.appointmentService.GetMonthAvailability(context.Background(), therapistID, month, durationMinutes)
+		if err != nil {
+			logging.Warnf(": Failed to load month availability, marking by schedule only: %v", err)
+		}
+		for _, d := range days {
+			statuses[d.Date.Day()] = d.Status
+		}
+	}
 	var rows []telebot.Row
 
 	// Navigation row
@@ -378,13 +415,28 @@ func (h *BookingHandler) generateCalendar(month time.Time) *telebot.ReplyMarkup
 			} else {
 				dayStr := fmt.Sprintf("%d", currentDay.Day())
 				isPast := currentDay.Truncate(24 * time.Hour).Before(nowInLoc)
-				isWeekend := currentDay.Weekday() == time.Saturday || currentDay.Weekday() == time.Sunday
+				isClosed := true
+				for _, sch := range schedules {
+					if len(sch.OpenIntervals(currentDay)) > 0 {
+						isClosed = false
+						break
+					}
+				}
 
-				if isPast || isWeekend {
+				status, known := statuses[currentDay.Day()]
+				if known {
+					isClosed = status == domain.DayClosed
+				}
+
+				if isPast || isClosed {
 					// Use a "faded" look for unavailable dates
-					fadedDay := fmt.Sprintf("░%d░", currentDay.Day())
+					fadedDay := keyboards.DayLabel(currentDay.Day(), domain.DayClosed)
 					weekBtns = append(weekBtns, selector.Data(fadedDay, "ignore"))
 				} else {
+					// Full days stay selectable so the waitlist can be offered
+					if known {
+						dayStr = keyboards.DayLabel(currentDay.Day(), status)
+					}
 					weekBtns = append(weekBtns, selector.Data(dayStr, "select_date", currentDay.Format("2006-01-02")))
 				}
 			}
@@ -417,7 +469,7 @@ func (h *BookingHandler) HandleDateSelection(c telebot.Context) error {
 			logging.Errorf(": Invalid month format in navigation: %s, error: %v", monthStr, err)
 			return c.Edit("Некорректная дата. Попробуйте снова.")
 		}
-		calendarKeyboard := h.generateCalendar(selectedMonth)
+		calendarKeyboard := h.dateKeyboard(userID, selectedMonth)
 		return c.Edit(c.Message().Text, calendarKeyboard, telebot.ModeHTML) // Edit the existing message
 	} else if strings.HasPrefix(data, "select_date|") {
 		parts := strings.Split(data, "|")
@@ -462,14 +514,14 @@ func (h *BookingHandler) HandleReminderConfirmation(c telebot.Context) error {
 		return c.Send("❌ Ошибка при подтверждении записи.")
 	}
 
-	// Notify Vera
+	// Notify the therapist (or all admins when unknown)
 	appt, err := h.appointmentService.FindByID(context.Background(), apptID)
 	if err == nil {
+		h.recordStatus(context.Background(), appt, domain.StatusConfirmed)
 		notification := h.presenter.FormatAppointment(appt, true)
 
-		for _, adminIDStr := range h.adminIDs {
-			adminID, _ := strconv.ParseInt(adminIDStr, 10, 64)
-			h.BotNotify(c.Bot(), adminID, notification)
+		for _, recipient := range h.bookingRecipients(appt) {
+			h.BotNotify(c.Bot(), recipient, notification)
 		}
 	}
 
@@ -527,7 +579,13 @@ func (h *BookingHandler) askForTime(c telebot.Context) error {
 	selectedDateInLoc := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
 
 	logging.Debugf(": Calling GetAvailableTimeSlots for user %d with date %s and duration %d", userID, selectedDateInLoc.Format("2006-01-02"), service.DurationMinutes)
-	timeSlots, err := h.appointmentService.GetAvailableTimeSlots(context.Background(), selectedDateInLoc, service.DurationMinutes)
+	var timeSlots []domain.TimeSlot
+	var err error
+	if therapistID := h.sessionTherapist(userID); therapistID != "" {
+		timeSlots, err = h.appointmentService.GetTherapistTimeSlots(holderContext(userID), therapistID, selectedDateInLoc, service.DurationMinutes)
+	} else {
+		timeSlots, err = h.appointmentService.GetAvailableTimeSlots(holderContext(userID), selectedDateInLoc, service.DurationMinutes)
+	}
 	if err != nil {
 		logging.Errorf(": Error getting available time slots for user %d: %v", userID, err)
 		// Clean up the calendar keyboard before showing the error
@@ -541,6 +599,9 @@ func (h *BookingHandler) askForTime(c telebot.Context) error {
 	logging.Debugf(": Received %d time slots for user %d.", len(timeSlots), userID)
 
 	if len(timeSlots) == 0 {
+		if h.canJoinWaitlist(userID) {
+			return c.EditOrSend("На эту дату нет доступных временных слотов.\n\nМожно встать в лист ожидания — мы напишем, если время освободится.", h.waitlistOfferMarkup())
+		}
 		// Используем c.EditOrSend для обновления сообщения, если слотов нет
 		return c.EditOrSend("На эту дату нет доступных временных слотов. Пожалуйста, выберите другую дату.", h.getMainMenuWithBackBtn())
 	}
@@ -593,6 +654,7 @@ func (h *BookingHandler) HandleTimeSelection(c telebot.Context) error {
 
 	if data == "back_to_date" {
 		userID := c.Sender().ID
+		h.releaseSlotHold(userID)
 		session := h.sessionStorage.Get(userID)
 		service, ok := session[SessionKeyService].(domain.Service)
 		if !ok {
@@ -614,6 +676,12 @@ func (h *BookingHandler) HandleTimeSelection(c telebot.Context) error {
 		logging.Errorf(": Invalid time format in selection: %s, error: %v", timeStr, err)
 		return c.Edit("Некорректное время. Пожалуйста, попробуйте /start снова.")
 	}
+
+	// Keep the slot for this user while they finish booking; moving an
+	// existing appointment completes at once and needs no hold
+	if h.rescheduleID(userID) == "" && !h.holdPickedSlot(userID, timeStr) {
+		return h.slotTakenResponse(c)
+	}
 	h.sessionStorage.Set(userID, SessionKeyTime, timeStr)
 	logging.Debugf(": Time selected and stored in session for user %d: %s", userID, timeStr)
 
@@ -625,6 +693,11 @@ func (h *BookingHandler) HandleTimeSelection(c telebot.Context) error {
 		}
 	}
 
+	// Moving an existing appointment: nothing else to ask
+	if apptID := h.rescheduleID(userID); apptID != "" {
+		return h.completeReschedule(c, apptID)
+	}
+
 	// Check if this is a block service (skip name input)
 	sessionData := h.sessionStorage.Get(userID)
 	if service, ok := sessionData[SessionKeyService].(domain.Service); ok {
@@ -703,18 +776,34 @@ func (h *BookingHandler) askForConfirmation(c telebot.Context) error {
 	}
 
 	title := "<b>Пожалуйста, подтвердите вашу запись:</b>"
-	if val, ok := sessionData[SessionKeyIsAdminManual].(bool); ok && val {
+	isAdminManual, _ := sessionData[SessionKeyIsAdminManual].(bool)
+	isAdminBlock, _ := sessionData[SessionKeyIsAdminBlock].(bool)
+	if isAdminManual {
 		title = "<b>Подтвердите создание ручной записи:</b>"
 	}
 
-	confirmMessage := h.presenter.FormatBookingSummary(title, name, service.Name, appointmentTime, service.DurationMinutes, service.Price)
+	// Promo codes are for patients booking themselves
+	offerPromo := h.promos != nil && !isAdminManual && !isAdminBlock
+	var promoCode string
+	var discount float64
+	if offerPromo {
+		promoCode, discount = h.sessionPromo(userID, service)
+	}
+
+	confirmMessage := h.presenter.FormatBookingSummary(title, name, service.Name, appointmentTime, service.DurationMinutes, service.Price, promoCode, discount)
 
 	// Inline Keyboard - One button per row for maximum prominence
 	selector := &telebot.ReplyMarkup{}
-	selector.Inline(
-		selector.Row(selector.Data("✅ ПОДТВЕРДИТЬ", "confirm_booking")),
-		selector.Row(selector.Data("❌ ОТМЕНИТЬ", "cancel_booking")),
-	)
+	rows := []telebot.Row{selector.Row(selector.Data("✅ ПОДТВЕРДИТЬ", "confirm_booking"))}
+	if offerPromo {
+		label := "🏷 Ввести промокод"
+		if promoCode != "" {
+			label = "🏷 Изменить промокод"
+		}
+		rows = append(rows, selector.Row(selector.Data(label, "enter_promo")))
+	}
+	rows = append(rows, selector.Row(selector.Data("❌ ОТМЕНИТЬ", "cancel_booking")))
+	selector.Inline(rows...)
 
 	// Set session flag indicating awaiting confirmation (keep for fallback/cleanup)
 	h.sessionStorage.Set(userID, SessionKeyAwaitingConfirmation, true)
@@ -731,6 +820,7 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 
 	// Clear awaiting confirmation flag
 	h.sessionStorage.Set(userID, SessionKeyAwaitingConfirmation, false)
+	h.sessionStorage.Set(userID, SessionKeyAwaitingPromo, false)
 	logging.Debugf(": Cleared SessionKeyAwaitingConfirmation for user %d.", userID)
 
 	service, okS := sessionData[SessionKeyService].(domain.Service)
@@ -783,6 +873,8 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 		CustomerTgID: strconv.FormatInt(userID, 10),
 		CustomerName: name,
 		Notes:        "Telegram Bot Booking",
+		TherapistID:  h.sessionTherapist(userID),
+		Source:       domain.SourceBot,
 	}
 
 	if isAdminManual {
@@ -797,19 +889,29 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 			logging.Warnf(": Manual booking fallback to generated ID: %s", appt.CustomerTgID)
 		}
 		appt.Notes = "Manual Appointment by Admin"
+		appt.Source = domain.SourceAdmin
 	}
 
 	if isAdminBlock {
 		appt.Notes = "Manual Block by Admin"
 		appt.CustomerName = "Admin Block"
+		appt.Source = domain.SourceBlock
 		// Use a distinct summary for blocks
 		// The service name is already "⛔ Block: X min"
 	}
 
+	if !isAdminBlock && !isAdminManual {
+		var ok bool
+		if appt.PromoCode, appt.Discount, ok = h.reservePromo(c, service); !ok {
+			return h.askForConfirmation(c)
+		}
+	}
+
 	// Save to Google Calendar (and internal DB via adapter)
-	_, err = h.appointmentService.CreateAppointment(context.Background(), &appt)
+	created, err := h.appointmentService.CreateAppointment(holderContext(userID), &appt)
 	if err != nil {
 		logging.Infof("Error creating appointment: %v", err)
+		h.releasePromo(appt.PromoCode)
 		if strings.Contains(err.Error(), "slot is not available") {
 			return c.Send("❌ К сожалению, это время уже занято. Пожалуйста, выберите другое время.", telebot.RemoveKeyboard)
 		}
@@ -889,17 +991,11 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 		}
 	}
 
-	// 1. Notify Admin(s)
+	// 1-2. Notify the therapist concerned, or all admins and therapists when
+	// the booking is not tied to a registered therapist
 	adminMsg := h.presenter.FormatAppointment(&appt, true)
-	for _, adminIDStr := range h.adminIDs {
-		adminID, _ := strconv.ParseInt(adminIDStr, 10, 64)
-		h.BotNotify(c.Bot(), adminID, adminMsg)
-	}
-
-	// 2. Notify Therapists
-	for _, tID := range h.therapistIDs {
-		therapistID, _ := strconv.ParseInt(tID, 10, 64)
-		h.BotNotify(c.Bot(), therapistID, adminMsg)
+	for _, recipient := range h.bookingRecipients(&appt) {
+		h.BotNotify(c.Bot(), recipient, adminMsg)
 	}
 
 	// Increment booking metric
@@ -912,6 +1008,8 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 	confirmationMsg := h.presenter.FormatAppointment(&appt, false)
 	if isAdminManual {
 		confirmationMsg = "✅ <b>РУЧНАЯ ЗАПИСЬ СОЗДАНА</b>\n" + confirmationMsg
+	} else {
+		confirmationMsg = h.withCancellationPolicy(confirmationMsg)
 	}
 
 	// Add Calendar Link
@@ -926,16 +1024,29 @@ func (h *BookingHandler) HandleConfirmBooking(c telebot.Context) error {
 		)
 	}
 
+	// The calendar file needs the event ID the calendar assigned
+	if created != nil && created.ID != "" {
+		appt.ID = created.ID
+	}
+	h.redeemPromo(appt)
+
 	// 4. Notify patient if manual
 	if isAdminManual {
 		patientIDStr, ok := session[SessionKeyPatientID].(string)
 		if ok && patientIDStr != "" {
 			patientID, _ := strconv.ParseInt(patientIDStr, 10, 64)
-			h.BotNotify(c.Bot(), patientID, h.presenter.FormatAppointment(&appt, false))
+			h.BotNotify(c.Bot(), patientID, h.withCancellationPolicy(h.presenter.FormatAppointment(&appt, false)))
+			sendICS(c.Bot(), patientIDStr, &appt, presentation.ICSPublish)
 		}
 	}
 
-	return c.Send(confirmationMsg, h.GetMainMenu(), selector, telebot.ModeHTML)
+	if err := c.Send(confirmationMsg, h.GetMainMenu(), selector, telebot.ModeHTML); err != nil {
+		return err
+	}
+	if !isAdminManual {
+		sendICS(c.Bot(), appt.CustomerTgID, &appt, presentation.ICSPublish)
+	}
+	return nil
 }
 
 func (h *BookingHandler) syncPatientStats(ctx context.Context, telegramID string, name string) (domain.Patient, error) {
@@ -999,6 +1110,7 @@ func (h *BookingHandler) HandleCancel(c telebot.Context) error {
 	h.sessionStorage.Set(userID, SessionKeyAwaitingConfirmation, false)
 	logging.Debugf(": Cleared SessionKeyAwaitingConfirmation for user %d (via cancel).", userID)
 
+	h.releaseSlotHold(userID)
 	h.sessionStorage.ClearSession(userID)
 	// Remove keyboard and send confirmation
 	return c.Send("Запись отменена. Сессия очищена. Вы можете начать /start снова.", h.GetMainMenu())
@@ -1017,7 +1129,14 @@ func (h *BookingHandler) HandleMyRecords(c telebot.Context) error {
 Запишитесь на прием через меню бота!`, telebot.ModeHTML)
 	}
 
+	patient.NoShows = h.noShowCount(telegramID)
 	card := h.presenter.FormatPatientCard(patient)
+	if balance := h.packageBalance(telegramID); balance != "" {
+		card += "\n\n" + balance
+	}
+	if owed := h.outstandingBalance(telegramID); owed != "" {
+		card += "\n\n" + owed
+	}
 
 	// Compact menu for record management
 	selector := &telebot.ReplyMarkup{}
@@ -1068,6 +1187,7 @@ func (h *BookingHandler) HandleMyAppointments(c telebot.Context) error {
 	selector := &telebot.ReplyMarkup{}
 	var rows []telebot.Row
 	hasLateAppts := false
+	policy := h.appointmentService.GetCancellationPolicy()
 
 	// Sort by time for display
 	sort.Slice(appts, func(i, j int) bool {
@@ -1089,16 +1209,19 @@ func (h *BookingHandler) HandleMyAppointments(c telebot.Context) error {
 			appt.Service.Name,
 			patientInfo)
 
-		// Smart Cancellation Logic: Only show Cancel button if more than 72 hours (3 days) remain
-		// OR if the user is an admin (admins can always cancel)
-		now := time.Now().In(domain.ApptTimeZone)
-		timeRemaining := appt.StartTime.Sub(now)
-
-		if isAdmin || timeRemaining > 72*time.Hour {
-			btn := selector.Data(fmt.Sprintf("❌ Отменить %s (%s)", apptTime.Format("02.01"), apptTime.Format("15:04")), "cancel_appt", appt.ID)
+		// Smart Cancellation Logic: inside the policy's notice window patients
+		// may only cancel (with a warning) or not at all; admins always can
+		btn := selector.Data(fmt.Sprintf("❌ Отменить %s (%s)", apptTime.Format("02.01"), apptTime.Format("15:04")), "cancel_appt", appt.ID)
+		switch {
+		case isAdmin || !policy.IsLate(appt.StartTime, time.Now()):
+			btnMove := selector.Data("🔄 Перенести", "reschedule_appt", appt.ID)
+			rows = append(rows, selector.Row(btn, btnMove))
+		case !policy.BlockLate:
 			rows = append(rows, selector.Row(btn))
-		} else {
-			message += "⚠️ <i>Отмена только через терапевта</i>\n"
+			message += "⚠️ <i>Поздняя отмена; перенос только через терапевта</i>\n"
+			hasLateAppts = true
+		default:
+			message += "⚠️ <i>Отмена и перенос только через терапевта</i>\n"
 			hasLateAppts = true
 		}
 		message += "\n"
@@ -1127,18 +1250,20 @@ func (h *BookingHandler) HandleCancelAppointmentCallback(c telebot.Context) erro
 	// Get appointment details BEFORE deleting for block check
 	appt, _ := h.appointmentService.FindByID(context.Background(), appointmentID)
 
-	if appt != nil {
-		now := time.Now().In(domain.ApptTimeZone)
-		if appt.StartTime.Sub(now) < 72*time.Hour {
-			logging.Infof("BLOCKED: Late cancellation attempt for user %s, appt %s", appt.CustomerTgID, appt.ID)
-			return c.Respond(&telebot.CallbackResponse{
-				Text:      "⛔ До записи меньше 3 дней!\nАвтоматическая отмена невозможна.\nПожалуйста, напишите терапевту напрямую.",
-				ShowAlert: true,
-			})
+	late, handled, err := h.checkLateCancel(c, appt, parts)
+	if handled {
+		return err
+	}
+
+	// Part of a course: ask whether to cancel only this session or the rest,
+	// unless the answer ("cancel_appt|id|one") is already in the callback
+	if appt != nil && !hasCallbackFlag(parts, "one") {
+		if series, err := h.appointmentService.GetAppointmentSeries(context.Background(), appointmentID); err == nil {
+			return h.askSeriesCancelScope(c, appt, series, late)
 		}
 	}
 
-	err := h.appointmentService.CancelAppointment(context.Background(), appointmentID)
+	err = h.appointmentService.CancelAppointment(context.Background(), appointmentID)
 	if err != nil {
 		logging.Errorf(": Failed to cancel appointment %s: %v", appointmentID, err)
 		return c.Respond(&telebot.CallbackResponse{Text: "Не удалось отменить запись. Возможно, она уже отменена."})
@@ -1146,9 +1271,16 @@ func (h *BookingHandler) HandleCancelAppointmentCallback(c telebot.Context) erro
 
 	// Notify admin
 	if appt != nil {
+		status := h.cancelStatusFor(c.Sender().ID, late)
+		h.recordStatus(context.Background(), appt, status)
+		sendICS(c.Bot(), appt.CustomerTgID, appt, presentation.ICSCancel)
+		adminMsg := h.presenter.FormatCancellation(appt, true)
+		if status == domain.StatusLateCancel {
+			adminMsg = "⏰ <b>ПОЗДНЯЯ ОТМЕНА</b>\n" + adminMsg
+		}
 		for _, adminIDStr := range h.adminIDs {
 			adminID, _ := strconv.ParseInt(adminIDStr, 10, 64)
-			h.BotNotify(c.Bot(), adminID, h.presenter.FormatCancellation(appt, true))
+			h.BotNotify(c.Bot(), adminID, adminMsg)
 		}
 
 		// Robust sync after cancellation
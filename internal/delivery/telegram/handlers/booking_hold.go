@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// A picked time is held for the user while they type their name and
+// confirm, so nobody else is offered it meanwhile. The hold goes away on
+// booking, on cancel or restart, or when it expires.
+
+// holderContext marks calls made on behalf of userID, so their own hold is
+// neither hidden from them nor a conflict for their booking.
+func holderContext(userID int64) context.Context {
+	return domain.WithSlotHolder(context.Background(), strconv.FormatInt(userID, 10))
+}
+
+// holdPickedSlot holds the time the user just picked on the session's date.
+// It reports false when the slot turned out to be taken; failures to store
+// the hold are only logged, the booking re-checks the slot anyway.
+func (h *BookingHandler) holdPickedSlot(userID int64, timeStr string) bool {
+	if h.appointmentService == nil {
+		return true
+	}
+	session := h.sessionStorage.Get(userID)
+	service, okS := session[SessionKeyService].(domain.Service)
+	date, okD := session[SessionKeyDate].(time.Time)
+	if !okS || !okD || service.DurationMinutes <= 0 {
+		return true
+	}
+	loc := domain.ApptTimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+	start, err := time.ParseInLocation("2006-01-02 15:04", fmt.Sprintf("%s %s", date.Format("2006-01-02"), timeStr), loc)
+	if err != nil {
+		return true
+	}
+
+	err = h.appointmentService.HoldSlot(context.Background(), strconv.FormatInt(userID, 10), h.sessionTherapist(userID), start, service.DurationMinutes)
+	if errors.Is(err, domain.ErrSlotUnavailable) {
+		logging.Infof("Slot %s picked by user %d is already taken", start.Format("2006-01-02 15:04"), userID)
+		return false
+	}
+	if err != nil {
+		logging.Warnf("Failed to hold slot %s for user %d: %v", start.Format("2006-01-02 15:04"), userID, err)
+	}
+	return true
+}
+
+// releaseSlotHold frees the user's held slot, if any.
+func (h *BookingHandler) releaseSlotHold(userID int64) {
+	if h.appointmentService == nil {
+		return
+	}
+	if err := h.appointmentService.ReleaseSlotHold(context.Background(), strconv.FormatInt(userID, 10)); err != nil {
+		logging.Warnf("Failed to release slot hold of user %d: %v", userID, err)
+	}
+}
+
+// slotTakenResponse tells the user their pick was taken and lists the
+// remaining times again.
+func (h *BookingHandler) slotTakenResponse(c telebot.Context) error {
+	if err := c.Respond(&telebot.CallbackResponse{
+		Text:      "⏳ Это время только что заняли. Пожалуйста, выберите другое.",
+		ShowAlert: true,
+	}); err != nil {
+		logging.Warnf("Failed to respond to callback: %v", err)
+	}
+	return h.askForTime(c)
+}
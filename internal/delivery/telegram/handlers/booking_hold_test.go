@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func TestHandleTimeSelection_HoldsSlot(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(123)
+	date := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+
+	newSession := func() *mockSessionStorage {
+		s := newMockSessionStorage()
+		s.Set(userID, SessionKeyService, domain.Service{ID: "s1", Name: "Massage", DurationMinutes: 60})
+		s.Set(userID, SessionKeyDate, date)
+		return s
+	}
+
+	t.Run("holds the picked time", func(t *testing.T) {
+		var holder string
+		var held time.Time
+		mock := &mockAppointmentService{
+			holdSlotFunc: func(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+				holder, held = holderID, start
+				return nil
+			},
+		}
+		session := newSession()
+		repo := newMockRepository()
+		h := NewBookingHandler(mock, session, nil, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+		ctx := &mockContext{sender: &telebot.User{ID: userID}, callback: &telebot.Callback{Data: "select_time|14:00"}}
+
+		if err := h.HandleTimeSelection(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if holder != "123" || !held.Equal(date.Add(14*time.Hour)) {
+			t.Errorf("expected a hold for 123 at 14:00, got %q at %v", holder, held)
+		}
+		if session.Get(userID)[SessionKeyTime] != "14:00" {
+			t.Error("time should be stored once held")
+		}
+	})
+
+	t.Run("taken slot", func(t *testing.T) {
+		var listedFor string
+		mock := &mockAppointmentService{
+			holdSlotFunc: func(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+				return domain.ErrSlotUnavailable
+			},
+			getAvailableTimeSlotsFunc: func(ctx context.Context, date time.Time, dur int) ([]domain.TimeSlot, error) {
+				listedFor = domain.SlotHolderFrom(ctx)
+				start := date.Add(15 * time.Hour)
+				return []domain.TimeSlot{{Start: start, End: start.Add(time.Hour)}}, nil
+			},
+		}
+		session := newSession()
+		bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+		h := NewBookingHandler(mock, session, nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+		ctx := &mockContext{
+			sender:   &telebot.User{ID: userID},
+			callback: &telebot.Callback{Data: "select_time|14:00"},
+			message:  &telebot.Message{ID: 1, Chat: &telebot.Chat{ID: userID}},
+			bot:      bot,
+		}
+
+		if err := h.HandleTimeSelection(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if ctx.response == nil || !ctx.response.ShowAlert || !contains(ctx.response.Text, "заняли") {
+			t.Errorf("expected a slot-taken alert, got %+v", ctx.response)
+		}
+		if _, ok := session.Get(userID)[SessionKeyTime]; ok {
+			t.Error("a taken time must not be stored")
+		}
+		if listedFor != "123" {
+			t.Errorf("remaining times should be listed for the user, got holder %q", listedFor)
+		}
+	})
+}
+
+func TestHandleCancel_ReleasesHold(t *testing.T) {
+	var released string
+	mock := &mockAppointmentService{
+		releaseSlotHoldFunc: func(ctx context.Context, holderID string) error {
+			released = holderID
+			return nil
+		},
+	}
+	h := NewBookingHandler(mock, newMockSessionStorage(), nil, nil, nil, nil, &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: 123}}
+
+	if err := h.HandleCancel(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if released != "123" {
+		t.Errorf("expected the hold of 123 to be released, got %q", released)
+	}
+}
+
+func TestHandleConfirmBooking_BooksAsHolder(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(123)
+	session := newMockSessionStorage()
+	session.Set(userID, SessionKeyService, domain.Service{ID: "s1", Name: "Massage", DurationMinutes: 60})
+	session.Set(userID, SessionKeyDate, time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC))
+	session.Set(userID, SessionKeyTime, "14:00")
+	session.Set(userID, SessionKeyName, "Иван")
+
+	var holder string
+	mock := &mockAppointmentService{
+		createAppointmentFunc: func(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+			holder = domain.SlotHolderFrom(ctx)
+			return appt, nil
+		},
+	}
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	h := NewBookingHandler(mock, session, nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: userID}, bot: bot}
+
+	_ = h.HandleConfirmBooking(ctx)
+	if holder != "123" {
+		t.Errorf("booking should be made as the slot holder, got %q", holder)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// nextAvailableLimit is how many of the earliest times "Ближайшее время"
+// offers.
+const nextAvailableLimit = 6
+
+// dateKeyboard is the month picker for the user's booking, marked with the
+// chosen service's availability, with the "Ближайшее время" shortcut above
+// the back button. The shortcut searches any therapist, so it is left out
+// once a specific one was chosen, and for admin blocks, which are not
+// catalog services.
+func (h *BookingHandler) dateKeyboard(userID int64, month time.Time) *telebot.ReplyMarkup {
+	therapistID := h.sessionTherapist(userID)
+	service, ok := h.sessionStorage.Get(userID)[SessionKeyService].(domain.Service)
+	markup := h.generateCalendar(month, therapistID, service.DurationMinutes)
+
+	if !ok || therapistID != "" || strings.HasPrefix(service.ID, "block_") {
+		return markup
+	}
+	selector := &telebot.ReplyMarkup{}
+	next := selector.Data("⚡ Ближайшее время", "next_available")
+	rows := markup.InlineKeyboard
+	back := rows[len(rows)-1]
+	markup.InlineKeyboard = append(rows[:len(rows)-1], []telebot.InlineButton{*next.Inline()}, back)
+	return markup
+}
+
+// HandleNextAvailable lists the earliest free times for the chosen service
+// across the coming days.
+func (h *BookingHandler) HandleNextAvailable(c telebot.Context) error {
+	userID := c.Sender().ID
+	service, ok := h.sessionStorage.Get(userID)[SessionKeyService].(domain.Service)
+	if !ok {
+		return h.showCategories(c)
+	}
+
+	slots, err := h.appointmentService.FindNextAvailable(holderContext(userID), service.ID, time.Now(), 0, nextAvailableLimit)
+	if err != nil {
+		logging.Errorf(": Failed to find next available slots for user %d: %v", userID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Не удалось подобрать время. Пожалуйста, выберите дату в календаре.", ShowAlert: true})
+	}
+
+	selector := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, slot := range slots {
+		start := slot.Start.In(domain.ApptTimeZone)
+		label := fmt.Sprintf("%s %s %s", weekdayShortNames[start.Weekday()], start.Format("02.01"), start.Format("15:04"))
+		rows = append(rows, selector.Row(selector.Data(label, "next_slot", start.Format("2006-01-02"), start.Format("15:04"))))
+	}
+	rows = append(rows, selector.Row(selector.Data("⬅️ Назад к выбору даты", "back_to_date")))
+	selector.Inline(rows...)
+
+	if len(slots) == 0 {
+		return c.Edit("В ближайший месяц свободного времени нет. Пожалуйста, выберите дату позже.", selector)
+	}
+	return c.Edit(fmt.Sprintf("⚡ Ближайшее свободное время для '%s':", service.Name), selector)
+}
+
+// HandleNextSlotSelection books on from a time picked in the
+// "Ближайшее время" list ("next_slot|2006-01-02|15:04") as if the date and
+// time had been picked one after the other.
+func (h *BookingHandler) HandleNextSlotSelection(c telebot.Context) error {
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) != 3 {
+		logging.Errorf(": Malformed next slot callback data: %s", c.Callback().Data)
+		return c.Edit("Некорректный выбор времени. Пожалуйста, попробуйте /start снова.")
+	}
+	date, err := time.Parse("2006-01-02", parts[1])
+	if err != nil {
+		logging.Errorf(": Invalid date in next slot selection: %s, error: %v", parts[1], err)
+		return c.Edit("Некорректная дата. Попробуйте /start снова.")
+	}
+
+	h.sessionStorage.Set(c.Sender().ID, SessionKeyDate, date)
+	c.Callback().Data = "select_time|" + parts[2]
+	return h.HandleTimeSelection(c)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func hasButton(markup *telebot.ReplyMarkup, unique string) bool {
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.Unique == unique {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestDateKeyboard_NextAvailableButton(t *testing.T) {
+	month := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	domain.ApptTimeZone = time.UTC
+
+	tests := []struct {
+		name      string
+		service   domain.Service
+		therapist string
+		want      bool
+	}{
+		{"catalog service", domain.Service{ID: "2", Name: "Massage", DurationMinutes: 60}, "", true},
+		{"chosen therapist", domain.Service{ID: "2", Name: "Massage", DurationMinutes: 60}, "anna", false},
+		{"admin block", domain.Service{ID: "block_60", DurationMinutes: 60}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := newMockSessionStorage()
+			session.Set(123, SessionKeyService, tt.service)
+			if tt.therapist != "" {
+				session.Set(123, SessionKeyTherapist, tt.therapist)
+			}
+			h := NewBookingHandler(&mockAppointmentService{}, session, nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+
+			markup := h.dateKeyboard(123, month)
+			if got := hasButton(markup, "next_available"); got != tt.want {
+				t.Errorf("next_available button shown = %v, want %v", got, tt.want)
+			}
+			last := markup.InlineKeyboard[len(markup.InlineKeyboard)-1]
+			if last[0].Unique != "back_to_services" {
+				t.Errorf("the back button must stay last, got %q", last[0].Unique)
+			}
+		})
+	}
+}
+
+func TestHandleNextAvailable(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	session := newMockSessionStorage()
+	session.Set(123, SessionKeyService, domain.Service{ID: "2", Name: "Massage", DurationMinutes: 60})
+	start := time.Date(2030, 1, 9, 14, 0, 0, 0, time.UTC)
+
+	var gotService, gotHolder string
+	var gotLimit int
+	mock := &mockAppointmentService{
+		findNextAvailableFunc: func(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+			gotService, gotHolder, gotLimit = serviceID, domain.SlotHolderFrom(ctx), limit
+			return []domain.TimeSlot{{Start: start, End: start.Add(time.Hour)}}, nil
+		},
+	}
+	h := NewBookingHandler(mock, session, nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: 123}, callback: &telebot.Callback{Data: "next_available"}}
+
+	if err := h.HandleNextAvailable(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gotService != "2" || gotHolder != "123" || gotLimit != nextAvailableLimit {
+		t.Errorf("unexpected search: service %q, holder %q, limit %d", gotService, gotHolder, gotLimit)
+	}
+	if msg, _ := ctx.editedMsg.(string); !contains(msg, "Ближайшее свободное время") {
+		t.Errorf("unexpected message: %v", ctx.editedMsg)
+	}
+	markup, _ := ctx.editedOpts[0].(*telebot.ReplyMarkup)
+	if markup == nil || markup.InlineKeyboard[0][0].Text != "Ср 09.01 14:00" || markup.InlineKeyboard[0][0].Data != "2030-01-09|14:00" {
+		t.Errorf("unexpected slot buttons: %+v", markup)
+	}
+}
+
+func TestHandleNextSlotSelection(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	session := newMockSessionStorage()
+	session.Set(123, SessionKeyService, domain.Service{ID: "2", Name: "Massage", DurationMinutes: 60})
+
+	var held time.Time
+	mock := &mockAppointmentService{
+		holdSlotFunc: func(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+			held = start
+			return nil
+		},
+	}
+	h := NewBookingHandler(mock, session, nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: 123}, callback: &telebot.Callback{Data: "next_slot|2030-01-09|14:00"}}
+
+	if err := h.HandleNextSlotSelection(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if date, _ := session.Get(123)[SessionKeyDate].(time.Time); date.Format("2006-01-02") != "2030-01-09" {
+		t.Errorf("date not stored, got %v", date)
+	}
+	if session.Get(123)[SessionKeyTime] != "14:00" {
+		t.Errorf("time not stored, got %v", session.Get(123)[SessionKeyTime])
+	}
+	if !held.Equal(time.Date(2030, 1, 9, 14, 0, 0, 0, time.UTC)) {
+		t.Errorf("picked time should be held, got %v", held)
+	}
+}
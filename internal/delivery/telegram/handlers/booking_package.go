@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// Prepaid session packages are sold by an admin:
+//
+//	/package_add {telegram_id} {сеансов} {цена} {дней} [id услуг через запятую]
+//
+// Completed visits are charged by the package service; patients see the
+// balance in /myrecords.
+const packageUsage = "Использование: /package_add {telegram_id} {сеансов} {цена} {дней} [id услуг через запятую]\nПример: /package_add 123456789 10 9000 90 classic,sport"
+
+// SetPackages enables session packages; without it /myrecords shows no
+// balance and the package commands report that packages are off.
+func (h *BookingHandler) SetPackages(p ports.PackageService) {
+	h.packages = p
+}
+
+// HandleAddPackage sells a package to a patient and notifies them.
+func (h *BookingHandler) HandleAddPackage(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.packages == nil {
+		return c.Send("❌ Абонементы не подключены к базе данных.")
+	}
+
+	args := c.Args()
+	if len(args) != 4 && len(args) != 5 {
+		return c.Send(packageUsage)
+	}
+	patientID := args[0]
+	patient, err := h.repository.GetPatient(patientID)
+	if err != nil || patient.Name == "" {
+		return c.Send(fmt.Sprintf("❌ Пациент %s не найден. Список: /patients", patientID))
+	}
+	sessions, err := strconv.Atoi(args[1])
+	if err != nil || sessions <= 0 {
+		return c.Send(fmt.Sprintf("❌ Неверное число сеансов: %s\n%s", args[1], packageUsage))
+	}
+	price, err := strconv.ParseFloat(args[2], 64)
+	if err != nil || price < 0 {
+		return c.Send(fmt.Sprintf("❌ Неверная цена: %s\n%s", args[2], packageUsage))
+	}
+	days, err := strconv.Atoi(args[3])
+	if err != nil || days <= 0 {
+		return c.Send(fmt.Sprintf("❌ Неверный срок в днях: %s\n%s", args[3], packageUsage))
+	}
+
+	title := "Все услуги"
+	var serviceIDs []string
+	if len(args) == 5 {
+		var names []string
+		for _, id := range strings.Split(args[4], ",") {
+			service := h.findCatalogService(strings.TrimSpace(id))
+			if service == nil {
+				return c.Send(fmt.Sprintf("❌ Услуга %s не найдена. Список: /services", id))
+			}
+			serviceIDs = append(serviceIDs, service.ID)
+			names = append(names, service.Name)
+		}
+		title = strings.Join(names, ", ")
+	}
+
+	now := time.Now().In(domain.ApptTimeZone)
+	pkg, err := h.packages.Sell(context.Background(), domain.SessionPackage{
+		PatientID:     patientID,
+		PatientName:   patient.Name,
+		Title:         title,
+		ServiceIDs:    serviceIDs,
+		SessionsTotal: sessions,
+		Price:         price,
+		PurchasedAt:   now,
+		ExpiresAt:     time.Date(now.Year(), now.Month(), now.Day()+days, 23, 59, 0, 0, domain.ApptTimeZone),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPackage) {
+			return c.Send("❌ Неверные параметры абонемента.\n" + packageUsage)
+		}
+		logging.Errorf(": Failed to sell package to %s: %v", patientID, err)
+		return c.Send("❌ Не удалось оформить абонемент. Пожалуйста, попробуйте позже.")
+	}
+	logging.Infof("[ADMIN] Package %d sold by %d to patient %s", pkg.ID, c.Sender().ID, patientID)
+
+	if id, err := strconv.ParseInt(patientID, 10, 64); err == nil {
+		h.BotNotify(c.Bot(), id, h.presenter.FormatPackageSold(pkg, false))
+	}
+	return c.Send(h.presenter.FormatPackageSold(pkg, true), telebot.ModeHTML)
+}
+
+// HandleListPackages shows every package of a patient to an admin.
+func (h *BookingHandler) HandleListPackages(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.packages == nil {
+		return c.Send("❌ Абонементы не подключены к базе данных.")
+	}
+	args := c.Args()
+	if len(args) != 1 {
+		return c.Send("Использование: /packages {telegram_id}")
+	}
+
+	pkgs, err := h.packages.ListForPatient(context.Background(), args[0])
+	if err != nil {
+		logging.Errorf(": Failed to list packages of %s: %v", args[0], err)
+		return c.Send("❌ Не удалось загрузить абонементы. Пожалуйста, попробуйте позже.")
+	}
+	if len(pkgs) == 0 {
+		return c.Send(fmt.Sprintf("У пациента %s нет абонементов. Оформить: /package_add", args[0]))
+	}
+	localizePackages(pkgs)
+	return c.Send(h.presenter.FormatPackageBalance(pkgs, time.Now()), telebot.ModeHTML)
+}
+
+// packageBalance formats the patient's active packages for /myrecords, or
+// returns "" when there are none.
+func (h *BookingHandler) packageBalance(patientID string) string {
+	if h.packages == nil {
+		return ""
+	}
+	pkgs, err := h.packages.ListForPatient(context.Background(), patientID)
+	if err != nil {
+		logging.Warnf("Failed to load packages of %s: %v", patientID, err)
+		return ""
+	}
+	now := time.Now()
+	var active []domain.SessionPackage
+	for _, p := range pkgs {
+		if p.ActiveAt(now) {
+			active = append(active, p)
+		}
+	}
+	if len(active) == 0 {
+		return ""
+	}
+	localizePackages(active)
+	return h.presenter.FormatPackageBalance(active, now)
+}
+
+func localizePackages(pkgs []domain.SessionPackage) {
+	for i := range pkgs {
+		pkgs[i].ExpiresAt = pkgs[i].ExpiresAt.In(domain.ApptTimeZone)
+	}
+}
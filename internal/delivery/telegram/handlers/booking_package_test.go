@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// mockPackageService records packages sold by the package handlers.
+type mockPackageService struct {
+	sold     []domain.SessionPackage
+	packages []domain.SessionPackage
+}
+
+func (m *mockPackageService) Sell(ctx context.Context, pkg domain.SessionPackage) (*domain.SessionPackage, error) {
+	m.sold = append(m.sold, pkg)
+	pkg.ID = int64(len(m.sold))
+	return &pkg, nil
+}
+func (m *mockPackageService) ListForPatient(ctx context.Context, patientID string) ([]domain.SessionPackage, error) {
+	return m.packages, nil
+}
+
+func newPackageTestHandler(p *mockPackageService) *BookingHandler {
+	repo := newMockRepository()
+	repo.patients["100"] = domain.Patient{TelegramID: "100", Name: "Иван Петров"}
+	mock := &mockAppointmentService{getAllServicesFunc: func(ctx context.Context) ([]domain.Service, error) {
+		return []domain.Service{{ID: "classic", Name: "Классический массаж"}, {ID: "sport", Name: "Спортивный массаж"}}, nil
+	}}
+	h := NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+	h.SetPackages(p)
+	return h
+}
+
+func TestHandleAddPackage(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	t.Run("sells a scoped package", func(t *testing.T) {
+		p := &mockPackageService{}
+		h := newPackageTestHandler(p)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"100", "10", "9000", "90", "classic,sport"}, bot: bot}
+
+		if err := h.HandleAddPackage(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if len(p.sold) != 1 {
+			t.Fatalf("expected one package sold, got %d", len(p.sold))
+		}
+		got := p.sold[0]
+		if got.PatientID != "100" || got.PatientName != "Иван Петров" || got.SessionsTotal != 10 || got.Price != 9000 {
+			t.Errorf("unexpected package: %+v", got)
+		}
+		if len(got.ServiceIDs) != 2 || got.Title != "Классический массаж, Спортивный массаж" {
+			t.Errorf("unexpected scope: %v %q", got.ServiceIDs, got.Title)
+		}
+		if days := got.ExpiresAt.Sub(got.PurchasedAt).Hours() / 24; days < 89 || days > 91 {
+			t.Errorf("package valid for %.1f days, want 90", days)
+		}
+		if !contains(ctx.sentMsg, "АБОНЕМЕНТ ОФОРМЛЕН") {
+			t.Errorf("expected the confirmation, got %q", ctx.sentMsg)
+		}
+	})
+
+	tests := []struct {
+		name string
+		user int64
+		args []string
+		want string
+	}{
+		{"not admin", 100, []string{"100", "10", "9000", "90"}, "Доступ запрещен"},
+		{"missing args", 999, []string{"100", "10"}, "Использование"},
+		{"unknown patient", 999, []string{"555", "10", "9000", "90"}, "не найден"},
+		{"bad sessions", 999, []string{"100", "0", "9000", "90"}, "Неверное число сеансов"},
+		{"unknown service", 999, []string{"100", "10", "9000", "90", "yoga"}, "Услуга yoga не найдена"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockPackageService{}
+			h := newPackageTestHandler(p)
+			ctx := &mockContext{sender: &telebot.User{ID: tt.user}, args: tt.args, bot: bot}
+
+			if err := h.HandleAddPackage(ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if !contains(ctx.sentMsg, tt.want) {
+				t.Errorf("expected %q, got %q", tt.want, ctx.sentMsg)
+			}
+			if len(p.sold) != 0 {
+				t.Errorf("expected nothing sold, got %+v", p.sold)
+			}
+		})
+	}
+}
+
+func TestHandleMyRecords_ShowsPackageBalance(t *testing.T) {
+	now := time.Now()
+	p := &mockPackageService{packages: []domain.SessionPackage{
+		{Title: "Классический массаж", SessionsTotal: 10, SessionsUsed: 3, ExpiresAt: now.AddDate(0, 1, 0)},
+		{Title: "Старый абонемент", SessionsTotal: 5, SessionsUsed: 5, ExpiresAt: now.AddDate(0, 1, 0)},
+	}}
+	h := newPackageTestHandler(p)
+	ctx := &mockContext{sender: &telebot.User{ID: 100}}
+
+	if err := h.HandleMyRecords(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "осталось <b>7 из 10</b>") {
+		t.Errorf("expected the active balance, got %q", ctx.sentMsg)
+	}
+	if contains(ctx.sentMsg, "Старый абонемент") {
+		t.Errorf("used-up packages should be hidden, got %q", ctx.sentMsg)
+	}
+}
+
+func TestHandleListPackages(t *testing.T) {
+	p := &mockPackageService{packages: []domain.SessionPackage{
+		{Title: "Старый абонемент", SessionsTotal: 5, SessionsUsed: 5, ExpiresAt: time.Now().AddDate(0, 1, 0)},
+	}}
+	h := newPackageTestHandler(p)
+
+	ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"100"}}
+	if err := h.HandleListPackages(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "Старый абонемент") || !contains(ctx.sentMsg, "использован") {
+		t.Errorf("expected every package listed, got %q", ctx.sentMsg)
+	}
+
+	ctx = &mockContext{sender: &telebot.User{ID: 100}, args: []string{"100"}}
+	if err := h.HandleListPackages(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "Доступ запрещен") {
+		t.Errorf("expected access denied for patients, got %q", ctx.sentMsg)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// Admins record visit payments and read the income:
+//
+//	/paid {appointment_id|telegram_id} {нал|карта|перевод|абонемент} [сумма] [скидка]
+//	/unpaid [telegram_id]
+//	/revenue [неделя|месяц|год]
+//
+// A Telegram ID stands for the patient's latest unpaid visit. Without a sum
+// the visit is paid at its price less the discount, which defaults to the
+// promo discount given at booking.
+const paymentUsage = "Использование: /paid {id записи или telegram_id} {нал|карта|перевод|абонемент} [сумма] [скидка]\nПример: /paid 123456789 карта 1800 200"
+
+// SetPayments enables payment recording; without it the payment commands
+// report that payments are off and cards show no balance.
+func (h *BookingHandler) SetPayments(p ports.PaymentService) {
+	h.payments = p
+}
+
+// HandlePaid marks a visit as paid.
+func (h *BookingHandler) HandlePaid(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.payments == nil {
+		return c.Send("❌ Учёт оплат не подключён к базе данных.")
+	}
+
+	args := c.Args()
+	if len(args) < 2 || len(args) > 4 {
+		return c.Send(paymentUsage)
+	}
+	method, ok := domain.ParsePaymentMethod(args[1])
+	if !ok {
+		return c.Send(fmt.Sprintf("❌ Неизвестный способ оплаты: %s\n%s", args[1], paymentUsage))
+	}
+	var amount, discount float64
+	var err error
+	if len(args) >= 3 {
+		if amount, err = strconv.ParseFloat(args[2], 64); err != nil || amount < 0 {
+			return c.Send(fmt.Sprintf("❌ Неверная сумма: %s\n%s", args[2], paymentUsage))
+		}
+	}
+	discountGiven := len(args) == 4
+	if discountGiven {
+		if discount, err = strconv.ParseFloat(args[3], 64); err != nil || discount < 0 {
+			return c.Send(fmt.Sprintf("❌ Неверная скидка: %s\n%s", args[3], paymentUsage))
+		}
+	}
+
+	ctx := context.Background()
+	visit, err := h.payments.FindVisit(ctx, args[0])
+	if err != nil {
+		if errors.Is(err, domain.ErrVisitNotFound) {
+			return c.Send(fmt.Sprintf("❌ Визит %s не найден. Неоплаченные визиты: /unpaid", args[0]))
+		}
+		logging.Errorf(": Failed to find visit %s for payment: %v", args[0], err)
+		return c.Send("❌ Не удалось найти визит. Пожалуйста, попробуйте позже.")
+	}
+
+	if !discountGiven {
+		discount = visit.Discount // Promo code applied at booking
+	}
+	serviceID := visit.ServiceID
+	if serviceID == "" {
+		serviceID = visit.Service.ID
+	}
+	payment, err := h.payments.RecordPayment(ctx, domain.Payment{
+		AppointmentID: visit.ID,
+		PatientID:     visit.CustomerTgID,
+		PatientName:   visit.CustomerName,
+		ServiceID:     serviceID,
+		ServiceName:   visit.Service.Name,
+		ListPrice:     visit.Service.Price,
+		Discount:      discount,
+		Amount:        amount,
+		Method:        method,
+		VisitTime:     visit.StartTime,
+		RecordedBy:    strconv.FormatInt(c.Sender().ID, 10),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidPayment) {
+			return c.Send("❌ Неверные параметры оплаты.\n" + paymentUsage)
+		}
+		logging.Errorf(": Failed to record payment for %s: %v", visit.ID, err)
+		return c.Send("❌ Не удалось записать оплату. Пожалуйста, попробуйте позже.")
+	}
+	logging.Infof("[ADMIN] Payment for appointment %s recorded by %d", visit.ID, c.Sender().ID)
+
+	payment.VisitTime = payment.VisitTime.In(domain.ApptTimeZone)
+	return c.Send(h.presenter.FormatPaymentRecorded(payment), telebot.ModeHTML)
+}
+
+// HandleUnpaid lists the recent visits still to be paid, of one patient or
+// of everyone.
+func (h *BookingHandler) HandleUnpaid(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.payments == nil {
+		return c.Send("❌ Учёт оплат не подключён к базе данных.")
+	}
+	args := c.Args()
+	if len(args) > 1 {
+		return c.Send("Использование: /unpaid [telegram_id]")
+	}
+	var patientID string
+	if len(args) == 1 {
+		patientID = args[0]
+	}
+
+	visits, err := h.payments.UnpaidVisits(context.Background(), patientID)
+	if err != nil {
+		logging.Errorf(": Failed to list unpaid visits of %q: %v", patientID, err)
+		return c.Send("❌ Не удалось загрузить визиты. Пожалуйста, попробуйте позже.")
+	}
+	for i := range visits {
+		visits[i].StartTime = visits[i].StartTime.In(domain.ApptTimeZone)
+	}
+	return c.Send(h.presenter.FormatUnpaidVisits(visits, h.payments.Currency()), telebot.ModeHTML)
+}
+
+// HandleRevenue reports the income of the current week by day, month by
+// week (the default) or year by month.
+func (h *BookingHandler) HandleRevenue(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.payments == nil {
+		return c.Send("❌ Учёт оплат не подключён к базе данных.")
+	}
+
+	now := time.Now().In(domain.ApptTimeZone)
+	var from time.Time
+	period := domain.RevenueWeek
+	span := "месяц"
+	if args := c.Args(); len(args) > 0 {
+		span = strings.ToLower(args[0])
+	}
+	switch span {
+	case "неделя", "week":
+		from, period = domain.RevenueWeek.Start(now), domain.RevenueDay
+	case "месяц", "month":
+		from = domain.RevenueMonth.Start(now)
+	case "год", "year":
+		from, period = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, domain.ApptTimeZone), domain.RevenueMonth
+	default:
+		return c.Send("Использование: /revenue [неделя|месяц|год]")
+	}
+
+	report, err := h.payments.Revenue(context.Background(), from, now, period)
+	if err != nil {
+		logging.Errorf(": Failed to build revenue report: %v", err)
+		return c.Send("❌ Не удалось построить отчёт. Пожалуйста, попробуйте позже.")
+	}
+	return c.Send(h.presenter.FormatRevenueReport(report), telebot.ModeHTML)
+}
+
+// outstandingBalance formats the patient's unpaid total for /myrecords, or
+// returns "" when nothing is owed.
+func (h *BookingHandler) outstandingBalance(patientID string) string {
+	if h.payments == nil {
+		return ""
+	}
+	balance, err := h.payments.OutstandingBalance(context.Background(), patientID)
+	if err != nil {
+		logging.Warnf("Failed to load balance of %s: %v", patientID, err)
+		return ""
+	}
+	if balance <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("💳 <b>К оплате:</b> %s", presentation.FormatMoney(balance, h.payments.Currency()))
+}
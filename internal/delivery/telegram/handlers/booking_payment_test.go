@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// mockPaymentService records payments and serves a fixed set of unpaid visits.
+type mockPaymentService struct {
+	recorded []domain.Payment
+	unpaid   []domain.Appointment
+	report   *domain.RevenueReport
+	from     time.Time
+	period   domain.RevenuePeriod
+	err      error
+}
+
+func (m *mockPaymentService) RecordPayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	if p.Amount == 0 && p.Method != domain.PaymentPackage {
+		p.Amount = p.ListPrice - p.Discount
+	}
+	p.Currency = m.Currency()
+	m.recorded = append(m.recorded, p)
+	return &p, nil
+}
+func (m *mockPaymentService) UnpaidVisits(ctx context.Context, patientID string) ([]domain.Appointment, error) {
+	var out []domain.Appointment
+	for _, v := range m.unpaid {
+		if patientID == "" || v.CustomerTgID == patientID {
+			out = append(out, v)
+		}
+	}
+	return out, m.err
+}
+func (m *mockPaymentService) FindVisit(ctx context.Context, ref string) (*domain.Appointment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := len(m.unpaid) - 1; i >= 0; i-- {
+		if v := m.unpaid[i]; v.ID == ref || v.CustomerTgID == ref {
+			return &v, nil
+		}
+	}
+	return nil, domain.ErrVisitNotFound
+}
+func (m *mockPaymentService) OutstandingBalance(ctx context.Context, patientID string) (float64, error) {
+	visits, err := m.UnpaidVisits(ctx, patientID)
+	var total float64
+	for _, v := range visits {
+		total += v.AmountDue()
+	}
+	return total, err
+}
+func (m *mockPaymentService) Currency() string { return "TRY" }
+func (m *mockPaymentService) Revenue(ctx context.Context, from, to time.Time, period domain.RevenuePeriod) (*domain.RevenueReport, error) {
+	m.from, m.period = from, period
+	if m.report == nil {
+		m.report = &domain.RevenueReport{From: from, To: to, Period: period, Currency: "TRY"}
+	}
+	return m.report, m.err
+}
+
+func newPaymentTestHandler(p *mockPaymentService) *BookingHandler {
+	h := NewBookingHandler(nil, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	if p != nil {
+		h.SetPayments(p)
+	}
+	return h
+}
+
+func testUnpaidVisits() []domain.Appointment {
+	start := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	return []domain.Appointment{
+		{ID: "a1", CustomerTgID: "100", CustomerName: "Иван", ServiceID: "classic", Service: domain.Service{Name: "Массаж", Price: 2000}, StartTime: start},
+		{ID: "a2", CustomerTgID: "100", CustomerName: "Иван", ServiceID: "classic", Service: domain.Service{Name: "Массаж", Price: 2000}, StartTime: start.AddDate(0, 0, 1)},
+		{ID: "b1", CustomerTgID: "200", CustomerName: "Анна", Service: domain.Service{ID: "sport", Name: "Спорт", Price: 1500}, StartTime: start},
+	}
+}
+
+func TestHandlePaid(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+
+	t.Run("latest visit of a patient with a discount", func(t *testing.T) {
+		p := &mockPaymentService{unpaid: testUnpaidVisits()}
+		h := newPaymentTestHandler(p)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"100", "карта", "0", "300"}}
+
+		if err := h.HandlePaid(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if len(p.recorded) != 1 {
+			t.Fatalf("expected one payment, got %d", len(p.recorded))
+		}
+		got := p.recorded[0]
+		if got.AppointmentID != "a2" || got.PatientID != "100" || got.Method != domain.PaymentCard || got.ListPrice != 2000 ||
+			got.Discount != 300 || got.Amount != 1700 || got.ServiceID != "classic" || got.RecordedBy != "999" {
+			t.Errorf("unexpected payment: %+v", got)
+		}
+		if !contains(ctx.sentMsg, "ОПЛАТА ЗАПИСАНА") || !contains(ctx.sentMsg, "1700 ₺") {
+			t.Errorf("expected the confirmation, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("booking discount by default", func(t *testing.T) {
+		visits := testUnpaidVisits()
+		visits[1].PromoCode, visits[1].Discount = "SPRING10", 200
+		p := &mockPaymentService{unpaid: visits}
+		h := newPaymentTestHandler(p)
+
+		_ = h.HandlePaid(&mockContext{sender: &telebot.User{ID: 999}, args: []string{"a2", "нал"}})
+		_ = h.HandlePaid(&mockContext{sender: &telebot.User{ID: 999}, args: []string{"a2", "нал", "0", "0"}})
+		if len(p.recorded) != 2 {
+			t.Fatalf("expected two payments, got %d", len(p.recorded))
+		}
+		if p.recorded[0].Discount != 200 || p.recorded[0].Amount != 1800 {
+			t.Errorf("expected the promo discount applied, got %+v", p.recorded[0])
+		}
+		if p.recorded[1].Discount != 0 || p.recorded[1].Amount != 2000 {
+			t.Errorf("expected an explicit discount to win, got %+v", p.recorded[1])
+		}
+	})
+
+	t.Run("by appointment ID", func(t *testing.T) {
+		p := &mockPaymentService{unpaid: testUnpaidVisits()}
+		h := newPaymentTestHandler(p)
+		_ = h.HandlePaid(&mockContext{sender: &telebot.User{ID: 999}, args: []string{"b1", "нал"}})
+		if len(p.recorded) != 1 || p.recorded[0].AppointmentID != "b1" || p.recorded[0].Amount != 1500 || p.recorded[0].ServiceID != "sport" {
+			t.Errorf("unexpected payments: %+v", p.recorded)
+		}
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"missing method", []string{"100"}, "Использование"},
+		{"unknown method", []string{"100", "crypto"}, "Неизвестный способ"},
+		{"bad amount", []string{"100", "нал", "много"}, "Неверная сумма"},
+		{"negative discount", []string{"100", "нал", "1000", "-5"}, "Неверная скидка"},
+		{"no such visit", []string{"300", "нал"}, "не найден"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mockPaymentService{unpaid: testUnpaidVisits()}
+			h := newPaymentTestHandler(p)
+			ctx := &mockContext{sender: &telebot.User{ID: 999}, args: tt.args}
+			_ = h.HandlePaid(ctx)
+			if !contains(ctx.sentMsg, tt.want) || len(p.recorded) != 0 {
+				t.Errorf("expected %q and nothing recorded, got %q (%d recorded)", tt.want, ctx.sentMsg, len(p.recorded))
+			}
+		})
+	}
+
+	t.Run("lookup failure", func(t *testing.T) {
+		h := newPaymentTestHandler(&mockPaymentService{err: errors.New("db down")})
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"100", "нал"}}
+		_ = h.HandlePaid(ctx)
+		if !contains(ctx.sentMsg, "Не удалось найти визит") {
+			t.Errorf("unexpected message %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("non-admin", func(t *testing.T) {
+		p := &mockPaymentService{unpaid: testUnpaidVisits()}
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, args: []string{"100", "нал"}}
+		_ = newPaymentTestHandler(p).HandlePaid(ctx)
+		if !contains(ctx.sentMsg, "Доступ запрещен") || len(p.recorded) != 0 {
+			t.Errorf("expected access denied, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("payments off", func(t *testing.T) {
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"100", "нал"}}
+		_ = newPaymentTestHandler(nil).HandlePaid(ctx)
+		if !contains(ctx.sentMsg, "не подключён") {
+			t.Errorf("unexpected message %q", ctx.sentMsg)
+		}
+	})
+}
+
+func TestHandleUnpaid(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	h := newPaymentTestHandler(&mockPaymentService{unpaid: testUnpaidVisits()})
+
+	all := &mockContext{sender: &telebot.User{ID: 999}}
+	_ = h.HandleUnpaid(all)
+	if !contains(all.sentMsg, "/paid a1 нал") || !contains(all.sentMsg, "Анна") || !contains(all.sentMsg, "5500 ₺") {
+		t.Errorf("unexpected list: %q", all.sentMsg)
+	}
+
+	one := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"200"}}
+	_ = h.HandleUnpaid(one)
+	if contains(one.sentMsg, "Иван") || !contains(one.sentMsg, "1500 ₺") {
+		t.Errorf("expected only the patient's visits: %q", one.sentMsg)
+	}
+
+	none := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"300"}}
+	_ = h.HandleUnpaid(none)
+	if !contains(none.sentMsg, "Неоплаченных визитов нет") {
+		t.Errorf("unexpected message: %q", none.sentMsg)
+	}
+}
+
+func TestHandleRevenue(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	now := time.Now().UTC()
+
+	tests := []struct {
+		arg    string
+		period domain.RevenuePeriod
+		from   time.Time
+	}{
+		{"", domain.RevenueWeek, domain.RevenueMonth.Start(now)},
+		{"неделя", domain.RevenueDay, domain.RevenueWeek.Start(now)},
+		{"год", domain.RevenueMonth, time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run("span "+tt.arg, func(t *testing.T) {
+			p := &mockPaymentService{}
+			ctx := &mockContext{sender: &telebot.User{ID: 999}}
+			if tt.arg != "" {
+				ctx.args = []string{tt.arg}
+			}
+			if err := newPaymentTestHandler(p).HandleRevenue(ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if p.period != tt.period || !p.from.Equal(tt.from) {
+				t.Errorf("report for %s from %v, want %s from %v", p.period, p.from, tt.period, tt.from)
+			}
+			if !contains(ctx.sentMsg, "ВЫРУЧКА") {
+				t.Errorf("expected the report, got %q", ctx.sentMsg)
+			}
+		})
+	}
+
+	bad := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"век"}}
+	_ = newPaymentTestHandler(&mockPaymentService{}).HandleRevenue(bad)
+	if !contains(bad.sentMsg, "Использование") {
+		t.Errorf("expected usage, got %q", bad.sentMsg)
+	}
+}
+
+func TestHandleMyRecords_OutstandingBalance(t *testing.T) {
+	repo := newMockRepository()
+	_ = repo.SavePatient(domain.Patient{TelegramID: "100", Name: "Иван"})
+	h := NewBookingHandler(nil, nil, nil, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+	h.SetPayments(&mockPaymentService{unpaid: testUnpaidVisits()})
+
+	ctx := &mockContext{sender: &telebot.User{ID: 100}}
+	if err := h.HandleMyRecords(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "К оплате:</b> 4000 ₺") {
+		t.Errorf("expected the balance on the card, got %q", ctx.sentMsg)
+	}
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// Admins create promo codes and follow their use:
+//
+//	/promo_add {КОД} {10%|500} {дней|ДД.ММ.ГГГГ-ДД.ММ.ГГГГ} [лимит] [id услуг через запятую]
+//	/promos
+//	/promo {КОД}
+//
+// A value with "%" takes a percentage off the price, a plain number a fixed
+// amount. A limit of 0 means unlimited. Patients enter a code from the
+// booking confirmation; a use is reserved when they confirm and given back
+// if the booking fails.
+const promoUsage = "Использование: /promo_add {КОД} {10%|500} {дней|ДД.ММ.ГГГГ-ДД.ММ.ГГГГ} [лимит] [id услуг через запятую]\nПример: /promo_add SPRING10 10% 30 50 classic,sport"
+
+// SetPromos enables promo codes; without it the confirmation step offers no
+// code and the promo commands report that promo codes are off.
+func (h *BookingHandler) SetPromos(p ports.PromoService) {
+	h.promos = p
+}
+
+// HandleAddPromo creates a promo code.
+func (h *BookingHandler) HandleAddPromo(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.promos == nil {
+		return c.Send("❌ Промокоды не подключены к базе данных.")
+	}
+
+	args := c.Args()
+	if len(args) < 3 || len(args) > 5 {
+		return c.Send(promoUsage)
+	}
+	kind, value, ok := parsePromoValue(args[1])
+	if !ok {
+		return c.Send(fmt.Sprintf("❌ Неверная скидка: %s\n%s", args[1], promoUsage))
+	}
+	from, until, ok := parsePromoWindow(args[2], time.Now().In(domain.ApptTimeZone))
+	if !ok {
+		return c.Send(fmt.Sprintf("❌ Неверный срок действия: %s\n%s", args[2], promoUsage))
+	}
+	var maxUses int
+	if len(args) >= 4 {
+		var err error
+		if maxUses, err = strconv.Atoi(args[3]); err != nil || maxUses < 0 {
+			return c.Send(fmt.Sprintf("❌ Неверный лимит: %s\n%s", args[3], promoUsage))
+		}
+	}
+	var serviceIDs []string
+	if len(args) == 5 {
+		for _, id := range strings.Split(args[4], ",") {
+			service := h.findCatalogService(strings.TrimSpace(id))
+			if service == nil {
+				return c.Send(fmt.Sprintf("❌ Услуга %s не найдена. Список: /services", id))
+			}
+			serviceIDs = append(serviceIDs, service.ID)
+		}
+	}
+
+	promo, err := h.promos.CreatePromo(context.Background(), domain.PromoCode{
+		Code:       args[0],
+		Kind:       kind,
+		Value:      value,
+		ServiceIDs: serviceIDs,
+		ValidFrom:  from,
+		ValidUntil: until,
+		MaxUses:    maxUses,
+		CreatedBy:  strconv.FormatInt(c.Sender().ID, 10),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPromoExists):
+			return c.Send(fmt.Sprintf("❌ Промокод %s уже существует. Список: /promos", domain.NormalizePromoCode(args[0])))
+		case errors.Is(err, domain.ErrInvalidPromoCode):
+			return c.Send("❌ Неверные параметры промокода.\n" + promoUsage)
+		}
+		logging.Errorf(": Failed to create promo code %s: %v", args[0], err)
+		return c.Send("❌ Не удалось создать промокод. Пожалуйста, попробуйте позже.")
+	}
+	logging.Infof("[ADMIN] Promo code %s created by %d", promo.Code, c.Sender().ID)
+
+	localizePromo(promo)
+	return c.Send(h.presenter.FormatPromoCreated(promo, h.promoCurrency()), telebot.ModeHTML)
+}
+
+// HandleListPromos shows every promo code with its use count.
+func (h *BookingHandler) HandleListPromos(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.promos == nil {
+		return c.Send("❌ Промокоды не подключены к базе данных.")
+	}
+
+	promos, err := h.promos.ListPromos(context.Background())
+	if err != nil {
+		logging.Errorf(": Failed to list promo codes: %v", err)
+		return c.Send("❌ Не удалось загрузить промокоды. Пожалуйста, попробуйте позже.")
+	}
+	for i := range promos {
+		localizePromo(&promos[i])
+	}
+	return c.Send(h.presenter.FormatPromoList(promos, time.Now(), h.promoCurrency()), telebot.ModeHTML)
+}
+
+// HandlePromoUsages lists the bookings that used a promo code.
+func (h *BookingHandler) HandlePromoUsages(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+	if h.promos == nil {
+		return c.Send("❌ Промокоды не подключены к базе данных.")
+	}
+	args := c.Args()
+	if len(args) != 1 {
+		return c.Send("Использование: /promo {КОД}")
+	}
+
+	code := domain.NormalizePromoCode(args[0])
+	usages, err := h.promos.ListPromoUsages(context.Background(), code)
+	if err != nil {
+		logging.Errorf(": Failed to list usages of promo code %s: %v", code, err)
+		return c.Send("❌ Не удалось загрузить использования. Пожалуйста, попробуйте позже.")
+	}
+	for i := range usages {
+		usages[i].UsedAt = usages[i].UsedAt.In(domain.ApptTimeZone)
+	}
+	return c.Send(h.presenter.FormatPromoUsages(code, usages, h.promoCurrency()), telebot.ModeHTML)
+}
+
+// HandleEnterPromo asks the patient for a promo code from the booking
+// confirmation.
+func (h *BookingHandler) HandleEnterPromo(c telebot.Context) error {
+	userID := c.Sender().ID
+	if h.promos == nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Промокоды сейчас недоступны."})
+	}
+	if _, ok := h.sessionStorage.Get(userID)[SessionKeyService].(domain.Service); !ok {
+		return c.Send("Ошибка сессии. Пожалуйста, начните /start снова.", telebot.RemoveKeyboard)
+	}
+
+	h.sessionStorage.Set(userID, SessionKeyAwaitingPromo, true)
+	return c.Send("🏷 Введите промокод одним сообщением.\nЧтобы убрать промокод, отправьте «-».")
+}
+
+// HandlePromoInput checks the code the patient typed and shows the
+// confirmation again, with the discounted price when the code applies.
+func (h *BookingHandler) HandlePromoInput(c telebot.Context) error {
+	userID := c.Sender().ID
+	h.sessionStorage.Set(userID, SessionKeyAwaitingPromo, false)
+
+	service, ok := h.sessionStorage.Get(userID)[SessionKeyService].(domain.Service)
+	if !ok || h.promos == nil {
+		return h.askForConfirmation(c)
+	}
+
+	text := strings.TrimSpace(c.Text())
+	switch strings.ToLower(text) {
+	case "-", "нет", "отмена":
+		h.sessionStorage.Set(userID, SessionKeyPromoCode, "")
+		return h.askForConfirmation(c)
+	}
+
+	code := domain.NormalizePromoCode(text)
+	promo, discount, err := h.promos.ApplyPromo(context.Background(), code, service.ID, service.Price)
+	if err != nil {
+		var msg string
+		switch {
+		case errors.Is(err, domain.ErrPromoNotFound):
+			msg = fmt.Sprintf("❌ Промокод %s не найден.", code)
+		case errors.Is(err, domain.ErrPromoExpired):
+			msg = fmt.Sprintf("❌ Промокод %s сейчас не действует.", code)
+		case errors.Is(err, domain.ErrPromoUsedUp):
+			msg = fmt.Sprintf("❌ Промокод %s больше недоступен: лимит использований исчерпан.", code)
+		case errors.Is(err, domain.ErrPromoNotApplicable):
+			msg = fmt.Sprintf("❌ Промокод %s не действует для услуги «%s».", code, service.Name)
+		default:
+			logging.Errorf(": Failed to apply promo code %s for user %d: %v", code, userID, err)
+			msg = "❌ Не удалось проверить промокод. Пожалуйста, попробуйте позже."
+		}
+		if err := c.Send(msg); err != nil {
+			return err
+		}
+		return h.askForConfirmation(c)
+	}
+
+	h.sessionStorage.Set(userID, SessionKeyPromoCode, promo.Code)
+	logging.Infof("Promo code %s applied by user %d to %s: -%.0f", promo.Code, userID, service.Name, discount)
+	return h.askForConfirmation(c)
+}
+
+// sessionPromo returns the promo code the patient entered and the discount
+// it gives on service now. A code that no longer applies, for instance after
+// picking another service, is dropped from the session.
+func (h *BookingHandler) sessionPromo(userID int64, service domain.Service) (string, float64) {
+	if h.promos == nil {
+		return "", 0
+	}
+	code, _ := h.sessionStorage.Get(userID)[SessionKeyPromoCode].(string)
+	if code == "" {
+		return "", 0
+	}
+	promo, discount, err := h.promos.ApplyPromo(context.Background(), code, service.ID, service.Price)
+	if err != nil {
+		logging.Infof("Promo code %s of user %d no longer applies: %v", code, userID, err)
+		h.sessionStorage.Set(userID, SessionKeyPromoCode, "")
+		return "", 0
+	}
+	return promo.Code, discount
+}
+
+// reservePromo takes a use of the patient's promo code right before the
+// booking is made. When the code can no longer be used, for instance because
+// another patient took its last use, it is dropped from the session, the
+// patient is told and ok is false: the booking must not go ahead at a price
+// they did not confirm.
+func (h *BookingHandler) reservePromo(c telebot.Context, service domain.Service) (code string, discount float64, ok bool) {
+	userID := c.Sender().ID
+	if h.promos == nil {
+		return "", 0, true
+	}
+	code, _ = h.sessionStorage.Get(userID)[SessionKeyPromoCode].(string)
+	if code == "" {
+		return "", 0, true
+	}
+	promo, discount, err := h.promos.ReservePromo(context.Background(), code, service.ID, service.Price)
+	if err != nil {
+		logging.Infof("Promo code %s of user %d could not be reserved: %v", code, userID, err)
+		h.sessionStorage.Set(userID, SessionKeyPromoCode, "")
+		if err := c.Send(fmt.Sprintf("❌ Промокод %s больше недоступен, стоимость записи изменилась. Пожалуйста, проверьте и подтвердите запись снова.", code)); err != nil {
+			logging.Warnf("Failed to tell user %d about promo code %s: %v", userID, code, err)
+		}
+		return "", 0, false
+	}
+	return promo.Code, discount, true
+}
+
+// releasePromo gives back the use reserved for a booking that failed.
+func (h *BookingHandler) releasePromo(code string) {
+	if h.promos == nil || code == "" {
+		return
+	}
+	if err := h.promos.ReleasePromo(context.Background(), code); err != nil {
+		logging.Warnf("Failed to release promo code %s: %v", code, err)
+	}
+}
+
+// redeemPromo records the booking against the use reserved for it.
+func (h *BookingHandler) redeemPromo(appt domain.Appointment) {
+	if h.promos == nil || appt.PromoCode == "" || appt.ID == "" {
+		return
+	}
+	if err := h.promos.RedeemPromo(context.Background(), appt); err != nil {
+		logging.Warnf("Failed to redeem promo code %s for appointment %s: %v", appt.PromoCode, appt.ID, err)
+	}
+}
+
+// promoCurrency is the currency promo discounts are shown in.
+func (h *BookingHandler) promoCurrency() string {
+	if h.payments != nil {
+		return h.payments.Currency()
+	}
+	return domain.DefaultCurrency
+}
+
+// parsePromoValue reads "10%" as a percentage and "500" as a fixed amount.
+func parsePromoValue(s string) (domain.PromoKind, float64, bool) {
+	kind := domain.PromoFixed
+	if strings.HasSuffix(s, "%") {
+		kind = domain.PromoPercent
+		s = strings.TrimSuffix(s, "%")
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value <= 0 || (kind == domain.PromoPercent && value > 100) {
+		return "", 0, false
+	}
+	return kind, value, true
+}
+
+// parsePromoWindow reads a number of days from now, or a date range, both
+// ending at the end of the last day.
+func parsePromoWindow(s string, now time.Time) (time.Time, time.Time, bool) {
+	if days, err := strconv.Atoi(s); err == nil {
+		if days <= 0 {
+			return time.Time{}, time.Time{}, false
+		}
+		return now, time.Date(now.Year(), now.Month(), now.Day()+days+1, 0, 0, 0, 0, domain.ApptTimeZone), true
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, false
+	}
+	first, err1 := time.ParseInLocation("02.01.2006", parts[0], domain.ApptTimeZone)
+	last, err2 := time.ParseInLocation("02.01.2006", parts[1], domain.ApptTimeZone)
+	if err1 != nil || err2 != nil || last.Before(first) {
+		return time.Time{}, time.Time{}, false
+	}
+	return first, last.AddDate(0, 0, 1), true
+}
+
+func localizePromo(p *domain.PromoCode) {
+	p.ValidFrom = p.ValidFrom.In(domain.ApptTimeZone)
+	p.ValidUntil = p.ValidUntil.In(domain.ApptTimeZone)
+}
@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// mockPromoService keeps promo codes in memory and records reservations and
+// redemptions.
+type mockPromoService struct {
+	codes    map[string]domain.PromoCode
+	created  []domain.PromoCode
+	usages   []domain.PromoUsage
+	reserved []string
+	released []string
+	redeemed []domain.Appointment
+	err      error
+}
+
+func newMockPromoService() *mockPromoService {
+	return &mockPromoService{codes: map[string]domain.PromoCode{
+		"SPRING10": {Code: "SPRING10", Kind: domain.PromoPercent, Value: 10},
+		"MASSAGE":  {Code: "MASSAGE", Kind: domain.PromoFixed, Value: 500, ServiceIDs: []string{"classic"}},
+	}}
+}
+
+func (m *mockPromoService) CreatePromo(ctx context.Context, p domain.PromoCode) (*domain.PromoCode, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	p.Code = domain.NormalizePromoCode(p.Code)
+	if _, ok := m.codes[p.Code]; ok {
+		return nil, domain.ErrPromoExists
+	}
+	m.codes[p.Code] = p
+	m.created = append(m.created, p)
+	return &p, nil
+}
+func (m *mockPromoService) ListPromos(ctx context.Context) ([]domain.PromoCode, error) {
+	var out []domain.PromoCode
+	for _, p := range m.codes {
+		out = append(out, p)
+	}
+	return out, m.err
+}
+func (m *mockPromoService) ListPromoUsages(ctx context.Context, code string) ([]domain.PromoUsage, error) {
+	return m.usages, m.err
+}
+func (m *mockPromoService) ApplyPromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error) {
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+	p, ok := m.codes[code]
+	if !ok {
+		return nil, 0, domain.ErrPromoNotFound
+	}
+	if !p.Covers(serviceID) {
+		return nil, 0, domain.ErrPromoNotApplicable
+	}
+	return &p, p.DiscountFor(price), nil
+}
+func (m *mockPromoService) ReservePromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error) {
+	p, discount, err := m.ApplyPromo(ctx, code, serviceID, price)
+	if err != nil {
+		return nil, 0, err
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return nil, 0, domain.ErrPromoUsedUp
+	}
+	p.Uses++
+	m.codes[code] = *p
+	m.reserved = append(m.reserved, code)
+	return p, discount, nil
+}
+func (m *mockPromoService) ReleasePromo(ctx context.Context, code string) error {
+	p := m.codes[code]
+	p.Uses--
+	m.codes[code] = p
+	m.released = append(m.released, code)
+	return nil
+}
+func (m *mockPromoService) RedeemPromo(ctx context.Context, appt domain.Appointment) error {
+	m.redeemed = append(m.redeemed, appt)
+	return nil
+}
+
+func newPromoTestHandler(p *mockPromoService) *BookingHandler {
+	mock := &mockAppointmentService{getAllServicesFunc: func(ctx context.Context) ([]domain.Service, error) {
+		return []domain.Service{{ID: "classic", Name: "Классический массаж"}, {ID: "sport", Name: "Спортивный массаж"}}, nil
+	}}
+	h := NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	if p != nil {
+		h.SetPromos(p)
+	}
+	return h
+}
+
+// setBookingSession fills the session as it is right before confirmation.
+func setBookingSession(h *BookingHandler, userID int64, service domain.Service) {
+	h.sessionStorage.Set(userID, SessionKeyService, service)
+	h.sessionStorage.Set(userID, SessionKeyDate, time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC))
+	h.sessionStorage.Set(userID, SessionKeyTime, "14:00")
+	h.sessionStorage.Set(userID, SessionKeyName, "Иван")
+}
+
+// hasPromoButton reports whether the sent keyboard offers a promo code.
+func hasPromoButton(opts []interface{}) bool {
+	for _, opt := range opts {
+		markup, ok := opt.(*telebot.ReplyMarkup)
+		if !ok {
+			continue
+		}
+		for _, row := range markup.InlineKeyboard {
+			for _, btn := range row {
+				if btn.Unique == "enter_promo" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+var classicService = domain.Service{ID: "classic", Name: "Классический массаж", DurationMinutes: 60, Price: 2000}
+
+func TestHandleAddPromo(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+
+	t.Run("percentage for some services", func(t *testing.T) {
+		p := newMockPromoService()
+		h := newPromoTestHandler(p)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"summer", "15%", "30", "50", "classic,sport"}}
+
+		if err := h.HandleAddPromo(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if len(p.created) != 1 {
+			t.Fatalf("expected one promo created, got %d", len(p.created))
+		}
+		got := p.created[0]
+		if got.Code != "SUMMER" || got.Kind != domain.PromoPercent || got.Value != 15 || got.MaxUses != 50 || got.CreatedBy != "999" {
+			t.Errorf("unexpected promo: %+v", got)
+		}
+		if len(got.ServiceIDs) != 2 || got.ServiceIDs[1] != "sport" {
+			t.Errorf("unexpected scope: %v", got.ServiceIDs)
+		}
+		if days := got.ValidUntil.Sub(got.ValidFrom).Hours() / 24; days < 30 || days > 31 {
+			t.Errorf("expected about 30 days of validity, got %.1f", days)
+		}
+		if !contains(ctx.sentMsg, "ПРОМОКОД СОЗДАН") {
+			t.Errorf("expected the confirmation, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("fixed amount in a date range", func(t *testing.T) {
+		p := newMockPromoService()
+		h := newPromoTestHandler(p)
+		_ = h.HandleAddPromo(&mockContext{sender: &telebot.User{ID: 999}, args: []string{"NY500", "500", "25.12.2030-10.01.2031"}})
+		if len(p.created) != 1 {
+			t.Fatalf("expected one promo created, got %d", len(p.created))
+		}
+		got := p.created[0]
+		if got.Kind != domain.PromoFixed || got.Value != 500 || got.MaxUses != 0 || got.ServiceIDs != nil {
+			t.Errorf("unexpected promo: %+v", got)
+		}
+		if !got.ValidFrom.Equal(time.Date(2030, 12, 25, 0, 0, 0, 0, time.UTC)) || !got.ValidUntil.Equal(time.Date(2031, 1, 11, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected window %s — %s", got.ValidFrom, got.ValidUntil)
+		}
+	})
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"missing window", []string{"CODE", "10%"}, "Использование"},
+		{"bad value", []string{"CODE", "много", "30"}, "Неверная скидка"},
+		{"percent over 100", []string{"CODE", "150%", "30"}, "Неверная скидка"},
+		{"bad window", []string{"CODE", "10%", "завтра"}, "Неверный срок"},
+		{"reversed range", []string{"CODE", "10%", "10.01.2031-25.12.2030"}, "Неверный срок"},
+		{"bad limit", []string{"CODE", "10%", "30", "-1"}, "Неверный лимит"},
+		{"unknown service", []string{"CODE", "10%", "30", "0", "yoga"}, "не найдена"},
+		{"taken code", []string{"spring10", "10%", "30"}, "уже существует"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newMockPromoService()
+			ctx := &mockContext{sender: &telebot.User{ID: 999}, args: tt.args}
+			_ = newPromoTestHandler(p).HandleAddPromo(ctx)
+			if !contains(ctx.sentMsg, tt.want) || len(p.created) != 0 {
+				t.Errorf("expected %q and nothing created, got %q (%d created)", tt.want, ctx.sentMsg, len(p.created))
+			}
+		})
+	}
+
+	t.Run("non-admin", func(t *testing.T) {
+		p := newMockPromoService()
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, args: []string{"CODE", "10%", "30"}}
+		_ = newPromoTestHandler(p).HandleAddPromo(ctx)
+		if !contains(ctx.sentMsg, "Доступ запрещен") || len(p.created) != 0 {
+			t.Errorf("expected access denied, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("promos off", func(t *testing.T) {
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"CODE", "10%", "30"}}
+		_ = newPromoTestHandler(nil).HandleAddPromo(ctx)
+		if !contains(ctx.sentMsg, "не подключены") {
+			t.Errorf("unexpected message %q", ctx.sentMsg)
+		}
+	})
+}
+
+func TestHandleListPromos(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	h := newPromoTestHandler(newMockPromoService())
+	ctx := &mockContext{sender: &telebot.User{ID: 999}}
+	_ = h.HandleListPromos(ctx)
+	if !contains(ctx.sentMsg, "ПРОМОКОДЫ") || !contains(ctx.sentMsg, "SPRING10") || !contains(ctx.sentMsg, "MASSAGE") {
+		t.Errorf("expected both codes listed, got %q", ctx.sentMsg)
+	}
+
+	failing := newPromoTestHandler(&mockPromoService{err: errors.New("db down")})
+	ctx = &mockContext{sender: &telebot.User{ID: 999}}
+	_ = failing.HandleListPromos(ctx)
+	if !contains(ctx.sentMsg, "Не удалось загрузить") {
+		t.Errorf("unexpected message %q", ctx.sentMsg)
+	}
+}
+
+func TestHandlePromoUsages(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	p := newMockPromoService()
+	p.usages = []domain.PromoUsage{{Code: "SPRING10", PatientID: "100", PatientName: "Иван", ServiceName: "Массаж", Discount: 200, UsedAt: time.Date(2030, 1, 9, 14, 0, 0, 0, time.UTC)}}
+	h := newPromoTestHandler(p)
+
+	ctx := &mockContext{sender: &telebot.User{ID: 999}, args: []string{"spring10"}}
+	_ = h.HandlePromoUsages(ctx)
+	if !contains(ctx.sentMsg, "ПРОМОКОД SPRING10") || !contains(ctx.sentMsg, "Иван (100)") {
+		t.Errorf("expected the usages listed, got %q", ctx.sentMsg)
+	}
+
+	ctx = &mockContext{sender: &telebot.User{ID: 999}}
+	_ = h.HandlePromoUsages(ctx)
+	if !contains(ctx.sentMsg, "Использование: /promo") {
+		t.Errorf("expected usage, got %q", ctx.sentMsg)
+	}
+}
+
+func TestAskForConfirmation_PromoButton(t *testing.T) {
+	userID := int64(100)
+
+	t.Run("offered to patients", func(t *testing.T) {
+		h := newPromoTestHandler(newMockPromoService())
+		setBookingSession(h, userID, classicService)
+		ctx := &mockContext{sender: &telebot.User{ID: userID}}
+		_ = h.askForConfirmation(ctx)
+		if !hasPromoButton(ctx.sentOpts) {
+			t.Error("expected the promo button")
+		}
+	})
+
+	t.Run("not offered for manual bookings", func(t *testing.T) {
+		h := newPromoTestHandler(newMockPromoService())
+		setBookingSession(h, userID, classicService)
+		h.sessionStorage.Set(userID, SessionKeyIsAdminManual, true)
+		ctx := &mockContext{sender: &telebot.User{ID: userID}}
+		_ = h.askForConfirmation(ctx)
+		if hasPromoButton(ctx.sentOpts) {
+			t.Error("expected no promo button for a manual booking")
+		}
+	})
+
+	t.Run("not offered when promos are off", func(t *testing.T) {
+		h := newPromoTestHandler(nil)
+		setBookingSession(h, userID, classicService)
+		ctx := &mockContext{sender: &telebot.User{ID: userID}}
+		_ = h.askForConfirmation(ctx)
+		if hasPromoButton(ctx.sentOpts) {
+			t.Error("expected no promo button without promo codes")
+		}
+	})
+}
+
+func TestHandleEnterPromo(t *testing.T) {
+	userID := int64(100)
+	h := newPromoTestHandler(newMockPromoService())
+	setBookingSession(h, userID, classicService)
+
+	ctx := &mockContext{sender: &telebot.User{ID: userID}}
+	_ = h.HandleEnterPromo(ctx)
+	if awaiting, _ := h.sessionStorage.Get(userID)[SessionKeyAwaitingPromo].(bool); !awaiting {
+		t.Error("expected the session to await a promo code")
+	}
+	if !contains(ctx.sentMsg, "Введите промокод") {
+		t.Errorf("unexpected message %q", ctx.sentMsg)
+	}
+}
+
+func TestHandlePromoInput(t *testing.T) {
+	userID := int64(100)
+
+	t.Run("applies a valid code", func(t *testing.T) {
+		h := newPromoTestHandler(newMockPromoService())
+		setBookingSession(h, userID, classicService)
+		h.sessionStorage.Set(userID, SessionKeyAwaitingPromo, true)
+
+		ctx := &mockContext{sender: &telebot.User{ID: userID}, text: " spring10 "}
+		if err := h.HandlePromoInput(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		session := h.sessionStorage.Get(userID)
+		if session[SessionKeyPromoCode] != "SPRING10" || session[SessionKeyAwaitingPromo] != false {
+			t.Errorf("unexpected session %v", session)
+		}
+		if !contains(ctx.sentMsg, "1800 ₺") || !contains(ctx.sentMsg, "Промокод:</b> SPRING10") {
+			t.Errorf("expected the discounted confirmation, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("rejects a code for another service", func(t *testing.T) {
+		h := newPromoTestHandler(newMockPromoService())
+		setBookingSession(h, userID, domain.Service{ID: "sport", Name: "Спортивный массаж", DurationMinutes: 60, Price: 2500})
+
+		ctx := &mockContext{sender: &telebot.User{ID: userID}, text: "massage"}
+		_ = h.HandlePromoInput(ctx)
+		if code, _ := h.sessionStorage.Get(userID)[SessionKeyPromoCode].(string); code != "" {
+			t.Errorf("expected no code applied, got %q", code)
+		}
+		if !contains(ctx.sentMsg, "ПОДТВЕРДИТЕ") || contains(ctx.sentMsg, "Промокод:") {
+			t.Errorf("expected the confirmation without a discount, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("dash removes the code", func(t *testing.T) {
+		h := newPromoTestHandler(newMockPromoService())
+		setBookingSession(h, userID, classicService)
+		h.sessionStorage.Set(userID, SessionKeyPromoCode, "SPRING10")
+
+		ctx := &mockContext{sender: &telebot.User{ID: userID}, text: "-"}
+		_ = h.HandlePromoInput(ctx)
+		if code, _ := h.sessionStorage.Get(userID)[SessionKeyPromoCode].(string); code != "" {
+			t.Errorf("expected the code removed, got %q", code)
+		}
+	})
+}
+
+func TestHandleConfirmBooking_WithPromo(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(100)
+	p := newMockPromoService()
+	h := newPromoTestHandler(p)
+	var booked *domain.Appointment
+	h.appointmentService.(*mockAppointmentService).createAppointmentFunc = func(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+		booked = appt
+		created := *appt
+		created.ID = "evt-1"
+		return &created, nil
+	}
+	setBookingSession(h, userID, classicService)
+	h.sessionStorage.Set(userID, SessionKeyDate, time.Now().AddDate(0, 0, 7))
+	h.sessionStorage.Set(userID, SessionKeyPromoCode, "SPRING10")
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	_ = h.HandleConfirmBooking(&mockContext{sender: &telebot.User{ID: userID}, bot: bot})
+	if booked == nil {
+		t.Fatal("expected the appointment to be created")
+	}
+	if booked.PromoCode != "SPRING10" || booked.Discount != 200 {
+		t.Errorf("expected the promo stored on the appointment, got %q %.0f", booked.PromoCode, booked.Discount)
+	}
+	if len(p.reserved) != 1 || len(p.released) != 0 {
+		t.Errorf("expected one use reserved and kept, got %v reserved, %v released", p.reserved, p.released)
+	}
+	if len(p.redeemed) != 1 || p.redeemed[0].ID != "evt-1" || p.redeemed[0].PromoCode != "SPRING10" {
+		t.Errorf("expected the promo redeemed for evt-1, got %+v", p.redeemed)
+	}
+}
+
+func TestHandleConfirmBooking_PromoUsedUp(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(100)
+	p := newMockPromoService()
+	// Another patient took the last use after this one entered the code
+	p.codes["ONCE"] = domain.PromoCode{Code: "ONCE", Kind: domain.PromoFixed, Value: 500, MaxUses: 1, Uses: 1}
+	h := newPromoTestHandler(p)
+	var booked *domain.Appointment
+	h.appointmentService.(*mockAppointmentService).createAppointmentFunc = func(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+		booked = appt
+		return appt, nil
+	}
+	setBookingSession(h, userID, classicService)
+	h.sessionStorage.Set(userID, SessionKeyDate, time.Now().AddDate(0, 0, 7))
+	h.sessionStorage.Set(userID, SessionKeyPromoCode, "ONCE")
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	ctx := &mockContext{sender: &telebot.User{ID: userID}, bot: bot}
+	_ = h.HandleConfirmBooking(ctx)
+	if booked != nil {
+		t.Fatalf("expected no booking with a used-up code, got %+v", booked)
+	}
+	if code, _ := h.sessionStorage.Get(userID)[SessionKeyPromoCode].(string); code != "" {
+		t.Errorf("expected the code dropped from the session, got %q", code)
+	}
+	if !contains(ctx.sentMsg, "ПОДТВЕРДИТЕ") || contains(ctx.sentMsg, "Промокод:") {
+		t.Errorf("expected the confirmation again at full price, got %q", ctx.sentMsg)
+	}
+	if len(p.redeemed) != 0 || p.codes["ONCE"].Uses != 1 {
+		t.Errorf("expected no redemption, got %+v (uses %d)", p.redeemed, p.codes["ONCE"].Uses)
+	}
+
+	// Confirming again books at full price
+	_ = h.HandleConfirmBooking(&mockContext{sender: &telebot.User{ID: userID}, bot: bot})
+	if booked == nil || booked.PromoCode != "" || booked.Discount != 0 {
+		t.Errorf("expected a booking without the discount, got %+v", booked)
+	}
+}
+
+func TestHandleConfirmBooking_PromoReleasedOnFailure(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(100)
+	p := newMockPromoService()
+	h := newPromoTestHandler(p)
+	h.appointmentService.(*mockAppointmentService).createAppointmentFunc = func(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+		return nil, errors.New("slot is not available")
+	}
+	setBookingSession(h, userID, classicService)
+	h.sessionStorage.Set(userID, SessionKeyDate, time.Now().AddDate(0, 0, 7))
+	h.sessionStorage.Set(userID, SessionKeyPromoCode, "SPRING10")
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	_ = h.HandleConfirmBooking(&mockContext{sender: &telebot.User{ID: userID}, bot: bot})
+	if len(p.reserved) != 1 || len(p.released) != 1 || p.codes["SPRING10"].Uses != 0 {
+		t.Errorf("expected the reserved use given back, got %v reserved, %v released", p.reserved, p.released)
+	}
+	if len(p.redeemed) != 0 {
+		t.Errorf("expected no redemption, got %+v", p.redeemed)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// Rescheduling reuses the booking flow's date and time steps: the
+// "🔄 Перенести" button in /myappointments stores the appointment ID in the
+// session, and HandleTimeSelection moves the booking instead of asking for a
+// name when that key is set.
+
+// HandleRescheduleAppointmentCallback starts moving an appointment. Patients
+// may move their own bookings while more than 72 hours remain, like
+// cancellation; admins can move any booking at any time.
+func (h *BookingHandler) HandleRescheduleAppointmentCallback(c telebot.Context) error {
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) < 2 || parts[1] == "" {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные для переноса."})
+	}
+
+	userID := c.Sender().ID
+	appointmentID := parts[1]
+	logging.Debugf(": HandleRescheduleAppointmentCallback for ID %s by user %d", appointmentID, userID)
+
+	appt, err := h.appointmentService.FindByID(context.Background(), appointmentID)
+	if err != nil || appt == nil {
+		logging.Warnf(": Appointment %s for reschedule not found: %v", appointmentID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Запись не найдена. Возможно, она уже отменена."})
+	}
+
+	if !h.IsAdmin(userID) {
+		if appt.CustomerTgID != strconv.FormatInt(userID, 10) {
+			return c.Respond(&telebot.CallbackResponse{Text: "⛔ Доступ запрещен."})
+		}
+		// Moving inside the notice window is never self-service
+		if policy := h.appointmentService.GetCancellationPolicy(); policy.IsLate(appt.StartTime, time.Now()) {
+			logging.Infof("BLOCKED: Late reschedule attempt for user %s, appt %s", appt.CustomerTgID, appt.ID)
+			return c.Respond(&telebot.CallbackResponse{
+				Text:      h.presenter.FormatLateCancelBlocked(policy, true),
+				ShowAlert: true,
+			})
+		}
+	}
+
+	// Slots are searched for the booked length, which the calendar knows
+	// even when the service has since changed
+	service := appt.Service
+	if minutes := int(appt.EndTime.Sub(appt.StartTime) / time.Minute); minutes > 0 {
+		service.DurationMinutes = minutes
+	}
+
+	h.sessionStorage.ClearSession(userID)
+	h.sessionStorage.Set(userID, SessionKeyService, service)
+	h.sessionStorage.Set(userID, SessionKeyTherapist, appt.TherapistID)
+	h.sessionStorage.Set(userID, SessionKeyRescheduleID, appt.ID)
+
+	return h.askForDate(c, service.Name)
+}
+
+// rescheduleID returns the appointment being moved, or "" in a normal booking.
+func (h *BookingHandler) rescheduleID(userID int64) string {
+	id, _ := h.sessionStorage.Get(userID)[SessionKeyRescheduleID].(string)
+	return id
+}
+
+// completeReschedule moves the appointment to the date and time in the
+// session and tells the other side about it.
+func (h *BookingHandler) completeReschedule(c telebot.Context, appointmentID string) error {
+	userID := c.Sender().ID
+	session := h.sessionStorage.Get(userID)
+	service, okS := session[SessionKeyService].(domain.Service)
+	date, okD := session[SessionKeyDate].(time.Time)
+	timeStr, okT := session[SessionKeyTime].(string)
+	clock, err := time.Parse("15:04", timeStr)
+	if !okS || !okD || !okT || err != nil {
+		h.sessionStorage.ClearSession(userID)
+		return c.Send("⚠️ Сессия истекла из-за перезагрузки бота.\nПожалуйста, начните заново: /myappointments")
+	}
+
+	loc := domain.ApptTimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+	newStart := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+
+	ctx := context.Background()
+	original, err := h.appointmentService.FindByID(ctx, appointmentID)
+	if err != nil || original == nil {
+		h.sessionStorage.ClearSession(userID)
+		logging.Warnf(": Appointment %s vanished before reschedule: %v", appointmentID, err)
+		return c.Send("❌ Запись не найдена. Возможно, она уже отменена.")
+	}
+	oldStart := original.StartTime.In(loc)
+
+	updated, err := h.appointmentService.RescheduleAppointment(ctx, appointmentID, newStart)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrSlotUnavailable), errors.Is(err, domain.ErrOutsideWorkingHours), errors.Is(err, domain.ErrAppointmentInPast):
+			// Keep the session so another date can be picked straight away
+			if sendErr := c.Send(rescheduleErrorMessage(err)); sendErr != nil {
+				logging.Warnf("Failed to send reschedule error: %v", sendErr)
+			}
+			return h.askForDate(c, service.Name)
+		default:
+			h.sessionStorage.ClearSession(userID)
+			return c.Send(rescheduleErrorMessage(err))
+		}
+	}
+	h.sessionStorage.ClearSession(userID)
+	updated.StartTime = updated.StartTime.In(loc)
+	logging.Infof("Appointment %s rescheduled by %d to %s", appointmentID, userID, updated.StartTime.Format("2006-01-02 15:04"))
+
+	// Therapist side, then the patient if someone else moved the booking
+	staffMsg := h.presenter.FormatReschedule(updated, oldStart, true)
+	for _, recipient := range h.bookingRecipients(updated) {
+		if recipient != userID {
+			h.BotNotify(c.Bot(), recipient, staffMsg)
+		}
+	}
+	if patientID, err := strconv.ParseInt(updated.CustomerTgID, 10, 64); err == nil && patientID != userID {
+		h.BotNotify(c.Bot(), patientID, h.presenter.FormatReschedule(updated, oldStart, false))
+	}
+
+	if err := c.Send(h.presenter.FormatReschedule(updated, oldStart, h.IsAdmin(userID)), telebot.ModeHTML, h.GetMainMenu()); err != nil {
+		return err
+	}
+	// Same UID with a higher sequence: the imported event moves to the new time
+	sendICS(c.Bot(), updated.CustomerTgID, updated, presentation.ICSPublish)
+	return nil
+}
+
+func rescheduleErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrSlotUnavailable):
+		return "❌ Это время уже занято. Пожалуйста, выберите другое."
+	case errors.Is(err, domain.ErrOutsideWorkingHours):
+		return "❌ Это время вне рабочего графика. Пожалуйста, выберите другое."
+	case errors.Is(err, domain.ErrAppointmentInPast):
+		return "❌ Это время уже прошло. Пожалуйста, выберите другое."
+	case errors.Is(err, domain.ErrAppointmentNotFound):
+		return "❌ Запись не найдена. Возможно, она уже отменена."
+	default:
+		logging.Errorf(": Reschedule failed: %v", err)
+		return "❌ Не удалось перенести запись. Пожалуйста, попробуйте позже."
+	}
+}
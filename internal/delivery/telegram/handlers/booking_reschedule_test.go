@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func newRescheduleTestHandler(appt *domain.Appointment, reschedule func(ctx context.Context, id string, newStart time.Time) (*domain.Appointment, error)) *BookingHandler {
+	mock := &mockAppointmentService{
+		findByIDFunc: func(ctx context.Context, id string) (*domain.Appointment, error) {
+			if appt != nil && id == appt.ID {
+				copied := *appt
+				return &copied, nil
+			}
+			return nil, domain.ErrAppointmentNotFound
+		},
+		rescheduleAppointmentFunc: reschedule,
+	}
+	return NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+}
+
+func TestHandleRescheduleAppointmentCallback(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	in := func(d time.Duration) time.Time { return time.Now().Add(d).Truncate(time.Minute) }
+
+	tests := []struct {
+		name      string
+		userID    int64
+		start     time.Time
+		data      string
+		wantAlert string
+		wantStart bool
+	}{
+		{"patient moves own booking", 100, in(100 * time.Hour), "reschedule_appt|a1", "", true},
+		{"patient too late", 100, in(48 * time.Hour), "reschedule_appt|a1", "меньше 3 дней", false},
+		{"someone else's booking", 200, in(100 * time.Hour), "reschedule_appt|a1", "Доступ запрещен", false},
+		{"admin moves late booking", 999, in(2 * time.Hour), "reschedule_appt|a1", "", true},
+		{"unknown appointment", 100, in(100 * time.Hour), "reschedule_appt|missing", "не найдена", false},
+		{"malformed data", 100, in(100 * time.Hour), "reschedule_appt", "неверные данные", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appt := &domain.Appointment{
+				ID: "a1", CustomerTgID: "100", TherapistID: "anna",
+				Service:   domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+				StartTime: tt.start, EndTime: tt.start.Add(90 * time.Minute),
+			}
+			h := newRescheduleTestHandler(appt, nil)
+			ctx := &mockContext{
+				sender:   &telebot.User{ID: tt.userID},
+				callback: &telebot.Callback{Data: tt.data},
+			}
+
+			if err := h.HandleRescheduleAppointmentCallback(ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if tt.wantAlert != "" {
+				if ctx.response == nil || !contains(ctx.response.Text, tt.wantAlert) {
+					t.Errorf("expected response containing %q, got %+v", tt.wantAlert, ctx.response)
+				}
+			}
+			if !tt.wantStart {
+				if h.rescheduleID(tt.userID) != "" {
+					t.Error("reschedule must not start")
+				}
+				return
+			}
+
+			if msg, _ := ctx.editedMsg.(string); !contains(msg, "Перенос записи 'Massage'") {
+				t.Errorf("expected the reschedule date step, got %v", ctx.editedMsg)
+			}
+			session := h.sessionStorage.Get(tt.userID)
+			if session[SessionKeyRescheduleID] != "a1" || session[SessionKeyTherapist] != "anna" {
+				t.Errorf("unexpected session: %+v", session)
+			}
+			if svc, _ := session[SessionKeyService].(domain.Service); svc.DurationMinutes != 90 {
+				t.Errorf("slots should be searched for the booked 90 minutes, got %d", svc.DurationMinutes)
+			}
+		})
+	}
+}
+
+func TestHandleTimeSelection_Reschedule(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	original := &domain.Appointment{
+		ID: "a1", CustomerTgID: "100", CustomerName: "Alice",
+		Service:   domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+		StartTime: day.Add(10 * time.Hour), EndTime: day.Add(11 * time.Hour),
+	}
+
+	start := func(h *BookingHandler, userID int64) {
+		h.sessionStorage.Set(userID, SessionKeyService, original.Service)
+		h.sessionStorage.Set(userID, SessionKeyDate, day)
+		h.sessionStorage.Set(userID, SessionKeyRescheduleID, "a1")
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// Record what the bot sends so the updated calendar file can be checked
+		var files []string
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/sendDocument") {
+				if f, _, err := r.FormFile("document"); err == nil {
+					data, _ := io.ReadAll(f)
+					files = append(files, r.FormValue("chat_id")+"\n"+string(data))
+				}
+			}
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"message_id":1,"chat":{"id":1}}}`))
+		}))
+		defer api.Close()
+		bot, _ := telebot.NewBot(telebot.Settings{URL: api.URL, Offline: true})
+
+		var gotStart time.Time
+		h := newRescheduleTestHandler(original, func(ctx context.Context, id string, newStart time.Time) (*domain.Appointment, error) {
+			gotStart = newStart
+			moved := *original
+			moved.StartTime, moved.EndTime = newStart, newStart.Add(time.Hour)
+			return &moved, nil
+		})
+		start(h, 999)
+		ctx := &mockContext{
+			sender:   &telebot.User{ID: 999},
+			callback: &telebot.Callback{Data: "select_time|15:30"},
+			bot:      bot,
+		}
+
+		if err := h.HandleTimeSelection(ctx); err != nil {
+			t.Fatalf("HandleTimeSelection returned error: %v", err)
+		}
+		if !gotStart.Equal(day.Add(15*time.Hour + 30*time.Minute)) {
+			t.Errorf("unexpected new start %v", gotStart)
+		}
+		if !contains(ctx.sentMsg, "ЗАПИСЬ ПЕРЕНЕСЕНА") || !contains(ctx.sentMsg, "09.01.2030 в 10:00") {
+			t.Errorf("expected reschedule summary, got %q", ctx.sentMsg)
+		}
+		if len(h.sessionStorage.Get(999)) != 0 {
+			t.Error("session should be cleared after reschedule")
+		}
+		if len(files) != 1 || !strings.HasPrefix(files[0], "100\n") ||
+			!strings.Contains(files[0], "METHOD:PUBLISH") || !strings.Contains(files[0], "UID:a1@massage-bot") ||
+			!strings.Contains(files[0], "DTSTART:20300109T153000Z") {
+			t.Errorf("expected the patient to get the moved event, got %q", files)
+		}
+	})
+
+	t.Run("slot taken keeps the session", func(t *testing.T) {
+		h := newRescheduleTestHandler(original, func(ctx context.Context, id string, newStart time.Time) (*domain.Appointment, error) {
+			return nil, domain.ErrSlotUnavailable
+		})
+		start(h, 100)
+		ctx := &mockContext{
+			sender:   &telebot.User{ID: 100},
+			callback: &telebot.Callback{Data: "select_time|15:30"},
+			bot:      bot,
+		}
+
+		if err := h.HandleTimeSelection(ctx); err != nil {
+			t.Fatalf("HandleTimeSelection returned error: %v", err)
+		}
+		if !contains(ctx.sentMsg, "уже занято") {
+			t.Errorf("expected slot taken message, got %q", ctx.sentMsg)
+		}
+		if h.rescheduleID(100) != "a1" {
+			t.Error("reschedule should stay in progress so another date can be chosen")
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "Выберите новую дату") {
+			t.Errorf("expected the date picker again, got %v", ctx.editedMsg)
+		}
+	})
+}
+
+func TestHandleMyAppointments_RescheduleButton(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	mock := &mockAppointmentService{
+		getCustomerAppointmentsFunc: func(ctx context.Context, id string) ([]domain.Appointment, error) {
+			return []domain.Appointment{
+				{ID: "early", Service: domain.Service{Name: "Massage"}, StartTime: time.Now().Add(100 * time.Hour)},
+				{ID: "late", Service: domain.Service{Name: "Massage"}, StartTime: time.Now().Add(24 * time.Hour)},
+			}, nil
+		},
+	}
+	h := NewBookingHandler(mock, newMockSessionStorage(), nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: 100}}
+
+	if err := h.HandleMyAppointments(ctx); err != nil {
+		t.Fatalf("HandleMyAppointments returned error: %v", err)
+	}
+
+	var moves []string
+	for _, opt := range ctx.sentOpts {
+		markup, ok := opt.(*telebot.ReplyMarkup)
+		if !ok {
+			continue
+		}
+		for _, row := range markup.InlineKeyboard {
+			for _, btn := range row {
+				if btn.Unique == "reschedule_appt" {
+					moves = append(moves, btn.Data)
+				}
+			}
+		}
+	}
+	if len(moves) != 1 || moves[0] != "early" {
+		t.Errorf("only the appointment outside the 72h window should be movable, got %v", moves)
+	}
+	if !contains(ctx.sentMsg, "Отмена и перенос только через терапевта") {
+		t.Errorf("expected late notice, got %q", ctx.sentMsg)
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Admin commands for the working schedule:
+//
+//	/schedule
+//	/schedule_day пн 09:00-13:00 14:00-18:00 перерыв 13:00-14:00
+//	/schedule_day вс выходной
+//	/day_off 2026-12-31 Новый год
+//	/day_hours 2026-12-27 10:00-14:00
+//	/day_reset 2026-12-31
+//
+// Each command also accepts a leading "@ключ" (see booking_therapist.go) to
+// address one therapist instead of the shared schedule.
+const (
+	scheduleDayUsage = "Использование: /schedule_day [@специалист] {день} 09:00-18:00 [перерыв 13:00-14:00]\nДень: пн, вт, ср, чт, пт, сб, вс. Без интервалов или «выходной» — день не рабочий."
+	dayOffUsage      = "Использование: /day_off [@специалист] ГГГГ-ММ-ДД [комментарий]"
+	dayHoursUsage    = "Использование: /day_hours [@специалист] ГГГГ-ММ-ДД 10:00-14:00 [ещё интервалы]"
+	dayResetUsage    = "Использование: /day_reset [@специалист] ГГГГ-ММ-ДД"
+)
+
+// scheduleWeekdays lists weekdays in display order (Monday first).
+var scheduleWeekdays = []time.Weekday{
+	time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+}
+
+var weekdayShortNames = map[time.Weekday]string{
+	time.Monday:    "Пн",
+	time.Tuesday:   "Вт",
+	time.Wednesday: "Ср",
+	time.Thursday:  "Чт",
+	time.Friday:    "Пт",
+	time.Saturday:  "Сб",
+	time.Sunday:    "Вс",
+}
+
+// HandleShowSchedule shows weekly hours and upcoming date exceptions, either
+// shared or as resolved for one therapist (/schedule @ключ).
+func (h *BookingHandler) HandleShowSchedule(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, _, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+
+	schedule, err := h.appointmentService.GetSchedule(context.Background())
+	if err != nil {
+		logging.Errorf(": Failed to load schedule: %v", err)
+		return c.Send("❌ Ошибка при загрузке расписания.")
+	}
+	schedule = schedule.ForTherapist(therapistID)
+
+	var sb strings.Builder
+	if therapistID != "" {
+		sb.WriteString(fmt.Sprintf("🗓 <b>Расписание: %s</b>\n\n", h.therapistName(therapistID)))
+	} else {
+		sb.WriteString("🗓 <b>Рабочее расписание:</b>\n\n")
+	}
+	for _, day := range scheduleWeekdays {
+		var work, breaks []string
+		for _, r := range schedule.Rules {
+			if r.Weekday != day {
+				continue
+			}
+			if r.Kind == domain.ScheduleKindBreak {
+				breaks = append(breaks, r.Range().String())
+			} else {
+				work = append(work, r.Range().String())
+			}
+		}
+		line := "выходной"
+		if len(work) > 0 {
+			line = strings.Join(work, ", ")
+			if len(breaks) > 0 {
+				line += fmt.Sprintf(" (перерыв %s)", strings.Join(breaks, ", "))
+			}
+		}
+		sb.WriteString(fmt.Sprintf("<b>%s:</b> %s\n", weekdayShortNames[day], line))
+	}
+
+	if len(schedule.Exceptions) > 0 {
+		sb.WriteString("\n<b>Особые дни:</b>\n")
+		for _, e := range schedule.Exceptions {
+			hours := "выходной"
+			if !e.Closed {
+				parts := make([]string, len(e.Hours))
+				for i, r := range e.Hours {
+					parts[i] = r.String()
+				}
+				hours = strings.Join(parts, ", ")
+			}
+			sb.WriteString(fmt.Sprintf("%s — %s", e.Date.Format("02.01.2006"), hours))
+			if e.Note != "" {
+				sb.WriteString(fmt.Sprintf(" <i>(%s)</i>", e.Note))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("\n/schedule_day, /day_off, /day_hours, /day_reset")
+	return c.Send(sb.String(), telebot.ModeHTML)
+}
+
+// HandleSetScheduleDay replaces the regular hours of one weekday.
+func (h *BookingHandler) HandleSetScheduleDay(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, args, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	if len(args) < 1 {
+		return c.Send(scheduleDayUsage)
+	}
+
+	weekday, ok := parseWeekday(args[0])
+	if !ok {
+		return c.Send(fmt.Sprintf("❌ Неизвестный день: %s\n%s", args[0], scheduleDayUsage))
+	}
+
+	var work, breaks []domain.MinuteRange
+	inBreaks := false
+	for _, arg := range args[1:] {
+		switch strings.ToLower(arg) {
+		case "перерыв", "break":
+			inBreaks = true
+			continue
+		case "выходной", "off":
+			continue
+		}
+		r, err := domain.ParseMinuteRange(arg)
+		if err != nil {
+			return c.Send(fmt.Sprintf("❌ Неверный интервал: %s\n%s", arg, scheduleDayUsage))
+		}
+		if inBreaks {
+			breaks = append(breaks, r)
+		} else {
+			work = append(work, r)
+		}
+	}
+
+	if err := h.appointmentService.SetWeekdayHours(context.Background(), therapistID, weekday, work, breaks); err != nil {
+		return c.Send(scheduleErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Schedule for %s updated by %d", weekday, c.Sender().ID)
+	if len(work) == 0 {
+		return c.Send(fmt.Sprintf("✅ %s теперь выходной.", weekdayShortNames[weekday]))
+	}
+	return c.Send(fmt.Sprintf("✅ Расписание на %s обновлено.", weekdayShortNames[weekday]))
+}
+
+// HandleDayOff closes a single date (holiday, vacation).
+func (h *BookingHandler) HandleDayOff(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, args, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	if len(args) < 1 {
+		return c.Send(dayOffUsage)
+	}
+	date, err := parseScheduleDate(args[0])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверная дата: %s\n%s", args[0], dayOffUsage))
+	}
+
+	exc := domain.ScheduleException{TherapistID: therapistID, Date: date, Closed: true, Note: strings.Join(args[1:], " ")}
+	if err := h.appointmentService.SaveScheduleException(context.Background(), exc); err != nil {
+		return c.Send(scheduleErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Day off %s set by %d", date.Format("2006-01-02"), c.Sender().ID)
+	return c.Send(fmt.Sprintf("✅ %s — выходной. Уже созданные записи на этот день не отменяются.", date.Format("02.01.2006")))
+}
+
+// HandleDayHours sets special working hours for a single date. It also
+// opens a date that is normally a day off.
+func (h *BookingHandler) HandleDayHours(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, args, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	if len(args) < 2 {
+		return c.Send(dayHoursUsage)
+	}
+	date, err := parseScheduleDate(args[0])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверная дата: %s\n%s", args[0], dayHoursUsage))
+	}
+
+	exc := domain.ScheduleException{TherapistID: therapistID, Date: date}
+	for _, arg := range args[1:] {
+		r, err := domain.ParseMinuteRange(arg)
+		if err != nil {
+			return c.Send(fmt.Sprintf("❌ Неверный интервал: %s\n%s", arg, dayHoursUsage))
+		}
+		exc.Hours = append(exc.Hours, r)
+	}
+
+	if err := h.appointmentService.SaveScheduleException(context.Background(), exc); err != nil {
+		return c.Send(scheduleErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Special hours for %s set by %d", date.Format("2006-01-02"), c.Sender().ID)
+	return c.Send(fmt.Sprintf("✅ Часы работы на %s обновлены.", date.Format("02.01.2006")))
+}
+
+// HandleDayReset removes a date exception, restoring the weekday's hours.
+func (h *BookingHandler) HandleDayReset(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, args, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	if len(args) < 1 {
+		return c.Send(dayResetUsage)
+	}
+	date, err := parseScheduleDate(args[0])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверная дата: %s\n%s", args[0], dayResetUsage))
+	}
+
+	if err := h.appointmentService.DeleteScheduleException(context.Background(), therapistID, date); err != nil {
+		return c.Send(scheduleErrorMessage(err))
+	}
+	return c.Send(fmt.Sprintf("✅ %s — обычное расписание.", date.Format("02.01.2006")))
+}
+
+// parseWeekday accepts Russian or English short names and ISO numbers (1 = Monday).
+func parseWeekday(s string) (time.Weekday, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "пн", "понедельник", "mon", "monday", "1":
+		return time.Monday, true
+	case "вт", "вторник", "tue", "tuesday", "2":
+		return time.Tuesday, true
+	case "ср", "среда", "wed", "wednesday", "3":
+		return time.Wednesday, true
+	case "чт", "четверг", "thu", "thursday", "4":
+		return time.Thursday, true
+	case "пт", "пятница", "fri", "friday", "5":
+		return time.Friday, true
+	case "сб", "суббота", "sat", "saturday", "6":
+		return time.Saturday, true
+	case "вс", "воскресенье", "sun", "sunday", "7", "0":
+		return time.Sunday, true
+	}
+	return 0, false
+}
+
+// parseScheduleDate parses YYYY-MM-DD or DD.MM.YYYY in ApptTimeZone.
+func parseScheduleDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02.01.2006"} {
+		if d, err := time.ParseInLocation(layout, s, domain.ApptTimeZone); err == nil {
+			return d, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+func scheduleErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidSchedule):
+		return "❌ Неверное расписание: интервалы должны быть в пределах суток, начало раньше конца."
+	case errors.Is(err, domain.ErrScheduleUnavailable):
+		return "❌ Расписание не подключено к базе данных."
+	default:
+		logging.Errorf(": Schedule operation failed: %v", err)
+		return "❌ Ошибка при сохранении расписания."
+	}
+}
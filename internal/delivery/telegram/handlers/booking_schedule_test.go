@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func TestScheduleHandlers(t *testing.T) {
+	adminID := "999999"
+	domain.ApptTimeZone = time.UTC
+
+	schedule := domain.WeeklySchedule{
+		Rules: []domain.ScheduleRule{
+			{Weekday: time.Monday, Kind: domain.ScheduleKindWork, StartMinute: 9 * 60, EndMinute: 18 * 60},
+			{Weekday: time.Monday, Kind: domain.ScheduleKindBreak, StartMinute: 13 * 60, EndMinute: 14 * 60},
+		},
+		Exceptions: []domain.ScheduleException{
+			{Date: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), Closed: true, Note: "Новый год"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		handlerMethod func(h *BookingHandler, c telebot.Context) error
+		userID        int64
+		args          []string
+		wantMsg       string
+		check         func(t *testing.T, weekday time.Weekday, work, breaks []domain.MinuteRange, exc *domain.ScheduleException)
+	}{
+		{
+			name:          "Show - Not Admin",
+			handlerMethod: (*BookingHandler).HandleShowSchedule,
+			userID:        123,
+			wantMsg:       "Доступ запрещен",
+		},
+		{
+			name:          "Show - Breaks",
+			handlerMethod: (*BookingHandler).HandleShowSchedule,
+			userID:        999999,
+			wantMsg:       "<b>Пн:</b> 09:00-18:00 (перерыв 13:00-14:00)",
+		},
+		{
+			name:          "Show - Exceptions",
+			handlerMethod: (*BookingHandler).HandleShowSchedule,
+			userID:        999999,
+			wantMsg:       "31.12.2026 — выходной <i>(Новый год)</i>",
+		},
+		{
+			name:          "Day - Unknown Weekday",
+			handlerMethod: (*BookingHandler).HandleSetScheduleDay,
+			userID:        999999,
+			args:          []string{"xx", "9-18"},
+			wantMsg:       "Неизвестный день",
+		},
+		{
+			name:          "Day - Bad Range",
+			handlerMethod: (*BookingHandler).HandleSetScheduleDay,
+			userID:        999999,
+			args:          []string{"пн", "18:00-09:00"},
+			wantMsg:       "Неверный интервал",
+		},
+		{
+			name:          "Day - With Break",
+			handlerMethod: (*BookingHandler).HandleSetScheduleDay,
+			userID:        999999,
+			args:          []string{"сб", "10:00-16:00", "перерыв", "12:00-12:30"},
+			wantMsg:       "Расписание на Сб обновлено",
+			check: func(t *testing.T, weekday time.Weekday, work, breaks []domain.MinuteRange, _ *domain.ScheduleException) {
+				if weekday != time.Saturday || len(work) != 1 || len(breaks) != 1 || breaks[0].End != 12*60+30 {
+					t.Errorf("unexpected update: %v work=%v breaks=%v", weekday, work, breaks)
+				}
+			},
+		},
+		{
+			name:          "Day - Off",
+			handlerMethod: (*BookingHandler).HandleSetScheduleDay,
+			userID:        999999,
+			args:          []string{"вс", "выходной"},
+			wantMsg:       "Вс теперь выходной",
+		},
+		{
+			name:          "Day - Unknown Therapist",
+			handlerMethod: (*BookingHandler).HandleSetScheduleDay,
+			userID:        999999,
+			args:          []string{"@nobody", "пн", "9-18"},
+			wantMsg:       "Специалист не найден",
+		},
+		{
+			name:          "Day Off - Bad Date",
+			handlerMethod: (*BookingHandler).HandleDayOff,
+			userID:        999999,
+			args:          []string{"31-12"},
+			wantMsg:       "Неверная дата",
+		},
+		{
+			name:          "Day Off - Success",
+			handlerMethod: (*BookingHandler).HandleDayOff,
+			userID:        999999,
+			args:          []string{"2026-05-01", "Праздник"},
+			wantMsg:       "01.05.2026 — выходной",
+			check: func(t *testing.T, _ time.Weekday, _, _ []domain.MinuteRange, exc *domain.ScheduleException) {
+				if exc == nil || !exc.Closed || exc.Note != "Праздник" {
+					t.Errorf("unexpected exception: %+v", exc)
+				}
+			},
+		},
+		{
+			name:          "Day Hours - Missing Range",
+			handlerMethod: (*BookingHandler).HandleDayHours,
+			userID:        999999,
+			args:          []string{"2026-05-02"},
+			wantMsg:       "/day_hours",
+		},
+		{
+			name:          "Day Hours - Success",
+			handlerMethod: (*BookingHandler).HandleDayHours,
+			userID:        999999,
+			args:          []string{"02.05.2026", "10-14"},
+			wantMsg:       "Часы работы на 02.05.2026 обновлены",
+			check: func(t *testing.T, _ time.Weekday, _, _ []domain.MinuteRange, exc *domain.ScheduleException) {
+				if exc == nil || exc.Closed || len(exc.Hours) != 1 || exc.Hours[0].Start != 10*60 {
+					t.Errorf("unexpected exception: %+v", exc)
+				}
+			},
+		},
+		{
+			name:          "Day Reset - Success",
+			handlerMethod: (*BookingHandler).HandleDayReset,
+			userID:        999999,
+			args:          []string{"2026-12-31"},
+			wantMsg:       "обычное расписание",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotWeekday      time.Weekday
+				gotWork, gotBrk []domain.MinuteRange
+				gotExc          *domain.ScheduleException
+			)
+			mockApptService := &mockAppointmentService{
+				getScheduleFunc: func(ctx context.Context) (domain.WeeklySchedule, error) {
+					return schedule, nil
+				},
+				setWeekdayHoursFunc: func(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+					gotWeekday, gotWork, gotBrk = weekday, work, breaks
+					return nil
+				},
+				saveScheduleExceptionFunc: func(ctx context.Context, exc domain.ScheduleException) error {
+					gotExc = &exc
+					return nil
+				},
+			}
+
+			handler := NewBookingHandler(
+				mockApptService,
+				newMockSessionStorage(),
+				[]string{adminID},
+				nil,
+				nil,
+				newMockRepository(),
+				&presentation.BotPresenter{},
+				"",
+				"",
+			)
+
+			ctx := &mockContext{
+				sender: &telebot.User{ID: tt.userID},
+				args:   tt.args,
+			}
+
+			if err := tt.handlerMethod(handler, ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if !contains(ctx.sentMsg, tt.wantMsg) {
+				t.Errorf("Expected msg containing %q, got %q", tt.wantMsg, ctx.sentMsg)
+			}
+			if tt.check != nil {
+				tt.check(t, gotWeekday, gotWork, gotBrk, gotExc)
+			}
+		})
+	}
+}
+
+func TestGenerateCalendar_DisablesClosedDays(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	month := time.Date(time.Now().Year()+1, time.March, 1, 0, 0, 0, 0, time.UTC)
+	holiday := time.Date(month.Year(), time.March, 10, 0, 0, 0, 0, time.UTC)
+
+	schedule := domain.DefaultWeeklySchedule()
+	schedule.Exceptions = []domain.ScheduleException{{Date: holiday, Closed: true}}
+
+	handler := NewBookingHandler(
+		&mockAppointmentService{
+			getScheduleFunc: func(ctx context.Context) (domain.WeeklySchedule, error) { return schedule, nil },
+		},
+		newMockSessionStorage(), nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "",
+	)
+
+	markup := handler.generateCalendar(month, "", 0)
+	selectable := make(map[string]bool)
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.Unique == "select_date" {
+				selectable[btn.Data] = true
+			}
+		}
+	}
+
+	for d := month; d.Month() == time.March; d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		want := d.Weekday() != time.Saturday && d.Weekday() != time.Sunday && !d.Equal(holiday)
+		if selectable[key] != want {
+			t.Errorf("%s (%s): selectable=%v, want %v", key, d.Weekday(), selectable[key], want)
+		}
+	}
+}
+
+func TestGenerateCalendar_MarksAvailability(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	month := time.Date(time.Now().Year()+1, time.March, 1, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return month.AddDate(0, 0, d-1) }
+
+	var gotDuration int
+	handler := NewBookingHandler(
+		&mockAppointmentService{
+			getScheduleFunc: func(ctx context.Context) (domain.WeeklySchedule, error) { return domain.DefaultWeeklySchedule(), nil },
+			getMonthAvailabilityFunc: func(ctx context.Context, therapistID string, m time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+				gotDuration = durationMinutes
+				return []domain.DayAvailability{
+					{Date: day(10), Status: domain.DayClosed},
+					{Date: day(11), Status: domain.DayLimited, FreeSlots: 1},
+					{Date: day(12), Status: domain.DayFull},
+					{Date: day(13), Status: domain.DayFree, FreeSlots: 9},
+				}, nil
+			},
+		},
+		newMockSessionStorage(), nil, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "",
+	)
+
+	markup := handler.generateCalendar(month, "", 90)
+	if gotDuration != 90 {
+		t.Errorf("availability asked for %d minutes, want 90", gotDuration)
+	}
+	buttons := make(map[string]telebot.InlineButton)
+	for _, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			buttons[btn.Text] = btn
+		}
+	}
+	for text, unique := range map[string]string{"░10░": "ignore", "11•": "select_date", "12✕": "select_date", "13": "select_date"} {
+		if btn, ok := buttons[text]; !ok || btn.Unique != unique {
+			t.Errorf("expected button %q → %q, got %+v", text, unique, btn)
+		}
+	}
+}
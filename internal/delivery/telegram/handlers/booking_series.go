@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Recurring series (rehabilitation programmes) are booked by an admin:
+//
+//	/series [@специалист] {telegram_id} {service_id} ГГГГ-ММ-ДД 18:00 пн,чт 6
+//
+// Cancelling an occurrence from /myappointments then asks whether to cancel
+// only that session or it and every later one.
+const seriesUsage = "Использование: /series [@специалист] {telegram_id} {id услуги} ГГГГ-ММ-ДД ЧЧ:ММ пн,чт {недель}\nПример: /series 123456789 rehab 2026-03-02 18:00 пн,чт 6"
+
+// HandleBookSeries books a recurring series for a patient. Nothing is booked
+// if any occurrence clashes; the clashes are listed instead.
+func (h *BookingHandler) HandleBookSeries(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapistID, args, err := h.scheduleTherapistArg(c.Args())
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	if len(args) != 6 {
+		return c.Send(seriesUsage)
+	}
+
+	patientID := args[0]
+	patient, err := h.repository.GetPatient(patientID)
+	if err != nil || patient.Name == "" {
+		return c.Send(fmt.Sprintf("❌ Пациент %s не найден. Список: /patients", patientID))
+	}
+	service := h.findCatalogService(args[1])
+	if service == nil {
+		return c.Send(fmt.Sprintf("❌ Услуга %s не найдена. Список: /services", args[1]))
+	}
+	date, err := parseScheduleDate(args[2])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверная дата: %s\n%s", args[2], seriesUsage))
+	}
+	clock, err := time.Parse("15:04", args[3])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверное время: %s\n%s", args[3], seriesUsage))
+	}
+	var weekdays []time.Weekday
+	for _, name := range strings.Split(args[4], ",") {
+		day, ok := parseWeekday(name)
+		if !ok {
+			return c.Send(fmt.Sprintf("❌ Неверный день недели: %s\n%s", name, seriesUsage))
+		}
+		weekdays = append(weekdays, day)
+	}
+	weeks, err := strconv.Atoi(args[5])
+	if err != nil {
+		return c.Send(fmt.Sprintf("❌ Неверное число недель: %s\n%s", args[5], seriesUsage))
+	}
+
+	series, err := h.appointmentService.BookSeries(context.Background(), domain.AppointmentSeries{
+		Service:      *service,
+		CustomerName: patient.Name,
+		CustomerTgID: patientID,
+		TherapistID:  therapistID,
+		FirstStart:   time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, domain.ApptTimeZone),
+		Weekdays:     weekdays,
+		Weeks:        weeks,
+	})
+	if err != nil {
+		return c.Send(seriesErrorMessage(err), telebot.ModeHTML)
+	}
+	for i := range series.Appointments {
+		series.Appointments[i].StartTime = series.Appointments[i].StartTime.In(domain.ApptTimeZone)
+	}
+	logging.Infof("[ADMIN] Series %d booked by %d for patient %s", series.ID, c.Sender().ID, patientID)
+
+	if id, err := strconv.ParseInt(patientID, 10, 64); err == nil {
+		h.BotNotify(c.Bot(), id, h.presenter.FormatSeriesBooked(series, false))
+	}
+	return c.Send(h.presenter.FormatSeriesBooked(series, true), telebot.ModeHTML)
+}
+
+// askSeriesCancelScope offers to cancel one session or the rest of its series.
+// A confirmed late cancellation carries its flag on to the chosen scope.
+func (h *BookingHandler) askSeriesCancelScope(c telebot.Context, appt *domain.Appointment, series *domain.AppointmentSeries, late bool) error {
+	remaining := 0
+	for _, occ := range series.Appointments {
+		if !occ.StartTime.Before(appt.StartTime) {
+			remaining++
+		}
+	}
+	oneData, seriesData := []string{appt.ID, "one"}, []string{appt.ID}
+	if late {
+		oneData, seriesData = append(oneData, lateCancelFlag), append(seriesData, lateCancelFlag)
+	}
+	selector := &telebot.ReplyMarkup{}
+	selector.Inline(
+		selector.Row(selector.Data("Только этот сеанс", "cancel_appt", oneData...)),
+		selector.Row(selector.Data(fmt.Sprintf("Этот и все следующие (%d)", remaining), "cancel_series", seriesData...)),
+	)
+	start := appt.StartTime.In(domain.ApptTimeZone)
+	return c.EditOrSend(fmt.Sprintf("🔁 Запись %s в %s — часть курса «%s».\nЧто отменить?",
+		start.Format("02.01.2006"), start.Format("15:04"), series.Service.Name), selector)
+}
+
+// HandleCancelSeriesCallback cancels an occurrence and the rest of its
+// series. Patients may do so for their own courses under the cancellation
+// policy; admins at any time.
+func (h *BookingHandler) HandleCancelSeriesCallback(c telebot.Context) error {
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) < 2 || parts[1] == "" {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные для отмены."})
+	}
+	userID := c.Sender().ID
+	appointmentID := parts[1]
+	ctx := context.Background()
+
+	appt, err := h.appointmentService.FindByID(ctx, appointmentID)
+	if err != nil || appt == nil {
+		logging.Warnf(": Appointment %s for series cancel not found: %v", appointmentID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Запись не найдена. Возможно, она уже отменена."})
+	}
+	isAdmin := h.IsAdmin(userID)
+	if !isAdmin {
+		if appt.CustomerTgID != strconv.FormatInt(userID, 10) {
+			return c.Respond(&telebot.CallbackResponse{Text: "⛔ Доступ запрещен."})
+		}
+	}
+	late, handled, err := h.checkLateCancel(c, appt, parts)
+	if handled {
+		return err
+	}
+
+	cancelled, err := h.appointmentService.CancelSeries(ctx, appointmentID)
+	if err != nil && len(cancelled) == 0 {
+		logging.Errorf(": Failed to cancel series from %s: %v", appointmentID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Не удалось отменить курс. Попробуйте позже."})
+	}
+	if err != nil {
+		logging.Errorf(": Series cancel from %s stopped after %d appointments: %v", appointmentID, len(cancelled), err)
+	}
+	policy, now := h.appointmentService.GetCancellationPolicy(), time.Now()
+	for i := range cancelled {
+		h.recordStatus(ctx, &cancelled[i], h.cancelStatusFor(userID, late && policy.IsLate(cancelled[i].StartTime, now)))
+		cancelled[i].StartTime = cancelled[i].StartTime.In(domain.ApptTimeZone)
+	}
+
+	staffMsg := h.presenter.FormatSeriesCancellation(cancelled, true)
+	for _, recipient := range h.bookingRecipients(appt) {
+		if recipient != userID {
+			h.BotNotify(c.Bot(), recipient, staffMsg)
+		}
+	}
+	if patientID, err := strconv.ParseInt(appt.CustomerTgID, 10, 64); err == nil && patientID != userID {
+		h.BotNotify(c.Bot(), patientID, h.presenter.FormatSeriesCancellation(cancelled, false))
+	}
+	if _, err := h.syncPatientStats(ctx, appt.CustomerTgID, appt.CustomerName); err != nil {
+		logging.Warnf("Failed to sync patient stats after series cancellation: %v", err)
+	}
+
+	if err := c.Respond(&telebot.CallbackResponse{Text: fmt.Sprintf("Отменено сеансов: %d", len(cancelled))}); err != nil {
+		logging.Warnf("Failed to respond to callback: %v", err)
+	}
+	if err := c.Edit(h.presenter.FormatSeriesCancellation(cancelled, isAdmin), telebot.ModeHTML); err != nil {
+		logging.Warnf("Failed to edit series cancellation message: %v", err)
+	}
+	return h.HandleMyAppointments(c)
+}
+
+func seriesErrorMessage(err error) string {
+	var conflicts *domain.SeriesConflictError
+	switch {
+	case errors.As(err, &conflicts):
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("❌ <b>Курс не создан:</b> %d из %d сеансов не помещаются.\n\n", len(conflicts.Conflicts), conflicts.Total))
+		for _, cf := range conflicts.Conflicts {
+			start := cf.Start.In(domain.ApptTimeZone)
+			sb.WriteString(fmt.Sprintf("• %s %s в %s — %s\n", weekdayShortNames[start.Weekday()],
+				start.Format("02.01.2006"), start.Format("15:04"), seriesConflictReason(cf.Err)))
+		}
+		sb.WriteString("\nИзмените время, дни или дату начала и повторите.")
+		return sb.String()
+	case errors.Is(err, domain.ErrInvalidSeries):
+		return fmt.Sprintf("❌ Неверные параметры курса (от 1 до %d недель).\n%s", domain.MaxSeriesWeeks, seriesUsage)
+	case errors.Is(err, domain.ErrSeriesUnavailable):
+		return "❌ Курсы не подключены к базе данных."
+	case errors.Is(err, domain.ErrTherapistNotFound):
+		return "❌ Специалист не найден или в архиве. Список: /therapists"
+	default:
+		logging.Errorf(": Series booking failed: %v", err)
+		return "❌ Не удалось создать курс. Пожалуйста, попробуйте позже."
+	}
+}
+
+func seriesConflictReason(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrSlotUnavailable):
+		return "время занято"
+	case errors.Is(err, domain.ErrOutsideWorkingHours):
+		return "вне рабочего графика"
+	case errors.Is(err, domain.ErrAppointmentInPast):
+		return "уже прошло"
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func newSeriesTestHandler(mock *mockAppointmentService) *BookingHandler {
+	repo := newMockRepository()
+	repo.patients["100"] = domain.Patient{TelegramID: "100", Name: "Иван Петров"}
+	if mock.getAllServicesFunc == nil {
+		mock.getAllServicesFunc = func(ctx context.Context) ([]domain.Service, error) {
+			return []domain.Service{{ID: "rehab", Name: "Реабилитация", DurationMinutes: 60}}, nil
+		}
+	}
+	return NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+}
+
+func TestHandleBookSeries(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	args := []string{"100", "rehab", "2030-03-04", "18:00", "пн,чт", "6"}
+
+	t.Run("books the series", func(t *testing.T) {
+		var got domain.AppointmentSeries
+		mock := &mockAppointmentService{bookSeriesFunc: func(ctx context.Context, s domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+			got = s
+			s.ID = 1
+			for _, start := range s.Occurrences() {
+				s.Appointments = append(s.Appointments, domain.Appointment{StartTime: start})
+			}
+			return &s, nil
+		}}
+		h := newSeriesTestHandler(mock)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: args, bot: bot}
+
+		if err := h.HandleBookSeries(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if got.CustomerTgID != "100" || got.CustomerName != "Иван Петров" || got.Service.ID != "rehab" || got.Weeks != 6 {
+			t.Errorf("unexpected series: %+v", got)
+		}
+		if got.FirstStart.Format("2006-01-02 15:04") != "2030-03-04 18:00" || len(got.Weekdays) != 2 || got.Weekdays[1] != time.Thursday {
+			t.Errorf("unexpected start/weekdays: %v %v", got.FirstStart, got.Weekdays)
+		}
+		if !contains(ctx.sentMsg, "СЕРИЯ ЗАПИСЕЙ СОЗДАНА") || !contains(ctx.sentMsg, "Сеансов:</b> 12") {
+			t.Errorf("expected the series summary, got %q", ctx.sentMsg)
+		}
+	})
+
+	t.Run("lists clashes", func(t *testing.T) {
+		clash := time.Date(2030, 3, 12, 18, 0, 0, 0, time.UTC)
+		mock := &mockAppointmentService{bookSeriesFunc: func(ctx context.Context, s domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+			return nil, &domain.SeriesConflictError{Total: 12, Conflicts: []domain.SeriesConflict{{Start: clash, Err: domain.ErrSlotUnavailable}}}
+		}}
+		h := newSeriesTestHandler(mock)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, args: args, bot: bot}
+
+		if err := h.HandleBookSeries(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		for _, want := range []string{"Курс не создан", "1 из 12", "12.03.2030 в 18:00 — время занято"} {
+			if !contains(ctx.sentMsg, want) {
+				t.Errorf("expected %q in %q", want, ctx.sentMsg)
+			}
+		}
+	})
+
+	t.Run("rejects bad input", func(t *testing.T) {
+		h := newSeriesTestHandler(&mockAppointmentService{})
+		cases := map[string]struct {
+			user int64
+			args []string
+			want string
+		}{
+			"not admin":       {100, args, "Доступ запрещен"},
+			"missing args":    {999, args[:5], "Использование"},
+			"unknown patient": {999, []string{"555", "rehab", "2030-03-04", "18:00", "пн", "6"}, "Пациент 555 не найден"},
+			"unknown service": {999, []string{"100", "nope", "2030-03-04", "18:00", "пн", "6"}, "Услуга nope не найдена"},
+			"bad weekday":     {999, []string{"100", "rehab", "2030-03-04", "18:00", "пн,xx", "6"}, "Неверный день недели"},
+		}
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				ctx := &mockContext{sender: &telebot.User{ID: tc.user}, args: tc.args}
+				if err := h.HandleBookSeries(ctx); err != nil {
+					t.Fatalf("Handler returned error: %v", err)
+				}
+				if !contains(ctx.sentMsg, tc.want) {
+					t.Errorf("expected %q in %q", tc.want, ctx.sentMsg)
+				}
+			})
+		}
+	})
+}
+
+func TestHandleCancelAppointment_SeriesScope(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	start := time.Now().Add(100 * time.Hour).Truncate(time.Minute)
+	appt := &domain.Appointment{ID: "a2", CustomerTgID: "100", StartTime: start}
+	series := &domain.AppointmentSeries{
+		Service:      domain.Service{Name: "Реабилитация"},
+		Appointments: []domain.Appointment{{ID: "a1", StartTime: start.AddDate(0, 0, -3)}, {ID: "a2", StartTime: start}, {ID: "a3", StartTime: start.AddDate(0, 0, 4)}},
+	}
+	var cancelled []string
+	mock := &mockAppointmentService{
+		findByIDFunc: func(ctx context.Context, id string) (*domain.Appointment, error) { return appt, nil },
+		getAppointmentSeriesFunc: func(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+			return series, nil
+		},
+		cancelAppointmentFunc: func(ctx context.Context, id string) error {
+			cancelled = append(cancelled, id)
+			return nil
+		},
+	}
+	h := newSeriesTestHandler(mock)
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a2"}, bot: bot}
+	if err := h.HandleCancelAppointmentCallback(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if msg, _ := ctx.editedMsg.(string); !contains(msg, "часть курса «Реабилитация»") || len(cancelled) != 0 {
+		t.Fatalf("expected the scope question and no cancellation, got %v / %v", ctx.editedMsg, cancelled)
+	}
+
+	ctx = &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "cancel_appt|a2|one"}, bot: bot}
+	if err := h.HandleCancelAppointmentCallback(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+	if len(cancelled) != 1 || cancelled[0] != "a2" {
+		t.Errorf("cancelled = %v, want only a2", cancelled)
+	}
+}
+
+func TestHandleCancelSeriesCallback(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	in := func(d time.Duration) time.Time { return time.Now().Add(d).Truncate(time.Minute) }
+
+	tests := []struct {
+		name       string
+		userID     int64
+		start      time.Time
+		wantAlert  string
+		wantCancel bool
+	}{
+		{"patient cancels own course", 100, in(100 * time.Hour), "Отменено сеансов: 2", true},
+		{"patient too late", 100, in(48 * time.Hour), "меньше 3 дней", false},
+		{"someone else's course", 200, in(100 * time.Hour), "Доступ запрещен", false},
+		{"admin cancels late course", 999, in(2 * time.Hour), "Отменено сеансов: 2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appt := &domain.Appointment{ID: "a2", CustomerTgID: "100", CustomerName: "Иван", StartTime: tt.start}
+			called := false
+			mock := &mockAppointmentService{
+				findByIDFunc: func(ctx context.Context, id string) (*domain.Appointment, error) { return appt, nil },
+				cancelSeriesFunc: func(ctx context.Context, id string) ([]domain.Appointment, error) {
+					called = true
+					return []domain.Appointment{*appt, {ID: "a3", StartTime: tt.start.AddDate(0, 0, 4)}}, nil
+				},
+			}
+			h := newSeriesTestHandler(mock)
+			ctx := &mockContext{sender: &telebot.User{ID: tt.userID}, callback: &telebot.Callback{Data: "cancel_series|a2"}, bot: bot}
+
+			if err := h.HandleCancelSeriesCallback(ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if called != tt.wantCancel {
+				t.Errorf("CancelSeries called = %v, want %v", called, tt.wantCancel)
+			}
+			if ctx.response == nil || !contains(ctx.response.Text, tt.wantAlert) {
+				t.Errorf("expected response containing %q, got %+v", tt.wantAlert, ctx.response)
+			}
+		})
+	}
+}
@@ -12,5 +12,9 @@ const (
 	SessionKeyIsAdminBlock         = "is_admin_block"
 	SessionKeyIsAdminManual        = "is_admin_manual"
 	SessionKeyAdminReplyingTo      = "admin_replying_to"
-	SessionKeyPatientID            = "patient_id" // For manual booking
+	SessionKeyPatientID            = "patient_id"    // For manual booking
+	SessionKeyTherapist            = "therapist"     // Chosen therapist ID; empty means any available
+	SessionKeyRescheduleID         = "reschedule_id" // Appointment being moved instead of booked
+	SessionKeyPromoCode            = "promo_code"    // Promo code entered at confirmation
+	SessionKeyAwaitingPromo        = "awaiting_promo"
 )
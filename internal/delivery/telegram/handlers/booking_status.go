@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Lifecycle statuses are recorded next to the existing flows: reminders mark
+// confirmations, cancel buttons mark who cancelled, and the reminder service
+// asks admins for the outcome of each visit; those buttons land in
+// HandleAppointmentOutcome.
+
+// recordStatus moves an appointment to status, best effort: the booking
+// itself has already changed, so failures are only logged.
+func (h *BookingHandler) recordStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) {
+	if appt == nil {
+		return
+	}
+	err := h.appointmentService.SetAppointmentStatus(ctx, appt, status)
+	if err != nil && !errors.Is(err, domain.ErrStatusUnavailable) {
+		logging.Warnf("Failed to record status %s for appointment %s: %v", status, appt.ID, err)
+	}
+}
+
+// cancellationStatus tells who cancelled: admins or the patient.
+func (h *BookingHandler) cancellationStatus(userID int64) domain.AppointmentStatus {
+	if h.IsAdmin(userID) {
+		return domain.StatusCancelledByAdmin
+	}
+	return domain.StatusCancelledByPatient
+}
+
+// noShowCount returns how many visits the patient missed, 0 when unknown.
+func (h *BookingHandler) noShowCount(telegramID string) int {
+	if h.appointmentService == nil {
+		return 0
+	}
+	counts, err := h.appointmentService.GetPatientStatusCounts(context.Background(), telegramID)
+	if err != nil {
+		logging.Warnf("Failed to count statuses of %s: %v", telegramID, err)
+		return 0
+	}
+	return counts[domain.StatusNoShow]
+}
+
+// HandleAppointmentOutcome records how a visit went from the admin prompt
+// ("appt_outcome|id|status").
+func (h *BookingHandler) HandleAppointmentOutcome(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Respond(&telebot.CallbackResponse{Text: "⛔ Доступ запрещен."})
+	}
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) < 3 || parts[1] == "" {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные."})
+	}
+	appointmentID, status := parts[1], domain.AppointmentStatus(parts[2])
+	ctx := context.Background()
+
+	appt, err := h.appointmentService.FindByID(ctx, appointmentID)
+	if err != nil || appt == nil {
+		logging.Warnf(": Appointment %s for outcome not found: %v", appointmentID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Запись не найдена."})
+	}
+
+	if err := h.appointmentService.SetAppointmentStatus(ctx, appt, status); err != nil {
+		var text string
+		switch {
+		case errors.Is(err, domain.ErrInvalidStatusChange):
+			current, _ := h.appointmentService.GetAppointmentStatus(ctx, appointmentID)
+			text = fmt.Sprintf("⛔ Нельзя отметить «%s»: запись уже в статусе «%s».", status.Label(), current.Label())
+		case errors.Is(err, domain.ErrOutcomeBeforeStart):
+			text = "⛔ Визит ещё не начался."
+		default:
+			logging.Errorf(": Failed to set outcome %s for %s: %v", status, appointmentID, err)
+			text = "Не удалось сохранить статус. Попробуйте позже."
+		}
+		return c.Respond(&telebot.CallbackResponse{Text: text, ShowAlert: true})
+	}
+
+	if err := c.Respond(&telebot.CallbackResponse{Text: "Статус сохранён"}); err != nil {
+		logging.Warnf("Failed to respond to callback: %v", err)
+	}
+	start := appt.StartTime.In(domain.ApptTimeZone)
+	return c.Edit(fmt.Sprintf("📋 %s — %s в %s\nСтатус: <b>%s</b>",
+		appt.CustomerName, start.Format("02.01.2006"), start.Format("15:04"), status.Label()), telebot.ModeHTML)
+}
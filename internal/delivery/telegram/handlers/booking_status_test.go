@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+func TestHandleAppointmentOutcome(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	appt := &domain.Appointment{ID: "a1", CustomerName: "Иван", StartTime: time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)}
+
+	newHandler := func(setErr error, set *domain.AppointmentStatus) *BookingHandler {
+		mock := &mockAppointmentService{
+			findByIDFunc: func(ctx context.Context, id string) (*domain.Appointment, error) {
+				if id != appt.ID {
+					return nil, domain.ErrAppointmentNotFound
+				}
+				return appt, nil
+			},
+			setAppointmentStatusFunc: func(ctx context.Context, a *domain.Appointment, status domain.AppointmentStatus) error {
+				if setErr != nil {
+					return setErr
+				}
+				*set = status
+				return nil
+			},
+			getAppointmentStatusFunc: func(ctx context.Context, id string) (domain.AppointmentStatus, error) {
+				return domain.StatusCompleted, nil
+			},
+		}
+		return NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+	}
+
+	t.Run("records the outcome", func(t *testing.T) {
+		var set domain.AppointmentStatus
+		h := newHandler(nil, &set)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, callback: &telebot.Callback{Data: "appt_outcome|a1|no_show"}}
+
+		if err := h.HandleAppointmentOutcome(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if set != domain.StatusNoShow {
+			t.Errorf("expected no_show to be recorded, got %q", set)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "Неявка") || !contains(msg, "09.01.2030") {
+			t.Errorf("unexpected edited message: %q", msg)
+		}
+	})
+
+	t.Run("rejects invalid transitions", func(t *testing.T) {
+		var set domain.AppointmentStatus
+		h := newHandler(fmt.Errorf("%w: completed → late_cancel", domain.ErrInvalidStatusChange), &set)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, callback: &telebot.Callback{Data: "appt_outcome|a1|late_cancel"}}
+
+		if err := h.HandleAppointmentOutcome(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if ctx.response == nil || !ctx.response.ShowAlert || !contains(ctx.response.Text, "Состоялся") {
+			t.Errorf("expected an alert naming the current status, got %+v", ctx.response)
+		}
+		if ctx.editedMsg != nil {
+			t.Error("message should not change on a rejected transition")
+		}
+	})
+
+	t.Run("admins only", func(t *testing.T) {
+		var set domain.AppointmentStatus
+		h := newHandler(nil, &set)
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "appt_outcome|a1|completed"}}
+
+		_ = h.HandleAppointmentOutcome(ctx)
+		if set != "" || ctx.response == nil || !contains(ctx.response.Text, "Доступ запрещен") {
+			t.Errorf("non-admin must not set statuses, got %q / %+v", set, ctx.response)
+		}
+	})
+
+	t.Run("unknown appointment", func(t *testing.T) {
+		var set domain.AppointmentStatus
+		h := newHandler(nil, &set)
+		ctx := &mockContext{sender: &telebot.User{ID: 999}, callback: &telebot.Callback{Data: "appt_outcome|gone|completed"}}
+
+		_ = h.HandleAppointmentOutcome(ctx)
+		if set != "" || ctx.response == nil || !contains(ctx.response.Text, "не найдена") {
+			t.Errorf("expected not-found response, got %+v", ctx.response)
+		}
+	})
+}
+
+func TestHandleCancelAppointmentCallback_RecordsStatus(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+	appt := &domain.Appointment{ID: "a1", CustomerTgID: "100", StartTime: time.Now().Add(100 * time.Hour)}
+
+	for _, tt := range []struct {
+		sender int64
+		want   domain.AppointmentStatus
+	}{
+		{100, domain.StatusCancelledByPatient},
+		{999, domain.StatusCancelledByAdmin},
+	} {
+		var set domain.AppointmentStatus
+		mock := &mockAppointmentService{
+			findByIDFunc: func(ctx context.Context, id string) (*domain.Appointment, error) { return appt, nil },
+			getAppointmentSeriesFunc: func(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+				return nil, domain.ErrSeriesNotFound
+			},
+			setAppointmentStatusFunc: func(ctx context.Context, a *domain.Appointment, status domain.AppointmentStatus) error {
+				set = status
+				return nil
+			},
+		}
+		h := NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+		ctx := &mockContext{sender: &telebot.User{ID: tt.sender}, callback: &telebot.Callback{Data: "cancel_appt|a1"}, bot: bot}
+
+		_ = h.HandleCancelAppointmentCallback(ctx)
+		if set != tt.want {
+			t.Errorf("cancel by %d recorded %q, want %q", tt.sender, set, tt.want)
+		}
+	}
+}
+
+func TestHandleMyRecords_ShowsNoShows(t *testing.T) {
+	repo := newMockRepository()
+	repo.patients["123"] = domain.Patient{TelegramID: "123", Name: "John Doe"}
+	mock := &mockAppointmentService{
+		getPatientStatusCountsFunc: func(ctx context.Context, id string) (map[domain.AppointmentStatus]int, error) {
+			return map[domain.AppointmentStatus]int{domain.StatusNoShow: 2, domain.StatusCompleted: 5}, nil
+		},
+	}
+	h := NewBookingHandler(mock, newMockSessionStorage(), nil, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+	ctx := &mockContext{sender: &telebot.User{ID: 123}}
+
+	if err := h.HandleMyRecords(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "НЕЯВОК:</b> 2") {
+		t.Errorf("expected the no-show count on the card, got %q", ctx.sentMsg)
+	}
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"gopkg.in/telebot.v3"
+)
+
+// Admin commands for the therapist registry. Fields are pipe-separated like
+// the catalog commands:
+//
+//	/therapists
+//	/therapist_save {ключ} | Имя | telegram_id | calendar_id | порядок
+//	/therapist_archive {ключ}
+//	/therapist_restore {ключ}
+//
+// Schedule commands take an optional leading "@ключ" to edit one therapist's
+// hours instead of the shared schedule.
+const (
+	therapistSaveUsage = "Использование: /therapist_save {ключ} | Имя | telegram_id | calendar_id | порядок\nПустой calendar_id — основной календарь."
+
+	// therapistAny is the callback value for "any available therapist".
+	therapistAny = "any"
+)
+
+// askForTherapist shows the therapist step when more than one therapist is
+// bookable. With one therapist it is chosen silently; with none (or if the
+// registry cannot be read) the flow continues in single-practitioner mode.
+func (h *BookingHandler) askForTherapist(c telebot.Context, serviceName string) error {
+	userID := c.Sender().ID
+	therapists, err := h.appointmentService.GetTherapists(context.Background(), false)
+	if err != nil {
+		logging.Warnf(": Failed to load therapists, skipping therapist step: %v", err)
+		therapists = nil
+	}
+
+	switch len(therapists) {
+	case 0:
+		h.sessionStorage.Set(userID, SessionKeyTherapist, "")
+		return h.askForDate(c, serviceName)
+	case 1:
+		h.sessionStorage.Set(userID, SessionKeyTherapist, therapists[0].ID)
+		return h.askForDate(c, serviceName)
+	}
+
+	selector := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, t := range therapists {
+		rows = append(rows, selector.Row(selector.Data("👤 "+t.Name, "select_therapist", t.ID)))
+	}
+	rows = append(rows, selector.Row(selector.Data("🎲 Любой свободный специалист", "select_therapist", therapistAny)))
+	rows = append(rows, selector.Row(selector.Data("⬅️ Назад к выбору услуги", "back_to_services")))
+	selector.Inline(rows...)
+
+	return c.EditOrSend(fmt.Sprintf("Услуга '%s' выбрана. К кому вы хотите записаться?", serviceName), selector)
+}
+
+// HandleTherapistSelection stores the chosen therapist and moves on to the date.
+func (h *BookingHandler) HandleTherapistSelection(c telebot.Context) error {
+	data := strings.TrimSpace(c.Callback().Data)
+	parts := strings.Split(data, "|")
+	if len(parts) != 2 || parts[0] != "select_therapist" {
+		logging.Errorf(": Malformed therapist selection callback data: %s", data)
+		return c.Edit("Некорректный выбор специалиста. Пожалуйста, попробуйте /start снова.")
+	}
+
+	userID := c.Sender().ID
+	service, ok := h.sessionStorage.Get(userID)[SessionKeyService].(domain.Service)
+	if !ok {
+		return h.showCategories(c)
+	}
+
+	therapistID := parts[1]
+	if therapistID == therapistAny {
+		therapistID = ""
+	}
+	h.sessionStorage.Set(userID, SessionKeyTherapist, therapistID)
+	logging.Debugf(": Therapist %q selected by user %d", therapistID, userID)
+
+	return h.askForDate(c, service.Name)
+}
+
+// sessionTherapist returns the therapist chosen in the booking flow, or ""
+// for "any available" / single-practitioner mode.
+func (h *BookingHandler) sessionTherapist(userID int64) string {
+	id, _ := h.sessionStorage.Get(userID)[SessionKeyTherapist].(string)
+	return id
+}
+
+// therapistName resolves a therapist ID for display; unknown IDs are shown as is.
+func (h *BookingHandler) therapistName(id string) string {
+	therapists, err := h.appointmentService.GetTherapists(context.Background(), true)
+	if err == nil {
+		for _, t := range therapists {
+			if t.ID == id {
+				return t.Name
+			}
+		}
+	}
+	return id
+}
+
+// bookingRecipients returns who is told about a booking: the therapist it
+// belongs to when their Telegram ID is known, otherwise every admin and
+// configured therapist as before multi-therapist support.
+func (h *BookingHandler) bookingRecipients(appt *domain.Appointment) []int64 {
+	if appt.TherapistID != "" {
+		therapists, err := h.appointmentService.GetTherapists(context.Background(), true)
+		if err != nil {
+			logging.Warnf(": Failed to load therapists for notification, notifying all admins: %v", err)
+		}
+		for _, t := range therapists {
+			if t.ID != appt.TherapistID || t.TelegramID == "" {
+				continue
+			}
+			if id, err := strconv.ParseInt(t.TelegramID, 10, 64); err == nil {
+				return []int64{id}
+			}
+		}
+	}
+
+	var recipients []int64
+	for _, idStr := range append(append([]string{}, h.adminIDs...), h.therapistIDs...) {
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+		recipients = append(recipients, id)
+	}
+	return recipients
+}
+
+// HandleListTherapists shows the registry, including archived therapists.
+func (h *BookingHandler) HandleListTherapists(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	therapists, err := h.appointmentService.GetTherapists(context.Background(), true)
+	if err != nil {
+		logging.Errorf(": Failed to list therapists: %v", err)
+		return c.Send("❌ Ошибка при получении списка специалистов.")
+	}
+	if len(therapists) == 0 {
+		return c.Send("Специалисты не заведены — запись идёт в основной календарь.\n\n" + therapistSaveUsage)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("👥 <b>Специалисты:</b>\n\n")
+	for _, t := range therapists {
+		calendar := t.CalendarID
+		if calendar == "" {
+			calendar = "основной календарь"
+		}
+		status := ""
+		if !t.Active {
+			status = " 🗄 <i>(в архиве)</i>"
+		}
+		sb.WriteString(fmt.Sprintf("<b>%s</b> — %s [%s]%s\n", t.ID, t.Name, calendar, status))
+		if t.TelegramID != "" {
+			sb.WriteString(fmt.Sprintf("    уведомления: %s\n", t.TelegramID))
+		}
+	}
+	sb.WriteString("\n/therapist_save, /therapist_archive, /therapist_restore\nЛичное расписание: /schedule_day @ключ пн 10:00-16:00")
+	return c.Send(sb.String(), telebot.ModeHTML)
+}
+
+// HandleSaveTherapist creates or updates a therapist. Omitted trailing
+// fields keep their current values when the therapist already exists.
+func (h *BookingHandler) HandleSaveTherapist(c telebot.Context) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	fields := splitServiceFields(c.Args())
+	if len(fields) < 2 {
+		return c.Send(therapistSaveUsage)
+	}
+
+	t := domain.Therapist{ID: fields[0], Active: true}
+	if existing, err := h.findTherapist(fields[0]); err == nil {
+		t = *existing
+	}
+	if fields[1] != "" {
+		t.Name = fields[1]
+	}
+	if len(fields) > 2 {
+		if fields[2] != "" {
+			if _, err := strconv.ParseInt(fields[2], 10, 64); err != nil {
+				return c.Send(fmt.Sprintf("❌ неверный telegram_id: %s\n%s", fields[2], therapistSaveUsage))
+			}
+		}
+		t.TelegramID = fields[2]
+	}
+	if len(fields) > 3 {
+		t.CalendarID = fields[3]
+	}
+	if len(fields) > 4 && fields[4] != "" {
+		order, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return c.Send(fmt.Sprintf("❌ неверный порядок: %s\n%s", fields[4], therapistSaveUsage))
+		}
+		t.SortOrder = order
+	}
+
+	if err := h.appointmentService.SaveTherapist(context.Background(), t); err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+
+	logging.Infof("[ADMIN] Therapist %s saved by %d", t.ID, c.Sender().ID)
+	return c.Send(fmt.Sprintf("✅ Специалист сохранён: <b>%s</b> (%s)", t.Name, t.ID), telebot.ModeHTML)
+}
+
+// HandleArchiveTherapist stops offering a therapist for booking. Their
+// existing appointments are kept.
+func (h *BookingHandler) HandleArchiveTherapist(c telebot.Context) error {
+	return h.setTherapistActive(c, false)
+}
+
+// HandleRestoreTherapist offers an archived therapist for booking again.
+func (h *BookingHandler) HandleRestoreTherapist(c telebot.Context) error {
+	return h.setTherapistActive(c, true)
+}
+
+func (h *BookingHandler) setTherapistActive(c telebot.Context, active bool) error {
+	if !h.IsAdmin(c.Sender().ID) {
+		return c.Send("⛔ Доступ запрещен.")
+	}
+
+	args := c.Args()
+	if len(args) < 1 {
+		if active {
+			return c.Send("Использование: /therapist_restore {ключ}")
+		}
+		return c.Send("Использование: /therapist_archive {ключ}")
+	}
+
+	t, err := h.findTherapist(args[0])
+	if err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+	t.Active = active
+	if err := h.appointmentService.SaveTherapist(context.Background(), *t); err != nil {
+		return c.Send(therapistErrorMessage(err))
+	}
+
+	if active {
+		return c.Send(fmt.Sprintf("✅ %s снова доступен(а) для записи.", t.Name))
+	}
+	return c.Send(fmt.Sprintf("🗄 %s больше не доступен(а) для записи. Существующие записи сохранены.", t.Name))
+}
+
+// findTherapist looks a therapist up by key, including archived ones.
+func (h *BookingHandler) findTherapist(id string) (*domain.Therapist, error) {
+	therapists, err := h.appointmentService.GetTherapists(context.Background(), true)
+	if err != nil {
+		return nil, err
+	}
+	for i := range therapists {
+		if therapists[i].ID == id {
+			return &therapists[i], nil
+		}
+	}
+	return nil, domain.ErrTherapistNotFound
+}
+
+// scheduleTherapistArg strips an optional leading "@ключ" from schedule
+// command arguments and checks that the therapist exists.
+func (h *BookingHandler) scheduleTherapistArg(args []string) (string, []string, error) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "@") {
+		return "", args, nil
+	}
+	id := strings.TrimPrefix(args[0], "@")
+	if _, err := h.findTherapist(id); err != nil {
+		return "", nil, err
+	}
+	return id, args[1:], nil
+}
+
+func therapistErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTherapist):
+		return "❌ Неверные данные: нужны ключ (без пробелов и «|», до 32 символов) и имя."
+	case errors.Is(err, domain.ErrTherapistNotFound):
+		return "❌ Специалист не найден. Список: /therapists"
+	case errors.Is(err, domain.ErrScheduleUnavailable):
+		return "❌ Реестр специалистов не подключен к базе данных."
+	default:
+		logging.Errorf(": Therapist operation failed: %v", err)
+		return "❌ Ошибка при сохранении специалиста."
+	}
+}
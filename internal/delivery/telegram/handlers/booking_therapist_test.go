@@ -0,0 +1,286 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+var testTherapists = []domain.Therapist{
+	{ID: "anna", Name: "Анна", TelegramID: "111", Active: true, SortOrder: 1},
+	{ID: "boris", Name: "Борис", CalendarID: "boris@group.calendar.google.com", Active: true, SortOrder: 2},
+	{ID: "old", Name: "Вера", Active: false, SortOrder: 3},
+}
+
+func newTherapistTestHandler(therapists []domain.Therapist, saved *domain.Therapist) *BookingHandler {
+	mock := &mockAppointmentService{
+		getTherapistsFunc: func(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+			var out []domain.Therapist
+			for _, t := range therapists {
+				if includeInactive || t.Active {
+					out = append(out, t)
+				}
+			}
+			return out, nil
+		},
+		saveTherapistFunc: func(ctx context.Context, t domain.Therapist) error {
+			if saved != nil {
+				*saved = t
+			}
+			return nil
+		},
+	}
+	return NewBookingHandler(mock, newMockSessionStorage(), []string{"999999"}, []string{"555"}, nil, newMockRepository(), &presentation.BotPresenter{}, "", "")
+}
+
+func TestTherapistHandlers(t *testing.T) {
+	tests := []struct {
+		name          string
+		handlerMethod func(h *BookingHandler, c telebot.Context) error
+		userID        int64
+		args          []string
+		therapists    []domain.Therapist
+		wantMsg       string
+		check         func(t *testing.T, saved domain.Therapist)
+	}{
+		{
+			name:          "List - Not Admin",
+			handlerMethod: (*BookingHandler).HandleListTherapists,
+			userID:        123,
+			wantMsg:       "Доступ запрещен",
+		},
+		{
+			name:          "List - Empty",
+			handlerMethod: (*BookingHandler).HandleListTherapists,
+			userID:        999999,
+			wantMsg:       "Специалисты не заведены",
+		},
+		{
+			name:          "List - Shows Calendar And Archive",
+			handlerMethod: (*BookingHandler).HandleListTherapists,
+			userID:        999999,
+			therapists:    testTherapists,
+			wantMsg:       "<b>old</b> — Вера [основной календарь] 🗄 <i>(в архиве)</i>",
+		},
+		{
+			name:          "Save - Usage",
+			handlerMethod: (*BookingHandler).HandleSaveTherapist,
+			userID:        999999,
+			args:          []string{"anna"},
+			wantMsg:       "Использование: /therapist_save",
+		},
+		{
+			name:          "Save - Bad Telegram ID",
+			handlerMethod: (*BookingHandler).HandleSaveTherapist,
+			userID:        999999,
+			args:          []string{"anna", "|", "Анна", "|", "abc"},
+			wantMsg:       "неверный telegram_id",
+		},
+		{
+			name:          "Save - New",
+			handlerMethod: (*BookingHandler).HandleSaveTherapist,
+			userID:        999999,
+			args:          []string{"dina", "|", "Дина", "|", "222", "|", "dina@group.calendar.google.com", "|", "4"},
+			wantMsg:       "Специалист сохранён: <b>Дина</b>",
+			check: func(t *testing.T, saved domain.Therapist) {
+				if saved.ID != "dina" || saved.TelegramID != "222" || saved.SortOrder != 4 || !saved.Active {
+					t.Errorf("unexpected saved therapist: %+v", saved)
+				}
+			},
+		},
+		{
+			name:          "Save - Rename Keeps Other Fields",
+			handlerMethod: (*BookingHandler).HandleSaveTherapist,
+			userID:        999999,
+			args:          []string{"boris", "|", "Борис Петров"},
+			therapists:    testTherapists,
+			wantMsg:       "Борис Петров",
+			check: func(t *testing.T, saved domain.Therapist) {
+				if saved.CalendarID != "boris@group.calendar.google.com" || saved.SortOrder != 2 {
+					t.Errorf("rename should keep calendar and order: %+v", saved)
+				}
+			},
+		},
+		{
+			name:          "Archive - Unknown",
+			handlerMethod: (*BookingHandler).HandleArchiveTherapist,
+			userID:        999999,
+			args:          []string{"nobody"},
+			therapists:    testTherapists,
+			wantMsg:       "Специалист не найден",
+		},
+		{
+			name:          "Archive - Success",
+			handlerMethod: (*BookingHandler).HandleArchiveTherapist,
+			userID:        999999,
+			args:          []string{"anna"},
+			therapists:    testTherapists,
+			wantMsg:       "Анна больше не доступен(а)",
+			check: func(t *testing.T, saved domain.Therapist) {
+				if saved.ID != "anna" || saved.Active {
+					t.Errorf("expected anna to be archived, got %+v", saved)
+				}
+			},
+		},
+		{
+			name:          "Restore - Success",
+			handlerMethod: (*BookingHandler).HandleRestoreTherapist,
+			userID:        999999,
+			args:          []string{"old"},
+			therapists:    testTherapists,
+			wantMsg:       "Вера снова доступен(а)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved domain.Therapist
+			handler := newTherapistTestHandler(tt.therapists, &saved)
+			ctx := &mockContext{
+				sender: &telebot.User{ID: tt.userID},
+				args:   tt.args,
+			}
+
+			if err := tt.handlerMethod(handler, ctx); err != nil {
+				t.Fatalf("Handler returned error: %v", err)
+			}
+			if !contains(ctx.sentMsg, tt.wantMsg) {
+				t.Errorf("Expected msg containing %q, got %q", tt.wantMsg, ctx.sentMsg)
+			}
+			if tt.check != nil {
+				tt.check(t, saved)
+			}
+		})
+	}
+}
+
+func TestAskForTherapist(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	service := domain.Service{ID: "1", Name: "Массаж", DurationMinutes: 60}
+
+	t.Run("several therapists show the step", func(t *testing.T) {
+		handler := newTherapistTestHandler(testTherapists, nil)
+		ctx := &mockContext{sender: &telebot.User{ID: 1}}
+		handler.sessionStorage.Set(1, SessionKeyService, service)
+
+		if err := handler.askForTherapist(ctx, service.Name); err != nil {
+			t.Fatalf("askForTherapist returned error: %v", err)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "К кому вы хотите записаться") {
+			t.Errorf("expected therapist step, got %v", ctx.editedMsg)
+		}
+
+		ctx.callback = &telebot.Callback{Data: "select_therapist|any"}
+		if err := handler.HandleTherapistSelection(ctx); err != nil {
+			t.Fatalf("HandleTherapistSelection returned error: %v", err)
+		}
+		if got := handler.sessionTherapist(1); got != "" {
+			t.Errorf("any available should store an empty therapist, got %q", got)
+		}
+
+		ctx.callback = &telebot.Callback{Data: "select_therapist|boris"}
+		if err := handler.HandleTherapistSelection(ctx); err != nil {
+			t.Fatalf("HandleTherapistSelection returned error: %v", err)
+		}
+		if got := handler.sessionTherapist(1); got != "boris" {
+			t.Errorf("expected boris in session, got %q", got)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "у специалиста Борис") {
+			t.Errorf("expected date step naming the therapist, got %v", ctx.editedMsg)
+		}
+	})
+
+	t.Run("single therapist is chosen silently", func(t *testing.T) {
+		handler := newTherapistTestHandler(testTherapists[:1], nil)
+		ctx := &mockContext{sender: &telebot.User{ID: 1}}
+
+		if err := handler.askForTherapist(ctx, service.Name); err != nil {
+			t.Fatalf("askForTherapist returned error: %v", err)
+		}
+		if got := handler.sessionTherapist(1); got != "anna" {
+			t.Errorf("expected anna in session, got %q", got)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "выберите дату") {
+			t.Errorf("expected date step, got %v", ctx.editedMsg)
+		}
+	})
+
+	t.Run("no therapists skip the step", func(t *testing.T) {
+		handler := newTherapistTestHandler(nil, nil)
+		ctx := &mockContext{sender: &telebot.User{ID: 1}}
+
+		if err := handler.askForTherapist(ctx, service.Name); err != nil {
+			t.Fatalf("askForTherapist returned error: %v", err)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "услуга 'Массаж' выбрана") {
+			t.Errorf("expected plain date step, got %v", ctx.editedMsg)
+		}
+	})
+}
+
+func TestBookingRecipients(t *testing.T) {
+	handler := newTherapistTestHandler(testTherapists, nil)
+
+	got := handler.bookingRecipients(&domain.Appointment{TherapistID: "anna"})
+	if len(got) != 1 || got[0] != 111 {
+		t.Errorf("anna's booking should notify only anna, got %v", got)
+	}
+
+	// boris has no Telegram ID: fall back to admins and therapists
+	got = handler.bookingRecipients(&domain.Appointment{TherapistID: "boris"})
+	if len(got) != 2 || got[0] != 999999 || got[1] != 555 {
+		t.Errorf("expected broadcast fallback, got %v", got)
+	}
+
+	got = handler.bookingRecipients(&domain.Appointment{})
+	if len(got) != 2 {
+		t.Errorf("unassigned booking should notify everyone, got %v", got)
+	}
+}
+
+func TestGenerateCalendar_Therapists(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	month := time.Date(time.Now().Year()+1, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	// anna also works Saturdays; boris keeps the shared Monday–Friday week
+	schedule := domain.DefaultWeeklySchedule()
+	for _, r := range domain.DefaultWeeklySchedule().Rules {
+		r.TherapistID = "anna"
+		schedule.Rules = append(schedule.Rules, r)
+	}
+	schedule.Rules = append(schedule.Rules, domain.ScheduleRule{TherapistID: "anna", Weekday: time.Saturday, Kind: domain.ScheduleKindWork, StartMinute: 600, EndMinute: 840})
+
+	handler := newTherapistTestHandler(testTherapists, nil)
+	handler.appointmentService.(*mockAppointmentService).getScheduleFunc = func(ctx context.Context) (domain.WeeklySchedule, error) {
+		return schedule, nil
+	}
+
+	saturdayOpen := func(therapistID string) bool {
+		markup := handler.generateCalendar(month, therapistID, 0)
+		for _, row := range markup.InlineKeyboard {
+			for _, btn := range row {
+				if btn.Unique != "select_date" {
+					continue
+				}
+				if d, err := time.Parse("2006-01-02", btn.Data); err == nil && d.Weekday() == time.Saturday {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if !saturdayOpen("anna") {
+		t.Error("anna's Saturdays should be selectable")
+	}
+	if saturdayOpen("boris") {
+		t.Error("boris' Saturdays should be closed")
+	}
+	if !saturdayOpen("") {
+		t.Error("any available should open a day when one therapist works")
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"gopkg.in/telebot.v3"
+)
+
+// The waitlist is offered when a chosen date has no free slots. Offers for
+// freed slots are sent by the waitlist service; the buttons on them land in
+// HandleWaitlistOffer.
+
+// SetWaitlist enables the waitlist; without it a full date only asks for
+// another date.
+func (h *BookingHandler) SetWaitlist(w ports.WaitlistService) {
+	h.waitlist = w
+}
+
+// canJoinWaitlist reports whether the "full date" screen should offer the
+// waitlist: only for patients booking for themselves.
+func (h *BookingHandler) canJoinWaitlist(userID int64) bool {
+	if h.waitlist == nil || h.rescheduleID(userID) != "" {
+		return false
+	}
+	session := h.sessionStorage.Get(userID)
+	if block, _ := session[SessionKeyIsAdminBlock].(bool); block {
+		return false
+	}
+	if manual, _ := session[SessionKeyIsAdminManual].(bool); manual {
+		return false
+	}
+	return true
+}
+
+func (h *BookingHandler) waitlistOfferMarkup() *telebot.ReplyMarkup {
+	selector := &telebot.ReplyMarkup{}
+	selector.Inline(
+		selector.Row(selector.Data("🔔 Ждать на эту дату", "waitlist_join", "0")),
+		selector.Row(selector.Data("🔔 Ждать ближайшие 7 дней", "waitlist_join", "6")),
+		selector.Row(selector.Data("⬅️ Назад к выбору даты", "back_to_date")),
+	)
+	return selector
+}
+
+// HandleWaitlistJoin puts the patient on the waitlist for the service and
+// date in the session. The callback carries how many extra days to cover.
+func (h *BookingHandler) HandleWaitlistJoin(c telebot.Context) error {
+	userID := c.Sender().ID
+	if h.waitlist == nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Лист ожидания недоступен."})
+	}
+
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	extraDays := 0
+	if len(parts) >= 2 {
+		extraDays, _ = strconv.Atoi(parts[1])
+	}
+	if extraDays < 0 || extraDays >= domain.MaxWaitlistDays {
+		extraDays = 0
+	}
+
+	session := h.sessionStorage.Get(userID)
+	service, okS := session[SessionKeyService].(domain.Service)
+	date, okD := session[SessionKeyDate].(time.Time)
+	if !okS || !okD {
+		h.sessionStorage.ClearSession(userID)
+		return c.Send("⚠️ Сессия истекла из-за перезагрузки бота.\nПожалуйста, начните заново командой /start", telebot.RemoveKeyboard)
+	}
+
+	telegramID := strconv.FormatInt(userID, 10)
+	entry, err := h.waitlist.Join(context.Background(), domain.WaitlistEntry{
+		PatientID:       telegramID,
+		PatientName:     h.waitlistPatientName(c, session),
+		ServiceID:       service.ID,
+		ServiceName:     service.Name,
+		DurationMinutes: service.DurationMinutes,
+		TherapistID:     h.sessionTherapist(userID),
+		DateFrom:        date,
+		DateTo:          date.AddDate(0, 0, extraDays),
+	})
+	if err != nil {
+		logging.Errorf(": Failed to join waitlist for user %d: %v", userID, err)
+		if errors.Is(err, domain.ErrInvalidWaitlistEntry) {
+			return c.Respond(&telebot.CallbackResponse{Text: "Нельзя встать в лист ожидания на эти даты.", ShowAlert: true})
+		}
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка. Попробуйте позже."})
+	}
+	h.sessionStorage.ClearSession(userID)
+
+	return c.EditOrSend(fmt.Sprintf(
+		"🔔 Вы в листе ожидания на '%s' (%s).\n\nЕсли время освободится, мы пришлём предложение — на ответ будет ограниченное время.\nСписок ожиданий: /waitlist",
+		entry.ServiceName, waitlistRange(*entry)))
+}
+
+// waitlistPatientName prefers the name typed in this booking, then the
+// patient card, then the Telegram profile.
+func (h *BookingHandler) waitlistPatientName(c telebot.Context, session map[string]interface{}) string {
+	if name, ok := session[SessionKeyName].(string); ok && name != "" {
+		return name
+	}
+	if h.repository != nil {
+		if patient, err := h.repository.GetPatient(strconv.FormatInt(c.Sender().ID, 10)); err == nil && patient.Name != "" {
+			return patient.Name
+		}
+	}
+	return strings.TrimSpace(c.Sender().FirstName + " " + c.Sender().LastName)
+}
+
+// HandleWaitlistOffer answers an offer for a freed slot, from the
+// "✅ Записаться" and "❌ Не подходит" buttons.
+func (h *BookingHandler) HandleWaitlistOffer(c telebot.Context) error {
+	userID := c.Sender().ID
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) < 2 || h.waitlist == nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные предложения."})
+	}
+	offerID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные предложения."})
+	}
+	telegramID := strconv.FormatInt(userID, 10)
+	ctx := context.Background()
+
+	if parts[0] == "waitlist_decline" {
+		if err := h.waitlist.DeclineOffer(ctx, telegramID, offerID); err != nil && !errors.Is(err, domain.ErrOfferExpired) {
+			logging.Warnf(": Failed to decline waitlist offer %d for user %d: %v", offerID, userID, err)
+			return c.Respond(&telebot.CallbackResponse{Text: "Предложение не найдено."})
+		}
+		return c.EditOrSend("Хорошо, мы продолжим искать для вас время. Список ожиданий: /waitlist")
+	}
+
+	appt, err := h.waitlist.AcceptOffer(ctx, telegramID, offerID)
+	if err != nil {
+		if errors.Is(err, domain.ErrOfferExpired) {
+			return c.EditOrSend("😔 Это время уже недоступно. Вы остаётесь в листе ожидания: /waitlist")
+		}
+		if errors.Is(err, domain.ErrOfferNotFound) {
+			return c.Respond(&telebot.CallbackResponse{Text: "Предложение не найдено."})
+		}
+		logging.Errorf(": Failed to accept waitlist offer %d for user %d: %v", offerID, userID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка при записи. Попробуйте позже.", ShowAlert: true})
+	}
+	appt.StartTime = appt.StartTime.In(domain.ApptTimeZone)
+	logging.Infof("Waitlist offer %d accepted by %d: appointment %s", offerID, userID, appt.ID)
+
+	if _, err := h.syncPatientStats(ctx, telegramID, appt.CustomerName); err != nil {
+		logging.Warnf("Failed to sync patient stats after waitlist booking: %v", err)
+	}
+	adminMsg := h.presenter.FormatAppointment(appt, true)
+	for _, recipient := range h.bookingRecipients(appt) {
+		h.BotNotify(c.Bot(), recipient, adminMsg)
+	}
+
+	return c.EditOrSend(h.withCancellationPolicy(h.presenter.FormatAppointment(appt, false)), telebot.ModeHTML)
+}
+
+// HandleMyWaitlist lists the patient's waitlist entries with a button to
+// leave each one.
+func (h *BookingHandler) HandleMyWaitlist(c telebot.Context) error {
+	if h.waitlist == nil {
+		return c.Send("Лист ожидания недоступен.")
+	}
+	entries, err := h.waitlist.ListForPatient(context.Background(), strconv.FormatInt(c.Sender().ID, 10))
+	if err != nil {
+		logging.Errorf(": Failed to list waitlist for user %d: %v", c.Sender().ID, err)
+		return c.Send("Ошибка при получении листа ожидания. Пожалуйста, попробуйте позже.")
+	}
+	if len(entries) == 0 {
+		return c.Send("Вы не стоите в листе ожидания. Если на выбранную дату нет времени, бот предложит встать в очередь.")
+	}
+
+	message := "🔔 <b>Ваш лист ожидания:</b>\n\n"
+	selector := &telebot.ReplyMarkup{}
+	var rows []telebot.Row
+	for _, e := range entries {
+		status := ""
+		if e.Status == domain.WaitlistOffered {
+			status = " — <i>отправлено предложение</i>"
+		}
+		message += fmt.Sprintf("💆 %s\n🗓 %s%s\n\n", e.ServiceName, waitlistRange(e), status)
+		rows = append(rows, selector.Row(selector.Data(
+			fmt.Sprintf("❌ Убрать %s (%s)", e.ServiceName, waitlistRange(e)), "waitlist_leave", strconv.FormatInt(e.ID, 10))))
+	}
+	selector.Inline(rows...)
+	return c.Send(message, selector, telebot.ModeHTML)
+}
+
+// HandleWaitlistLeave removes an entry from the patient's waitlist.
+func (h *BookingHandler) HandleWaitlistLeave(c telebot.Context) error {
+	parts := strings.Split(strings.TrimSpace(c.Callback().Data), "|")
+	if len(parts) < 2 || h.waitlist == nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные."})
+	}
+	entryID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond(&telebot.CallbackResponse{Text: "Ошибка: неверные данные."})
+	}
+	if err := h.waitlist.Leave(context.Background(), strconv.FormatInt(c.Sender().ID, 10), entryID); err != nil {
+		logging.Warnf(": Failed to leave waitlist entry %d for user %d: %v", entryID, c.Sender().ID, err)
+		return c.Respond(&telebot.CallbackResponse{Text: "Запись в листе ожидания не найдена."})
+	}
+	return c.EditOrSend("✅ Вы убраны из листа ожидания.")
+}
+
+// waitlistRange formats an entry's dates as "09.01" or "09.01–15.01".
+func waitlistRange(e domain.WaitlistEntry) string {
+	from := e.DateFrom.Format("02.01")
+	if to := e.DateTo.Format("02.01"); to != from {
+		return from + "–" + to
+	}
+	return from
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// mockWaitlistService records calls made by the waitlist handlers.
+type mockWaitlistService struct {
+	joined    []domain.WaitlistEntry
+	entries   []domain.WaitlistEntry
+	left      []int64
+	declined  []int64
+	acceptErr error
+	accepted  *domain.Appointment
+}
+
+func (m *mockWaitlistService) Join(ctx context.Context, e domain.WaitlistEntry) (*domain.WaitlistEntry, error) {
+	m.joined = append(m.joined, e)
+	e.ID = int64(len(m.joined))
+	return &e, nil
+}
+func (m *mockWaitlistService) ListForPatient(ctx context.Context, patientID string) ([]domain.WaitlistEntry, error) {
+	return m.entries, nil
+}
+func (m *mockWaitlistService) Leave(ctx context.Context, patientID string, entryID int64) error {
+	m.left = append(m.left, entryID)
+	return nil
+}
+func (m *mockWaitlistService) AcceptOffer(ctx context.Context, patientID string, offerID int64) (*domain.Appointment, error) {
+	if m.acceptErr != nil {
+		return nil, m.acceptErr
+	}
+	return m.accepted, nil
+}
+func (m *mockWaitlistService) DeclineOffer(ctx context.Context, patientID string, offerID int64) error {
+	m.declined = append(m.declined, offerID)
+	return nil
+}
+
+func newWaitlistTestHandler(w *mockWaitlistService, repo *mockRepository) *BookingHandler {
+	mock := &mockAppointmentService{
+		getAvailableTimeSlotsFunc: func(ctx context.Context, date time.Time, dur int) ([]domain.TimeSlot, error) {
+			return nil, nil
+		},
+	}
+	h := NewBookingHandler(mock, newMockSessionStorage(), []string{"999"}, nil, nil, repo, &presentation.BotPresenter{}, "", "")
+	h.SetWaitlist(w)
+	return h
+}
+
+func TestAskForTime_NoSlotsOffersWaitlist(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(100)
+
+	tests := []struct {
+		name      string
+		waitlist  bool
+		adminMode string
+		want      string
+	}{
+		{"patient with waitlist", true, "", "лист ожидания"},
+		{"waitlist disabled", false, "", "выберите другую дату"},
+		{"admin block", true, SessionKeyIsAdminBlock, "выберите другую дату"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newWaitlistTestHandler(&mockWaitlistService{}, newMockRepository())
+			if !tt.waitlist {
+				h.SetWaitlist(nil)
+			}
+			h.sessionStorage.Set(userID, SessionKeyService, domain.Service{ID: "s1", Name: "Massage", DurationMinutes: 60})
+			h.sessionStorage.Set(userID, SessionKeyDate, time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC))
+			if tt.adminMode != "" {
+				h.sessionStorage.Set(userID, tt.adminMode, true)
+			}
+			ctx := &mockContext{sender: &telebot.User{ID: userID}}
+
+			if err := h.askForTime(ctx); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if msg, _ := ctx.editedMsg.(string); !contains(msg, tt.want) {
+				t.Errorf("expected message containing %q, got %v", tt.want, ctx.editedMsg)
+			}
+		})
+	}
+}
+
+func TestHandleWaitlistJoin(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	userID := int64(100)
+	date := time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	w := &mockWaitlistService{}
+	repo := newMockRepository()
+	repo.patients["100"] = domain.Patient{TelegramID: "100", Name: "Иван Петров"}
+	h := newWaitlistTestHandler(w, repo)
+	h.sessionStorage.Set(userID, SessionKeyService, domain.Service{ID: "s1", Name: "Massage", DurationMinutes: 60})
+	h.sessionStorage.Set(userID, SessionKeyDate, date)
+	h.sessionStorage.Set(userID, SessionKeyTherapist, "anna")
+
+	ctx := &mockContext{sender: &telebot.User{ID: userID}, callback: &telebot.Callback{Data: "waitlist_join|6"}}
+	if err := h.HandleWaitlistJoin(ctx); err != nil {
+		t.Fatalf("Handler returned error: %v", err)
+	}
+
+	if len(w.joined) != 1 {
+		t.Fatalf("expected one Join call, got %d", len(w.joined))
+	}
+	e := w.joined[0]
+	if e.PatientID != "100" || e.PatientName != "Иван Петров" || e.ServiceID != "s1" || e.TherapistID != "anna" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if !e.DateFrom.Equal(date) || !e.DateTo.Equal(date.AddDate(0, 0, 6)) {
+		t.Errorf("dates = %v–%v, want a week from %v", e.DateFrom, e.DateTo, date)
+	}
+	if msg, _ := ctx.editedMsg.(string); !contains(msg, "01.07–07.07") {
+		t.Errorf("expected confirmation with the range, got %v", ctx.editedMsg)
+	}
+	if len(h.sessionStorage.Get(userID)) != 0 {
+		t.Error("expected the booking session to be cleared")
+	}
+}
+
+func TestHandleWaitlistOffer(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	start := time.Date(2030, 7, 1, 12, 0, 0, 0, time.UTC)
+	bot, _ := telebot.NewBot(telebot.Settings{Offline: true})
+
+	t.Run("accept", func(t *testing.T) {
+		w := &mockWaitlistService{accepted: &domain.Appointment{
+			ID: "new", CustomerTgID: "100", CustomerName: "Иван",
+			Service: domain.Service{Name: "Massage"}, StartTime: start, EndTime: start.Add(time.Hour),
+		}}
+		h := newWaitlistTestHandler(w, newMockRepository())
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "waitlist_accept|7"}, bot: bot}
+
+		if err := h.HandleWaitlistOffer(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "Massage") {
+			t.Errorf("expected the booking confirmation, got %v", ctx.editedMsg)
+		}
+	})
+
+	t.Run("accept after slot was taken", func(t *testing.T) {
+		h := newWaitlistTestHandler(&mockWaitlistService{acceptErr: domain.ErrOfferExpired}, newMockRepository())
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "waitlist_accept|7"}}
+
+		if err := h.HandleWaitlistOffer(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if msg, _ := ctx.editedMsg.(string); !contains(msg, "уже недоступно") {
+			t.Errorf("expected the expired message, got %v", ctx.editedMsg)
+		}
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		w := &mockWaitlistService{}
+		h := newWaitlistTestHandler(w, newMockRepository())
+		ctx := &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "waitlist_decline|7"}}
+
+		if err := h.HandleWaitlistOffer(ctx); err != nil {
+			t.Fatalf("Handler returned error: %v", err)
+		}
+		if len(w.declined) != 1 || w.declined[0] != 7 {
+			t.Errorf("declined = %v, want [7]", w.declined)
+		}
+	})
+}
+
+func TestHandleMyWaitlistAndLeave(t *testing.T) {
+	day := time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC)
+	w := &mockWaitlistService{entries: []domain.WaitlistEntry{
+		{ID: 5, ServiceName: "Massage", DateFrom: day, DateTo: day, Status: domain.WaitlistWaiting},
+	}}
+	h := newWaitlistTestHandler(w, newMockRepository())
+
+	ctx := &mockContext{sender: &telebot.User{ID: 100}}
+	if err := h.HandleMyWaitlist(ctx); err != nil {
+		t.Fatalf("HandleMyWaitlist returned error: %v", err)
+	}
+	if !contains(ctx.sentMsg, "Massage") || !contains(ctx.sentMsg, "01.07") {
+		t.Errorf("expected the entry in the list, got %q", ctx.sentMsg)
+	}
+
+	ctx = &mockContext{sender: &telebot.User{ID: 100}, callback: &telebot.Callback{Data: "waitlist_leave|5"}}
+	if err := h.HandleWaitlistLeave(ctx); err != nil {
+		t.Fatalf("HandleWaitlistLeave returned error: %v", err)
+	}
+	if len(w.left) != 1 || w.left[0] != 5 {
+		t.Errorf("left = %v, want [5]", w.left)
+	}
+}
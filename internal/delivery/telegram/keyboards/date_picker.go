@@ -1,12 +1,35 @@
 package keyboards
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/kfilin/massage-bot/internal/domain"
 	"gopkg.in/telebot.v3"
 )
 
-func NewDatePicker() *telebot.ReplyMarkup {
+// AvailabilityLegend explains the day marks produced by DayLabel.
+const AvailabilityLegend = "░X░ — дата недоступна, X• — осталось мало времени, X✕ — всё занято"
+
+// DayLabel is the button text for day of the month with the given
+// availability: closed days are faded, limited and full days get a mark.
+func DayLabel(day int, status domain.DayStatus) string {
+	switch status {
+	case domain.DayClosed:
+		return fmt.Sprintf("░%d░", day)
+	case domain.DayLimited:
+		return fmt.Sprintf("%d•", day)
+	case domain.DayFull:
+		return fmt.Sprintf("%d✕", day)
+	default:
+		return fmt.Sprintf("%d", day)
+	}
+}
+
+// NewDatePicker builds a reply keyboard for the current month. Given the
+// month's availability (see AppointmentService.GetMonthAvailability), the
+// days follow the header and weekday rows, labelled with DayLabel.
+func NewDatePicker(availability ...domain.DayAvailability) *telebot.ReplyMarkup {
 	kb := &telebot.ReplyMarkup{}
 	now := time.Now()
 
@@ -21,7 +44,29 @@ func NewDatePicker() *telebot.ReplyMarkup {
 	}
 	weekdayRow := kb.Row(dayRow...)
 
-	kb.Reply(headerRow, weekdayRow)
+	rows := []telebot.Row{headerRow, weekdayRow}
+	if len(availability) > 0 {
+		// Pad the first week so days line up with the weekday row
+		var week []telebot.Btn
+		for i := 0; i < (int(availability[0].Date.Weekday())+6)%7; i++ {
+			week = append(week, kb.Text(" "))
+		}
+		for _, day := range availability {
+			week = append(week, kb.Text(DayLabel(day.Date.Day(), day.Status)))
+			if len(week) == 7 {
+				rows = append(rows, kb.Row(week...))
+				week = nil
+			}
+		}
+		if len(week) > 0 {
+			for len(week) < 7 {
+				week = append(week, kb.Text(" "))
+			}
+			rows = append(rows, kb.Row(week...))
+		}
+	}
+
+	kb.Reply(rows...)
 
 	return kb
 }
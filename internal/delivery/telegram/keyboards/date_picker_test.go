@@ -3,6 +3,8 @@ package keyboards
 import (
 	"testing"
 	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
 )
 
 func TestNewDatePicker_NotNil(t *testing.T) {
@@ -52,3 +54,40 @@ func TestNewDatePicker_HasWeekdayRow(t *testing.T) {
 		}
 	}
 }
+
+func TestDayLabel(t *testing.T) {
+	tests := map[domain.DayStatus]string{
+		domain.DayFree:    "5",
+		domain.DayLimited: "5•",
+		domain.DayFull:    "5✕",
+		domain.DayClosed:  "░5░",
+	}
+	for status, want := range tests {
+		if got := DayLabel(5, status); got != want {
+			t.Errorf("DayLabel(5, %s) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestNewDatePicker_MarksAvailability(t *testing.T) {
+	// January 2030 starts on a Tuesday
+	first := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	var days []domain.DayAvailability
+	for i := 0; i < 31; i++ {
+		days = append(days, domain.DayAvailability{Date: first.AddDate(0, 0, i), Status: domain.DayFree})
+	}
+	days[0].Status = domain.DayClosed
+	days[1].Status = domain.DayFull
+
+	kb := NewDatePicker(days...)
+	if len(kb.ReplyKeyboard) != 2+5 {
+		t.Fatalf("expected header, weekdays and 5 weeks, got %d rows", len(kb.ReplyKeyboard))
+	}
+	week := kb.ReplyKeyboard[2]
+	if week[0].Text != " " || week[1].Text != "░1░" || week[2].Text != "2✕" || week[3].Text != "3" {
+		t.Errorf("unexpected first week: %v", week)
+	}
+	if last := kb.ReplyKeyboard[6]; len(last) != 7 || last[3].Text != "31" || last[4].Text != " " {
+		t.Errorf("unexpected last week: %v", last)
+	}
+}
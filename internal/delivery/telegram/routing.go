@@ -22,6 +22,7 @@ const (
 
 	// Session-driven routes.
 	TextActionAdminReply         // Admin is replying to a patient.
+	TextActionPromoCode          // Patient typed a promo code at confirmation.
 	TextActionConfirmBooking     // User confirmed a pending booking.
 	TextActionCancel             // User cancelled / rejected a pending booking.
 	TextActionAskUseButtons      // Awaiting confirmation but received unrelated text.
@@ -36,6 +37,7 @@ const (
 // SessionStorage interface) keeps the router pure and trivially testable.
 type SessionView struct {
 	AdminReplyingTo      string // Telegram ID of patient admin is replying to.
+	AwaitingPromo        bool   // True if a promo code was asked for at confirmation.
 	AwaitingConfirmation bool   // True if booking flow is awaiting yes/no.
 	HasService           bool   // True if a service is selected in session.
 	HasName              bool   // True if a name has been entered in session.
@@ -80,6 +82,8 @@ func RouteCallback(data string) (string, bool) {
 		return CallbackPrefixCategory, true
 	case strings.HasPrefix(data, CallbackPrefixService):
 		return CallbackPrefixService, true
+	case strings.HasPrefix(data, CallbackPrefixTherapist):
+		return CallbackPrefixTherapist, true
 	case strings.HasPrefix(data, CallbackPrefixDate):
 		return CallbackPrefixDate, true
 	case strings.HasPrefix(data, CallbackPrefixNavigateMonth):
@@ -90,12 +94,34 @@ func RouteCallback(data string) (string, bool) {
 		return CallbackBackToServices, true
 	case data == CallbackBackToDate:
 		return CallbackBackToDate, true
+	case data == CallbackNextAvailable:
+		return CallbackNextAvailable, true
+	case strings.HasPrefix(data, CallbackPrefixNextSlot):
+		return CallbackPrefixNextSlot, true
 	case data == CallbackConfirmBooking:
 		return CallbackConfirmBooking, true
 	case data == CallbackCancelBooking:
 		return CallbackCancelBooking, true
+	case data == CallbackEnterPromo:
+		return CallbackEnterPromo, true
+	case data == CallbackKeepAppointment:
+		return CallbackKeepAppointment, true
 	case strings.HasPrefix(data, CallbackPrefixCancelAppt):
 		return CallbackPrefixCancelAppt, true
+	case strings.HasPrefix(data, CallbackPrefixRescheduleAppt):
+		return CallbackPrefixRescheduleAppt, true
+	case strings.HasPrefix(data, CallbackPrefixCancelSeries):
+		return CallbackPrefixCancelSeries, true
+	case strings.HasPrefix(data, CallbackPrefixApptOutcome):
+		return CallbackPrefixApptOutcome, true
+	case strings.HasPrefix(data, CallbackPrefixWaitlistJoin):
+		return CallbackPrefixWaitlistJoin, true
+	case strings.HasPrefix(data, CallbackPrefixWaitlistAccept):
+		return CallbackPrefixWaitlistAccept, true
+	case strings.HasPrefix(data, CallbackPrefixWaitlistDecline):
+		return CallbackPrefixWaitlistDecline, true
+	case strings.HasPrefix(data, CallbackPrefixWaitlistLeave):
+		return CallbackPrefixWaitlistLeave, true
 	case strings.HasPrefix(data, CallbackPrefixConfirmReminder):
 		return CallbackPrefixConfirmReminder, true
 	case strings.HasPrefix(data, CallbackPrefixCancelReminder):
@@ -118,9 +144,10 @@ func RouteCallback(data string) (string, bool) {
 //  1. /create_appointment command fallback
 //  2. Main menu buttons
 //  3. Admin reply state
-//  4. Awaiting confirmation (yes/no/other)
-//  5. Safety fallbacks (Подтвердить / Отменить запись / Выбрать другую дату)
-//  6. Default name-input / forward-to-admins flow
+//  4. Awaiting promo code
+//  5. Awaiting confirmation (yes/no/other)
+//  6. Safety fallbacks (Подтвердить / Отменить запись / Выбрать другую дату)
+//  7. Default name-input / forward-to-admins flow
 //
 // Caller is expected to pass already-trimmed text.
 func RouteTextMessage(text string, s SessionView) TextAction {
@@ -146,7 +173,12 @@ func RouteTextMessage(text string, s SessionView) TextAction {
 		return TextActionAdminReply
 	}
 
-	// Priority 4: awaiting confirmation.
+	// Priority 4: promo code typed at confirmation.
+	if s.AwaitingPromo {
+		return TextActionPromoCode
+	}
+
+	// Priority 5: awaiting confirmation.
 	if s.AwaitingConfirmation {
 		switch strings.ToLower(text) {
 		case "подтвердить", "да", "д", "yes", "y", "ok", "ок":
@@ -158,7 +190,7 @@ func RouteTextMessage(text string, s SessionView) TextAction {
 		}
 	}
 
-	// Priority 5: safety fallbacks.
+	// Priority 6: safety fallbacks.
 	switch text {
 	case "Подтвердить":
 		return TextActionConfirmBooking
@@ -168,7 +200,7 @@ func RouteTextMessage(text string, s SessionView) TextAction {
 		return TextActionRestartFlow
 	}
 
-	// Priority 6: default flow based on session completeness.
+	// Priority 7: default flow based on session completeness.
 	if !s.HasService {
 		return TextActionAskSelectService
 	}
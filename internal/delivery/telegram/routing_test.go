@@ -21,6 +21,13 @@ func TestRouteCallback_ServicePrefix(t *testing.T) {
 	}
 }
 
+func TestRouteCallback_TherapistPrefix(t *testing.T) {
+	action, matched := RouteCallback("select_therapist|anna")
+	if !matched || action != CallbackPrefixTherapist {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixTherapist, action, matched)
+	}
+}
+
 func TestRouteCallback_DatePrefix(t *testing.T) {
 	action, matched := RouteCallback("select_date|2026-07-01")
 	if !matched || action != CallbackPrefixDate {
@@ -42,6 +49,20 @@ func TestRouteCallback_TimePrefix(t *testing.T) {
 	}
 }
 
+func TestRouteCallback_NextAvailableExact(t *testing.T) {
+	action, matched := RouteCallback("next_available")
+	if !matched || action != CallbackNextAvailable {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackNextAvailable, action, matched)
+	}
+}
+
+func TestRouteCallback_NextSlotPrefix(t *testing.T) {
+	action, matched := RouteCallback("next_slot|2026-07-01|14:30")
+	if !matched || action != CallbackPrefixNextSlot {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixNextSlot, action, matched)
+	}
+}
+
 func TestRouteCallback_CancelApptPrefix(t *testing.T) {
 	action, matched := RouteCallback("cancel_appt|abc-123")
 	if !matched || action != CallbackPrefixCancelAppt {
@@ -49,6 +70,55 @@ func TestRouteCallback_CancelApptPrefix(t *testing.T) {
 	}
 }
 
+func TestRouteCallback_RescheduleApptPrefix(t *testing.T) {
+	action, matched := RouteCallback("reschedule_appt|abc-123")
+	if !matched || action != CallbackPrefixRescheduleAppt {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixRescheduleAppt, action, matched)
+	}
+}
+
+func TestRouteCallback_CancelSeriesPrefix(t *testing.T) {
+	action, matched := RouteCallback("cancel_series|abc-123")
+	if !matched || action != CallbackPrefixCancelSeries {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixCancelSeries, action, matched)
+	}
+}
+
+func TestRouteCallback_ApptOutcomePrefix(t *testing.T) {
+	action, matched := RouteCallback("appt_outcome|abc-123|no_show")
+	if !matched || action != CallbackPrefixApptOutcome {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixApptOutcome, action, matched)
+	}
+}
+
+func TestRouteCallback_WaitlistJoinPrefix(t *testing.T) {
+	action, matched := RouteCallback("waitlist_join|6")
+	if !matched || action != CallbackPrefixWaitlistJoin {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixWaitlistJoin, action, matched)
+	}
+}
+
+func TestRouteCallback_WaitlistAcceptPrefix(t *testing.T) {
+	action, matched := RouteCallback("waitlist_accept|12")
+	if !matched || action != CallbackPrefixWaitlistAccept {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixWaitlistAccept, action, matched)
+	}
+}
+
+func TestRouteCallback_WaitlistDeclinePrefix(t *testing.T) {
+	action, matched := RouteCallback("waitlist_decline|12")
+	if !matched || action != CallbackPrefixWaitlistDecline {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixWaitlistDecline, action, matched)
+	}
+}
+
+func TestRouteCallback_WaitlistLeavePrefix(t *testing.T) {
+	action, matched := RouteCallback("waitlist_leave|3")
+	if !matched || action != CallbackPrefixWaitlistLeave {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackPrefixWaitlistLeave, action, matched)
+	}
+}
+
 func TestRouteCallback_ConfirmReminderPrefix(t *testing.T) {
 	action, matched := RouteCallback("confirm_appt_reminder|appt-1")
 	if !matched || action != CallbackPrefixConfirmReminder {
@@ -100,6 +170,20 @@ func TestRouteCallback_CancelBookingExact(t *testing.T) {
 	}
 }
 
+func TestRouteCallback_EnterPromoExact(t *testing.T) {
+	action, matched := RouteCallback("enter_promo")
+	if !matched || action != CallbackEnterPromo {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackEnterPromo, action, matched)
+	}
+}
+
+func TestRouteCallback_KeepAppointmentExact(t *testing.T) {
+	action, matched := RouteCallback("keep_appt")
+	if !matched || action != CallbackKeepAppointment {
+		t.Errorf("expected (%q, true), got (%q, %v)", CallbackKeepAppointment, action, matched)
+	}
+}
+
 func TestRouteCallback_BackToServicesExact(t *testing.T) {
 	action, matched := RouteCallback("back_to_services")
 	if !matched || action != CallbackBackToServices {
@@ -216,6 +300,22 @@ func TestRouteTextMessage_AwaitingConfirmation_Invalid(t *testing.T) {
 	}
 }
 
+// A promo code that looks like "да" or "нет" must still reach the promo
+// handler rather than confirm or cancel the booking.
+func TestRouteTextMessage_AwaitingPromo(t *testing.T) {
+	s := SessionView{AwaitingPromo: true, AwaitingConfirmation: true, HasService: true, HasName: true}
+	for _, txt := range []string{"SPRING10", "да", "-"} {
+		t.Run(txt, func(t *testing.T) {
+			if got := RouteTextMessage(txt, s); got != TextActionPromoCode {
+				t.Errorf("text=%q: expected TextActionPromoCode, got %v", txt, got)
+			}
+		})
+	}
+	if got := RouteTextMessage("📅 Мои записи", s); got != TextActionMyAppointments {
+		t.Errorf("menu buttons should beat promo input, got %v", got)
+	}
+}
+
 func TestRouteTextMessage_SafetyFallbacks(t *testing.T) {
 	cases := []struct {
 		text   string
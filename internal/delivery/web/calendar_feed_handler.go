@@ -0,0 +1,79 @@
+package web
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+)
+
+// feedWindow is how far back finished visits stay in the calendar feeds.
+const feedWindow = 24 * time.Hour
+
+// NewCalendarFeedHandler serves iCalendar subscription feeds: a patient's
+// upcoming sessions (/calendar/feed.ics) and, for admins, all bookings
+// (/calendar/admin.ics). Both are authorized by ?id= and a token signed
+// with the web app secret; admin feeds also need id to still be an admin.
+func NewCalendarFeedHandler(apptService ports.AppointmentService, secret string, adminIDs []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		admin := strings.HasSuffix(r.URL.Path, "/admin.ics")
+		id := strings.TrimSpace(r.URL.Query().Get("id"))
+		token := r.URL.Query().Get("token")
+		if secret == "" || id == "" || !hmac.Equal([]byte(token), []byte(presentation.ICSFeedToken(id, admin, secret))) {
+			logging.Warnf("WARNING: Calendar feed request with a bad token for ID=%s", id)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if admin && !isAdminID(id, adminIDs) {
+			logging.Warnf("WARNING: Admin calendar feed requested by non-admin %s", id)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		var appts []domain.Appointment
+		var err error
+		cal := presentation.ICSCalendar{Name: "Массаж"}
+		if admin {
+			cal = presentation.ICSCalendar{Name: "Массаж — все записи", ForAdmin: true}
+			appts, err = apptService.GetAllUpcomingAppointments(r.Context())
+		} else {
+			appts, err = apptService.GetCustomerAppointments(r.Context(), id)
+		}
+		if err != nil {
+			logging.Errorf("ERROR: Failed to load appointments for calendar feed of %s: %v", id, err)
+			http.Error(w, "Failed to load appointments", http.StatusInternalServerError)
+			return
+		}
+
+		cutoff := time.Now().Add(-feedWindow)
+		var upcoming []domain.Appointment
+		for _, appt := range appts {
+			if appt.Status != "cancelled" && appt.StartTime.After(cutoff) {
+				upcoming = append(upcoming, appt)
+			}
+		}
+
+		w.Header().Set("Content-Type", presentation.ICSMimeType)
+		w.Header().Set("Cache-Control", "private, max-age=900")
+		_, _ = w.Write(presentation.FormatICS(cal, upcoming, time.Now()))
+	}
+}
+
+func isAdminID(id string, adminIDs []string) bool {
+	for _, adminID := range adminIDs {
+		if adminID == id {
+			return true
+		}
+	}
+	return false
+}
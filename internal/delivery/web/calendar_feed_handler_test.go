@@ -0,0 +1,108 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+)
+
+type mockFeedApptService struct {
+	ports.AppointmentService
+	appts []domain.Appointment
+}
+
+func (m *mockFeedApptService) GetCustomerAppointments(ctx context.Context, id string) ([]domain.Appointment, error) {
+	var out []domain.Appointment
+	for _, appt := range m.appts {
+		if appt.CustomerTgID == id {
+			out = append(out, appt)
+		}
+	}
+	return out, nil
+}
+
+func (m *mockFeedApptService) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
+	return m.appts, nil
+}
+
+func TestCalendarFeedHandler(t *testing.T) {
+	const secret = "feed-secret"
+	tomorrow := time.Now().Add(24 * time.Hour)
+	svc := &mockFeedApptService{appts: []domain.Appointment{
+		{ID: "a1", CustomerTgID: "42", CustomerName: "Anna", Service: domain.Service{Name: "Massage", DurationMinutes: 60}, StartTime: tomorrow},
+		{ID: "a2", CustomerTgID: "42", CustomerName: "Anna", Service: domain.Service{Name: "Massage"}, StartTime: tomorrow.Add(time.Hour), Status: "cancelled"},
+		{ID: "a3", CustomerTgID: "42", CustomerName: "Anna", Service: domain.Service{Name: "Massage"}, StartTime: time.Now().AddDate(0, -1, 0)},
+		{ID: "b1", CustomerTgID: "7", CustomerName: "Bob", Service: domain.Service{Name: "Massage"}, StartTime: tomorrow},
+	}}
+	handler := NewCalendarFeedHandler(svc, secret, []string{"999"})
+
+	get := func(path, id, token string) *httptest.ResponseRecorder {
+		q := url.Values{"id": {id}, "token": {token}}
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path+"?"+q.Encode(), nil))
+		return rec
+	}
+
+	t.Run("Patient feed", func(t *testing.T) {
+		rec := get("/calendar/feed.ics", "42", presentation.ICSFeedToken("42", false, secret))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "UID:a1@massage-bot") {
+			t.Errorf("expected the upcoming visit in the feed:\n%s", body)
+		}
+		for _, id := range []string{"a2", "a3", "b1"} {
+			if strings.Contains(body, "UID:"+id+"@") {
+				t.Errorf("did not expect %s in the feed:\n%s", id, body)
+			}
+		}
+	})
+
+	t.Run("Admin feed", func(t *testing.T) {
+		rec := get("/calendar/admin.ics", "999", presentation.ICSFeedToken("999", true, secret))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, "UID:a1@massage-bot") || !strings.Contains(body, "UID:b1@massage-bot") || !strings.Contains(body, "Massage - Bob") {
+			t.Errorf("expected all bookings with names:\n%s", body)
+		}
+	})
+
+	rejected := []struct {
+		name, path, id, token string
+	}{
+		{"Bad token", "/calendar/feed.ics", "42", "nope"},
+		{"Other patient", "/calendar/feed.ics", "7", presentation.ICSFeedToken("42", false, secret)},
+		{"Patient token for admin feed", "/calendar/admin.ics", "42", presentation.ICSFeedToken("42", false, secret)},
+		{"No longer an admin", "/calendar/admin.ics", "42", presentation.ICSFeedToken("42", true, secret)},
+		{"Admin token for patient feed", "/calendar/feed.ics", "999", presentation.ICSFeedToken("999", true, secret)},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := get(tt.path, tt.id, tt.token); rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+
+	t.Run("Wrong method", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/calendar/feed.ics", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
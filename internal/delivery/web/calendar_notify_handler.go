@@ -0,0 +1,45 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// NewCalendarNotifyHandler receives Google Calendar push notifications. The
+// ping carries no event data, only the channel headers; the sync service
+// fetches the changes itself. Pings from channels this instance no longer
+// knows (left over from a restart) are acknowledged so Google stops
+// retrying them; a wrong token is refused.
+func NewCalendarNotifyHandler(calendarSync ports.CalendarSyncService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		n := domain.CalendarNotification{
+			ChannelID:  r.Header.Get("X-Goog-Channel-ID"),
+			ResourceID: r.Header.Get("X-Goog-Resource-ID"),
+			Token:      r.Header.Get("X-Goog-Channel-Token"),
+			State:      r.Header.Get("X-Goog-Resource-State"),
+		}
+		err := calendarSync.HandleNotification(r.Context(), n)
+		switch {
+		case errors.Is(err, domain.ErrInvalidWatchToken):
+			logging.Warnf("WARNING: Calendar notification for channel %s with a wrong token", n.ChannelID)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		case errors.Is(err, domain.ErrUnknownWatchChannel):
+			logging.Debugf("DEBUG: Calendar notification for unknown channel %s ignored", n.ChannelID)
+		case err != nil:
+			logging.Errorf("ERROR: Failed to handle calendar notification: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
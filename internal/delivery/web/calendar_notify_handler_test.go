@@ -0,0 +1,60 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+type mockCalendarSync struct {
+	got []domain.CalendarNotification
+	err error
+}
+
+func (m *mockCalendarSync) HandleNotification(ctx context.Context, n domain.CalendarNotification) error {
+	m.got = append(m.got, n)
+	return m.err
+}
+
+func TestCalendarNotifyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		err        error
+		wantStatus int
+	}{
+		{"Accepted", http.MethodPost, nil, http.StatusOK},
+		{"Unknown channel", http.MethodPost, domain.ErrUnknownWatchChannel, http.StatusOK},
+		{"Wrong token", http.MethodPost, domain.ErrInvalidWatchToken, http.StatusForbidden},
+		{"Failure", http.MethodPost, errors.New("boom"), http.StatusInternalServerError},
+		{"Wrong method", http.MethodGet, nil, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calSync := &mockCalendarSync{err: tt.err}
+			req := httptest.NewRequest(tt.method, "/api/calendar/notify", nil)
+			req.Header.Set("X-Goog-Channel-ID", "ch-1")
+			req.Header.Set("X-Goog-Channel-Token", "secret")
+			req.Header.Set("X-Goog-Resource-ID", "res-1")
+			req.Header.Set("X-Goog-Resource-State", "exists")
+			rec := httptest.NewRecorder()
+
+			NewCalendarNotifyHandler(calSync)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.method == http.MethodPost {
+				want := domain.CalendarNotification{ChannelID: "ch-1", ResourceID: "res-1", Token: "secret", State: "exists"}
+				if len(calSync.got) != 1 || calSync.got[0] != want {
+					t.Errorf("unexpected notification %+v", calSync.got)
+				}
+			}
+		})
+	}
+}
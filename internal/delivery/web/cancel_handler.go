@@ -2,18 +2,22 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
 	"github.com/kfilin/massage-bot/internal/ports"
 	"github.com/kfilin/massage-bot/internal/presentation"
 )
 
 // NewCancelHandler creates the handler for Appointment Cancellation.
-// Admins can cancel any appointment; patients can cancel their own with
-// at least 72 hours notice. Sends a Telegram notification to all admins
-// on success.
+// Admins can cancel any appointment; patients can cancel their own under
+// the cancellation policy: inside the notice window the request is refused,
+// or answered with 409 until it is resent with confirmLate. On success the
+// patient gets a METHOD:CANCEL .ics and all admins a Telegram notification.
 func NewCancelHandler(apptService ports.AppointmentService, botToken string, adminIDs []string, presenter *presentation.BotPresenter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -25,8 +29,9 @@ func NewCancelHandler(apptService ports.AppointmentService, botToken string, adm
 		}
 
 		var reqBody struct {
-			InitData string `json:"initData"`
-			ApptID   string `json:"apptId"`
+			InitData    string `json:"initData"`
+			ApptID      string `json:"apptId"`
+			ConfirmLate bool   `json:"confirmLate"` // Patient accepted the late-cancel warning
 		}
 		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -68,12 +73,22 @@ func NewCancelHandler(apptService ports.AppointmentService, botToken string, adm
 			return
 		}
 
-		now := time.Now().In(domain.ApptTimeZone)
-		if !isAdmin && appt.StartTime.Sub(now) < 72*time.Hour {
+		policy := apptService.GetCancellationPolicy()
+		late := !isAdmin && policy.IsLate(appt.StartTime, time.Now())
+		if late && policy.BlockLate {
 			w.WriteHeader(http.StatusBadRequest)
 			_ = json.NewEncoder(w).Encode(map[string]string{
 				"status": "error",
-				"error":  "До приема менее 72 часов. Напишите терапевту.",
+				"error":  presenter.FormatLateCancelBlocked(policy, false),
+			})
+			return
+		}
+		// Late cancels are allowed once the patient has seen the warning
+		if late && !reqBody.ConfirmLate {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"status": "confirm_late",
+				"error":  presenter.FormatLateCancelNotice(policy),
 			})
 			return
 		}
@@ -85,7 +100,27 @@ func NewCancelHandler(apptService ports.AppointmentService, botToken string, adm
 			return
 		}
 
+		cancelStatus := domain.StatusCancelledByPatient
+		switch {
+		case isAdmin:
+			cancelStatus = domain.StatusCancelledByAdmin
+		case late:
+			cancelStatus = domain.StatusLateCancel
+		}
+		if err := apptService.SetAppointmentStatus(r.Context(), appt, cancelStatus); err != nil && !errors.Is(err, domain.ErrStatusUnavailable) {
+			logging.Warnf("Failed to record cancellation of %s: %v", appt.ID, err)
+		}
+
+		// Remove the visit from calendars the patient imported it into
+		if _, err := strconv.ParseInt(appt.CustomerTgID, 10, 64); err == nil {
+			ics := presentation.FormatICS(presentation.ICSCalendar{Method: presentation.ICSCancel}, []domain.Appointment{*appt}, time.Now())
+			sendTelegramDocument(botToken, appt.CustomerTgID, presentation.ICSFileName(appt), "text/calendar", ics)
+		}
+
 		notificationMsg := presenter.FormatCancellation(appt, true)
+		if late {
+			notificationMsg = "⏰ <b>ПОЗДНЯЯ ОТМЕНА</b>\n" + notificationMsg
+		}
 		for _, adminID := range adminIDs {
 			sendTelegramMessage(botToken, adminID, notificationMsg)
 		}
@@ -1,11 +1,14 @@
 package web
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"strings"
 	"time"
@@ -28,6 +31,9 @@ func createWebAppMux(
 	transcriptionService ports.TranscriptionService,
 	dataDir string,
 	botUsername string,
+	packages ports.PackageService,
+	payments ports.PaymentService,
+	calendarSync ports.CalendarSyncService,
 ) *http.ServeMux {
 	if dataDir == "" {
 		dataDir = "data"
@@ -46,7 +52,7 @@ func createWebAppMux(
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(presentation.StaticFS))))
 
 	// Handle both root and /card with the same logic
-	handler := NewWebAppHandler(repo, apptService, webPresenter, botToken, adminIDs, secret)
+	handler := NewWebAppHandler(repo, apptService, packages, payments, webPresenter, botToken, adminIDs, secret)
 
 	mux.HandleFunc("/", handler)
 	mux.HandleFunc("/card", handler)
@@ -57,14 +63,29 @@ func createWebAppMux(
 	mux.HandleFunc("/cancel", NewCancelHandler(apptService, botToken, adminIDs, botPresenter))
 	mux.HandleFunc("/api/transcribe", NewTranscribeHandler(transcriptionService, botToken))
 
+	// Service Catalog Handlers
+	servicesHandler := NewServicesHandler(apptService, botToken, adminIDs)
+	mux.HandleFunc("/api/services", servicesHandler)
+	mux.HandleFunc("/api/services/", servicesHandler)
+
 	// Draft Handlers
 	draftHandler := NewDraftHandler(repo, botToken, adminIDs, secret)
 	mux.HandleFunc("/api/draft/approve", draftHandler)
 	mux.HandleFunc("/api/draft/discard", draftHandler)
 
+	// iCalendar subscription feeds
+	feedHandler := NewCalendarFeedHandler(apptService, secret, adminIDs)
+	mux.HandleFunc("/calendar/feed.ics", feedHandler)
+	mux.HandleFunc("/calendar/admin.ics", feedHandler)
+
 	mediaHandler := NewMediaHandler(repo, secret, adminIDs)
 	mux.Handle("/api/media/", http.StripPrefix("/api/media/", http.HandlerFunc(mediaHandler.GetMedia)))
 
+	// Google Calendar push notifications, when the sync is enabled
+	if calendarSync != nil {
+		mux.HandleFunc("/api/calendar/notify", NewCalendarNotifyHandler(calendarSync))
+	}
+
 	// WebDAV Handler for Obsidian Sync
 	davUser := os.Getenv("WEBDAV_USER")
 	davPass := os.Getenv("WEBDAV_PASSWORD")
@@ -156,7 +177,8 @@ func createWebAppMux(
 
 // StartServer launches the HTTP server for the WebApp on the given port.
 // It registers all webapp routes (patient card, search, draft, cancel,
-// update, transcribe, media, WebDAV) and blocks until ctx is cancelled.
+// update, transcribe, media, calendar feeds, WebDAV, calendar
+// notifications) and blocks until ctx is cancelled.
 func StartServer(
 	ctx context.Context,
 	port string,
@@ -168,12 +190,15 @@ func StartServer(
 	transcriptionService ports.TranscriptionService,
 	dataDir string,
 	botUsername string,
+	packages ports.PackageService,
+	payments ports.PaymentService,
+	calendarSync ports.CalendarSyncService,
 ) {
 	if port == "" {
 		port = "8082"
 	}
 
-	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptService, transcriptionService, dataDir, botUsername)
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptService, transcriptionService, dataDir, botUsername, packages, payments, calendarSync)
 
 	logging.Infof("Starting Web App server on :%s", port)
 	server := &http.Server{
@@ -224,3 +249,36 @@ func sendTelegramMessage(token, chatID, text string) {
 		logging.Infof("Telegram API error: %s", resp.Status)
 	}
 }
+
+// sendTelegramDocument uploads a file to a single chat via the Bot HTTP API.
+func sendTelegramDocument(token, chatID, fileName, mimeType string, data []byte) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("chat_id", chatID)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="document"; filename=%q`, fileName))
+	header.Set("Content-Type", mimeType)
+	part, err := form.CreatePart(header)
+	if err == nil {
+		_, err = part.Write(data)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		logging.Infof("Failed to build bot document upload: %v", err)
+		return
+	}
+
+	apiURL := fmt.Sprintf("%s/bot%s/sendDocument", telegramAPIBase, token)
+	resp, err := http.Post(apiURL, form.FormDataContentType(), &body)
+	if err != nil {
+		logging.Infof("Failed to send bot document: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logging.Infof("Telegram API error: %s", resp.Status)
+	}
+}
@@ -134,6 +134,44 @@ func TestSendTelegramMessage_EmptyToken(t *testing.T) {
 	}
 }
 
+// TestSendTelegramDocument_Upload checks the multipart upload sent to
+// sendDocument: chat ID, file name, content type and content.
+func TestSendTelegramDocument_Upload(t *testing.T) {
+	var (
+		gotPath  string
+		gotChat  string
+		gotName  string
+		gotType  string
+		gotBytes []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		file, header, err := r.FormFile("document")
+		if err != nil {
+			t.Errorf("no document in the upload: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		gotChat = r.FormValue("chat_id")
+		gotName = header.Filename
+		gotType = header.Header.Get("Content-Type")
+		gotBytes, _ = io.ReadAll(file)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	withTelegramAPIBase(t, srv.URL)
+
+	sendTelegramDocument("test-token", "12345", "visit.ics", "text/calendar", []byte("BEGIN:VCALENDAR"))
+
+	if gotPath != "/bottest-token/sendDocument" {
+		t.Errorf("path: got %q, want /bottest-token/sendDocument", gotPath)
+	}
+	if gotChat != "12345" || gotName != "visit.ics" || gotType != "text/calendar" || string(gotBytes) != "BEGIN:VCALENDAR" {
+		t.Errorf("unexpected upload: chat=%q name=%q type=%q body=%q", gotChat, gotName, gotType, gotBytes)
+	}
+}
+
 // createDummyMuxInputs returns minimal mock dependencies that satisfy
 // the signature of createWebAppMux without panicking on route setup.
 func createDummyMuxInputs(t *testing.T) (string, string, []string, *mockRepo, *mockApptService, *mockTranscriptionService, string, string) {
@@ -150,7 +188,7 @@ func createDummyMuxInputs(t *testing.T) (string, string, []string, *mockRepo, *m
 func TestCreateWebAppMux_RoutesRegistered(t *testing.T) {
 	secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser := createDummyMuxInputs(t)
 
-	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser)
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
 	if mux == nil {
 		t.Fatal("createWebAppMux returned nil")
 	}
@@ -172,6 +210,8 @@ func TestCreateWebAppMux_RoutesRegistered(t *testing.T) {
 		{path: "/api/draft/discard", wantStatus: 0},
 		{path: "/api/patient/update", wantStatus: 0},
 		{path: "/static/", wantStatus: 0},
+		{path: "/calendar/feed.ics", wantStatus: http.StatusForbidden},
+		{path: "/calendar/admin.ics", wantStatus: http.StatusForbidden},
 	}
 
 	for _, tc := range tests {
@@ -192,11 +232,31 @@ func TestCreateWebAppMux_RoutesRegistered(t *testing.T) {
 	}
 }
 
+// TestCreateWebAppMux_CalendarNotify checks that the notification endpoint
+// exists only when the calendar sync is enabled.
+func TestCreateWebAppMux_CalendarNotify(t *testing.T) {
+	secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser := createDummyMuxInputs(t)
+	calSync := &mockCalendarSync{}
+
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, calSync)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/calendar/notify", nil))
+	if rec.Code != http.StatusOK || len(calSync.got) != 1 {
+		t.Errorf("expected the ping handled, got status %d, %d pings", rec.Code, len(calSync.got))
+	}
+
+	mux = createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
+	_, pattern := mux.Handler(httptest.NewRequest(http.MethodPost, "/api/calendar/notify", nil))
+	if pattern == "/api/calendar/notify" {
+		t.Error("expected no notification endpoint without the calendar sync")
+	}
+}
+
 // TestCreateWebAppMux_StaticAssets checks that /static/ serves actual content.
 func TestCreateWebAppMux_StaticAssets(t *testing.T) {
 	secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser := createDummyMuxInputs(t)
 
-	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser)
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
@@ -218,7 +278,7 @@ func TestCreateWebAppMux_StaticAssets(t *testing.T) {
 func TestCreateWebAppMux_NoWebDAV(t *testing.T) {
 	secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser := createDummyMuxInputs(t)
 
-	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser)
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
@@ -252,7 +312,7 @@ func TestCreateWebAppMux_WithWebDAV(t *testing.T) {
 	dataDir := t.TempDir()
 	secret, botToken, adminIDs, repo, apptSvc, transSvc, _, botUser := createDummyMuxInputs(t)
 
-	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser)
+	mux := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
 	ts := httptest.NewServer(mux)
 	defer ts.Close()
 
@@ -328,7 +388,7 @@ func TestCreateWebAppMux_WithWebDAV(t *testing.T) {
 	})
 
 	t.Run("empty dataDir defaults to 'data'", func(t *testing.T) {
-		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, "", botUser)
+		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, "", botUser, nil, nil, nil)
 		if mux2 == nil {
 			t.Fatal("createWebAppMux with empty dataDir returned nil")
 		}
@@ -347,7 +407,7 @@ func TestCreateWebAppMux_WithWebDAV(t *testing.T) {
 
 	t.Run("WebDAV os.Stat error with nonexistent dir", func(t *testing.T) {
 		nonExistent := os.TempDir() + "/__vera_test_nonexistent__"
-		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, nonExistent, botUser)
+		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, nonExistent, botUser, nil, nil, nil)
 		ts2 := httptest.NewServer(mux2)
 		defer ts2.Close()
 
@@ -376,7 +436,7 @@ func TestCreateWebAppMux_WithWebDAV(t *testing.T) {
 			t.Fatalf("create file: %v", err)
 		}
 
-		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, filePath, botUser)
+		mux2 := createWebAppMux(secret, botToken, adminIDs, repo, apptSvc, transSvc, filePath, botUser, nil, nil, nil)
 		ts2 := httptest.NewServer(mux2)
 		defer ts2.Close()
 
@@ -460,7 +520,7 @@ func TestStartServer_Lifecycle(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	go StartServer(ctx, port, secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser)
+	go StartServer(ctx, port, secret, botToken, adminIDs, repo, apptSvc, transSvc, dataDir, botUser, nil, nil, nil)
 
 	// Retry until the server responds
 	var resp *http.Response
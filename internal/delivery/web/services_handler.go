@@ -0,0 +1,135 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// NewServicesHandler creates the handler for the Service Catalog API.
+// Admin-only: GET /api/services lists the full catalog (archived included);
+// POST /api/services/save, /archive, /reorder and /category modify it.
+func NewServicesHandler(apptService ports.AppointmentService, botToken string, adminIDs []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req struct {
+			InitData   string         `json:"initData"`
+			Service    domain.Service `json:"service"`
+			ID         string         `json:"id"`
+			Archived   bool           `json:"archived"`
+			OrderedIDs []string       `json:"orderedIds"`
+
+			Category domain.ServiceCategory `json:"category"`
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			req.InitData = r.Header.Get("X-Telegram-Init-Data")
+			if req.InitData == "" {
+				req.InitData = r.URL.Query().Get("initData")
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeServicesError(w, http.StatusBadRequest, "Invalid request")
+				return
+			}
+		default:
+			writeServicesError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		userID, _, err := validateInitData(req.InitData, botToken)
+		if err != nil {
+			writeServicesError(w, http.StatusUnauthorized, "Сессия недействительна.")
+			return
+		}
+
+		isAdmin := false
+		for _, adminID := range adminIDs {
+			if adminID == userID {
+				isAdmin = true
+				break
+			}
+		}
+		if !isAdmin {
+			writeServicesError(w, http.StatusForbidden, "Доступ запрещен")
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			services, err := apptService.GetAllServices(r.Context())
+			if err != nil {
+				logging.Errorf("Failed to list services: %v", err)
+				writeServicesError(w, http.StatusInternalServerError, "Не удалось загрузить услуги")
+				return
+			}
+			if services == nil {
+				services = []domain.Service{}
+			}
+			categories, err := apptService.GetServiceCategories(r.Context(), true)
+			if err != nil {
+				logging.Errorf("Failed to list service categories: %v", err)
+				categories = []domain.ServiceCategory{}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "services": services, "categories": categories})
+			return
+		}
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/save"):
+			saved, err := apptService.SaveService(r.Context(), req.Service)
+			if err != nil {
+				writeCatalogError(w, err)
+				return
+			}
+			logging.Infof("[ADMIN] Service %s saved via web by %s", saved.ID, userID)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "service": saved})
+		case strings.HasSuffix(r.URL.Path, "/archive"):
+			if err := apptService.SetServiceArchived(r.Context(), req.ID, req.Archived); err != nil {
+				writeCatalogError(w, err)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		case strings.HasSuffix(r.URL.Path, "/reorder"):
+			if err := apptService.ReorderServices(r.Context(), req.OrderedIDs); err != nil {
+				writeCatalogError(w, err)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		case strings.HasSuffix(r.URL.Path, "/category"):
+			if err := apptService.SaveServiceCategory(r.Context(), req.Category); err != nil {
+				writeCatalogError(w, err)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		default:
+			writeServicesError(w, http.StatusNotFound, "Not found")
+		}
+	}
+}
+
+func writeServicesError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": msg})
+}
+
+// writeCatalogError maps catalog domain errors to HTTP statuses.
+func writeCatalogError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidService), errors.Is(err, domain.ErrInvalidID):
+		writeServicesError(w, http.StatusBadRequest, "Неверные данные услуги")
+	case errors.Is(err, domain.ErrServiceNotFound):
+		writeServicesError(w, http.StatusNotFound, "Услуга не найдена")
+	case errors.Is(err, domain.ErrCatalogUnavailable):
+		writeServicesError(w, http.StatusServiceUnavailable, "Каталог услуг недоступен")
+	default:
+		logging.Errorf("Service catalog update failed: %v", err)
+		writeServicesError(w, http.StatusInternalServerError, "Не удалось сохранить услугу")
+	}
+}
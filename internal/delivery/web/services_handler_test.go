@@ -0,0 +1,176 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+type mockCatalogService struct {
+	ports.AppointmentService
+	services  []domain.Service
+	saved     *domain.Service
+	archived  map[string]bool
+	reordered []string
+	category  *domain.ServiceCategory
+}
+
+func (m *mockCatalogService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	return m.services, nil
+}
+
+func (m *mockCatalogService) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	if svc.Name == "" {
+		return nil, domain.ErrInvalidService
+	}
+	if svc.ID == "" {
+		svc.ID = "99"
+	}
+	m.saved = &svc
+	return &svc, nil
+}
+
+func (m *mockCatalogService) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	if id != "1" {
+		return domain.ErrServiceNotFound
+	}
+	m.archived[id] = archived
+	return nil
+}
+
+func (m *mockCatalogService) ReorderServices(ctx context.Context, orderedIDs []string) error {
+	m.reordered = orderedIDs
+	return nil
+}
+
+func (m *mockCatalogService) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	return []domain.ServiceCategory{{ID: "massages", Label: "Массаж"}}, nil
+}
+
+func (m *mockCatalogService) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	if cat.ID == "" {
+		return domain.ErrInvalidService
+	}
+	m.category = &cat
+	return nil
+}
+
+func postServices(t *testing.T, handler http.HandlerFunc, path string, body map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	jsonBody, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonBody))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestServicesHandler(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	adminInit := makeInitData("100", "Admin", botToken)
+	userInit := makeInitData("200", "User", botToken)
+
+	service := &mockCatalogService{
+		services: []domain.Service{{ID: "1", Name: "Массаж", DurationMinutes: 40, Price: 2000}},
+		archived: make(map[string]bool),
+	}
+	handler := NewServicesHandler(service, botToken, []string{"100"})
+
+	// 1. Admin lists the catalog
+	req, _ := http.NewRequest("GET", "/api/services?initData="+url.QueryEscape(adminInit), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for admin list, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	var listResp struct {
+		Services   []domain.Service         `json:"services"`
+		Categories []domain.ServiceCategory `json:"categories"`
+	}
+	_ = json.Unmarshal(rr.Body.Bytes(), &listResp)
+	if len(listResp.Services) != 1 || len(listResp.Categories) != 1 {
+		t.Errorf("Expected 1 service and 1 category, got %d/%d", len(listResp.Services), len(listResp.Categories))
+	}
+
+	// 2. Non-admin is rejected
+	req, _ = http.NewRequest("GET", "/api/services", nil)
+	req.Header.Set("X-Telegram-Init-Data", userInit)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for non-admin, got %d", rr.Code)
+	}
+
+	// 3. Missing initData
+	req, _ = http.NewRequest("GET", "/api/services", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without initData, got %d", rr.Code)
+	}
+
+	// 4. Save a new service
+	rr = postServices(t, handler, "/api/services/save", map[string]interface{}{
+		"initData": adminInit,
+		"service":  map[string]interface{}{"name": "Новая", "duration_minutes": 30, "price": 1000},
+	})
+	if rr.Code != http.StatusOK || service.saved == nil || service.saved.DurationMinutes != 30 {
+		t.Errorf("Expected service saved, got %d (%+v)", rr.Code, service.saved)
+	}
+
+	// 5. Invalid service data
+	rr = postServices(t, handler, "/api/services/save", map[string]interface{}{
+		"initData": adminInit,
+		"service":  map[string]interface{}{"duration_minutes": 30},
+	})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid service, got %d", rr.Code)
+	}
+
+	// 6. Archive
+	rr = postServices(t, handler, "/api/services/archive", map[string]interface{}{
+		"initData": adminInit, "id": "1", "archived": true,
+	})
+	if rr.Code != http.StatusOK || !service.archived["1"] {
+		t.Errorf("Expected service archived, got %d", rr.Code)
+	}
+
+	// 7. Archive unknown service
+	rr = postServices(t, handler, "/api/services/archive", map[string]interface{}{
+		"initData": adminInit, "id": "404", "archived": true,
+	})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown service, got %d", rr.Code)
+	}
+
+	// 8. Reorder
+	rr = postServices(t, handler, "/api/services/reorder", map[string]interface{}{
+		"initData": adminInit, "orderedIds": []string{"2", "1"},
+	})
+	if rr.Code != http.StatusOK || len(service.reordered) != 2 {
+		t.Errorf("Expected reorder, got %d (%v)", rr.Code, service.reordered)
+	}
+
+	// 9. Save a category
+	rr = postServices(t, handler, "/api/services/category", map[string]interface{}{
+		"initData": adminInit,
+		"category": map[string]interface{}{"id": "spa", "label": "SPA", "icon": "🧖"},
+	})
+	if rr.Code != http.StatusOK || service.category == nil || service.category.Icon != "🧖" {
+		t.Errorf("Expected category saved, got %d (%+v)", rr.Code, service.category)
+	}
+
+	// 10. Unsupported method
+	req, _ = http.NewRequest("DELETE", "/api/services", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", rr.Code)
+	}
+}
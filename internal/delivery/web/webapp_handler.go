@@ -19,7 +19,9 @@ import (
 // NewWebAppHandler creates the main handler for the WebApp.
 // It performs auth (InitData preferred, HMAC fallback), enforces admin
 // routing, and renders either the patient card or the admin search page.
-func NewWebAppHandler(repo ports.Repository, apptService ports.AppointmentService, presenter *presentation.WebPresenter, botToken string, adminIDs []string, secret string) http.HandlerFunc {
+// packages may be nil, in which case the card shows no package balance;
+// likewise payments and the outstanding balance.
+func NewWebAppHandler(repo ports.Repository, apptService ports.AppointmentService, packages ports.PackageService, payments ports.PaymentService, presenter *presentation.WebPresenter, botToken string, adminIDs []string, secret string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		logging.Debugf(" [WebApp]: Incoming Request: %s %s RemoteAddr: %s", r.Method, r.URL.String(), r.RemoteAddr)
 		// Prepare paths for query parsing (supports both root and /card)
@@ -151,9 +153,12 @@ func NewWebAppHandler(repo ports.Repository, apptService ports.AppointmentServic
 		// If DB is empty -> We MUST sync synchronously (blocking) to show data
 		// If DB has data -> We sync asynchronously (non-blocking) to update cache
 
+		// With calendar sync running the DB is complete, so no calendar reads
 		var appts []domain.Appointment
 
-		if len(dbAppts) == 0 {
+		if apptService.HistorySynced() {
+			appts = dbAppts
+		} else if len(dbAppts) == 0 {
 			// EMPTY CACHE: Blocking Sync
 			logging.Infof("Cache miss for %s. Performing blocking sync...", finalID)
 			fetchedAppts, err := apptService.GetCustomerHistory(r.Context(), finalID)
@@ -314,10 +319,44 @@ func NewWebAppHandler(repo ports.Repository, apptService ports.AppointmentServic
 		hasMore := offset+limit < len(appts)
 		nextOffset := offset + limit
 
+		// Active prepaid packages for the balance block
+		var activePackages []domain.SessionPackage
+		if packages != nil {
+			pkgs, err := packages.ListForPatient(r.Context(), finalID)
+			if err != nil {
+				logging.Warnf("Failed to load packages for %s: %v", finalID, err)
+			}
+			for _, p := range pkgs {
+				if p.ActiveAt(time.Now()) {
+					p.ExpiresAt = p.ExpiresAt.In(domain.ApptTimeZone)
+					activePackages = append(activePackages, p)
+				}
+			}
+		}
+
+		// Unpaid visits for the balance block
+		var balance string
+		if payments != nil {
+			if owed, err := payments.OutstandingBalance(r.Context(), finalID); err != nil {
+				logging.Warnf("Failed to load balance for %s: %v", finalID, err)
+			} else if owed > 0 {
+				balance = presentation.FormatMoney(owed, payments.Currency())
+			}
+		}
+
+		// Missed visits for the no-show badge
+		if counts, err := apptService.GetPatientStatusCounts(r.Context(), finalID); err != nil {
+			logging.Warnf("Failed to count statuses for %s: %v", finalID, err)
+		} else {
+			patient.NoShows = counts[domain.StatusNoShow]
+		}
+
 		// Prepare Template Data
 		data := struct {
 			Title        string
 			Patient      domain.Patient
+			Packages     []domain.SessionPackage
+			Balance      string
 			RecentVisits []domain.Appointment
 			Drafts       []map[string]interface{}
 			DocGroups    []interface{}
@@ -329,6 +368,8 @@ func NewWebAppHandler(repo ports.Repository, apptService ports.AppointmentServic
 		}{
 			Title:        "Карта пациента",
 			Patient:      patient,
+			Packages:     activePackages,
+			Balance:      balance,
 			RecentVisits: viewAppts,
 			Drafts:       drafts,
 			DocGroups:    docGroups,
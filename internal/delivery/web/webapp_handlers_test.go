@@ -109,12 +109,22 @@ type mockApptService struct {
 	ports.AppointmentService
 	appointments map[string]domain.Appointment
 	cancelError  error // if set, CancelAppointment returns this error
+	statuses     map[string]domain.AppointmentStatus
+	noShows      int
+	policy       *domain.CancellationPolicy
+	synced       bool // HistorySynced
+	historyCalls int
 }
 
 func (m *mockApptService) GetCustomerHistory(ctx context.Context, id string) ([]domain.Appointment, error) {
+	m.historyCalls++
 	return []domain.Appointment{}, nil
 }
 
+func (m *mockApptService) HistorySynced() bool {
+	return m.synced
+}
+
 func (m *mockApptService) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
 	if appt, ok := m.appointments[id]; ok {
 		return &appt, nil
@@ -133,6 +143,25 @@ func (m *mockApptService) CancelAppointment(ctx context.Context, id string) erro
 	return fmt.Errorf("not found")
 }
 
+func (m *mockApptService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	if m.statuses == nil {
+		m.statuses = make(map[string]domain.AppointmentStatus)
+	}
+	m.statuses[appt.ID] = status
+	return nil
+}
+
+func (m *mockApptService) GetPatientStatusCounts(ctx context.Context, id string) (map[domain.AppointmentStatus]int, error) {
+	return map[domain.AppointmentStatus]int{domain.StatusNoShow: m.noShows}, nil
+}
+
+func (m *mockApptService) GetCancellationPolicy() domain.CancellationPolicy {
+	if m.policy != nil {
+		return *m.policy
+	}
+	return domain.DefaultCancellationPolicy()
+}
+
 // signTestInitData mimics telegram's HMAC signature
 func signTestInitData(data map[string]string, token string) string {
 	var keys []string
@@ -183,7 +212,7 @@ func TestAdminViewPatient(t *testing.T) {
 	service := &mockApptService{}
 
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{adminID}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{adminID}, "secret")
 
 	initData := makeInitData(adminID, "Admin", botToken)
 
@@ -206,12 +235,107 @@ func TestAdminViewPatient(t *testing.T) {
 	}
 }
 
+// stubPackageService returns fixed packages for the card's balance block.
+type stubPackageService struct {
+	packages []domain.SessionPackage
+}
+
+func (s *stubPackageService) Sell(ctx context.Context, pkg domain.SessionPackage) (*domain.SessionPackage, error) {
+	return &pkg, nil
+}
+func (s *stubPackageService) ListForPatient(ctx context.Context, patientID string) ([]domain.SessionPackage, error) {
+	return s.packages, nil
+}
+
+func TestWebAppHandler_PackageBalance(t *testing.T) {
+	patientID := "200"
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	repo := &mockRepo{patient: domain.Patient{TelegramID: patientID, Name: "Target Patient"}}
+	packages := &stubPackageService{packages: []domain.SessionPackage{
+		{Title: "Классический массаж", SessionsTotal: 10, SessionsUsed: 3, ExpiresAt: time.Now().AddDate(0, 1, 0)},
+		{Title: "Старый абонемент", SessionsTotal: 5, SessionsUsed: 5, ExpiresAt: time.Now().AddDate(0, 1, 0)},
+	}}
+	presenter, _ := presentation.NewWebPresenter()
+	handler := NewWebAppHandler(repo, &mockApptService{}, packages, nil, presenter, botToken, []string{}, "secret")
+
+	req, _ := http.NewRequest("GET", "/?initData="+url.QueryEscape(makeInitData(patientID, "Target", botToken)), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", rr.Code)
+	}
+	body := rr.Body.String()
+	if !strings.Contains(body, "Классический массаж") || !strings.Contains(body, "из 10") {
+		t.Errorf("Expected the active package balance in the card")
+	}
+	if strings.Contains(body, "Старый абонемент") {
+		t.Errorf("Used-up packages should not be shown")
+	}
+}
+
+// stubPaymentService returns a fixed amount owed for the card's balance block.
+type stubPaymentService struct {
+	ports.PaymentService
+	owed map[string]float64
+}
+
+func (s *stubPaymentService) OutstandingBalance(ctx context.Context, patientID string) (float64, error) {
+	return s.owed[patientID], nil
+}
+func (s *stubPaymentService) Currency() string { return "TRY" }
+
+func TestWebAppHandler_OutstandingBalance(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	presenter, _ := presentation.NewWebPresenter()
+	payments := &stubPaymentService{owed: map[string]float64{"200": 3500}}
+
+	for _, tt := range []struct {
+		patientID string
+		want      bool
+	}{{"200", true}, {"201", false}} {
+		repo := &mockRepo{patient: domain.Patient{TelegramID: tt.patientID, Name: "Target Patient"}}
+		handler := NewWebAppHandler(repo, &mockApptService{}, nil, payments, presenter, botToken, []string{}, "secret")
+
+		req, _ := http.NewRequest("GET", "/?initData="+url.QueryEscape(makeInitData(tt.patientID, "Target", botToken)), nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected 200 OK, got %d", rr.Code)
+		}
+		body := rr.Body.String()
+		if got := strings.Contains(body, "К ОПЛАТЕ") && strings.Contains(body, "3500 ₺"); got != tt.want {
+			t.Errorf("patient %s: balance shown = %v, want %v", tt.patientID, got, tt.want)
+		}
+	}
+}
+
+func TestWebAppHandler_NoShowCount(t *testing.T) {
+	patientID := "200"
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	repo := &mockRepo{patient: domain.Patient{TelegramID: patientID, Name: "Target Patient"}}
+	presenter, _ := presentation.NewWebPresenter()
+	handler := NewWebAppHandler(repo, &mockApptService{noShows: 3}, nil, nil, presenter, botToken, []string{}, "secret")
+
+	req, _ := http.NewRequest("GET", "/?initData="+url.QueryEscape(makeInitData(patientID, "Target", botToken)), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Неявок: 3") {
+		t.Errorf("Expected the no-show count in the card")
+	}
+}
+
 func TestWebAppHandler_Unauthenticated(t *testing.T) {
 	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
 	repo := &mockRepo{}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
 
 	// No auth at all -> should show loading page
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -233,7 +357,7 @@ func TestWebAppHandler_AdminSearchPage(t *testing.T) {
 	repo := &mockRepo{patient: domain.Patient{TelegramID: adminID, Name: "Admin"}}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{adminID}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{adminID}, "secret")
 
 	// Admin with no target ID -> search page
 	initData := makeInitData(adminID, "Admin", botToken)
@@ -253,7 +377,7 @@ func TestWebAppHandler_HMACAuth(t *testing.T) {
 	repo := &mockRepo{patient: domain.Patient{TelegramID: patientID, Name: "HMAC Patient"}}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, secret)
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, secret)
 
 	// Generate valid HMAC token
 	h := hmac.New(sha256.New, []byte(secret))
@@ -277,7 +401,7 @@ func TestWebAppHandler_PatientNotFound_SelfHeal(t *testing.T) {
 	repo := &mockRepo{}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
 
 	// Unknown patient -> self-heal path
 	initData := makeInitData("777", "NewUser", botToken)
@@ -419,7 +543,7 @@ func TestWebAppHandler_WithAppointments(t *testing.T) {
 
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
 
 	initData := makeInitData(patientID, "Patient", botToken)
 	req, _ := http.NewRequest("GET", "/?id="+patientID+"&initData="+url.QueryEscape(initData), nil)
@@ -434,6 +558,31 @@ func TestWebAppHandler_WithAppointments(t *testing.T) {
 	}
 }
 
+func TestWebAppHandler_SyncedHistorySkipsCalendar(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	patientID := "501"
+
+	repo := &mockRepo{
+		patient: domain.Patient{TelegramID: patientID, Name: "Synced Patient"},
+	}
+
+	service := &mockApptService{synced: true}
+	presenter, _ := presentation.NewWebPresenter()
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
+
+	initData := makeInitData(patientID, "Patient", botToken)
+	req, _ := http.NewRequest("GET", "/?id="+patientID+"&initData="+url.QueryEscape(initData), nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if service.historyCalls != 0 {
+		t.Errorf("Expected no calendar history reads, got %d", service.historyCalls)
+	}
+}
+
 func TestHandleSearch(t *testing.T) {
 	adminID := "100"
 	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
@@ -465,6 +614,47 @@ func TestHandleSearch(t *testing.T) {
 	}
 }
 
+// TestHandleCancel_SendsCancelFile checks that the patient is sent a
+// METHOD:CANCEL .ics and the admins a message.
+func TestHandleCancel_SendsCancelFile(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	var uploads []string
+	var messages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/sendDocument") {
+			file, _, err := r.FormFile("document")
+			if err == nil {
+				data, _ := io.ReadAll(file)
+				uploads = append(uploads, r.FormValue("chat_id")+":"+string(data))
+			}
+		} else {
+			messages = append(messages, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	withTelegramAPIBase(t, srv.URL)
+
+	service := &mockApptService{appointments: map[string]domain.Appointment{
+		"appt_1": {ID: "appt_1", CustomerTgID: "300", StartTime: time.Now().Add(100 * time.Hour), Service: domain.Service{Name: "Massage"}},
+	}}
+	handler := NewCancelHandler(service, botToken, []string{"999"}, presentation.NewBotPresenter())
+
+	jsonBody, _ := json.Marshal(map[string]string{"initData": makeInitData("300", "User", botToken), "apptId": "appt_1"})
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/cancel", bytes.NewBuffer(jsonBody)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d. Body: %s", rr.Code, rr.Body.String())
+	}
+	if len(uploads) != 1 || !strings.HasPrefix(uploads[0], "300:") || !strings.Contains(uploads[0], "METHOD:CANCEL") || !strings.Contains(uploads[0], "UID:appt_1@massage-bot") {
+		t.Errorf("Expected a cancellation .ics for the patient, got %v", uploads)
+	}
+	if len(messages) != 1 {
+		t.Errorf("Expected one admin notification, got %v", messages)
+	}
+}
+
 func TestHandleCancel(t *testing.T) {
 	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
 	userID := "300"
@@ -502,6 +692,9 @@ func TestHandleCancel(t *testing.T) {
 	if rr.Code != http.StatusOK {
 		t.Errorf("Expected 200 OK for valid cancel, got %d. Body: %s", rr.Code, rr.Body.String())
 	}
+	if got := service.statuses[apptID]; got != domain.StatusCancelledByPatient {
+		t.Errorf("Expected cancellation by patient to be recorded, got %q", got)
+	}
 
 	// 2. Cancel Someone Else's Appt (Forbidden)
 	service.appointments[apptID] = domain.Appointment{
@@ -596,6 +789,48 @@ func TestHandleCancel(t *testing.T) {
 	if rrAdmin.Code != http.StatusOK {
 		t.Errorf("Expected 200 for admin cancel, got %d", rrAdmin.Code)
 	}
+	if got := service.statuses["late_appt"]; got != domain.StatusCancelledByAdmin {
+		t.Errorf("Expected cancellation by admin to be recorded, got %q", got)
+	}
+}
+
+func TestHandleCancel_LateCancelWarning(t *testing.T) {
+	botToken := "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+	userID := "300"
+	service := &mockApptService{
+		appointments: map[string]domain.Appointment{
+			"soon": {ID: "soon", CustomerTgID: userID, StartTime: time.Now().Add(5 * time.Hour)},
+		},
+		policy: &domain.CancellationPolicy{NoticeHours: 24},
+	}
+	handler := NewCancelHandler(service, botToken, []string{"999"}, presentation.NewBotPresenter())
+
+	post := func(confirm bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"initData":    makeInitData(userID, "User", botToken),
+			"apptId":      "soon",
+			"confirmLate": confirm,
+		})
+		req, _ := http.NewRequest("POST", "/cancel", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post(false)
+	if rr.Code != http.StatusConflict || !strings.Contains(rr.Body.String(), "confirm_late") {
+		t.Fatalf("Expected 409 asking to confirm, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if _, ok := service.appointments["soon"]; !ok {
+		t.Fatal("Appointment must not be cancelled before confirmation")
+	}
+
+	if rr := post(true); rr.Code != http.StatusOK {
+		t.Fatalf("Expected 200 after confirmation, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := service.statuses["soon"]; got != domain.StatusLateCancel {
+		t.Errorf("Expected late cancel to be recorded, got %q", got)
+	}
 }
 
 func TestHandleCancel_ServiceError(t *testing.T) {
@@ -975,7 +1210,7 @@ func TestWebAppHandler_WithAppointmentsAndMedia(t *testing.T) {
 
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
 
 	initData := makeInitData(patientID, "Patient", botToken)
 	req, _ := http.NewRequest("GET", "/?id="+patientID+"&initData="+url.QueryEscape(initData), nil)
@@ -1000,7 +1235,7 @@ func TestWebAppHandler_InitDataFailHMACFallback(t *testing.T) {
 	}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, secret)
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, secret)
 
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write([]byte(patientID))
@@ -1153,7 +1388,7 @@ func TestWebAppHandler_HMACAdminSearchPage(t *testing.T) {
 	repo := &mockRepo{}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{adminID}, secret)
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{adminID}, secret)
 
 	h := hmac.New(sha256.New, []byte(secret))
 	h.Write([]byte(adminID))
@@ -1210,7 +1445,7 @@ func makePaginationHandler(t *testing.T, n int) (http.HandlerFunc, string, strin
 	}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{adminID}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{adminID}, "secret")
 	return handler, adminID, patientID, repo
 }
 
@@ -1326,7 +1561,7 @@ func TestWebAppHandler_SelfHealNoName(t *testing.T) {
 	repo := &mockRepo{}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, secret)
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, secret)
 
 	// Use HMAC auth (no name in payload) to trigger the `name == ""` fallback to "Пациент"
 	h := hmac.New(sha256.New, []byte(secret))
@@ -1357,7 +1592,7 @@ func TestWebAppHandler_DBHistoryError(t *testing.T) {
 	}
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, "secret")
 
 	initData := makeInitData(patientID, "Patient", botToken)
 	req, _ := http.NewRequest("GET", "/?id="+patientID+"&initData="+url.QueryEscape(initData), nil)
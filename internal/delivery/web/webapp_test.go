@@ -401,7 +401,7 @@ func TestWebAppHandler_InvalidHMAC(t *testing.T) {
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
 
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{}, secret)
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{}, secret)
 
 	req, _ := http.NewRequest("GET", "/?id=12345&token=invalid-token", nil)
 	rr := httptest.NewRecorder()
@@ -428,7 +428,7 @@ func TestWebAppHandler_AdminNoTarget(t *testing.T) {
 	service := &mockApptService{}
 	presenter, _ := presentation.NewWebPresenter()
 
-	handler := NewWebAppHandler(repo, service, presenter, botToken, []string{adminID}, "secret")
+	handler := NewWebAppHandler(repo, service, nil, nil, presenter, botToken, []string{adminID}, "secret")
 
 	initData := makeInitData(adminID, "Admin", botToken)
 	req, _ := http.NewRequest("GET", "/?initData="+url.QueryEscape(initData), nil)
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// DayStatus summarises how bookable a calendar day is for one service.
+type DayStatus string
+
+const (
+	DayFree    DayStatus = "free"    // Plenty of free time
+	DayLimited DayStatus = "limited" // Only a few slots left
+	DayFull    DayStatus = "full"    // Working day, but nothing left (or already over)
+	DayClosed  DayStatus = "closed"  // No working hours, or in the past
+)
+
+// LimitedDaySlots is the number of free slots at or below which a working
+// day counts as limited rather than free.
+const LimitedDaySlots = 2
+
+// DayAvailability is one day of a month availability overview.
+type DayAvailability struct {
+	Date      time.Time `json:"date"` // Midnight in ApptTimeZone
+	Status    DayStatus `json:"status"`
+	FreeSlots int       `json:"free_slots"`
+}
+
+// ClassifyDay tells a day's status from whether it has working hours and
+// how many slots are still free.
+func ClassifyDay(open bool, freeSlots int) DayStatus {
+	switch {
+	case !open:
+		return DayClosed
+	case freeSlots == 0:
+		return DayFull
+	case freeSlots <= LimitedDaySlots:
+		return DayLimited
+	default:
+		return DayFree
+	}
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "testing"
+
+func TestClassifyDay(t *testing.T) {
+	tests := []struct {
+		open  bool
+		slots int
+		want  DayStatus
+	}{
+		{false, 0, DayClosed},
+		{false, 5, DayClosed},
+		{true, 0, DayFull},
+		{true, 1, DayLimited},
+		{true, LimitedDaySlots, DayLimited},
+		{true, LimitedDaySlots + 1, DayFree},
+	}
+	for _, tt := range tests {
+		if got := ClassifyDay(tt.open, tt.slots); got != tt.want {
+			t.Errorf("ClassifyDay(%v, %d) = %q, want %q", tt.open, tt.slots, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// WatchChannel is a push-notification channel on one calendar (Google
+// Calendar events.watch). The calendar pings Address whenever an event
+// changes, until Expiration.
+type WatchChannel struct {
+	ID         string
+	ResourceID string // Set by the calendar; needed to stop the channel
+	Token      string // Sent back with every ping, so pings can be verified
+	Address    string
+	CalendarID string
+	Expiration time.Time
+}
+
+// ExpiresWithin reports whether the channel lapses within d of now.
+func (c WatchChannel) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return !c.Expiration.After(now.Add(d))
+}
+
+// CalendarNotification is one ping received from a watch channel.
+type CalendarNotification struct {
+	ChannelID  string
+	ResourceID string
+	Token      string
+	State      string // "sync" right after the channel opens, "exists" on changes
+}
+
+// CalendarChangeKind is what an edit made directly in the calendar did to a
+// booking.
+type CalendarChangeKind string
+
+const (
+	ChangeMoved     CalendarChangeKind = "moved"     // New start time or duration
+	ChangeCancelled CalendarChangeKind = "cancelled" // Event deleted
+)
+
+// CalendarChange is a booking edited outside the bot, as found by comparing
+// the calendar with the last known state in the appointments table.
+type CalendarChange struct {
+	Kind     CalendarChangeKind
+	Previous Appointment
+	Current  Appointment // Zero for cancellations
+}
+
+// DetectCalendarChange compares the stored state of a booking with its
+// event. It reports false when nothing the patient cares about changed.
+func DetectCalendarChange(previous, current Appointment) (CalendarChange, bool) {
+	if current.Status == "cancelled" {
+		return CalendarChange{Kind: ChangeCancelled, Previous: previous}, true
+	}
+	moved := !current.StartTime.Equal(previous.StartTime)
+	// Rows cached without a duration only tell the start
+	if before := previous.EndTime.Sub(previous.StartTime); before > 0 && before != current.EndTime.Sub(current.StartTime) {
+		moved = true
+	}
+	if !moved {
+		return CalendarChange{}, false
+	}
+	return CalendarChange{Kind: ChangeMoved, Previous: previous, Current: current}, true
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatchChannel_ExpiresWithin(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	channel := WatchChannel{Expiration: now.Add(2 * time.Hour)}
+
+	if channel.ExpiresWithin(time.Hour, now) {
+		t.Error("a channel valid for two more hours does not expire within one")
+	}
+	if !channel.ExpiresWithin(2*time.Hour, now) {
+		t.Error("a channel expiring exactly at the limit should be renewed")
+	}
+	if !(WatchChannel{}).ExpiresWithin(time.Hour, now) {
+		t.Error("a channel without expiration should count as expired")
+	}
+}
+
+func TestDetectCalendarChange(t *testing.T) {
+	start := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	stored := Appointment{ID: "a1", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	tests := []struct {
+		name    string
+		prev    Appointment
+		current Appointment
+		want    CalendarChangeKind
+	}{
+		{"unchanged", stored, Appointment{ID: "a1", StartTime: start.In(time.FixedZone("TRT", 3*3600)), EndTime: start.Add(time.Hour)}, ""},
+		{"moved", stored, Appointment{ID: "a1", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)}, ChangeMoved},
+		{"shortened", stored, Appointment{ID: "a1", StartTime: start, EndTime: start.Add(30 * time.Minute)}, ChangeMoved},
+		{"deleted", stored, Appointment{ID: "a1", Status: "cancelled"}, ChangeCancelled},
+		{"no stored duration", Appointment{ID: "a1", StartTime: start, EndTime: start}, Appointment{ID: "a1", StartTime: start, EndTime: start.Add(time.Hour)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, changed := DetectCalendarChange(tt.prev, tt.current)
+			if changed != (tt.want != "") || change.Kind != tt.want {
+				t.Errorf("DetectCalendarChange() = %q, %v; want %q", change.Kind, changed, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// CancellationPolicy controls how patients may cancel on their own. Inside
+// the notice window a cancellation is late: it is either blocked (the
+// patient has to contact the therapist) or allowed after a warning and
+// recorded as a late cancel.
+type CancellationPolicy struct {
+	NoticeHours int  `json:"notice_hours"` // Minimum notice for a regular cancellation; 0 disables the window
+	BlockLate   bool `json:"block_late"`   // Refuse self-service cancellation inside the window
+}
+
+// MaxCancellationNoticeHours caps the notice window at two weeks.
+const MaxCancellationNoticeHours = 14 * 24
+
+// DefaultCancellationPolicy blocks cancellations less than 72 hours ahead,
+// the original behaviour.
+func DefaultCancellationPolicy() CancellationPolicy {
+	return CancellationPolicy{NoticeHours: 72, BlockLate: true}
+}
+
+// Validate checks the notice window is within bounds.
+func (p CancellationPolicy) Validate() error {
+	if p.NoticeHours < 0 || p.NoticeHours > MaxCancellationNoticeHours {
+		return fmt.Errorf("%w: notice of %d hours (allowed: 0-%d)", ErrInvalidCancellationPolicy, p.NoticeHours, MaxCancellationNoticeHours)
+	}
+	return nil
+}
+
+// Notice returns the notice window as a duration.
+func (p CancellationPolicy) Notice() time.Duration {
+	return time.Duration(p.NoticeHours) * time.Hour
+}
+
+// IsLate reports whether cancelling an appointment starting at start is
+// late at now.
+func (p CancellationPolicy) IsLate(start, now time.Time) bool {
+	return p.NoticeHours > 0 && start.Sub(now) < p.Notice()
+}
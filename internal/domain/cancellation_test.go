@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCancellationPolicy_IsLate(t *testing.T) {
+	now := time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)
+	policy := DefaultCancellationPolicy()
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  bool
+	}{
+		{"well ahead", now.Add(96 * time.Hour), false},
+		{"exactly on the window", now.Add(72 * time.Hour), false},
+		{"inside the window", now.Add(71 * time.Hour), true},
+		{"already started", now.Add(-time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.IsLate(tt.start, now); got != tt.want {
+				t.Errorf("IsLate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if (CancellationPolicy{}).IsLate(now.Add(time.Minute), now) {
+		t.Error("a zero notice window should never be late")
+	}
+}
+
+func TestCancellationPolicy_Validate(t *testing.T) {
+	for _, p := range []CancellationPolicy{{NoticeHours: 0}, {NoticeHours: 24}, {NoticeHours: MaxCancellationNoticeHours}} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", p, err)
+		}
+	}
+	for _, p := range []CancellationPolicy{{NoticeHours: -1}, {NoticeHours: MaxCancellationNoticeHours + 1}} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidCancellationPolicy) {
+			t.Errorf("Validate(%+v) = %v, want ErrInvalidCancellationPolicy", p, err)
+		}
+	}
+}
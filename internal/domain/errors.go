@@ -10,8 +10,42 @@ var (
 	ErrOutsideWorkingHours   = errors.New("appointment time is outside working hours") // Renamed from ErrOutsideBusinessHours and consolidated
 	ErrSlotUnavailable       = errors.New("the chosen time slot is unavailable")       // Renamed from ErrSlotNotAvailable and consolidated
 	ErrServiceNotFound       = errors.New("service not found")
+	ErrInvalidService        = errors.New("invalid service details provided")
+	ErrCatalogUnavailable    = errors.New("service catalog is not configured")
+	ErrInvalidSchedule       = errors.New("invalid working schedule")
+	ErrScheduleUnavailable   = errors.New("working schedule is not configured")
+	ErrInvalidTherapist      = errors.New("invalid therapist details provided")
+	ErrTherapistNotFound     = errors.New("therapist not found")
 	ErrAppointmentNotFound   = errors.New("appointment not found")
 	ErrInvalidID             = errors.New("invalid ID provided")
 	ErrCalendarEventNotFound = errors.New("calendar event not found")
 	ErrUserBanned            = errors.New("user is banned")
+	ErrInvalidWaitlistEntry  = errors.New("invalid waitlist request")
+	ErrWaitlistUnavailable   = errors.New("waitlist is not configured")
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrOfferNotFound         = errors.New("waitlist offer not found")
+	ErrOfferExpired          = errors.New("waitlist offer is no longer valid")
+	ErrInvalidSeries         = errors.New("invalid recurring series")
+	ErrSeriesConflict        = errors.New("recurring series conflicts with existing bookings")
+	ErrSeriesNotFound        = errors.New("appointment is not part of a series")
+	ErrSeriesUnavailable     = errors.New("recurring series are not configured")
+	ErrInvalidPackage        = errors.New("invalid session package")
+	ErrPackageNotFound       = errors.New("session package not found")
+	ErrPackageExhausted      = errors.New("session package has no sessions left")
+	ErrInvalidStatusChange   = errors.New("invalid appointment status change")
+	ErrOutcomeBeforeStart    = errors.New("appointment outcome cannot be set before it starts")
+	ErrStatusUnavailable     = errors.New("appointment statuses are not configured")
+	ErrUnknownWatchChannel   = errors.New("calendar watch channel is not known")
+	ErrInvalidWatchToken     = errors.New("calendar watch channel token does not match")
+	ErrSyncTokenExpired      = errors.New("calendar sync token expired, a full sync is needed")
+	ErrInvalidPayment        = errors.New("invalid payment")
+	ErrVisitNotFound         = errors.New("no unpaid visit found")
+	ErrInvalidPromoCode      = errors.New("invalid promo code")
+	ErrPromoNotFound         = errors.New("promo code not found")
+	ErrPromoExists           = errors.New("promo code already exists")
+	ErrPromoExpired          = errors.New("promo code is not valid at this time")
+	ErrPromoUsedUp           = errors.New("promo code has no uses left")
+	ErrPromoNotApplicable    = errors.New("promo code does not apply to this service")
+
+	ErrInvalidCancellationPolicy = errors.New("invalid cancellation policy")
 )
@@ -6,12 +6,56 @@ import (
 )
 
 // Service represents a massage service offered.
+// Appointments keep their own copy of the service (see Appointment.Service),
+// so editing or archiving a catalog entry never rewrites past bookings.
 type Service struct {
 	ID              string  `json:"id" db:"id"`                             // Unique identifier for the service
 	Name            string  `json:"name" db:"name"`                         // Service name
 	DurationMinutes int     `json:"duration_minutes" db:"duration"`         // Duration in minutes (db tag maps to :service.duration in queries)
 	Price           float64 `json:"price" db:"price"`                       // Service price
 	Description     string  `json:"description,omitempty" db:"description"` // Service description
+	CategoryID      string  `json:"category_id,omitempty" db:"category_id"` // Menu category key (e.g. "massages")
+	SortOrder       int     `json:"sort_order" db:"sort_order"`             // Position in menus, ascending
+	Archived        bool    `json:"archived,omitempty" db:"archived"`       // Archived services are hidden from booking
+}
+
+// DefaultServiceCategoryID is the category used for services that have none.
+const DefaultServiceCategoryID = "other"
+
+// Category returns the service's category key, falling back to
+// DefaultServiceCategoryID so uncategorized services stay bookable.
+func (s Service) Category() string {
+	if s.CategoryID == "" {
+		return DefaultServiceCategoryID
+	}
+	return s.CategoryID
+}
+
+// ServiceCategory groups services in the booking menu.
+type ServiceCategory struct {
+	ID        string `json:"id" db:"id"`                 // Stable key referenced by Service.CategoryID (kept short for callback data)
+	Label     string `json:"label" db:"label"`           // Button text shown to patients
+	Icon      string `json:"icon,omitempty" db:"icon"`   // Optional emoji prefix
+	SortOrder int    `json:"sort_order" db:"sort_order"` // Position in the menu, ascending
+}
+
+// ButtonText returns the label with its icon, as shown on the category keyboard.
+func (c ServiceCategory) ButtonText() string {
+	if c.Icon == "" {
+		return c.Label
+	}
+	return c.Icon + " " + c.Label
+}
+
+// Therapist is a practitioner patients can book. Each therapist has their own
+// calendar; an empty CalendarID means the default calendar from configuration.
+type Therapist struct {
+	ID         string `json:"id" db:"id"`                   // Short stable key (kept short for callback data)
+	Name       string `json:"name" db:"name"`               // Name shown to patients
+	TelegramID string `json:"telegram_id" db:"telegram_id"` // Receives notifications about their bookings
+	CalendarID string `json:"calendar_id" db:"calendar_id"` // Google Calendar holding their appointments
+	Active     bool   `json:"active" db:"active"`           // Inactive therapists are not offered for booking
+	SortOrder  int    `json:"sort_order" db:"sort_order"`   // Position in the therapist step, ascending
 }
 
 // TimeSlot represents an available time slot for an appointment.
@@ -32,6 +76,10 @@ type Appointment struct {
 	StartTime time.Time `json:"start_time" db:"start_time"`
 	EndTime   time.Time `json:"end_time" db:"end_time"`
 
+	// TherapistID is the practitioner the appointment belongs to. Empty means
+	// single-practitioner mode, or "any available" before CreateAppointment assigns one.
+	TherapistID string `json:"therapist_id,omitempty" db:"therapist_id"`
+
 	// Client/Customer related information
 	ClientID     string `json:"client_id" db:"client_id"`         // Can be the same as ID, or a separate client-specific ID
 	ClientName   string `json:"client_name" db:"client_name"`     // Full name of the client (from Telegram or input)
@@ -44,8 +92,28 @@ type Appointment struct {
 	Status          string          `json:"status" db:"status"`                       // Event status (confirmed, tentative, cancelled)
 	ConfirmedAt     *time.Time      `json:"confirmed_at,omitempty"`
 	RemindersSent   map[string]bool `json:"reminders_sent,omitempty"` // Map of reminder types (72h, 24h) to sent status
+
+	// Source is how the appointment was booked, one of the Source* constants.
+	Source string `json:"source,omitempty"`
+	// LifecycleStatus is the lifecycle status recorded on the calendar event,
+	// for calendars that keep one; the status repository stays authoritative.
+	LifecycleStatus AppointmentStatus `json:"lifecycle_status,omitempty"`
+
+	// PromoCode is the code applied at booking and Discount the amount it
+	// took off the service price.
+	PromoCode string  `json:"promo_code,omitempty" db:"promo_code"`
+	Discount  float64 `json:"discount,omitempty" db:"discount"`
 }
 
+// Booking sources recorded with each appointment.
+const (
+	SourceBot      = "bot"      // Patient booked through the bot
+	SourceAdmin    = "admin"    // Admin booked on a patient's behalf
+	SourceBlock    = "block"    // Admin blocked the time
+	SourceSeries   = "series"   // Occurrence of a recurring series
+	SourceWaitlist = "waitlist" // Accepted waitlist offer
+)
+
 // --- Константы и глобальные переменные для временных слотов и рабочего дня ---
 const (
 	WorkDayStartHour = 9  // 9 AM
@@ -82,6 +150,7 @@ type Patient struct {
 	TherapistNotes   string    `json:"therapist_notes,omitempty" db:"therapist_notes"`
 	VoiceTranscripts string    `json:"voice_transcripts,omitempty" db:"voice_transcripts"`
 	CurrentService   string    `json:"current_service,omitempty" db:"current_service"`
+	NoShows          int       `json:"no_shows,omitempty" db:"-"` // Missed visits, filled from appointment statuses
 }
 
 // PatientMedia represents a media file associated with a patient
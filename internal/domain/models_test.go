@@ -289,6 +289,23 @@ func TestAnalyticsEventStruct(t *testing.T) {
 	}
 }
 
+// TestServiceCategory verifies category fallback and button text
+func TestServiceCategory(t *testing.T) {
+	if got := (Service{}).Category(); got != DefaultServiceCategoryID {
+		t.Errorf("Service{}.Category() = %q, want %q", got, DefaultServiceCategoryID)
+	}
+	if got := (Service{CategoryID: "massages"}).Category(); got != "massages" {
+		t.Errorf("Category() = %q, want massages", got)
+	}
+
+	if got := (ServiceCategory{Label: "Массаж", Icon: "💆"}).ButtonText(); got != "💆 Массаж" {
+		t.Errorf("ButtonText() = %q, want \"💆 Массаж\"", got)
+	}
+	if got := (ServiceCategory{Label: "Массаж"}).ButtonText(); got != "Массаж" {
+		t.Errorf("ButtonText() without icon = %q, want \"Массаж\"", got)
+	}
+}
+
 // BenchmarkSplitSummary benchmarks the SplitSummary function
 func BenchmarkSplitSummary(b *testing.B) {
 	summary := "Deep Tissue Massage - John Doe Smith"
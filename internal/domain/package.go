@@ -0,0 +1,74 @@
+package domain
+
+import "time"
+
+// SessionPackage is a prepaid set of sessions (an "абонемент") bought by a
+// patient. Every completed appointment of a covered service uses one
+// session until the package runs out or expires.
+type SessionPackage struct {
+	ID            int64     `db:"id" json:"id"`
+	PatientID     string    `db:"patient_id" json:"patient_id"` // Telegram ID
+	PatientName   string    `db:"patient_name" json:"patient_name"`
+	Title         string    `db:"title" json:"title"`
+	ServiceIDs    []string  `db:"-" json:"service_ids,omitempty"` // empty covers every service
+	SessionsTotal int       `db:"sessions_total" json:"sessions_total"`
+	SessionsUsed  int       `db:"sessions_used" json:"sessions_used"`
+	Price         float64   `db:"price" json:"price"`
+	PurchasedAt   time.Time `db:"purchased_at" json:"purchased_at"`
+	ExpiresAt     time.Time `db:"expires_at" json:"expires_at"`
+
+	// Admin alerts already sent for this package
+	LowBalanceAlerted bool `db:"low_balance_alerted" json:"-"`
+	ExpiryAlerted     bool `db:"expiry_alerted" json:"-"`
+}
+
+// Remaining returns the number of unused sessions.
+func (p SessionPackage) Remaining() int {
+	if p.SessionsUsed >= p.SessionsTotal {
+		return 0
+	}
+	return p.SessionsTotal - p.SessionsUsed
+}
+
+// Covers reports whether the package may pay for the service.
+func (p SessionPackage) Covers(serviceID string) bool {
+	if len(p.ServiceIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ServiceIDs {
+		if id == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveAt reports whether the package still has sessions at t.
+func (p SessionPackage) ActiveAt(t time.Time) bool {
+	return p.Remaining() > 0 && t.Before(p.ExpiresAt)
+}
+
+// Validate checks a package before it is sold.
+func (p SessionPackage) Validate() error {
+	if p.PatientID == "" || p.SessionsTotal <= 0 || p.Price < 0 {
+		return ErrInvalidPackage
+	}
+	if p.PurchasedAt.IsZero() || !p.ExpiresAt.After(p.PurchasedAt) {
+		return ErrInvalidPackage
+	}
+	return nil
+}
+
+// PackageUsage links a completed appointment to the package it used.
+type PackageUsage struct {
+	PackageID     int64     `db:"package_id" json:"package_id"`
+	AppointmentID string    `db:"appointment_id" json:"appointment_id"`
+	ServiceName   string    `db:"service_name" json:"service_name"`
+	StartTime     time.Time `db:"start_time" json:"start_time"`
+}
+
+// Admin alerts sent once per package.
+const (
+	PackageAlertLowBalance = "low_balance"
+	PackageAlertExpiry     = "expiry"
+)
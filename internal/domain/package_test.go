@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionPackage_Remaining(t *testing.T) {
+	p := SessionPackage{SessionsTotal: 10, SessionsUsed: 3}
+	if got := p.Remaining(); got != 7 {
+		t.Errorf("Remaining() = %d, want 7", got)
+	}
+	p.SessionsUsed = 12
+	if got := p.Remaining(); got != 0 {
+		t.Errorf("Remaining() on an overdrawn package = %d, want 0", got)
+	}
+}
+
+func TestSessionPackage_Covers(t *testing.T) {
+	unscoped := SessionPackage{}
+	if !unscoped.Covers("massage") {
+		t.Error("a package without a scope should cover every service")
+	}
+	scoped := SessionPackage{ServiceIDs: []string{"massage", "rehab"}}
+	if !scoped.Covers("rehab") {
+		t.Error("expected rehab to be covered")
+	}
+	if scoped.Covers("consult") {
+		t.Error("expected consult not to be covered")
+	}
+}
+
+func TestSessionPackage_ActiveAt(t *testing.T) {
+	expires := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	p := SessionPackage{SessionsTotal: 5, SessionsUsed: 4, ExpiresAt: expires}
+
+	if !p.ActiveAt(expires.Add(-time.Hour)) {
+		t.Error("expected the package to be active before expiry")
+	}
+	if p.ActiveAt(expires) {
+		t.Error("expected the package to be inactive at expiry")
+	}
+	p.SessionsUsed = 5
+	if p.ActiveAt(expires.Add(-time.Hour)) {
+		t.Error("expected a used-up package to be inactive")
+	}
+}
+
+func TestSessionPackage_Validate(t *testing.T) {
+	bought := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	valid := SessionPackage{PatientID: "100", SessionsTotal: 10, Price: 9000, PurchasedAt: bought, ExpiresAt: bought.AddDate(0, 3, 0)}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *SessionPackage)
+	}{
+		{"no patient", func(p *SessionPackage) { p.PatientID = "" }},
+		{"no sessions", func(p *SessionPackage) { p.SessionsTotal = 0 }},
+		{"negative price", func(p *SessionPackage) { p.Price = -1 }},
+		{"expires before purchase", func(p *SessionPackage) { p.ExpiresAt = bought.Add(-time.Hour) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			if err := p.Validate(); !errors.Is(err, ErrInvalidPackage) {
+				t.Errorf("Validate() = %v, want ErrInvalidPackage", err)
+			}
+		})
+	}
+}
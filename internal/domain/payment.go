@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// PaymentMethod is how a visit was paid.
+type PaymentMethod string
+
+const (
+	PaymentCash     PaymentMethod = "cash"
+	PaymentCard     PaymentMethod = "card"
+	PaymentTransfer PaymentMethod = "transfer"
+	// PaymentPackage marks a visit covered by a prepaid package; its
+	// income was counted when the package was sold, so the amount is zero.
+	PaymentPackage PaymentMethod = "package"
+)
+
+// DefaultCurrency is the currency prices are kept in unless configured.
+const DefaultCurrency = "TRY"
+
+// Valid reports whether m is a known payment method.
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PaymentCash, PaymentCard, PaymentTransfer, PaymentPackage:
+		return true
+	}
+	return false
+}
+
+// Label returns the Russian name of the method shown to admins.
+func (m PaymentMethod) Label() string {
+	switch m {
+	case PaymentCash:
+		return "Наличные"
+	case PaymentCard:
+		return "Карта"
+	case PaymentTransfer:
+		return "Перевод"
+	case PaymentPackage:
+		return "Абонемент"
+	}
+	return string(m)
+}
+
+// ParsePaymentMethod reads a payment method as typed by an admin, in
+// English or Russian (e.g. "card", "карта", "нал").
+func ParsePaymentMethod(s string) (PaymentMethod, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "cash", "нал", "наличные":
+		return PaymentCash, true
+	case "card", "карта":
+		return PaymentCard, true
+	case "transfer", "перевод":
+		return PaymentTransfer, true
+	case "package", "абонемент":
+		return PaymentPackage, true
+	}
+	return "", false
+}
+
+// AmountDue is what the visit costs: its service price less the discount
+// applied at booking.
+func (a Appointment) AmountDue() float64 {
+	if a.Discount >= a.Service.Price {
+		return 0
+	}
+	return a.Service.Price - a.Discount
+}
+
+// Payment records how one appointment was paid. A visit has at most one
+// payment; recording it again replaces the previous one.
+type Payment struct {
+	ID            int64         `db:"id" json:"id"`
+	AppointmentID string        `db:"appointment_id" json:"appointment_id"`
+	PatientID     string        `db:"patient_id" json:"patient_id"` // Telegram ID
+	PatientName   string        `db:"patient_name" json:"patient_name"`
+	ServiceID     string        `db:"service_id" json:"service_id"`
+	ServiceName   string        `db:"service_name" json:"service_name"`
+	ListPrice     float64       `db:"list_price" json:"list_price"` // Service price before the discount
+	Discount      float64       `db:"discount" json:"discount"`
+	Amount        float64       `db:"amount" json:"amount"` // Amount actually received
+	Currency      string        `db:"currency" json:"currency"`
+	Method        PaymentMethod `db:"method" json:"method"`
+	VisitTime     time.Time     `db:"visit_time" json:"visit_time"`
+	PaidAt        time.Time     `db:"paid_at" json:"paid_at"`
+	RecordedBy    string        `db:"recorded_by" json:"recorded_by"` // Admin Telegram ID
+}
+
+// Validate checks a payment before it is recorded.
+func (p Payment) Validate() error {
+	if p.AppointmentID == "" || p.PatientID == "" || p.Currency == "" || !p.Method.Valid() {
+		return ErrInvalidPayment
+	}
+	if p.Amount < 0 || p.Discount < 0 || p.ListPrice < 0 || p.PaidAt.IsZero() {
+		return ErrInvalidPayment
+	}
+	return nil
+}
+
+// RevenuePeriod is the bucket size of a revenue report.
+type RevenuePeriod string
+
+const (
+	RevenueDay   RevenuePeriod = "day"
+	RevenueWeek  RevenuePeriod = "week"
+	RevenueMonth RevenuePeriod = "month"
+)
+
+// Start returns the beginning of the period containing t, in t's location.
+// Weeks start on Monday.
+func (p RevenuePeriod) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch p {
+	case RevenueWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case RevenueMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+// RevenueLine is the income of one period, service or payment method.
+type RevenueLine struct {
+	Label    string    `json:"label"`
+	Start    time.Time `json:"start,omitempty"` // Period lines only
+	Visits   int       `json:"visits"`
+	Amount   float64   `json:"amount"`
+	Discount float64   `json:"discount"`
+}
+
+// RevenueReport breaks down the income between From and To. Visit payments
+// are attributed to the day they were paid; prepaid packages count in full
+// when sold, and the visits they cover count with a zero amount.
+type RevenueReport struct {
+	From      time.Time     `json:"from"`
+	To        time.Time     `json:"to"`
+	Period    RevenuePeriod `json:"period"`
+	Currency  string        `json:"currency"`
+	Total     float64       `json:"total"`
+	Discounts float64       `json:"discounts"`
+	Visits    int           `json:"visits"`
+	ByPeriod  []RevenueLine `json:"by_period"`  // Chronological
+	ByService []RevenueLine `json:"by_service"` // Highest income first
+	ByMethod  []RevenueLine `json:"by_method"`  // Highest income first
+	Packages  RevenueLine   `json:"packages"`   // Packages sold
+}
+
+// BuildRevenueReport aggregates payments and package sales, all assumed to
+// fall between from and to, into a report. Times are bucketed in loc.
+func BuildRevenueReport(payments []Payment, packages []SessionPackage, from, to time.Time, period RevenuePeriod, currency string, loc *time.Location) RevenueReport {
+	report := RevenueReport{From: from, To: to, Period: period, Currency: currency, Packages: RevenueLine{Label: "packages"}}
+	periods := make(map[time.Time]*RevenueLine)
+	services := make(map[string]*RevenueLine)
+	methods := make(map[string]*RevenueLine)
+
+	line := func(lines map[string]*RevenueLine, label string) *RevenueLine {
+		if lines[label] == nil {
+			lines[label] = &RevenueLine{Label: label}
+		}
+		return lines[label]
+	}
+	periodLine := func(t time.Time) *RevenueLine {
+		start := period.Start(t.In(loc))
+		if periods[start] == nil {
+			periods[start] = &RevenueLine{Label: start.Format("2006-01-02"), Start: start}
+		}
+		return periods[start]
+	}
+
+	for _, p := range payments {
+		report.Total += p.Amount
+		report.Discounts += p.Discount
+		report.Visits++
+		service := p.ServiceName
+		if service == "" {
+			service = p.ServiceID
+		}
+		for _, l := range []*RevenueLine{periodLine(p.PaidAt), line(services, service), line(methods, string(p.Method))} {
+			l.Visits++
+			l.Amount += p.Amount
+			l.Discount += p.Discount
+		}
+	}
+	for _, pkg := range packages {
+		report.Total += pkg.Price
+		report.Packages.Visits++
+		report.Packages.Amount += pkg.Price
+		periodLine(pkg.PurchasedAt).Amount += pkg.Price
+	}
+
+	for _, l := range periods {
+		report.ByPeriod = append(report.ByPeriod, *l)
+	}
+	sort.Slice(report.ByPeriod, func(i, j int) bool { return report.ByPeriod[i].Start.Before(report.ByPeriod[j].Start) })
+	report.ByService = sortedLines(services)
+	report.ByMethod = sortedLines(methods)
+	return report
+}
+
+// sortedLines returns lines by descending amount, then label.
+func sortedLines(lines map[string]*RevenueLine) []RevenueLine {
+	out := make([]RevenueLine, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, *l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Amount != out[j].Amount {
+			return out[i].Amount > out[j].Amount
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePaymentMethod(t *testing.T) {
+	tests := map[string]PaymentMethod{
+		"cash": PaymentCash, "Нал": PaymentCash, "карта": PaymentCard, "card": PaymentCard,
+		"перевод": PaymentTransfer, "абонемент": PaymentPackage,
+	}
+	for input, want := range tests {
+		if got, ok := ParsePaymentMethod(input); !ok || got != want {
+			t.Errorf("ParsePaymentMethod(%q) = %q, %v; want %q", input, got, ok, want)
+		}
+	}
+	if _, ok := ParsePaymentMethod("crypto"); ok {
+		t.Error("expected an unknown method to be rejected")
+	}
+	if PaymentCard.Label() != "Карта" || PaymentMethod("crypto").Label() != "crypto" {
+		t.Error("unexpected method labels")
+	}
+}
+
+func TestAppointment_AmountDue(t *testing.T) {
+	appt := Appointment{Service: Service{Price: 2000}, Discount: 200}
+	if got := appt.AmountDue(); got != 1800 {
+		t.Errorf("AmountDue() = %.0f, want 1800", got)
+	}
+	appt.Discount = 2500
+	if got := appt.AmountDue(); got != 0 {
+		t.Errorf("AmountDue() with a discount over the price = %.0f, want 0", got)
+	}
+}
+
+func TestPayment_Validate(t *testing.T) {
+	valid := Payment{AppointmentID: "a1", PatientID: "42", Amount: 1500, Currency: DefaultCurrency, Method: PaymentCash, PaidAt: time.Now()}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	for name, mutate := range map[string]func(*Payment){
+		"no appointment":    func(p *Payment) { p.AppointmentID = "" },
+		"no patient":        func(p *Payment) { p.PatientID = "" },
+		"no currency":       func(p *Payment) { p.Currency = "" },
+		"unknown method":    func(p *Payment) { p.Method = "crypto" },
+		"negative amount":   func(p *Payment) { p.Amount = -1 },
+		"negative discount": func(p *Payment) { p.Discount = -5 },
+		"no date":           func(p *Payment) { p.PaidAt = time.Time{} },
+	} {
+		p := valid
+		mutate(&p)
+		if err := p.Validate(); !errors.Is(err, ErrInvalidPayment) {
+			t.Errorf("%s: Validate() = %v, want ErrInvalidPayment", name, err)
+		}
+	}
+}
+
+func TestRevenuePeriod_Start(t *testing.T) {
+	// Thursday afternoon
+	ts := time.Date(2030, 1, 10, 15, 30, 0, 0, time.UTC)
+	tests := map[RevenuePeriod]time.Time{
+		RevenueDay:   time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+		RevenueWeek:  time.Date(2030, 1, 7, 0, 0, 0, 0, time.UTC),
+		RevenueMonth: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for period, want := range tests {
+		if got := period.Start(ts); !got.Equal(want) {
+			t.Errorf("%s.Start() = %v, want %v", period, got, want)
+		}
+	}
+	// Sunday belongs to the week that started on Monday
+	if got := RevenueWeek.Start(time.Date(2030, 1, 13, 9, 0, 0, 0, time.UTC)); got.Day() != 7 {
+		t.Errorf("week of Sunday 13th starts on %v, want the 7th", got)
+	}
+}
+
+func TestBuildRevenueReport(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)
+	payments := []Payment{
+		{ServiceName: "Massage", Amount: 1500, Method: PaymentCash, PaidAt: time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)},
+		{ServiceName: "Massage", Amount: 1200, Discount: 300, Method: PaymentCard, PaidAt: time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)},
+		{ServiceName: "Consultation", Amount: 500, Method: PaymentCash, PaidAt: time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)},
+		{ServiceName: "Massage", Amount: 0, Method: PaymentPackage, PaidAt: time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)},
+	}
+	packages := []SessionPackage{{Price: 9000, PurchasedAt: time.Date(2030, 1, 3, 9, 0, 0, 0, time.UTC)}}
+
+	r := BuildRevenueReport(payments, packages, from, to, RevenueWeek, DefaultCurrency, time.UTC)
+
+	if r.Total != 12200 || r.Discounts != 300 || r.Visits != 4 {
+		t.Errorf("totals = %.0f / %.0f / %d, want 12200 / 300 / 4", r.Total, r.Discounts, r.Visits)
+	}
+	if r.Packages.Visits != 1 || r.Packages.Amount != 9000 {
+		t.Errorf("packages = %+v", r.Packages)
+	}
+	if len(r.ByPeriod) != 2 || r.ByPeriod[0].Label != "2029-12-31" || r.ByPeriod[0].Amount != 10500 || r.ByPeriod[1].Amount != 1700 {
+		t.Errorf("by period = %+v", r.ByPeriod)
+	}
+	if len(r.ByService) != 2 || r.ByService[0].Label != "Massage" || r.ByService[0].Amount != 2700 || r.ByService[0].Visits != 3 {
+		t.Errorf("by service = %+v", r.ByService)
+	}
+	if len(r.ByMethod) != 3 || r.ByMethod[0].Label != "cash" || r.ByMethod[0].Amount != 2000 {
+		t.Errorf("by method = %+v", r.ByMethod)
+	}
+}
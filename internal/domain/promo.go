@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PromoKind is how a promo code lowers the price.
+type PromoKind string
+
+const (
+	PromoPercent PromoKind = "percent" // Value is a percentage of the price
+	PromoFixed   PromoKind = "fixed"   // Value is an amount off the price
+)
+
+// PromoCode is a discount patients enter while booking.
+type PromoCode struct {
+	Code       string    `db:"code" json:"code"` // Stored upper-case, see NormalizePromoCode
+	Kind       PromoKind `db:"kind" json:"kind"`
+	Value      float64   `db:"value" json:"value"`
+	ServiceIDs []string  `db:"-" json:"service_ids,omitempty"` // empty covers every service
+	ValidFrom  time.Time `db:"valid_from" json:"valid_from"`
+	ValidUntil time.Time `db:"valid_until" json:"valid_until"`
+	MaxUses    int       `db:"max_uses" json:"max_uses"` // 0 means unlimited
+	Uses       int       `db:"uses" json:"uses"`
+	CreatedBy  string    `db:"created_by" json:"created_by"` // Admin Telegram ID
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// NormalizePromoCode returns the form codes are stored and looked up in.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks a promo code before it is created.
+func (p PromoCode) Validate() error {
+	if p.Code == "" || strings.ContainsAny(p.Code, " \t\n") || p.Value <= 0 || p.MaxUses < 0 {
+		return ErrInvalidPromoCode
+	}
+	switch p.Kind {
+	case PromoPercent:
+		if p.Value > 100 {
+			return ErrInvalidPromoCode
+		}
+	case PromoFixed:
+	default:
+		return ErrInvalidPromoCode
+	}
+	if p.ValidFrom.IsZero() || !p.ValidUntil.After(p.ValidFrom) {
+		return ErrInvalidPromoCode
+	}
+	return nil
+}
+
+// Covers reports whether the code may be used for the service.
+func (p PromoCode) Covers(serviceID string) bool {
+	if len(p.ServiceIDs) == 0 {
+		return true
+	}
+	for _, id := range p.ServiceIDs {
+		if id == serviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// Check reports why the code cannot be used for the service at t, if it
+// cannot.
+func (p PromoCode) Check(serviceID string, at time.Time) error {
+	if at.Before(p.ValidFrom) || !at.Before(p.ValidUntil) {
+		return ErrPromoExpired
+	}
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return ErrPromoUsedUp
+	}
+	if !p.Covers(serviceID) {
+		return ErrPromoNotApplicable
+	}
+	return nil
+}
+
+// DiscountFor returns the amount the code takes off price, rounded to whole
+// units and never more than the price itself.
+func (p PromoCode) DiscountFor(price float64) float64 {
+	if price <= 0 {
+		return 0
+	}
+	var discount float64
+	switch p.Kind {
+	case PromoPercent:
+		discount = math.Round(price * p.Value / 100)
+	case PromoFixed:
+		discount = p.Value
+	}
+	return math.Min(discount, price)
+}
+
+// Label returns the discount as shown to admins, e.g. "10%" or "500".
+func (p PromoCode) Label() string {
+	value := strconv.FormatFloat(p.Value, 'f', -1, 64)
+	if p.Kind == PromoPercent {
+		return value + "%"
+	}
+	return value
+}
+
+// PromoUsage records one booking that used a promo code.
+type PromoUsage struct {
+	Code          string    `db:"code" json:"code"`
+	AppointmentID string    `db:"appointment_id" json:"appointment_id"`
+	PatientID     string    `db:"patient_id" json:"patient_id"` // Telegram ID
+	PatientName   string    `db:"patient_name" json:"patient_name"`
+	ServiceName   string    `db:"service_name" json:"service_name"`
+	Discount      float64   `db:"discount" json:"discount"`
+	UsedAt        time.Time `db:"used_at" json:"used_at"`
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizePromoCode(t *testing.T) {
+	if got := NormalizePromoCode("  spring10 "); got != "SPRING10" {
+		t.Errorf("NormalizePromoCode() = %q, want SPRING10", got)
+	}
+}
+
+func TestPromoCode_Validate(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := PromoCode{Code: "SPRING10", Kind: PromoPercent, Value: 10, ValidFrom: from, ValidUntil: from.AddDate(0, 1, 0)}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(p *PromoCode)
+	}{
+		{"empty code", func(p *PromoCode) { p.Code = "" }},
+		{"code with spaces", func(p *PromoCode) { p.Code = "SPRING 10" }},
+		{"zero value", func(p *PromoCode) { p.Value = 0 }},
+		{"percent over 100", func(p *PromoCode) { p.Value = 150 }},
+		{"unknown kind", func(p *PromoCode) { p.Kind = "gift" }},
+		{"negative limit", func(p *PromoCode) { p.MaxUses = -1 }},
+		{"no start", func(p *PromoCode) { p.ValidFrom = time.Time{} }},
+		{"ends before start", func(p *PromoCode) { p.ValidUntil = from.Add(-time.Hour) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			if err := p.Validate(); !errors.Is(err, ErrInvalidPromoCode) {
+				t.Errorf("Validate() = %v, want ErrInvalidPromoCode", err)
+			}
+		})
+	}
+
+	fixed := valid
+	fixed.Kind, fixed.Value = PromoFixed, 500
+	if err := fixed.Validate(); err != nil {
+		t.Errorf("Validate() on a fixed discount = %v, want nil", err)
+	}
+}
+
+func TestPromoCode_Check(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 1, 0)
+	p := PromoCode{Code: "SPRING10", Kind: PromoPercent, Value: 10, ServiceIDs: []string{"massage"}, ValidFrom: from, ValidUntil: until, MaxUses: 2, Uses: 1}
+
+	if err := p.Check("massage", from.Add(time.Hour)); err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+	if err := p.Check("massage", from.Add(-time.Hour)); !errors.Is(err, ErrPromoExpired) {
+		t.Errorf("Check() before the window = %v, want ErrPromoExpired", err)
+	}
+	if err := p.Check("massage", until); !errors.Is(err, ErrPromoExpired) {
+		t.Errorf("Check() at the end of the window = %v, want ErrPromoExpired", err)
+	}
+	if err := p.Check("consult", from.Add(time.Hour)); !errors.Is(err, ErrPromoNotApplicable) {
+		t.Errorf("Check() for another service = %v, want ErrPromoNotApplicable", err)
+	}
+	p.Uses = 2
+	if err := p.Check("massage", from.Add(time.Hour)); !errors.Is(err, ErrPromoUsedUp) {
+		t.Errorf("Check() on a used-up code = %v, want ErrPromoUsedUp", err)
+	}
+	p.MaxUses = 0
+	if err := p.Check("massage", from.Add(time.Hour)); err != nil {
+		t.Errorf("Check() on an unlimited code = %v, want nil", err)
+	}
+}
+
+func TestPromoCode_DiscountFor(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo PromoCode
+		price float64
+		want  float64
+	}{
+		{"percent", PromoCode{Kind: PromoPercent, Value: 10}, 2000, 200},
+		{"percent rounds", PromoCode{Kind: PromoPercent, Value: 15}, 1250, 188},
+		{"fixed", PromoCode{Kind: PromoFixed, Value: 500}, 2000, 500},
+		{"fixed capped at price", PromoCode{Kind: PromoFixed, Value: 500}, 300, 300},
+		{"no price", PromoCode{Kind: PromoFixed, Value: 500}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.promo.DiscountFor(tt.price); got != tt.want {
+				t.Errorf("DiscountFor(%v) = %v, want %v", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromoCode_Label(t *testing.T) {
+	if got := (PromoCode{Kind: PromoPercent, Value: 12.5}).Label(); got != "12.5%" {
+		t.Errorf("Label() = %q, want 12.5%%", got)
+	}
+	if got := (PromoCode{Kind: PromoFixed, Value: 500}).Label(); got != "500" {
+		t.Errorf("Label() = %q, want 500", got)
+	}
+}
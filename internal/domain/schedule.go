@@ -0,0 +1,318 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Schedule rule kinds.
+const (
+	ScheduleKindWork  = "work"  // Recurring working interval
+	ScheduleKindBreak = "break" // Recurring break subtracted from working intervals
+)
+
+// MinuteRange is a half-open [Start, End) interval in minutes after local midnight.
+type MinuteRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Valid reports whether the range is non-empty and inside one day.
+func (r MinuteRange) Valid() bool {
+	return r.Start >= 0 && r.End <= 24*60 && r.Start < r.End
+}
+
+// String formats the range as "09:00-13:00".
+func (r MinuteRange) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", r.Start/60, r.Start%60, r.End/60, r.End%60)
+}
+
+// ParseMinuteRange parses "09:00-13:00" (or "9-13") into a MinuteRange.
+func ParseMinuteRange(s string) (MinuteRange, error) {
+	var r MinuteRange
+	var sh, sm, eh, em int
+	if n, _ := fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em); n == 4 {
+		r = MinuteRange{Start: sh*60 + sm, End: eh*60 + em}
+	} else if n, _ := fmt.Sscanf(s, "%d-%d", &sh, &eh); n == 2 {
+		r = MinuteRange{Start: sh * 60, End: eh * 60}
+	} else {
+		return r, fmt.Errorf("%w: %q", ErrInvalidSchedule, s)
+	}
+	if !r.Valid() || sm >= 60 || em >= 60 {
+		return r, fmt.Errorf("%w: %q", ErrInvalidSchedule, s)
+	}
+	return r, nil
+}
+
+// ScheduleRule is one recurring weekly interval (working time or a break).
+// Rules with an empty TherapistID form the shared schedule.
+type ScheduleRule struct {
+	TherapistID string       `json:"therapist_id,omitempty" db:"therapist_id"`
+	Weekday     time.Weekday `json:"weekday" db:"weekday"`
+	Kind        string       `json:"kind" db:"kind"` // ScheduleKindWork or ScheduleKindBreak
+	StartMinute int          `json:"start_minute" db:"start_minute"`
+	EndMinute   int          `json:"end_minute" db:"end_minute"`
+}
+
+// Range returns the rule's interval.
+func (r ScheduleRule) Range() MinuteRange {
+	return MinuteRange{Start: r.StartMinute, End: r.EndMinute}
+}
+
+// ScheduleException overrides the weekly rules for a single date.
+// Closed days have no hours at all; otherwise Hours replaces the weekday's
+// working intervals, which covers both shortened days and extra working days.
+// Recurring breaks of that weekday still apply. An exception with an empty
+// TherapistID applies to everyone unless a therapist has their own for that date.
+type ScheduleException struct {
+	TherapistID string        `json:"therapist_id,omitempty"`
+	Date        time.Time     `json:"date"` // Calendar date in ApptTimeZone (time part ignored)
+	Closed      bool          `json:"closed"`
+	Hours       []MinuteRange `json:"hours,omitempty"`
+	Note        string        `json:"note,omitempty"`
+}
+
+// WeeklySchedule is the therapist's working calendar.
+type WeeklySchedule struct {
+	Rules      []ScheduleRule      `json:"rules"`
+	Exceptions []ScheduleException `json:"exceptions"`
+}
+
+// DefaultWeeklySchedule is Monday to Friday, WorkDayStartHour to WorkDayEndHour,
+// which is what the bot offered before schedules were configurable.
+func DefaultWeeklySchedule() WeeklySchedule {
+	var rules []ScheduleRule
+	for d := time.Monday; d <= time.Friday; d++ {
+		rules = append(rules, ScheduleRule{
+			Weekday:     d,
+			Kind:        ScheduleKindWork,
+			StartMinute: WorkDayStartHour * 60,
+			EndMinute:   WorkDayEndHour * 60,
+		})
+	}
+	return WeeklySchedule{Rules: rules}
+}
+
+// ForTherapist resolves the schedule one therapist actually works: their own
+// weekly rules if they have any (otherwise the shared ones), and for each date
+// their own exception if set (otherwise the shared one). An empty ID yields
+// the shared schedule.
+func (w WeeklySchedule) ForTherapist(therapistID string) WeeklySchedule {
+	var own, shared []ScheduleRule
+	for _, r := range w.Rules {
+		switch r.TherapistID {
+		case therapistID:
+			own = append(own, r)
+		case "":
+			shared = append(shared, r)
+		}
+	}
+	out := WeeklySchedule{Rules: own}
+	if therapistID != "" && len(own) == 0 {
+		out.Rules = shared
+	}
+
+	ownDates := make(map[string]bool)
+	for _, e := range w.Exceptions {
+		if therapistID != "" && e.TherapistID == therapistID {
+			ownDates[e.Date.In(ApptTimeZone).Format("2006-01-02")] = true
+		}
+	}
+	for _, e := range w.Exceptions {
+		switch {
+		case e.TherapistID == therapistID:
+			out.Exceptions = append(out.Exceptions, e)
+		case e.TherapistID == "" && !ownDates[e.Date.In(ApptTimeZone).Format("2006-01-02")]:
+			out.Exceptions = append(out.Exceptions, e)
+		}
+	}
+	return out
+}
+
+// Exception returns the exception for the given date, if any.
+func (w WeeklySchedule) Exception(date time.Time) (ScheduleException, bool) {
+	key := date.In(ApptTimeZone).Format("2006-01-02")
+	for _, e := range w.Exceptions {
+		if e.Date.In(ApptTimeZone).Format("2006-01-02") == key {
+			return e, true
+		}
+	}
+	return ScheduleException{}, false
+}
+
+// OpenIntervals returns the bookable intervals for the date's calendar day
+// (in ApptTimeZone), sorted and with breaks removed.
+func (w WeeklySchedule) OpenIntervals(date time.Time) []TimeSlot {
+	day := date.In(ApptTimeZone)
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, ApptTimeZone)
+
+	var work, breaks []MinuteRange
+	for _, r := range w.Rules {
+		if r.Weekday != day.Weekday() {
+			continue
+		}
+		switch r.Kind {
+		case ScheduleKindWork:
+			work = append(work, r.Range())
+		case ScheduleKindBreak:
+			breaks = append(breaks, r.Range())
+		}
+	}
+	if exc, ok := w.Exception(day); ok {
+		if exc.Closed {
+			return nil
+		}
+		work = exc.Hours
+	}
+
+	open := subtractRanges(mergeRanges(work), mergeRanges(breaks))
+	slots := make([]TimeSlot, 0, len(open))
+	for _, r := range open {
+		slots = append(slots, TimeSlot{
+			Start: midnight.Add(time.Duration(r.Start) * time.Minute),
+			End:   midnight.Add(time.Duration(r.End) * time.Minute),
+		})
+	}
+	return slots
+}
+
+// Contains reports whether [start, end) fits entirely inside one open interval.
+func (w WeeklySchedule) Contains(start, end time.Time) bool {
+	for _, iv := range w.OpenIntervals(start) {
+		if !start.Before(iv.Start) && !end.After(iv.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that every rule and exception describes a sane interval.
+func (w WeeklySchedule) Validate() error {
+	for _, r := range w.Rules {
+		if r.Weekday < time.Sunday || r.Weekday > time.Saturday || !r.Range().Valid() ||
+			(r.Kind != ScheduleKindWork && r.Kind != ScheduleKindBreak) {
+			return fmt.Errorf("%w: rule %+v", ErrInvalidSchedule, r)
+		}
+	}
+	for _, e := range w.Exceptions {
+		if err := e.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks a single exception.
+func (e ScheduleException) Validate() error {
+	if e.Date.IsZero() {
+		return fmt.Errorf("%w: exception without date", ErrInvalidSchedule)
+	}
+	if !e.Closed && len(e.Hours) == 0 {
+		return fmt.Errorf("%w: exception %s has no hours", ErrInvalidSchedule, e.Date.Format("2006-01-02"))
+	}
+	for _, r := range e.Hours {
+		if !r.Valid() {
+			return fmt.Errorf("%w: exception range %s", ErrInvalidSchedule, r)
+		}
+	}
+	return nil
+}
+
+// SlotPolicy controls how the slot engine lays out candidate start times.
+type SlotPolicy struct {
+	StepMinutes         int  `json:"step_minutes"`          // Grid step between candidate starts: 15, 30 or 60
+	BufferBeforeMinutes int  `json:"buffer_before_minutes"` // Preparation time kept free before each appointment
+	BufferAfterMinutes  int  `json:"buffer_after_minutes"`  // Cleanup time kept free after each appointment
+	PackToBookings      bool `json:"pack_to_bookings"`      // Also offer starts right after existing bookings
+}
+
+// MaxSlotBufferMinutes caps each buffer so a typo cannot close the whole day.
+const MaxSlotBufferMinutes = 120
+
+// DefaultSlotPolicy is hourly slots without buffers, the original behaviour.
+func DefaultSlotPolicy() SlotPolicy {
+	return SlotPolicy{StepMinutes: 60}
+}
+
+// Validate checks the step is one of 15/30/60 and buffers are sane.
+func (p SlotPolicy) Validate() error {
+	switch p.StepMinutes {
+	case 15, 30, 60:
+	default:
+		return fmt.Errorf("%w: slot step %d (allowed: 15, 30, 60)", ErrInvalidSchedule, p.StepMinutes)
+	}
+	if p.BufferBeforeMinutes < 0 || p.BufferBeforeMinutes > MaxSlotBufferMinutes ||
+		p.BufferAfterMinutes < 0 || p.BufferAfterMinutes > MaxSlotBufferMinutes {
+		return fmt.Errorf("%w: slot buffers %d/%d", ErrInvalidSchedule, p.BufferBeforeMinutes, p.BufferAfterMinutes)
+	}
+	return nil
+}
+
+// Gap is the free time required between two appointments: the cleanup after
+// the earlier one plus the preparation before the later one.
+func (p SlotPolicy) Gap() time.Duration {
+	return time.Duration(p.BufferBeforeMinutes+p.BufferAfterMinutes) * time.Minute
+}
+
+// Conflicts reports whether [start, end) is too close to any busy interval
+// once buffers are taken into account. Busy intervals are assumed to be
+// appointments with the same buffers.
+func (p SlotPolicy) Conflicts(start, end time.Time, busy []TimeSlot) bool {
+	gap := p.Gap()
+	for _, b := range busy {
+		if start.Before(b.End.Add(gap)) && end.After(b.Start.Add(-gap)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeRanges sorts ranges and joins overlapping or touching ones.
+func mergeRanges(in []MinuteRange) []MinuteRange {
+	if len(in) == 0 {
+		return nil
+	}
+	rs := make([]MinuteRange, 0, len(in))
+	for _, r := range in {
+		if r.Valid() {
+			rs = append(rs, r)
+		}
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Start < rs[j].Start })
+	var out []MinuteRange
+	for _, r := range rs {
+		if n := len(out); n > 0 && r.Start <= out[n-1].End {
+			if r.End > out[n-1].End {
+				out[n-1].End = r.End
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// subtractRanges removes every range in cut from base. Both must be merged.
+func subtractRanges(base, cut []MinuteRange) []MinuteRange {
+	var out []MinuteRange
+	for _, b := range base {
+		cur := b
+		for _, c := range cut {
+			if c.End <= cur.Start || c.Start >= cur.End {
+				continue
+			}
+			if c.Start > cur.Start {
+				out = append(out, MinuteRange{Start: cur.Start, End: c.Start})
+			}
+			cur.Start = c.End
+			if cur.Start >= cur.End {
+				break
+			}
+		}
+		if cur.Start < cur.End {
+			out = append(out, cur)
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseMinuteRange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    MinuteRange
+		wantErr bool
+	}{
+		{"09:00-13:00", MinuteRange{Start: 540, End: 780}, false},
+		{"9-18", MinuteRange{Start: 540, End: 1080}, false},
+		{"13:30-14:15", MinuteRange{Start: 810, End: 855}, false},
+		{"00:00-24:00", MinuteRange{Start: 0, End: 1440}, false},
+		{"18:00-09:00", MinuteRange{}, true},
+		{"09:75-10:00", MinuteRange{}, true},
+		{"10:00", MinuteRange{}, true},
+		{"завтра", MinuteRange{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMinuteRange(tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidSchedule) {
+				t.Errorf("ParseMinuteRange(%q): expected ErrInvalidSchedule, got %v", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMinuteRange(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	if s := (MinuteRange{Start: 540, End: 810}).String(); s != "09:00-13:30" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestWeeklySchedule_OpenIntervals(t *testing.T) {
+	ApptTimeZone = time.UTC
+	wed := time.Date(2030, 1, 9, 15, 0, 0, 0, time.UTC) // time part is ignored
+	base := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	at := func(min int) time.Time { return base.Add(time.Duration(min) * time.Minute) }
+
+	schedule := WeeklySchedule{Rules: []ScheduleRule{
+		{Weekday: time.Wednesday, Kind: ScheduleKindWork, StartMinute: 540, EndMinute: 780},
+		{Weekday: time.Wednesday, Kind: ScheduleKindWork, StartMinute: 720, EndMinute: 1080}, // overlaps, merged
+		{Weekday: time.Wednesday, Kind: ScheduleKindBreak, StartMinute: 780, EndMinute: 840},
+		{Weekday: time.Thursday, Kind: ScheduleKindWork, StartMinute: 600, EndMinute: 700},
+	}}
+
+	got := schedule.OpenIntervals(wed)
+	want := []TimeSlot{{Start: at(540), End: at(780)}, {Start: at(840), End: at(1080)}}
+	if len(got) != len(want) {
+		t.Fatalf("OpenIntervals = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if !got[i].Start.Equal(want[i].Start) || !got[i].End.Equal(want[i].End) {
+			t.Errorf("interval %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if !schedule.Contains(at(540), at(600)) {
+		t.Error("09:00-10:00 should be inside working hours")
+	}
+	if schedule.Contains(at(750), at(810)) {
+		t.Error("12:30-13:30 overlaps the break")
+	}
+	if schedule.Contains(at(1050), at(1110)) {
+		t.Error("17:30-18:30 runs past closing")
+	}
+
+	sunday := base.AddDate(0, 0, 4)
+	if len(schedule.OpenIntervals(sunday)) != 0 {
+		t.Error("Sunday has no rules and should be closed")
+	}
+}
+
+func TestWeeklySchedule_Exceptions(t *testing.T) {
+	ApptTimeZone = time.UTC
+	wed := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	schedule := DefaultWeeklySchedule()
+	schedule.Rules = append(schedule.Rules, ScheduleRule{Weekday: time.Wednesday, Kind: ScheduleKindBreak, StartMinute: 780, EndMinute: 840})
+
+	schedule.Exceptions = []ScheduleException{{Date: wed, Closed: true}}
+	if len(schedule.OpenIntervals(wed.Add(10*time.Hour))) != 0 {
+		t.Error("closed exception should remove all hours")
+	}
+
+	schedule.Exceptions = []ScheduleException{{Date: wed, Hours: []MinuteRange{{Start: 720, End: 900}}}}
+	got := schedule.OpenIntervals(wed)
+	if len(got) != 2 || got[0].End.Hour() != 13 || got[1].Start.Hour() != 14 {
+		t.Errorf("shortened day should keep the weekday break, got %+v", got)
+	}
+
+	sat := wed.AddDate(0, 0, 3)
+	schedule.Exceptions = []ScheduleException{{Date: sat, Hours: []MinuteRange{{Start: 600, End: 840}}}}
+	if got := schedule.OpenIntervals(sat); len(got) != 1 || got[0].Start.Hour() != 10 {
+		t.Errorf("extra working day should open Saturday, got %+v", got)
+	}
+}
+
+func TestWeeklySchedule_ForTherapist(t *testing.T) {
+	ApptTimeZone = time.UTC
+	wed := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	thu := wed.AddDate(0, 0, 1)
+	schedule := DefaultWeeklySchedule()
+	schedule.Rules = append(schedule.Rules, ScheduleRule{TherapistID: "anna", Weekday: time.Wednesday, Kind: ScheduleKindWork, StartMinute: 14 * 60, EndMinute: 20 * 60})
+	schedule.Exceptions = []ScheduleException{
+		{Date: wed, Closed: true},
+		{TherapistID: "anna", Date: wed, Hours: []MinuteRange{{Start: 15 * 60, End: 17 * 60}}},
+	}
+
+	if got := schedule.ForTherapist("").OpenIntervals(wed); len(got) != 0 {
+		t.Errorf("shared schedule should keep the shared holiday, got %+v", got)
+	}
+	if got := schedule.ForTherapist("boris").OpenIntervals(wed); len(got) != 0 {
+		t.Errorf("therapist without own rules should follow the shared schedule, got %+v", got)
+	}
+	if got := schedule.ForTherapist("boris").OpenIntervals(thu); len(got) != 1 || got[0].Start.Hour() != 9 {
+		t.Errorf("therapist without own rules should get shared hours, got %+v", got)
+	}
+	if got := schedule.ForTherapist("anna").OpenIntervals(wed); len(got) != 1 || got[0].Start.Hour() != 15 {
+		t.Errorf("own exception should override the shared one, got %+v", got)
+	}
+	if got := schedule.ForTherapist("anna").OpenIntervals(thu); len(got) != 0 {
+		t.Errorf("own rules replace the shared week entirely, got %+v", got)
+	}
+}
+
+func TestWeeklySchedule_Validate(t *testing.T) {
+	if err := DefaultWeeklySchedule().Validate(); err != nil {
+		t.Errorf("default schedule should be valid: %v", err)
+	}
+
+	bad := []WeeklySchedule{
+		{Rules: []ScheduleRule{{Weekday: 7, Kind: ScheduleKindWork, StartMinute: 0, EndMinute: 60}}},
+		{Rules: []ScheduleRule{{Weekday: time.Monday, Kind: "lunch", StartMinute: 0, EndMinute: 60}}},
+		{Rules: []ScheduleRule{{Weekday: time.Monday, Kind: ScheduleKindWork, StartMinute: 600, EndMinute: 600}}},
+		{Exceptions: []ScheduleException{{Closed: true}}},
+		{Exceptions: []ScheduleException{{Date: time.Now()}}},
+		{Exceptions: []ScheduleException{{Date: time.Now(), Hours: []MinuteRange{{Start: 60, End: 2000}}}}},
+	}
+	for i, s := range bad {
+		if err := s.Validate(); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("case %d: expected ErrInvalidSchedule, got %v", i, err)
+		}
+	}
+}
+
+func TestSlotPolicy_Conflicts(t *testing.T) {
+	base := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	busy := []TimeSlot{{Start: base, End: base.Add(time.Hour)}}
+	p := SlotPolicy{StepMinutes: 15, BufferBeforeMinutes: 5, BufferAfterMinutes: 10}
+
+	if p.Gap() != 15*time.Minute {
+		t.Errorf("Gap() = %s, want 15m", p.Gap())
+	}
+	tests := []struct {
+		start, end time.Time
+		want       bool
+	}{
+		{base.Add(time.Hour), base.Add(2 * time.Hour), true},              // directly after
+		{base.Add(75 * time.Minute), base.Add(135 * time.Minute), false},  // after the gap
+		{base.Add(-time.Hour), base, true},                                // directly before
+		{base.Add(-75 * time.Minute), base.Add(-15 * time.Minute), false}, // before the gap
+		{base.Add(30 * time.Minute), base.Add(90 * time.Minute), true},    // overlapping
+		{base.Add(-2 * time.Hour), base.Add(-time.Hour), false},           // far away
+	}
+	for i, tt := range tests {
+		if got := p.Conflicts(tt.start, tt.end, busy); got != tt.want {
+			t.Errorf("case %d: Conflicts = %v, want %v", i, got, tt.want)
+		}
+	}
+	if (SlotPolicy{}).Conflicts(base.Add(time.Hour), base.Add(2*time.Hour), busy) {
+		t.Error("back-to-back booking without buffers should not conflict")
+	}
+}
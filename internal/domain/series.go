@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxSeriesWeeks caps how far ahead a recurring series may be booked.
+const MaxSeriesWeeks = 26
+
+// AppointmentSeries is a set of appointments booked together: the same
+// service at the same time of day on the chosen weekdays, for Weeks weeks
+// starting at FirstStart. Rehabilitation programmes are sold
+// this way.
+type AppointmentSeries struct {
+	ID           int64          `json:"id"`
+	Service      Service        `json:"service"`
+	CustomerName string         `json:"customer_name"`
+	CustomerTgID string         `json:"customer_tg_id"`
+	TherapistID  string         `json:"therapist_id,omitempty"`
+	FirstStart   time.Time      `json:"first_start"` // first day considered, at the series' time of day
+	Weekdays     []time.Weekday `json:"weekdays"`
+	Weeks        int            `json:"weeks"`
+	CreatedAt    time.Time      `json:"created_at"`
+
+	// Appointments are the booked occurrences in start order. Storage only
+	// keeps their IDs and start times.
+	Appointments []Appointment `json:"appointments,omitempty"`
+}
+
+// Occurrences returns every start time of the series in order. The series
+// covers Weeks*7 days from FirstStart, so each chosen weekday occurs exactly
+// Weeks times whatever weekday FirstStart falls on.
+func (s AppointmentSeries) Occurrences() []time.Time {
+	loc := ApptTimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+	first := s.FirstStart.In(loc)
+	days := make(map[time.Weekday]bool, len(s.Weekdays))
+	for _, d := range s.Weekdays {
+		days[d] = true
+	}
+
+	var out []time.Time
+	for i := 0; i < s.Weeks*7; i++ {
+		day := time.Date(first.Year(), first.Month(), first.Day()+i, first.Hour(), first.Minute(), 0, 0, loc)
+		if days[day.Weekday()] {
+			out = append(out, day)
+		}
+	}
+	return out
+}
+
+// Validate checks the series can be expanded into bookings.
+func (s AppointmentSeries) Validate() error {
+	if s.Service.ID == "" || s.Service.DurationMinutes <= 0 || s.CustomerName == "" || s.FirstStart.IsZero() {
+		return ErrInvalidSeries
+	}
+	if len(s.Weekdays) == 0 || s.Weeks < 1 || s.Weeks > MaxSeriesWeeks {
+		return ErrInvalidSeries
+	}
+	for _, d := range s.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return ErrInvalidSeries
+		}
+	}
+	return nil
+}
+
+// SeriesConflict is one occurrence of a series that cannot be booked.
+type SeriesConflict struct {
+	Start time.Time
+	Err   error // ErrSlotUnavailable, ErrOutsideWorkingHours or ErrAppointmentInPast
+}
+
+// SeriesConflictError reports every clashing occurrence of a series. Nothing
+// is booked when it is returned. errors.Is(err, ErrSeriesConflict) matches it.
+type SeriesConflictError struct {
+	Conflicts []SeriesConflict
+	Total     int // number of occurrences in the series
+}
+
+func (e *SeriesConflictError) Error() string {
+	return fmt.Sprintf("%d of %d series occurrences conflict", len(e.Conflicts), e.Total)
+}
+
+func (e *SeriesConflictError) Is(target error) bool {
+	return target == ErrSeriesConflict
+}
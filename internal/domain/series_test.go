@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAppointmentSeries_Occurrences(t *testing.T) {
+	ApptTimeZone = time.UTC
+	// Thursday 2030-01-10 at 18:30, Mon/Thu for 2 weeks
+	series := AppointmentSeries{
+		FirstStart: time.Date(2030, 1, 10, 18, 30, 0, 0, time.UTC),
+		Weekdays:   []time.Weekday{time.Monday, time.Thursday},
+		Weeks:      2,
+	}
+
+	got := series.Occurrences()
+	want := []string{"2030-01-10 18:30", "2030-01-14 18:30", "2030-01-17 18:30", "2030-01-21 18:30"}
+	if len(got) != len(want) {
+		t.Fatalf("Occurrences() = %v, want %d items", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Format("2006-01-02 15:04") != w {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i].Format("2006-01-02 15:04"), w)
+		}
+	}
+}
+
+func TestAppointmentSeries_Validate(t *testing.T) {
+	valid := AppointmentSeries{
+		Service:      Service{ID: "rehab", DurationMinutes: 60},
+		CustomerName: "Иван",
+		FirstStart:   time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC),
+		Weekdays:     []time.Weekday{time.Monday},
+		Weeks:        6,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v, want nil", err)
+	}
+
+	tests := map[string]func(s *AppointmentSeries){
+		"no service":    func(s *AppointmentSeries) { s.Service.ID = "" },
+		"no name":       func(s *AppointmentSeries) { s.CustomerName = "" },
+		"no weekdays":   func(s *AppointmentSeries) { s.Weekdays = nil },
+		"zero weeks":    func(s *AppointmentSeries) { s.Weeks = 0 },
+		"too many":      func(s *AppointmentSeries) { s.Weeks = MaxSeriesWeeks + 1 },
+		"bad weekday":   func(s *AppointmentSeries) { s.Weekdays = []time.Weekday{9} },
+		"zero duration": func(s *AppointmentSeries) { s.Service.DurationMinutes = 0 },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := valid
+			mutate(&s)
+			if err := s.Validate(); !errors.Is(err, ErrInvalidSeries) {
+				t.Errorf("Validate() = %v, want ErrInvalidSeries", err)
+			}
+		})
+	}
+}
+
+func TestSeriesConflictError(t *testing.T) {
+	err := error(&SeriesConflictError{Conflicts: []SeriesConflict{{Err: ErrSlotUnavailable}}, Total: 12})
+	if !errors.Is(err, ErrSeriesConflict) {
+		t.Error("expected errors.Is(err, ErrSeriesConflict)")
+	}
+	if err.Error() != "1 of 12 series occurrences conflict" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
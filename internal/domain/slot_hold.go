@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SlotHold reserves a slot for a patient while they finish the booking
+// conversation (name, confirmation). Until ExpiresAt the slot is hidden from
+// everyone else and cannot be booked by them. An empty TherapistID is the
+// default calendar.
+type SlotHold struct {
+	HolderID    string    `db:"holder_id" json:"holder_id"` // Telegram ID of whoever is booking
+	TherapistID string    `db:"therapist_id" json:"therapist_id,omitempty"`
+	Start       time.Time `db:"slot_start" json:"slot_start"`
+	End         time.Time `db:"slot_end" json:"slot_end"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+}
+
+type slotHolderKey struct{}
+
+// WithSlotHolder marks ctx as acting for holderID, so the holder's own hold
+// is neither hidden from their slot listings nor a conflict for their booking.
+func WithSlotHolder(ctx context.Context, holderID string) context.Context {
+	return context.WithValue(ctx, slotHolderKey{}, holderID)
+}
+
+// SlotHolderFrom returns the holder set by WithSlotHolder, or "".
+func SlotHolderFrom(ctx context.Context) string {
+	holderID, _ := ctx.Value(slotHolderKey{}).(string)
+	return holderID
+}
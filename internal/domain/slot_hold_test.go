@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSlotHolderContext(t *testing.T) {
+	if got := SlotHolderFrom(context.Background()); got != "" {
+		t.Errorf("expected no holder on a bare context, got %q", got)
+	}
+	ctx := WithSlotHolder(context.Background(), "100")
+	if got := SlotHolderFrom(ctx); got != "100" {
+		t.Errorf("SlotHolderFrom = %q, want 100", got)
+	}
+}
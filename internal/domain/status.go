@@ -0,0 +1,82 @@
+package domain
+
+// AppointmentStatus is where an appointment is in its lifecycle. It is kept
+// by the bot, independently of the calendar event status (Appointment.Status):
+//
+//	booked → confirmed → completed / no_show
+//	booked, confirmed → cancelled_by_patient / cancelled_by_admin / late_cancel
+//
+// An appointment with nothing recorded is booked.
+type AppointmentStatus string
+
+const (
+	StatusBooked             AppointmentStatus = "booked"
+	StatusConfirmed          AppointmentStatus = "confirmed"
+	StatusCompleted          AppointmentStatus = "completed"
+	StatusNoShow             AppointmentStatus = "no_show"
+	StatusCancelledByPatient AppointmentStatus = "cancelled_by_patient"
+	StatusCancelledByAdmin   AppointmentStatus = "cancelled_by_admin"
+	StatusLateCancel         AppointmentStatus = "late_cancel"
+)
+
+// statusTransitions lists the allowed moves. A rescheduled appointment goes
+// back from confirmed to booked; completed and no_show may be swapped to
+// correct a mis-click.
+var statusTransitions = map[AppointmentStatus][]AppointmentStatus{
+	StatusBooked:    {StatusConfirmed, StatusCompleted, StatusNoShow, StatusCancelledByPatient, StatusCancelledByAdmin, StatusLateCancel},
+	StatusConfirmed: {StatusBooked, StatusCompleted, StatusNoShow, StatusCancelledByPatient, StatusCancelledByAdmin, StatusLateCancel},
+	StatusCompleted: {StatusNoShow},
+	StatusNoShow:    {StatusCompleted},
+}
+
+// Valid reports whether s is a known status.
+func (s AppointmentStatus) Valid() bool {
+	switch s {
+	case StatusBooked, StatusConfirmed, StatusCompleted, StatusNoShow,
+		StatusCancelledByPatient, StatusCancelledByAdmin, StatusLateCancel:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an appointment in status s may move to next.
+func (s AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOutcome reports whether s records how a held visit went; those can only
+// be set once the appointment has started.
+func (s AppointmentStatus) IsOutcome() bool {
+	return s == StatusCompleted || s == StatusNoShow
+}
+
+// IsCancelled reports whether s is one of the cancellation statuses.
+func (s AppointmentStatus) IsCancelled() bool {
+	return s == StatusCancelledByPatient || s == StatusCancelledByAdmin || s == StatusLateCancel
+}
+
+// Label returns the Russian name of the status shown to users.
+func (s AppointmentStatus) Label() string {
+	switch s {
+	case StatusBooked:
+		return "Записан"
+	case StatusConfirmed:
+		return "Подтверждён"
+	case StatusCompleted:
+		return "Состоялся"
+	case StatusNoShow:
+		return "Неявка"
+	case StatusCancelledByPatient:
+		return "Отменён пациентом"
+	case StatusCancelledByAdmin:
+		return "Отменён администратором"
+	case StatusLateCancel:
+		return "Поздняя отмена"
+	}
+	return string(s)
+}
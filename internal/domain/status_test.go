@@ -0,0 +1,45 @@
+package domain
+
+import "testing"
+
+func TestAppointmentStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to AppointmentStatus
+		want     bool
+	}{
+		{StatusBooked, StatusConfirmed, true},
+		{StatusBooked, StatusNoShow, true},
+		{StatusConfirmed, StatusCompleted, true},
+		{StatusConfirmed, StatusBooked, true},
+		{StatusConfirmed, StatusLateCancel, true},
+		{StatusCompleted, StatusNoShow, true},
+		{StatusNoShow, StatusCompleted, true},
+		{StatusCompleted, StatusConfirmed, false},
+		{StatusCompleted, StatusCancelledByAdmin, false},
+		{StatusCancelledByPatient, StatusBooked, false},
+		{StatusCancelledByAdmin, StatusCompleted, false},
+		{StatusLateCancel, StatusNoShow, false},
+		{StatusBooked, StatusBooked, false},
+		{StatusBooked, AppointmentStatus("lost"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s → %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAppointmentStatus_Kinds(t *testing.T) {
+	if !StatusNoShow.IsOutcome() || StatusLateCancel.IsOutcome() {
+		t.Error("only completed and no_show are outcomes")
+	}
+	if !StatusLateCancel.IsCancelled() || StatusNoShow.IsCancelled() {
+		t.Error("late_cancel is a cancellation, no_show is not")
+	}
+	if !StatusCancelledByAdmin.Valid() || AppointmentStatus("lost").Valid() {
+		t.Error("unexpected Valid() result")
+	}
+	if StatusNoShow.Label() != "Неявка" || AppointmentStatus("lost").Label() != "lost" {
+		t.Error("unexpected Label() result")
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import "time"
+
+// Waitlist entry states. An entry is "offered" while one of its offers is
+// pending and goes back to "waiting" if the patient lets it lapse or declines.
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistOffered   = "offered"
+	WaitlistBooked    = "booked"
+	WaitlistCancelled = "cancelled"
+)
+
+// Waitlist offer states.
+const (
+	OfferPending  = "pending"
+	OfferAccepted = "accepted"
+	OfferDeclined = "declined"
+	OfferExpired  = "expired"
+)
+
+// MaxWaitlistDays caps how wide a single waitlist request may be.
+const MaxWaitlistDays = 14
+
+// WaitlistEntry is a patient waiting for a slot of a service on any day
+// between DateFrom and DateTo (calendar dates in ApptTimeZone, inclusive).
+// An empty TherapistID accepts any therapist.
+type WaitlistEntry struct {
+	ID              int64     `db:"id" json:"id"`
+	PatientID       string    `db:"patient_id" json:"patient_id"` // Telegram ID
+	PatientName     string    `db:"patient_name" json:"patient_name"`
+	ServiceID       string    `db:"service_id" json:"service_id"`
+	ServiceName     string    `db:"service_name" json:"service_name"`
+	DurationMinutes int       `db:"duration_minutes" json:"duration_minutes"`
+	TherapistID     string    `db:"therapist_id" json:"therapist_id,omitempty"`
+	DateFrom        time.Time `db:"date_from" json:"date_from"`
+	DateTo          time.Time `db:"date_to" json:"date_to"`
+	Status          string    `db:"status" json:"status"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+}
+
+// Covers reports whether t falls on one of the entry's dates.
+func (e WaitlistEntry) Covers(t time.Time) bool {
+	day := t.In(ApptTimeZone).Format("2006-01-02")
+	return day >= e.DateFrom.Format("2006-01-02") && day <= e.DateTo.Format("2006-01-02")
+}
+
+// WaitlistOffer is a freed slot held for one waitlisted patient until
+// ExpiresAt. The slot is booked only when the patient accepts.
+type WaitlistOffer struct {
+	ID          int64     `db:"id" json:"id"`
+	EntryID     int64     `db:"entry_id" json:"entry_id"`
+	PatientID   string    `db:"patient_id" json:"patient_id"`
+	TherapistID string    `db:"therapist_id" json:"therapist_id,omitempty"`
+	SlotStart   time.Time `db:"slot_start" json:"slot_start"`
+	SlotEnd     time.Time `db:"slot_end" json:"slot_end"`
+	Status      string    `db:"status" json:"status"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitlistEntry_Covers(t *testing.T) {
+	ApptTimeZone = time.UTC
+	entry := WaitlistEntry{
+		DateFrom: time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC),
+		DateTo:   time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{time.Date(2030, 1, 8, 23, 59, 0, 0, time.UTC), false},
+		{time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC), true},
+		{time.Date(2030, 1, 11, 18, 0, 0, 0, time.UTC), true},
+		{time.Date(2030, 1, 12, 9, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		if got := entry.Covers(tt.at); got != tt.want {
+			t.Errorf("Covers(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
@@ -25,6 +25,14 @@ var (
 		},
 	)
 
+	FreeBusyDayCacheLookups = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "vera_freebusy_day_cache_lookups_total",
+			Help: "FreeBusy cache lookups per calendar day, by how far ahead the day is and hit or miss",
+		},
+		[]string{"bucket", "result"},
+	)
+
 	ApiRequestsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "vera_api_requests_total",
@@ -115,6 +123,29 @@ var (
 		[]string{"service_name"},
 	)
 
+	RevenueTotal = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vera_revenue",
+			Help: "Income of the current day, week or month by service, in the clinic currency",
+		},
+		[]string{"period", "service"},
+	)
+
+	RevenueByMethod = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "vera_revenue_by_method",
+			Help: "Income of the current day, week or month by payment method",
+		},
+		[]string{"period", "method"},
+	)
+
+	OutstandingBalance = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "vera_outstanding_balance",
+			Help: "Total price of recent visits that are not paid yet",
+		},
+	)
+
 	// --- System Status ---
 
 	ActiveSessions = promauto.NewGauge(
@@ -16,6 +16,7 @@ func TestMetricsRegistration(t *testing.T) {
 	}{
 		{"FreeBusyCacheHits", FreeBusyCacheHits},
 		{"FreeBusyCacheMisses", FreeBusyCacheMisses},
+		{"FreeBusyDayCacheLookups", FreeBusyDayCacheLookups},
 		{"ApiRequestsTotal", ApiRequestsTotal},
 		{"ApiLatency", ApiLatency},
 		{"DbErrorsTotal", DbErrorsTotal},
@@ -27,6 +28,9 @@ func TestMetricsRegistration(t *testing.T) {
 		{"BookingCreationHour", BookingCreationHour},
 		{"ServiceBookingsTotal", ServiceBookingsTotal},
 		{"CancellationsTotal", CancellationsTotal},
+		{"RevenueTotal", RevenueTotal},
+		{"RevenueByMethod", RevenueByMethod},
+		{"OutstandingBalance", OutstandingBalance},
 		{"ActiveSessions", ActiveSessions},
 		{"TokenExpiryDays", TokenExpiryDays},
 	}
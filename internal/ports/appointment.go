@@ -10,12 +10,64 @@ import (
 // AppointmentService defines the interface for managing appointments (business logic layer).
 type AppointmentService interface {
 	GetAvailableServices(ctx context.Context) ([]domain.Service, error)
+	GetAllServices(ctx context.Context) ([]domain.Service, error)
+	SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error)
+	SetServiceArchived(ctx context.Context, id string, archived bool) error
+	ReorderServices(ctx context.Context, orderedIDs []string) error
+	GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error)
+	SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error
+	GetSchedule(ctx context.Context) (domain.WeeklySchedule, error)
+	GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error)
+	SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error
+	SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error
+	DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error
+	GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error)
+	SaveTherapist(ctx context.Context, t domain.Therapist) error
+	GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, durationMinutes int) ([]domain.TimeSlot, error)
 	GetAvailableTimeSlots(ctx context.Context, date time.Time, durationMinutes int) ([]domain.TimeSlot, error)
+	// HoldSlot keeps a picked slot from other patients while holderID
+	// finishes booking it; domain.ErrSlotUnavailable means it is taken.
+	// Listings and bookings made with domain.WithSlotHolder ignore the
+	// holder's own hold.
+	HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error
+	ReleaseSlotHold(ctx context.Context, holderID string) error
+	// FindNextAvailable returns up to limit of the earliest free slots for
+	// the service from from on, looking at most horizon ahead.
+	FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error)
+	// GetMonthAvailability tells, for each day of month, whether a service
+	// of durationMinutes is free, limited, full or closed. An empty
+	// therapistID means "any available".
+	GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error)
 	CreateAppointment(ctx context.Context, appointment *domain.Appointment) (*domain.Appointment, error)
 	CancelAppointment(ctx context.Context, appointmentID string) error
+	// RescheduleAppointment moves a booking to newStart in place, validating
+	// the new slot like CreateAppointment does.
+	RescheduleAppointment(ctx context.Context, appointmentID string, newStart time.Time) (*domain.Appointment, error)
+	// BookSeries books every occurrence of a recurring series or none of
+	// them; clashes are reported as a *domain.SeriesConflictError.
+	BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error)
+	// GetAppointmentSeries returns the series an appointment belongs to, or
+	// domain.ErrSeriesNotFound.
+	GetAppointmentSeries(ctx context.Context, appointmentID string) (*domain.AppointmentSeries, error)
+	// CancelSeries cancels the appointment and every later occurrence of its
+	// series, returning what was cancelled.
+	CancelSeries(ctx context.Context, appointmentID string) ([]domain.Appointment, error)
+	// GetAppointmentStatus returns the lifecycle status of an appointment;
+	// appointments with nothing recorded are booked.
+	GetAppointmentStatus(ctx context.Context, appointmentID string) (domain.AppointmentStatus, error)
+	// SetAppointmentStatus moves an appointment along its lifecycle and
+	// returns domain.ErrInvalidStatusChange for moves that are not allowed.
+	SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error
+	GetPatientStatusCounts(ctx context.Context, customerTgID string) (map[domain.AppointmentStatus]int, error)
+	// GetCancellationPolicy returns the notice window for patients' own
+	// cancellations and what happens inside it.
+	GetCancellationPolicy() domain.CancellationPolicy
 	GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error)
 	GetCustomerAppointments(ctx context.Context, customerTgID string) ([]domain.Appointment, error)
 	GetCustomerHistory(ctx context.Context, customerTgID string) ([]domain.Appointment, error)
+	// HistorySynced reports whether the appointments table is kept in step
+	// with the calendars, so history needs no calendar reads.
+	HistorySynced() bool
 	GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error)
 	FindByID(ctx context.Context, appointmentID string) (*domain.Appointment, error)
 	GetTotalUpcomingCount(ctx context.Context) (int, error)
@@ -32,12 +84,32 @@ type AppointmentRepository interface {
 	FindEvents(ctx context.Context, timeMin, timeMax *time.Time) ([]domain.Appointment, error)
 	FindByID(ctx context.Context, id string) (*domain.Appointment, error)
 	Delete(ctx context.Context, id string) error
+	// Update moves an existing event to appt.StartTime/appt.EndTime, keeping its ID.
+	Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error)
 	GetAccountInfo(ctx context.Context) (string, error)
 	GetCalendarID() string
 	ListCalendars(ctx context.Context) ([]string, error)
 	GetFreeBusy(ctx context.Context, timeMin, timeMax time.Time) ([]domain.TimeSlot, error)
 }
 
+// EventStatusWriter is implemented by calendars that keep the lifecycle
+// status on their events, so it can be kept current after status changes.
+type EventStatusWriter interface {
+	SetEventStatus(ctx context.Context, id string, status domain.AppointmentStatus) error
+}
+
+// CalendarFactory builds an AppointmentRepository for a specific calendar ID.
+// It lets the service give every therapist their own calendar.
+type CalendarFactory func(calendarID string) AppointmentRepository
+
+// CalendarSource is one distinct calendar appointments are read from.
+// TherapistID is set when exactly one therapist uses the calendar, so its
+// events can be attributed without extra metadata.
+type CalendarSource struct {
+	Repo        AppointmentRepository
+	TherapistID string
+}
+
 // SessionStorage defines the interface for managing user sessions (e.g., in-memory or Redis).
 type SessionStorage interface {
 	Set(userID int64, key string, value interface{})
@@ -0,0 +1,48 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// CalendarWatcher is implemented by calendar repositories that can push
+// change notifications (Google Calendar). Other repositories are not
+// watched.
+type CalendarWatcher interface {
+	// Watch opens channel on the calendar for ttl; the calendar may cap it.
+	Watch(ctx context.Context, channel domain.WatchChannel, ttl time.Duration) (*domain.WatchChannel, error)
+	StopWatch(ctx context.Context, channel domain.WatchChannel) error
+	// SyncChanges returns the events changed since syncToken was issued and
+	// the token for the next call. An empty syncToken lists every event.
+	// Deleted events come back with status "cancelled" and possibly without
+	// times. An expired token gives domain.ErrSyncTokenExpired.
+	SyncChanges(ctx context.Context, syncToken string) ([]domain.Appointment, string, error)
+}
+
+// CalendarSyncRepository is the local copy of the calendars' appointments.
+type CalendarSyncRepository interface {
+	// GetAppointmentsByID returns the stored appointments among ids.
+	GetAppointmentsByID(ids []string) ([]domain.Appointment, error)
+	UpsertAppointments(appts []domain.Appointment) error
+	DeleteAppointment(appointmentID string) error
+	// ClaimAppointments records that the appointments were seen in the
+	// calendar's sync.
+	ClaimAppointments(calendarID string, ids []string) error
+	// PruneAppointments deletes the appointments claimed by the calendar,
+	// or never claimed when calendarID is "", that are not among keep, and
+	// returns how many went.
+	PruneAppointments(calendarID string, keep []string) (int64, error)
+	// GetCalendarSyncToken returns the calendar's last sync token, or "".
+	GetCalendarSyncToken(calendarID string) (string, error)
+	SaveCalendarSyncToken(calendarID, token string) error
+}
+
+// CalendarSyncService receives pings from the calendars' watch channels.
+type CalendarSyncService interface {
+	// HandleNotification queues a sync of the pinged calendar. It returns
+	// domain.ErrUnknownWatchChannel or domain.ErrInvalidWatchToken for pings
+	// that are not ours.
+	HandleNotification(ctx context.Context, n domain.CalendarNotification) error
+}
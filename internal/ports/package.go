@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// PackageService sells prepaid session packages and reports their balance.
+// Sessions are charged by the implementation as appointments complete.
+type PackageService interface {
+	Sell(ctx context.Context, pkg domain.SessionPackage) (*domain.SessionPackage, error)
+	// ListForPatient returns all of the patient's packages, newest first.
+	ListForPatient(ctx context.Context, patientID string) ([]domain.SessionPackage, error)
+}
@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// PaymentService records how visits were paid and reports the income.
+type PaymentService interface {
+	// RecordPayment stores how a visit was paid, replacing an earlier record.
+	// An empty currency or payment time is filled in.
+	RecordPayment(ctx context.Context, p domain.Payment) (*domain.Payment, error)
+	// UnpaidVisits returns the recent visits of a patient (of everyone when
+	// patientID is empty) that were held but neither paid nor charged to a
+	// package, oldest first, with their service price.
+	UnpaidVisits(ctx context.Context, patientID string) ([]domain.Appointment, error)
+	// FindVisit resolves an appointment ID, or a patient's Telegram ID to
+	// their latest unpaid visit, to the visit with its service price.
+	FindVisit(ctx context.Context, ref string) (*domain.Appointment, error)
+	// OutstandingBalance sums the prices of the patient's unpaid visits,
+	// less the discounts applied at booking.
+	OutstandingBalance(ctx context.Context, patientID string) (float64, error)
+	// Currency is the currency visit prices and payments are kept in.
+	Currency() string
+	// Revenue reports the income in [from, to) broken down by period.
+	Revenue(ctx context.Context, from, to time.Time, period domain.RevenuePeriod) (*domain.RevenueReport, error)
+}
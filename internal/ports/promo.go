@@ -0,0 +1,30 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// PromoService manages promo codes and applies them to bookings.
+type PromoService interface {
+	// CreatePromo stores a new code; an empty start or creation time is
+	// filled in.
+	CreatePromo(ctx context.Context, p domain.PromoCode) (*domain.PromoCode, error)
+	// ListPromos returns every code, newest first.
+	ListPromos(ctx context.Context) ([]domain.PromoCode, error)
+	// ListPromoUsages returns the bookings that used the code, newest first.
+	ListPromoUsages(ctx context.Context, code string) ([]domain.PromoUsage, error)
+	// ApplyPromo checks that the code may be used now for the service and
+	// returns it with the discount it gives on price. Nothing is counted
+	// until ReservePromo.
+	ApplyPromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error)
+	// ReservePromo checks the code like ApplyPromo and takes one of its uses
+	// before the booking is made. It returns domain.ErrPromoUsedUp when no
+	// use is left.
+	ReservePromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error)
+	// ReleasePromo gives back a use reserved for a booking that failed.
+	ReleasePromo(ctx context.Context, code string) error
+	// RedeemPromo records the booking against the use reserved for it.
+	RedeemPromo(ctx context.Context, appt domain.Appointment) error
+}
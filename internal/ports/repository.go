@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"context"
 	"time"
 
 	"github.com/kfilin/massage-bot/internal/domain"
@@ -39,3 +40,166 @@ type Repository interface {
 	SaveAppointmentMetadata(apptID string, confirmedAt *time.Time, remindersSent map[string]bool) error
 	GetAppointmentMetadata(apptID string) (confirmedAt *time.Time, remindersSent map[string]bool, err error)
 }
+
+// ServiceCatalogRepository persists the bookable service catalog.
+// Services are never hard-deleted: archiving hides them from booking while
+// keeping them resolvable for reports and admin screens.
+type ServiceCatalogRepository interface {
+	ListServices(includeArchived bool) ([]domain.Service, error)
+	GetService(id string) (*domain.Service, error)
+	SaveService(svc domain.Service) error
+	SetServiceArchived(id string, archived bool) error
+	ReorderServices(orderedIDs []string) error
+
+	ListCategories() ([]domain.ServiceCategory, error)
+	SaveCategory(cat domain.ServiceCategory) error
+}
+
+// ScheduleRepository persists the weekly working schedule and its dated
+// exceptions (closures, shortened days, extra days). An empty therapistID
+// addresses the shared schedule.
+type ScheduleRepository interface {
+	// GetWeeklySchedule returns all rules (every therapist) plus exceptions
+	// from yesterday onward; use WeeklySchedule.ForTherapist to resolve one.
+	GetWeeklySchedule() (domain.WeeklySchedule, error)
+	// ReplaceWeekdayRules swaps all rules of one weekday in a single transaction.
+	ReplaceWeekdayRules(therapistID string, weekday time.Weekday, rules []domain.ScheduleRule) error
+	SaveScheduleException(exc domain.ScheduleException) error
+	DeleteScheduleException(therapistID string, date time.Time) error
+}
+
+// TherapistRepository persists the therapist registry. Therapists are never
+// deleted so past appointments keep resolving; deactivate them instead.
+type TherapistRepository interface {
+	ListTherapists() ([]domain.Therapist, error)
+	SaveTherapist(t domain.Therapist) error
+}
+
+// WaitlistRepository persists waitlist entries and the offers made from
+// them when a slot frees up.
+type WaitlistRepository interface {
+	// AddWaitlistEntry stores a new entry and fills in its ID and CreatedAt.
+	AddWaitlistEntry(e *domain.WaitlistEntry) error
+	GetWaitlistEntry(id int64) (*domain.WaitlistEntry, error)
+	// ListPatientWaitlist returns the patient's open (waiting or offered)
+	// entries that have not ended yet.
+	ListPatientWaitlist(patientID string) ([]domain.WaitlistEntry, error)
+	// ListWaitlistCandidates returns waiting entries covering the date of
+	// slotStart that were never offered that slot, oldest first.
+	ListWaitlistCandidates(slotStart time.Time) ([]domain.WaitlistEntry, error)
+	SetWaitlistEntryStatus(id int64, status string) error
+
+	// CreateWaitlistOffer stores a new offer and fills in its ID.
+	CreateWaitlistOffer(o *domain.WaitlistOffer) error
+	GetWaitlistOffer(id int64) (*domain.WaitlistOffer, error)
+	SetWaitlistOfferStatus(id int64, status string) error
+	// ListExpiredWaitlistOffers returns pending offers whose time ran out.
+	ListExpiredWaitlistOffers(now time.Time) ([]domain.WaitlistOffer, error)
+}
+
+// SeriesRepository links the appointments of a recurring series. The
+// appointments themselves live in the calendar.
+type SeriesRepository interface {
+	// CreateSeries stores the series and its booked appointments, filling
+	// in ID and CreatedAt.
+	CreateSeries(series *domain.AppointmentSeries) error
+	// GetSeriesByAppointment returns the series an appointment belongs to,
+	// with the remaining occurrences (ID and StartTime only) in start order.
+	// It returns domain.ErrSeriesNotFound for a standalone appointment.
+	GetSeriesByAppointment(appointmentID string) (*domain.AppointmentSeries, error)
+	// RemoveSeriesAppointment forgets a cancelled occurrence.
+	RemoveSeriesAppointment(appointmentID string) error
+}
+
+// PackageRepository persists prepaid session packages and the appointments
+// charged to them.
+type PackageRepository interface {
+	// CreatePackage stores a sold package and fills in its ID.
+	CreatePackage(p *domain.SessionPackage) error
+	// ListPatientPackages returns all of the patient's packages, newest first.
+	ListPatientPackages(patientID string) ([]domain.SessionPackage, error)
+	// ListOpenPackages returns packages not expired at now that still have
+	// an admin alert outstanding.
+	ListOpenPackages(now time.Time) ([]domain.SessionPackage, error)
+	// ChargePackage uses one session of the package for the appointment.
+	// It reports false when the appointment was already charged to any
+	// package, and returns domain.ErrPackageExhausted when none are left.
+	ChargePackage(packageID int64, usage domain.PackageUsage) (bool, error)
+	// RefundPackage gives back the session the appointment was charged and
+	// returns the package it went back to. It reports false when the
+	// appointment was not charged.
+	RefundPackage(appointmentID string) (int64, bool, error)
+	// MarkPackageAlerted records that the low-balance or expiry alert
+	// (domain.PackageAlertLowBalance / PackageAlertExpiry) was sent.
+	MarkPackageAlerted(packageID int64, alert string) error
+}
+
+// AppointmentStatusRepository keeps the lifecycle status of appointments
+// and its history. It outlives the calendar event, so cancelled and past
+// appointments keep their status.
+type AppointmentStatusRepository interface {
+	// GetAppointmentStatus returns domain.StatusBooked when nothing is recorded.
+	GetAppointmentStatus(appointmentID string) (domain.AppointmentStatus, error)
+	// UpdateAppointmentStatus moves the appointment from one status to
+	// another and logs the change. It returns domain.ErrInvalidStatusChange
+	// when the stored status is no longer from.
+	UpdateAppointmentStatus(appt domain.Appointment, from, to domain.AppointmentStatus) error
+	// CountPatientStatuses counts the patient's appointments per status.
+	CountPatientStatuses(customerID string) (map[domain.AppointmentStatus]int, error)
+}
+
+// SlotHoldRepository persists short-lived holds on slots picked during the
+// booking conversation. Each holder has at most one hold.
+type SlotHoldRepository interface {
+	// PlaceSlotHold stores hold, replacing the holder's previous one, and
+	// drops holds that expired before now.
+	PlaceSlotHold(hold domain.SlotHold, now time.Time) error
+	ReleaseSlotHold(holderID string) error
+	// GetSlotHolds returns the holds on therapistID's calendar that overlap
+	// [from, to) and are still valid at now.
+	GetSlotHolds(therapistID string, from, to, now time.Time) ([]domain.SlotHold, error)
+}
+
+// BookingLocker serializes slot reservations across bot instances, so two
+// replicas (or both sides of a blue/green deploy) cannot book the same slot.
+type BookingLocker interface {
+	// LockBookings blocks until the booking lock is held or ctx is done. The
+	// returned func releases it.
+	LockBookings(ctx context.Context) (unlock func(), err error)
+}
+
+// PaymentRepository persists visit payments and reads what the revenue
+// reports need.
+type PaymentRepository interface {
+	// SavePayment stores the payment of a visit, replacing an earlier one,
+	// and fills in its ID.
+	SavePayment(p *domain.Payment) error
+	// ListPayments returns the payments made in [from, to), oldest first.
+	ListPayments(from, to time.Time) ([]domain.Payment, error)
+	// ListSettledAppointments returns which of the appointments need no
+	// payment: paid, charged to a package, no-shows or cancelled.
+	ListSettledAppointments(ids []string) (map[string]bool, error)
+	// ListPackagesSold returns the session packages bought in [from, to).
+	ListPackagesSold(from, to time.Time) ([]domain.SessionPackage, error)
+}
+
+// PromoRepository persists promo codes and the bookings that used them.
+type PromoRepository interface {
+	// CreatePromoCode stores a new code. It returns domain.ErrPromoExists
+	// when the code is taken.
+	CreatePromoCode(p domain.PromoCode) error
+	// GetPromoCode returns the code, or domain.ErrPromoNotFound.
+	GetPromoCode(code string) (*domain.PromoCode, error)
+	// ListPromoCodes returns every code, newest first.
+	ListPromoCodes() ([]domain.PromoCode, error)
+	// ReservePromoCode atomically counts one use of the code. It returns
+	// domain.ErrPromoUsedUp when the code has no uses left.
+	ReservePromoCode(code string) error
+	// ReleasePromoCode gives back one use reserved by ReservePromoCode.
+	ReleasePromoCode(code string) error
+	// RecordPromoUsage stores the booking that used the code. It reports
+	// false when the appointment is already recorded.
+	RecordPromoUsage(usage domain.PromoUsage) (bool, error)
+	// ListPromoUsages returns the bookings that used the code, newest first.
+	ListPromoUsages(code string) ([]domain.PromoUsage, error)
+}
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// WaitlistService lets patients wait for a fully booked day and claim slots
+// freed by cancellations. Offers are sent to patients by the implementation.
+type WaitlistService interface {
+	Join(ctx context.Context, entry domain.WaitlistEntry) (*domain.WaitlistEntry, error)
+	ListForPatient(ctx context.Context, patientID string) ([]domain.WaitlistEntry, error)
+	Leave(ctx context.Context, patientID string, entryID int64) error
+	// AcceptOffer books the offered slot for the patient.
+	AcceptOffer(ctx context.Context, patientID string, offerID int64) (*domain.Appointment, error)
+	// DeclineOffer releases the slot to the next patient in line.
+	DeclineOffer(ctx context.Context, patientID string, offerID int64) error
+}
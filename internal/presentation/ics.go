@@ -0,0 +1,166 @@
+package presentation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// iCalendar methods (RFC 5546) of the objects built by FormatICS.
+const (
+	ICSPublish = "PUBLISH" // Add or update the events
+	ICSCancel  = "CANCEL"  // Remove the events from the calendar
+)
+
+// ICSMimeType is the content type of iCalendar files and feeds.
+const ICSMimeType = "text/calendar; charset=utf-8"
+
+const icsUTCLayout = "20060102T150405Z"
+
+// icsSequenceEpoch is where event sequence numbers start counting seconds.
+var icsSequenceEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ICSCalendar describes an iCalendar object built by FormatICS.
+type ICSCalendar struct {
+	Name   string // Calendar name shown by subscribing apps, optional
+	Method string // ICSPublish or ICSCancel; empty means ICSPublish
+	// ForAdmin puts the patient's name in each summary, for the admin feed.
+	ForAdmin bool
+}
+
+// FormatICS renders appts as an iCalendar object that phones and calendar
+// apps can import or subscribe to. Event UIDs are derived from appointment
+// IDs, so importing a CANCEL for a booking removes the event added earlier
+// and a later PUBLISH moves it.
+func FormatICS(cal ICSCalendar, appts []domain.Appointment, stamp time.Time) []byte {
+	method := cal.Method
+	if method == "" {
+		method = ICSPublish
+	}
+
+	var b strings.Builder
+	line := func(name, value string) {
+		writeICSLine(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//massage-bot//Bookings//RU")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", method)
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escapeICSText(cal.Name))
+	}
+	for _, appt := range appts {
+		start, end := icsSpan(appt)
+		line("BEGIN", "VEVENT")
+		line("UID", ICSEventUID(appt.ID))
+		line("DTSTAMP", stamp.UTC().Format(icsUTCLayout))
+		line("DTSTART", start.UTC().Format(icsUTCLayout))
+		line("DTEND", end.UTC().Format(icsUTCLayout))
+		line("SUMMARY", escapeICSText(icsSummary(appt, cal.ForAdmin)))
+		if appt.MeetLink != "" {
+			line("DESCRIPTION", escapeICSText("Google Meet: "+appt.MeetLink))
+			line("URL", appt.MeetLink)
+		}
+		if method == ICSCancel {
+			line("STATUS", "CANCELLED")
+		} else {
+			line("STATUS", "CONFIRMED")
+		}
+		line("SEQUENCE", strconv.FormatInt(icsSequence(stamp), 10))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// icsSequence is the SEQUENCE of events stamped at stamp. It grows with
+// every file sent, so calendar apps apply a moved booking or a cancellation
+// over the copy they imported earlier.
+func icsSequence(stamp time.Time) int64 {
+	seq := int64(stamp.Sub(icsSequenceEpoch) / time.Second)
+	if seq < 0 {
+		return 0
+	}
+	return seq
+}
+
+// ICSFeedToken signs the calendar feed of a patient, or of an admin when
+// admin is set. Unlike card links, feed links do not expire: calendar apps
+// keep polling the same URL.
+func ICSFeedToken(id string, admin bool, secret string) string {
+	prefix := "ics:"
+	if admin {
+		prefix = "ics-admin:"
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(prefix + strings.TrimSpace(id)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ICSEventUID is the iCalendar UID of the event for an appointment.
+func ICSEventUID(apptID string) string {
+	return apptID + "@massage-bot"
+}
+
+// ICSFileName is the name of the .ics attachment for an appointment.
+func ICSFileName(appt *domain.Appointment) string {
+	start, _ := icsSpan(*appt)
+	return fmt.Sprintf("massage-%s.ics", start.In(domain.ApptTimeZone).Format("2006-01-02-1504"))
+}
+
+// icsSpan returns when appt starts and ends, falling back to its duration
+// or the service's when the end is not set.
+func icsSpan(appt domain.Appointment) (time.Time, time.Time) {
+	start := appt.StartTime
+	if start.IsZero() {
+		start = appt.Time
+	}
+	end := appt.EndTime
+	if end.IsZero() || !end.After(start) {
+		minutes := appt.Duration
+		if minutes <= 0 {
+			minutes = appt.Service.DurationMinutes
+		}
+		end = start.Add(time.Duration(minutes) * time.Minute)
+	}
+	return start, end
+}
+
+func icsSummary(appt domain.Appointment, forAdmin bool) string {
+	name := appt.Service.Name
+	if name == "" {
+		name = "Массаж"
+	}
+	if forAdmin && appt.CustomerName != "" {
+		return name + " - " + appt.CustomerName
+	}
+	return name
+}
+
+// writeICSLine writes a content line folded at 75 octets without splitting
+// UTF-8 sequences (RFC 5545 §3.1).
+func writeICSLine(b *strings.Builder, line string) {
+	const limit = 75
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+func escapeICSText(s string) string { return icsTextEscaper.Replace(s) }
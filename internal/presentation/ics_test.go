@@ -0,0 +1,127 @@
+package presentation
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestFormatICS(t *testing.T) {
+	trt := time.FixedZone("TRT", 3*60*60)
+	stamp := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	appts := []domain.Appointment{
+		{
+			ID:           "evt1",
+			Service:      domain.Service{Name: "Massage, 60 min", DurationMinutes: 60},
+			CustomerName: "Anna",
+			StartTime:    time.Date(2030, 1, 9, 13, 0, 0, 0, trt),
+			MeetLink:     "https://meet.google.com/abc",
+		},
+		{
+			ID:        "evt2",
+			Service:   domain.Service{Name: "Consultation"},
+			StartTime: time.Date(2030, 1, 10, 10, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2030, 1, 10, 10, 30, 0, 0, time.UTC),
+		},
+	}
+
+	t.Run("Publish", func(t *testing.T) {
+		got := string(FormatICS(ICSCalendar{Name: "Massage"}, appts, stamp))
+		for _, want := range []string{
+			"BEGIN:VCALENDAR\r\n",
+			"METHOD:PUBLISH\r\n",
+			"X-WR-CALNAME:Massage\r\n",
+			"UID:evt1@massage-bot\r\n",
+			"DTSTAMP:20300101T090000Z\r\n",
+			"DTSTART:20300109T100000Z\r\n",
+			"DTEND:20300109T110000Z\r\n",
+			`SUMMARY:Massage\, 60 min` + "\r\n",
+			"URL:https://meet.google.com/abc\r\n",
+			"DTEND:20300110T103000Z\r\n",
+			"STATUS:CONFIRMED\r\n",
+			"END:VCALENDAR\r\n",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected %q in\n%s", want, got)
+			}
+		}
+		if strings.Count(got, "BEGIN:VEVENT") != 2 {
+			t.Errorf("expected two events in\n%s", got)
+		}
+		if strings.Contains(got, "Anna") {
+			t.Error("patient calendars should not repeat the patient's name")
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		got := string(FormatICS(ICSCalendar{Method: ICSCancel}, appts[:1], stamp))
+		for _, want := range []string{"METHOD:CANCEL\r\n", "STATUS:CANCELLED\r\n", "UID:evt1@massage-bot\r\n"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected %q in\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("Admin summaries", func(t *testing.T) {
+		got := string(FormatICS(ICSCalendar{ForAdmin: true}, appts[:1], stamp))
+		if !strings.Contains(got, `SUMMARY:Massage\, 60 min - Anna`) {
+			t.Errorf("expected the patient's name in the summary:\n%s", got)
+		}
+	})
+}
+
+func TestFormatICS_SequenceGrows(t *testing.T) {
+	appt := []domain.Appointment{{ID: "evt1", StartTime: time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)}}
+	booked := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	sequence := func(cal ICSCalendar, stamp time.Time) int {
+		got := string(FormatICS(cal, appt, stamp))
+		i := strings.Index(got, "SEQUENCE:")
+		if i < 0 {
+			t.Fatalf("no SEQUENCE in\n%s", got)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(got[i+len("SEQUENCE:") : i+strings.Index(got[i:], "\r\n")]))
+		if err != nil {
+			t.Fatalf("bad SEQUENCE in\n%s", got)
+		}
+		return n
+	}
+
+	published := sequence(ICSCalendar{}, booked)
+	moved := sequence(ICSCalendar{}, booked.Add(time.Hour))
+	cancelled := sequence(ICSCalendar{Method: ICSCancel}, booked.Add(2*time.Hour))
+	if !(published < moved && moved < cancelled) {
+		t.Errorf("sequence should grow with each file: %d, %d, %d", published, moved, cancelled)
+	}
+	if got := sequence(ICSCalendar{}, time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("sequence before the epoch = %d, want 0", got)
+	}
+}
+
+func TestFormatICS_FoldsLongLines(t *testing.T) {
+	appt := domain.Appointment{
+		ID:        "evt1",
+		Service:   domain.Service{Name: strings.Repeat("Массаж спины ", 10), DurationMinutes: 60},
+		StartTime: time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC),
+	}
+	got := string(FormatICS(ICSCalendar{}, []domain.Appointment{appt}, time.Now()))
+
+	for _, line := range strings.Split(got, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(got, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+appt.Service.Name) {
+		t.Errorf("summary lost when folding:\n%s", got)
+	}
+}
+
+func TestICSFileName(t *testing.T) {
+	appt := &domain.Appointment{StartTime: time.Date(2030, 1, 9, 10, 0, 0, 0, domain.ApptTimeZone)}
+	if got := ICSFileName(appt); got != "massage-2030-01-09-1000.ics" {
+		t.Errorf("ICSFileName() = %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/fs"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -73,6 +74,10 @@ func (p *BotPresenter) FormatAppointment(appt *domain.Appointment, isAdmin bool)
 		sb.WriteString(fmt.Sprintf("⏳ <b>Длительность:</b> %d мин\n", appt.Duration))
 	}
 
+	if appt.PromoCode != "" {
+		sb.WriteString(fmt.Sprintf("🏷 <b>Промокод:</b> %s (−%.0f ₺)\n", appt.PromoCode, appt.Discount))
+	}
+
 	if appt.MeetLink != "" {
 		sb.WriteString(fmt.Sprintf("💻 <b>Meet:</b> <a href=\"%s\">Перейти</a>\n", appt.MeetLink))
 	}
@@ -105,6 +110,510 @@ func (p *BotPresenter) FormatCancellation(appt *domain.Appointment, isAdmin bool
 	return sb.String()
 }
 
+// FormatReschedule formats a message about an appointment moved from oldStart
+func (p *BotPresenter) FormatReschedule(appt *domain.Appointment, oldStart time.Time, isAdmin bool) string {
+	var sb strings.Builder
+	if isAdmin {
+		sb.WriteString("🔄 <b>ЗАПИСЬ ПЕРЕНЕСЕНА</b>\n")
+	} else {
+		sb.WriteString("🔄 <b>ВАША ЗАПИСЬ ПЕРЕНЕСЕНА</b>\n")
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s\n", appt.CustomerName))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appt.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Было:</b> %s в %s\n",
+		oldStart.Format("02.01.2006"),
+		oldStart.Format("15:04")))
+	sb.WriteString(fmt.Sprintf("📅 <b>Стало:</b> %s в %s\n",
+		appt.StartTime.Format("02.01.2006"),
+		appt.StartTime.Format("15:04")))
+	sb.WriteString("──────────────────\n")
+	if !isAdmin {
+		sb.WriteString("<i>💡 Напоминания придут к новому времени.</i>")
+	}
+	return sb.String()
+}
+
+// FormatCalendarChange formats the patient message about a booking the
+// therapist moved, resized or deleted directly in the calendar
+func (p *BotPresenter) FormatCalendarChange(change domain.CalendarChange) string {
+	prev := change.Previous
+	var sb strings.Builder
+	if change.Kind == domain.ChangeCancelled {
+		sb.WriteString("🚫 <b>ВАША ЗАПИСЬ ОТМЕНЕНА</b>\n")
+		sb.WriteString("──────────────────\n")
+		sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", prev.Service.Name))
+		sb.WriteString(fmt.Sprintf("🕒 <b>Было назначено:</b> %s в %s\n",
+			prev.StartTime.Format("02.01.2006"),
+			prev.StartTime.Format("15:04")))
+		sb.WriteString("──────────────────\n")
+		sb.WriteString("<i>Запись отменена терапевтом. Для выбора другого времени используйте /start</i>")
+		return sb.String()
+	}
+
+	current := change.Current
+	sb.WriteString("🔄 <b>ВАША ЗАПИСЬ ИЗМЕНЕНА</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", prev.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Было:</b> %s\n", visitSpan(prev)))
+	sb.WriteString(fmt.Sprintf("📅 <b>Стало:</b> %s\n", visitSpan(current)))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString("<i>Изменение внесено терапевтом, напоминания придут к новому времени. Если оно не подходит, используйте /start</i>")
+	return sb.String()
+}
+
+// FormatCalendarConflict formats the admin alert about a calendar edit the
+// bot could not settle on its own
+func (p *BotPresenter) FormatCalendarConflict(change domain.CalendarChange, problems []string) string {
+	prev := change.Previous
+	var sb strings.Builder
+	sb.WriteString("⚠️ <b>КОНФЛИКТ В КАЛЕНДАРЕ</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s (%s)\n", prev.CustomerName, prev.CustomerTgID))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", prev.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Было:</b> %s\n", visitSpan(prev)))
+	if change.Kind == domain.ChangeCancelled {
+		sb.WriteString("🗑 <b>Стало:</b> событие удалено\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("📅 <b>Стало:</b> %s\n", visitSpan(change.Current)))
+	}
+	sb.WriteString("──────────────────\n")
+	for _, problem := range problems {
+		sb.WriteString(fmt.Sprintf("• %s\n", problem))
+	}
+	sb.WriteString("<i>Проверьте запись и свяжитесь с пациентом.</i>")
+	return sb.String()
+}
+
+// visitSpan spells a visit's date, start and end: "15.03.2026 14:30–15:30".
+func visitSpan(appt domain.Appointment) string {
+	if !appt.EndTime.After(appt.StartTime) {
+		return appt.StartTime.Format("02.01.2006 в 15:04")
+	}
+	return appt.StartTime.Format("02.01.2006 15:04") + "–" + appt.EndTime.Format("15:04")
+}
+
+// FormatSeriesBooked formats the confirmation of a booked recurring series
+func (p *BotPresenter) FormatSeriesBooked(series *domain.AppointmentSeries, isAdmin bool) string {
+	var sb strings.Builder
+	if isAdmin {
+		sb.WriteString("🔁 <b>СЕРИЯ ЗАПИСЕЙ СОЗДАНА</b>\n")
+	} else {
+		sb.WriteString("🔁 <b>ВЫ ЗАПИСАНЫ НА КУРС</b>\n")
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s\n", series.CustomerName))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", series.Service.Name))
+	sb.WriteString(fmt.Sprintf("📋 <b>Сеансов:</b> %d\n", len(series.Appointments)))
+	for _, appt := range series.Appointments {
+		sb.WriteString(fmt.Sprintf("• %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	}
+	sb.WriteString("──────────────────\n")
+	if !isAdmin {
+		sb.WriteString("<i>Отменить один сеанс или остаток курса можно в /myappointments</i>")
+	}
+	return sb.String()
+}
+
+// FormatSeriesCancellation formats a message about cancelled occurrences of a series
+func (p *BotPresenter) FormatSeriesCancellation(appts []domain.Appointment, isAdmin bool) string {
+	var sb strings.Builder
+	if isAdmin {
+		sb.WriteString("⚠️ <b>СЕРИЯ ЗАПИСЕЙ ОТМЕНЕНА</b>\n")
+	} else {
+		sb.WriteString("🚫 <b>ВАШ КУРС ОТМЕНЁН</b>\n")
+	}
+	sb.WriteString("──────────────────\n")
+	if len(appts) > 0 {
+		sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s\n", appts[0].CustomerName))
+		sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appts[0].Service.Name))
+	}
+	sb.WriteString(fmt.Sprintf("🕒 <b>Отменено сеансов:</b> %d\n", len(appts)))
+	for _, appt := range appts {
+		sb.WriteString(fmt.Sprintf("• %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	}
+	sb.WriteString("──────────────────\n")
+	if !isAdmin {
+		sb.WriteString("<i>Для выбора другого времени используйте /start</i>")
+	}
+	return sb.String()
+}
+
+// FormatWaitlistOffer formats the message offering a freed slot to a waitlisted patient
+func (p *BotPresenter) FormatWaitlistOffer(entry domain.WaitlistEntry, offer domain.WaitlistOffer) string {
+	var sb strings.Builder
+	sb.WriteString("🔔 <b>ОСВОБОДИЛОСЬ ВРЕМЯ</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", entry.ServiceName))
+	sb.WriteString(fmt.Sprintf("📅 <b>Дата:</b> %s\n", offer.SlotStart.Format("02.01.2006")))
+	sb.WriteString(fmt.Sprintf("⏰ <b>Время:</b> %s\n", offer.SlotStart.Format("15:04")))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("<i>Предложение действует до %s. Потом время получит следующий в листе ожидания.</i>",
+		offer.ExpiresAt.Format("15:04")))
+	return sb.String()
+}
+
+// FormatPackageSold formats the confirmation of a sold session package
+func (p *BotPresenter) FormatPackageSold(pkg *domain.SessionPackage, isAdmin bool) string {
+	var sb strings.Builder
+	if isAdmin {
+		sb.WriteString("🎟 <b>АБОНЕМЕНТ ОФОРМЛЕН</b>\n")
+	} else {
+		sb.WriteString("🎟 <b>ВАШ АБОНЕМЕНТ</b>\n")
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s\n", pkg.PatientName))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуги:</b> %s\n", pkg.Title))
+	sb.WriteString(fmt.Sprintf("📋 <b>Сеансов:</b> %d\n", pkg.SessionsTotal))
+	if pkg.Price > 0 {
+		sb.WriteString(fmt.Sprintf("💰 <b>Цена:</b> %.0f ₺\n", pkg.Price))
+	}
+	sb.WriteString(fmt.Sprintf("📅 <b>Действует до:</b> %s\n", pkg.ExpiresAt.Format("02.01.2006")))
+	sb.WriteString("──────────────────\n")
+	if !isAdmin {
+		sb.WriteString("<i>Сеансы списываются автоматически после визита. Остаток виден в /myrecords</i>")
+	}
+	return sb.String()
+}
+
+// FormatPackageBalance formats the remaining sessions of the given packages
+func (p *BotPresenter) FormatPackageBalance(pkgs []domain.SessionPackage, now time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("🎟 <b>АБОНЕМЕНТЫ:</b>\n")
+	for _, pkg := range pkgs {
+		var state string
+		switch {
+		case pkg.Remaining() == 0:
+			state = "использован"
+		case !now.Before(pkg.ExpiresAt):
+			state = fmt.Sprintf("истёк %s", pkg.ExpiresAt.Format("02.01.2006"))
+		default:
+			state = fmt.Sprintf("до %s", pkg.ExpiresAt.Format("02.01.2006"))
+		}
+		sb.WriteString(fmt.Sprintf("• %s — осталось <b>%d из %d</b>, %s\n", pkg.Title, pkg.Remaining(), pkg.SessionsTotal, state))
+	}
+	return sb.String()
+}
+
+// FormatPackageCharged formats the patient notice about a used session
+func (p *BotPresenter) FormatPackageCharged(pkg *domain.SessionPackage, appt *domain.Appointment) string {
+	var sb strings.Builder
+	sb.WriteString("🎟 <b>СЕАНС СПИСАН С АБОНЕМЕНТА</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appt.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Визит:</b> %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	sb.WriteString(fmt.Sprintf("📋 <b>Осталось:</b> %d из %d\n", pkg.Remaining(), pkg.SessionsTotal))
+	sb.WriteString("──────────────────\n")
+	return sb.String()
+}
+
+// FormatPackageRefunded formats the patient notice about a session given back
+func (p *BotPresenter) FormatPackageRefunded(pkg *domain.SessionPackage, appt *domain.Appointment) string {
+	var sb strings.Builder
+	sb.WriteString("🎟 <b>СЕАНС ВОЗВРАЩЁН НА АБОНЕМЕНТ</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appt.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Визит:</b> %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	sb.WriteString(fmt.Sprintf("📋 <b>Осталось:</b> %d из %d\n", pkg.Remaining(), pkg.SessionsTotal))
+	sb.WriteString("──────────────────\n")
+	return sb.String()
+}
+
+// FormatPackageAlert formats the admin alert about a package running out or expiring
+func (p *BotPresenter) FormatPackageAlert(pkg *domain.SessionPackage, alert string) string {
+	var sb strings.Builder
+	if alert == domain.PackageAlertExpiry {
+		sb.WriteString("⏳ <b>АБОНЕМЕНТ СКОРО ИСТЕКАЕТ</b>\n")
+	} else {
+		sb.WriteString("🎟 <b>АБОНЕМЕНТ ЗАКАНЧИВАЕТСЯ</b>\n")
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s (%s)\n", pkg.PatientName, pkg.PatientID))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуги:</b> %s\n", pkg.Title))
+	sb.WriteString(fmt.Sprintf("📋 <b>Осталось:</b> %d из %d\n", pkg.Remaining(), pkg.SessionsTotal))
+	sb.WriteString(fmt.Sprintf("📅 <b>Действует до:</b> %s\n", pkg.ExpiresAt.Format("02.01.2006")))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString("<i>Предложите пациенту продлить абонемент.</i>")
+	return sb.String()
+}
+
+// FormatPaymentRecorded formats the admin confirmation of a visit payment
+func (p *BotPresenter) FormatPaymentRecorded(pay *domain.Payment) string {
+	var sb strings.Builder
+	sb.WriteString("💳 <b>ОПЛАТА ЗАПИСАНА</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s (%s)\n", pay.PatientName, pay.PatientID))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", pay.ServiceName))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Визит:</b> %s в %s\n", pay.VisitTime.Format("02.01.2006"), pay.VisitTime.Format("15:04")))
+	sb.WriteString(fmt.Sprintf("💰 <b>Сумма:</b> %s (%s)\n", FormatMoney(pay.Amount, pay.Currency), pay.Method.Label()))
+	if pay.Discount > 0 {
+		sb.WriteString(fmt.Sprintf("🏷 <b>Скидка:</b> %s\n", FormatMoney(pay.Discount, pay.Currency)))
+	}
+	sb.WriteString("──────────────────\n")
+	return sb.String()
+}
+
+// FormatUnpaidVisits formats the visits still to be paid, one per line with
+// the command that marks it paid
+func (p *BotPresenter) FormatUnpaidVisits(visits []domain.Appointment, currency string) string {
+	if len(visits) == 0 {
+		return "✅ Неоплаченных визитов нет."
+	}
+	var sb strings.Builder
+	var total float64
+	sb.WriteString("🧾 <b>НЕОПЛАЧЕННЫЕ ВИЗИТЫ</b>\n")
+	sb.WriteString("──────────────────\n")
+	for _, v := range visits {
+		total += v.AmountDue()
+		sb.WriteString(fmt.Sprintf("• %s %s — %s, %s, %s\n  <code>/paid %s нал</code>\n",
+			v.StartTime.Format("02.01"), v.StartTime.Format("15:04"), v.CustomerName, v.Service.Name,
+			FormatMoney(v.AmountDue(), currency), v.ID))
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💰 <b>К оплате:</b> %s", FormatMoney(total, currency)))
+	return sb.String()
+}
+
+// FormatRevenueReport formats income by period, service and payment method
+func (p *BotPresenter) FormatRevenueReport(r *domain.RevenueReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 <b>ВЫРУЧКА %s — %s</b>\n", r.From.Format("02.01.2006"), r.To.Add(-time.Second).Format("02.01.2006")))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💰 <b>Итого:</b> %s\n", FormatMoney(r.Total, r.Currency)))
+	sb.WriteString(fmt.Sprintf("💆 <b>Оплачено визитов:</b> %d\n", r.Visits))
+	if r.Discounts > 0 {
+		sb.WriteString(fmt.Sprintf("🏷 <b>Скидки:</b> %s\n", FormatMoney(r.Discounts, r.Currency)))
+	}
+	if r.Packages.Visits > 0 {
+		sb.WriteString(fmt.Sprintf("🎟 <b>Абонементов продано:</b> %d на %s\n", r.Packages.Visits, FormatMoney(r.Packages.Amount, r.Currency)))
+	}
+	if r.Total == 0 && r.Visits == 0 {
+		sb.WriteString("──────────────────\n")
+		sb.WriteString("<i>Оплат за этот период нет.</i>")
+		return sb.String()
+	}
+
+	sb.WriteString("\n<b>По периодам:</b>\n")
+	for _, l := range r.ByPeriod {
+		var label string
+		switch r.Period {
+		case domain.RevenueWeek:
+			label = "неделя с " + l.Start.Format("02.01")
+		case domain.RevenueMonth:
+			label = l.Start.Format("01.2006")
+		default:
+			label = l.Start.Format("02.01")
+		}
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", label, FormatMoney(l.Amount, r.Currency)))
+	}
+	if len(r.ByService) > 0 {
+		sb.WriteString("\n<b>По услугам:</b>\n")
+		for _, l := range r.ByService {
+			sb.WriteString(fmt.Sprintf("• %s — %s (%d)\n", l.Label, FormatMoney(l.Amount, r.Currency), l.Visits))
+		}
+	}
+	if len(r.ByMethod) > 0 {
+		sb.WriteString("\n<b>По способам оплаты:</b>\n")
+		for _, l := range r.ByMethod {
+			sb.WriteString(fmt.Sprintf("• %s — %s (%d)\n", domain.PaymentMethod(l.Label).Label(), FormatMoney(l.Amount, r.Currency), l.Visits))
+		}
+	}
+	sb.WriteString("──────────────────\n")
+	return sb.String()
+}
+
+// FormatPromoCreated formats the admin confirmation of a new promo code
+func (p *BotPresenter) FormatPromoCreated(promo *domain.PromoCode, currency string) string {
+	var sb strings.Builder
+	sb.WriteString("🏷 <b>ПРОМОКОД СОЗДАН</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("🔑 <b>Код:</b> <code>%s</code>\n", promo.Code))
+	sb.WriteString(fmt.Sprintf("💸 <b>Скидка:</b> %s\n", promoValue(promo, currency)))
+	sb.WriteString(fmt.Sprintf("📅 <b>Действует:</b> %s\n", promoWindow(promo)))
+	sb.WriteString(fmt.Sprintf("🔢 <b>Лимит:</b> %s\n", promoLimit(promo)))
+	if len(promo.ServiceIDs) > 0 {
+		sb.WriteString(fmt.Sprintf("💆 <b>Услуги:</b> %s\n", strings.Join(promo.ServiceIDs, ", ")))
+	} else {
+		sb.WriteString("💆 <b>Услуги:</b> все\n")
+	}
+	sb.WriteString("──────────────────\n")
+	return sb.String()
+}
+
+// FormatPromoList formats every promo code with its use count
+func (p *BotPresenter) FormatPromoList(promos []domain.PromoCode, now time.Time, currency string) string {
+	if len(promos) == 0 {
+		return "Промокодов пока нет. Создать: /promo_add"
+	}
+	var sb strings.Builder
+	sb.WriteString("🏷 <b>ПРОМОКОДЫ</b>\n")
+	sb.WriteString("──────────────────\n")
+	for i := range promos {
+		promo := &promos[i]
+		state := "✅"
+		switch {
+		case now.Before(promo.ValidFrom):
+			state = "⏳"
+		case !now.Before(promo.ValidUntil), promo.MaxUses > 0 && promo.Uses >= promo.MaxUses:
+			state = "⛔"
+		}
+		sb.WriteString(fmt.Sprintf("%s <code>%s</code> — %s, %s, использован %d/%s\n",
+			state, promo.Code, promoValue(promo, currency), promoWindow(promo), promo.Uses, promoLimit(promo)))
+		if len(promo.ServiceIDs) > 0 {
+			sb.WriteString(fmt.Sprintf("   услуги: %s\n", strings.Join(promo.ServiceIDs, ", ")))
+		}
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString("<i>Использования кода: /promo {код}</i>")
+	return sb.String()
+}
+
+// FormatPromoUsages formats the bookings that used a promo code
+func (p *BotPresenter) FormatPromoUsages(code string, usages []domain.PromoUsage, currency string) string {
+	if len(usages) == 0 {
+		return fmt.Sprintf("Промокод %s ещё не использовался.", code)
+	}
+	var sb strings.Builder
+	var total float64
+	sb.WriteString(fmt.Sprintf("🏷 <b>ПРОМОКОД %s</b>\n", code))
+	sb.WriteString("──────────────────\n")
+	for _, u := range usages {
+		total += u.Discount
+		sb.WriteString(fmt.Sprintf("• %s — %s (%s), %s, −%s\n",
+			u.UsedAt.Format("02.01.2006"), u.PatientName, u.PatientID, u.ServiceName, FormatMoney(u.Discount, currency)))
+	}
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("🔢 <b>Использований:</b> %d\n", len(usages)))
+	sb.WriteString(fmt.Sprintf("💸 <b>Скидки:</b> %s", FormatMoney(total, currency)))
+	return sb.String()
+}
+
+func promoValue(promo *domain.PromoCode, currency string) string {
+	if promo.Kind == domain.PromoFixed {
+		return FormatMoney(promo.Value, currency)
+	}
+	return promo.Label()
+}
+
+func promoWindow(promo *domain.PromoCode) string {
+	return fmt.Sprintf("%s — %s", promo.ValidFrom.Format("02.01.2006"), promo.ValidUntil.Add(-time.Second).Format("02.01.2006"))
+}
+
+func promoLimit(promo *domain.PromoCode) string {
+	if promo.MaxUses == 0 {
+		return "∞"
+	}
+	return strconv.Itoa(promo.MaxUses)
+}
+
+// FormatMoney formats an amount with the currency sign, e.g. "1500 ₺".
+// Unknown currencies are shown by their code.
+func FormatMoney(amount float64, currency string) string {
+	switch strings.ToUpper(currency) {
+	case "", "TRY":
+		return fmt.Sprintf("%.0f ₺", amount)
+	case "RUB":
+		return fmt.Sprintf("%.0f ₽", amount)
+	case "EUR":
+		return fmt.Sprintf("%.0f €", amount)
+	case "USD":
+		return fmt.Sprintf("%.0f $", amount)
+	}
+	return fmt.Sprintf("%.0f %s", amount, currency)
+}
+
+// FormatCancellationPolicy formats the cancellation rules shown with a booking
+func (p *BotPresenter) FormatCancellationPolicy(policy domain.CancellationPolicy) string {
+	if policy.NoticeHours == 0 {
+		return ""
+	}
+	if policy.BlockLate {
+		return fmt.Sprintf("📌 <i>Отменить или перенести запись можно не позднее чем за %s до визита. Позже — только через терапевта.</i>",
+			noticeAhead(policy.NoticeHours))
+	}
+	return fmt.Sprintf("📌 <i>Отменить или перенести запись можно не позднее чем за %s до визита. Более поздняя отмена отмечается в карте как поздняя.</i>",
+		noticeAhead(policy.NoticeHours))
+}
+
+// FormatLateCancelBlocked formats the alert refusing a self-service
+// cancellation (or reschedule) inside the notice window
+func (p *BotPresenter) FormatLateCancelBlocked(policy domain.CancellationPolicy, reschedule bool) string {
+	action := "Автоматическая отмена невозможна"
+	if reschedule {
+		action = "Автоматический перенос невозможен"
+	}
+	return fmt.Sprintf("⛔ До записи меньше %s!\n%s.\nПожалуйста, напишите терапевту напрямую.", noticeWithin(policy.NoticeHours), action)
+}
+
+// FormatLateCancelWarning formats the warning shown before a late cancellation is confirmed
+func (p *BotPresenter) FormatLateCancelWarning(appt *domain.Appointment, policy domain.CancellationPolicy) string {
+	var sb strings.Builder
+	sb.WriteString("⚠️ <b>ПОЗДНЯЯ ОТМЕНА</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appt.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Время:</b> %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(p.FormatLateCancelNotice(policy) + "\n\n")
+	sb.WriteString("<i>Всё равно отменить?</i>")
+	return sb.String()
+}
+
+// FormatLateCancelNotice explains, in plain text, that a cancellation is late
+func (p *BotPresenter) FormatLateCancelNotice(policy domain.CancellationPolicy) string {
+	return fmt.Sprintf("До визита меньше %s. Такая отмена отмечается в карте как поздняя.", noticeWithin(policy.NoticeHours))
+}
+
+// noticeAhead spells a notice period after "за": "3 дня", "12 часов".
+func noticeAhead(hours int) string {
+	if hours%24 == 0 {
+		days := hours / 24
+		return fmt.Sprintf("%d %s", days, ruPlural(days, "день", "дня", "дней"))
+	}
+	return fmt.Sprintf("%d %s", hours, ruPlural(hours, "час", "часа", "часов"))
+}
+
+// noticeWithin spells a notice period after "меньше": "3 дней", "суток".
+func noticeWithin(hours int) string {
+	if hours == 24 {
+		return "суток"
+	}
+	genitive := func(n int, one, many string) string {
+		if n%10 == 1 && n%100 != 11 {
+			return one
+		}
+		return many
+	}
+	if hours%24 == 0 {
+		days := hours / 24
+		return fmt.Sprintf("%d %s", days, genitive(days, "дня", "дней"))
+	}
+	return fmt.Sprintf("%d %s", hours, genitive(hours, "часа", "часов"))
+}
+
+// ruPlural picks the Russian noun form for n: 1 день, 2 дня, 5 дней.
+func ruPlural(n int, one, few, many string) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return one
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return few
+	}
+	return many
+}
+
+// FormatOutcomeRequest formats the admin prompt to record how a visit went
+func (p *BotPresenter) FormatOutcomeRequest(appt *domain.Appointment) string {
+	var sb strings.Builder
+	sb.WriteString("📋 <b>КАК ПРОШЁЛ ВИЗИТ?</b>\n")
+	sb.WriteString("──────────────────\n")
+	sb.WriteString(fmt.Sprintf("👤 <b>Пациент:</b> %s\n", appt.CustomerName))
+	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", appt.Service.Name))
+	sb.WriteString(fmt.Sprintf("🕒 <b>Визит:</b> %s в %s\n", appt.StartTime.Format("02.01.2006"), appt.StartTime.Format("15:04")))
+	sb.WriteString("──────────────────\n")
+	sb.WriteString("<i>Отметьте результат визита.</i>")
+	return sb.String()
+}
+
 // FormatNotification formats a generic clinical notification (e.g. locks, admin actions)
 func (p *BotPresenter) FormatNotification(header string, details map[string]string) string {
 	var sb strings.Builder
@@ -126,7 +635,7 @@ func (p *BotPresenter) FormatNotification(header string, details map[string]stri
 }
 
 // FormatBookingSummary formats a pre-confirmation booking summary
-func (p *BotPresenter) FormatBookingSummary(title string, patientName string, serviceName string, date time.Time, duration int, price float64) string {
+func (p *BotPresenter) FormatBookingSummary(title string, patientName string, serviceName string, date time.Time, duration int, price float64, promoCode string, discount float64) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("📖 <b>%s</b>\n", strings.ToUpper(title)))
 	sb.WriteString("──────────────────\n")
@@ -134,9 +643,14 @@ func (p *BotPresenter) FormatBookingSummary(title string, patientName string, se
 	sb.WriteString(fmt.Sprintf("💆 <b>Услуга:</b> %s\n", serviceName))
 	sb.WriteString(fmt.Sprintf("🕒 <b>Время:</b> %s в %s\n", date.Format("02.01.2006"), date.Format("15:04")))
 	sb.WriteString(fmt.Sprintf("⏳ <b>Длительность:</b> %d мин\n", duration))
-	if price > 0 {
+	if price > 0 && discount > 0 {
+		sb.WriteString(fmt.Sprintf("💰 <b>Цена:</b> <s>%.0f ₺</s> %.0f ₺\n", price, price-discount))
+	} else if price > 0 {
 		sb.WriteString(fmt.Sprintf("💰 <b>Цена:</b> %.0f ₺\n", price))
 	}
+	if promoCode != "" {
+		sb.WriteString(fmt.Sprintf("🏷 <b>Промокод:</b> %s (−%.0f ₺)\n", promoCode, discount))
+	}
 	sb.WriteString("──────────────────\n")
 	sb.WriteString("<i>Всё верно?</i>")
 	return sb.String()
@@ -176,6 +690,9 @@ func (p *BotPresenter) FormatPatientCard(patient domain.Patient) string {
 	sb.WriteString("──────────────────\n")
 	sb.WriteString(fmt.Sprintf("👤 <b>ФИО:</b> %s\n", patient.Name))
 	sb.WriteString(fmt.Sprintf("🔢 <b>ВИЗИТОВ:</b> %d\n", patient.TotalVisits))
+	if patient.NoShows > 0 {
+		sb.WriteString(fmt.Sprintf("🚫 <b>НЕЯВОК:</b> %d\n", patient.NoShows))
+	}
 	sb.WriteString(fmt.Sprintf("💆 <b>ПРОГРАММА:</b> %s\n\n", patient.CurrentService))
 	
 	sb.WriteString("<b>КЛИНИЧЕСКИЕ ЗАМЕТКИ:</b>\n")
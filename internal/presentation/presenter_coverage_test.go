@@ -48,6 +48,162 @@ func TestBotPresenter_FormatCancellation_PatientView(t *testing.T) {
 	}
 }
 
+func TestBotPresenter_FormatReschedule(t *testing.T) {
+	p := NewBotPresenter()
+	appt := &domain.Appointment{
+		CustomerName: "Иван Петров",
+		Service:      domain.Service{Name: "Классический массаж"},
+		StartTime:    time.Date(2026, 3, 17, 11, 0, 0, 0, time.UTC),
+	}
+	old := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	got := p.FormatReschedule(appt, old, true)
+	for _, want := range []string{"ЗАПИСЬ ПЕРЕНЕСЕНА", "Иван Петров", "15.03.2026 в 14:30", "17.03.2026 в 11:00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatReschedule(admin) missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "Напоминания") {
+		t.Error("Admin view should not contain the reminder tip")
+	}
+
+	if got := p.FormatReschedule(appt, old, false); !strings.Contains(got, "ВАША ЗАПИСЬ ПЕРЕНЕСЕНА") {
+		t.Errorf("Expected patient reschedule header, got:\n%s", got)
+	}
+}
+
+func TestBotPresenter_FormatSeries(t *testing.T) {
+	p := NewBotPresenter()
+	start := time.Date(2026, 3, 16, 18, 0, 0, 0, time.UTC)
+	appts := []domain.Appointment{
+		{CustomerName: "Иван Петров", Service: domain.Service{Name: "Реабилитация"}, StartTime: start},
+		{CustomerName: "Иван Петров", Service: domain.Service{Name: "Реабилитация"}, StartTime: start.AddDate(0, 0, 3)},
+	}
+	series := &domain.AppointmentSeries{CustomerName: "Иван Петров", Service: domain.Service{Name: "Реабилитация"}, Appointments: appts}
+
+	got := p.FormatSeriesBooked(series, false)
+	for _, want := range []string{"ВЫ ЗАПИСАНЫ НА КУРС", "Реабилитация", "Сеансов:</b> 2", "16.03.2026 в 18:00", "19.03.2026 в 18:00", "/myappointments"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatSeriesBooked missing %q in:\n%s", want, got)
+		}
+	}
+
+	got = p.FormatSeriesCancellation(appts, true)
+	for _, want := range []string{"СЕРИЯ ЗАПИСЕЙ ОТМЕНЕНА", "Иван Петров", "Отменено сеансов:</b> 2", "19.03.2026 в 18:00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatSeriesCancellation missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBotPresenter_FormatWaitlistOffer(t *testing.T) {
+	p := NewBotPresenter()
+	start := time.Date(2026, 3, 17, 11, 0, 0, 0, time.UTC)
+	got := p.FormatWaitlistOffer(
+		domain.WaitlistEntry{ServiceName: "Классический массаж"},
+		domain.WaitlistOffer{SlotStart: start, ExpiresAt: start.Add(-2 * time.Hour)},
+	)
+	for _, want := range []string{"ОСВОБОДИЛОСЬ ВРЕМЯ", "Классический массаж", "17.03.2026", "11:00", "до 09:00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatWaitlistOffer missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBotPresenter_FormatPackages(t *testing.T) {
+	p := NewBotPresenter()
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	pkg := domain.SessionPackage{
+		PatientID: "100", PatientName: "Иван Петров", Title: "Классический массаж",
+		SessionsTotal: 10, SessionsUsed: 9, Price: 9000, ExpiresAt: now.AddDate(0, 1, 0),
+	}
+
+	got := p.FormatPackageSold(&pkg, true)
+	for _, want := range []string{"АБОНЕМЕНТ ОФОРМЛЕН", "Иван Петров", "10", "9000 ₺", "01.04.2026"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPackageSold missing %q in:\n%s", want, got)
+		}
+	}
+
+	used := pkg
+	used.Title, used.SessionsUsed = "Все услуги", 10
+	got = p.FormatPackageBalance([]domain.SessionPackage{pkg, used}, now)
+	for _, want := range []string{"Классический массаж — осталось <b>1 из 10</b>, до 01.04.2026", "Все услуги — осталось <b>0 из 10</b>, использован"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPackageBalance missing %q in:\n%s", want, got)
+		}
+	}
+
+	appt := &domain.Appointment{Service: domain.Service{Name: "Классический массаж"}, StartTime: now}
+	if got := p.FormatPackageCharged(&pkg, appt); !strings.Contains(got, "1 из 10") || !strings.Contains(got, "01.03.2026 в 10:00") {
+		t.Errorf("FormatPackageCharged unexpected output:\n%s", got)
+	}
+	if got := p.FormatPackageRefunded(&pkg, appt); !strings.Contains(got, "ВОЗВРАЩЁН") || !strings.Contains(got, "1 из 10") {
+		t.Errorf("FormatPackageRefunded unexpected output:\n%s", got)
+	}
+
+	if got := p.FormatPackageAlert(&pkg, domain.PackageAlertExpiry); !strings.Contains(got, "СКОРО ИСТЕКАЕТ") || !strings.Contains(got, "(100)") {
+		t.Errorf("FormatPackageAlert(expiry) unexpected output:\n%s", got)
+	}
+	if got := p.FormatPackageAlert(&pkg, domain.PackageAlertLowBalance); !strings.Contains(got, "ЗАКАНЧИВАЕТСЯ") {
+		t.Errorf("FormatPackageAlert(low balance) unexpected output:\n%s", got)
+	}
+}
+
+// --- Cancellation policy ---
+
+func TestBotPresenter_FormatCancellationPolicy(t *testing.T) {
+	p := NewBotPresenter()
+
+	if got := p.FormatCancellationPolicy(domain.DefaultCancellationPolicy()); !strings.Contains(got, "за 3 дня") || !strings.Contains(got, "только через терапевта") {
+		t.Errorf("unexpected blocking policy text: %s", got)
+	}
+	if got := p.FormatCancellationPolicy(domain.CancellationPolicy{NoticeHours: 12}); !strings.Contains(got, "за 12 часов") || !strings.Contains(got, "поздняя") {
+		t.Errorf("unexpected warning policy text: %s", got)
+	}
+	if got := p.FormatCancellationPolicy(domain.CancellationPolicy{}); got != "" {
+		t.Errorf("no window should mean no policy text, got %q", got)
+	}
+}
+
+func TestBotPresenter_FormatLateCancel(t *testing.T) {
+	p := NewBotPresenter()
+
+	if got := p.FormatLateCancelBlocked(domain.DefaultCancellationPolicy(), false); !strings.Contains(got, "меньше 3 дней") || !strings.Contains(got, "отмена невозможна") {
+		t.Errorf("unexpected block text: %s", got)
+	}
+	if got := p.FormatLateCancelBlocked(domain.CancellationPolicy{NoticeHours: 24}, true); !strings.Contains(got, "меньше суток") || !strings.Contains(got, "перенос невозможен") {
+		t.Errorf("unexpected reschedule block text: %s", got)
+	}
+
+	appt := &domain.Appointment{Service: domain.Service{Name: "Массаж"}, StartTime: time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)}
+	got := p.FormatLateCancelWarning(appt, domain.CancellationPolicy{NoticeHours: 21})
+	if !strings.Contains(got, "ПОЗДНЯЯ ОТМЕНА") || !strings.Contains(got, "меньше 21 часа") || !strings.Contains(got, "09.01.2030 в 12:00") {
+		t.Errorf("unexpected warning text: %s", got)
+	}
+}
+
+func TestRuPlural(t *testing.T) {
+	for n, want := range map[int]string{1: "день", 2: "дня", 4: "дня", 5: "дней", 11: "дней", 12: "дней", 21: "день", 22: "дня"} {
+		if got := ruPlural(n, "день", "дня", "дней"); got != want {
+			t.Errorf("ruPlural(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestBotPresenter_FormatOutcomeRequest(t *testing.T) {
+	p := NewBotPresenter()
+	appt := domain.Appointment{
+		CustomerName: "Иван",
+		Service:      domain.Service{Name: "Классический массаж"},
+		StartTime:    time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC),
+	}
+	got := p.FormatOutcomeRequest(&appt)
+	if !strings.Contains(got, "Иван") || !strings.Contains(got, "09.01.2030 в 12:00") {
+		t.Errorf("FormatOutcomeRequest unexpected output:\n%s", got)
+	}
+}
+
 // --- FormatNotification ---
 
 func TestBotPresenter_FormatNotification_Basic(t *testing.T) {
@@ -111,6 +267,8 @@ func TestBotPresenter_FormatBookingSummary_WithPrice(t *testing.T) {
 		date,
 		90,
 		2500,
+		"",
+		0,
 	)
 
 	checks := []struct {
@@ -138,13 +296,66 @@ func TestBotPresenter_FormatBookingSummary_ZeroPrice(t *testing.T) {
 	p := NewBotPresenter()
 	date := time.Date(2026, 7, 20, 11, 0, 0, 0, time.UTC)
 
-	got := p.FormatBookingSummary("Тест", "Пациент", "Услуга", date, 60, 0)
+	got := p.FormatBookingSummary("Тест", "Пациент", "Услуга", date, 60, 0, "", 0)
 
 	if strings.Contains(got, "Цена") {
 		t.Error("Zero price should not render price line")
 	}
 }
 
+func TestBotPresenter_FormatBookingSummary_WithPromo(t *testing.T) {
+	p := NewBotPresenter()
+	date := time.Date(2026, 7, 20, 11, 0, 0, 0, time.UTC)
+
+	got := p.FormatBookingSummary("Тест", "Пациент", "Услуга", date, 60, 2000, "SPRING10", 200)
+	for _, want := range []string{"<s>2000 ₺</s> 1800 ₺", "Промокод:</b> SPRING10 (−200 ₺)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatBookingSummary missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBotPresenter_FormatPromos(t *testing.T) {
+	p := NewBotPresenter()
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	promos := []domain.PromoCode{
+		{Code: "SPRING10", Kind: domain.PromoPercent, Value: 10, ValidFrom: from, ValidUntil: from.AddDate(0, 1, 0), Uses: 3},
+		{Code: "MINUS500", Kind: domain.PromoFixed, Value: 500, ServiceIDs: []string{"classic"}, ValidFrom: from, ValidUntil: from.AddDate(0, 1, 0), MaxUses: 2, Uses: 2},
+	}
+
+	got := p.FormatPromoCreated(&promos[1], "TRY")
+	for _, want := range []string{"<code>MINUS500</code>", "Скидка:</b> 500 ₺", "01.01.2030 — 31.01.2030", "Лимит:</b> 2", "Услуги:</b> classic"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPromoCreated missing %q in:\n%s", want, got)
+		}
+	}
+
+	got = p.FormatPromoList(promos, now, "TRY")
+	for _, want := range []string{"✅ <code>SPRING10</code> — 10%", "использован 3/∞", "⛔ <code>MINUS500</code>", "услуги: classic"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPromoList missing %q in:\n%s", want, got)
+		}
+	}
+	if got := p.FormatPromoList(nil, now, "TRY"); !strings.Contains(got, "/promo_add") {
+		t.Errorf("FormatPromoList(nil) = %q", got)
+	}
+
+	usages := []domain.PromoUsage{
+		{Code: "SPRING10", PatientID: "100", PatientName: "Иван", ServiceName: "Массаж", Discount: 200, UsedAt: now},
+		{Code: "SPRING10", PatientID: "200", PatientName: "Анна", ServiceName: "Массаж", Discount: 150, UsedAt: now},
+	}
+	got = p.FormatPromoUsages("SPRING10", usages, "TRY")
+	for _, want := range []string{"ПРОМОКОД SPRING10", "10.01.2030 — Иван (100), Массаж, −200 ₺", "Использований:</b> 2", "Скидки:</b> 350 ₺"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPromoUsages missing %q in:\n%s", want, got)
+		}
+	}
+	if got := p.FormatPromoUsages("NEW", nil, "TRY"); !strings.Contains(got, "не использовался") {
+		t.Errorf("FormatPromoUsages(nil) = %q", got)
+	}
+}
+
 // --- FormatAppointment with Duration ---
 
 func TestBotPresenter_FormatAppointment_WithDuration(t *testing.T) {
@@ -179,6 +390,25 @@ func TestBotPresenter_FormatAppointment_ZeroDuration(t *testing.T) {
 	}
 }
 
+func TestBotPresenter_FormatAppointment_WithPromo(t *testing.T) {
+	p := NewBotPresenter()
+	appt := &domain.Appointment{
+		CustomerName: "Тест",
+		Service:      domain.Service{Name: "Тест"},
+		StartTime:    time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		PromoCode:    "SPRING10",
+		Discount:     200,
+	}
+
+	if got := p.FormatAppointment(appt, true); !strings.Contains(got, "Промокод:</b> SPRING10 (−200 ₺)") {
+		t.Errorf("Expected the promo code in output, got:\n%s", got)
+	}
+	appt.PromoCode = ""
+	if got := p.FormatAppointment(appt, true); strings.Contains(got, "Промокод") {
+		t.Error("No promo code should not render promo line")
+	}
+}
+
 func TestBotPresenter_FormatAppointment_NoMeetLink(t *testing.T) {
 	p := NewBotPresenter()
 	appt := &domain.Appointment{
@@ -214,6 +444,19 @@ func TestBotPresenter_FormatPatientCard_EmptyNotes(t *testing.T) {
 	}
 }
 
+func TestBotPresenter_FormatPatientCard_NoShows(t *testing.T) {
+	p := NewBotPresenter()
+	patient := domain.Patient{TelegramID: "777", Name: "Пациент", TotalVisits: 5}
+
+	if got := p.FormatPatientCard(patient); strings.Contains(got, "НЕЯВОК") {
+		t.Error("No-show line should be hidden when there are none")
+	}
+	patient.NoShows = 2
+	if got := p.FormatPatientCard(patient); !strings.Contains(got, "НЕЯВОК:</b> 2") {
+		t.Errorf("Expected no-show count in card:\n%s", got)
+	}
+}
+
 func TestBotPresenter_FormatPatientCard_LongNotes(t *testing.T) {
 	p := NewBotPresenter()
 	// Notes longer than 500 chars should be truncated
@@ -236,3 +479,90 @@ func TestBotPresenter_FormatPatientCard_LongNotes(t *testing.T) {
 		t.Error("Notes should be truncated to 500 chars")
 	}
 }
+
+func TestBotPresenter_FormatCalendarChange(t *testing.T) {
+	p := NewBotPresenter()
+	start := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+	prev := domain.Appointment{
+		CustomerName: "Иван Петров",
+		Service:      domain.Service{Name: "Классический массаж"},
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+	}
+	moved := domain.CalendarChange{Kind: domain.ChangeMoved, Previous: prev, Current: prev}
+	moved.Current.EndTime = start.Add(30 * time.Minute)
+
+	got := p.FormatCalendarChange(moved)
+	for _, want := range []string{"ВАША ЗАПИСЬ ИЗМЕНЕНА", "15.03.2026 14:30–15:30", "15.03.2026 14:30–15:00", "терапевтом"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCalendarChange(moved) missing %q in:\n%s", want, got)
+		}
+	}
+
+	got = p.FormatCalendarChange(domain.CalendarChange{Kind: domain.ChangeCancelled, Previous: prev})
+	for _, want := range []string{"ВАША ЗАПИСЬ ОТМЕНЕНА", "15.03.2026 в 14:30", "/start"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCalendarChange(cancelled) missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBotPresenter_FormatCalendarConflict(t *testing.T) {
+	p := NewBotPresenter()
+	start := time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC)
+	prev := domain.Appointment{CustomerName: "Иван Петров", CustomerTgID: "42", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	got := p.FormatCalendarConflict(domain.CalendarChange{Kind: domain.ChangeCancelled, Previous: prev}, []string{"Пациента не удалось уведомить"})
+	for _, want := range []string{"КОНФЛИКТ", "Иван Петров (42)", "событие удалено", "• Пациента не удалось уведомить"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCalendarConflict missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestBotPresenter_FormatPayments(t *testing.T) {
+	p := NewBotPresenter()
+	visit := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+
+	pay := &domain.Payment{PatientID: "42", PatientName: "Анна", ServiceName: "Массаж", Amount: 1800, Discount: 200,
+		Currency: "TRY", Method: domain.PaymentCard, VisitTime: visit}
+	got := p.FormatPaymentRecorded(pay)
+	for _, want := range []string{"Анна (42)", "1800 ₺ (Карта)", "Скидка:</b> 200 ₺", "09.01.2030 в 10:00"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatPaymentRecorded missing %q in:\n%s", want, got)
+		}
+	}
+
+	visits := []domain.Appointment{
+		{ID: "a1", CustomerName: "Анна", Service: domain.Service{Name: "Массаж", Price: 2000}, StartTime: visit},
+		{ID: "a2", CustomerName: "Борис", Service: domain.Service{Name: "Массаж", Price: 1500}, StartTime: visit.Add(2 * time.Hour)},
+	}
+	got = p.FormatUnpaidVisits(visits, "EUR")
+	for _, want := range []string{"/paid a1 нал", "Борис", "К оплате:</b> 3500 €"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatUnpaidVisits missing %q in:\n%s", want, got)
+		}
+	}
+	if got := p.FormatUnpaidVisits(nil, "TRY"); !strings.Contains(got, "нет") {
+		t.Errorf("FormatUnpaidVisits(nil) = %q", got)
+	}
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	report := domain.BuildRevenueReport([]domain.Payment{
+		{ServiceName: "Массаж", Amount: 1800, Discount: 200, Method: domain.PaymentCard, PaidAt: visit},
+	}, []domain.SessionPackage{{Price: 9000, PurchasedAt: visit}}, from, from.AddDate(0, 1, 0), domain.RevenueWeek, "TRY", time.UTC)
+	got = p.FormatRevenueReport(&report)
+	for _, want := range []string{"01.01.2030 — 31.01.2030", "Итого:</b> 10800 ₺", "Абонементов продано:</b> 1 на 9000 ₺", "неделя с 07.01", "Массаж — 1800 ₺ (1)", "Карта — 1800 ₺"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatRevenueReport missing %q in:\n%s", want, got)
+		}
+	}
+	empty := domain.BuildRevenueReport(nil, nil, from, from.AddDate(0, 1, 0), domain.RevenueWeek, "TRY", time.UTC)
+	if got := p.FormatRevenueReport(&empty); !strings.Contains(got, "Оплат за этот период нет") {
+		t.Errorf("FormatRevenueReport(empty) unexpected output:\n%s", got)
+	}
+
+	if FormatMoney(5, "GBP") != "5 GBP" {
+		t.Errorf("FormatMoney(GBP) = %q", FormatMoney(5, "GBP"))
+	}
+}
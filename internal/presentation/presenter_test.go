@@ -105,6 +105,8 @@ func TestWebPresenter_RenderCard(t *testing.T) {
 		Title        string
 		BotVersion   string
 		Patient      domain.Patient
+		Packages     []domain.SessionPackage
+		Balance      string
 		RecentVisits []interface{}
 		Drafts       []interface{}
 		DocGroups    []interface{}
@@ -112,6 +114,7 @@ func TestWebPresenter_RenderCard(t *testing.T) {
 		Title:      "Med Card",
 		BotVersion: "v1.0",
 		Patient:    domain.Patient{Name: "Alice"},
+		Balance:    "2000 ₺",
 	}
 
 	var buf bytes.Buffer
@@ -127,6 +130,9 @@ func TestWebPresenter_RenderCard(t *testing.T) {
 	if !strings.Contains(got, "МЕДИЦИНСКАЯ КАРТА") {
 		t.Error("Expected title in HTML")
 	}
+	if !strings.Contains(got, "К ОПЛАТЕ") || !strings.Contains(got, "2000 ₺") {
+		t.Error("Expected outstanding balance in HTML")
+	}
 }
 
 func TestWebPresenter_RenderSearch(t *testing.T) {
@@ -32,6 +32,9 @@ func (m *MockRepoForCache) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
+func (m *MockRepoForCache) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	return appt, nil
+}
 func (m *MockRepoForCache) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
 	return nil, nil
 }
@@ -53,7 +56,7 @@ func TestService_FreeBusyCache(t *testing.T) {
 	ctx := context.Background()
 
 	// Setup time range
-	now := time.Now()
+	now := nextWorkday(time.Now())
 	testDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 	// Adjust logic to match service expectations (GetAvailableTimeSlots calculates min/max internally based on date)
 	// We'll trust the service calls the repo with *some* range.
@@ -84,7 +87,7 @@ func TestService_CacheInvalidation_OnCreate(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now()
 	// Use tomorrow for both warm-up and creation to ensures cache hit and valid future appointment
-	tomorrow := now.Add(24 * time.Hour)
+	tomorrow := nextWorkday(now)
 	testDate := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC)
 
 	// 1. Fill Cache (Expect 1 call)
@@ -97,7 +100,7 @@ func TestService_CacheInvalidation_OnCreate(t *testing.T) {
 	newAppt := &domain.Appointment{
 		ID:           "new",
 		Service:      domain.Service{ID: "1", DurationMinutes: 60},
-		StartTime:    time.Date(now.Year(), now.Month(), now.Day()+1, 10, 0, 0, 0, time.UTC),
+		StartTime:    time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 10, 0, 0, 0, time.UTC),
 		Duration:     60,
 		CustomerName: "Test",
 	}
@@ -0,0 +1,22 @@
+package appointment
+
+import "github.com/kfilin/massage-bot/internal/domain"
+
+// SetCancellationPolicy changes the notice window for self-service
+// cancellation and whether late cancellations are blocked.
+func (s *Service) SetCancellationPolicy(policy domain.CancellationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	s.cancelPolicyMu.Lock()
+	s.cancelPolicy = policy
+	s.cancelPolicyMu.Unlock()
+	return nil
+}
+
+// GetCancellationPolicy returns the policy patients' cancellations follow.
+func (s *Service) GetCancellationPolicy() domain.CancellationPolicy {
+	s.cancelPolicyMu.RLock()
+	defer s.cancelPolicyMu.RUnlock()
+	return s.cancelPolicy
+}
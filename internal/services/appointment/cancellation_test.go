@@ -0,0 +1,30 @@
+package appointment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestSetCancellationPolicy(t *testing.T) {
+	svc := NewService(newMockRepo(), nil)
+	if got := svc.GetCancellationPolicy(); got != domain.DefaultCancellationPolicy() {
+		t.Errorf("expected the default policy, got %+v", got)
+	}
+
+	if err := svc.SetCancellationPolicy(domain.CancellationPolicy{NoticeHours: -1}); !errors.Is(err, domain.ErrInvalidCancellationPolicy) {
+		t.Errorf("expected ErrInvalidCancellationPolicy, got %v", err)
+	}
+	if got := svc.GetCancellationPolicy(); got != domain.DefaultCancellationPolicy() {
+		t.Errorf("an invalid policy must not be applied, got %+v", got)
+	}
+
+	want := domain.CancellationPolicy{NoticeHours: 24}
+	if err := svc.SetCancellationPolicy(want); err != nil {
+		t.Fatalf("SetCancellationPolicy failed: %v", err)
+	}
+	if got := svc.GetCancellationPolicy(); got != want {
+		t.Errorf("GetCancellationPolicy() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,254 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// defaultServices is the built-in catalog. It seeds an empty services table
+// and is served directly when no catalog repository is configured.
+var defaultServices = []domain.Service{
+	{ID: "1", Name: "Массаж Спина + Шея", DurationMinutes: 40, Price: 2000.00, CategoryID: "massages", SortOrder: 1},
+	{ID: "2", Name: "Общий массаж", DurationMinutes: 60, Price: 2800.00, CategoryID: "massages", SortOrder: 2},
+	{ID: "3", Name: "Лимфодренаж", DurationMinutes: 50, Price: 2400.00, CategoryID: "massages", SortOrder: 3},
+	{ID: "4", Name: "Иглоукалывание", DurationMinutes: 30, Price: 1400.00, CategoryID: "other", SortOrder: 4},
+	{ID: "5", Name: "Консультация офлайн", DurationMinutes: 60, Price: 2000.00, CategoryID: "consultations", SortOrder: 5},
+	{ID: "6", Name: "Консультация онлайн", DurationMinutes: 45, Price: 1500.00, CategoryID: "consultations", SortOrder: 6},
+	{ID: "7", Name: "Реабилитационные программы", DurationMinutes: 60, Price: 13000.00, Description: "от 13000 ₺ в месяц", CategoryID: "other", SortOrder: 7},
+}
+
+// defaultCategories mirrors the three menu sections the bot always had.
+var defaultCategories = []domain.ServiceCategory{
+	{ID: "massages", Label: "Массаж", Icon: "💆", SortOrder: 1},
+	{ID: "consultations", Label: "Консультация", Icon: "👥", SortOrder: 2},
+	{ID: domain.DefaultServiceCategoryID, Label: "Другие услуги", Icon: "✨", SortOrder: 3},
+}
+
+// maxServiceDurationMinutes caps a single service at one working day.
+const maxServiceDurationMinutes = 8 * 60
+
+// SetServiceCatalog switches the service to the persisted catalog.
+func (s *Service) SetServiceCatalog(catalog ports.ServiceCatalogRepository) {
+	s.catalog = catalog
+}
+
+// SeedServiceCatalog writes defaultCategories and defaultServices into empty
+// tables so a fresh database starts with the same menu the bot always offered.
+func (s *Service) SeedServiceCatalog(ctx context.Context) error {
+	if s.catalog == nil {
+		return nil
+	}
+
+	categories, err := s.catalog.ListCategories()
+	if err != nil {
+		return fmt.Errorf("failed to check service categories: %w", err)
+	}
+	if len(categories) == 0 {
+		for _, cat := range defaultCategories {
+			if err := s.catalog.SaveCategory(cat); err != nil {
+				return fmt.Errorf("failed to seed category %s: %w", cat.ID, err)
+			}
+		}
+	}
+
+	existing, err := s.catalog.ListServices(true)
+	if err != nil {
+		return fmt.Errorf("failed to check service catalog: %w", err)
+	}
+	if len(existing) > 0 {
+		return nil
+	}
+	for _, svc := range defaultServices {
+		if err := s.catalog.SaveService(svc); err != nil {
+			return fmt.Errorf("failed to seed service %s: %w", svc.ID, err)
+		}
+	}
+	logging.Infof("Service catalog seeded with %d default services.", len(defaultServices))
+	return nil
+}
+
+// GetAvailableServices returns the bookable (non-archived) services in menu order.
+func (s *Service) GetAvailableServices(ctx context.Context) ([]domain.Service, error) {
+	if s.catalog == nil {
+		services := make([]domain.Service, len(defaultServices))
+		copy(services, defaultServices)
+		logging.Debugf("DEBUG: GetAvailableServices returned %d default services.", len(services))
+		return services, nil
+	}
+
+	services, err := s.catalog.ListServices(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service catalog: %w", err)
+	}
+	sortServices(services)
+	logging.Debugf("DEBUG: GetAvailableServices returned %d services.", len(services))
+	return services, nil
+}
+
+// GetAllServices returns the whole catalog including archived entries (admin view).
+func (s *Service) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	if s.catalog == nil {
+		return s.GetAvailableServices(ctx)
+	}
+	services, err := s.catalog.ListServices(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service catalog: %w", err)
+	}
+	sortServices(services)
+	return services, nil
+}
+
+// SaveService validates and stores a catalog entry. An empty ID creates a new
+// service with the next numeric ID (kept short for Telegram callback data)
+// placed at the end of the menu.
+func (s *Service) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	if s.catalog == nil {
+		return nil, domain.ErrCatalogUnavailable
+	}
+
+	svc.Name = strings.TrimSpace(svc.Name)
+	svc.Description = strings.TrimSpace(svc.Description)
+	svc.CategoryID = strings.TrimSpace(svc.CategoryID)
+	if svc.Name == "" || svc.DurationMinutes <= 0 || svc.DurationMinutes > maxServiceDurationMinutes || svc.Price < 0 {
+		return nil, domain.ErrInvalidService
+	}
+
+	if svc.ID == "" {
+		existing, err := s.catalog.ListServices(true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load service catalog: %w", err)
+		}
+		maxID, maxOrder := 0, 0
+		for _, e := range existing {
+			if n, err := strconv.Atoi(e.ID); err == nil && n > maxID {
+				maxID = n
+			}
+			if e.SortOrder > maxOrder {
+				maxOrder = e.SortOrder
+			}
+		}
+		svc.ID = strconv.Itoa(maxID + 1)
+		if svc.SortOrder == 0 {
+			svc.SortOrder = maxOrder + 1
+		}
+	} else if svc.SortOrder == 0 {
+		// Editing without an explicit position keeps the current one.
+		if current, err := s.catalog.GetService(svc.ID); err == nil {
+			svc.SortOrder = current.SortOrder
+		}
+	}
+
+	if err := s.catalog.SaveService(svc); err != nil {
+		return nil, err
+	}
+	logging.Infof("Service %s saved: %s (%d мин, %.0f)", svc.ID, svc.Name, svc.DurationMinutes, svc.Price)
+	return &svc, nil
+}
+
+// SetServiceArchived hides a service from booking (or restores it).
+// Existing appointments keep the service snapshot they were booked with.
+func (s *Service) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	if s.catalog == nil {
+		return domain.ErrCatalogUnavailable
+	}
+	if id == "" {
+		return domain.ErrInvalidID
+	}
+	return s.catalog.SetServiceArchived(id, archived)
+}
+
+// ReorderServices sets the menu order to follow orderedIDs.
+func (s *Service) ReorderServices(ctx context.Context, orderedIDs []string) error {
+	if s.catalog == nil {
+		return domain.ErrCatalogUnavailable
+	}
+	if len(orderedIDs) == 0 {
+		return domain.ErrInvalidID
+	}
+	return s.catalog.ReorderServices(orderedIDs)
+}
+
+// GetServiceCategories returns the menu categories in display order. Unless
+// includeEmpty is set, categories without a bookable service are dropped.
+// Services pointing at an unknown category get a category named after their
+// key, so a typo never hides a service from the menu.
+func (s *Service) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	categories := make([]domain.ServiceCategory, len(defaultCategories))
+	copy(categories, defaultCategories)
+	if s.catalog != nil {
+		stored, err := s.catalog.ListCategories()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load service categories: %w", err)
+		}
+		categories = stored
+	}
+
+	services, err := s.GetAvailableServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(categories))
+	for _, cat := range categories {
+		known[cat.ID] = true
+	}
+	used := make(map[string]bool)
+	maxOrder := 0
+	for _, cat := range categories {
+		if cat.SortOrder > maxOrder {
+			maxOrder = cat.SortOrder
+		}
+	}
+	for _, svc := range services {
+		id := svc.Category()
+		used[id] = true
+		if !known[id] {
+			maxOrder++
+			known[id] = true
+			categories = append(categories, domain.ServiceCategory{ID: id, Label: id, SortOrder: maxOrder})
+		}
+	}
+
+	result := make([]domain.ServiceCategory, 0, len(categories))
+	for _, cat := range categories {
+		if includeEmpty || used[cat.ID] {
+			result = append(result, cat)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].SortOrder < result[j].SortOrder
+	})
+	return result, nil
+}
+
+// SaveServiceCategory creates or updates a menu category.
+func (s *Service) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	if s.catalog == nil {
+		return domain.ErrCatalogUnavailable
+	}
+	cat.ID = strings.TrimSpace(cat.ID)
+	cat.Label = strings.TrimSpace(cat.Label)
+	cat.Icon = strings.TrimSpace(cat.Icon)
+	// The ID travels in "select_category|<id>" callback data (64 bytes max);
+	// "back" is reserved for the menu's back button.
+	if cat.ID == "" || cat.Label == "" || len(cat.ID) > 32 || strings.Contains(cat.ID, "|") || cat.ID == "back" {
+		return domain.ErrInvalidService
+	}
+	return s.catalog.SaveCategory(cat)
+}
+
+func sortServices(services []domain.Service) {
+	sort.SliceStable(services, func(i, j int) bool {
+		if services[i].SortOrder != services[j].SortOrder {
+			return services[i].SortOrder < services[j].SortOrder
+		}
+		return services[i].Name < services[j].Name
+	})
+}
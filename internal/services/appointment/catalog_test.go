@@ -0,0 +1,337 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// mockCatalog is an in-memory ports.ServiceCatalogRepository.
+type mockCatalog struct {
+	services   map[string]domain.Service
+	categories []domain.ServiceCategory
+	listErr    error
+}
+
+func newMockCatalog(services ...domain.Service) *mockCatalog {
+	m := &mockCatalog{services: make(map[string]domain.Service)}
+	for _, s := range services {
+		m.services[s.ID] = s
+	}
+	return m
+}
+
+func (m *mockCatalog) ListServices(includeArchived bool) ([]domain.Service, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	var out []domain.Service
+	for _, s := range m.services {
+		if s.Archived && !includeArchived {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m *mockCatalog) GetService(id string) (*domain.Service, error) {
+	s, ok := m.services[id]
+	if !ok {
+		return nil, domain.ErrServiceNotFound
+	}
+	return &s, nil
+}
+
+func (m *mockCatalog) SaveService(svc domain.Service) error {
+	m.services[svc.ID] = svc
+	return nil
+}
+
+func (m *mockCatalog) SetServiceArchived(id string, archived bool) error {
+	s, ok := m.services[id]
+	if !ok {
+		return domain.ErrServiceNotFound
+	}
+	s.Archived = archived
+	m.services[id] = s
+	return nil
+}
+
+func (m *mockCatalog) ReorderServices(orderedIDs []string) error {
+	for i, id := range orderedIDs {
+		s, ok := m.services[id]
+		if !ok {
+			return domain.ErrServiceNotFound
+		}
+		s.SortOrder = i + 1
+		m.services[id] = s
+	}
+	return nil
+}
+
+func (m *mockCatalog) ListCategories() ([]domain.ServiceCategory, error) {
+	return m.categories, nil
+}
+
+func (m *mockCatalog) SaveCategory(cat domain.ServiceCategory) error {
+	for i, c := range m.categories {
+		if c.ID == cat.ID {
+			m.categories[i] = cat
+			return nil
+		}
+	}
+	m.categories = append(m.categories, cat)
+	return nil
+}
+
+func TestGetAvailableServices_DefaultsWithoutCatalog(t *testing.T) {
+	svc := NewService(newMockRepo(), nil)
+
+	services, err := svc.GetAvailableServices(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailableServices failed: %v", err)
+	}
+	if len(services) != len(defaultServices) {
+		t.Fatalf("Expected %d default services, got %d", len(defaultServices), len(services))
+	}
+
+	// Callers must not be able to mutate the shared defaults.
+	services[0].Name = "changed"
+	if defaultServices[0].Name == "changed" {
+		t.Error("GetAvailableServices leaked the defaultServices slice")
+	}
+}
+
+func TestGetAvailableServices_FromCatalog(t *testing.T) {
+	catalog := newMockCatalog(
+		domain.Service{ID: "1", Name: "B", DurationMinutes: 30, SortOrder: 2},
+		domain.Service{ID: "2", Name: "A", DurationMinutes: 60, SortOrder: 1},
+		domain.Service{ID: "3", Name: "Archived", DurationMinutes: 60, SortOrder: 3, Archived: true},
+	)
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+
+	services, err := svc.GetAvailableServices(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailableServices failed: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 bookable services, got %d", len(services))
+	}
+	if services[0].ID != "2" || services[1].ID != "1" {
+		t.Errorf("Expected sort order [2 1], got [%s %s]", services[0].ID, services[1].ID)
+	}
+
+	all, _ := svc.GetAllServices(context.Background())
+	if len(all) != 3 {
+		t.Errorf("Expected 3 services incl. archived, got %d", len(all))
+	}
+}
+
+func TestGetAvailableServices_CatalogError(t *testing.T) {
+	catalog := newMockCatalog()
+	catalog.listErr = errors.New("db down")
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+
+	if _, err := svc.GetAvailableServices(context.Background()); err == nil {
+		t.Error("Expected error when catalog fails")
+	}
+}
+
+func TestSeedServiceCatalog(t *testing.T) {
+	catalog := newMockCatalog()
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+
+	if err := svc.SeedServiceCatalog(context.Background()); err != nil {
+		t.Fatalf("SeedServiceCatalog failed: %v", err)
+	}
+	if len(catalog.services) != len(defaultServices) {
+		t.Fatalf("Expected %d seeded services, got %d", len(defaultServices), len(catalog.services))
+	}
+	if len(catalog.categories) != len(defaultCategories) {
+		t.Fatalf("Expected %d seeded categories, got %d", len(defaultCategories), len(catalog.categories))
+	}
+
+	// A second run must not overwrite admin edits.
+	edited := catalog.services["1"]
+	edited.Price = 9999
+	catalog.services["1"] = edited
+	if err := svc.SeedServiceCatalog(context.Background()); err != nil {
+		t.Fatalf("SeedServiceCatalog failed: %v", err)
+	}
+	if catalog.services["1"].Price != 9999 {
+		t.Error("Seeding overwrote an existing catalog")
+	}
+}
+
+func TestSaveService(t *testing.T) {
+	catalog := newMockCatalog(
+		domain.Service{ID: "1", Name: "A", DurationMinutes: 30, Price: 100, SortOrder: 1},
+		domain.Service{ID: "7", Name: "B", DurationMinutes: 30, Price: 100, SortOrder: 4},
+	)
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		input   domain.Service
+		wantErr error
+	}{
+		{"missing name", domain.Service{DurationMinutes: 30}, domain.ErrInvalidService},
+		{"zero duration", domain.Service{Name: "X"}, domain.ErrInvalidService},
+		{"negative price", domain.Service{Name: "X", DurationMinutes: 30, Price: -1}, domain.ErrInvalidService},
+		{"too long", domain.Service{Name: "X", DurationMinutes: 9 * 60}, domain.ErrInvalidService},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.SaveService(ctx, tt.input); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	created, err := svc.SaveService(ctx, domain.Service{Name: "  New  ", DurationMinutes: 45, Price: 1500})
+	if err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	if created.ID != "8" || created.SortOrder != 5 || created.Name != "New" {
+		t.Errorf("Unexpected new service: %+v", created)
+	}
+
+	// Editing keeps the current position when none is given.
+	updated, err := svc.SaveService(ctx, domain.Service{ID: "7", Name: "B2", DurationMinutes: 60, Price: 200})
+	if err != nil {
+		t.Fatalf("SaveService (edit) failed: %v", err)
+	}
+	if updated.SortOrder != 4 || catalog.services["7"].Name != "B2" {
+		t.Errorf("Unexpected edited service: %+v", catalog.services["7"])
+	}
+}
+
+func TestCatalogMutations_RequireCatalog(t *testing.T) {
+	svc := NewService(newMockRepo(), nil)
+	ctx := context.Background()
+
+	if _, err := svc.SaveService(ctx, domain.Service{Name: "X", DurationMinutes: 30}); !errors.Is(err, domain.ErrCatalogUnavailable) {
+		t.Errorf("SaveService: expected ErrCatalogUnavailable, got %v", err)
+	}
+	if err := svc.SetServiceArchived(ctx, "1", true); !errors.Is(err, domain.ErrCatalogUnavailable) {
+		t.Errorf("SetServiceArchived: expected ErrCatalogUnavailable, got %v", err)
+	}
+	if err := svc.ReorderServices(ctx, []string{"1"}); !errors.Is(err, domain.ErrCatalogUnavailable) {
+		t.Errorf("ReorderServices: expected ErrCatalogUnavailable, got %v", err)
+	}
+}
+
+func TestArchiveAndReorderServices(t *testing.T) {
+	catalog := newMockCatalog(
+		domain.Service{ID: "1", Name: "A", DurationMinutes: 30, SortOrder: 1},
+		domain.Service{ID: "2", Name: "B", DurationMinutes: 30, SortOrder: 2},
+	)
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+	ctx := context.Background()
+
+	if err := svc.SetServiceArchived(ctx, "1", true); err != nil {
+		t.Fatalf("SetServiceArchived failed: %v", err)
+	}
+	available, _ := svc.GetAvailableServices(ctx)
+	if len(available) != 1 || available[0].ID != "2" {
+		t.Errorf("Archived service still bookable: %+v", available)
+	}
+
+	if err := svc.ReorderServices(ctx, []string{"2", "1"}); err != nil {
+		t.Fatalf("ReorderServices failed: %v", err)
+	}
+	if catalog.services["2"].SortOrder != 1 || catalog.services["1"].SortOrder != 2 {
+		t.Errorf("Unexpected order: %+v", catalog.services)
+	}
+
+	if err := svc.ReorderServices(ctx, nil); !errors.Is(err, domain.ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID for empty order, got %v", err)
+	}
+}
+
+func TestGetServiceCategories_DefaultsWithoutCatalog(t *testing.T) {
+	svc := NewService(newMockRepo(), nil)
+
+	categories, err := svc.GetServiceCategories(context.Background(), false)
+	if err != nil {
+		t.Fatalf("GetServiceCategories failed: %v", err)
+	}
+	if len(categories) != 3 || categories[0].ID != "massages" || categories[2].ID != domain.DefaultServiceCategoryID {
+		t.Errorf("Unexpected default categories: %+v", categories)
+	}
+}
+
+func TestGetServiceCategories_HidesEmptyAndAddsUnknown(t *testing.T) {
+	catalog := newMockCatalog(
+		domain.Service{ID: "1", Name: "Массаж", DurationMinutes: 60, CategoryID: "massages", SortOrder: 1},
+		domain.Service{ID: "2", Name: "SPA", DurationMinutes: 60, CategoryID: "spa", SortOrder: 2},
+		domain.Service{ID: "3", Name: "Без категории", DurationMinutes: 30, SortOrder: 3},
+		domain.Service{ID: "4", Name: "Архив", DurationMinutes: 30, CategoryID: "consultations", Archived: true},
+	)
+	catalog.categories = []domain.ServiceCategory{
+		{ID: "other", Label: "Другие", SortOrder: 3},
+		{ID: "massages", Label: "Массаж", Icon: "💆", SortOrder: 1},
+		{ID: "consultations", Label: "Консультация", SortOrder: 2},
+	}
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+
+	categories, err := svc.GetServiceCategories(context.Background(), false)
+	if err != nil {
+		t.Fatalf("GetServiceCategories failed: %v", err)
+	}
+	var ids []string
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	// consultations only has an archived service, so it is hidden;
+	// "spa" has no category row but its service must stay reachable.
+	want := []string{"massages", "other", "spa"}
+	if len(ids) != len(want) {
+		t.Fatalf("Expected categories %v, got %v", want, ids)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("Expected categories %v, got %v", want, ids)
+		}
+	}
+
+	all, _ := svc.GetServiceCategories(context.Background(), true)
+	if len(all) != 4 {
+		t.Errorf("Expected 4 categories with includeEmpty, got %d", len(all))
+	}
+}
+
+func TestSaveServiceCategory(t *testing.T) {
+	catalog := newMockCatalog()
+	svc := NewService(newMockRepo(), nil)
+	svc.SetServiceCatalog(catalog)
+	ctx := context.Background()
+
+	if err := svc.SaveServiceCategory(ctx, domain.ServiceCategory{ID: " spa ", Label: "SPA", Icon: "🧖"}); err != nil {
+		t.Fatalf("SaveServiceCategory failed: %v", err)
+	}
+	if len(catalog.categories) != 1 || catalog.categories[0].ID != "spa" {
+		t.Errorf("Unexpected categories: %+v", catalog.categories)
+	}
+
+	for _, bad := range []domain.ServiceCategory{
+		{ID: "", Label: "X"},
+		{ID: "x", Label: ""},
+		{ID: "a|b", Label: "X"},
+		{ID: "back", Label: "X"},
+	} {
+		if err := svc.SaveServiceCategory(ctx, bad); !errors.Is(err, domain.ErrInvalidService) {
+			t.Errorf("Expected ErrInvalidService for %+v, got %v", bad, err)
+		}
+	}
+}
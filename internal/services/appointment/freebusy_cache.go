@@ -0,0 +1,279 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// FreeBusy results are cached per calendar and day, so a range request is
+// answered from whatever days are already known and only the missing days
+// are fetched (in one request). Bookings drop just the days they touch, and
+// StartCacheWarmer keeps the coming days fetched before anyone asks.
+
+// freeBusyEntry holds the busy intervals of one calendar day.
+type freeBusyEntry struct {
+	calendarID string
+	day        time.Time // Midnight the day starts at
+	slots      []domain.TimeSlot
+	expiresAt  time.Time
+}
+
+const cacheTTL = 2 * time.Minute
+
+// cacheWarmInterval is how often the warmer refetches; shorter than
+// cacheTTL so warmed days never expire in between.
+const cacheWarmInterval = cacheTTL / 2
+
+// freeBusyDays returns the midnights (in timeMin's location) of every day
+// [timeMin, timeMax) touches.
+func freeBusyDays(timeMin, timeMax time.Time) []time.Time {
+	day := time.Date(timeMin.Year(), timeMin.Month(), timeMin.Day(), 0, 0, 0, 0, timeMin.Location())
+	var days []time.Time
+	for ; day.Before(timeMax); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// freeBusyKey identifies a cached day of one calendar.
+func freeBusyKey(cal ports.AppointmentRepository, day time.Time) string {
+	return fmt.Sprintf("%s|%s", cal.GetCalendarID(), day.Format(time.RFC3339))
+}
+
+// getFreeBusy retrieves busy slots of one calendar from cache or repository
+func (s *Service) getFreeBusy(ctx context.Context, cal ports.AppointmentRepository, timeMin, timeMax time.Time) ([]domain.TimeSlot, error) {
+	days := freeBusyDays(timeMin, timeMax)
+	now := s.NowFunc()
+
+	var busy []domain.TimeSlot
+	var missing []time.Time
+	s.fbCacheMu.RLock()
+	for _, day := range days {
+		entry, found := s.fbCache[freeBusyKey(cal, day)]
+		hit := found && time.Now().Before(entry.expiresAt)
+		s.metrics.RecordFreeBusyDayLookup(dayBucket(day, now), hit)
+		if hit {
+			busy = append(busy, entry.slots...)
+		} else {
+			missing = append(missing, day)
+		}
+	}
+	s.fbCacheMu.RUnlock()
+
+	if len(missing) == 0 {
+		s.metrics.RecordFreeBusyCacheHit()
+		logging.Debugf("DEBUG: FreeBusy cache HIT for %s, %d days from %s", cal.GetCalendarID(), len(days), timeMin.Format("2006-01-02"))
+		return busyWithin(busy, timeMin, timeMax), nil
+	}
+
+	s.metrics.RecordFreeBusyCacheMiss()
+	logging.Debugf("DEBUG: FreeBusy cache MISS for %s, %d of %d days from %s", cal.GetCalendarID(), len(missing), len(days), timeMin.Format("2006-01-02"))
+
+	// One request covers every missing day; known days in between are
+	// simply refreshed
+	from, to := missing[0], missing[len(missing)-1].AddDate(0, 0, 1)
+	fetched, err := s.fetchFreeBusy(ctx, cal, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, slot := range busy {
+		if slot.End.After(from) && slot.Start.Before(to) {
+			continue // Refetched
+		}
+		fetched = append(fetched, slot)
+	}
+	return busyWithin(fetched, timeMin, timeMax), nil
+}
+
+// fetchFreeBusy reads [from, to) from the calendar, where both are
+// midnights, and caches the result day by day. The result is not cached
+// if the cache was invalidated while the request was in flight.
+func (s *Service) fetchFreeBusy(ctx context.Context, cal ports.AppointmentRepository, from, to time.Time) ([]domain.TimeSlot, error) {
+	s.fbCacheMu.RLock()
+	generation := s.fbGeneration
+	s.fbCacheMu.RUnlock()
+
+	busy, err := cal.GetFreeBusy(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(cacheTTL)
+	s.fbCacheMu.Lock()
+	if s.fbGeneration == generation {
+		for _, day := range freeBusyDays(from, to) {
+			s.fbCache[freeBusyKey(cal, day)] = freeBusyEntry{
+				calendarID: cal.GetCalendarID(),
+				day:        day,
+				slots:      busyWithin(busy, day, day.AddDate(0, 0, 1)),
+				expiresAt:  expiresAt,
+			}
+		}
+	}
+	s.fbCacheMu.Unlock()
+	return busy, nil
+}
+
+// busyWithin returns the busy intervals overlapping [from, to), sorted and
+// without the duplicates left by intervals cached on two days.
+func busyWithin(busy []domain.TimeSlot, from, to time.Time) []domain.TimeSlot {
+	out := make([]domain.TimeSlot, 0, len(busy))
+	seen := make(map[[2]int64]bool, len(busy))
+	for _, slot := range busy {
+		key := [2]int64{slot.Start.UnixNano(), slot.End.UnixNano()}
+		if seen[key] || !slot.End.After(from) || !slot.Start.Before(to) {
+			continue
+		}
+		seen[key] = true
+		out = append(out, slot)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// dayBucket groups a cached day by how far ahead of now it is, for the
+// per-bucket hit and miss counters.
+func dayBucket(day, now time.Time) string {
+	now = now.In(day.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, day.Location())
+	switch ahead := int(math.Round(day.Sub(today).Hours() / 24)); {
+	case ahead < 0:
+		return "past"
+	case ahead == 0:
+		return "today"
+	case ahead == 1:
+		return "tomorrow"
+	case ahead <= 7:
+		return "week"
+	case ahead <= 31:
+		return "month"
+	default:
+		return "later"
+	}
+}
+
+// forgetFreeBusy drops the cached days of one calendar in [timeMin, timeMax).
+func (s *Service) forgetFreeBusy(cal ports.AppointmentRepository, timeMin, timeMax time.Time) {
+	s.fbCacheMu.Lock()
+	for _, day := range freeBusyDays(timeMin, timeMax) {
+		delete(s.fbCache, freeBusyKey(cal, day))
+	}
+	s.fbCacheMu.Unlock()
+}
+
+// invalidateSlots drops what a booking change on cal affects, see
+// InvalidateCalendar.
+func (s *Service) invalidateSlots(cal ports.AppointmentRepository, spans ...domain.TimeSlot) {
+	s.InvalidateCalendar(cal.GetCalendarID(), spans...)
+}
+
+// InvalidateCalendar drops what a change to the calendar's events affects:
+// the cached days of that calendar touched by spans (widened by the buffers
+// between clients) and the month overviews of those months. Days of other
+// calendars and other months stay cached.
+func (s *Service) InvalidateCalendar(calendarID string, spans ...domain.TimeSlot) {
+	gap := s.getSlotPolicy().Gap()
+
+	s.fbCacheMu.Lock()
+	s.fbGeneration++
+	dropped := 0
+	for key, entry := range s.fbCache {
+		if entry.calendarID != calendarID {
+			continue
+		}
+		for _, span := range spans {
+			if entry.day.Before(span.End.Add(gap)) && entry.day.AddDate(0, 0, 1).After(span.Start.Add(-gap)) {
+				delete(s.fbCache, key)
+				dropped++
+				break
+			}
+		}
+	}
+	for key, entry := range s.monthCache {
+		for _, span := range spans {
+			if entry.month.Before(span.End.Add(gap)) && entry.month.AddDate(0, 1, 0).After(span.Start.Add(-gap)) {
+				delete(s.monthCache, key)
+				break
+			}
+		}
+	}
+	s.fbCacheMu.Unlock()
+	logging.Debugf("DEBUG: FreeBusy cache invalidated for %d days of %s.", dropped, calendarID)
+}
+
+// invalidateCache clears the FreeBusy and month availability caches.
+// Used when a change can affect any day (schedule, therapists, or a
+// booking whose time is unknown).
+func (s *Service) invalidateCache() {
+	s.fbCacheMu.Lock()
+	s.fbGeneration++
+	s.fbCache = make(map[string]freeBusyEntry)
+	s.monthCache = make(map[string]monthAvailabilityEntry)
+	s.fbCacheMu.Unlock()
+	logging.Debug("DEBUG: FreeBusy cache invalidated.")
+}
+
+// StartCacheWarmer keeps the FreeBusy cache of every calendar filled for
+// the next days days (today included), refetching them with one request
+// per calendar before the entries expire, so patients rarely wait for
+// Google. It stops when ctx is done.
+func (s *Service) StartCacheWarmer(ctx context.Context, days int) <-chan struct{} {
+	ticker := time.NewTicker(cacheWarmInterval)
+	logging.Infof("FreeBusy cache warmer started (%d days ahead).", days)
+	return s.runCacheWarmer(ctx, ticker.C, ticker.Stop, days)
+}
+
+// runCacheWarmer is the loop behind StartCacheWarmer, driven by ticks so
+// tests can step it.
+func (s *Service) runCacheWarmer(ctx context.Context, ticks <-chan time.Time, stop func(), days int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stop()
+		s.warmCache(ctx, days)
+		for {
+			select {
+			case <-ticks:
+				s.warmCache(ctx, days)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// warmCache fetches the next days days of each bookable calendar.
+func (s *Service) warmCache(ctx context.Context, days int) {
+	if days <= 0 {
+		return
+	}
+	targets, err := s.availabilityTargets("")
+	if err != nil {
+		logging.Warnf("WARNING: FreeBusy cache warm-up skipped: %v", err)
+		return
+	}
+	now := s.NowFunc().In(domain.ApptTimeZone)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+	to := from.AddDate(0, 0, days)
+
+	warmed := make(map[string]bool)
+	for _, t := range targets {
+		cal := s.calendarFor(t)
+		if warmed[cal.GetCalendarID()] {
+			continue
+		}
+		warmed[cal.GetCalendarID()] = true
+		if _, err := s.fetchFreeBusy(ctx, cal, from, to); err != nil {
+			logging.Warnf("WARNING: Failed to warm FreeBusy cache of %s: %v", cal.GetCalendarID(), err)
+		}
+	}
+	logging.Debugf("DEBUG: FreeBusy cache warmed for %d calendars, %d days.", len(warmed), days)
+}
@@ -0,0 +1,211 @@
+package appointment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// recordingCollector counts per-day cache lookups by bucket.
+type recordingCollector struct {
+	NoOpCollector
+	hits, misses map[string]int
+}
+
+func (r *recordingCollector) RecordFreeBusyDayLookup(bucket string, hit bool) {
+	if hit {
+		r.hits[bucket]++
+	} else {
+		r.misses[bucket]++
+	}
+}
+
+// freeBusyCall is one range requested from a calendar.
+type freeBusyCall struct{ from, to int } // Days of January 2030
+
+func newCacheTestService(t *testing.T) (*Service, *mockRepo, *[]freeBusyCall) {
+	t.Helper()
+	svc := newScheduleTestService(t, nil)
+	repo := svc.repo.(*mockRepo)
+	var calls []freeBusyCall
+	repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		calls = append(calls, freeBusyCall{start.Day(), end.Day()})
+		// One busy hour at 10:00 on each requested day
+		var busy []domain.TimeSlot
+		for _, day := range freeBusyDays(start, end) {
+			busy = append(busy, domain.TimeSlot{Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour)})
+		}
+		return busy, nil
+	}
+	return svc, repo, &calls
+}
+
+func cacheTestDay(day int) time.Time {
+	return time.Date(2030, 1, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestGetFreeBusy_PerDay(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, calls := newCacheTestService(t)
+
+	if _, err := svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(10)); err != nil {
+		t.Fatalf("getFreeBusy failed: %v", err)
+	}
+	busy, err := svc.getFreeBusy(ctx, repo, cacheTestDay(8), cacheTestDay(12))
+	if err != nil {
+		t.Fatalf("getFreeBusy failed: %v", err)
+	}
+	if len(*calls) != 2 || (*calls)[1] != (freeBusyCall{8, 12}) {
+		t.Errorf("expected the missing days fetched in one request, got %v", *calls)
+	}
+	if len(busy) != 4 || busy[0].Start.Day() != 8 || busy[3].Start.Day() != 11 {
+		t.Errorf("expected one busy hour per day, sorted, got %v", busy)
+	}
+
+	// A sub-range of cached days needs no request
+	busy, err = svc.getFreeBusy(ctx, repo, cacheTestDay(10).Add(12*time.Hour), cacheTestDay(11).Add(12*time.Hour))
+	if err != nil || len(*calls) != 2 {
+		t.Fatalf("expected a cache hit, got %d calls, %v", len(*calls), err)
+	}
+	if len(busy) != 1 || busy[0].Start.Day() != 11 {
+		t.Errorf("expected only the busy hour inside the range, got %v", busy)
+	}
+}
+
+func TestInvalidateSlots_OnlyAffectedDays(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, calls := newCacheTestService(t)
+	other := newMockRepo()
+	other.calendarID = "other-cal"
+
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	_, _ = svc.getFreeBusy(ctx, other, cacheTestDay(9), cacheTestDay(12))
+	_, _ = svc.GetMonthAvailability(ctx, "", cacheTestDay(9), 60)
+	_, _ = svc.GetMonthAvailability(ctx, "", cacheTestDay(9).AddDate(0, 1, 0), 60)
+
+	svc.invalidateSlots(repo, domain.TimeSlot{Start: cacheTestDay(10).Add(14 * time.Hour), End: cacheTestDay(10).Add(15 * time.Hour)})
+
+	svc.fbCacheMu.RLock()
+	_, day9 := svc.fbCache[freeBusyKey(repo, cacheTestDay(9))]
+	_, day10 := svc.fbCache[freeBusyKey(repo, cacheTestDay(10))]
+	_, otherDay10 := svc.fbCache[freeBusyKey(other, cacheTestDay(10))]
+	months := len(svc.monthCache)
+	svc.fbCacheMu.RUnlock()
+	if !day9 || day10 || !otherDay10 {
+		t.Errorf("expected only day 10 of the booked calendar dropped: day9=%v day10=%v other=%v", day9, day10, otherDay10)
+	}
+	if months != 1 {
+		t.Errorf("expected only January's overview dropped, %d overviews left", months)
+	}
+
+	before := len(*calls)
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	if got := (*calls)[before:]; len(got) != 1 || got[0] != (freeBusyCall{10, 11}) {
+		t.Errorf("expected only day 10 refetched, got %v", got)
+	}
+}
+
+func TestInvalidateCalendar_ByID(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, calls := newCacheTestService(t)
+
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	svc.InvalidateCalendar("other-cal", domain.TimeSlot{Start: cacheTestDay(10), End: cacheTestDay(11)})
+	svc.InvalidateCalendar(repo.GetCalendarID(), domain.TimeSlot{Start: cacheTestDay(11).Add(9 * time.Hour), End: cacheTestDay(11).Add(10 * time.Hour)})
+
+	before := len(*calls)
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	if got := (*calls)[before:]; len(got) != 1 || got[0] != (freeBusyCall{11, 12}) {
+		t.Errorf("expected only day 11 refetched, got %v", got)
+	}
+}
+
+func TestCancelAppointment_InvalidatesItsDay(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, calls := newCacheTestService(t)
+	start := cacheTestDay(10).Add(10 * time.Hour)
+	repo.appointments["a1"] = &domain.Appointment{ID: "a1", StartTime: start, EndTime: start.Add(time.Hour)}
+
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	if err := svc.CancelAppointment(ctx, "a1"); err != nil {
+		t.Fatalf("CancelAppointment failed: %v", err)
+	}
+	before := len(*calls)
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(12))
+	if got := (*calls)[before:]; len(got) != 1 || got[0] != (freeBusyCall{10, 11}) {
+		t.Errorf("expected only the cancelled day refetched, got %v", got)
+	}
+}
+
+func TestFetchFreeBusy_RacingInvalidation(t *testing.T) {
+	ctx := context.Background()
+	svc, repo, calls := newCacheTestService(t)
+	fetch := repo.getFreeBusyFunc
+	repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		svc.invalidateCache() // A booking lands while the request is in flight
+		return fetch(ctx, start, end)
+	}
+
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(10))
+	repo.getFreeBusyFunc = fetch
+	_, _ = svc.getFreeBusy(ctx, repo, cacheTestDay(9), cacheTestDay(10))
+	if len(*calls) != 2 {
+		t.Errorf("a result fetched across an invalidation must not be cached, got %d calls", len(*calls))
+	}
+}
+
+func TestGetFreeBusy_DayBucketMetrics(t *testing.T) {
+	metrics := &recordingCollector{hits: map[string]int{}, misses: map[string]int{}}
+	svc, repo, _ := newCacheTestService(t)
+	svc.metrics = metrics
+	now := svc.NowFunc() // 2 January
+
+	_, _ = svc.getFreeBusy(context.Background(), repo, now, now.AddDate(0, 0, 2))
+	_, _ = svc.getFreeBusy(context.Background(), repo, now, now.AddDate(0, 0, 1))
+	if metrics.misses["today"] != 1 || metrics.misses["tomorrow"] != 1 || metrics.hits["today"] != 1 || metrics.hits["tomorrow"] != 0 {
+		t.Errorf("unexpected lookups: hits %v, misses %v", metrics.hits, metrics.misses)
+	}
+}
+
+func TestDayBucket(t *testing.T) {
+	now := cacheTestDay(9).Add(15 * time.Hour)
+	tests := map[int]string{8: "past", 9: "today", 10: "tomorrow", 16: "week", 31: "month"}
+	for day, want := range tests {
+		if got := dayBucket(cacheTestDay(day), now); got != want {
+			t.Errorf("dayBucket(%d) = %q, want %q", day, got, want)
+		}
+	}
+	if got := dayBucket(cacheTestDay(9).AddDate(0, 2, 0), now); got != "later" {
+		t.Errorf("dayBucket(two months ahead) = %q, want later", got)
+	}
+}
+
+func TestCacheWarmer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	svc, _, calls := newCacheTestService(t)
+	ticks := make(chan time.Time)
+	stopped := false
+
+	done := svc.runCacheWarmer(ctx, ticks, func() { stopped = true }, 7)
+	ticks <- time.Now() // Taken once the initial warm-up is over
+	ticks <- time.Now() // Taken once the first refresh is over
+	cancel()
+	<-done
+
+	if len(*calls) != 3 || !stopped {
+		t.Fatalf("expected a warm-up and one refresh per tick, then the ticker stopped; got %v, stopped=%v", *calls, stopped)
+	}
+	for _, call := range *calls {
+		if call != (freeBusyCall{2, 9}) {
+			t.Errorf("expected the next 7 days fetched in one request, got %v", call)
+		}
+	}
+	if _, err := svc.GetAvailableTimeSlots(context.Background(), cacheTestDay(5), 60); err != nil {
+		t.Fatalf("GetAvailableTimeSlots failed: %v", err)
+	}
+	if len(*calls) != 3 {
+		t.Errorf("warmed days should not be fetched again, got %v", (*calls)[3:])
+	}
+}
@@ -0,0 +1,37 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// SetBookingLocker makes bookings, reschedules and series take locker's
+// lock, so instances sharing the calendar serialize their slot checks.
+// Without one only this process is serialized.
+func (s *Service) SetBookingLocker(locker ports.BookingLocker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bookingLocker = locker
+}
+
+// lockBookings serializes slot reservations: first within the process, then
+// across instances through the booking locker. The returned func releases both.
+func (s *Service) lockBookings(ctx context.Context) (func(), error) {
+	s.mu.Lock()
+	if s.bookingLocker == nil {
+		return s.mu.Unlock, nil
+	}
+	unlock, err := s.bookingLocker.LockBookings(ctx)
+	if err != nil {
+		s.mu.Unlock()
+		logging.Errorf("ERROR: Failed to take the booking lock: %v", err)
+		return nil, fmt.Errorf("failed to lock bookings: %w", err)
+	}
+	return func() {
+		unlock()
+		s.mu.Unlock()
+	}, nil
+}
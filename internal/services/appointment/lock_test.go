@@ -0,0 +1,93 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// fakeLocker stands in for the Postgres advisory lock.
+type fakeLocker struct {
+	held    bool
+	locks   int
+	lockErr error
+}
+
+func (l *fakeLocker) LockBookings(ctx context.Context) (func(), error) {
+	if l.lockErr != nil {
+		return nil, l.lockErr
+	}
+	l.held = true
+	l.locks++
+	return func() { l.held = false }, nil
+}
+
+func TestService_CreateAppointment_BookingLock(t *testing.T) {
+	ctx := context.Background()
+	start := scheduleTestDate.Add(10 * time.Hour)
+	dayStart := scheduleTestDate
+	newAppt := func() *domain.Appointment {
+		return &domain.Appointment{Service: domain.Service{ID: "1", Name: "Massage"}, StartTime: start, Duration: 60, CustomerName: "Bob"}
+	}
+
+	t.Run("checks the slot under the lock", func(t *testing.T) {
+		svc := newScheduleTestService(t, nil)
+		repo := svc.repo.(*mockRepo)
+		locker := &fakeLocker{}
+		svc.SetBookingLocker(locker)
+		repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+			if !locker.held {
+				t.Error("availability checked outside the booking lock")
+			}
+			return nil, nil
+		}
+
+		if _, err := svc.CreateAppointment(ctx, newAppt()); err != nil {
+			t.Fatalf("CreateAppointment failed: %v", err)
+		}
+		if locker.locks != 1 || locker.held {
+			t.Errorf("expected the lock taken once and released, got %d locks, held=%v", locker.locks, locker.held)
+		}
+	})
+
+	t.Run("re-reads the calendar instead of the cache", func(t *testing.T) {
+		svc := newScheduleTestService(t, nil)
+		repo := svc.repo.(*mockRepo)
+		svc.SetBookingLocker(&fakeLocker{})
+		var busy []domain.TimeSlot
+		repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+			return busy, nil
+		}
+		// Warm the cache, then another instance books the slot
+		if _, err := svc.getFreeBusy(ctx, repo, dayStart, dayStart.Add(24*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		busy = []domain.TimeSlot{{Start: start, End: start.Add(time.Hour)}}
+
+		if _, err := svc.CreateAppointment(ctx, newAppt()); !errors.Is(err, domain.ErrSlotUnavailable) {
+			t.Errorf("expected ErrSlotUnavailable for a slot booked elsewhere, got %v", err)
+		}
+	})
+
+	t.Run("lock failure", func(t *testing.T) {
+		svc := newScheduleTestService(t, nil)
+		repo := svc.repo.(*mockRepo)
+		locker := &fakeLocker{lockErr: errors.New("connection refused")}
+		svc.SetBookingLocker(locker)
+
+		if _, err := svc.CreateAppointment(ctx, newAppt()); err == nil {
+			t.Fatal("expected an error when the lock cannot be taken")
+		}
+		if len(repo.appointments) != 0 {
+			t.Error("nothing must be booked without the lock")
+		}
+		// The in-process lock must not stay held
+		locker.lockErr = nil
+		if _, err := svc.CreateAppointment(ctx, newAppt()); err != nil {
+			t.Errorf("CreateAppointment after a lock failure: %v", err)
+		}
+	})
+}
@@ -14,6 +14,9 @@ type MetricsCollector interface {
 	RecordAppointmentCancelled()
 	RecordFreeBusyCacheHit()
 	RecordFreeBusyCacheMiss()
+	// RecordFreeBusyDayLookup counts one cached day looked up, by how far
+	// ahead it is (today, tomorrow, week, ...).
+	RecordFreeBusyDayLookup(bucket string, hit bool)
 }
 
 // PrometheusCollector implements MetricsCollector using the global monitoring package.
@@ -42,6 +45,14 @@ func (p *PrometheusCollector) RecordFreeBusyCacheMiss() {
 	monitoring.FreeBusyCacheMisses.Inc()
 }
 
+func (p *PrometheusCollector) RecordFreeBusyDayLookup(bucket string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	monitoring.FreeBusyDayCacheLookups.WithLabelValues(bucket, result).Inc()
+}
+
 // NoOpCollector for testing
 type NoOpCollector struct{}
 
@@ -49,3 +60,4 @@ func (n *NoOpCollector) RecordAppointmentCreated(serviceName string, leadTimeDay
 func (n *NoOpCollector) RecordAppointmentCancelled()                                       {}
 func (n *NoOpCollector) RecordFreeBusyCacheHit()                                           {}
 func (n *NoOpCollector) RecordFreeBusyCacheMiss()                                          {}
+func (n *NoOpCollector) RecordFreeBusyDayLookup(bucket string, hit bool)                   {}
@@ -31,6 +31,12 @@ func TestPrometheusCollector_RecordFreeBusyCacheMiss(t *testing.T) {
 	c.RecordFreeBusyCacheMiss()
 }
 
+func TestPrometheusCollector_RecordFreeBusyDayLookup(t *testing.T) {
+	c := NewPrometheusCollector()
+	c.RecordFreeBusyDayLookup("today", true)
+	c.RecordFreeBusyDayLookup("week", false)
+}
+
 // TestNoOpCollector_AllMethods ensures the no-op collector satisfies
 // the interface and can be called without panicking.
 func TestNoOpCollector_AllMethods(t *testing.T) {
@@ -40,6 +46,7 @@ func TestNoOpCollector_AllMethods(t *testing.T) {
 	n.RecordAppointmentCancelled()
 	n.RecordFreeBusyCacheHit()
 	n.RecordFreeBusyCacheMiss()
+	n.RecordFreeBusyDayLookup("today", true)
 }
 
 // TestPrometheusCollector_ImplementsInterface is a compile-time
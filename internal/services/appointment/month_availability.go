@@ -0,0 +1,121 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+)
+
+type monthAvailabilityEntry struct {
+	month     time.Time // First day of the month
+	days      []domain.DayAvailability
+	expiresAt time.Time
+}
+
+// GetMonthAvailability returns one entry per day of month telling whether a
+// service of durationMinutes can still be booked then: free, limited, full
+// or closed. Each calendar is asked for FreeBusy once for the whole month.
+// An empty therapistID means "any available", like GetTherapistTimeSlots.
+//
+// The overview is shared by all patients, so everyone's slot holds count as
+// busy. Results are cached for cacheTTL next to the FreeBusy cache and
+// dropped with it whenever bookings in that month change.
+func (s *Service) GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+	if durationMinutes <= 0 {
+		return nil, domain.ErrInvalidDuration
+	}
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, domain.ApptTimeZone)
+	key := fmt.Sprintf("%s|%s|%d", therapistID, first.Format("2006-01"), durationMinutes)
+
+	s.fbCacheMu.RLock()
+	entry, found := s.monthCache[key]
+	s.fbCacheMu.RUnlock()
+	if found && time.Now().Before(entry.expiresAt) {
+		logging.Debugf("DEBUG: Month availability cache HIT for %s", key)
+		return append([]domain.DayAvailability(nil), entry.days...), nil
+	}
+
+	targets, err := s.availabilityTargets(therapistID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.loadSchedule()
+	if err != nil {
+		logging.Errorf("ERROR: Failed to load working schedule: %v", err)
+		return nil, fmt.Errorf("failed to load working schedule: %w", err)
+	}
+
+	days := first.AddDate(0, 1, -1).Day()
+	open := make([]bool, days)
+	var perTherapist [][]domain.TimeSlot
+	for _, t := range targets {
+		slots, err := s.slotsInRange(domain.WithSlotHolder(ctx, ""), t, first, days, durationMinutes)
+		if err != nil {
+			return nil, err
+		}
+		perTherapist = append(perTherapist, slots)
+
+		own := schedule.ForTherapist(therapistKey(t))
+		for i := range open {
+			open[i] = open[i] || len(own.OpenIntervals(first.AddDate(0, 0, i))) > 0
+		}
+	}
+
+	free := make([]int, days)
+	for _, slot := range mergeSlots(perTherapist...) {
+		if i := slot.Start.In(domain.ApptTimeZone).Day() - 1; i >= 0 && i < days {
+			free[i]++
+		}
+	}
+
+	today := s.NowFunc().In(domain.ApptTimeZone)
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+	result := make([]domain.DayAvailability, days)
+	for i := range result {
+		date := first.AddDate(0, 0, i)
+		result[i] = domain.DayAvailability{
+			Date:      date,
+			Status:    domain.ClassifyDay(open[i] && !date.Before(today), free[i]),
+			FreeSlots: free[i],
+		}
+	}
+
+	s.fbCacheMu.Lock()
+	s.monthCache[key] = monthAvailabilityEntry{month: first, days: result, expiresAt: time.Now().Add(cacheTTL)}
+	s.fbCacheMu.Unlock()
+	logging.Debugf("DEBUG: Month availability for %s computed over %d calendars.", key, len(targets))
+	return append([]domain.DayAvailability(nil), result...), nil
+}
+
+// availabilityTargets resolves the therapists whose calendars make up
+// therapistID's availability: that therapist alone, every active one for
+// "any available", or nil (the default calendar) without a registry.
+func (s *Service) availabilityTargets(therapistID string) ([]*domain.Therapist, error) {
+	if therapistID != "" {
+		t, err := s.findTherapist(therapistID)
+		if err != nil {
+			return nil, err
+		}
+		if !t.Active {
+			return nil, domain.ErrTherapistNotFound
+		}
+		return []*domain.Therapist{t}, nil
+	}
+
+	therapists, err := s.activeTherapists()
+	if err != nil {
+		logging.Errorf("ERROR: Failed to load therapists: %v", err)
+		return nil, fmt.Errorf("failed to load therapists: %w", err)
+	}
+	if len(therapists) == 0 {
+		return []*domain.Therapist{nil}, nil
+	}
+	targets := make([]*domain.Therapist, 0, len(therapists))
+	for i := range therapists {
+		targets = append(targets, &therapists[i])
+	}
+	return targets, nil
+}
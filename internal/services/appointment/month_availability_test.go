@@ -0,0 +1,90 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestService_GetMonthAvailability(t *testing.T) {
+	ctx := context.Background()
+	wed := scheduleTestDate // Wednesday 9 January; "now" is the 2nd
+	thu := wed.AddDate(0, 0, 1)
+
+	svc := newScheduleTestService(t, nil)
+	calls := 0
+	svc.repo.(*mockRepo).getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		calls++
+		if !start.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected one FreeBusy call over January, got %s – %s", start, end)
+		}
+		return []domain.TimeSlot{
+			{Start: wed.Add(9 * time.Hour), End: wed.Add(18 * time.Hour)},
+			{Start: thu.Add(9 * time.Hour), End: thu.Add(16 * time.Hour)},
+		}, nil
+	}
+
+	days, err := svc.GetMonthAvailability(ctx, "", wed, 60)
+	if err != nil {
+		t.Fatalf("GetMonthAvailability failed: %v", err)
+	}
+	if len(days) != 31 {
+		t.Fatalf("expected 31 days, got %d", len(days))
+	}
+	for _, tt := range []struct {
+		day   int
+		want  domain.DayStatus
+		slots int
+	}{
+		{1, domain.DayClosed, 0},   // past
+		{9, domain.DayFull, 0},     // booked out
+		{10, domain.DayLimited, 2}, // 16:00 and 17:00 left
+		{11, domain.DayFree, 9},
+		{12, domain.DayClosed, 0}, // Saturday
+	} {
+		got := days[tt.day-1]
+		if got.Date.Day() != tt.day || got.Status != tt.want || got.FreeSlots != tt.slots {
+			t.Errorf("day %d = %+v, want %s with %d slots", tt.day, got, tt.want, tt.slots)
+		}
+	}
+
+	if _, err := svc.GetMonthAvailability(ctx, "", wed, 60); err != nil || calls != 1 {
+		t.Errorf("second overview should come from the cache, got %d calls, %v", calls, err)
+	}
+	svc.invalidateCache()
+	if _, err := svc.GetMonthAvailability(ctx, "", wed, 60); err != nil || calls != 2 {
+		t.Errorf("invalidated overview should be recomputed, got %d calls, %v", calls, err)
+	}
+
+	if _, err := svc.GetMonthAvailability(ctx, "", wed, 0); !errors.Is(err, domain.ErrInvalidDuration) {
+		t.Errorf("expected ErrInvalidDuration, got %v", err)
+	}
+}
+
+func TestService_GetMonthAvailability_Therapists(t *testing.T) {
+	// anna is busy all Wednesday 9 January, boris is free: the day stays open
+	svc, _, _ := newTherapistTestService(t, []int{9, 10, 11, 12, 13, 14, 15, 16, 17}, nil)
+
+	days, err := svc.GetMonthAvailability(context.Background(), "", scheduleTestDate, 60)
+	if err != nil {
+		t.Fatalf("GetMonthAvailability failed: %v", err)
+	}
+	if got := days[8].Status; got != domain.DayFree {
+		t.Errorf("any-therapist status for the 9th = %q, want free", got)
+	}
+
+	days, err = svc.GetMonthAvailability(context.Background(), "anna", scheduleTestDate, 60)
+	if err != nil {
+		t.Fatalf("GetMonthAvailability failed: %v", err)
+	}
+	if got := days[8].Status; got != domain.DayFull {
+		t.Errorf("anna's status for the 9th = %q, want full", got)
+	}
+
+	if _, err := svc.GetMonthAvailability(context.Background(), "nobody", scheduleTestDate, 60); !errors.Is(err, domain.ErrTherapistNotFound) {
+		t.Errorf("expected ErrTherapistNotFound, got %v", err)
+	}
+}
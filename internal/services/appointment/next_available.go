@@ -0,0 +1,88 @@
+package appointment
+
+import (
+	"context"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+)
+
+const (
+	// DefaultNextAvailableHorizon is how far ahead FindNextAvailable looks
+	// when no horizon is given.
+	DefaultNextAvailableHorizon = 30 * 24 * time.Hour
+	// MaxNextAvailableHorizon caps the search.
+	MaxNextAvailableHorizon = 90 * 24 * time.Hour
+
+	// nextAvailableBatchDays is how many days one FreeBusy call covers.
+	nextAvailableBatchDays = 7
+)
+
+// FindNextAvailable returns up to limit of the earliest free slots for the
+// service at or after from, looking at most horizon ahead. Days are scanned
+// in weekly batches with one FreeBusy call per calendar and batch, and the
+// scan stops as soon as enough slots are found. With therapists registered
+// their slots are merged like in GetAvailableTimeSlots.
+func (s *Service) FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	if horizon <= 0 {
+		horizon = DefaultNextAvailableHorizon
+	}
+	if horizon > MaxNextAvailableHorizon {
+		horizon = MaxNextAvailableHorizon
+	}
+	service, err := s.findBookableService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := s.availabilityTargets("")
+	if err != nil {
+		return nil, err
+	}
+
+	from = from.In(domain.ApptTimeZone)
+	until := from.Add(horizon)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+	var found []domain.TimeSlot
+	for day.Before(until) && len(found) < limit {
+		days := nextAvailableBatchDays
+		var perTherapist [][]domain.TimeSlot
+		for _, t := range targets {
+			slots, err := s.slotsInRange(ctx, t, day, days, service.DurationMinutes)
+			if err != nil {
+				return nil, err
+			}
+			perTherapist = append(perTherapist, slots)
+		}
+		for _, slot := range mergeSlots(perTherapist...) {
+			if slot.Start.Before(from) || !slot.Start.Before(until) {
+				continue
+			}
+			found = append(found, slot)
+			if len(found) == limit {
+				break
+			}
+		}
+		day = day.AddDate(0, 0, days)
+	}
+	logging.Debugf("DEBUG: FindNextAvailable found %d slots for service %s from %s.", len(found), serviceID, from.Format("2006-01-02 15:04"))
+	return found, nil
+}
+
+// findBookableService resolves a non-archived service by ID.
+func (s *Service) findBookableService(ctx context.Context, serviceID string) (*domain.Service, error) {
+	services, err := s.GetAvailableServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].ID == serviceID {
+			return &services[i], nil
+		}
+	}
+	return nil, domain.ErrServiceNotFound
+}
@@ -0,0 +1,80 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestService_FindNextAvailable(t *testing.T) {
+	ctx := context.Background()
+	wed := scheduleTestDate // Wednesday
+
+	newService := func(busy []domain.TimeSlot) (*Service, *int) {
+		svc := newScheduleTestService(t, nil)
+		calls := 0
+		svc.repo.(*mockRepo).getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+			calls++
+			return busy, nil
+		}
+		return svc, &calls
+	}
+	hours := func(slots []domain.TimeSlot) []string {
+		out := make([]string, len(slots))
+		for i, s := range slots {
+			out[i] = s.Start.Format("02 15:04")
+		}
+		return out
+	}
+
+	t.Run("earliest slots across days in one batch", func(t *testing.T) {
+		thu := wed.AddDate(0, 0, 1)
+		svc, calls := newService([]domain.TimeSlot{{Start: thu.Add(9 * time.Hour), End: thu.Add(18 * time.Hour)}})
+
+		slots, err := svc.FindNextAvailable(ctx, "2", wed.Add(16*time.Hour+30*time.Minute), 0, 3)
+		if err != nil {
+			t.Fatalf("FindNextAvailable failed: %v", err)
+		}
+		want := []string{"09 17:00", "11 09:00", "11 10:00"}
+		if got := hours(slots); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+			t.Errorf("slots = %v, want %v", got, want)
+		}
+		if *calls != 1 {
+			t.Errorf("expected one batched FreeBusy call, got %d", *calls)
+		}
+	})
+
+	t.Run("scans further batches when a week is full", func(t *testing.T) {
+		svc, calls := newService([]domain.TimeSlot{{Start: wed, End: wed.AddDate(0, 0, 7)}})
+
+		slots, err := svc.FindNextAvailable(ctx, "2", wed, 0, 1)
+		if err != nil {
+			t.Fatalf("FindNextAvailable failed: %v", err)
+		}
+		if got := hours(slots); len(got) != 1 || got[0] != "16 09:00" {
+			t.Errorf("slots = %v, want the next Wednesday 09:00", got)
+		}
+		if *calls != 2 {
+			t.Errorf("expected two batched FreeBusy calls, got %d", *calls)
+		}
+	})
+
+	t.Run("stops at the horizon", func(t *testing.T) {
+		svc, _ := newService([]domain.TimeSlot{{Start: wed, End: wed.AddDate(0, 0, 2)}})
+
+		slots, err := svc.FindNextAvailable(ctx, "2", wed, 48*time.Hour, 5)
+		if err != nil || len(slots) != 0 {
+			t.Errorf("expected nothing within the horizon, got %v, %v", hours(slots), err)
+		}
+	})
+
+	t.Run("unknown service", func(t *testing.T) {
+		svc, _ := newService(nil)
+		if _, err := svc.FindNextAvailable(ctx, "nope", wed, 0, 3); !errors.Is(err, domain.ErrServiceNotFound) {
+			t.Errorf("expected ErrServiceNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// scheduleTTL bounds how long a loaded schedule is reused. Edits made through
+// the service refresh it immediately; the TTL covers edits from other
+// processes and lets the exception window roll forward each day.
+const scheduleTTL = 5 * time.Minute
+
+// SetScheduleRepository switches the service to the persisted working schedule.
+func (s *Service) SetScheduleRepository(repo ports.ScheduleRepository) {
+	s.scheduleMu.Lock()
+	s.scheduleRepo = repo
+	s.schedule = nil
+	s.scheduleMu.Unlock()
+}
+
+// SeedSchedule stores domain.DefaultWeeklySchedule when no rules exist yet.
+func (s *Service) SeedSchedule(ctx context.Context) error {
+	if s.scheduleRepo == nil {
+		return nil
+	}
+	current, err := s.scheduleRepo.GetWeeklySchedule()
+	if err != nil {
+		return fmt.Errorf("failed to check working schedule: %w", err)
+	}
+	if len(current.Rules) > 0 {
+		return nil
+	}
+
+	byDay := make(map[time.Weekday][]domain.ScheduleRule)
+	for _, r := range domain.DefaultWeeklySchedule().Rules {
+		byDay[r.Weekday] = append(byDay[r.Weekday], r)
+	}
+	for day, rules := range byDay {
+		if err := s.scheduleRepo.ReplaceWeekdayRules("", day, rules); err != nil {
+			return fmt.Errorf("failed to seed schedule for %s: %w", day, err)
+		}
+	}
+	s.resetSchedule()
+	logging.Info("Working schedule seeded with default weekday hours.")
+	return nil
+}
+
+// loadSchedule returns the cached schedule, reloading it after scheduleTTL.
+func (s *Service) loadSchedule() (domain.WeeklySchedule, error) {
+	s.scheduleMu.RLock()
+	repo, cached, loadedAt := s.scheduleRepo, s.schedule, s.scheduleLoadedAt
+	s.scheduleMu.RUnlock()
+
+	if repo == nil {
+		return domain.DefaultWeeklySchedule(), nil
+	}
+	if cached != nil && time.Since(loadedAt) < scheduleTTL {
+		return *cached, nil
+	}
+
+	schedule, err := repo.GetWeeklySchedule()
+	if err != nil {
+		return domain.WeeklySchedule{}, err
+	}
+
+	s.scheduleMu.Lock()
+	s.schedule = &schedule
+	s.scheduleLoadedAt = time.Now()
+	s.scheduleMu.Unlock()
+	return schedule, nil
+}
+
+// resetSchedule drops the cached schedule and the FreeBusy-derived slot
+// cache, since open hours changed.
+func (s *Service) resetSchedule() {
+	s.scheduleMu.Lock()
+	s.schedule = nil
+	s.scheduleMu.Unlock()
+	s.invalidateCache()
+}
+
+// GetSchedule returns the current weekly schedule with upcoming exceptions,
+// including every therapist's own rules; see WeeklySchedule.ForTherapist.
+func (s *Service) GetSchedule(ctx context.Context) (domain.WeeklySchedule, error) {
+	return s.loadSchedule()
+}
+
+// GetWorkingHours returns a therapist's open intervals for a calendar day,
+// after breaks and exceptions. An empty therapistID means the shared
+// schedule. An empty result means the day is closed.
+func (s *Service) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	schedule, err := s.loadSchedule()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load working schedule: %w", err)
+	}
+	return schedule.ForTherapist(therapistID).OpenIntervals(date), nil
+}
+
+// SetWeekdayHours replaces the working intervals and breaks of one weekday,
+// for one therapist or (empty therapistID) the shared schedule. Passing no
+// work intervals makes the weekday a regular day off.
+func (s *Service) SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+	if s.scheduleRepo == nil {
+		return domain.ErrScheduleUnavailable
+	}
+
+	var rules []domain.ScheduleRule
+	for _, r := range work {
+		rules = append(rules, domain.ScheduleRule{TherapistID: therapistID, Weekday: weekday, Kind: domain.ScheduleKindWork, StartMinute: r.Start, EndMinute: r.End})
+	}
+	for _, r := range breaks {
+		rules = append(rules, domain.ScheduleRule{TherapistID: therapistID, Weekday: weekday, Kind: domain.ScheduleKindBreak, StartMinute: r.Start, EndMinute: r.End})
+	}
+	if err := (domain.WeeklySchedule{Rules: rules}).Validate(); err != nil {
+		return err
+	}
+	if err := s.scheduleRepo.ReplaceWeekdayRules(therapistID, weekday, rules); err != nil {
+		return err
+	}
+	s.resetSchedule()
+	return nil
+}
+
+// SaveScheduleException stores a closure, shortened day or extra working day.
+func (s *Service) SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error {
+	if s.scheduleRepo == nil {
+		return domain.ErrScheduleUnavailable
+	}
+	if err := exc.Validate(); err != nil {
+		return err
+	}
+	if err := s.scheduleRepo.SaveScheduleException(exc); err != nil {
+		return err
+	}
+	s.resetSchedule()
+	return nil
+}
+
+// DeleteScheduleException restores the regular weekly hours for a date.
+func (s *Service) DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error {
+	if s.scheduleRepo == nil {
+		return domain.ErrScheduleUnavailable
+	}
+	if err := s.scheduleRepo.DeleteScheduleException(therapistID, date); err != nil {
+		return err
+	}
+	s.resetSchedule()
+	return nil
+}
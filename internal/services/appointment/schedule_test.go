@@ -0,0 +1,269 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// mockScheduleRepo is an in-memory ports.ScheduleRepository.
+type mockScheduleRepo struct {
+	schedule domain.WeeklySchedule
+	loads    int
+	getErr   error
+}
+
+func (m *mockScheduleRepo) GetWeeklySchedule() (domain.WeeklySchedule, error) {
+	m.loads++
+	if m.getErr != nil {
+		return domain.WeeklySchedule{}, m.getErr
+	}
+	return m.schedule, nil
+}
+
+func (m *mockScheduleRepo) ReplaceWeekdayRules(therapistID string, weekday time.Weekday, rules []domain.ScheduleRule) error {
+	var kept []domain.ScheduleRule
+	for _, r := range m.schedule.Rules {
+		if r.TherapistID != therapistID || r.Weekday != weekday {
+			kept = append(kept, r)
+		}
+	}
+	m.schedule.Rules = append(kept, rules...)
+	return nil
+}
+
+func (m *mockScheduleRepo) SaveScheduleException(exc domain.ScheduleException) error {
+	_ = m.DeleteScheduleException(exc.TherapistID, exc.Date)
+	m.schedule.Exceptions = append(m.schedule.Exceptions, exc)
+	return nil
+}
+
+func (m *mockScheduleRepo) DeleteScheduleException(therapistID string, date time.Time) error {
+	var kept []domain.ScheduleException
+	for _, e := range m.schedule.Exceptions {
+		if e.TherapistID != therapistID || !e.Date.Equal(date) {
+			kept = append(kept, e)
+		}
+	}
+	m.schedule.Exceptions = kept
+	return nil
+}
+
+// nextWorkday returns the first Monday–Friday date strictly after from, so
+// tests relying on the default schedule do not fail on weekends.
+func nextWorkday(from time.Time) time.Time {
+	d := from.AddDate(0, 0, 1)
+	for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+		d = d.AddDate(0, 0, 1)
+	}
+	return d
+}
+
+// scheduleTestDate is a fixed Wednesday.
+var scheduleTestDate = time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+
+func newScheduleTestService(t *testing.T, repo *mockScheduleRepo) *Service {
+	t.Helper()
+	domain.ApptTimeZone = time.UTC
+	appts := newMockRepo()
+	appts.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		return nil, nil
+	}
+	svc := NewService(appts, nil)
+	svc.NowFunc = func() time.Time { return scheduleTestDate.AddDate(0, 0, -7) }
+	if repo != nil {
+		svc.SetScheduleRepository(repo)
+	}
+	return svc
+}
+
+func slotHours(slots []domain.TimeSlot) []int {
+	hours := make([]int, len(slots))
+	for i, s := range slots {
+		hours[i] = s.Start.Hour()
+	}
+	return hours
+}
+
+func TestGetAvailableTimeSlots_Schedule(t *testing.T) {
+	wed := scheduleTestDate
+	sat := wed.AddDate(0, 0, 3)
+	sun := wed.AddDate(0, 0, 4)
+
+	rules := []domain.ScheduleRule{
+		{Weekday: time.Wednesday, Kind: domain.ScheduleKindWork, StartMinute: 9 * 60, EndMinute: 18 * 60},
+		{Weekday: time.Wednesday, Kind: domain.ScheduleKindBreak, StartMinute: 13 * 60, EndMinute: 14 * 60},
+		{Weekday: time.Saturday, Kind: domain.ScheduleKindWork, StartMinute: 10 * 60, EndMinute: 14 * 60},
+	}
+
+	tests := []struct {
+		name       string
+		date       time.Time
+		duration   int
+		exceptions []domain.ScheduleException
+		want       []int
+	}{
+		{"lunch break removes 13:00", wed, 60, nil, []int{9, 10, 11, 12, 14, 15, 16, 17}},
+		{"slot may not cross the break", wed, 90, nil, []int{9, 10, 11, 14, 15, 16}},
+		{"shorter saturday", sat, 60, nil, []int{10, 11, 12, 13}},
+		{"sunday has no rules", sun, 60, nil, []int{}},
+		{"holiday closes the day", wed, 60, []domain.ScheduleException{{Date: wed, Closed: true}}, []int{}},
+		{"shortened day keeps weekday break", wed, 60,
+			[]domain.ScheduleException{{Date: wed, Hours: []domain.MinuteRange{{Start: 12 * 60, End: 15 * 60}}}},
+			[]int{12, 14}},
+		{"extra working sunday", sun, 60,
+			[]domain.ScheduleException{{Date: sun, Hours: []domain.MinuteRange{{Start: 11 * 60, End: 13 * 60}}}},
+			[]int{11, 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockScheduleRepo{schedule: domain.WeeklySchedule{Rules: rules, Exceptions: tt.exceptions}}
+			svc := newScheduleTestService(t, repo)
+
+			slots, err := svc.GetAvailableTimeSlots(context.Background(), tt.date, tt.duration)
+			if err != nil {
+				t.Fatalf("GetAvailableTimeSlots failed: %v", err)
+			}
+			got := slotHours(slots)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got slots %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got slots %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateAppointment_UsesSchedule(t *testing.T) {
+	repo := &mockScheduleRepo{schedule: domain.WeeklySchedule{Rules: []domain.ScheduleRule{
+		{Weekday: time.Wednesday, Kind: domain.ScheduleKindWork, StartMinute: 9 * 60, EndMinute: 18 * 60},
+		{Weekday: time.Wednesday, Kind: domain.ScheduleKindBreak, StartMinute: 13 * 60, EndMinute: 14 * 60},
+	}}}
+	svc := newScheduleTestService(t, repo)
+
+	appt := func(hour int) *domain.Appointment {
+		return &domain.Appointment{
+			Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+			StartTime:    scheduleTestDate.Add(time.Duration(hour) * time.Hour),
+			Duration:     60,
+			CustomerName: "Alice",
+			CustomerTgID: "1",
+		}
+	}
+
+	if _, err := svc.CreateAppointment(context.Background(), appt(13)); !errors.Is(err, domain.ErrOutsideWorkingHours) {
+		t.Errorf("booking into the break: expected ErrOutsideWorkingHours, got %v", err)
+	}
+	if _, err := svc.CreateAppointment(context.Background(), appt(14)); err != nil {
+		t.Errorf("booking after the break failed: %v", err)
+	}
+}
+
+func TestSchedule_DefaultWithoutRepository(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	ctx := context.Background()
+
+	hours, err := svc.GetWorkingHours(ctx, "", scheduleTestDate)
+	if err != nil {
+		t.Fatalf("GetWorkingHours failed: %v", err)
+	}
+	if len(hours) != 1 || hours[0].Start.Hour() != domain.WorkDayStartHour || hours[0].End.Hour() != domain.WorkDayEndHour {
+		t.Errorf("unexpected default hours: %+v", hours)
+	}
+
+	if err := svc.SetWeekdayHours(ctx, "", time.Monday, nil, nil); !errors.Is(err, domain.ErrScheduleUnavailable) {
+		t.Errorf("expected ErrScheduleUnavailable, got %v", err)
+	}
+	if err := svc.SaveScheduleException(ctx, domain.ScheduleException{Date: scheduleTestDate, Closed: true}); !errors.Is(err, domain.ErrScheduleUnavailable) {
+		t.Errorf("expected ErrScheduleUnavailable, got %v", err)
+	}
+}
+
+func TestSchedule_EditsInvalidateCache(t *testing.T) {
+	repo := &mockScheduleRepo{schedule: domain.DefaultWeeklySchedule()}
+	svc := newScheduleTestService(t, repo)
+	ctx := context.Background()
+
+	if _, err := svc.GetWorkingHours(ctx, "", scheduleTestDate); err != nil {
+		t.Fatalf("GetWorkingHours failed: %v", err)
+	}
+	if _, err := svc.GetWorkingHours(ctx, "", scheduleTestDate); err != nil {
+		t.Fatalf("GetWorkingHours failed: %v", err)
+	}
+	if repo.loads != 1 {
+		t.Errorf("expected schedule to be cached, loaded %d times", repo.loads)
+	}
+
+	if err := svc.SaveScheduleException(ctx, domain.ScheduleException{Date: scheduleTestDate, Closed: true, Note: "Праздник"}); err != nil {
+		t.Fatalf("SaveScheduleException failed: %v", err)
+	}
+	hours, _ := svc.GetWorkingHours(ctx, "", scheduleTestDate)
+	if len(hours) != 0 {
+		t.Errorf("expected closed day after exception, got %+v", hours)
+	}
+
+	if err := svc.DeleteScheduleException(ctx, "", scheduleTestDate); err != nil {
+		t.Fatalf("DeleteScheduleException failed: %v", err)
+	}
+	hours, _ = svc.GetWorkingHours(ctx, "", scheduleTestDate)
+	if len(hours) != 1 {
+		t.Errorf("expected regular hours after reset, got %+v", hours)
+	}
+}
+
+func TestSetWeekdayHours(t *testing.T) {
+	repo := &mockScheduleRepo{}
+	svc := newScheduleTestService(t, repo)
+	ctx := context.Background()
+
+	work := []domain.MinuteRange{{Start: 10 * 60, End: 16 * 60}}
+	breaks := []domain.MinuteRange{{Start: 12 * 60, End: 12*60 + 30}}
+	if err := svc.SetWeekdayHours(ctx, "", time.Wednesday, work, breaks); err != nil {
+		t.Fatalf("SetWeekdayHours failed: %v", err)
+	}
+	hours, _ := svc.GetWorkingHours(ctx, "", scheduleTestDate)
+	if len(hours) != 2 || hours[0].End.Hour() != 12 || hours[1].Start.Minute() != 30 {
+		t.Errorf("unexpected hours after edit: %+v", hours)
+	}
+
+	bad := []domain.MinuteRange{{Start: 16 * 60, End: 10 * 60}}
+	if err := svc.SetWeekdayHours(ctx, "", time.Wednesday, bad, nil); !errors.Is(err, domain.ErrInvalidSchedule) {
+		t.Errorf("expected ErrInvalidSchedule, got %v", err)
+	}
+}
+
+func TestSeedSchedule(t *testing.T) {
+	repo := &mockScheduleRepo{}
+	svc := newScheduleTestService(t, repo)
+
+	if err := svc.SeedSchedule(context.Background()); err != nil {
+		t.Fatalf("SeedSchedule failed: %v", err)
+	}
+	if len(repo.schedule.Rules) != 5 {
+		t.Errorf("expected 5 default rules, got %d", len(repo.schedule.Rules))
+	}
+
+	repo.schedule.Rules = repo.schedule.Rules[:1]
+	if err := svc.SeedSchedule(context.Background()); err != nil {
+		t.Fatalf("SeedSchedule failed: %v", err)
+	}
+	if len(repo.schedule.Rules) != 1 {
+		t.Errorf("seed must not overwrite existing rules, got %d", len(repo.schedule.Rules))
+	}
+}
+
+func TestGetWorkingHours_RepoError(t *testing.T) {
+	repo := &mockScheduleRepo{getErr: errors.New("db down")}
+	svc := newScheduleTestService(t, repo)
+
+	if _, err := svc.GetWorkingHours(context.Background(), "", scheduleTestDate); err == nil {
+		t.Error("expected error when schedule cannot be loaded")
+	}
+}
@@ -0,0 +1,243 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// SetSeriesRepository enables recurring series. Without it BookSeries
+// returns domain.ErrSeriesUnavailable and every appointment is standalone.
+func (s *Service) SetSeriesRepository(repo ports.SeriesRepository) {
+	s.seriesRepo = repo
+}
+
+// BookSeries books every occurrence of the series in one go. Each occurrence
+// is validated like a single booking; if any of them clashes nothing is
+// booked and the clashes are returned as a *domain.SeriesConflictError. With
+// "any available" the first therapist free for the whole series is used.
+func (s *Service) BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+	if s.seriesRepo == nil {
+		return nil, domain.ErrSeriesUnavailable
+	}
+	if err := series.Validate(); err != nil {
+		logging.Errorf("ERROR: BookSeries - invalid series: %+v", series)
+		return nil, err
+	}
+
+	unlock, err := s.lockBookings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	starts := series.Occurrences()
+	duration := time.Duration(series.Service.DurationMinutes) * time.Minute
+	appts := make([]domain.Appointment, len(starts))
+	for i, start := range starts {
+		appts[i] = domain.Appointment{
+			ServiceID:    series.Service.ID,
+			Service:      series.Service,
+			StartTime:    start,
+			EndTime:      start.Add(duration),
+			Duration:     series.Service.DurationMinutes,
+			CustomerName: series.CustomerName,
+			CustomerTgID: series.CustomerTgID,
+			Source:       domain.SourceSeries,
+		}
+	}
+
+	therapist, conflicts, err := s.seriesTherapist(ctx, &series, appts)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		logging.Infof("Series for %s not booked: %d of %d occurrences clash", series.CustomerName, len(conflicts), len(appts))
+		return nil, &domain.SeriesConflictError{Conflicts: conflicts, Total: len(appts)}
+	}
+
+	cal := s.calendarFor(therapist)
+	booked := make([]domain.Appointment, 0, len(appts))
+	for i := range appts {
+		appts[i].TherapistID = series.TherapistID
+		created, err := cal.Create(ctx, &appts[i])
+		if err != nil {
+			logging.Errorf("ERROR: Failed to create series occurrence %s: %v", appts[i].StartTime.Format("2006-01-02 15:04"), err)
+			s.rollbackSeries(ctx, cal, booked)
+			return nil, fmt.Errorf("failed to create appointment in repository: %w", err)
+		}
+		if created.TherapistID == "" {
+			created.TherapistID = series.TherapistID
+		}
+		booked = append(booked, *created)
+	}
+
+	series.Appointments = booked
+	if err := s.seriesRepo.CreateSeries(&series); err != nil {
+		logging.Errorf("ERROR: Failed to save series, cancelling its %d appointments: %v", len(booked), err)
+		s.rollbackSeries(ctx, cal, booked)
+		return nil, fmt.Errorf("failed to save series: %w", err)
+	}
+
+	for _, appt := range booked {
+		leadTimeDays := time.Until(appt.StartTime).Hours() / 24
+		if leadTimeDays < 0 {
+			leadTimeDays = 0
+		}
+		s.metrics.RecordAppointmentCreated(appt.Service.Name, leadTimeDays)
+	}
+	s.invalidateSlots(cal, appointmentSpans(booked)...)
+	logging.Infof("Series %d booked for %s: %d appointments from %s", series.ID, series.CustomerName,
+		len(booked), booked[0].StartTime.Format("2006-01-02 15:04"))
+
+	return &series, nil
+}
+
+// seriesTherapist picks the therapist for a series and reports the clashing
+// occurrences. With "any available" and nobody free for the whole series,
+// the clashes of the therapist with the fewest are reported.
+func (s *Service) seriesTherapist(ctx context.Context, series *domain.AppointmentSeries, appts []domain.Appointment) (*domain.Therapist, []domain.SeriesConflict, error) {
+	if series.TherapistID != "" {
+		t, err := s.findTherapist(series.TherapistID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !t.Active {
+			return nil, nil, domain.ErrTherapistNotFound
+		}
+		conflicts, err := s.seriesConflicts(ctx, t, appts)
+		return t, conflicts, err
+	}
+
+	therapists, err := s.activeTherapists()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load therapists: %w", err)
+	}
+	if len(therapists) == 0 {
+		conflicts, err := s.seriesConflicts(ctx, nil, appts)
+		return nil, conflicts, err
+	}
+
+	var fewest []domain.SeriesConflict
+	for i := range therapists {
+		conflicts, err := s.seriesConflicts(ctx, &therapists[i], appts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(conflicts) == 0 {
+			series.TherapistID = therapists[i].ID
+			return &therapists[i], nil, nil
+		}
+		if fewest == nil || len(conflicts) < len(fewest) {
+			fewest = conflicts
+		}
+	}
+	return nil, fewest, nil
+}
+
+// seriesConflicts checks every occurrence against the therapist's hours and
+// calendar. Unexpected errors (calendar unreachable) abort the check.
+func (s *Service) seriesConflicts(ctx context.Context, t *domain.Therapist, appts []domain.Appointment) ([]domain.SeriesConflict, error) {
+	cal := s.calendarFor(t)
+	now := s.NowFunc()
+	var conflicts []domain.SeriesConflict
+	for i := range appts {
+		if appts[i].StartTime.Before(now) {
+			conflicts = append(conflicts, domain.SeriesConflict{Start: appts[i].StartTime, Err: domain.ErrAppointmentInPast})
+			continue
+		}
+		err := s.checkAvailability(ctx, t, cal, &appts[i], nil)
+		switch {
+		case err == nil:
+		case errors.Is(err, domain.ErrSlotUnavailable), errors.Is(err, domain.ErrOutsideWorkingHours):
+			conflicts = append(conflicts, domain.SeriesConflict{Start: appts[i].StartTime, Err: err})
+		default:
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
+// rollbackSeries deletes occurrences already created for a failed series.
+func (s *Service) rollbackSeries(ctx context.Context, cal ports.AppointmentRepository, booked []domain.Appointment) {
+	for _, appt := range booked {
+		if err := cal.Delete(ctx, appt.ID); err != nil {
+			logging.Errorf("ERROR: Failed to roll back series appointment %s: %v", appt.ID, err)
+		}
+	}
+	s.invalidateSlots(cal, appointmentSpans(booked)...)
+}
+
+// appointmentSpans returns the time each appointment takes up.
+func appointmentSpans(appts []domain.Appointment) []domain.TimeSlot {
+	spans := make([]domain.TimeSlot, len(appts))
+	for i, appt := range appts {
+		spans[i] = domain.TimeSlot{Start: appt.StartTime, End: appt.EndTime}
+	}
+	return spans
+}
+
+// GetAppointmentSeries returns the series an appointment belongs to, with
+// its remaining occurrences.
+func (s *Service) GetAppointmentSeries(ctx context.Context, appointmentID string) (*domain.AppointmentSeries, error) {
+	if s.seriesRepo == nil {
+		return nil, domain.ErrSeriesNotFound
+	}
+	if appointmentID == "" {
+		return nil, domain.ErrInvalidID
+	}
+	return s.seriesRepo.GetSeriesByAppointment(appointmentID)
+}
+
+// CancelSeries cancels the appointment and every later occurrence of its
+// series. Occurrences already gone from the calendar are skipped.
+func (s *Service) CancelSeries(ctx context.Context, appointmentID string) ([]domain.Appointment, error) {
+	series, err := s.GetAppointmentSeries(ctx, appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var from time.Time
+	for _, occ := range series.Appointments {
+		if occ.ID == appointmentID {
+			from = occ.StartTime
+		}
+	}
+
+	var cancelled []domain.Appointment
+	for _, occ := range series.Appointments {
+		if occ.StartTime.Before(from) {
+			continue
+		}
+		appt, _, err := s.findAppointment(ctx, occ.ID)
+		if err != nil {
+			if errors.Is(err, domain.ErrAppointmentNotFound) {
+				logging.Warnf("WARNING: Series %d occurrence %s is no longer in the calendar", series.ID, occ.ID)
+				s.forgetSeriesAppointment(occ.ID)
+				continue
+			}
+			return cancelled, fmt.Errorf("failed to find appointment in repository: %w", err)
+		}
+		if err := s.CancelAppointment(ctx, occ.ID); err != nil && !errors.Is(err, domain.ErrAppointmentNotFound) {
+			return cancelled, err
+		}
+		cancelled = append(cancelled, *appt)
+	}
+	logging.Infof("Series %d: cancelled %d appointments from %s", series.ID, len(cancelled), appointmentID)
+	return cancelled, nil
+}
+
+// forgetSeriesAppointment unlinks a cancelled occurrence; best effort only.
+func (s *Service) forgetSeriesAppointment(appointmentID string) {
+	if s.seriesRepo == nil {
+		return
+	}
+	if err := s.seriesRepo.RemoveSeriesAppointment(appointmentID); err != nil {
+		logging.Warnf("WARNING: Failed to unlink appointment %s from its series: %v", appointmentID, err)
+	}
+}
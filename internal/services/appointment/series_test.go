@@ -0,0 +1,213 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// seriesCalendar hands out distinct IDs and can fail the n-th Create.
+type seriesCalendar struct {
+	*mockRepo
+	created  int
+	failAt   int
+	deleted  []string
+	busyDays map[string]bool
+}
+
+func (c *seriesCalendar) Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	c.created++
+	if c.created == c.failAt {
+		return nil, errors.New("calendar down")
+	}
+	stored := *appt
+	stored.ID = fmt.Sprintf("occ-%d", c.created)
+	c.appointments[stored.ID] = &stored
+	return &stored, nil
+}
+
+func (c *seriesCalendar) Delete(ctx context.Context, id string) error {
+	c.deleted = append(c.deleted, id)
+	return c.mockRepo.Delete(ctx, id)
+}
+
+// mockSeriesRepo keeps series in memory.
+type mockSeriesRepo struct {
+	series  []*domain.AppointmentSeries
+	removed []string
+	err     error
+}
+
+func (m *mockSeriesRepo) CreateSeries(series *domain.AppointmentSeries) error {
+	if m.err != nil {
+		return m.err
+	}
+	series.ID = int64(len(m.series) + 1)
+	stored := *series
+	m.series = append(m.series, &stored)
+	return nil
+}
+
+func (m *mockSeriesRepo) GetSeriesByAppointment(appointmentID string) (*domain.AppointmentSeries, error) {
+	for _, s := range m.series {
+		for _, a := range s.Appointments {
+			if a.ID == appointmentID {
+				copied := *s
+				return &copied, nil
+			}
+		}
+	}
+	return nil, domain.ErrSeriesNotFound
+}
+
+func (m *mockSeriesRepo) RemoveSeriesAppointment(appointmentID string) error {
+	m.removed = append(m.removed, appointmentID)
+	for _, s := range m.series {
+		kept := s.Appointments[:0]
+		for _, a := range s.Appointments {
+			if a.ID != appointmentID {
+				kept = append(kept, a)
+			}
+		}
+		s.Appointments = kept
+	}
+	return nil
+}
+
+func newSeriesTestService(t *testing.T) (*Service, *seriesCalendar, *mockSeriesRepo) {
+	t.Helper()
+	svc := newScheduleTestService(t, nil)
+	cal := &seriesCalendar{mockRepo: svc.repo.(*mockRepo), busyDays: map[string]bool{}}
+	cal.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		if cal.busyDays[start.Format("2006-01-02")] {
+			return []domain.TimeSlot{{Start: start.Add(10 * time.Hour), End: start.Add(11 * time.Hour)}}, nil
+		}
+		return nil, nil
+	}
+	svc.repo = cal
+	series := &mockSeriesRepo{}
+	svc.SetSeriesRepository(series)
+	return svc, cal, series
+}
+
+// testSeries is Mon/Wed 10:00 for two weeks from Wednesday 2030-01-09:
+// 09.01, 14.01, 16.01, 21.01.
+func testSeries() domain.AppointmentSeries {
+	return domain.AppointmentSeries{
+		Service:      domain.Service{ID: "rehab", Name: "Реабилитация", DurationMinutes: 60},
+		CustomerName: "Иван",
+		CustomerTgID: "100",
+		FirstStart:   scheduleTestDate.Add(10 * time.Hour),
+		Weekdays:     []time.Weekday{time.Monday, time.Wednesday},
+		Weeks:        2,
+	}
+}
+
+func TestService_BookSeries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("books every occurrence", func(t *testing.T) {
+		svc, cal, repo := newSeriesTestService(t)
+		series, err := svc.BookSeries(ctx, testSeries())
+		if err != nil {
+			t.Fatalf("BookSeries() error = %v", err)
+		}
+		if len(series.Appointments) != 4 || len(cal.appointments) != 4 {
+			t.Fatalf("booked %d (calendar %d), want 4", len(series.Appointments), len(cal.appointments))
+		}
+		if series.ID == 0 || len(repo.series) != 1 {
+			t.Errorf("series not stored: %+v", series)
+		}
+		if got := series.Appointments[1].StartTime.Format("2006-01-02 15:04"); got != "2030-01-14 10:00" {
+			t.Errorf("second occurrence = %s, want 2030-01-14 10:00", got)
+		}
+	})
+
+	t.Run("reports clashes and books nothing", func(t *testing.T) {
+		svc, cal, repo := newSeriesTestService(t)
+		cal.busyDays["2030-01-16"] = true
+		s := testSeries()
+		s.Weekdays = append(s.Weekdays, time.Saturday)
+
+		_, err := svc.BookSeries(ctx, s)
+		var conflictErr *domain.SeriesConflictError
+		if !errors.As(err, &conflictErr) || !errors.Is(err, domain.ErrSeriesConflict) {
+			t.Fatalf("BookSeries() error = %v, want SeriesConflictError", err)
+		}
+		if conflictErr.Total != 6 || len(conflictErr.Conflicts) != 3 {
+			t.Fatalf("conflicts = %+v (total %d), want 3 of 6", conflictErr.Conflicts, conflictErr.Total)
+		}
+		if !errors.Is(conflictErr.Conflicts[0].Err, domain.ErrOutsideWorkingHours) {
+			t.Errorf("first clash = %v, want Saturday outside working hours", conflictErr.Conflicts[0].Err)
+		}
+		if got := conflictErr.Conflicts[1]; got.Start.Format("2006-01-02") != "2030-01-16" || !errors.Is(got.Err, domain.ErrSlotUnavailable) {
+			t.Errorf("second clash = %+v, want 16.01 slot unavailable", got)
+		}
+		if len(cal.appointments) != 0 || len(repo.series) != 0 {
+			t.Error("nothing may be booked when the series clashes")
+		}
+	})
+
+	t.Run("rolls back on calendar failure", func(t *testing.T) {
+		svc, cal, repo := newSeriesTestService(t)
+		cal.failAt = 3
+		if _, err := svc.BookSeries(ctx, testSeries()); err == nil {
+			t.Fatal("BookSeries() expected error")
+		}
+		if len(cal.deleted) != 2 || len(cal.appointments) != 0 || len(repo.series) != 0 {
+			t.Errorf("deleted %v, left %d appointments; want a full rollback", cal.deleted, len(cal.appointments))
+		}
+	})
+
+	t.Run("invalid or unavailable", func(t *testing.T) {
+		svc, _, _ := newSeriesTestService(t)
+		s := testSeries()
+		s.Weeks = 0
+		if _, err := svc.BookSeries(ctx, s); !errors.Is(err, domain.ErrInvalidSeries) {
+			t.Errorf("BookSeries() error = %v, want ErrInvalidSeries", err)
+		}
+
+		plain := newScheduleTestService(t, nil)
+		if _, err := plain.BookSeries(ctx, testSeries()); !errors.Is(err, domain.ErrSeriesUnavailable) {
+			t.Errorf("BookSeries() without repository error = %v, want ErrSeriesUnavailable", err)
+		}
+	})
+}
+
+func TestService_CancelSeries(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, repo := newSeriesTestService(t)
+	series, err := svc.BookSeries(ctx, testSeries())
+	if err != nil {
+		t.Fatalf("BookSeries() error = %v", err)
+	}
+	ids := []string{series.Appointments[0].ID, series.Appointments[1].ID, series.Appointments[2].ID, series.Appointments[3].ID}
+
+	// A single occurrence leaves the series
+	if err := svc.CancelAppointment(ctx, ids[0]); err != nil {
+		t.Fatalf("CancelAppointment() error = %v", err)
+	}
+	if len(repo.removed) != 1 || repo.removed[0] != ids[0] {
+		t.Errorf("removed = %v, want [%s]", repo.removed, ids[0])
+	}
+
+	// The rest from the third occurrence on
+	cancelled, err := svc.CancelSeries(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("CancelSeries() error = %v", err)
+	}
+	if len(cancelled) != 2 || cancelled[0].ID != ids[2] || cancelled[1].ID != ids[3] {
+		t.Errorf("cancelled = %+v, want the last two", cancelled)
+	}
+	if _, ok := cal.appointments[ids[1]]; !ok || len(cal.appointments) != 1 {
+		t.Errorf("calendar = %v, want only %s left", cal.appointments, ids[1])
+	}
+
+	if _, err := svc.CancelSeries(ctx, "standalone"); !errors.Is(err, domain.ErrSeriesNotFound) {
+		t.Errorf("CancelSeries(standalone) error = %v, want ErrSeriesNotFound", err)
+	}
+}
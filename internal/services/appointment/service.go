@@ -31,144 +31,120 @@ type Service struct {
 
 	// Cache for FreeBusy results
 	fbCacheMu sync.RWMutex
-	fbCache   map[string]freeBusyEntry
+	fbCache   map[string]freeBusyEntry // By calendar and day
+	// Bumped on every invalidation so fetches that raced it are not cached
+	fbGeneration uint64
+	// Month availability overviews, kept with the FreeBusy results they
+	// are derived from and cleared together with them
+	monthCache map[string]monthAvailabilityEntry
 
 	metrics MetricsCollector
 
 	// Database repository for local caching
 	dbRepo ports.Repository
+	// Set when the appointments table holds every appointment (calendar
+	// sync or Postgres-only mode), so history is read from it alone
+	historySynced bool
+	// Set when the calendar repository is the appointments table itself
+	// (Postgres-only mode); the local copy is then never written separately
+	calendarInDB bool
+
+	// Optional persisted service catalog; defaultServices is used when nil
+	catalog ports.ServiceCatalogRepository
+
+	// Optional persisted working schedule; domain.DefaultWeeklySchedule is used when nil
+	scheduleRepo     ports.ScheduleRepository
+	scheduleMu       sync.RWMutex
+	schedule         *domain.WeeklySchedule
+	scheduleLoadedAt time.Time
+
+	// Slot layout: grid step, buffers between clients and packing
+	slotPolicyMu sync.RWMutex
+	slotPolicy   domain.SlotPolicy
+
+	// Notice window for self-service cancellation
+	cancelPolicyMu sync.RWMutex
+	cancelPolicy   domain.CancellationPolicy
+
+	// Optional therapist registry; without it the service runs in
+	// single-practitioner mode on the default calendar
+	therapistRepo      ports.TherapistRepository
+	calendarFactory    ports.CalendarFactory
+	therapistMu        sync.RWMutex
+	therapists         []domain.Therapist
+	therapistsLoadedAt time.Time
+	calendars          map[string]ports.AppointmentRepository
+
+	// Optional listener for slots freed by cancellation or rescheduling
+	slotFreed func(ctx context.Context, appt domain.Appointment)
+
+	// Optional listener for lifecycle status changes
+	statusChanged func(ctx context.Context, appt domain.Appointment, status domain.AppointmentStatus)
+
+	// Optional links between the appointments of recurring series
+	seriesRepo ports.SeriesRepository
+
+	// Optional lifecycle status store (booked, confirmed, completed, ...)
+	statusRepo ports.AppointmentStatusRepository
+
+	// Optional cross-instance lock around slot reservations; guarded by mu
+	bookingLocker ports.BookingLocker
+
+	// Optional holds on slots picked but not yet booked
+	holdRepo ports.SlotHoldRepository
+	holdTTL  time.Duration
 }
 
-type freeBusyEntry struct {
-	slots     []domain.TimeSlot
-	expiresAt time.Time
-}
-
-const cacheTTL = 2 * time.Minute
-
 // NewService creates a new appointment service with default dependencies.
 func NewService(repo ports.AppointmentRepository, dbRepo ports.Repository) *Service {
 	return &Service{
-		repo:    repo,
-		dbRepo:  dbRepo,
-		NowFunc: time.Now, // Default to standard time.Now()
-		fbCache: make(map[string]freeBusyEntry),
-		metrics: NewPrometheusCollector(), // Default to Prometheus
+		repo:         repo,
+		dbRepo:       dbRepo,
+		NowFunc:      time.Now, // Default to standard time.Now()
+		fbCache:      make(map[string]freeBusyEntry),
+		monthCache:   make(map[string]monthAvailabilityEntry),
+		metrics:      NewPrometheusCollector(), // Default to Prometheus
+		slotPolicy:   domain.DefaultSlotPolicy(),
+		cancelPolicy: domain.DefaultCancellationPolicy(),
 	}
 }
 
 // NewServiceWithMetrics creates a new appointment service with a custom metrics collector.
 func NewServiceWithMetrics(repo ports.AppointmentRepository, dbRepo ports.Repository, metrics MetricsCollector) *Service {
 	return &Service{
-		repo:    repo,
-		dbRepo:  dbRepo,
-		NowFunc: time.Now,
-		fbCache: make(map[string]freeBusyEntry),
-		metrics: metrics,
-	}
-}
-
-// getFreeBusy retrieves busy slots from cache or repository
-func (s *Service) getFreeBusy(ctx context.Context, timeMin, timeMax time.Time) ([]domain.TimeSlot, error) {
-	// Create a unique cache key based on the time range
-	// Since we typically query for full days, Format("2006-01-02") is sufficient if timeMin is start of day
-	// But to be safe for arbitrary ranges, we can use a more precise key
-	key := fmt.Sprintf("%s-%s", timeMin.Format(time.RFC3339), timeMax.Format(time.RFC3339))
-
-	s.fbCacheMu.RLock()
-	entry, found := s.fbCache[key]
-	s.fbCacheMu.RUnlock()
-
-	if found && time.Now().Before(entry.expiresAt) {
-		s.metrics.RecordFreeBusyCacheHit()
-		logging.Debugf("DEBUG: FreeBusy cache HIT for %s", key)
-		return entry.slots, nil
-	}
-
-	s.metrics.RecordFreeBusyCacheMiss()
-	logging.Debugf("DEBUG: FreeBusy cache MISS for %s", key)
-
-	// Fetch from repo
-	slots, err := s.repo.GetFreeBusy(ctx, timeMin, timeMax)
-	if err != nil {
-		return nil, err
+		repo:         repo,
+		dbRepo:       dbRepo,
+		NowFunc:      time.Now,
+		fbCache:      make(map[string]freeBusyEntry),
+		monthCache:   make(map[string]monthAvailabilityEntry),
+		metrics:      metrics,
+		slotPolicy:   domain.DefaultSlotPolicy(),
+		cancelPolicy: domain.DefaultCancellationPolicy(),
 	}
-
-	// Cache the result
-	s.fbCacheMu.Lock()
-	s.fbCache[key] = freeBusyEntry{
-		slots:     slots,
-		expiresAt: time.Now().Add(cacheTTL),
-	}
-	s.fbCacheMu.Unlock()
-
-	return slots, nil
 }
 
-// invalidateCache clears the FreeBusy cache.
-// Should be called when appointments are created or cancelled.
-func (s *Service) invalidateCache() {
-	s.fbCacheMu.Lock()
-	s.fbCache = make(map[string]freeBusyEntry)
-	s.fbCacheMu.Unlock()
-	logging.Debug("DEBUG: FreeBusy cache invalidated.")
+// SetSlotFreedHook registers fn to be told about every future appointment
+// that is cancelled or moved away, e.g. to offer the time to a waitlist.
+// fn runs in its own goroutine after the change is committed.
+func (s *Service) SetSlotFreedHook(fn func(ctx context.Context, appt domain.Appointment)) {
+	s.slotFreed = fn
 }
 
-// GetAvailableServices returns a predefined list of services.
-func (s *Service) GetAvailableServices(ctx context.Context) ([]domain.Service, error) {
-	services := []domain.Service{
-		{
-			ID:              "1",
-			Name:            "Массаж Спина + Шея",
-			DurationMinutes: 40,
-			Price:           2000.00,
-		},
-		{
-			ID:              "2",
-			Name:            "Общий массаж",
-			DurationMinutes: 60,
-			Price:           2800.00,
-		},
-		{
-			ID:              "3",
-			Name:            "Лимфодренаж",
-			DurationMinutes: 50,
-			Price:           2400.00,
-		},
-		{
-			ID:              "4",
-			Name:            "Иглоукалывание",
-			DurationMinutes: 30,
-			Price:           1400.00,
-		},
-		{
-			ID:              "5",
-			Name:            "Консультация офлайн",
-			DurationMinutes: 60,
-			Price:           2000.00,
-		},
-		{
-			ID:              "6",
-			Name:            "Консультация онлайн",
-			DurationMinutes: 45,
-			Price:           1500.00,
-		},
-		{
-			ID:              "7",
-			Name:            "Реабилитационные программы",
-			DurationMinutes: 60,
-			Price:           13000.00,
-			Description:     "от 13000 ₺ в месяц",
-		},
-	}
-	logging.Debugf("DEBUG: GetAvailableServices returned %d services.", len(services))
-	return services, nil
+// notifySlotFreed hands a freed appointment to the hook, if any.
+func (s *Service) notifySlotFreed(appt domain.Appointment) {
+	if s.slotFreed == nil || !appt.StartTime.After(s.NowFunc()) {
+		return
+	}
+	go s.slotFreed(context.Background(), appt)
 }
 
 // CreateAppointment handles the creation of a new appointment.
 func (s *Service) CreateAppointment(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	unlock, err := s.lockBookings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 
 	if appt == nil {
 		logging.Error("ERROR: CreateAppointment - Appointment is nil")
@@ -199,48 +175,29 @@ func (s *Service) CreateAppointment(ctx context.Context, appt *domain.Appointmen
 		return nil, domain.ErrAppointmentInPast
 	}
 
-	// 2. Validate against working hours
-	startHour := appt.StartTime.Hour()
-	endHour := appt.EndTime.Hour()
-	endMinute := appt.EndTime.Minute()
-
-	// If end time is exactly on the hour (e.g., 18:00 for a 17:00-18:00 appointment), it's still within.
-	// If it's past the hour (e.g., 18:01), it's outside.
-	if startHour < domain.WorkDayStartHour || startHour >= domain.WorkDayEndHour ||
-		(endHour > domain.WorkDayEndHour || (endHour == domain.WorkDayEndHour && endMinute > 0)) {
-		logging.Errorf("ERROR: Appointment time %s-%s is outside working hours %d:00-%d:00",
-			appt.StartTime.Format("15:04"), appt.EndTime.Format("15:04"), domain.WorkDayStartHour, domain.WorkDayEndHour)
-		return nil, domain.ErrOutsideWorkingHours
-	}
-
-	// 3. Check for slot availability (re-check to prevent race conditions or double bookings)
-	// Fetch busy intervals for the day
-	dayStart := time.Date(appt.StartTime.Year(), appt.StartTime.Month(), appt.StartTime.Day(), 0, 0, 0, 0, loc)
-	dayEnd := dayStart.Add(24 * time.Hour)
-
-	busySlots, err := s.getFreeBusy(ctx, dayStart, dayEnd)
+	// 2. Pick the therapist: the requested one, or the first free one when
+	// therapists are registered and the patient chose "any available"
+	therapist, err := s.assignTherapist(ctx, appt)
 	if err != nil {
-		logging.Errorf("ERROR: Failed to fetch FreeBusy for overlapping check: %v", err)
-		return nil, fmt.Errorf("failed to verify slot availability: %w", err)
+		return nil, err
 	}
 
-	for _, busy := range busySlots {
-		// Check for overlap: [start, end)
-		if appt.StartTime.Before(busy.End) && appt.EndTime.After(busy.Start) {
-			logging.Errorf("ERROR: New appointment %s-%s overlaps with busy interval %s-%s",
-				appt.StartTime.Format("15:04"), appt.EndTime.Format("15:04"),
-				busy.Start.Format("15:04"), busy.End.Format("15:04"))
-			return nil, domain.ErrSlotUnavailable
-		}
+	// 3. Validate against the working schedule and check for slot availability
+	// (re-check to prevent race conditions or double bookings)
+	if err := s.checkAvailability(ctx, therapist, s.calendarFor(therapist), appt, nil); err != nil {
+		return nil, err
 	}
 	logging.Debug("DEBUG: Appointment slot is available.")
 
 	// 4. Persist the appointment (e.g., in Google Calendar)
-	createdAppt, err := s.repo.Create(ctx, appt)
+	createdAppt, err := s.calendarFor(therapist).Create(ctx, appt)
 	if err != nil {
 		logging.Errorf("ERROR: Failed to create appointment in repository: %v", err)
 		return nil, fmt.Errorf("failed to create appointment in repository: %w", err)
 	}
+	if createdAppt.TherapistID == "" {
+		createdAppt.TherapistID = appt.TherapistID
+	}
 	logging.Debugf("DEBUG: Appointment successfully created in repository with ID: %s", createdAppt.ID)
 
 	// Record metrics
@@ -250,12 +207,106 @@ func (s *Service) CreateAppointment(ctx context.Context, appt *domain.Appointmen
 	}
 	s.metrics.RecordAppointmentCreated(createdAppt.Service.Name, leadTimeDays)
 
-	// Invalidate cache to prevent stale availability
-	s.invalidateCache()
+	// With a synced history the new booking shows up before the next sync
+	if s.historySynced && s.dbRepo != nil && !s.calendarInDB {
+		stored := *createdAppt
+		stored.StartTime = stored.StartTime.In(domain.ApptTimeZone)
+		if err := s.dbRepo.UpsertAppointments([]domain.Appointment{stored}); err != nil {
+			logging.Warnf("WARNING: Failed to store appointment %s in local database: %v", createdAppt.ID, err)
+		}
+	}
+
+	// Invalidate the booked day to prevent stale availability
+	s.invalidateSlots(s.calendarFor(therapist), domain.TimeSlot{Start: appt.StartTime, End: appt.EndTime})
+	s.releaseOwnHold(ctx)
 
 	return createdAppt, nil
 }
 
+// assignTherapist resolves appt.TherapistID. Without registered therapists
+// it returns nil (default calendar, shared schedule). For "any available" it
+// tries active therapists in display order and stamps the first free one.
+func (s *Service) assignTherapist(ctx context.Context, appt *domain.Appointment) (*domain.Therapist, error) {
+	if appt.TherapistID != "" {
+		t, err := s.findTherapist(appt.TherapistID)
+		if err != nil {
+			logging.Errorf("ERROR: Unknown therapist %q for new appointment: %v", appt.TherapistID, err)
+			return nil, err
+		}
+		if !t.Active {
+			return nil, domain.ErrTherapistNotFound
+		}
+		return t, nil
+	}
+
+	therapists, err := s.activeTherapists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load therapists: %w", err)
+	}
+	if len(therapists) == 0 {
+		return nil, nil
+	}
+
+	// Report the most specific reason if nobody is free
+	lastErr := domain.ErrOutsideWorkingHours
+	for i := range therapists {
+		err := s.checkAvailability(ctx, &therapists[i], s.calendarFor(&therapists[i]), appt, nil)
+		if err == nil {
+			appt.TherapistID = therapists[i].ID
+			logging.Debugf("DEBUG: Assigned therapist %s to new appointment.", therapists[i].ID)
+			return &therapists[i], nil
+		}
+		if !errors.Is(err, domain.ErrOutsideWorkingHours) {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// checkAvailability verifies the appointment fits the therapist's working
+// hours and does not overlap cal (buffers included). A non-nil own interval
+// is the appointment's current slot when moving it, and is not a conflict.
+func (s *Service) checkAvailability(ctx context.Context, t *domain.Therapist, cal ports.AppointmentRepository, appt *domain.Appointment, own *domain.TimeSlot) error {
+	schedule, err := s.loadSchedule()
+	if err != nil {
+		logging.Errorf("ERROR: Failed to load working schedule: %v", err)
+		return fmt.Errorf("failed to load working schedule: %w", err)
+	}
+	if !schedule.ForTherapist(therapistKey(t)).Contains(appt.StartTime, appt.EndTime) {
+		logging.Errorf("ERROR: Appointment time %s %s-%s is outside working hours",
+			appt.StartTime.Format("2006-01-02"), appt.StartTime.Format("15:04"), appt.EndTime.Format("15:04"))
+		return domain.ErrOutsideWorkingHours
+	}
+
+	// Fetch busy intervals for the day
+	loc := appt.StartTime.Location()
+	dayStart := time.Date(appt.StartTime.Year(), appt.StartTime.Month(), appt.StartTime.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.Add(24 * time.Hour)
+
+	// Other instances book under the shared lock without touching this
+	// process's cache, so with a locker the check reads the calendar itself
+	if s.bookingLocker != nil {
+		s.forgetFreeBusy(cal, dayStart, dayEnd)
+	}
+	busySlots, err := s.getFreeBusy(ctx, cal, dayStart, dayEnd)
+	if err != nil {
+		logging.Errorf("ERROR: Failed to fetch FreeBusy for overlapping check: %v", err)
+		return fmt.Errorf("failed to verify slot availability: %w", err)
+	}
+	busySlots = s.withHolds(ctx, t, busySlots, dayStart, dayEnd)
+	if own != nil {
+		busySlots = withoutInterval(busySlots, *own)
+	}
+
+	// Overlap check [start, end), widened by the configured buffers
+	if s.getSlotPolicy().Conflicts(appt.StartTime, appt.EndTime, busySlots) {
+		logging.Errorf("ERROR: New appointment %s-%s overlaps with a busy interval (buffers included)",
+			appt.StartTime.Format("15:04"), appt.EndTime.Format("15:04"))
+		return domain.ErrSlotUnavailable
+	}
+	return nil
+}
+
 // CancelAppointment cancels an appointment by ID.
 func (s *Service) CancelAppointment(ctx context.Context, appointmentID string) error {
 	logging.Debugf("DEBUG: CancelAppointment called for ID: %s", appointmentID)
@@ -263,7 +314,11 @@ func (s *Service) CancelAppointment(ctx context.Context, appointmentID string) e
 		return domain.ErrInvalidID
 	}
 
-	err := s.repo.Delete(ctx, appointmentID)
+	// Remember what is being freed for the cache and the waitlist; best
+	// effort only
+	freed, freedCal, _ := s.findAppointment(ctx, appointmentID)
+
+	err := s.deleteAppointment(ctx, appointmentID)
 	if err != nil {
 		if errors.Is(err, domain.ErrAppointmentNotFound) {
 			logging.Warnf("WARNING: Attempted to cancel non-existent appointment ID: %s", appointmentID)
@@ -274,30 +329,167 @@ func (s *Service) CancelAppointment(ctx context.Context, appointmentID string) e
 	}
 	logging.Debugf("DEBUG: Appointment %s successfully cancelled.", appointmentID)
 
-	// Also delete from local database to prevent it from reappearing in TWA
-	if s.dbRepo != nil {
+	// Also delete from local database to prevent it from reappearing in TWA.
+	// With Postgres as the calendar the row is the appointment itself and
+	// stays, marked cancelled.
+	if s.dbRepo != nil && !s.calendarInDB {
 		if err := s.dbRepo.DeleteAppointment(appointmentID); err != nil {
 			logging.Warnf("WARNING: Failed to delete appointment %s from local database: %v", appointmentID, err)
 			// Don't return error - appointment is already deleted from GCal
 		}
 	}
 
+	s.forgetSeriesAppointment(appointmentID)
+
 	// Record cancellation metric
 	s.metrics.RecordAppointmentCancelled()
 
 	// Invalidate cache as a slot just freed up
-	s.invalidateCache()
+	if freed != nil {
+		s.invalidateSlots(freedCal, domain.TimeSlot{Start: freed.StartTime, End: freed.EndTime})
+	} else {
+		s.invalidateCache()
+	}
+
+	if freed != nil {
+		s.notifySlotFreed(*freed)
+	}
 
 	return nil
 }
 
+// RescheduleAppointment moves an appointment to newStart, keeping its ID,
+// service, duration and therapist. The new slot is validated like a new
+// booking; the event is patched in place so there is no window in which the
+// patient holds neither slot. Reminder state is reset for the new time.
+func (s *Service) RescheduleAppointment(ctx context.Context, appointmentID string, newStart time.Time) (*domain.Appointment, error) {
+	unlock, err := s.lockBookings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	logging.Debugf("DEBUG: RescheduleAppointment called for ID %s to %s", appointmentID, newStart.Format("2006-01-02 15:04"))
+	if appointmentID == "" {
+		return nil, domain.ErrInvalidID
+	}
+	if newStart.IsZero() {
+		return nil, domain.ErrInvalidAppointment
+	}
+
+	appt, cal, err := s.findAppointment(ctx, appointmentID)
+	if err != nil {
+		if errors.Is(err, domain.ErrAppointmentNotFound) {
+			logging.Warnf("WARNING: Attempted to reschedule non-existent appointment ID: %s", appointmentID)
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to find appointment in repository: %w", err)
+	}
+
+	loc := domain.ApptTimeZone
+	if loc == nil {
+		loc = time.Local
+	}
+
+	duration := appt.EndTime.Sub(appt.StartTime)
+	if duration <= 0 {
+		duration = time.Duration(appt.Duration) * time.Minute
+	}
+	if duration <= 0 {
+		logging.Errorf("ERROR: RescheduleAppointment - appointment %s has no duration", appointmentID)
+		return nil, domain.ErrInvalidAppointment
+	}
+	own := domain.TimeSlot{Start: appt.StartTime, End: appt.EndTime}
+
+	moved := *appt
+	moved.StartTime = newStart.In(loc)
+	moved.EndTime = moved.StartTime.Add(duration)
+	moved.Duration = int(duration / time.Minute)
+
+	if nowInLoc := s.NowFunc().In(loc); moved.StartTime.Before(nowInLoc) {
+		logging.Errorf("ERROR: Reschedule target %s is in the past (now: %s)", moved.StartTime.Format("15:04"), nowInLoc.Format("15:04"))
+		return nil, domain.ErrAppointmentInPast
+	}
+
+	// The booking stays with its therapist even if they were archived since
+	var therapist *domain.Therapist
+	if appt.TherapistID != "" {
+		if therapist, err = s.findTherapist(appt.TherapistID); err != nil {
+			logging.Warnf("WARNING: Therapist %q of appointment %s is unknown, using the shared schedule: %v", appt.TherapistID, appointmentID, err)
+			therapist = nil
+		}
+	}
+
+	if err := s.checkAvailability(ctx, therapist, cal, &moved, &own); err != nil {
+		return nil, err
+	}
+
+	updated, err := cal.Update(ctx, &moved)
+	if err != nil {
+		if errors.Is(err, domain.ErrAppointmentNotFound) {
+			return nil, err
+		}
+		logging.Errorf("ERROR: Failed to move appointment %s in repository: %v", appointmentID, err)
+		return nil, fmt.Errorf("failed to update appointment in repository: %w", err)
+	}
+	if updated.TherapistID == "" {
+		updated.TherapistID = moved.TherapistID
+	}
+	logging.Infof("Appointment %s moved from %s to %s", appointmentID,
+		own.Start.In(loc).Format("2006-01-02 15:04"), updated.StartTime.Format("2006-01-02 15:04"))
+
+	// Keep the local copy in step and let reminders and the confirmation
+	// request go out again for the new time
+	if s.dbRepo != nil {
+		if !s.calendarInDB {
+			if err := s.dbRepo.UpsertAppointments([]domain.Appointment{*updated}); err != nil {
+				logging.Warnf("WARNING: Failed to update appointment %s in local database: %v", appointmentID, err)
+			}
+		}
+		if err := s.dbRepo.SaveAppointmentMetadata(appointmentID, nil, map[string]bool{}); err != nil {
+			logging.Warnf("WARNING: Failed to reset reminder state for appointment %s: %v", appointmentID, err)
+		}
+	}
+	s.unconfirmMoved(updated)
+	updated.ConfirmedAt = nil
+	updated.RemindersSent = nil
+
+	s.invalidateSlots(cal, own, domain.TimeSlot{Start: updated.StartTime, End: updated.EndTime})
+
+	freed := *appt
+	freed.StartTime, freed.EndTime = own.Start, own.End
+	s.notifySlotFreed(freed)
+
+	return updated, nil
+}
+
+// withoutInterval removes cut from the busy intervals. FreeBusy merges
+// back-to-back events, so the appointment being moved may be only part of
+// a reported block.
+func withoutInterval(busy []domain.TimeSlot, cut domain.TimeSlot) []domain.TimeSlot {
+	out := make([]domain.TimeSlot, 0, len(busy))
+	for _, b := range busy {
+		if !b.Start.Before(cut.End) || !b.End.After(cut.Start) {
+			out = append(out, b)
+			continue
+		}
+		if b.Start.Before(cut.Start) {
+			out = append(out, domain.TimeSlot{Start: b.Start, End: cut.Start})
+		}
+		if b.End.After(cut.End) {
+			out = append(out, domain.TimeSlot{Start: cut.End, End: b.End})
+		}
+	}
+	return out
+}
+
 // FindByID retrieves an appointment by its ID.
 func (s *Service) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
 	logging.Debugf("DEBUG: FindByID called for ID: %s", id)
 	if id == "" {
 		return nil, domain.ErrInvalidID
 	}
-	appt, err := s.repo.FindByID(ctx, id)
+	appt, _, err := s.findAppointment(ctx, id)
 	if err != nil {
 		if errors.Is(err, domain.ErrAppointmentNotFound) {
 			logging.Warnf("WARNING: Appointment with ID %s not found.", id)
@@ -317,7 +509,9 @@ func (s *Service) GetCustomerAppointments(ctx context.Context, customerTgID stri
 		return nil, domain.ErrInvalidID
 	}
 
-	allAppts, err := s.repo.FindAll(ctx)
+	allAppts, err := s.collectAppointments(ctx, func(r ports.AppointmentRepository) ([]domain.Appointment, error) {
+		return r.FindAll(ctx)
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrAppointmentNotFound) {
 			return []domain.Appointment{}, nil
@@ -340,7 +534,9 @@ func (s *Service) GetCustomerAppointments(ctx context.Context, customerTgID stri
 func (s *Service) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
 	logging.Debug("DEBUG: GetAllUpcomingAppointments called")
 
-	allAppts, err := s.repo.FindAll(ctx)
+	allAppts, err := s.collectAppointments(ctx, func(r ports.AppointmentRepository) ([]domain.Appointment, error) {
+		return r.FindAll(ctx)
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrAppointmentNotFound) {
 			return []domain.Appointment{}, nil
@@ -363,6 +559,24 @@ func (s *Service) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appo
 	return upcomingAppts, nil
 }
 
+// SetHistorySynced makes history reads come from the appointments table
+// only. Enable it when something keeps that table complete.
+func (s *Service) SetHistorySynced(synced bool) {
+	s.historySynced = synced
+}
+
+// SetCalendarInDatabase tells the service that its calendar repository
+// stores appointments in the appointments table, so creating, moving and
+// cancelling must not write the local copy on top of it.
+func (s *Service) SetCalendarInDatabase(inDB bool) {
+	s.calendarInDB = inDB
+}
+
+// HistorySynced reports whether history is read from the appointments table.
+func (s *Service) HistorySynced() bool {
+	return s.historySynced
+}
+
 // GetCustomerHistory returns ALL appointments (past and future) for a specific customer.
 func (s *Service) GetCustomerHistory(ctx context.Context, customerTgID string) ([]domain.Appointment, error) {
 	logging.Debugf("DEBUG: GetCustomerHistory called for customer TGID: %s", customerTgID)
@@ -370,6 +584,15 @@ func (s *Service) GetCustomerHistory(ctx context.Context, customerTgID string) (
 		return nil, domain.ErrInvalidID
 	}
 
+	if s.historySynced && s.dbRepo != nil {
+		appts, err := s.dbRepo.GetAppointmentHistory(customerTgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history for customer: %w", err)
+		}
+		logging.Debugf("DEBUG: Found %d stored history events for customer %s", len(appts), customerTgID)
+		return appts, nil
+	}
+
 	// Optimize: Set a reasonable timeout for history fetching to prevent TWA hangs
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
@@ -378,7 +601,9 @@ func (s *Service) GetCustomerHistory(ctx context.Context, customerTgID string) (
 	timeMin := time.Now().AddDate(-5, 0, 0)
 
 	// Fetch events with time limit
-	allAppts, err := s.repo.FindEvents(ctx, &timeMin, nil)
+	allAppts, err := s.collectAppointments(ctx, func(r ports.AppointmentRepository) ([]domain.Appointment, error) {
+		return r.FindEvents(ctx, &timeMin, nil)
+	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			logging.Warnf("WARNING: Sync timeout for customer %s. GCal API took too long.", customerTgID)
@@ -404,12 +629,16 @@ func (s *Service) GetCustomerHistory(ctx context.Context, customerTgID string) (
 // GetUpcomingAppointments returns all appointments within a specific time range.
 func (s *Service) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
 	logging.Debugf("DEBUG: GetUpcomingAppointments called for range %s - %s", timeMin.Format("02.01 15:04"), timeMax.Format("02.01 15:04"))
-	return s.repo.FindEvents(ctx, &timeMin, &timeMax)
+	return s.collectAppointments(ctx, func(r ports.AppointmentRepository) ([]domain.Appointment, error) {
+		return r.FindEvents(ctx, &timeMin, &timeMax)
+	})
 }
 
 // GetTotalUpcomingCount returns the total number of upcoming appointments for all customers.
 func (s *Service) GetTotalUpcomingCount(ctx context.Context) (int, error) {
-	allAppts, err := s.repo.FindAll(ctx)
+	allAppts, err := s.collectAppointments(ctx, func(r ports.AppointmentRepository) ([]domain.Appointment, error) {
+		return r.FindAll(ctx)
+	})
 	if err != nil {
 		if errors.Is(err, domain.ErrAppointmentNotFound) {
 			return 0, nil
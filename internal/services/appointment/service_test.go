@@ -11,6 +11,7 @@ import (
 
 // mockRepo is a simple mock implementation of ports.AppointmentRepository
 type mockRepo struct {
+	calendarID      string
 	appointments    map[string]*domain.Appointment
 	shouldError     bool
 	getFreeBusyFunc func(context.Context, time.Time, time.Time) ([]domain.TimeSlot, error)
@@ -54,6 +55,18 @@ func (m *mockRepo) Delete(ctx context.Context, id string) error {
 	return domain.ErrAppointmentNotFound
 }
 
+func (m *mockRepo) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if m.shouldError {
+		return nil, errors.New("mock error")
+	}
+	if _, ok := m.appointments[appt.ID]; !ok {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	stored := *appt
+	m.appointments[appt.ID] = &stored
+	return appt, nil
+}
+
 func (m *mockRepo) FindAll(ctx context.Context) ([]domain.Appointment, error) {
 	if m.shouldError {
 		return nil, errors.New("mock error")
@@ -85,7 +98,12 @@ func (m *mockRepo) GetFreeBusy(ctx context.Context, start, end time.Time) ([]dom
 }
 
 func (m *mockRepo) GetAccountInfo(ctx context.Context) (string, error) { return "mock@gmail.com", nil }
-func (m *mockRepo) GetCalendarID() string                              { return "mock-cal-id" }
+func (m *mockRepo) GetCalendarID() string {
+	if m.calendarID != "" {
+		return m.calendarID
+	}
+	return "mock-cal-id"
+}
 func (m *mockRepo) ListCalendars(ctx context.Context) ([]string, error) {
 	return []string{"primary"}, nil
 }
@@ -381,9 +399,9 @@ func TestService_FreeBusy_CacheHit(t *testing.T) {
 	end := start.Add(24 * time.Hour)
 
 	// First call — cache miss → repo called
-	_, _ = svc.getFreeBusy(context.Background(), start, end)
+	_, _ = svc.getFreeBusy(context.Background(), repo, start, end)
 	// Second call same range — cache hit → repo NOT called again
-	_, _ = svc.getFreeBusy(context.Background(), start, end)
+	_, _ = svc.getFreeBusy(context.Background(), repo, start, end)
 
 	if callCount != 1 {
 		t.Errorf("Expected repo.GetFreeBusy called once (cache hit on 2nd call), got %d", callCount)
@@ -402,9 +420,9 @@ func TestService_InvalidateCache(t *testing.T) {
 	start := time.Date(2023, 10, 25, 0, 0, 0, 0, time.UTC)
 	end := start.Add(24 * time.Hour)
 
-	_, _ = svc.getFreeBusy(context.Background(), start, end) // miss → callCount=1
+	_, _ = svc.getFreeBusy(context.Background(), repo, start, end) // miss → callCount=1
 	svc.invalidateCache()
-	_, _ = svc.getFreeBusy(context.Background(), start, end) // miss again → callCount=2
+	_, _ = svc.getFreeBusy(context.Background(), repo, start, end) // miss again → callCount=2
 
 	if callCount != 2 {
 		t.Errorf("Expected 2 repo calls after cache invalidation, got %d", callCount)
@@ -492,6 +510,60 @@ func TestService_CancelAppointment_DBRepoDeleteError(t *testing.T) {
 	}
 }
 
+// tableRepo is a calendar kept in the appointments table itself, like the
+// Postgres provider: cancelling marks the row instead of removing it.
+type tableRepo struct {
+	*mockRepo
+	table *mockDBRepo
+}
+
+func (r *tableRepo) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	appt, ok := r.table.appointments[id]
+	if !ok {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	return &appt, nil
+}
+
+func (r *tableRepo) Delete(ctx context.Context, id string) error {
+	appt, ok := r.table.appointments[id]
+	if !ok {
+		return domain.ErrAppointmentNotFound
+	}
+	appt.Status = "cancelled"
+	r.table.appointments[id] = appt
+	return nil
+}
+
+func TestService_CancelAppointment_CalendarInDatabase(t *testing.T) {
+	table := &mockDBRepo{appointments: make(map[string]domain.Appointment)}
+	table.appointments["appt1"] = domain.Appointment{ID: "appt1", CustomerTgID: "100", Status: "confirmed"}
+
+	svc := NewService(&tableRepo{mockRepo: newMockRepo(), table: table}, table)
+	svc.SetHistorySynced(true)
+	svc.SetCalendarInDatabase(true)
+	if err := svc.CancelAppointment(context.Background(), "appt1"); err != nil {
+		t.Fatalf("CancelAppointment failed: %v", err)
+	}
+
+	appt, err := svc.FindByID(context.Background(), "appt1")
+	if err != nil {
+		t.Fatalf("expected the cancelled appointment to stay readable, got %v", err)
+	}
+	if appt.Status != "cancelled" {
+		t.Errorf("expected status cancelled, got %q", appt.Status)
+	}
+	history, err := svc.GetCustomerHistory(context.Background(), "100")
+	if err != nil || len(history) != 1 || history[0].Status != "cancelled" {
+		t.Errorf("expected the cancelled visit in the history, got %+v, %v", history, err)
+	}
+
+	// Cancelling again is not an error, like a deleted calendar event
+	if err := svc.CancelAppointment(context.Background(), "appt1"); err != nil {
+		t.Errorf("expected cancelling again to succeed, got %v", err)
+	}
+}
+
 func TestService_FindByID_RepoError(t *testing.T) {
 	repo := newMockRepo()
 	repo.shouldError = true
@@ -568,6 +640,49 @@ func TestService_GetCustomerHistory_NotFound(t *testing.T) {
 	}
 }
 
+func TestService_GetCustomerHistory_Synced(t *testing.T) {
+	repo := newMockRepo()
+	repo.shouldError = true // The calendar must not be read
+	dbRepo := &mockDBRepo{appointments: map[string]domain.Appointment{
+		"a1": {ID: "a1", CustomerTgID: "user1"},
+		"a2": {ID: "a2", CustomerTgID: "user2"},
+	}}
+	svc := NewService(repo, dbRepo)
+	svc.SetHistorySynced(true)
+
+	if !svc.HistorySynced() {
+		t.Fatal("Expected HistorySynced to be true")
+	}
+	appts, err := svc.GetCustomerHistory(context.Background(), "user1")
+	if err != nil {
+		t.Fatalf("GetCustomerHistory failed: %v", err)
+	}
+	if len(appts) != 1 || appts[0].ID != "a1" {
+		t.Errorf("Expected only a1 from the database, got %+v", appts)
+	}
+}
+
+func TestService_CreateAppointment_SyncedStoresLocally(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	dbRepo := &mockDBRepo{appointments: make(map[string]domain.Appointment)}
+	svc.dbRepo = dbRepo
+	svc.SetHistorySynced(true)
+
+	created, err := svc.CreateAppointment(context.Background(), &domain.Appointment{
+		Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+		StartTime:    scheduleTestDate.Add(10 * time.Hour),
+		Duration:     60,
+		CustomerName: "Alice",
+		CustomerTgID: "1",
+	})
+	if err != nil {
+		t.Fatalf("CreateAppointment failed: %v", err)
+	}
+	if _, ok := dbRepo.appointments[created.ID]; !ok {
+		t.Error("Expected the new appointment in the local database")
+	}
+}
+
 func TestService_GetTotalUpcomingCount_NotFound(t *testing.T) {
 	repo := newMockRepo()
 	svc := NewService(repo, nil)
@@ -610,8 +725,9 @@ func TestService_NewServiceWithMetrics(t *testing.T) {
 
 // mockDBRepo implements ports.Repository for local DB testing
 type mockDBRepo struct {
-	appointments map[string]domain.Appointment
-	deleteError  bool
+	appointments  map[string]domain.Appointment
+	deleteError   bool
+	metadataReset []string
 }
 
 func (m *mockDBRepo) SavePatient(patient domain.Patient) error            { return nil }
@@ -628,12 +744,32 @@ func (m *mockDBRepo) GetPatientMedia(id string) ([]domain.PatientMedia, error) {
 func (m *mockDBRepo) GetMediaByID(id string) (*domain.PatientMedia, error) { return nil, nil }
 func (m *mockDBRepo) UpdateMediaStatus(id string, s string, t string) error { return nil }
 func (m *mockDBRepo) CreateBackup() (string, error)                       { return "", nil }
-func (m *mockDBRepo) GetAppointmentHistory(id string) ([]domain.Appointment, error) { return nil, nil }
+func (m *mockDBRepo) GetAppointmentHistory(id string) ([]domain.Appointment, error) {
+	var appts []domain.Appointment
+	for _, appt := range m.appointments {
+		if appt.CustomerTgID == id {
+			appts = append(appts, appt)
+		}
+	}
+	return appts, nil
+}
 func (m *mockDBRepo) GetAppointmentHistoryPaginated(id string, limit, offset int) ([]domain.Appointment, bool, error) {
 	return nil, false, nil
 }
-func (m *mockDBRepo) UpsertAppointments(a []domain.Appointment) error     { return nil }
-func (m *mockDBRepo) SaveAppointmentMetadata(id string, t *time.Time, r map[string]bool) error { return nil }
+func (m *mockDBRepo) UpsertAppointments(a []domain.Appointment) error {
+	if m.appointments != nil {
+		for _, appt := range a {
+			m.appointments[appt.ID] = appt
+		}
+	}
+	return nil
+}
+func (m *mockDBRepo) SaveAppointmentMetadata(id string, t *time.Time, r map[string]bool) error {
+	if t == nil && len(r) == 0 {
+		m.metadataReset = append(m.metadataReset, id)
+	}
+	return nil
+}
 func (m *mockDBRepo) GetAppointmentMetadata(id string) (*time.Time, map[string]bool, error) {
 	return nil, nil, nil
 }
@@ -645,3 +781,108 @@ func (m *mockDBRepo) DeleteAppointment(id string) error {
 	delete(m.appointments, id)
 	return nil
 }
+
+func TestService_RescheduleAppointment(t *testing.T) {
+	ctx := context.Background()
+
+	// a1 is booked 10:00-11:00 and someone else 11:00-12:00; FreeBusy reports
+	// them as one merged block
+	setup := func(t *testing.T) (*Service, *mockRepo, *mockDBRepo) {
+		t.Helper()
+		svc := newScheduleTestService(t, nil)
+		repo := svc.repo.(*mockRepo)
+		start := scheduleTestDate.Add(10 * time.Hour)
+		repo.appointments["a1"] = &domain.Appointment{
+			ID: "a1", Service: domain.Service{ID: "1", Name: "Massage"}, CustomerTgID: "1",
+			StartTime: start, EndTime: start.Add(time.Hour), Duration: 60,
+		}
+		repo.getFreeBusyFunc = func(ctx context.Context, from, to time.Time) ([]domain.TimeSlot, error) {
+			return []domain.TimeSlot{{Start: start, End: start.Add(2 * time.Hour)}}, nil
+		}
+		db := &mockDBRepo{appointments: make(map[string]domain.Appointment)}
+		svc.dbRepo = db
+		return svc, repo, db
+	}
+	at := func(hour, minute int) time.Time {
+		return scheduleTestDate.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	t.Run("overlapping its own slot", func(t *testing.T) {
+		svc, repo, db := setup(t)
+		got, err := svc.RescheduleAppointment(ctx, "a1", at(9, 30))
+		if err != nil {
+			t.Fatalf("RescheduleAppointment failed: %v", err)
+		}
+		if !got.StartTime.Equal(at(9, 30)) || !got.EndTime.Equal(at(10, 30)) {
+			t.Errorf("unexpected new time %v-%v", got.StartTime, got.EndTime)
+		}
+		if !repo.appointments["a1"].StartTime.Equal(at(9, 30)) {
+			t.Error("calendar event should be moved in place")
+		}
+		if len(repo.appointments) != 1 {
+			t.Errorf("reschedule must not create a new event, got %d", len(repo.appointments))
+		}
+		if !db.appointments["a1"].StartTime.Equal(at(9, 30)) {
+			t.Error("local copy should be updated")
+		}
+		if len(db.metadataReset) != 1 || db.metadataReset[0] != "a1" {
+			t.Errorf("reminder state should be reset, got %v", db.metadataReset)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		id      string
+		start   time.Time
+		wantErr error
+	}{
+		{"conflict with next booking", "a1", at(10, 30), domain.ErrSlotUnavailable},
+		{"outside working hours", "a1", at(20, 0), domain.ErrOutsideWorkingHours},
+		{"in the past", "a1", scheduleTestDate.AddDate(0, 0, -8), domain.ErrAppointmentInPast},
+		{"not found", "missing", at(15, 0), domain.ErrAppointmentNotFound},
+		{"empty ID", "", at(15, 0), domain.ErrInvalidID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, db := setup(t)
+			if _, err := svc.RescheduleAppointment(ctx, tt.id, tt.start); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+			if !repo.appointments["a1"].StartTime.Equal(at(10, 0)) || len(db.metadataReset) != 0 {
+				t.Error("failed reschedule must leave the appointment untouched")
+			}
+		})
+	}
+}
+
+func TestService_CancelAppointment_SlotFreedHook(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	repo := svc.repo.(*mockRepo)
+	future := scheduleTestDate.Add(10 * time.Hour)
+	repo.appointments["future"] = &domain.Appointment{ID: "future", StartTime: future, EndTime: future.Add(time.Hour)}
+	repo.appointments["past"] = &domain.Appointment{ID: "past", StartTime: svc.NowFunc().Add(-time.Hour)}
+
+	freed := make(chan domain.Appointment, 2)
+	svc.SetSlotFreedHook(func(ctx context.Context, appt domain.Appointment) { freed <- appt })
+
+	if err := svc.CancelAppointment(context.Background(), "past"); err != nil {
+		t.Fatalf("CancelAppointment(past) error = %v", err)
+	}
+	if err := svc.CancelAppointment(context.Background(), "future"); err != nil {
+		t.Fatalf("CancelAppointment(future) error = %v", err)
+	}
+
+	select {
+	case appt := <-freed:
+		if appt.ID != "future" || !appt.StartTime.Equal(future) {
+			t.Errorf("hook got %+v, want the future appointment", appt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slot-freed hook was not called")
+	}
+	select {
+	case appt := <-freed:
+		t.Errorf("hook called for %s, want only future slots", appt.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
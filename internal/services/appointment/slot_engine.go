@@ -3,6 +3,7 @@ package appointment
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/kfilin/massage-bot/internal/domain"
@@ -10,6 +11,9 @@ import (
 )
 
 // GetAvailableTimeSlots returns available time slots for a given date and duration.
+// With therapists registered it returns the union of every active therapist's
+// free slots ("any available"); otherwise the default calendar and shared
+// schedule are used.
 // This logic was extracted from service.go to reduce complexity.
 func (s *Service) GetAvailableTimeSlots(ctx context.Context, date time.Time, durationMinutes int) ([]domain.TimeSlot, error) {
 	logging.Debugf("DEBUG: GetAvailableTimeSlots called for date: %s, duration: %d minutes.", date.Format("2006-01-02"), durationMinutes)
@@ -18,62 +22,139 @@ func (s *Service) GetAvailableTimeSlots(ctx context.Context, date time.Time, dur
 		return nil, domain.ErrInvalidDuration
 	}
 
+	therapists, err := s.activeTherapists()
+	if err != nil {
+		logging.Errorf("ERROR: Failed to load therapists: %v", err)
+		return nil, fmt.Errorf("failed to load therapists: %w", err)
+	}
+	if len(therapists) == 0 {
+		return s.slotsFor(ctx, nil, date, durationMinutes)
+	}
+
+	var perTherapist [][]domain.TimeSlot
+	for i := range therapists {
+		slots, err := s.slotsFor(ctx, &therapists[i], date, durationMinutes)
+		if err != nil {
+			return nil, err
+		}
+		perTherapist = append(perTherapist, slots)
+	}
+	availableSlots := mergeSlots(perTherapist...)
+	logging.Debugf("DEBUG: GetAvailableTimeSlots merged %d therapists into %d slots.", len(therapists), len(availableSlots))
+	return availableSlots, nil
+}
+
+// slotsFor computes free slots for one therapist (nil means the default
+// calendar and the shared schedule).
+func (s *Service) slotsFor(ctx context.Context, t *domain.Therapist, date time.Time, durationMinutes int) ([]domain.TimeSlot, error) {
 	// Ensure the date is in the correct timezone for working hours logic
 	dateInApptTimezone := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+	availableSlots, err := s.slotsInRange(ctx, t, dateInApptTimezone, 1, durationMinutes)
+	if err != nil {
+		return nil, err
+	}
+	logging.Debugf("DEBUG: GetAvailableTimeSlots finished. Found %d available slots.", len(availableSlots))
+	return availableSlots, nil
+}
 
-	// Fetch busy intervals for the entire day
-	timeMin := dateInApptTimezone
-	timeMax := dateInApptTimezone.Add(24 * time.Hour)
+// slotsInRange computes free slots for one therapist on days consecutive
+// days starting at the midnight start, with a single FreeBusy call.
+func (s *Service) slotsInRange(ctx context.Context, t *domain.Therapist, start time.Time, days int, durationMinutes int) ([]domain.TimeSlot, error) {
+	// Fetch busy intervals for the entire range
+	timeMin := start
+	timeMax := start.Add(time.Duration(days) * 24 * time.Hour)
 
 	// Use cached FreeBusy if available (uses logic from service.go)
-	busySlots, err := s.getFreeBusy(ctx, timeMin, timeMax)
+	busySlots, err := s.getFreeBusy(ctx, s.calendarFor(t), timeMin, timeMax)
 	if err != nil {
 		logging.Errorf("ERROR: Failed to fetch FreeBusy: %v", err)
 		return nil, fmt.Errorf("failed to fetch available slots: %w", err)
 	}
+	// Slots others are in the middle of booking are not offered
+	busySlots = s.withHolds(ctx, t, busySlots, timeMin, timeMax)
 
-	var availableSlots []domain.TimeSlot
-
-	// Iterate through the working day in 1-hour steps
-	// Note: WorkDayStartHour and WorkDayEndHour are defined in service.go constants
-	currentSlotStart := time.Date(dateInApptTimezone.Year(), dateInApptTimezone.Month(), dateInApptTimezone.Day(), domain.WorkDayStartHour, 0, 0, 0, domain.ApptTimeZone)
-	workDayEnd := time.Date(dateInApptTimezone.Year(), dateInApptTimezone.Month(), dateInApptTimezone.Day(), domain.WorkDayEndHour, 0, 0, 0, domain.ApptTimeZone)
-
-	// Default step interval - could be configurable
-	stepInterval := 60 * time.Minute
+	// Open intervals come from the working schedule (weekday hours minus breaks,
+	// with date exceptions applied). A closed day has none.
+	schedule, err := s.loadSchedule()
+	if err != nil {
+		logging.Errorf("ERROR: Failed to load working schedule: %v", err)
+		return nil, fmt.Errorf("failed to load working schedule: %w", err)
+	}
+	schedule = schedule.ForTherapist(therapistKey(t))
 
-	nowInApptTimezone := s.NowFunc().In(domain.ApptTimeZone)
+	now := s.NowFunc().In(domain.ApptTimeZone)
+	policy := s.getSlotPolicy()
+	duration := time.Duration(durationMinutes) * time.Minute
+	var availableSlots []domain.TimeSlot
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i)
+		availableSlots = append(availableSlots, buildSlots(schedule.OpenIntervals(day), busySlots, duration, now, policy)...)
+	}
+	return availableSlots, nil
+}
 
-	// Loop until the potential slot end exceeds the workday end
-	for currentSlotStart.Add(time.Duration(durationMinutes)*time.Minute).Before(workDayEnd) || currentSlotStart.Add(time.Duration(durationMinutes)*time.Minute).Equal(workDayEnd) {
-		currentSlotEnd := currentSlotStart.Add(time.Duration(durationMinutes) * time.Minute)
+// buildSlots lays out candidate starts inside each open interval and keeps
+// those that are in the future and clear of busy time (including buffers).
+//
+// Candidates come from a grid of policy.StepMinutes starting at the interval
+// start. With PackToBookings, a start right after each busy block (plus the
+// buffer gap) is added too, so short services fill the gaps the grid leaves.
+// The appointment itself must fit inside the interval; buffers only separate
+// it from other bookings and may extend past opening hours.
+func buildSlots(openIntervals, busySlots []domain.TimeSlot, duration time.Duration, now time.Time, policy domain.SlotPolicy) []domain.TimeSlot {
+	step := time.Duration(policy.StepMinutes) * time.Minute
+	if step <= 0 {
+		step = 60 * time.Minute
+	}
 
-		// Check if the slot is in the past
-		if currentSlotStart.Before(nowInApptTimezone) {
-			currentSlotStart = currentSlotStart.Add(stepInterval)
-			continue
+	var availableSlots []domain.TimeSlot
+	for _, interval := range openIntervals {
+		var starts []time.Time
+		for t := interval.Start; !t.Add(duration).After(interval.End); t = t.Add(step) {
+			starts = append(starts, t)
 		}
-
-		isAvailable := true
-		for _, busy := range busySlots {
-			// Check for overlap: [start, end)
-			// Overlap logic: Start < BusyEnd AND End > BusyStart
-			if currentSlotStart.Before(busy.End) && currentSlotEnd.After(busy.Start) {
-				isAvailable = false
-				break
+		if policy.PackToBookings {
+			for _, busy := range busySlots {
+				t := busy.End.Add(policy.Gap())
+				if !t.Before(interval.Start) && !t.Add(duration).After(interval.End) {
+					starts = append(starts, t)
+				}
 			}
+			sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
 		}
 
-		if isAvailable {
-			availableSlots = append(availableSlots, domain.TimeSlot{
-				Start: currentSlotStart,
-				End:   currentSlotEnd,
-			})
+		for i, start := range starts {
+			if i > 0 && start.Equal(starts[i-1]) {
+				continue
+			}
+			end := start.Add(duration)
+
+			// Check if the slot is in the past
+			if start.Before(now) {
+				continue
+			}
+			if policy.Conflicts(start, end, busySlots) {
+				continue
+			}
+			availableSlots = append(availableSlots, domain.TimeSlot{Start: start, End: end})
 		}
+	}
+	return availableSlots
+}
 
-		currentSlotStart = currentSlotStart.Add(stepInterval)
+// SetSlotPolicy changes the slot step, buffers and packing mode.
+func (s *Service) SetSlotPolicy(policy domain.SlotPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
 	}
+	s.slotPolicyMu.Lock()
+	s.slotPolicy = policy
+	s.slotPolicyMu.Unlock()
+	return nil
+}
 
-	logging.Debugf("DEBUG: GetAvailableTimeSlots finished. Found %d available slots.", len(availableSlots))
-	return availableSlots, nil
+func (s *Service) getSlotPolicy() domain.SlotPolicy {
+	s.slotPolicyMu.RLock()
+	defer s.slotPolicyMu.RUnlock()
+	return s.slotPolicy
 }
@@ -2,6 +2,8 @@ package appointment
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -40,7 +42,7 @@ func TestGetAvailableTimeSlots_AllFree(t *testing.T) {
 	yesterday := time.Now().UTC().Add(-24 * time.Hour)
 	svc.NowFunc = func() time.Time { return yesterday }
 
-	date := time.Now().UTC()
+	date := nextWorkday(time.Now().UTC())
 	slots, err := svc.GetAvailableTimeSlots(context.Background(), date, 60)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -54,7 +56,7 @@ func TestGetAvailableTimeSlots_AllFree(t *testing.T) {
 
 func TestGetAvailableTimeSlots_BusyBlocking(t *testing.T) {
 	domain.ApptTimeZone = time.UTC
-	date := time.Now().UTC()
+	date := nextWorkday(time.Now().UTC())
 	// Busy from 10:00 to 11:00
 	dayBase := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 	busyStart := dayBase.Add(10 * time.Hour)
@@ -88,7 +90,7 @@ func TestGetAvailableTimeSlots_BusyBlocking(t *testing.T) {
 
 func TestGetAvailableTimeSlots_PastSlotsExcluded(t *testing.T) {
 	domain.ApptTimeZone = time.UTC
-	date := time.Now().UTC()
+	date := nextWorkday(time.Now().UTC())
 	dayBase := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 
 	repo := newMockRepo()
@@ -117,7 +119,7 @@ func TestGetAvailableTimeSlots_PastSlotsExcluded(t *testing.T) {
 
 func TestGetAvailableTimeSlots_AllBusy(t *testing.T) {
 	domain.ApptTimeZone = time.UTC
-	date := time.Now().UTC()
+	date := nextWorkday(time.Now().UTC())
 	dayBase := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 	// Block the entire workday
 	busyStart := dayBase.Add(time.Duration(domain.WorkDayStartHour) * time.Hour)
@@ -142,7 +144,7 @@ func TestGetAvailableTimeSlots_AllBusy(t *testing.T) {
 
 func TestGetAvailableTimeSlots_40MinDuration(t *testing.T) {
 	domain.ApptTimeZone = time.UTC
-	date := time.Now().UTC()
+	date := nextWorkday(time.Now().UTC())
 
 	repo := newMockRepo()
 	repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
@@ -163,3 +165,186 @@ func TestGetAvailableTimeSlots_40MinDuration(t *testing.T) {
 		t.Errorf("Expected 9 slots for 40-min service, got %d", len(slots))
 	}
 }
+
+func TestBuildSlots(t *testing.T) {
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	at := func(h, m int) time.Time { return day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute) }
+	hm := func(ts time.Time) string { return ts.Format("15:04") }
+	workday := []domain.TimeSlot{{Start: at(9, 0), End: at(18, 0)}}
+	past := day.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		open     []domain.TimeSlot
+		busy     []domain.TimeSlot
+		duration int
+		now      time.Time
+		policy   domain.SlotPolicy
+		want     []string
+	}{
+		{
+			name:     "hourly default",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(12, 0)}},
+			duration: 60, now: past, policy: domain.DefaultSlotPolicy(),
+			want: []string{"09:00", "10:00", "11:00"},
+		},
+		{
+			name:     "30 minute step fits more 40 minute services",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(11, 0)}},
+			duration: 40, now: past, policy: domain.SlotPolicy{StepMinutes: 30},
+			want: []string{"09:00", "09:30", "10:00"},
+		},
+		{
+			name:     "15 minute step last start ends exactly at close",
+			open:     []domain.TimeSlot{{Start: at(17, 0), End: at(18, 0)}},
+			duration: 50, now: past, policy: domain.SlotPolicy{StepMinutes: 15},
+			want: []string{"17:00"},
+		},
+		{
+			name:     "service longer than interval",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(9, 30)}},
+			duration: 40, now: past, policy: domain.SlotPolicy{StepMinutes: 15},
+			want: nil,
+		},
+		{
+			name:     "buffers push neighbours away from a booking",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(13, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(10, 0), End: at(11, 0)}},
+			duration: 45, now: past, policy: domain.SlotPolicy{StepMinutes: 15, BufferAfterMinutes: 15},
+			// 09:00-09:45 leaves the 15 min gap; 09:15 would end at 10:00 with no gap.
+			want: []string{"09:00", "11:15", "11:30", "11:45", "12:00", "12:15"},
+		},
+		{
+			name:     "before and after buffers add up",
+			open:     []domain.TimeSlot{{Start: at(11, 0), End: at(13, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(10, 0), End: at(11, 0)}},
+			duration: 60, now: past, policy: domain.SlotPolicy{StepMinutes: 30, BufferBeforeMinutes: 10, BufferAfterMinutes: 10},
+			want: []string{"11:30", "12:00"},
+		},
+		{
+			name:     "buffer may extend past opening hours",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(10, 0)}},
+			duration: 60, now: past, policy: domain.SlotPolicy{StepMinutes: 60, BufferBeforeMinutes: 15, BufferAfterMinutes: 15},
+			want: []string{"09:00"},
+		},
+		{
+			name:     "grid leaves gap after odd-length booking",
+			open:     workday,
+			busy:     []domain.TimeSlot{{Start: at(9, 0), End: at(9, 50)}},
+			duration: 40, now: at(9, 0), policy: domain.SlotPolicy{StepMinutes: 60},
+			want: []string{"10:00", "11:00", "12:00", "13:00", "14:00", "15:00", "16:00", "17:00"},
+		},
+		{
+			name:     "packing starts right after the previous booking",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(12, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(9, 0), End: at(9, 50)}},
+			duration: 40, now: past, policy: domain.SlotPolicy{StepMinutes: 60, PackToBookings: true},
+			want: []string{"09:50", "10:00", "11:00"},
+		},
+		{
+			name:     "packing honours buffers",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(12, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(9, 0), End: at(9, 50)}},
+			duration: 40, now: past, policy: domain.SlotPolicy{StepMinutes: 60, BufferAfterMinutes: 10, PackToBookings: true},
+			want: []string{"10:00", "11:00"},
+		},
+		{
+			name:     "packed start that collides with the next booking is dropped",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(12, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(9, 0), End: at(9, 50)}, {Start: at(10, 0), End: at(11, 0)}},
+			duration: 40, now: past, policy: domain.SlotPolicy{StepMinutes: 60, PackToBookings: true},
+			want: []string{"11:00"},
+		},
+		{
+			name:     "packing ignores bookings outside the interval",
+			open:     []domain.TimeSlot{{Start: at(14, 0), End: at(16, 0)}},
+			busy:     []domain.TimeSlot{{Start: at(11, 0), End: at(11, 20)}},
+			duration: 60, now: past, policy: domain.SlotPolicy{StepMinutes: 60, PackToBookings: true},
+			want: []string{"14:00", "15:00"},
+		},
+		{
+			name:     "past starts are skipped",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(12, 0)}},
+			duration: 30, now: at(10, 10), policy: domain.SlotPolicy{StepMinutes: 30},
+			want: []string{"10:30", "11:00", "11:30"},
+		},
+		{
+			name:     "grid restarts at each interval after a break",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(10, 0)}, {Start: at(13, 30), End: at(15, 0)}},
+			duration: 60, now: past, policy: domain.SlotPolicy{StepMinutes: 30},
+			want: []string{"09:00", "13:30", "14:00"},
+		},
+		{
+			name:     "zero step falls back to hourly",
+			open:     []domain.TimeSlot{{Start: at(9, 0), End: at(11, 0)}},
+			duration: 60, now: past, policy: domain.SlotPolicy{},
+			want: []string{"09:00", "10:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slots := buildSlots(tt.open, tt.busy, time.Duration(tt.duration)*time.Minute, tt.now, tt.policy)
+			var got []string
+			for _, s := range slots {
+				got = append(got, hm(s.Start))
+				if s.End.Sub(s.Start) != time.Duration(tt.duration)*time.Minute {
+					t.Errorf("slot %s has wrong length %s", hm(s.Start), s.End.Sub(s.Start))
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetSlotPolicy(t *testing.T) {
+	svc := NewService(newMockRepo(), nil)
+
+	for _, bad := range []domain.SlotPolicy{
+		{StepMinutes: 20},
+		{StepMinutes: 30, BufferBeforeMinutes: -5},
+		{StepMinutes: 30, BufferAfterMinutes: domain.MaxSlotBufferMinutes + 1},
+	} {
+		if err := svc.SetSlotPolicy(bad); !errors.Is(err, domain.ErrInvalidSchedule) {
+			t.Errorf("SetSlotPolicy(%+v): expected ErrInvalidSchedule, got %v", bad, err)
+		}
+	}
+	if got := svc.getSlotPolicy(); got != domain.DefaultSlotPolicy() {
+		t.Errorf("invalid policy must not replace the default, got %+v", got)
+	}
+
+	good := domain.SlotPolicy{StepMinutes: 15, BufferAfterMinutes: 10, PackToBookings: true}
+	if err := svc.SetSlotPolicy(good); err != nil {
+		t.Fatalf("SetSlotPolicy failed: %v", err)
+	}
+	if got := svc.getSlotPolicy(); got != good {
+		t.Errorf("policy = %+v, want %+v", got, good)
+	}
+}
+
+func TestCreateAppointment_RespectsBuffers(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	day := nextWorkday(time.Now().UTC())
+	base := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	repo := newMockRepo()
+	repo.getFreeBusyFunc = func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+		return []domain.TimeSlot{{Start: base.Add(10 * time.Hour), End: base.Add(11 * time.Hour)}}, nil
+	}
+	svc := NewService(repo, nil)
+	if err := svc.SetSlotPolicy(domain.SlotPolicy{StepMinutes: 15, BufferAfterMinutes: 15}); err != nil {
+		t.Fatalf("SetSlotPolicy failed: %v", err)
+	}
+
+	appt := &domain.Appointment{
+		Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+		StartTime:    base.Add(11 * time.Hour),
+		Duration:     60,
+		CustomerName: "Alice",
+	}
+	if _, err := svc.CreateAppointment(context.Background(), appt); !errors.Is(err, domain.ErrSlotUnavailable) {
+		t.Errorf("booking inside the cleanup buffer: expected ErrSlotUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package appointment
+
+import (
+	"context"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// DefaultSlotHoldTTL is how long a picked slot stays held when no TTL is
+// configured.
+const DefaultSlotHoldTTL = 10 * time.Minute
+
+// SetSlotHoldRepository enables slot holds: a slot picked in the booking
+// conversation is hidden from other patients for ttl, or until it is booked
+// or released.
+func (s *Service) SetSlotHoldRepository(repo ports.SlotHoldRepository, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultSlotHoldTTL
+	}
+	s.holdRepo = repo
+	s.holdTTL = ttl
+}
+
+// HoldSlot holds the slot at start for holderID, replacing their previous
+// hold. The slot is checked like a booking, so domain.ErrSlotUnavailable
+// means it was booked or held by someone else in the meantime. Without a
+// hold repository only the check is made.
+func (s *Service) HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+	if holderID == "" {
+		return domain.ErrInvalidID
+	}
+	if durationMinutes <= 0 {
+		return domain.ErrInvalidDuration
+	}
+	ctx = domain.WithSlotHolder(ctx, holderID)
+	appt := &domain.Appointment{
+		StartTime:   start,
+		EndTime:     start.Add(time.Duration(durationMinutes) * time.Minute),
+		Duration:    durationMinutes,
+		TherapistID: therapistID,
+	}
+
+	unlock, err := s.lockBookings(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	therapist, err := s.assignTherapist(ctx, appt)
+	if err != nil {
+		return err
+	}
+	if err := s.checkAvailability(ctx, therapist, s.calendarFor(therapist), appt, nil); err != nil {
+		return err
+	}
+	if s.holdRepo == nil {
+		return nil
+	}
+
+	now := s.NowFunc()
+	hold := domain.SlotHold{
+		HolderID:    holderID,
+		TherapistID: therapistKey(therapist),
+		Start:       appt.StartTime,
+		End:         appt.EndTime,
+		ExpiresAt:   now.Add(s.holdTTL),
+	}
+	if err := s.holdRepo.PlaceSlotHold(hold, now); err != nil {
+		logging.Errorf("ERROR: Failed to hold slot %s for %s: %v", start.Format("2006-01-02 15:04"), holderID, err)
+		return err
+	}
+	logging.Debugf("DEBUG: Slot %s held for %s until %s", start.Format("2006-01-02 15:04"), holderID, hold.ExpiresAt.Format("15:04"))
+	return nil
+}
+
+// ReleaseSlotHold gives up holderID's hold, if any.
+func (s *Service) ReleaseSlotHold(ctx context.Context, holderID string) error {
+	if s.holdRepo == nil || holderID == "" {
+		return nil
+	}
+	return s.holdRepo.ReleaseSlotHold(holderID)
+}
+
+// withHolds adds the slots other people hold on t's calendar to busy. The
+// holder acting through ctx does not see their own hold. Holds are best
+// effort: when they cannot be read, busy is returned as is.
+func (s *Service) withHolds(ctx context.Context, t *domain.Therapist, busy []domain.TimeSlot, from, to time.Time) []domain.TimeSlot {
+	if s.holdRepo == nil {
+		return busy
+	}
+	holds, err := s.holdRepo.GetSlotHolds(therapistKey(t), from, to, s.NowFunc())
+	if err != nil {
+		logging.Warnf("WARNING: Failed to load slot holds, ignoring them: %v", err)
+		return busy
+	}
+	holder := domain.SlotHolderFrom(ctx)
+	// Copy so the cached FreeBusy result is not modified
+	merged := append([]domain.TimeSlot{}, busy...)
+	for _, h := range holds {
+		if h.HolderID != holder {
+			merged = append(merged, domain.TimeSlot{Start: h.Start, End: h.End})
+		}
+	}
+	return merged
+}
+
+// releaseOwnHold drops the hold of the holder acting through ctx once their
+// booking went through.
+func (s *Service) releaseOwnHold(ctx context.Context) {
+	holder := domain.SlotHolderFrom(ctx)
+	if err := s.ReleaseSlotHold(ctx, holder); err != nil {
+		logging.Warnf("WARNING: Failed to release slot hold of %s: %v", holder, err)
+	}
+}
@@ -0,0 +1,150 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// mockHoldRepo keeps slot holds in memory.
+type mockHoldRepo struct {
+	holds map[string]domain.SlotHold
+}
+
+func newMockHoldRepo() *mockHoldRepo {
+	return &mockHoldRepo{holds: make(map[string]domain.SlotHold)}
+}
+
+func (m *mockHoldRepo) PlaceSlotHold(hold domain.SlotHold, now time.Time) error {
+	m.holds[hold.HolderID] = hold
+	return nil
+}
+
+func (m *mockHoldRepo) ReleaseSlotHold(holderID string) error {
+	delete(m.holds, holderID)
+	return nil
+}
+
+func (m *mockHoldRepo) GetSlotHolds(therapistID string, from, to, now time.Time) ([]domain.SlotHold, error) {
+	var out []domain.SlotHold
+	for _, h := range m.holds {
+		if h.TherapistID == therapistID && h.Start.Before(to) && h.End.After(from) && h.ExpiresAt.After(now) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func hasSlotAt(slots []domain.TimeSlot, start time.Time) bool {
+	for _, s := range slots {
+		if s.Start.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestService_HoldSlot(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	holds := newMockHoldRepo()
+	svc.SetSlotHoldRepository(holds, 0)
+	start := scheduleTestDate.Add(10 * time.Hour)
+
+	if err := svc.HoldSlot(ctx, "100", "", start, 60); err != nil {
+		t.Fatalf("HoldSlot failed: %v", err)
+	}
+	hold, ok := holds.holds["100"]
+	if !ok || !hold.Start.Equal(start) || !hold.End.Equal(start.Add(time.Hour)) {
+		t.Fatalf("hold not stored as expected: %+v", holds.holds)
+	}
+	if want := svc.NowFunc().Add(DefaultSlotHoldTTL); !hold.ExpiresAt.Equal(want) {
+		t.Errorf("hold expires at %v, want %v", hold.ExpiresAt, want)
+	}
+
+	// Hidden from everyone but the holder
+	others, err := svc.GetAvailableTimeSlots(domain.WithSlotHolder(ctx, "200"), scheduleTestDate, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasSlotAt(others, start) {
+		t.Error("a held slot must not be offered to other users")
+	}
+	own, err := svc.GetAvailableTimeSlots(domain.WithSlotHolder(ctx, "100"), scheduleTestDate, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasSlotAt(own, start) {
+		t.Error("the holder must still see their slot")
+	}
+
+	// Nobody else can hold or book it
+	if err := svc.HoldSlot(ctx, "200", "", start.Add(30*time.Minute), 60); !errors.Is(err, domain.ErrSlotUnavailable) {
+		t.Errorf("expected ErrSlotUnavailable for an overlapping hold, got %v", err)
+	}
+	appt := func() *domain.Appointment {
+		return &domain.Appointment{Service: domain.Service{ID: "1", Name: "Massage"}, StartTime: start, Duration: 60, CustomerName: "Bob"}
+	}
+	if _, err := svc.CreateAppointment(domain.WithSlotHolder(ctx, "200"), appt()); !errors.Is(err, domain.ErrSlotUnavailable) {
+		t.Errorf("expected ErrSlotUnavailable when booking someone else's hold, got %v", err)
+	}
+
+	// Moving the hold frees the old slot
+	if err := svc.HoldSlot(ctx, "100", "", start.Add(2*time.Hour), 60); err != nil {
+		t.Fatalf("moving the hold failed: %v", err)
+	}
+	if len(holds.holds) != 1 || !holds.holds["100"].Start.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("expected one hold at the new time, got %+v", holds.holds)
+	}
+
+	// The holder books and the hold goes away
+	if _, err := svc.CreateAppointment(domain.WithSlotHolder(ctx, "100"), &domain.Appointment{
+		Service: domain.Service{ID: "1", Name: "Massage"}, StartTime: start.Add(2 * time.Hour), Duration: 60, CustomerName: "Alice",
+	}); err != nil {
+		t.Fatalf("holder could not book their slot: %v", err)
+	}
+	if len(holds.holds) != 0 {
+		t.Errorf("hold should be released after booking, got %+v", holds.holds)
+	}
+}
+
+func TestService_ReleaseSlotHold(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	if err := svc.ReleaseSlotHold(ctx, "100"); err != nil {
+		t.Errorf("without a repository release should be a no-op, got %v", err)
+	}
+
+	holds := newMockHoldRepo()
+	svc.SetSlotHoldRepository(holds, 5*time.Minute)
+	start := scheduleTestDate.Add(10 * time.Hour)
+	if err := svc.HoldSlot(ctx, "100", "", start, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.ReleaseSlotHold(ctx, "100"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.HoldSlot(ctx, "200", "", start, 60); err != nil {
+		t.Errorf("a released slot should be free again, got %v", err)
+	}
+	if got := holds.holds["200"].ExpiresAt; !got.Equal(svc.NowFunc().Add(5 * time.Minute)) {
+		t.Errorf("expected the configured TTL, hold expires at %v", got)
+	}
+}
+
+func TestService_HoldSlot_Invalid(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	start := scheduleTestDate.Add(10 * time.Hour)
+	if err := svc.HoldSlot(context.Background(), "", "", start, 60); !errors.Is(err, domain.ErrInvalidID) {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+	if err := svc.HoldSlot(context.Background(), "100", "", start, 0); !errors.Is(err, domain.ErrInvalidDuration) {
+		t.Errorf("expected ErrInvalidDuration, got %v", err)
+	}
+	if err := svc.HoldSlot(context.Background(), "100", "", scheduleTestDate.Add(22*time.Hour), 60); !errors.Is(err, domain.ErrOutsideWorkingHours) {
+		t.Errorf("expected ErrOutsideWorkingHours, got %v", err)
+	}
+}
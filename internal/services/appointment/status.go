@@ -0,0 +1,122 @@
+package appointment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// SetStatusRepository enables the appointment lifecycle. Without it every
+// appointment reads as booked and SetAppointmentStatus returns
+// domain.ErrStatusUnavailable.
+func (s *Service) SetStatusRepository(repo ports.AppointmentStatusRepository) {
+	s.statusRepo = repo
+}
+
+// SetStatusChangedHook registers fn to be told about every lifecycle status
+// change made through SetAppointmentStatus, e.g. to give back a package
+// session for a visit later marked a no-show. fn runs before
+// SetAppointmentStatus returns.
+func (s *Service) SetStatusChangedHook(fn func(ctx context.Context, appt domain.Appointment, status domain.AppointmentStatus)) {
+	s.statusChanged = fn
+}
+
+// GetAppointmentStatus returns the lifecycle status of an appointment.
+func (s *Service) GetAppointmentStatus(ctx context.Context, appointmentID string) (domain.AppointmentStatus, error) {
+	if appointmentID == "" {
+		return "", domain.ErrInvalidID
+	}
+	if s.statusRepo == nil {
+		return domain.StatusBooked, nil
+	}
+	return s.statusRepo.GetAppointmentStatus(appointmentID)
+}
+
+// SetAppointmentStatus moves an appointment to a new lifecycle status.
+// Moves not allowed by the state machine return domain.ErrInvalidStatusChange;
+// outcomes (completed, no_show) cannot be set before the appointment starts.
+// Setting the current status again is a no-op. Cancellation statuses only
+// record who cancelled; the caller cancels the booking itself.
+func (s *Service) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	if s.statusRepo == nil {
+		return domain.ErrStatusUnavailable
+	}
+	if appt == nil || appt.ID == "" {
+		return domain.ErrInvalidID
+	}
+	if !status.Valid() {
+		return fmt.Errorf("%w: unknown status %q", domain.ErrInvalidStatusChange, status)
+	}
+	if status.IsOutcome() && appt.StartTime.After(s.NowFunc()) {
+		return domain.ErrOutcomeBeforeStart
+	}
+
+	current, err := s.statusRepo.GetAppointmentStatus(appt.ID)
+	if err != nil {
+		return err
+	}
+	if current == status {
+		return nil
+	}
+	if !current.CanTransitionTo(status) {
+		return fmt.Errorf("%w: %s → %s", domain.ErrInvalidStatusChange, current, status)
+	}
+	if err := s.statusRepo.UpdateAppointmentStatus(*appt, current, status); err != nil {
+		return err
+	}
+	logging.Infof("Appointment %s: %s → %s", appt.ID, current, status)
+	s.writeEventStatus(ctx, appt, status)
+	if s.statusChanged != nil {
+		s.statusChanged(ctx, *appt, status)
+	}
+	return nil
+}
+
+// GetPatientStatusCounts counts a patient's appointments per lifecycle
+// status, e.g. for the no-show count on the patient card.
+func (s *Service) GetPatientStatusCounts(ctx context.Context, customerTgID string) (map[domain.AppointmentStatus]int, error) {
+	if s.statusRepo == nil {
+		return map[domain.AppointmentStatus]int{}, nil
+	}
+	return s.statusRepo.CountPatientStatuses(customerTgID)
+}
+
+// unconfirmMoved puts a confirmed appointment back to booked after it was
+// rescheduled, since the patient confirmed the old time; best effort only.
+func (s *Service) unconfirmMoved(appt *domain.Appointment) {
+	if s.statusRepo == nil {
+		return
+	}
+	current, err := s.statusRepo.GetAppointmentStatus(appt.ID)
+	if err != nil || current != domain.StatusConfirmed {
+		return
+	}
+	if err := s.statusRepo.UpdateAppointmentStatus(*appt, current, domain.StatusBooked); err != nil {
+		logging.Warnf("WARNING: Failed to reset status of moved appointment %s: %v", appt.ID, err)
+		return
+	}
+	s.writeEventStatus(context.Background(), appt, domain.StatusBooked)
+}
+
+// writeEventStatus copies a new status onto the calendar event, for
+// calendars that keep it there; best effort only. Cancelled appointments
+// are skipped, their events are deleted.
+func (s *Service) writeEventStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) {
+	if status.IsCancelled() {
+		return
+	}
+	var therapist *domain.Therapist
+	if appt.TherapistID != "" {
+		therapist, _ = s.findTherapist(appt.TherapistID)
+	}
+	writer, ok := s.calendarFor(therapist).(ports.EventStatusWriter)
+	if !ok {
+		return
+	}
+	if err := writer.SetEventStatus(ctx, appt.ID, status); err != nil {
+		logging.Warnf("WARNING: Failed to record status of appointment %s on its event: %v", appt.ID, err)
+	}
+}
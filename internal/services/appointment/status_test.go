@@ -0,0 +1,190 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// mockStatusRepo keeps lifecycle statuses in memory.
+type mockStatusRepo struct {
+	statuses map[string]domain.AppointmentStatus
+	changes  []string
+}
+
+func newMockStatusRepo() *mockStatusRepo {
+	return &mockStatusRepo{statuses: make(map[string]domain.AppointmentStatus)}
+}
+
+func (m *mockStatusRepo) GetAppointmentStatus(id string) (domain.AppointmentStatus, error) {
+	if status, ok := m.statuses[id]; ok {
+		return status, nil
+	}
+	return domain.StatusBooked, nil
+}
+
+func (m *mockStatusRepo) UpdateAppointmentStatus(appt domain.Appointment, from, to domain.AppointmentStatus) error {
+	if current, _ := m.GetAppointmentStatus(appt.ID); current != from {
+		return domain.ErrInvalidStatusChange
+	}
+	m.statuses[appt.ID] = to
+	m.changes = append(m.changes, string(from)+"→"+string(to))
+	return nil
+}
+
+func (m *mockStatusRepo) CountPatientStatuses(customerID string) (map[domain.AppointmentStatus]int, error) {
+	counts := make(map[domain.AppointmentStatus]int)
+	for _, status := range m.statuses {
+		counts[status]++
+	}
+	return counts, nil
+}
+
+func TestService_SetAppointmentStatus(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	now := svc.NowFunc()
+	past := &domain.Appointment{ID: "past", StartTime: now.Add(-2 * time.Hour)}
+	future := &domain.Appointment{ID: "future", StartTime: now.Add(48 * time.Hour)}
+
+	if err := svc.SetAppointmentStatus(ctx, past, domain.StatusCompleted); !errors.Is(err, domain.ErrStatusUnavailable) {
+		t.Fatalf("without a repository expected ErrStatusUnavailable, got %v", err)
+	}
+	if got, err := svc.GetAppointmentStatus(ctx, "past"); err != nil || got != domain.StatusBooked {
+		t.Errorf("without a repository expected booked, got %q, %v", got, err)
+	}
+
+	tests := []struct {
+		name    string
+		current domain.AppointmentStatus
+		appt    *domain.Appointment
+		to      domain.AppointmentStatus
+		wantErr error
+		want    domain.AppointmentStatus
+	}{
+		{"confirm", "", future, domain.StatusConfirmed, nil, domain.StatusConfirmed},
+		{"mark completed", domain.StatusConfirmed, past, domain.StatusCompleted, nil, domain.StatusCompleted},
+		{"correct no-show", domain.StatusCompleted, past, domain.StatusNoShow, nil, domain.StatusNoShow},
+		{"same status is a no-op", domain.StatusNoShow, past, domain.StatusNoShow, nil, domain.StatusNoShow},
+		{"outcome before start", "", future, domain.StatusNoShow, domain.ErrOutcomeBeforeStart, domain.StatusBooked},
+		{"reopen completed", domain.StatusCompleted, past, domain.StatusConfirmed, domain.ErrInvalidStatusChange, domain.StatusCompleted},
+		{"cancel after no-show", domain.StatusNoShow, past, domain.StatusLateCancel, domain.ErrInvalidStatusChange, domain.StatusNoShow},
+		{"unknown status", "", future, "paid", domain.ErrInvalidStatusChange, domain.StatusBooked},
+		{"missing ID", "", &domain.Appointment{}, domain.StatusConfirmed, domain.ErrInvalidID, domain.StatusBooked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMockStatusRepo()
+			if tt.current != "" {
+				repo.statuses[tt.appt.ID] = tt.current
+			}
+			svc.SetStatusRepository(repo)
+
+			err := svc.SetAppointmentStatus(ctx, tt.appt, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if got, _ := svc.GetAppointmentStatus(ctx, tt.appt.ID); tt.appt.ID != "" && got != tt.want {
+				t.Errorf("status = %q, want %q", got, tt.want)
+			}
+			if tt.current == tt.to && len(repo.changes) != 0 {
+				t.Errorf("no-op should not write, got %v", repo.changes)
+			}
+		})
+	}
+}
+
+func TestService_SetAppointmentStatus_Hook(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	svc.SetStatusRepository(newMockStatusRepo())
+	var changes []domain.AppointmentStatus
+	svc.SetStatusChangedHook(func(ctx context.Context, appt domain.Appointment, status domain.AppointmentStatus) {
+		changes = append(changes, status)
+	})
+	past := &domain.Appointment{ID: "past", StartTime: svc.NowFunc().Add(-2 * time.Hour)}
+
+	_ = svc.SetAppointmentStatus(ctx, past, domain.StatusCompleted)
+	_ = svc.SetAppointmentStatus(ctx, past, domain.StatusCompleted) // no-op
+	_ = svc.SetAppointmentStatus(ctx, past, domain.StatusNoShow)
+	_ = svc.SetAppointmentStatus(ctx, past, domain.StatusConfirmed) // not allowed
+
+	if len(changes) != 2 || changes[0] != domain.StatusCompleted || changes[1] != domain.StatusNoShow {
+		t.Errorf("expected the hook told about completed and no_show, got %v", changes)
+	}
+}
+
+func TestService_GetPatientStatusCounts(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	if counts, err := svc.GetPatientStatusCounts(context.Background(), "100"); err != nil || len(counts) != 0 {
+		t.Errorf("without a repository expected no counts, got %v, %v", counts, err)
+	}
+
+	repo := newMockStatusRepo()
+	repo.statuses["a1"] = domain.StatusNoShow
+	repo.statuses["a2"] = domain.StatusNoShow
+	repo.statuses["a3"] = domain.StatusCompleted
+	svc.SetStatusRepository(repo)
+	counts, err := svc.GetPatientStatusCounts(context.Background(), "100")
+	if err != nil || counts[domain.StatusNoShow] != 2 {
+		t.Errorf("expected 2 no-shows, got %v, %v", counts, err)
+	}
+}
+
+func TestService_RescheduleAppointment_ResetsConfirmation(t *testing.T) {
+	svc := newScheduleTestService(t, nil)
+	repo := svc.repo.(*mockRepo)
+	start := scheduleTestDate.Add(10 * time.Hour)
+	repo.appointments["a1"] = &domain.Appointment{
+		ID: "a1", Service: domain.Service{ID: "1", Name: "Massage"}, CustomerTgID: "1",
+		StartTime: start, EndTime: start.Add(time.Hour), Duration: 60,
+	}
+	statuses := newMockStatusRepo()
+	statuses.statuses["a1"] = domain.StatusConfirmed
+	svc.SetStatusRepository(statuses)
+
+	if _, err := svc.RescheduleAppointment(context.Background(), "a1", start.Add(3*time.Hour)); err != nil {
+		t.Fatalf("RescheduleAppointment failed: %v", err)
+	}
+	if got := statuses.statuses["a1"]; got != domain.StatusBooked {
+		t.Errorf("moved appointment should need confirming again, status = %q", got)
+	}
+}
+
+// statusWriterRepo is a calendar that keeps lifecycle statuses on its events.
+type statusWriterRepo struct {
+	*mockRepo
+	written map[string]domain.AppointmentStatus
+}
+
+func (r *statusWriterRepo) SetEventStatus(ctx context.Context, id string, status domain.AppointmentStatus) error {
+	r.written[id] = status
+	return nil
+}
+
+func TestService_SetAppointmentStatus_WritesEvent(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	cal := &statusWriterRepo{mockRepo: svc.repo.(*mockRepo), written: make(map[string]domain.AppointmentStatus)}
+	svc.repo = cal
+	svc.SetStatusRepository(newMockStatusRepo())
+	future := &domain.Appointment{ID: "a1", StartTime: svc.NowFunc().Add(48 * time.Hour)}
+
+	if err := svc.SetAppointmentStatus(ctx, future, domain.StatusConfirmed); err != nil {
+		t.Fatalf("SetAppointmentStatus failed: %v", err)
+	}
+	if got := cal.written["a1"]; got != domain.StatusConfirmed {
+		t.Errorf("event status = %q, want confirmed", got)
+	}
+
+	// Cancelled events are deleted, so nothing is written
+	if err := svc.SetAppointmentStatus(ctx, future, domain.StatusCancelledByAdmin); err != nil {
+		t.Fatalf("SetAppointmentStatus failed: %v", err)
+	}
+	if got := cal.written["a1"]; got != domain.StatusConfirmed {
+		t.Errorf("event status after cancelling = %q, want confirmed", got)
+	}
+}
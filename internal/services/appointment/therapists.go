@@ -0,0 +1,271 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// SetTherapistRegistry enables multi-therapist mode. factory builds the
+// calendar adapter for a therapist's CalendarID; therapists without one, or
+// with the default calendar's ID, share the service's default repository.
+func (s *Service) SetTherapistRegistry(repo ports.TherapistRepository, factory ports.CalendarFactory) {
+	s.therapistMu.Lock()
+	s.therapistRepo = repo
+	s.calendarFactory = factory
+	s.therapists = nil
+	s.calendars = make(map[string]ports.AppointmentRepository)
+	s.therapistMu.Unlock()
+}
+
+// loadTherapists returns all therapists (active or not), reloading them
+// after scheduleTTL. Without a registry the list is empty, which keeps the
+// service in single-practitioner mode.
+func (s *Service) loadTherapists() ([]domain.Therapist, error) {
+	s.therapistMu.RLock()
+	repo, cached, loadedAt := s.therapistRepo, s.therapists, s.therapistsLoadedAt
+	s.therapistMu.RUnlock()
+
+	if repo == nil {
+		return nil, nil
+	}
+	if cached != nil && time.Since(loadedAt) < scheduleTTL {
+		return cached, nil
+	}
+
+	therapists, err := repo.ListTherapists()
+	if err != nil {
+		return nil, err
+	}
+	if therapists == nil {
+		therapists = []domain.Therapist{}
+	}
+
+	s.therapistMu.Lock()
+	s.therapists = therapists
+	s.therapistsLoadedAt = time.Now()
+	s.therapistMu.Unlock()
+	return therapists, nil
+}
+
+// activeTherapists returns the therapists offered for booking, in display order.
+func (s *Service) activeTherapists() ([]domain.Therapist, error) {
+	all, err := s.loadTherapists()
+	if err != nil {
+		return nil, err
+	}
+	var active []domain.Therapist
+	for _, t := range all {
+		if t.Active {
+			active = append(active, t)
+		}
+	}
+	return active, nil
+}
+
+// findTherapist looks up a therapist by ID, active or not.
+func (s *Service) findTherapist(id string) (*domain.Therapist, error) {
+	all, err := s.loadTherapists()
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		if all[i].ID == id {
+			return &all[i], nil
+		}
+	}
+	return nil, domain.ErrTherapistNotFound
+}
+
+// calendarFor returns the repository holding a therapist's appointments.
+// A nil therapist means the default calendar.
+func (s *Service) calendarFor(t *domain.Therapist) ports.AppointmentRepository {
+	if t == nil || t.CalendarID == "" || t.CalendarID == s.repo.GetCalendarID() {
+		return s.repo
+	}
+
+	s.therapistMu.Lock()
+	defer s.therapistMu.Unlock()
+	if s.calendarFactory == nil {
+		return s.repo
+	}
+	if cal, ok := s.calendars[t.CalendarID]; ok {
+		return cal
+	}
+	cal := s.calendarFactory(t.CalendarID)
+	s.calendars[t.CalendarID] = cal
+	return cal
+}
+
+// CalendarSources lists every distinct calendar to read appointments from:
+// the default one plus each therapist's own, including inactive therapists
+// so their past and remaining bookings stay visible.
+func (s *Service) CalendarSources() []ports.CalendarSource {
+	therapists, err := s.loadTherapists()
+	if err != nil {
+		logging.Warnf("WARNING: Failed to load therapists, reading the default calendar only: %v", err)
+	}
+
+	owners := make(map[string][]string)
+	order := []string{s.repo.GetCalendarID()}
+	repos := map[string]ports.AppointmentRepository{order[0]: s.repo}
+	for i := range therapists {
+		cal := s.calendarFor(&therapists[i])
+		id := cal.GetCalendarID()
+		if _, ok := repos[id]; !ok {
+			repos[id] = cal
+			order = append(order, id)
+		}
+		owners[id] = append(owners[id], therapists[i].ID)
+	}
+
+	sources := make([]ports.CalendarSource, 0, len(order))
+	for _, id := range order {
+		src := ports.CalendarSource{Repo: repos[id]}
+		if len(owners[id]) == 1 {
+			src.TherapistID = owners[id][0]
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// collectAppointments runs fetch against every calendar and merges the
+// results, attributing events to the calendar's therapist where possible.
+// A failing calendar is skipped unless it is the only one.
+func (s *Service) collectAppointments(ctx context.Context, fetch func(ports.AppointmentRepository) ([]domain.Appointment, error)) ([]domain.Appointment, error) {
+	sources := s.CalendarSources()
+	var all []domain.Appointment
+	for _, src := range sources {
+		appts, err := fetch(src.Repo)
+		if err != nil {
+			if len(sources) == 1 {
+				return nil, err
+			}
+			if !errors.Is(err, domain.ErrAppointmentNotFound) {
+				logging.Warnf("WARNING: Failed to read calendar %s: %v", src.Repo.GetCalendarID(), err)
+			}
+			continue
+		}
+		for i := range appts {
+			if appts[i].TherapistID == "" {
+				appts[i].TherapistID = src.TherapistID
+			}
+		}
+		all = append(all, appts...)
+	}
+	return all, nil
+}
+
+// findAppointment looks an event up in each calendar in turn and returns the
+// calendar it was found in.
+func (s *Service) findAppointment(ctx context.Context, id string) (*domain.Appointment, ports.AppointmentRepository, error) {
+	var lastErr error = domain.ErrAppointmentNotFound
+	for _, src := range s.CalendarSources() {
+		appt, err := src.Repo.FindByID(ctx, id)
+		if err == nil && appt != nil {
+			if appt.TherapistID == "" {
+				appt.TherapistID = src.TherapistID
+			}
+			return appt, src.Repo, nil
+		}
+		if !errors.Is(err, domain.ErrAppointmentNotFound) {
+			lastErr = err
+		}
+	}
+	return nil, nil, lastErr
+}
+
+// deleteAppointment removes an event from whichever calendar holds it.
+func (s *Service) deleteAppointment(ctx context.Context, id string) error {
+	sources := s.CalendarSources()
+	if len(sources) == 1 {
+		return sources[0].Repo.Delete(ctx, id)
+	}
+	_, cal, err := s.findAppointment(ctx, id)
+	if err != nil {
+		return err
+	}
+	return cal.Delete(ctx, id)
+}
+
+// GetTherapists returns the therapist registry in display order.
+func (s *Service) GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+	if includeInactive {
+		return s.loadTherapists()
+	}
+	return s.activeTherapists()
+}
+
+// SaveTherapist creates or updates a therapist. The ID ends up in callback
+// data, so it must be short and free of the "|" separator.
+func (s *Service) SaveTherapist(ctx context.Context, t domain.Therapist) error {
+	if s.therapistRepo == nil {
+		return domain.ErrScheduleUnavailable
+	}
+	t.ID = strings.TrimSpace(t.ID)
+	t.Name = strings.TrimSpace(t.Name)
+	if t.ID == "" || len(t.ID) > 32 || strings.ContainsAny(t.ID, "| ") || t.Name == "" {
+		return domain.ErrInvalidTherapist
+	}
+	if err := s.therapistRepo.SaveTherapist(t); err != nil {
+		return fmt.Errorf("failed to save therapist: %w", err)
+	}
+
+	s.therapistMu.Lock()
+	s.therapists = nil
+	s.therapistMu.Unlock()
+	s.invalidateCache()
+	return nil
+}
+
+// GetTherapistTimeSlots returns free slots for one therapist, using their
+// schedule and calendar. An empty therapistID means "any available".
+func (s *Service) GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, durationMinutes int) ([]domain.TimeSlot, error) {
+	if therapistID == "" {
+		return s.GetAvailableTimeSlots(ctx, date, durationMinutes)
+	}
+	if durationMinutes <= 0 {
+		return nil, domain.ErrInvalidDuration
+	}
+	t, err := s.findTherapist(therapistID)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Active {
+		return nil, domain.ErrTherapistNotFound
+	}
+	return s.slotsFor(ctx, t, date, durationMinutes)
+}
+
+// therapistKey returns the therapist's ID, or "" for the shared schedule.
+func therapistKey(t *domain.Therapist) string {
+	if t == nil {
+		return ""
+	}
+	return t.ID
+}
+
+// mergeSlots unions per-therapist slot lists, keeping one slot per start time.
+func mergeSlots(lists ...[]domain.TimeSlot) []domain.TimeSlot {
+	seen := make(map[int64]bool)
+	var merged []domain.TimeSlot
+	for _, list := range lists {
+		for _, slot := range list {
+			if seen[slot.Start.Unix()] {
+				continue
+			}
+			seen[slot.Start.Unix()] = true
+			merged = append(merged, slot)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start.Before(merged[j].Start) })
+	return merged
+}
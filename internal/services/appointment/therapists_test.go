@@ -0,0 +1,240 @@
+package appointment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// mockTherapistRepo is an in-memory ports.TherapistRepository.
+type mockTherapistRepo struct {
+	therapists []domain.Therapist
+	saved      []domain.Therapist
+}
+
+func (m *mockTherapistRepo) ListTherapists() ([]domain.Therapist, error) {
+	return m.therapists, nil
+}
+
+func (m *mockTherapistRepo) SaveTherapist(t domain.Therapist) error {
+	m.saved = append(m.saved, t)
+	return nil
+}
+
+// newTherapistTestService wires two therapists: anna on the default
+// calendar and boris on his own, busy at the given hours on scheduleTestDate.
+func newTherapistTestService(t *testing.T, annaBusy, borisBusy []int) (*Service, *mockRepo, *mockRepo) {
+	t.Helper()
+	svc := newScheduleTestService(t, &mockScheduleRepo{schedule: domain.DefaultWeeklySchedule()})
+	anna := svc.repo.(*mockRepo)
+	boris := newMockRepo()
+	boris.calendarID = "boris-cal"
+
+	busyAt := func(hours []int) func(context.Context, time.Time, time.Time) ([]domain.TimeSlot, error) {
+		return func(ctx context.Context, start, end time.Time) ([]domain.TimeSlot, error) {
+			var busy []domain.TimeSlot
+			for _, h := range hours {
+				from := scheduleTestDate.Add(time.Duration(h) * time.Hour)
+				busy = append(busy, domain.TimeSlot{Start: from, End: from.Add(time.Hour)})
+			}
+			return busy, nil
+		}
+	}
+	anna.getFreeBusyFunc = busyAt(annaBusy)
+	boris.getFreeBusyFunc = busyAt(borisBusy)
+
+	registry := &mockTherapistRepo{therapists: []domain.Therapist{
+		{ID: "anna", Name: "Анна", Active: true, SortOrder: 1},
+		{ID: "boris", Name: "Борис", CalendarID: "boris-cal", Active: true, SortOrder: 2},
+		{ID: "old", Name: "Уволен", CalendarID: "boris-cal", Active: false, SortOrder: 3},
+	}}
+	svc.SetTherapistRegistry(registry, func(calendarID string) ports.AppointmentRepository {
+		if calendarID == "boris-cal" {
+			return boris
+		}
+		t.Fatalf("unexpected calendar %q", calendarID)
+		return nil
+	})
+	return svc, anna, boris
+}
+
+func therapistTestAppt(hour int, therapistID string) *domain.Appointment {
+	return &domain.Appointment{
+		Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+		StartTime:    scheduleTestDate.Add(time.Duration(hour) * time.Hour),
+		Duration:     60,
+		CustomerName: "Alice",
+		CustomerTgID: "1",
+		TherapistID:  therapistID,
+	}
+}
+
+func TestGetAvailableTimeSlots_UnionOfTherapists(t *testing.T) {
+	svc, _, _ := newTherapistTestService(t, []int{10, 11}, []int{11, 12})
+	ctx := context.Background()
+
+	slots, err := svc.GetAvailableTimeSlots(ctx, scheduleTestDate, 60)
+	if err != nil {
+		t.Fatalf("GetAvailableTimeSlots failed: %v", err)
+	}
+	got := slotHours(slots)
+	want := []int{9, 10, 12, 13, 14, 15, 16, 17} // 11:00 is taken by both
+	if len(got) != len(want) {
+		t.Fatalf("got slots %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got slots %v, want %v", got, want)
+		}
+	}
+
+	slots, err = svc.GetTherapistTimeSlots(ctx, "boris", scheduleTestDate, 60)
+	if err != nil {
+		t.Fatalf("GetTherapistTimeSlots failed: %v", err)
+	}
+	for _, s := range slots {
+		if h := s.Start.Hour(); h == 11 || h == 12 {
+			t.Errorf("boris is busy at %d:00 but got it offered", h)
+		}
+	}
+
+	if _, err := svc.GetTherapistTimeSlots(ctx, "old", scheduleTestDate, 60); !errors.Is(err, domain.ErrTherapistNotFound) {
+		t.Errorf("inactive therapist: expected ErrTherapistNotFound, got %v", err)
+	}
+}
+
+func TestGetTherapistTimeSlots_OwnSchedule(t *testing.T) {
+	svc, _, _ := newTherapistTestService(t, nil, nil)
+	if err := svc.SetWeekdayHours(context.Background(), "boris", time.Wednesday,
+		[]domain.MinuteRange{{Start: 14 * 60, End: 16 * 60}}, nil); err != nil {
+		t.Fatalf("SetWeekdayHours failed: %v", err)
+	}
+
+	slots, err := svc.GetTherapistTimeSlots(context.Background(), "boris", scheduleTestDate, 60)
+	if err != nil {
+		t.Fatalf("GetTherapistTimeSlots failed: %v", err)
+	}
+	if got := slotHours(slots); len(got) != 2 || got[0] != 14 || got[1] != 15 {
+		t.Errorf("boris should only work 14-16, got %v", got)
+	}
+
+	slots, _ = svc.GetTherapistTimeSlots(context.Background(), "anna", scheduleTestDate, 60)
+	if len(slots) != 9 {
+		t.Errorf("anna should keep the shared 9-18 schedule, got %v", slotHours(slots))
+	}
+}
+
+func TestCreateAppointment_Therapists(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("any available picks the first free therapist", func(t *testing.T) {
+		svc, anna, boris := newTherapistTestService(t, []int{10}, nil)
+		created, err := svc.CreateAppointment(ctx, therapistTestAppt(10, ""))
+		if err != nil {
+			t.Fatalf("CreateAppointment failed: %v", err)
+		}
+		if created.TherapistID != "boris" {
+			t.Errorf("expected boris to be assigned, got %q", created.TherapistID)
+		}
+		if len(boris.appointments) != 1 || len(anna.appointments) != 0 {
+			t.Errorf("appointment should be created in boris' calendar")
+		}
+	})
+
+	t.Run("chosen therapist busy", func(t *testing.T) {
+		svc, _, _ := newTherapistTestService(t, []int{10}, nil)
+		if _, err := svc.CreateAppointment(ctx, therapistTestAppt(10, "anna")); !errors.Is(err, domain.ErrSlotUnavailable) {
+			t.Errorf("expected ErrSlotUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("everyone busy", func(t *testing.T) {
+		svc, _, _ := newTherapistTestService(t, []int{10}, []int{10})
+		if _, err := svc.CreateAppointment(ctx, therapistTestAppt(10, "")); !errors.Is(err, domain.ErrSlotUnavailable) {
+			t.Errorf("expected ErrSlotUnavailable, got %v", err)
+		}
+	})
+
+	t.Run("unknown therapist", func(t *testing.T) {
+		svc, _, _ := newTherapistTestService(t, nil, nil)
+		if _, err := svc.CreateAppointment(ctx, therapistTestAppt(10, "nobody")); !errors.Is(err, domain.ErrTherapistNotFound) {
+			t.Errorf("expected ErrTherapistNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTherapistCalendars_FanOut(t *testing.T) {
+	svc, anna, boris := newTherapistTestService(t, nil, nil)
+	ctx := context.Background()
+	anna.appointments["a1"] = &domain.Appointment{ID: "a1", CustomerTgID: "1", StartTime: time.Now().Add(time.Hour)}
+	boris.appointments["b1"] = &domain.Appointment{ID: "b1", CustomerTgID: "1", StartTime: time.Now().Add(2 * time.Hour)}
+
+	appts, err := svc.GetCustomerAppointments(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetCustomerAppointments failed: %v", err)
+	}
+	if len(appts) != 2 {
+		t.Fatalf("expected appointments from both calendars, got %+v", appts)
+	}
+	for _, a := range appts {
+		if a.ID == "b1" && a.TherapistID != "" {
+			// boris-cal is shared by boris and an inactive therapist, so it
+			// cannot be attributed from the calendar alone
+			t.Errorf("b1 should not be attributed, got %q", a.TherapistID)
+		}
+		if a.ID == "a1" && a.TherapistID != "anna" {
+			t.Errorf("a1 should be attributed to anna, got %q", a.TherapistID)
+		}
+	}
+
+	if err := svc.CancelAppointment(ctx, "b1"); err != nil {
+		t.Fatalf("CancelAppointment failed: %v", err)
+	}
+	if len(boris.appointments) != 0 || len(anna.appointments) != 1 {
+		t.Error("cancel should delete from the calendar holding the event")
+	}
+	if err := svc.CancelAppointment(ctx, "missing"); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+}
+
+func TestCalendarSources(t *testing.T) {
+	svc, anna, boris := newTherapistTestService(t, nil, nil)
+
+	sources := svc.CalendarSources()
+	if len(sources) != 2 {
+		t.Fatalf("expected the two distinct calendars, got %+v", sources)
+	}
+	if sources[0].Repo != anna || sources[0].TherapistID != "anna" {
+		t.Errorf("expected the default calendar attributed to anna, got %+v", sources[0])
+	}
+	if sources[1].Repo != boris || sources[1].TherapistID != "" {
+		t.Errorf("expected the shared calendar unattributed, got %+v", sources[1])
+	}
+}
+
+func TestSaveTherapist(t *testing.T) {
+	ctx := context.Background()
+	svc := newScheduleTestService(t, nil)
+	if err := svc.SaveTherapist(ctx, domain.Therapist{ID: "anna", Name: "Анна"}); !errors.Is(err, domain.ErrScheduleUnavailable) {
+		t.Errorf("without registry: expected ErrScheduleUnavailable, got %v", err)
+	}
+
+	registry := &mockTherapistRepo{}
+	svc.SetTherapistRegistry(registry, nil)
+	for _, bad := range []domain.Therapist{{ID: "", Name: "Анна"}, {ID: "a|b", Name: "Анна"}, {ID: "anna"}} {
+		if err := svc.SaveTherapist(ctx, bad); !errors.Is(err, domain.ErrInvalidTherapist) {
+			t.Errorf("SaveTherapist(%+v): expected ErrInvalidTherapist, got %v", bad, err)
+		}
+	}
+	if err := svc.SaveTherapist(ctx, domain.Therapist{ID: " anna ", Name: "Анна", Active: true}); err != nil {
+		t.Fatalf("SaveTherapist failed: %v", err)
+	}
+	if len(registry.saved) != 1 || registry.saved[0].ID != "anna" {
+		t.Errorf("unexpected saved therapists: %+v", registry.saved)
+	}
+}
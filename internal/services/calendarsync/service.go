@@ -0,0 +1,459 @@
+package calendarsync
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+const (
+	// DefaultChannelTTL is how long a watch channel is asked to live. Google
+	// caps event channels at about a week.
+	DefaultChannelTTL = 7 * 24 * time.Hour
+
+	// refreshInterval is how often channels are checked and every calendar
+	// is synced, which also catches changes whose ping was lost.
+	refreshInterval = 10 * time.Minute
+
+	// renewBefore is how early a channel is replaced before it lapses, so a
+	// failed renewal can be retried on the next check.
+	renewBefore = 24 * time.Hour
+
+	// upsertBatch caps the rows stored per statement; a full sync can bring
+	// years of events.
+	upsertBatch = 500
+)
+
+// Calendars is the part of the appointment service the sync works with.
+// *appointment.Service satisfies this interface.
+type Calendars interface {
+	CalendarSources() []ports.CalendarSource
+	InvalidateCalendar(calendarID string, spans ...domain.TimeSlot)
+}
+
+// watchedCalendar is the sync state of one calendar.
+type watchedCalendar struct {
+	source  ports.CalendarSource
+	watcher ports.CalendarWatcher
+	channel *domain.WatchChannel
+	// syncToken is where the next incremental sync starts; empty means a
+	// full sync
+	syncToken string
+}
+
+// Service keeps the appointments table in step with the calendars that can
+// report their changes. Each calendar is fully synced once, then only the
+// events changed since the stored sync token are pulled: on every ping from
+// its watch channel and every refreshInterval. Without a notification
+// address it only polls.
+type Service struct {
+	repo      ports.CalendarSyncRepository
+	calendars Calendars
+	address   string
+	ttl       time.Duration
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+
+	// onChange is told about bookings edited directly in the calendar
+	onChange func(ctx context.Context, change domain.CalendarChange)
+
+	mu      sync.Mutex
+	watched map[string]*watchedCalendar // By calendar ID
+	// Calendars fully synced since start; once all are, rows no sync has
+	// claimed are gone from every calendar
+	fullySynced     map[string]bool
+	unclaimedPruned bool
+	channels        map[string]domain.WatchChannel // Open channels by channel ID
+	dirty           map[string]bool                // Calendars pinged since the last sync
+	wake            chan struct{}
+}
+
+var _ ports.CalendarSyncService = (*Service)(nil)
+
+// NewService creates the sync service. address is the public HTTPS URL of
+// the notification endpoint; empty disables push notifications.
+func NewService(repo ports.CalendarSyncRepository, calendars Calendars, address string, ttl time.Duration) *Service {
+	if ttl <= 0 {
+		ttl = DefaultChannelTTL
+	}
+	return &Service{
+		repo:        repo,
+		calendars:   calendars,
+		address:     address,
+		ttl:         ttl,
+		NowFunc:     time.Now,
+		watched:     make(map[string]*watchedCalendar),
+		fullySynced: make(map[string]bool),
+		channels:    make(map[string]domain.WatchChannel),
+		dirty:       make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// SetChangeHook registers a function told about bookings edited directly in
+// the calendar: moved, resized or deleted upcoming appointments found by an
+// incremental sync. It runs on the sync goroutine after the change is stored.
+func (s *Service) SetChangeHook(hook func(ctx context.Context, change domain.CalendarChange)) {
+	s.onChange = hook
+}
+
+// Start syncs the calendars, opens and renews their watch channels and
+// syncs pinged calendars until ctx is done. The channels are closed on the
+// way out.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	ticker := time.NewTicker(refreshInterval)
+	if s.address != "" {
+		logging.Infof("Calendar sync started (notifications to %s).", s.address)
+	} else {
+		logging.Infof("Calendar sync started (polling every %s).", refreshInterval)
+	}
+
+	return s.RunLoopForTest(ctx, ticker.C, ticker.Stop)
+}
+
+// RunLoopForTest is the inner goroutine extracted from Start so it can be
+// driven by a manual channel in tests; see reminder.Service.RunLoopForTest.
+func (s *Service) RunLoopForTest(ctx context.Context, ticks <-chan time.Time, stop func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stop()
+		s.Refresh(ctx)
+		for {
+			select {
+			case <-ticks:
+				s.Refresh(ctx)
+			case <-s.wake:
+				s.SyncPending(ctx)
+			case <-ctx.Done():
+				s.stopChannels()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// HandleNotification queues a sync of the calendar the ping came from.
+func (s *Service) HandleNotification(ctx context.Context, n domain.CalendarNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	channel, ok := s.channels[n.ChannelID]
+	if !ok {
+		return domain.ErrUnknownWatchChannel
+	}
+	if subtle.ConstantTimeCompare([]byte(channel.Token), []byte(n.Token)) != 1 {
+		return domain.ErrInvalidWatchToken
+	}
+	// The first ping only confirms the channel is open
+	if n.State == "sync" {
+		return nil
+	}
+
+	s.dirty[channel.CalendarID] = true
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Refresh picks up calendars that can be synced, opens a channel on those
+// without one and replaces channels about to lapse, then syncs every
+// calendar.
+func (s *Service) Refresh(ctx context.Context) {
+	now := s.NowFunc()
+	for _, src := range s.calendars.CalendarSources() {
+		watcher, ok := src.Repo.(ports.CalendarWatcher)
+		if !ok {
+			continue
+		}
+		calendarID := src.Repo.GetCalendarID()
+
+		s.mu.Lock()
+		w, known := s.watched[calendarID]
+		s.mu.Unlock()
+		if !known {
+			token, err := s.repo.GetCalendarSyncToken(calendarID)
+			if err != nil {
+				logging.Warnf("WARNING: Failed to load sync token of %s, doing a full sync: %v", calendarID, err)
+			}
+			w = &watchedCalendar{watcher: watcher, syncToken: token}
+		}
+
+		s.mu.Lock()
+		s.watched[calendarID] = w
+		w.source = src
+		old := w.channel
+		s.dirty[calendarID] = true
+		s.mu.Unlock()
+
+		if s.address == "" || (old != nil && !old.ExpiresWithin(renewBefore, now)) {
+			continue
+		}
+		if err := s.openChannel(ctx, w, calendarID); err != nil {
+			logging.Warnf("WARNING: Failed to watch calendar %s: %v", calendarID, err)
+			continue
+		}
+		if old != nil {
+			if err := watcher.StopWatch(ctx, *old); err != nil {
+				logging.Warnf("WARNING: Failed to stop old watch channel %s: %v", old.ID, err)
+			}
+		}
+	}
+
+	s.SyncPending(ctx)
+}
+
+// openChannel opens a new channel on w and makes it the current one.
+func (s *Service) openChannel(ctx context.Context, w *watchedCalendar, calendarID string) error {
+	id, err := randomToken()
+	if err != nil {
+		return err
+	}
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	channel, err := w.watcher.Watch(ctx, domain.WatchChannel{
+		ID:         id,
+		Token:      token,
+		Address:    s.address,
+		CalendarID: calendarID,
+	}, s.ttl)
+	if err != nil {
+		return err
+	}
+	channel.CalendarID = calendarID
+
+	s.mu.Lock()
+	if w.channel != nil {
+		delete(s.channels, w.channel.ID)
+	}
+	w.channel = channel
+	s.channels[channel.ID] = *channel
+	s.mu.Unlock()
+	return nil
+}
+
+// stopChannels closes every open channel, so Google stops pinging an
+// instance that is shutting down.
+func (s *Service) stopChannels() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s.mu.Lock()
+	var open []*watchedCalendar
+	for _, w := range s.watched {
+		if w.channel != nil {
+			open = append(open, w)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, w := range open {
+		if err := w.watcher.StopWatch(ctx, *w.channel); err != nil {
+			logging.Warnf("WARNING: Failed to stop watch channel %s: %v", w.channel.ID, err)
+		}
+	}
+}
+
+// SyncPending syncs every calendar pinged since the last run.
+func (s *Service) SyncPending(ctx context.Context) {
+	s.mu.Lock()
+	dirty := s.dirty
+	s.dirty = make(map[string]bool)
+	s.mu.Unlock()
+
+	for calendarID := range dirty {
+		if err := s.syncCalendar(ctx, calendarID); err != nil {
+			logging.Warnf("WARNING: Failed to sync calendar %s: %v", calendarID, err)
+		}
+	}
+}
+
+// syncCalendar copies the events changed since the last sync into the
+// appointments table and drops the cached availability of the days they
+// left and moved to. Events without a patient only block time, so they
+// are not stored. An expired token falls back to a full sync. A full sync
+// also deletes the calendar's rows missing from the listing, such as events
+// deleted while the token was stale; see pruneUnclaimed for rows stored
+// before any sync claimed them.
+func (s *Service) syncCalendar(ctx context.Context, calendarID string) error {
+	s.mu.Lock()
+	w, ok := s.watched[calendarID]
+	if !ok {
+		s.mu.Unlock()
+		return domain.ErrUnknownWatchChannel
+	}
+	token, src := w.syncToken, w.source
+	s.mu.Unlock()
+
+	changes, next, err := w.watcher.SyncChanges(ctx, token)
+	if errors.Is(err, domain.ErrSyncTokenExpired) {
+		logging.Warnf("WARNING: Sync token of calendar %s expired, doing a full sync", calendarID)
+		token = ""
+		changes, next, err = w.watcher.SyncChanges(ctx, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(changes))
+	for _, c := range changes {
+		ids = append(ids, c.ID)
+	}
+	stored, err := s.repo.GetAppointmentsByID(ids)
+	if err != nil {
+		return fmt.Errorf("failed to read stored appointments: %w", err)
+	}
+	previous := make(map[string]domain.Appointment, len(stored))
+	for _, appt := range stored {
+		previous[appt.ID] = appt
+	}
+
+	// Edits made by the bot reach the table before the sync does, so what
+	// differs from it was edited in the calendar. A full sync may compare
+	// against rows cached long ago, so only incremental ones report edits.
+	now := s.NowFunc()
+	var edits []domain.CalendarChange
+	var upserts []domain.Appointment
+	var spans []domain.TimeSlot
+	deleted := 0
+	for _, c := range changes {
+		prev, known := previous[c.ID]
+		if known {
+			spans = append(spans, domain.TimeSlot{Start: prev.StartTime, End: prev.EndTime})
+		}
+		if !c.StartTime.IsZero() {
+			spans = append(spans, domain.TimeSlot{Start: c.StartTime, End: c.EndTime})
+			// The table holds the clinic's wall clock
+			c.StartTime = c.StartTime.In(domain.ApptTimeZone)
+			c.EndTime = c.EndTime.In(domain.ApptTimeZone)
+		}
+		if known && token != "" && (prev.StartTime.After(now) || c.StartTime.After(now)) {
+			if edit, ok := domain.DetectCalendarChange(prev, c); ok {
+				edits = append(edits, edit)
+			}
+		}
+
+		if c.Status == "cancelled" {
+			if known {
+				if err := s.repo.DeleteAppointment(c.ID); err != nil {
+					return fmt.Errorf("failed to delete appointment %s: %w", c.ID, err)
+				}
+				deleted++
+			}
+			continue
+		}
+		if c.CustomerTgID == "" {
+			continue
+		}
+		if c.TherapistID == "" {
+			c.TherapistID = src.TherapistID
+		}
+		if c.TherapistID == "" && known {
+			c.TherapistID = prev.TherapistID
+		}
+		upserts = append(upserts, c)
+	}
+
+	kept := make([]string, 0, len(upserts))
+	for start := 0; start < len(upserts); start += upsertBatch {
+		end := min(start+upsertBatch, len(upserts))
+		if err := s.repo.UpsertAppointments(upserts[start:end]); err != nil {
+			return fmt.Errorf("failed to store changed appointments: %w", err)
+		}
+		batch := make([]string, 0, end-start)
+		for _, appt := range upserts[start:end] {
+			batch = append(batch, appt.ID)
+		}
+		if err := s.repo.ClaimAppointments(calendarID, batch); err != nil {
+			return fmt.Errorf("failed to claim changed appointments: %w", err)
+		}
+		kept = append(kept, batch...)
+	}
+	if token == "" {
+		pruned, err := s.repo.PruneAppointments(calendarID, kept)
+		if err != nil {
+			return fmt.Errorf("failed to prune appointments: %w", err)
+		}
+		deleted += int(pruned)
+	}
+	if len(spans) > 0 {
+		s.calendars.InvalidateCalendar(calendarID, spans...)
+	}
+	if s.onChange != nil {
+		for _, edit := range edits {
+			s.onChange(ctx, edit)
+		}
+	}
+
+	if next != "" {
+		if err := s.repo.SaveCalendarSyncToken(calendarID, next); err != nil {
+			logging.Warnf("WARNING: Failed to save sync token of %s: %v", calendarID, err)
+		}
+		s.mu.Lock()
+		w.syncToken = next
+		s.mu.Unlock()
+	}
+	if len(changes) > 0 || token == "" {
+		logging.Infof("Calendar %s synced (full: %v): %d changed events, %d stored, %d removed, %d edited outside the bot.",
+			calendarID, token == "", len(changes), len(upserts), deleted, len(edits))
+	}
+	if token == "" {
+		s.pruneUnclaimed(calendarID)
+	}
+	return nil
+}
+
+// pruneUnclaimed deletes, once every watched calendar has been fully
+// synced, the rows none of them claimed: history cached before calendar
+// sync, or visits whose events were deleted meanwhile. A booking stored by
+// the bot during the full syncs may be deleted too; the next incremental
+// sync brings it back with its event.
+func (s *Service) pruneUnclaimed(calendarID string) {
+	s.mu.Lock()
+	s.fullySynced[calendarID] = true
+	ready := !s.unclaimedPruned
+	for id := range s.watched {
+		ready = ready && s.fullySynced[id]
+	}
+	s.mu.Unlock()
+	if !ready {
+		return
+	}
+
+	pruned, err := s.repo.PruneAppointments("", nil)
+	if err != nil {
+		logging.Warnf("WARNING: Failed to prune appointments no calendar claimed: %v", err)
+		return
+	}
+	s.mu.Lock()
+	s.unclaimedPruned = true
+	s.mu.Unlock()
+	if pruned > 0 {
+		logging.Infof("Removed %d stored appointments missing from every calendar.", pruned)
+	}
+}
+
+// randomToken returns a random channel ID or token. Google accepts
+// [A-Za-z0-9\-_\+/=] in both.
+func randomToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate watch channel token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,452 @@
+package calendarsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var testNow = time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+
+// mockCalendar is a watchable calendar. Only the methods the sync uses are
+// implemented.
+type mockCalendar struct {
+	ports.AppointmentRepository
+	id      string
+	watched []domain.WatchChannel
+	stopped []string
+	changes []domain.Appointment
+	tokens  []string // Sync tokens asked with
+	next    int
+	expired bool // Reject the next incremental sync with 410
+	err     error
+	onSync  func()
+}
+
+func (m *mockCalendar) GetCalendarID() string { return m.id }
+
+func (m *mockCalendar) Watch(ctx context.Context, channel domain.WatchChannel, ttl time.Duration) (*domain.WatchChannel, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.watched = append(m.watched, channel)
+	channel.ResourceID = "res-" + channel.ID
+	channel.Expiration = testNow.Add(ttl)
+	return &channel, nil
+}
+
+func (m *mockCalendar) StopWatch(ctx context.Context, channel domain.WatchChannel) error {
+	m.stopped = append(m.stopped, channel.ID)
+	return nil
+}
+
+func (m *mockCalendar) SyncChanges(ctx context.Context, syncToken string) ([]domain.Appointment, string, error) {
+	m.tokens = append(m.tokens, syncToken)
+	if m.onSync != nil {
+		defer m.onSync()
+	}
+	if m.err != nil {
+		return nil, "", m.err
+	}
+	if m.expired && syncToken != "" {
+		m.expired = false
+		return nil, "", domain.ErrSyncTokenExpired
+	}
+	changes := m.changes
+	m.changes = nil
+	m.next++
+	return changes, fmt.Sprintf("token-%d", m.next), nil
+}
+
+// plainCalendar cannot be watched.
+type plainCalendar struct {
+	ports.AppointmentRepository
+}
+
+func (plainCalendar) GetCalendarID() string { return "caldav" }
+
+type mockCalendars struct {
+	sources     []ports.CalendarSource
+	invalidated map[string][]domain.TimeSlot
+}
+
+func (m *mockCalendars) CalendarSources() []ports.CalendarSource { return m.sources }
+
+func (m *mockCalendars) InvalidateCalendar(calendarID string, spans ...domain.TimeSlot) {
+	m.invalidated[calendarID] = append(m.invalidated[calendarID], spans...)
+}
+
+type mockSyncRepo struct {
+	appts   map[string]domain.Appointment
+	claimed map[string]string // appointment ID -> calendar ID
+	tokens  map[string]string
+	batches int
+}
+
+func (m *mockSyncRepo) GetAppointmentsByID(ids []string) ([]domain.Appointment, error) {
+	var res []domain.Appointment
+	for _, id := range ids {
+		if appt, ok := m.appts[id]; ok {
+			res = append(res, appt)
+		}
+	}
+	return res, nil
+}
+
+func (m *mockSyncRepo) UpsertAppointments(appts []domain.Appointment) error {
+	m.batches++
+	for _, appt := range appts {
+		m.appts[appt.ID] = appt
+	}
+	return nil
+}
+
+func (m *mockSyncRepo) DeleteAppointment(id string) error {
+	delete(m.appts, id)
+	return nil
+}
+
+func (m *mockSyncRepo) ClaimAppointments(calendarID string, ids []string) error {
+	for _, id := range ids {
+		m.claimed[id] = calendarID
+	}
+	return nil
+}
+
+func (m *mockSyncRepo) PruneAppointments(calendarID string, keep []string) (int64, error) {
+	kept := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		kept[id] = true
+	}
+	var n int64
+	for id := range m.appts {
+		if m.claimed[id] == calendarID && !kept[id] {
+			delete(m.appts, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *mockSyncRepo) GetCalendarSyncToken(calendarID string) (string, error) {
+	return m.tokens[calendarID], nil
+}
+
+func (m *mockSyncRepo) SaveCalendarSyncToken(calendarID, token string) error {
+	m.tokens[calendarID] = token
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *mockCalendar, *mockCalendars, *mockSyncRepo) {
+	t.Helper()
+	cal := &mockCalendar{id: "primary"}
+	calendars := &mockCalendars{
+		sources: []ports.CalendarSource{
+			{Repo: cal, TherapistID: "anna"},
+			{Repo: plainCalendar{}},
+		},
+		invalidated: map[string][]domain.TimeSlot{},
+	}
+	repo := &mockSyncRepo{appts: map[string]domain.Appointment{}, claimed: map[string]string{}, tokens: map[string]string{}}
+	svc := NewService(repo, calendars, "https://bot.example.com/api/calendar/notify", 0)
+	svc.NowFunc = func() time.Time { return testNow }
+	return svc, cal, calendars, repo
+}
+
+func slot(hour int) (time.Time, time.Time) {
+	start := testNow.AddDate(0, 0, 1).Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour)
+	return start, start.Add(time.Hour)
+}
+
+func TestRefresh_OpensAndRenewsChannels(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, _ := newTestService(t)
+
+	svc.Refresh(ctx)
+	if len(cal.watched) != 1 {
+		t.Fatalf("expected one channel on the watchable calendar, got %+v", cal.watched)
+	}
+	first := svc.watched["primary"].channel
+	if first.Address != "https://bot.example.com/api/calendar/notify" || first.Token == "" || first.CalendarID != "primary" {
+		t.Errorf("unexpected channel %+v", first)
+	}
+
+	svc.Refresh(ctx)
+	if len(cal.watched) != 1 {
+		t.Errorf("expected a fresh channel kept, got %d", len(cal.watched))
+	}
+
+	svc.NowFunc = func() time.Time { return testNow.Add(DefaultChannelTTL - time.Hour) }
+	svc.Refresh(ctx)
+	if len(cal.watched) != 2 || len(cal.stopped) != 1 || cal.stopped[0] != first.ID {
+		t.Errorf("expected the lapsing channel replaced and stopped, watched %d, stopped %v", len(cal.watched), cal.stopped)
+	}
+	if _, ok := svc.channels[first.ID]; ok || len(svc.channels) != 1 {
+		t.Errorf("expected only the new channel accepted, got %v", svc.channels)
+	}
+}
+
+func TestRefresh_SyncsWithTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, repo := newTestService(t)
+	repo.tokens["primary"] = "token-saved"
+
+	svc.Refresh(ctx)
+	svc.Refresh(ctx)
+	if len(cal.tokens) != 2 || cal.tokens[0] != "token-saved" || cal.tokens[1] != "token-1" {
+		t.Errorf("expected each sync to continue from the last token, got %v", cal.tokens)
+	}
+	if repo.tokens["primary"] != "token-2" {
+		t.Errorf("expected the latest token saved, got %q", repo.tokens["primary"])
+	}
+}
+
+func TestRefresh_PollsWithoutAddress(t *testing.T) {
+	svc, cal, _, _ := newTestService(t)
+	svc.address = ""
+
+	svc.Refresh(context.Background())
+	if len(cal.watched) != 0 || len(cal.tokens) != 1 || cal.tokens[0] != "" {
+		t.Errorf("expected a full sync and no channel, watched %v, tokens %v", cal.watched, cal.tokens)
+	}
+}
+
+func TestRefresh_WatchError(t *testing.T) {
+	svc, cal, _, _ := newTestService(t)
+	cal.err = errors.New("push not allowed")
+
+	svc.Refresh(context.Background())
+	if svc.watched["primary"].channel != nil || len(svc.channels) != 0 {
+		t.Error("expected no channel after a failed watch")
+	}
+}
+func TestHandleNotification(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, _ := newTestService(t)
+	svc.Refresh(ctx)
+	channel := *svc.watched["primary"].channel
+
+	if err := svc.HandleNotification(ctx, domain.CalendarNotification{ChannelID: "other", Token: channel.Token}); !errors.Is(err, domain.ErrUnknownWatchChannel) {
+		t.Errorf("expected ErrUnknownWatchChannel, got %v", err)
+	}
+	if err := svc.HandleNotification(ctx, domain.CalendarNotification{ChannelID: channel.ID, Token: "forged"}); !errors.Is(err, domain.ErrInvalidWatchToken) {
+		t.Errorf("expected ErrInvalidWatchToken, got %v", err)
+	}
+	if err := svc.HandleNotification(ctx, domain.CalendarNotification{ChannelID: channel.ID, Token: channel.Token, State: "sync"}); err != nil || len(svc.dirty) != 0 {
+		t.Errorf("expected the opening ping ignored, got %v, dirty %v", err, svc.dirty)
+	}
+	if err := svc.HandleNotification(ctx, domain.CalendarNotification{ChannelID: channel.ID, Token: channel.Token, State: "exists"}); err != nil || !svc.dirty["primary"] {
+		t.Errorf("expected the calendar queued, got %v, dirty %v", err, svc.dirty)
+	}
+	select {
+	case <-svc.wake:
+	default:
+		t.Error("expected the loop woken")
+	}
+}
+
+func TestSyncPending_AppliesChanges(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, calendars, repo := newTestService(t)
+	svc.Refresh(ctx)
+	calendars.invalidated = map[string][]domain.TimeSlot{}
+
+	oldStart, oldEnd := slot(9)
+	newStart, newEnd := slot(15)
+	personalStart, personalEnd := slot(12)
+	repo.appts["moved"] = domain.Appointment{ID: "moved", CustomerTgID: "1", StartTime: oldStart, EndTime: oldEnd, TherapistID: "anna"}
+	repo.appts["gone"] = domain.Appointment{ID: "gone", CustomerTgID: "2", StartTime: oldStart, EndTime: oldEnd}
+	cal.changes = []domain.Appointment{
+		{ID: "moved", CustomerTgID: "1", StartTime: newStart, EndTime: newEnd, Status: "confirmed"},
+		{ID: "gone", Status: "cancelled"},
+		{ID: "personal", StartTime: personalStart, EndTime: personalEnd, Status: "confirmed"},
+		{ID: "new", CustomerTgID: "3", StartTime: newStart, EndTime: newEnd, Status: "confirmed"},
+	}
+
+	svc.dirty["primary"] = true
+	svc.SyncPending(ctx)
+
+	if got := repo.appts["moved"]; !got.StartTime.Equal(newStart) || got.TherapistID != "anna" {
+		t.Errorf("expected the moved appointment updated, got %+v", got)
+	}
+	if _, ok := repo.appts["gone"]; ok {
+		t.Error("expected the deleted event removed")
+	}
+	if _, ok := repo.appts["personal"]; ok {
+		t.Error("expected the event without a patient not stored")
+	}
+	if got := repo.appts["new"]; got.TherapistID != "anna" {
+		t.Errorf("expected the new appointment attributed to the calendar's therapist, got %+v", got)
+	}
+	if len(calendars.invalidated["primary"]) != 5 {
+		t.Errorf("expected the old and new times invalidated, got %v", calendars.invalidated["primary"])
+	}
+}
+
+func TestSyncPending_ReportsExternalEdits(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, repo := newTestService(t)
+	var edits []domain.CalendarChange
+	svc.SetChangeHook(func(ctx context.Context, change domain.CalendarChange) {
+		edits = append(edits, change)
+	})
+
+	oldStart, oldEnd := slot(9)
+	newStart, newEnd := slot(15)
+	pastStart := testNow.Add(-48 * time.Hour)
+	repo.appts["moved"] = domain.Appointment{ID: "moved", CustomerTgID: "1", StartTime: oldStart, EndTime: oldEnd}
+	repo.appts["same"] = domain.Appointment{ID: "same", CustomerTgID: "2", StartTime: newStart, EndTime: newEnd}
+	repo.appts["gone"] = domain.Appointment{ID: "gone", CustomerTgID: "3", StartTime: oldStart, EndTime: oldEnd}
+	repo.appts["past"] = domain.Appointment{ID: "past", CustomerTgID: "4", StartTime: pastStart, EndTime: pastStart.Add(time.Hour)}
+
+	// The first sync is a full one and reports nothing
+	cal.changes = []domain.Appointment{
+		{ID: "moved", CustomerTgID: "1", StartTime: oldStart.Add(time.Hour), EndTime: oldEnd.Add(time.Hour), Status: "confirmed"},
+		{ID: "same", CustomerTgID: "2", StartTime: newStart, EndTime: newEnd, Status: "confirmed"},
+		{ID: "gone", CustomerTgID: "3", StartTime: oldStart, EndTime: oldEnd, Status: "confirmed"},
+		{ID: "past", CustomerTgID: "4", StartTime: pastStart, EndTime: pastStart.Add(time.Hour), Status: "confirmed"},
+	}
+	svc.Refresh(ctx)
+	if len(edits) != 0 {
+		t.Fatalf("expected no edits reported by a full sync, got %+v", edits)
+	}
+
+	cal.changes = []domain.Appointment{
+		{ID: "moved", CustomerTgID: "1", StartTime: newStart, EndTime: newEnd, Status: "confirmed"},
+		{ID: "same", CustomerTgID: "2", StartTime: newStart, EndTime: newEnd, Status: "confirmed"},
+		{ID: "gone", Status: "cancelled"},
+		{ID: "past", Status: "cancelled"},
+	}
+	svc.dirty["primary"] = true
+	svc.SyncPending(ctx)
+
+	if len(edits) != 2 {
+		t.Fatalf("expected the move and the deletion reported, got %+v", edits)
+	}
+	if edits[0].Kind != domain.ChangeMoved || edits[0].Previous.ID != "moved" || !edits[0].Current.StartTime.Equal(newStart) {
+		t.Errorf("unexpected move %+v", edits[0])
+	}
+	if edits[1].Kind != domain.ChangeCancelled || edits[1].Previous.CustomerTgID != "3" {
+		t.Errorf("unexpected deletion %+v", edits[1])
+	}
+}
+
+func TestSyncPending_ExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, repo := newTestService(t)
+	svc.Refresh(ctx)
+
+	start, end := slot(10)
+	cal.expired = true
+	cal.changes = []domain.Appointment{{ID: "e1", CustomerTgID: "1", StartTime: start, EndTime: end, Status: "confirmed"}}
+	svc.dirty["primary"] = true
+	svc.SyncPending(ctx)
+
+	if got := cal.tokens[len(cal.tokens)-2:]; got[0] != "token-1" || got[1] != "" {
+		t.Errorf("expected a full sync after the expired token, got %v", cal.tokens)
+	}
+	if _, ok := repo.appts["e1"]; !ok || repo.tokens["primary"] != "token-2" {
+		t.Errorf("expected the full sync stored with its token, got %q", repo.tokens["primary"])
+	}
+}
+
+func TestSyncPending_FullSyncPrunesDeletedEvents(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, repo := newTestService(t)
+	start, end := slot(10)
+
+	// Cached before calendar sync: one still in the calendar, one deleted
+	repo.appts["kept"] = domain.Appointment{ID: "kept", CustomerTgID: "1", StartTime: start, EndTime: end}
+	repo.appts["cached"] = domain.Appointment{ID: "cached", CustomerTgID: "2", StartTime: start, EndTime: end}
+	cal.changes = []domain.Appointment{
+		{ID: "kept", CustomerTgID: "1", StartTime: start, EndTime: end, Status: "confirmed"},
+		{ID: "stale", CustomerTgID: "3", StartTime: start, EndTime: end, Status: "confirmed"},
+	}
+	svc.Refresh(ctx)
+
+	if _, ok := repo.appts["cached"]; ok {
+		t.Error("expected the cached row missing from the calendar removed")
+	}
+	if _, ok := repo.appts["kept"]; !ok || repo.claimed["kept"] != "primary" || repo.claimed["stale"] != "primary" {
+		t.Errorf("expected the listed events kept and claimed, got %v", repo.claimed)
+	}
+
+	// "stale" is deleted while the token is expired: the fallback full sync
+	// no longer lists it
+	cal.expired = true
+	cal.changes = []domain.Appointment{{ID: "kept", CustomerTgID: "1", StartTime: start, EndTime: end, Status: "confirmed"}}
+	svc.dirty["primary"] = true
+	svc.SyncPending(ctx)
+
+	if _, ok := repo.appts["stale"]; ok {
+		t.Error("expected the event deleted while the token was stale removed")
+	}
+	if _, ok := repo.appts["kept"]; !ok {
+		t.Error("expected the listed event kept")
+	}
+}
+
+func TestSyncPending_Error(t *testing.T) {
+	ctx := context.Background()
+	svc, cal, _, repo := newTestService(t)
+	svc.address = ""
+	svc.Refresh(ctx)
+
+	cal.err = errors.New("rate limited")
+	svc.dirty["primary"] = true
+	svc.SyncPending(ctx)
+	if repo.tokens["primary"] != "token-1" || svc.watched["primary"].syncToken != "token-1" {
+		t.Errorf("expected a failed sync to keep the token, got %q", repo.tokens["primary"])
+	}
+}
+
+func TestSyncPending_BatchesLargeSyncs(t *testing.T) {
+	svc, cal, _, repo := newTestService(t)
+	start, end := slot(10)
+	for i := 0; i < upsertBatch+1; i++ {
+		cal.changes = append(cal.changes, domain.Appointment{ID: fmt.Sprintf("e%d", i), CustomerTgID: "1", StartTime: start, EndTime: end})
+	}
+
+	svc.Refresh(context.Background())
+	if len(repo.appts) != upsertBatch+1 || repo.batches != 2 {
+		t.Errorf("expected %d appointments in 2 batches, got %d in %d", upsertBatch+1, len(repo.appts), repo.batches)
+	}
+}
+
+func TestRunLoop_SyncsAtStartAndStopsChannels(t *testing.T) {
+	svc, cal, _, repo := newTestService(t)
+	start, end := slot(10)
+	cal.changes = []domain.Appointment{{ID: "e1", CustomerTgID: "1", StartTime: start, EndTime: end, Status: "confirmed"}}
+	listed := make(chan struct{})
+	cal.onSync = func() { close(listed) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := false
+	done := svc.RunLoopForTest(ctx, make(chan time.Time), func() { stopped = true })
+	select {
+	case <-listed:
+	case <-time.After(time.Second):
+		t.Fatal("the first sync did not run within 1s")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunLoopForTest did not exit within 1s after context cancel")
+	}
+
+	if !stopped {
+		t.Error("expected stop() to be called")
+	}
+	if _, ok := repo.appts["e1"]; !ok {
+		t.Error("expected the first full sync stored at start")
+	}
+	if len(cal.watched) != 1 || len(cal.stopped) != 1 || cal.stopped[0] != cal.watched[0].ID {
+		t.Errorf("expected the channel stopped on shutdown, watched %v, stopped %v", cal.watched, cal.stopped)
+	}
+}
@@ -0,0 +1,270 @@
+package packages
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// chargeWindow is how far back completed appointments are looked up, so
+// sessions held while the bot was down are still charged.
+const chargeWindow = 7 * 24 * time.Hour
+
+// BotSender is a minimal interface for sending Telegram messages.
+// *telebot.Bot satisfies this interface automatically.
+type BotSender interface {
+	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+}
+
+// Service keeps prepaid session packages: it charges completed
+// appointments to the patient's package and alerts admins once when a
+// package is about to run out or expire.
+type Service struct {
+	repo      ports.PackageRepository
+	appts     ports.AppointmentService
+	bot       BotSender
+	adminIDs  []string
+	presenter *presentation.BotPresenter
+
+	alertSessions int // alert when this many sessions or fewer remain
+	alertDays     int // alert this many days before expiry
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+}
+
+var _ ports.PackageService = (*Service)(nil)
+
+func NewService(repo ports.PackageRepository, as ports.AppointmentService, bot BotSender, adminIDs []string, p *presentation.BotPresenter, alertSessions, alertDays int) *Service {
+	return &Service{
+		repo:          repo,
+		appts:         as,
+		bot:           bot,
+		adminIDs:      adminIDs,
+		presenter:     p,
+		alertSessions: alertSessions,
+		alertDays:     alertDays,
+		NowFunc:       time.Now,
+	}
+}
+
+// Start charges completed appointments and checks alerts every 10 minutes
+// until ctx is done.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	ticker := time.NewTicker(10 * time.Minute)
+	logging.Infof("Package Service started (alerts at %d sessions / %d days left).", s.alertSessions, s.alertDays)
+
+	return s.RunLoopForTest(ctx, ticker.C, ticker.Stop)
+}
+
+// RunLoopForTest is the inner goroutine extracted from Start so it can be
+// driven by a manual channel in tests; see reminder.Service.RunLoopForTest.
+func (s *Service) RunLoopForTest(ctx context.Context, ticks <-chan time.Time, stop func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stop()
+		for {
+			select {
+			case <-ticks:
+				s.ChargeCompleted(ctx)
+				s.CheckAlerts(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// Sell stores a new package for a patient.
+func (s *Service) Sell(ctx context.Context, pkg domain.SessionPackage) (*domain.SessionPackage, error) {
+	if pkg.PurchasedAt.IsZero() {
+		pkg.PurchasedAt = s.NowFunc()
+	}
+	pkg.SessionsUsed = 0
+	if err := pkg.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePackage(&pkg); err != nil {
+		return nil, err
+	}
+	logging.Infof("Package %d sold to %s: %d sessions until %s", pkg.ID, pkg.PatientID, pkg.SessionsTotal, pkg.ExpiresAt.Format("02.01.2006"))
+	return &pkg, nil
+}
+
+// ListForPatient returns all of the patient's packages, newest first.
+func (s *Service) ListForPatient(ctx context.Context, patientID string) ([]domain.SessionPackage, error) {
+	return s.repo.ListPatientPackages(patientID)
+}
+
+// ChargeCompleted uses one package session for every appointment that
+// ended recently. No-shows and cancellations use none, matching the payments
+// balance, which owes nothing for them. Appointments already charged are
+// skipped by the repository, so running it repeatedly is safe.
+func (s *Service) ChargeCompleted(ctx context.Context) {
+	now := s.NowFunc()
+	appts, err := s.appts.GetUpcomingAppointments(ctx, now.Add(-chargeWindow), now)
+	if err != nil {
+		logging.Errorf(": Failed to fetch completed appointments for packages: %v", err)
+		return
+	}
+
+	byPatient := make(map[string][]domain.SessionPackage)
+	for i := range appts {
+		appt := &appts[i]
+		if appt.CustomerTgID == "" || appt.Status == "cancelled" || appt.EndTime.After(now) {
+			continue
+		}
+		pkgs, ok := byPatient[appt.CustomerTgID]
+		if !ok {
+			pkgs, err = s.repo.ListPatientPackages(appt.CustomerTgID)
+			if err != nil {
+				logging.Errorf(": Failed to load packages of %s: %v", appt.CustomerTgID, err)
+				continue
+			}
+			byPatient[appt.CustomerTgID] = pkgs
+		}
+		pkg := packageFor(pkgs, appt)
+		if pkg == nil {
+			continue
+		}
+		status, err := s.appts.GetAppointmentStatus(ctx, appt.ID)
+		if err != nil {
+			logging.Errorf(": Failed to get status of appointment %s: %v", appt.ID, err)
+			continue
+		}
+		if status == domain.StatusNoShow || status.IsCancelled() {
+			continue
+		}
+		s.charge(pkg, appt)
+	}
+}
+
+// StatusChanged gives back the session charged for a visit that was later
+// marked a no-show or cancelled, e.g. when the admin answers the outcome
+// prompt after the visit was charged or corrects a completed visit.
+func (s *Service) StatusChanged(ctx context.Context, appt domain.Appointment, status domain.AppointmentStatus) {
+	if status != domain.StatusNoShow && !status.IsCancelled() {
+		return
+	}
+	packageID, refunded, err := s.repo.RefundPackage(appt.ID)
+	if err != nil {
+		logging.Errorf(": Failed to refund package session of appointment %s: %v", appt.ID, err)
+		return
+	}
+	if !refunded {
+		return
+	}
+	logging.Infof("Package %d: session of appointment %s (%s) given back", packageID, appt.ID, status)
+
+	id, err := strconv.ParseInt(appt.CustomerTgID, 10, 64)
+	if err != nil {
+		return
+	}
+	pkgs, err := s.repo.ListPatientPackages(appt.CustomerTgID)
+	if err != nil {
+		logging.Warnf("Failed to load packages of %s after refund: %v", appt.CustomerTgID, err)
+		return
+	}
+	for i := range pkgs {
+		if pkgs[i].ID != packageID {
+			continue
+		}
+		local := appt
+		local.StartTime = appt.StartTime.In(domain.ApptTimeZone)
+		if _, err := s.bot.Send(&telebot.User{ID: id}, s.presenter.FormatPackageRefunded(&pkgs[i], &local), telebot.ModeHTML); err != nil {
+			logging.Warnf("Failed to notify %s about package refund: %v", appt.CustomerTgID, err)
+		}
+	}
+}
+
+// packageFor picks the package paying for the appointment: one bought
+// before the visit ended, covering its service and still valid at its
+// start, soonest to expire first.
+func packageFor(pkgs []domain.SessionPackage, appt *domain.Appointment) *domain.SessionPackage {
+	var best *domain.SessionPackage
+	for i := range pkgs {
+		p := &pkgs[i]
+		if !p.PurchasedAt.Before(appt.EndTime) || !p.Covers(appt.ServiceID) || !p.ActiveAt(appt.StartTime) {
+			continue
+		}
+		if best == nil || p.ExpiresAt.Before(best.ExpiresAt) {
+			best = p
+		}
+	}
+	return best
+}
+
+func (s *Service) charge(pkg *domain.SessionPackage, appt *domain.Appointment) {
+	charged, err := s.repo.ChargePackage(pkg.ID, domain.PackageUsage{
+		AppointmentID: appt.ID,
+		ServiceName:   appt.Service.Name,
+		StartTime:     appt.StartTime,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrPackageExhausted) {
+			pkg.SessionsUsed = pkg.SessionsTotal
+		}
+		logging.Errorf(": Failed to charge appointment %s to package %d: %v", appt.ID, pkg.ID, err)
+		return
+	}
+	if !charged {
+		return
+	}
+	pkg.SessionsUsed++
+	logging.Infof("Package %d: appointment %s charged, %d of %d left", pkg.ID, appt.ID, pkg.Remaining(), pkg.SessionsTotal)
+
+	if id, err := strconv.ParseInt(appt.CustomerTgID, 10, 64); err == nil {
+		local := *appt
+		local.StartTime = appt.StartTime.In(domain.ApptTimeZone)
+		if _, err := s.bot.Send(&telebot.User{ID: id}, s.presenter.FormatPackageCharged(pkg, &local), telebot.ModeHTML); err != nil {
+			logging.Warnf("Failed to notify %s about package charge: %v", appt.CustomerTgID, err)
+		}
+	}
+}
+
+// CheckAlerts tells admins, once per package, that it is running out of
+// sessions or is about to expire with sessions left.
+func (s *Service) CheckAlerts(ctx context.Context) {
+	now := s.NowFunc()
+	pkgs, err := s.repo.ListOpenPackages(now)
+	if err != nil {
+		logging.Errorf(": Failed to list packages for alerts: %v", err)
+		return
+	}
+	for i := range pkgs {
+		pkg := &pkgs[i]
+		if !pkg.LowBalanceAlerted && pkg.Remaining() <= s.alertSessions {
+			s.alert(pkg, domain.PackageAlertLowBalance)
+		}
+		if !pkg.ExpiryAlerted && pkg.Remaining() > 0 && pkg.ExpiresAt.Sub(now) <= time.Duration(s.alertDays)*24*time.Hour {
+			s.alert(pkg, domain.PackageAlertExpiry)
+		}
+	}
+}
+
+func (s *Service) alert(pkg *domain.SessionPackage, alert string) {
+	local := *pkg
+	local.ExpiresAt = pkg.ExpiresAt.In(domain.ApptTimeZone)
+	msg := s.presenter.FormatPackageAlert(&local, alert)
+	for _, admin := range s.adminIDs {
+		id, err := strconv.ParseInt(admin, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := s.bot.Send(&telebot.User{ID: id}, msg, telebot.ModeHTML); err != nil {
+			logging.Warnf("Failed to send package alert to admin %s: %v", admin, err)
+		}
+	}
+	if err := s.repo.MarkPackageAlerted(pkg.ID, alert); err != nil {
+		logging.Errorf(": Failed to mark package %d alerted: %v", pkg.ID, err)
+	}
+}
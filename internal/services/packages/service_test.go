@@ -0,0 +1,449 @@
+package packages
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// --- Mocks ---
+
+// mockBotSender captures Send calls without a real Telegram connection.
+type mockBotSender struct {
+	sentTo   []telebot.Recipient
+	sentWhat []interface{}
+}
+
+func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
+	m.sentTo = append(m.sentTo, to)
+	m.sentWhat = append(m.sentWhat, what)
+	return &telebot.Message{}, nil
+}
+
+// mockRepo is an in-memory PackageRepository.
+type mockRepo struct {
+	packages map[int64]*domain.SessionPackage
+	usages   map[string]int64 // appointment ID -> package ID
+	nextID   int64
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{packages: map[int64]*domain.SessionPackage{}, usages: map[string]int64{}}
+}
+
+func (m *mockRepo) CreatePackage(p *domain.SessionPackage) error {
+	m.nextID++
+	p.ID = m.nextID
+	cp := *p
+	m.packages[p.ID] = &cp
+	return nil
+}
+func (m *mockRepo) ListPatientPackages(patientID string) ([]domain.SessionPackage, error) {
+	var out []domain.SessionPackage
+	for id := m.nextID; id > 0; id-- {
+		if p, ok := m.packages[id]; ok && p.PatientID == patientID {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) ListOpenPackages(now time.Time) ([]domain.SessionPackage, error) {
+	var out []domain.SessionPackage
+	for id := int64(1); id <= m.nextID; id++ {
+		p := m.packages[id]
+		if p.ExpiresAt.After(now) && (!p.LowBalanceAlerted || !p.ExpiryAlerted) {
+			out = append(out, *p)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) ChargePackage(packageID int64, usage domain.PackageUsage) (bool, error) {
+	if _, ok := m.usages[usage.AppointmentID]; ok {
+		return false, nil
+	}
+	p := m.packages[packageID]
+	if p.SessionsUsed >= p.SessionsTotal {
+		return false, domain.ErrPackageExhausted
+	}
+	m.usages[usage.AppointmentID] = packageID
+	p.SessionsUsed++
+	return true, nil
+}
+func (m *mockRepo) RefundPackage(appointmentID string) (int64, bool, error) {
+	packageID, ok := m.usages[appointmentID]
+	if !ok {
+		return 0, false, nil
+	}
+	delete(m.usages, appointmentID)
+	if p := m.packages[packageID]; p.SessionsUsed > 0 {
+		p.SessionsUsed--
+	}
+	return packageID, true, nil
+}
+func (m *mockRepo) MarkPackageAlerted(packageID int64, alert string) error {
+	if alert == domain.PackageAlertExpiry {
+		m.packages[packageID].ExpiryAlerted = true
+	} else {
+		m.packages[packageID].LowBalanceAlerted = true
+	}
+	return nil
+}
+
+// mockApptService is a minimal AppointmentService stub for package tests.
+type mockApptService struct {
+	appts    []domain.Appointment
+	statuses map[string]domain.AppointmentStatus
+	err      error
+}
+
+func (m *mockApptService) GetAvailableServices(ctx context.Context) ([]domain.Service, error) {
+	return []domain.Service{{ID: "svc", Name: "Массаж", DurationMinutes: 60, Price: 2000}}, nil
+}
+func (m *mockApptService) GetAvailableTimeSlots(ctx context.Context, date time.Time, dur int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, dur int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) CreateAppointment(ctx context.Context, a *domain.Appointment) (*domain.Appointment, error) {
+	return a, nil
+}
+func (m *mockApptService) CancelAppointment(ctx context.Context, id string) error { return nil }
+func (m *mockApptService) RescheduleAppointment(ctx context.Context, id string, t time.Time) (*domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+	return &series, nil
+}
+func (m *mockApptService) GetAppointmentSeries(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+	return nil, domain.ErrSeriesNotFound
+}
+func (m *mockApptService) CancelSeries(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetAppointmentStatus(ctx context.Context, id string) (domain.AppointmentStatus, error) {
+	if status, ok := m.statuses[id]; ok {
+		return status, nil
+	}
+	return domain.StatusBooked, nil
+}
+func (m *mockApptService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	return nil
+}
+func (m *mockApptService) GetPatientStatusCounts(ctx context.Context, id string) (map[domain.AppointmentStatus]int, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCancellationPolicy() domain.CancellationPolicy {
+	return domain.DefaultCancellationPolicy()
+}
+func (m *mockApptService) HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+	return nil
+}
+func (m *mockApptService) ReleaseSlotHold(ctx context.Context, holderID string) error {
+	return nil
+}
+func (m *mockApptService) FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
+	var out []domain.Appointment
+	for _, a := range m.appts {
+		if !a.StartTime.Before(timeMin) && a.StartTime.Before(timeMax) {
+			out = append(out, a)
+		}
+	}
+	return out, m.err
+}
+func (m *mockApptService) GetCustomerAppointments(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCustomerHistory(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) HistorySynced() bool {
+	return false
+}
+func (m *mockApptService) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetTotalUpcomingCount(ctx context.Context) (int, error) { return 0, nil }
+func (m *mockApptService) GetCalendarAccountInfo(ctx context.Context) (string, error) {
+	return "", nil
+}
+func (m *mockApptService) GetCalendarID() string                               { return "" }
+func (m *mockApptService) ListCalendars(ctx context.Context) ([]string, error) { return nil, nil }
+func (m *mockApptService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	return &svc, nil
+}
+func (m *mockApptService) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	return nil
+}
+func (m *mockApptService) ReorderServices(ctx context.Context, ids []string) error { return nil }
+func (m *mockApptService) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	return nil
+}
+func (m *mockApptService) GetSchedule(ctx context.Context) (domain.WeeklySchedule, error) {
+	return domain.WeeklySchedule{}, nil
+}
+func (m *mockApptService) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+	return nil
+}
+func (m *mockApptService) SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error {
+	return nil
+}
+func (m *mockApptService) DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error {
+	return nil
+}
+func (m *mockApptService) GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveTherapist(ctx context.Context, t domain.Therapist) error { return nil }
+
+// --- Helpers ---
+
+var testNow = time.Date(2030, 2, 1, 20, 0, 0, 0, time.UTC)
+
+func newTestService(t *testing.T) (*Service, *mockRepo, *mockApptService, *mockBotSender) {
+	t.Helper()
+	oldTZ := domain.ApptTimeZone
+	domain.ApptTimeZone = time.UTC
+	t.Cleanup(func() { domain.ApptTimeZone = oldTZ })
+
+	repo := newMockRepo()
+	appts := &mockApptService{}
+	bot := &mockBotSender{}
+	svc := NewService(repo, appts, bot, []string{"999"}, presentation.NewBotPresenter(), 1, 7)
+	svc.NowFunc = func() time.Time { return testNow }
+	return svc, repo, appts, bot
+}
+
+func sell(t *testing.T, svc *Service, pkg domain.SessionPackage) *domain.SessionPackage {
+	t.Helper()
+	if pkg.PatientID == "" {
+		pkg.PatientID = "100"
+	}
+	if pkg.PurchasedAt.IsZero() {
+		pkg.PurchasedAt = testNow.AddDate(0, 0, -10)
+	}
+	if pkg.ExpiresAt.IsZero() {
+		pkg.ExpiresAt = testNow.AddDate(0, 2, 0)
+	}
+	sold, err := svc.Sell(context.Background(), pkg)
+	if err != nil {
+		t.Fatalf("Sell failed: %v", err)
+	}
+	return sold
+}
+
+func visit(id, serviceID string, start time.Time) domain.Appointment {
+	return domain.Appointment{
+		ID: id, ServiceID: serviceID, Service: domain.Service{ID: serviceID, Name: "Массаж"},
+		CustomerTgID: "100", CustomerName: "Иван",
+		StartTime: start, EndTime: start.Add(time.Hour),
+	}
+}
+
+// --- Tests ---
+
+func TestService_Sell(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+
+	pkg, err := svc.Sell(context.Background(), domain.SessionPackage{
+		PatientID: "100", SessionsTotal: 10, SessionsUsed: 4, ExpiresAt: testNow.AddDate(0, 3, 0),
+	})
+	if err != nil {
+		t.Fatalf("Sell failed: %v", err)
+	}
+	if pkg.ID == 0 || !pkg.PurchasedAt.Equal(testNow) || pkg.SessionsUsed != 0 {
+		t.Errorf("unexpected package: %+v", pkg)
+	}
+	if len(repo.packages) != 1 {
+		t.Errorf("stored packages = %d, want 1", len(repo.packages))
+	}
+
+	_, err = svc.Sell(context.Background(), domain.SessionPackage{PatientID: "100", SessionsTotal: 10, ExpiresAt: testNow.Add(-time.Hour)})
+	if !errors.Is(err, domain.ErrInvalidPackage) {
+		t.Errorf("Sell with past expiry = %v, want ErrInvalidPackage", err)
+	}
+}
+
+func TestService_ChargeCompleted(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	pkg := sell(t, svc, domain.SessionPackage{SessionsTotal: 5, ServiceIDs: []string{"massage"}, PurchasedAt: testNow.AddDate(0, 0, -2)})
+
+	appts.appts = []domain.Appointment{
+		visit("done", "massage", testNow.Add(-3*time.Hour)),
+		visit("other-service", "consult", testNow.Add(-5*time.Hour)),
+		visit("in-progress", "massage", testNow.Add(-30*time.Minute)),
+		visit("before-purchase", "massage", testNow.AddDate(0, 0, -3)),
+	}
+	cancelled := visit("cancelled", "massage", testNow.Add(-26*time.Hour))
+	cancelled.Status = "cancelled"
+	appts.appts = append(appts.appts, cancelled)
+
+	svc.ChargeCompleted(context.Background())
+	svc.ChargeCompleted(context.Background())
+
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 1 {
+		t.Errorf("SessionsUsed = %d, want 1", got)
+	}
+	if _, ok := repo.usages["done"]; !ok || len(repo.usages) != 1 {
+		t.Errorf("usages = %v, want only the completed massage", repo.usages)
+	}
+	if len(bot.sentTo) != 1 || bot.sentTo[0].Recipient() != "100" {
+		t.Fatalf("expected one notice to the patient, got %v", bot.sentTo)
+	}
+	if msg, _ := bot.sentWhat[0].(string); !strings.Contains(msg, "4 из 5") {
+		t.Errorf("expected the remaining balance in %q", msg)
+	}
+}
+
+func TestService_ChargeCompleted_SkipsNoShowsAndCancellations(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	pkg := sell(t, svc, domain.SessionPackage{SessionsTotal: 5})
+
+	appts.appts = []domain.Appointment{
+		visit("completed", "massage", testNow.Add(-2*time.Hour)),
+		visit("no-show", "massage", testNow.Add(-3*time.Hour)),
+		visit("late-cancel", "massage", testNow.Add(-4*time.Hour)),
+	}
+	appts.statuses = map[string]domain.AppointmentStatus{
+		"completed":   domain.StatusCompleted,
+		"no-show":     domain.StatusNoShow,
+		"late-cancel": domain.StatusLateCancel,
+	}
+
+	svc.ChargeCompleted(context.Background())
+
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 1 {
+		t.Errorf("SessionsUsed = %d, want 1", got)
+	}
+	if _, ok := repo.usages["completed"]; !ok || len(repo.usages) != 1 {
+		t.Errorf("usages = %v, want only the completed visit", repo.usages)
+	}
+	if len(bot.sentTo) != 1 {
+		t.Errorf("expected one notice to the patient, got %d", len(bot.sentTo))
+	}
+}
+
+func TestService_StatusChanged_RefundsLateNoShow(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	pkg := sell(t, svc, domain.SessionPackage{SessionsTotal: 5})
+	appt := visit("a1", "massage", testNow.Add(-2*time.Hour))
+	appts.appts = []domain.Appointment{appt}
+
+	// Charged before the admin answered the outcome prompt
+	svc.ChargeCompleted(context.Background())
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 1 {
+		t.Fatalf("SessionsUsed = %d, want 1", got)
+	}
+
+	// Completed visits keep their charge
+	svc.StatusChanged(context.Background(), appt, domain.StatusCompleted)
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 1 {
+		t.Errorf("SessionsUsed after completed = %d, want 1", got)
+	}
+
+	appts.statuses = map[string]domain.AppointmentStatus{"a1": domain.StatusNoShow}
+	svc.StatusChanged(context.Background(), appt, domain.StatusNoShow)
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 0 {
+		t.Errorf("SessionsUsed after no-show = %d, want 0", got)
+	}
+	if _, ok := repo.usages["a1"]; ok {
+		t.Error("expected the usage removed")
+	}
+	if len(bot.sentTo) != 2 {
+		t.Fatalf("expected the charge and the refund notices, got %d", len(bot.sentTo))
+	}
+	if msg, _ := bot.sentWhat[1].(string); !strings.Contains(msg, "ВОЗВРАЩЁН") || !strings.Contains(msg, "5 из 5") {
+		t.Errorf("unexpected refund notice %q", msg)
+	}
+
+	// The no-show is not charged again, and refunding twice is a no-op
+	svc.ChargeCompleted(context.Background())
+	svc.StatusChanged(context.Background(), appt, domain.StatusNoShow)
+	if got := repo.packages[pkg.ID].SessionsUsed; got != 0 || len(bot.sentTo) != 2 {
+		t.Errorf("expected nothing more to happen, got SessionsUsed=%d, %d notices", got, len(bot.sentTo))
+	}
+}
+
+func TestService_ChargeCompleted_SoonestExpiringFirst(t *testing.T) {
+	svc, repo, appts, _ := newTestService(t)
+	later := sell(t, svc, domain.SessionPackage{SessionsTotal: 5, ExpiresAt: testNow.AddDate(0, 3, 0)})
+	sooner := sell(t, svc, domain.SessionPackage{SessionsTotal: 5, ExpiresAt: testNow.AddDate(0, 1, 0)})
+	appts.appts = []domain.Appointment{visit("a1", "massage", testNow.Add(-2*time.Hour))}
+
+	svc.ChargeCompleted(context.Background())
+
+	if repo.packages[sooner.ID].SessionsUsed != 1 || repo.packages[later.ID].SessionsUsed != 0 {
+		t.Errorf("expected the package expiring sooner to be charged: sooner=%d later=%d",
+			repo.packages[sooner.ID].SessionsUsed, repo.packages[later.ID].SessionsUsed)
+	}
+}
+
+func TestService_CheckAlerts(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	low := sell(t, svc, domain.SessionPackage{SessionsTotal: 5})
+	repo.packages[low.ID].SessionsUsed = 4
+	expiring := sell(t, svc, domain.SessionPackage{SessionsTotal: 5, ExpiresAt: testNow.AddDate(0, 0, 3)})
+	sell(t, svc, domain.SessionPackage{SessionsTotal: 5})
+
+	svc.CheckAlerts(context.Background())
+	svc.CheckAlerts(context.Background())
+
+	if len(bot.sentTo) != 2 {
+		t.Fatalf("alerts sent = %d, want 2", len(bot.sentTo))
+	}
+	for _, to := range bot.sentTo {
+		if to.Recipient() != "999" {
+			t.Errorf("alert sent to %s, want the admin", to.Recipient())
+		}
+	}
+	if !repo.packages[low.ID].LowBalanceAlerted || repo.packages[low.ID].ExpiryAlerted {
+		t.Errorf("low package flags = %+v", repo.packages[low.ID])
+	}
+	if !repo.packages[expiring.ID].ExpiryAlerted || repo.packages[expiring.ID].LowBalanceAlerted {
+		t.Errorf("expiring package flags = %+v", repo.packages[expiring.ID])
+	}
+}
+
+func TestService_RunLoopForTest(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	pkg := sell(t, svc, domain.SessionPackage{SessionsTotal: 2})
+	appts.appts = []domain.Appointment{visit("a1", "massage", testNow.Add(-2*time.Hour))}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	done := svc.RunLoopForTest(ctx, ticks, func() {})
+	ticks <- testNow
+	cancel()
+	<-done
+
+	if repo.packages[pkg.ID].SessionsUsed != 1 || !repo.packages[pkg.ID].LowBalanceAlerted {
+		t.Errorf("expected a charge and a low-balance alert, got %+v", repo.packages[pkg.ID])
+	}
+	if len(bot.sentTo) != 2 {
+		t.Errorf("messages sent = %d, want 2 (patient notice and admin alert)", len(bot.sentTo))
+	}
+}
@@ -0,0 +1,259 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// unpaidWindow is how far back visits are checked for a payment. Older
+// visits are assumed to have been settled outside the bot.
+const unpaidWindow = 30 * 24 * time.Hour
+
+// Service records visit payments, finds visits still to be paid and keeps
+// the revenue gauges up to date.
+type Service struct {
+	repo     ports.PaymentRepository
+	appts    ports.AppointmentService
+	currency string
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+}
+
+var _ ports.PaymentService = (*Service)(nil)
+
+func NewService(repo ports.PaymentRepository, as ports.AppointmentService, currency string) *Service {
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	return &Service{
+		repo:     repo,
+		appts:    as,
+		currency: currency,
+		NowFunc:  time.Now,
+	}
+}
+
+// Start refreshes the revenue gauges every 10 minutes until ctx is done.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	ticker := time.NewTicker(10 * time.Minute)
+	logging.Infof("Payment Service started (currency %s).", s.currency)
+
+	return s.RunLoopForTest(ctx, ticker.C, ticker.Stop)
+}
+
+// RunLoopForTest is the inner goroutine extracted from Start so it can be
+// driven by a manual channel in tests; see reminder.Service.RunLoopForTest.
+func (s *Service) RunLoopForTest(ctx context.Context, ticks <-chan time.Time, stop func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stop()
+		for {
+			select {
+			case <-ticks:
+				s.UpdateMetrics(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// RecordPayment stores a visit payment. Without an amount the visit is
+// taken as paid at its price less the discount; package visits are free.
+func (s *Service) RecordPayment(ctx context.Context, p domain.Payment) (*domain.Payment, error) {
+	if p.Currency == "" {
+		p.Currency = s.currency
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = s.NowFunc()
+	}
+	switch {
+	case p.Method == domain.PaymentPackage:
+		p.Amount = 0
+	case p.Amount == 0 && p.ListPrice > p.Discount:
+		p.Amount = p.ListPrice - p.Discount
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePayment(&p); err != nil {
+		return nil, err
+	}
+	logging.Infof("Payment %d recorded for appointment %s: %.0f %s by %s", p.ID, p.AppointmentID, p.Amount, p.Currency, p.Method)
+	return &p, nil
+}
+
+// UnpaidVisits returns visits held within unpaidWindow that have no payment
+// yet, oldest first. Blocked time and cancelled events are not visits.
+func (s *Service) UnpaidVisits(ctx context.Context, patientID string) ([]domain.Appointment, error) {
+	now := s.NowFunc()
+	appts, err := s.appts.GetUpcomingAppointments(ctx, now.Add(-unpaidWindow), now)
+	if err != nil && !errors.Is(err, domain.ErrAppointmentNotFound) {
+		return nil, err
+	}
+
+	var held []domain.Appointment
+	var ids []string
+	for _, appt := range appts {
+		if appt.CustomerTgID == "" || appt.Source == domain.SourceBlock || appt.Status == "cancelled" || appt.EndTime.After(now) {
+			continue
+		}
+		if patientID != "" && appt.CustomerTgID != patientID {
+			continue
+		}
+		held = append(held, appt)
+		ids = append(ids, appt.ID)
+	}
+	if len(held) == 0 {
+		return nil, nil
+	}
+
+	settled, err := s.repo.ListSettledAppointments(ids)
+	if err != nil {
+		return nil, err
+	}
+	var unpaid []domain.Appointment
+	for _, appt := range held {
+		if !settled[appt.ID] {
+			unpaid = append(unpaid, appt)
+		}
+	}
+	s.fillPrices(ctx, unpaid)
+	sort.Slice(unpaid, func(i, j int) bool { return unpaid[i].StartTime.Before(unpaid[j].StartTime) })
+	return unpaid, nil
+}
+
+// FindVisit looks ref up as a patient's Telegram ID first, taking their
+// latest unpaid visit, then as an appointment ID.
+func (s *Service) FindVisit(ctx context.Context, ref string) (*domain.Appointment, error) {
+	if _, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		unpaid, err := s.UnpaidVisits(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if len(unpaid) > 0 {
+			return &unpaid[len(unpaid)-1], nil
+		}
+	}
+
+	appt, err := s.appts.FindByID(ctx, ref)
+	if err != nil {
+		if errors.Is(err, domain.ErrAppointmentNotFound) {
+			return nil, domain.ErrVisitNotFound
+		}
+		return nil, err
+	}
+	if appt == nil || appt.CustomerTgID == "" || appt.Source == domain.SourceBlock {
+		return nil, domain.ErrVisitNotFound
+	}
+	visit := []domain.Appointment{*appt}
+	s.fillPrices(ctx, visit)
+	return &visit[0], nil
+}
+
+// OutstandingBalance sums what is due for the patient's unpaid visits.
+func (s *Service) OutstandingBalance(ctx context.Context, patientID string) (float64, error) {
+	unpaid, err := s.UnpaidVisits(ctx, patientID)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, appt := range unpaid {
+		total += appt.AmountDue()
+	}
+	return total, nil
+}
+
+// Currency is the currency payments are recorded in by default.
+func (s *Service) Currency() string {
+	return s.currency
+}
+
+// Revenue reports the visit payments and package sales in [from, to),
+// bucketed in the clinic time zone.
+func (s *Service) Revenue(ctx context.Context, from, to time.Time, period domain.RevenuePeriod) (*domain.RevenueReport, error) {
+	payments, err := s.repo.ListPayments(from, to)
+	if err != nil {
+		return nil, err
+	}
+	packages, err := s.repo.ListPackagesSold(from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := domain.BuildRevenueReport(payments, packages, from, to, period, s.currency, domain.ApptTimeZone)
+	return &report, nil
+}
+
+// UpdateMetrics sets the revenue gauges for the current day, week and month
+// and the outstanding balance of all patients.
+func (s *Service) UpdateMetrics(ctx context.Context) {
+	now := s.NowFunc().In(domain.ApptTimeZone)
+	monitoring.RevenueTotal.Reset()
+	monitoring.RevenueByMethod.Reset()
+	for _, period := range []domain.RevenuePeriod{domain.RevenueDay, domain.RevenueWeek, domain.RevenueMonth} {
+		report, err := s.Revenue(ctx, period.Start(now), now, period)
+		if err != nil {
+			logging.Errorf(": Failed to build %s revenue for metrics: %v", period, err)
+			return
+		}
+		for _, line := range report.ByService {
+			monitoring.RevenueTotal.WithLabelValues(string(period), line.Label).Set(line.Amount)
+		}
+		if report.Packages.Visits > 0 {
+			monitoring.RevenueTotal.WithLabelValues(string(period), "packages").Set(report.Packages.Amount)
+		}
+		for _, line := range report.ByMethod {
+			monitoring.RevenueByMethod.WithLabelValues(string(period), line.Label).Set(line.Amount)
+		}
+	}
+
+	balance, err := s.OutstandingBalance(ctx, "")
+	if err != nil {
+		logging.Errorf(": Failed to compute outstanding balance for metrics: %v", err)
+		return
+	}
+	monitoring.OutstandingBalance.Set(balance)
+}
+
+// fillPrices sets the catalog price on visits whose calendar event did not
+// carry one.
+func (s *Service) fillPrices(ctx context.Context, appts []domain.Appointment) {
+	var catalog map[string]domain.Service
+	for i := range appts {
+		appt := &appts[i]
+		if appt.Service.Price > 0 {
+			continue
+		}
+		if catalog == nil {
+			catalog = make(map[string]domain.Service)
+			services, err := s.appts.GetAllServices(ctx)
+			if err != nil {
+				logging.Warnf("Failed to load services for visit prices: %v", err)
+			}
+			for _, svc := range services {
+				catalog[svc.ID] = svc
+			}
+		}
+		id := appt.ServiceID
+		if id == "" {
+			id = appt.Service.ID
+		}
+		if svc, ok := catalog[id]; ok {
+			appt.Service.Price = svc.Price
+			if appt.Service.Name == "" {
+				appt.Service.Name = svc.Name
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// --- Mocks ---
+
+// mockRepo is an in-memory PaymentRepository.
+type mockRepo struct {
+	payments []domain.Payment
+	packages []domain.SessionPackage
+	settled  map[string]bool
+	err      error
+}
+
+func (m *mockRepo) SavePayment(p *domain.Payment) error {
+	if m.err != nil {
+		return m.err
+	}
+	p.ID = int64(len(m.payments) + 1)
+	m.payments = append(m.payments, *p)
+	return nil
+}
+func (m *mockRepo) ListPayments(from, to time.Time) ([]domain.Payment, error) {
+	var out []domain.Payment
+	for _, p := range m.payments {
+		if !p.PaidAt.Before(from) && p.PaidAt.Before(to) {
+			out = append(out, p)
+		}
+	}
+	return out, m.err
+}
+func (m *mockRepo) ListSettledAppointments(ids []string) (map[string]bool, error) {
+	out := make(map[string]bool)
+	for _, id := range ids {
+		if m.settled[id] {
+			out[id] = true
+		}
+	}
+	return out, m.err
+}
+func (m *mockRepo) ListPackagesSold(from, to time.Time) ([]domain.SessionPackage, error) {
+	var out []domain.SessionPackage
+	for _, p := range m.packages {
+		if !p.PurchasedAt.Before(from) && p.PurchasedAt.Before(to) {
+			out = append(out, p)
+		}
+	}
+	return out, m.err
+}
+
+// mockApptService serves a fixed list of appointments and services.
+type mockApptService struct {
+	ports.AppointmentService
+	appts    []domain.Appointment
+	services []domain.Service
+}
+
+func (m *mockApptService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
+	var out []domain.Appointment
+	for _, a := range m.appts {
+		if a.EndTime.After(timeMin) && a.StartTime.Before(timeMax) {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+func (m *mockApptService) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	for _, a := range m.appts {
+		if a.ID == id {
+			return &a, nil
+		}
+	}
+	return nil, domain.ErrAppointmentNotFound
+}
+func (m *mockApptService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	return m.services, nil
+}
+
+// --- Helpers ---
+
+var testNow = time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+
+func visit(id, patient string, daysAgo int, price float64) domain.Appointment {
+	start := testNow.AddDate(0, 0, -daysAgo).Add(-4 * time.Hour)
+	return domain.Appointment{
+		ID: id, CustomerTgID: patient, CustomerName: "Patient " + patient,
+		ServiceID: "massage", Service: domain.Service{ID: "massage", Name: "Massage", Price: price},
+		StartTime: start, EndTime: start.Add(time.Hour),
+	}
+}
+
+func newTestService(repo *mockRepo, appts []domain.Appointment) *Service {
+	s := NewService(repo, &mockApptService{
+		appts:    appts,
+		services: []domain.Service{{ID: "massage", Name: "Massage", Price: 2000}},
+	}, "")
+	s.NowFunc = func() time.Time { return testNow }
+	return s
+}
+
+// --- Tests ---
+
+func TestRecordPayment(t *testing.T) {
+	repo := &mockRepo{}
+	s := newTestService(repo, nil)
+
+	t.Run("Defaults", func(t *testing.T) {
+		p, err := s.RecordPayment(context.Background(), domain.Payment{
+			AppointmentID: "a1", PatientID: "42", ListPrice: 2000, Discount: 500, Method: domain.PaymentCard,
+		})
+		if err != nil {
+			t.Fatalf("RecordPayment() error = %v", err)
+		}
+		if p.ID == 0 || p.Amount != 1500 || p.Currency != domain.DefaultCurrency || !p.PaidAt.Equal(testNow) {
+			t.Errorf("unexpected payment %+v", p)
+		}
+	})
+
+	t.Run("Package visits are free", func(t *testing.T) {
+		p, err := s.RecordPayment(context.Background(), domain.Payment{
+			AppointmentID: "a2", PatientID: "42", ListPrice: 2000, Amount: 2000, Method: domain.PaymentPackage,
+		})
+		if err != nil || p.Amount != 0 {
+			t.Errorf("expected a zero amount, got %+v, %v", p, err)
+		}
+	})
+
+	t.Run("Explicit amount is kept", func(t *testing.T) {
+		p, err := s.RecordPayment(context.Background(), domain.Payment{
+			AppointmentID: "a3", PatientID: "42", ListPrice: 2000, Amount: 2500, Method: domain.PaymentCash,
+		})
+		if err != nil || p.Amount != 2500 {
+			t.Errorf("expected the amount kept, got %+v, %v", p, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := s.RecordPayment(context.Background(), domain.Payment{PatientID: "42", Method: domain.PaymentCash}); !errors.Is(err, domain.ErrInvalidPayment) {
+			t.Errorf("error = %v, want ErrInvalidPayment", err)
+		}
+	})
+}
+
+func TestUnpaidVisits(t *testing.T) {
+	blocked := visit("block", "42", 1, 0)
+	blocked.Source = domain.SourceBlock
+	cancelled := visit("cancelled", "42", 1, 2000)
+	cancelled.Status = "cancelled"
+	upcoming := visit("later", "42", 0, 2000)
+	upcoming.StartTime, upcoming.EndTime = testNow.Add(time.Hour), testNow.Add(2*time.Hour)
+
+	repo := &mockRepo{settled: map[string]bool{"paid": true}}
+	s := newTestService(repo, []domain.Appointment{
+		visit("recent", "42", 1, 1800),
+		visit("old", "42", 5, 0), // price from the catalog
+		visit("paid", "42", 2, 2000),
+		visit("other", "7", 1, 1500),
+		blocked, cancelled, upcoming,
+		visit("ancient", "42", 60, 2000),
+	})
+
+	unpaid, err := s.UnpaidVisits(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("UnpaidVisits() error = %v", err)
+	}
+	if len(unpaid) != 2 || unpaid[0].ID != "old" || unpaid[1].ID != "recent" {
+		t.Fatalf("unexpected unpaid visits %+v", unpaid)
+	}
+	if unpaid[0].Service.Price != 2000 {
+		t.Errorf("expected the catalog price filled in, got %.0f", unpaid[0].Service.Price)
+	}
+
+	balance, _ := s.OutstandingBalance(context.Background(), "42")
+	if balance != 3800 {
+		t.Errorf("OutstandingBalance(42) = %.0f, want 3800", balance)
+	}
+	all, _ := s.OutstandingBalance(context.Background(), "")
+	if all != 5300 {
+		t.Errorf("OutstandingBalance() = %.0f, want 5300", all)
+	}
+}
+
+func TestOutstandingBalance_LessBookingDiscount(t *testing.T) {
+	promo := visit("promo", "42", 1, 2000)
+	promo.PromoCode, promo.Discount = "SPRING10", 200
+	s := newTestService(&mockRepo{}, []domain.Appointment{promo, visit("plain", "42", 2, 1500)})
+
+	if balance, _ := s.OutstandingBalance(context.Background(), "42"); balance != 3300 {
+		t.Errorf("OutstandingBalance(42) = %.0f, want 3300", balance)
+	}
+}
+
+func TestFindVisit(t *testing.T) {
+	repo := &mockRepo{}
+	s := newTestService(repo, []domain.Appointment{
+		visit("older", "42", 3, 2000),
+		visit("latest", "42", 1, 2000),
+		visit("a7", "7", 1, 0),
+	})
+
+	if v, err := s.FindVisit(context.Background(), "42"); err != nil || v.ID != "latest" {
+		t.Errorf("FindVisit(patient) = %+v, %v; want the latest unpaid visit", v, err)
+	}
+	if v, err := s.FindVisit(context.Background(), "a7"); err != nil || v.ID != "a7" || v.Service.Price != 2000 {
+		t.Errorf("FindVisit(appointment) = %+v, %v", v, err)
+	}
+	if _, err := s.FindVisit(context.Background(), "99"); !errors.Is(err, domain.ErrVisitNotFound) {
+		t.Errorf("FindVisit(unknown) error = %v, want ErrVisitNotFound", err)
+	}
+}
+
+func TestRevenue(t *testing.T) {
+	repo := &mockRepo{
+		payments: []domain.Payment{
+			{AppointmentID: "a1", ServiceName: "Massage", Amount: 1500, Method: domain.PaymentCash, PaidAt: testNow.Add(-time.Hour)},
+			{AppointmentID: "a2", ServiceName: "Massage", Amount: 2000, Method: domain.PaymentCard, PaidAt: testNow.AddDate(0, -2, 0)},
+		},
+		packages: []domain.SessionPackage{{Price: 9000, PurchasedAt: testNow.Add(-2 * time.Hour)}},
+	}
+	s := newTestService(repo, nil)
+
+	report, err := s.Revenue(context.Background(), testNow.AddDate(0, 0, -7), testNow, domain.RevenueDay)
+	if err != nil {
+		t.Fatalf("Revenue() error = %v", err)
+	}
+	if report.Total != 10500 || report.Visits != 1 || report.Currency != domain.DefaultCurrency {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	repo.err = errors.New("db down")
+	if _, err := s.Revenue(context.Background(), testNow.AddDate(0, 0, -7), testNow, domain.RevenueDay); err == nil {
+		t.Error("expected the repository error")
+	}
+}
+
+func TestUpdateMetrics(t *testing.T) {
+	repo := &mockRepo{payments: []domain.Payment{
+		{AppointmentID: "a1", ServiceName: "Massage", Amount: 1500, Method: domain.PaymentCash, PaidAt: testNow.Add(-time.Hour)},
+	}}
+	s := newTestService(repo, []domain.Appointment{visit("unpaid", "42", 1, 2000)})
+	s.UpdateMetrics(context.Background())
+
+	gauge := func(g interface{ Write(*dto.Metric) error }) float64 {
+		m := &dto.Metric{}
+		if err := g.Write(m); err != nil {
+			t.Fatalf("failed to read gauge: %v", err)
+		}
+		return m.Gauge.GetValue()
+	}
+	if v := gauge(monitoring.RevenueTotal.WithLabelValues("day", "Massage")); v != 1500 {
+		t.Errorf("day revenue = %.0f, want 1500", v)
+	}
+	if v := gauge(monitoring.RevenueByMethod.WithLabelValues("month", "cash")); v != 1500 {
+		t.Errorf("monthly cash revenue = %.0f, want 1500", v)
+	}
+	if v := gauge(monitoring.OutstandingBalance); v != 2000 {
+		t.Errorf("outstanding balance = %.0f, want 2000", v)
+	}
+}
+
+func TestRunLoop(t *testing.T) {
+	repo := &mockRepo{}
+	s := newTestService(repo, []domain.Appointment{visit("unpaid", "42", 1, 700)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	stopped := false
+	done := s.RunLoopForTest(ctx, ticks, func() { stopped = true })
+
+	ticks <- testNow
+	cancel()
+	<-done
+	if !stopped {
+		t.Error("expected the ticker to be stopped")
+	}
+}
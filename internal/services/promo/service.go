@@ -0,0 +1,124 @@
+package promo
+
+import (
+	"context"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// Service keeps promo codes: admins create them, patients apply them while
+// booking, and every confirmed booking counts one use.
+type Service struct {
+	repo ports.PromoRepository
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+}
+
+var _ ports.PromoService = (*Service)(nil)
+
+func NewService(repo ports.PromoRepository) *Service {
+	return &Service{
+		repo:    repo,
+		NowFunc: time.Now,
+	}
+}
+
+// CreatePromo stores a new code, normalized, with no uses yet.
+func (s *Service) CreatePromo(ctx context.Context, p domain.PromoCode) (*domain.PromoCode, error) {
+	now := s.NowFunc()
+	p.Code = domain.NormalizePromoCode(p.Code)
+	if p.ValidFrom.IsZero() {
+		p.ValidFrom = now
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = now
+	}
+	p.Uses = 0
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreatePromoCode(p); err != nil {
+		return nil, err
+	}
+	logging.Infof("Promo code %s created by %s: %s until %s", p.Code, p.CreatedBy, p.Label(), p.ValidUntil.Format("02.01.2006"))
+	return &p, nil
+}
+
+// ListPromos returns every code, newest first.
+func (s *Service) ListPromos(ctx context.Context) ([]domain.PromoCode, error) {
+	return s.repo.ListPromoCodes()
+}
+
+// ListPromoUsages returns the bookings that used the code, newest first.
+func (s *Service) ListPromoUsages(ctx context.Context, code string) ([]domain.PromoUsage, error) {
+	return s.repo.ListPromoUsages(domain.NormalizePromoCode(code))
+}
+
+// ApplyPromo looks the code up and checks it against the service and the
+// current time. The use is only counted by ReservePromo.
+func (s *Service) ApplyPromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error) {
+	promo, err := s.repo.GetPromoCode(domain.NormalizePromoCode(code))
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := promo.Check(serviceID, s.NowFunc()); err != nil {
+		return nil, 0, err
+	}
+	return promo, promo.DiscountFor(price), nil
+}
+
+// ReservePromo checks the code and takes one of its uses. The limit is
+// enforced by the reservation itself, not by the check, so concurrent
+// bookings cannot overrun it.
+func (s *Service) ReservePromo(ctx context.Context, code, serviceID string, price float64) (*domain.PromoCode, float64, error) {
+	promo, discount, err := s.ApplyPromo(ctx, code, serviceID, price)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.repo.ReservePromoCode(promo.Code); err != nil {
+		return nil, 0, err
+	}
+	return promo, discount, nil
+}
+
+// ReleasePromo gives back a use reserved for a booking that was not made.
+func (s *Service) ReleasePromo(ctx context.Context, code string) error {
+	if code == "" {
+		return nil
+	}
+	if err := s.repo.ReleasePromoCode(code); err != nil {
+		return err
+	}
+	logging.Infof("Promo code %s use released", code)
+	return nil
+}
+
+// RedeemPromo records the appointment against the use ReservePromo took for
+// it. Appointments without a code are left alone; recording one twice gives
+// back the extra use.
+func (s *Service) RedeemPromo(ctx context.Context, appt domain.Appointment) error {
+	if appt.PromoCode == "" {
+		return nil
+	}
+	recorded, err := s.repo.RecordPromoUsage(domain.PromoUsage{
+		Code:          appt.PromoCode,
+		AppointmentID: appt.ID,
+		PatientID:     appt.CustomerTgID,
+		PatientName:   appt.CustomerName,
+		ServiceName:   appt.Service.Name,
+		Discount:      appt.Discount,
+		UsedAt:        s.NowFunc(),
+	})
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		return s.repo.ReleasePromoCode(appt.PromoCode)
+	}
+	logging.Infof("Promo code %s redeemed for appointment %s (discount %.0f)", appt.PromoCode, appt.ID, appt.Discount)
+	return nil
+}
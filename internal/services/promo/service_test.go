@@ -0,0 +1,223 @@
+package promo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+// mockRepo is an in-memory PromoRepository.
+type mockRepo struct {
+	codes  map[string]domain.PromoCode
+	usages []domain.PromoUsage
+	err    error
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{codes: make(map[string]domain.PromoCode)}
+}
+
+func (m *mockRepo) CreatePromoCode(p domain.PromoCode) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.codes[p.Code]; ok {
+		return domain.ErrPromoExists
+	}
+	m.codes[p.Code] = p
+	return nil
+}
+func (m *mockRepo) GetPromoCode(code string) (*domain.PromoCode, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	p, ok := m.codes[code]
+	if !ok {
+		return nil, domain.ErrPromoNotFound
+	}
+	return &p, nil
+}
+func (m *mockRepo) ListPromoCodes() ([]domain.PromoCode, error) {
+	var out []domain.PromoCode
+	for _, p := range m.codes {
+		out = append(out, p)
+	}
+	return out, m.err
+}
+func (m *mockRepo) ReservePromoCode(code string) error {
+	if m.err != nil {
+		return m.err
+	}
+	p := m.codes[code]
+	if p.MaxUses > 0 && p.Uses >= p.MaxUses {
+		return domain.ErrPromoUsedUp
+	}
+	p.Uses++
+	m.codes[code] = p
+	return nil
+}
+func (m *mockRepo) ReleasePromoCode(code string) error {
+	if m.err != nil {
+		return m.err
+	}
+	p := m.codes[code]
+	if p.Uses > 0 {
+		p.Uses--
+	}
+	m.codes[code] = p
+	return nil
+}
+func (m *mockRepo) RecordPromoUsage(usage domain.PromoUsage) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	for _, u := range m.usages {
+		if u.AppointmentID == usage.AppointmentID {
+			return false, nil
+		}
+	}
+	m.usages = append(m.usages, usage)
+	return true, nil
+}
+func (m *mockRepo) ListPromoUsages(code string) ([]domain.PromoUsage, error) {
+	var out []domain.PromoUsage
+	for _, u := range m.usages {
+		if u.Code == code {
+			out = append(out, u)
+		}
+	}
+	return out, m.err
+}
+
+var now = time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo *mockRepo) *Service {
+	s := NewService(repo)
+	s.NowFunc = func() time.Time { return now }
+	return s
+}
+
+func TestCreatePromo(t *testing.T) {
+	repo := newMockRepo()
+	s := newTestService(repo)
+
+	p, err := s.CreatePromo(context.Background(), domain.PromoCode{
+		Code: " spring10 ", Kind: domain.PromoPercent, Value: 10, ValidUntil: now.AddDate(0, 1, 0), Uses: 5, CreatedBy: "1",
+	})
+	if err != nil {
+		t.Fatalf("CreatePromo failed: %v", err)
+	}
+	if p.Code != "SPRING10" || !p.ValidFrom.Equal(now) || !p.CreatedAt.Equal(now) || p.Uses != 0 {
+		t.Errorf("unexpected promo: %+v", p)
+	}
+	if _, ok := repo.codes["SPRING10"]; !ok {
+		t.Error("expected the promo stored")
+	}
+
+	if _, err := s.CreatePromo(context.Background(), domain.PromoCode{Code: "SPRING10", Kind: domain.PromoPercent, Value: 10, ValidUntil: now.AddDate(0, 1, 0)}); !errors.Is(err, domain.ErrPromoExists) {
+		t.Errorf("CreatePromo of a taken code = %v, want ErrPromoExists", err)
+	}
+	if _, err := s.CreatePromo(context.Background(), domain.PromoCode{Code: "BAD", Kind: domain.PromoPercent, Value: 10}); !errors.Is(err, domain.ErrInvalidPromoCode) {
+		t.Errorf("CreatePromo without an end = %v, want ErrInvalidPromoCode", err)
+	}
+}
+
+func TestApplyPromo(t *testing.T) {
+	repo := newMockRepo()
+	repo.codes["SPRING10"] = domain.PromoCode{Code: "SPRING10", Kind: domain.PromoPercent, Value: 10, ServiceIDs: []string{"massage"}, ValidFrom: now.Add(-time.Hour), ValidUntil: now.Add(time.Hour)}
+	repo.codes["OLD"] = domain.PromoCode{Code: "OLD", Kind: domain.PromoFixed, Value: 500, ValidFrom: now.AddDate(0, -2, 0), ValidUntil: now.AddDate(0, -1, 0)}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	promo, discount, err := s.ApplyPromo(ctx, "spring10", "massage", 2000)
+	if err != nil || promo.Code != "SPRING10" || discount != 200 {
+		t.Fatalf("ApplyPromo = %+v, %v, %v; want SPRING10, 200, nil", promo, discount, err)
+	}
+	if _, _, err := s.ApplyPromo(ctx, "SPRING10", "consult", 2000); !errors.Is(err, domain.ErrPromoNotApplicable) {
+		t.Errorf("ApplyPromo for another service = %v, want ErrPromoNotApplicable", err)
+	}
+	if _, _, err := s.ApplyPromo(ctx, "OLD", "massage", 2000); !errors.Is(err, domain.ErrPromoExpired) {
+		t.Errorf("ApplyPromo of an expired code = %v, want ErrPromoExpired", err)
+	}
+	if _, _, err := s.ApplyPromo(ctx, "NOPE", "massage", 2000); !errors.Is(err, domain.ErrPromoNotFound) {
+		t.Errorf("ApplyPromo of an unknown code = %v, want ErrPromoNotFound", err)
+	}
+	if got := repo.codes["SPRING10"].Uses; got != 0 {
+		t.Errorf("ApplyPromo should not count a use, got %d", got)
+	}
+}
+
+func TestReservePromo(t *testing.T) {
+	repo := newMockRepo()
+	repo.codes["ONCE"] = domain.PromoCode{Code: "ONCE", Kind: domain.PromoFixed, Value: 500, MaxUses: 1, ValidUntil: now.AddDate(0, 0, 1)}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	promo, discount, err := s.ReservePromo(ctx, "once", "classic", 2000)
+	if err != nil || promo.Code != "ONCE" || discount != 500 {
+		t.Fatalf("ReservePromo = %+v, %.0f, %v", promo, discount, err)
+	}
+	if got := repo.codes["ONCE"].Uses; got != 1 {
+		t.Errorf("expected the use counted, got %d", got)
+	}
+	// The only use is taken
+	if _, _, err := s.ReservePromo(ctx, "ONCE", "classic", 2000); !errors.Is(err, domain.ErrPromoUsedUp) {
+		t.Errorf("ReservePromo over the limit = %v, want ErrPromoUsedUp", err)
+	}
+
+	if err := s.ReleasePromo(ctx, "ONCE"); err != nil {
+		t.Fatalf("ReleasePromo failed: %v", err)
+	}
+	if got := repo.codes["ONCE"].Uses; got != 0 {
+		t.Errorf("expected the use given back, got %d", got)
+	}
+	if _, _, err := s.ReservePromo(ctx, "ONCE", "classic", 2000); err != nil {
+		t.Errorf("ReservePromo after release = %v, want nil", err)
+	}
+}
+
+func TestRedeemPromo(t *testing.T) {
+	repo := newMockRepo()
+	repo.codes["ONCE"] = domain.PromoCode{Code: "ONCE", Kind: domain.PromoFixed, Value: 500, MaxUses: 1, Uses: 1}
+	s := newTestService(repo)
+	ctx := context.Background()
+
+	appt := domain.Appointment{ID: "a1", CustomerTgID: "100", CustomerName: "Иван", Service: domain.Service{Name: "Массаж"}, PromoCode: "ONCE", Discount: 500}
+	if err := s.RedeemPromo(ctx, appt); err != nil {
+		t.Fatalf("RedeemPromo failed: %v", err)
+	}
+	if len(repo.usages) != 1 || repo.usages[0].PatientID != "100" || repo.usages[0].Discount != 500 || !repo.usages[0].UsedAt.Equal(now) {
+		t.Errorf("unexpected usages: %+v", repo.usages)
+	}
+	if got := repo.codes["ONCE"].Uses; got != 1 {
+		t.Errorf("expected the reserved use kept, got %d", got)
+	}
+
+	// Redeeming the same booking again gives back the extra use
+	if err := s.RedeemPromo(ctx, appt); err != nil {
+		t.Errorf("RedeemPromo again = %v, want nil", err)
+	}
+	if got := repo.codes["ONCE"].Uses; got != 0 {
+		t.Errorf("expected the duplicate use released, got %d", got)
+	}
+	if err := s.RedeemPromo(ctx, domain.Appointment{ID: "a3"}); err != nil {
+		t.Errorf("RedeemPromo without a code = %v, want nil", err)
+	}
+	if len(repo.usages) != 1 {
+		t.Errorf("expected one usage, got %d", len(repo.usages))
+	}
+}
+
+func TestListPromoUsages(t *testing.T) {
+	repo := newMockRepo()
+	repo.usages = []domain.PromoUsage{{Code: "SPRING10", AppointmentID: "a1"}, {Code: "OTHER", AppointmentID: "a2"}}
+	s := newTestService(repo)
+
+	usages, err := s.ListPromoUsages(context.Background(), "spring10")
+	if err != nil || len(usages) != 1 || usages[0].AppointmentID != "a1" {
+		t.Errorf("ListPromoUsages = %+v, %v", usages, err)
+	}
+}
@@ -0,0 +1,188 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// BotSender is a minimal interface for sending Telegram messages.
+// *telebot.Bot satisfies this interface automatically.
+type BotSender interface {
+	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+}
+
+// Service follows up on bookings edited directly in the calendar, as
+// reported by the calendar sync: the patient is told the visit was moved or
+// cancelled, the edit is logged to the analytics events, and admins are
+// alerted when it leaves something the bot cannot settle, such as a double
+// booking or a patient it cannot reach.
+type Service struct {
+	repo      ports.Repository
+	appts     ports.AppointmentService
+	bot       BotSender
+	adminIDs  []string
+	presenter *presentation.BotPresenter
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+}
+
+func NewService(repo ports.Repository, as ports.AppointmentService, bot BotSender, adminIDs []string, p *presentation.BotPresenter) *Service {
+	return &Service{
+		repo:      repo,
+		appts:     as,
+		bot:       bot,
+		adminIDs:  adminIDs,
+		presenter: p,
+		NowFunc:   time.Now,
+	}
+}
+
+// HandleChange settles one calendar edit; see calendarsync.Service.SetChangeHook.
+func (s *Service) HandleChange(ctx context.Context, change domain.CalendarChange) {
+	change = localize(change)
+	prev := change.Previous
+
+	var problems []string
+	switch change.Kind {
+	case domain.ChangeMoved:
+		problems = s.checkMove(ctx, change)
+		// Reminders and the confirmation request go out again for the new time
+		if err := s.repo.SaveAppointmentMetadata(prev.ID, nil, map[string]bool{}); err != nil {
+			logging.Warnf("WARNING: Failed to reset reminder state for edited appointment %s: %v", prev.ID, err)
+		}
+	case domain.ChangeCancelled:
+		err := s.appts.SetAppointmentStatus(ctx, &prev, domain.StatusCancelledByAdmin)
+		if err != nil && !errors.Is(err, domain.ErrStatusUnavailable) {
+			logging.Warnf("WARNING: Failed to record cancellation of appointment %s: %v", prev.ID, err)
+		}
+	}
+
+	notified := false
+	if id, err := strconv.ParseInt(prev.CustomerTgID, 10, 64); err != nil {
+		problems = append(problems, "У пациента нет Telegram ID, сообщите ему сами")
+	} else if _, err := s.bot.Send(&telebot.User{ID: id}, s.presenter.FormatCalendarChange(change), telebot.ModeHTML); err != nil {
+		logging.Warnf("Failed to tell %s about edited appointment %s: %v", prev.CustomerTgID, prev.ID, err)
+		problems = append(problems, "Пациенту не удалось отправить сообщение")
+	} else {
+		notified = true
+		if change.Kind == domain.ChangeCancelled {
+			s.sendCancelFile(id, &prev)
+		}
+	}
+
+	details := map[string]interface{}{
+		"appointment_id": prev.ID,
+		"change":         string(change.Kind),
+		"old_start":      prev.StartTime.Format(time.RFC3339),
+		"old_end":        prev.EndTime.Format(time.RFC3339),
+		"notified":       notified,
+		"conflicts":      len(problems),
+	}
+	if change.Kind == domain.ChangeMoved {
+		details["new_start"] = change.Current.StartTime.Format(time.RFC3339)
+		details["new_end"] = change.Current.EndTime.Format(time.RFC3339)
+	}
+	if err := s.repo.LogEvent(prev.CustomerTgID, "calendar_edited", details); err != nil {
+		logging.Warnf("Failed to log calendar edit of appointment %s: %v", prev.ID, err)
+	}
+	logging.Infof("Appointment %s %s in the calendar (patient notified: %v, conflicts: %d)", prev.ID, change.Kind, notified, len(problems))
+
+	if len(problems) > 0 {
+		s.alertAdmins(change, problems)
+	}
+}
+
+// checkMove lists what is wrong with the new time of a moved booking: it is
+// over, falls outside working hours or overlaps another booking.
+func (s *Service) checkMove(ctx context.Context, change domain.CalendarChange) []string {
+	appt := change.Current
+	therapistID := change.Previous.TherapistID
+	var problems []string
+
+	if appt.StartTime.Before(s.NowFunc()) {
+		problems = append(problems, "Новое время уже прошло")
+	}
+
+	hours, err := s.appts.GetWorkingHours(ctx, therapistID, appt.StartTime)
+	if err != nil {
+		logging.Warnf("WARNING: Failed to check working hours for edited appointment %s: %v", appt.ID, err)
+	} else if !withinHours(hours, appt.StartTime, appt.EndTime) {
+		problems = append(problems, "Новое время вне рабочего графика")
+	}
+
+	others, err := s.appts.GetUpcomingAppointments(ctx, appt.StartTime, appt.EndTime)
+	if err != nil {
+		logging.Warnf("WARNING: Failed to check overlaps for edited appointment %s: %v", appt.ID, err)
+		return problems
+	}
+	for _, other := range others {
+		if other.ID == appt.ID || other.Status == "cancelled" || other.CustomerTgID == "" {
+			continue
+		}
+		if therapistID != "" && other.TherapistID != "" && other.TherapistID != therapistID {
+			continue
+		}
+		if other.StartTime.Before(appt.EndTime) && appt.StartTime.Before(other.EndTime) {
+			problems = append(problems, fmt.Sprintf("Пересекается с записью %s (%s–%s)", other.CustomerName,
+				other.StartTime.In(domain.ApptTimeZone).Format("15:04"), other.EndTime.In(domain.ApptTimeZone).Format("15:04")))
+		}
+	}
+	return problems
+}
+
+// sendCancelFile sends the METHOD:CANCEL .ics of a cancelled visit, which
+// removes it from calendars the patient imported it into.
+func (s *Service) sendCancelFile(patientID int64, appt *domain.Appointment) {
+	data := presentation.FormatICS(presentation.ICSCalendar{Method: presentation.ICSCancel}, []domain.Appointment{*appt}, s.NowFunc())
+	doc := &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(data)),
+		FileName: presentation.ICSFileName(appt),
+		MIME:     "text/calendar",
+	}
+	if _, err := s.bot.Send(&telebot.User{ID: patientID}, doc); err != nil {
+		logging.Warnf("Failed to send cancellation file for appointment %s: %v", appt.ID, err)
+	}
+}
+
+func (s *Service) alertAdmins(change domain.CalendarChange, problems []string) {
+	msg := s.presenter.FormatCalendarConflict(change, problems)
+	for _, admin := range s.adminIDs {
+		id, err := strconv.ParseInt(admin, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := s.bot.Send(&telebot.User{ID: id}, msg, telebot.ModeHTML); err != nil {
+			logging.Warnf("Failed to send calendar conflict alert to admin %s: %v", admin, err)
+		}
+	}
+}
+
+// withinHours reports whether [start, end) fits in one open interval.
+func withinHours(hours []domain.TimeSlot, start, end time.Time) bool {
+	for _, h := range hours {
+		if !start.Before(h.Start) && !end.After(h.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// localize puts the times of a change in the clinic's time zone for messages.
+func localize(change domain.CalendarChange) domain.CalendarChange {
+	for _, appt := range []*domain.Appointment{&change.Previous, &change.Current} {
+		appt.StartTime = appt.StartTime.In(domain.ApptTimeZone)
+		appt.EndTime = appt.EndTime.In(domain.ApptTimeZone)
+	}
+	return change
+}
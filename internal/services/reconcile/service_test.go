@@ -0,0 +1,180 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+var testNow = time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+
+// --- Mocks ---
+
+// mockBotSender captures Send calls without a real Telegram connection.
+type mockBotSender struct {
+	sent  map[int64][]string
+	files map[int64][]*telebot.Document
+	fail  bool
+}
+
+func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
+	if m.fail {
+		return nil, errors.New("bot was blocked by the user")
+	}
+	id := to.(*telebot.User).ID
+	if doc, ok := what.(*telebot.Document); ok {
+		m.files[id] = append(m.files[id], doc)
+		return &telebot.Message{}, nil
+	}
+	m.sent[id] = append(m.sent[id], what.(string))
+	return &telebot.Message{}, nil
+}
+
+// mockRepo records analytics events and reminder resets.
+type mockRepo struct {
+	ports.Repository
+	events []map[string]interface{}
+	resets []string
+}
+
+func (m *mockRepo) LogEvent(patientID string, eventType string, details map[string]interface{}) error {
+	details["type"] = eventType
+	m.events = append(m.events, details)
+	return nil
+}
+
+func (m *mockRepo) SaveAppointmentMetadata(id string, confirmedAt *time.Time, reminders map[string]bool) error {
+	m.resets = append(m.resets, id)
+	return nil
+}
+
+// mockApptService serves working hours 9-18 and a fixed set of bookings.
+type mockApptService struct {
+	ports.AppointmentService
+	upcoming []domain.Appointment
+	statuses map[string]domain.AppointmentStatus
+}
+
+func (m *mockApptService) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return []domain.TimeSlot{{Start: day.Add(9 * time.Hour), End: day.Add(18 * time.Hour)}}, nil
+}
+
+func (m *mockApptService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
+	return m.upcoming, nil
+}
+
+func (m *mockApptService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	m.statuses[appt.ID] = status
+	return nil
+}
+
+func newTestService(t *testing.T) (*Service, *mockRepo, *mockApptService, *mockBotSender) {
+	t.Helper()
+	domain.ApptTimeZone = time.UTC
+	repo := &mockRepo{}
+	appts := &mockApptService{statuses: map[string]domain.AppointmentStatus{}}
+	bot := &mockBotSender{sent: map[int64][]string{}, files: map[int64][]*telebot.Document{}}
+	svc := NewService(repo, appts, bot, []string{"999"}, presentation.NewBotPresenter())
+	svc.NowFunc = func() time.Time { return testNow }
+	return svc, repo, appts, bot
+}
+
+func booking(id, patient string, hour int) domain.Appointment {
+	start := testNow.AddDate(0, 0, 1).Truncate(24 * time.Hour).Add(time.Duration(hour) * time.Hour)
+	return domain.Appointment{
+		ID: id, CustomerTgID: patient, CustomerName: "Anna",
+		Service:   domain.Service{Name: "Massage"},
+		StartTime: start, EndTime: start.Add(time.Hour),
+	}
+}
+
+// --- Tests ---
+
+func TestHandleChange_Moved(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	prev := booking("a1", "42", 10)
+	current := booking("a1", "42", 14)
+
+	svc.HandleChange(context.Background(), domain.CalendarChange{Kind: domain.ChangeMoved, Previous: prev, Current: current})
+
+	if len(bot.sent[42]) != 1 || !strings.Contains(bot.sent[42][0], "ВАША ЗАПИСЬ ИЗМЕНЕНА") {
+		t.Errorf("expected the patient told about the move, got %v", bot.sent[42])
+	}
+	if len(bot.sent[999]) != 0 {
+		t.Errorf("expected no admin alert for a clean move, got %v", bot.sent[999])
+	}
+	if len(bot.files[42]) != 0 {
+		t.Errorf("expected no calendar file for a move, got %v", bot.files[42])
+	}
+	if len(repo.resets) != 1 || repo.resets[0] != "a1" {
+		t.Errorf("expected reminders reset for the new time, got %v", repo.resets)
+	}
+	if len(repo.events) != 1 || repo.events[0]["type"] != "calendar_edited" || repo.events[0]["change"] != "moved" || repo.events[0]["notified"] != true {
+		t.Errorf("unexpected analytics events %v", repo.events)
+	}
+}
+
+func TestHandleChange_Cancelled(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	prev := booking("a1", "42", 10)
+
+	svc.HandleChange(context.Background(), domain.CalendarChange{Kind: domain.ChangeCancelled, Previous: prev})
+
+	if len(bot.sent[42]) != 1 || !strings.Contains(bot.sent[42][0], "ВАША ЗАПИСЬ ОТМЕНЕНА") {
+		t.Errorf("expected the patient told about the cancellation, got %v", bot.sent[42])
+	}
+	if appts.statuses["a1"] != domain.StatusCancelledByAdmin {
+		t.Errorf("expected the cancellation recorded, got %q", appts.statuses["a1"])
+	}
+	if len(bot.files[42]) != 1 || !strings.HasSuffix(bot.files[42][0].FileName, ".ics") {
+		t.Errorf("expected the cancellation .ics sent, got %v", bot.files[42])
+	}
+	if len(repo.resets) != 0 || repo.events[0]["change"] != "cancelled" {
+		t.Errorf("unexpected side effects: resets %v, events %v", repo.resets, repo.events)
+	}
+}
+
+func TestHandleChange_Conflicts(t *testing.T) {
+	tests := []struct {
+		name    string
+		current domain.Appointment
+		other   []domain.Appointment
+		patient string
+		fail    bool
+		want    string
+	}{
+		{"double booking", booking("a1", "42", 14), []domain.Appointment{booking("b1", "7", 14)}, "42", false, "Пересекается с записью"},
+		{"outside hours", booking("a1", "42", 19), nil, "42", false, "вне рабочего графика"},
+		{"in the past", booking("a1", "42", -20), nil, "42", false, "уже прошло"},
+		{"no Telegram ID", booking("a1", "manual_anna", 14), nil, "manual_anna", false, "нет Telegram ID"},
+		{"send failed", booking("a1", "42", 14), nil, "42", true, "не удалось отправить"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo, appts, bot := newTestService(t)
+			appts.upcoming = append(tt.other, tt.current)
+			bot.fail = tt.fail
+			prev := booking("a1", tt.patient, 10)
+
+			svc.HandleChange(context.Background(), domain.CalendarChange{Kind: domain.ChangeMoved, Previous: prev, Current: tt.current})
+
+			if tt.fail {
+				if repo.events[0]["conflicts"] != 1 {
+					t.Errorf("expected the failed message counted as a conflict, got %v", repo.events[0])
+				}
+				return
+			}
+			if len(bot.sent[999]) != 1 || !strings.Contains(bot.sent[999][0], tt.want) {
+				t.Errorf("expected an admin alert containing %q, got %v", tt.want, bot.sent[999])
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package reminder
 
 import (
+	"bytes"
 	"context"
 	"strconv"
 	"time"
@@ -64,6 +65,7 @@ func (s *Service) RunLoopForTest(ctx context.Context, ticks <-chan time.Time, st
 			select {
 			case <-ticks:
 				s.ScanAndSendReminders(ctx)
+				s.ScanAndAskOutcomes(ctx)
 			case <-ctx.Done():
 				return
 			}
@@ -143,7 +145,14 @@ func (s *Service) sendReminder(appt *domain.Appointment, reminderType string) {
 	btnCancel := menu.Data("❌ Отменить", "cancel_appt_reminder", appt.ID)
 	menu.Inline(menu.Row(btnConfirm, btnCancel))
 
-	_, err = s.bot.Send(user, msg, telebot.ModeHTML, menu)
+	// The reminder goes out as the caption of the visit's .ics file, so the
+	// patient can add it to their calendar in one tap. Should the upload
+	// fail, the reminder is still sent as text.
+	_, err = s.bot.Send(user, icsReminder(appt, msg), telebot.ModeHTML, menu)
+	if err != nil {
+		logging.Warnf("Failed to send %s reminder with calendar file to %s, sending text: %v", reminderType, appt.CustomerTgID, err)
+		_, err = s.bot.Send(user, msg, telebot.ModeHTML, menu)
+	}
 	if err != nil {
 		logging.Errorf(": Failed to send %s reminder to patient %s: %v", reminderType, appt.CustomerTgID, err)
 		return
@@ -158,3 +167,87 @@ func (s *Service) sendReminder(appt *domain.Appointment, reminderType string) {
 		logging.Errorf("Failed to save appointment metadata: %v", err)
 	}
 }
+
+// icsReminder wraps a reminder message as the caption of appt's .ics file.
+func icsReminder(appt *domain.Appointment, msg string) *telebot.Document {
+	data := presentation.FormatICS(presentation.ICSCalendar{}, []domain.Appointment{*appt}, time.Now())
+	return &telebot.Document{
+		File:     telebot.FromReader(bytes.NewReader(data)),
+		FileName: presentation.ICSFileName(appt),
+		MIME:     "text/calendar",
+		Caption:  msg,
+	}
+}
+
+// outcomeWindow is how long after a visit ends admins are still asked to
+// record its outcome.
+const outcomeWindow = 24 * time.Hour
+
+// ScanAndAskOutcomes asks admins how each recently finished visit went
+// (completed, no-show, late cancel), once per appointment. Visits that
+// already have a final status are skipped.
+func (s *Service) ScanAndAskOutcomes(ctx context.Context) {
+	now := time.Now().In(domain.ApptTimeZone)
+	// Start the scan earlier so long visits that ended in the window are found
+	appts, err := s.apptService.GetUpcomingAppointments(ctx, now.Add(-outcomeWindow-12*time.Hour), now)
+	if err != nil {
+		logging.Errorf(": Failed to fetch finished appointments for outcome prompts: %v", err)
+		return
+	}
+
+	for _, appt := range appts {
+		if appt.CustomerTgID == "" || appt.Status == "cancelled" {
+			continue
+		}
+		if appt.EndTime.After(now) || now.Sub(appt.EndTime) > outcomeWindow {
+			continue
+		}
+		status, err := s.apptService.GetAppointmentStatus(ctx, appt.ID)
+		if err != nil || (status != domain.StatusBooked && status != domain.StatusConfirmed) {
+			continue
+		}
+		s.askOutcome(&appt)
+	}
+}
+
+func (s *Service) askOutcome(appt *domain.Appointment) {
+	confirmedAt, sentMap, err := s.repo.GetAppointmentMetadata(appt.ID)
+	if err == nil && sentMap["outcome"] {
+		return
+	}
+
+	appt.StartTime = appt.StartTime.In(domain.ApptTimeZone)
+	msg := s.presenter.FormatOutcomeRequest(appt)
+	menu := &telebot.ReplyMarkup{}
+	menu.Inline(
+		menu.Row(
+			menu.Data("✅ Состоялся", "appt_outcome", appt.ID, string(domain.StatusCompleted)),
+			menu.Data("🚫 Неявка", "appt_outcome", appt.ID, string(domain.StatusNoShow)),
+		),
+		menu.Row(menu.Data("⏰ Поздняя отмена", "appt_outcome", appt.ID, string(domain.StatusLateCancel))),
+	)
+
+	sent := false
+	for _, adminIDStr := range s.adminIDs {
+		adminID, err := strconv.ParseInt(adminIDStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := s.bot.Send(&telebot.User{ID: adminID}, msg, telebot.ModeHTML, menu); err != nil {
+			logging.Errorf(": Failed to send outcome prompt for %s to admin %s: %v", appt.ID, adminIDStr, err)
+			continue
+		}
+		sent = true
+	}
+	if !sent {
+		return
+	}
+
+	if sentMap == nil {
+		sentMap = make(map[string]bool)
+	}
+	sentMap["outcome"] = true
+	if err := s.repo.SaveAppointmentMetadata(appt.ID, confirmedAt, sentMap); err != nil {
+		logging.Errorf("Failed to save appointment metadata: %v", err)
+	}
+}
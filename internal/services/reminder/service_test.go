@@ -3,6 +3,7 @@ package reminder
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ type mockBotSender struct {
 	sentTo   []telebot.Recipient
 	sentWhat []interface{}
 	err      error // if non-nil, Send returns this error
+	noFiles  bool  // if set, sending documents fails
 }
 
 func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
@@ -26,6 +28,9 @@ func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...int
 	if m.err != nil {
 		return nil, m.err
 	}
+	if _, isFile := what.(*telebot.Document); isFile && m.noFiles {
+		return nil, errors.New("upload failed")
+	}
 	return &telebot.Message{}, nil
 }
 
@@ -33,6 +38,7 @@ func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...int
 type mockApptService struct {
 	upcomingAppts []domain.Appointment
 	err           error
+	statuses      map[string]domain.AppointmentStatus
 }
 
 func (m *mockApptService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
@@ -48,12 +54,54 @@ func (m *mockApptService) CreateAppointment(ctx context.Context, a *domain.Appoi
 	return nil, nil
 }
 func (m *mockApptService) CancelAppointment(ctx context.Context, id string) error { return nil }
+func (m *mockApptService) RescheduleAppointment(ctx context.Context, id string, t time.Time) (*domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+	return &series, nil
+}
+func (m *mockApptService) GetAppointmentSeries(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+	return nil, domain.ErrSeriesNotFound
+}
+func (m *mockApptService) CancelSeries(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetAppointmentStatus(ctx context.Context, id string) (domain.AppointmentStatus, error) {
+	if status, ok := m.statuses[id]; ok {
+		return status, nil
+	}
+	return domain.StatusBooked, nil
+}
+func (m *mockApptService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	return nil
+}
+func (m *mockApptService) GetPatientStatusCounts(ctx context.Context, id string) (map[domain.AppointmentStatus]int, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCancellationPolicy() domain.CancellationPolicy {
+	return domain.DefaultCancellationPolicy()
+}
+func (m *mockApptService) HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+	return nil
+}
+func (m *mockApptService) ReleaseSlotHold(ctx context.Context, holderID string) error {
+	return nil
+}
+func (m *mockApptService) FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+	return nil, nil
+}
 func (m *mockApptService) GetCustomerAppointments(ctx context.Context, id string) ([]domain.Appointment, error) {
 	return nil, nil
 }
 func (m *mockApptService) GetCustomerHistory(ctx context.Context, id string) ([]domain.Appointment, error) {
 	return nil, nil
 }
+func (m *mockApptService) HistorySynced() bool {
+	return false
+}
 func (m *mockApptService) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
 	return nil, nil
 }
@@ -66,6 +114,46 @@ func (m *mockApptService) GetCalendarAccountInfo(ctx context.Context) (string, e
 }
 func (m *mockApptService) GetCalendarID() string                              { return "" }
 func (m *mockApptService) ListCalendars(ctx context.Context) ([]string, error) { return nil, nil }
+func (m *mockApptService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	return &svc, nil
+}
+func (m *mockApptService) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	return nil
+}
+func (m *mockApptService) ReorderServices(ctx context.Context, ids []string) error { return nil }
+func (m *mockApptService) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	return nil
+}
+func (m *mockApptService) GetSchedule(ctx context.Context) (domain.WeeklySchedule, error) {
+	return domain.WeeklySchedule{}, nil
+}
+func (m *mockApptService) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+	return nil
+}
+func (m *mockApptService) SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error {
+	return nil
+}
+func (m *mockApptService) DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error {
+	return nil
+}
+func (m *mockApptService) GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveTherapist(ctx context.Context, t domain.Therapist) error {
+	return nil
+}
+func (m *mockApptService) GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, durationMinutes int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
 
 // mockReminderRepo covers the Repository methods used by reminder.Service.
 type mockReminderRepo struct {
@@ -197,6 +285,51 @@ func TestScanAndSendReminders_Sends72hReminder(t *testing.T) {
 	}
 }
 
+func TestScanAndSendReminders_AttachesCalendarFile(t *testing.T) {
+	now := time.Now().In(domain.ApptTimeZone)
+	appt := domain.Appointment{
+		ID:           "appt-ics",
+		CustomerTgID: "123456",
+		StartTime:    now.Add(23*time.Hour + 30*time.Minute),
+		Status:       "confirmed",
+		Service:      domain.Service{Name: "Массаж", DurationMinutes: 60},
+	}
+
+	t.Run("Caption on the .ics file", func(t *testing.T) {
+		bot := &mockBotSender{}
+		svc := NewService(&mockApptService{upcomingAppts: []domain.Appointment{appt}}, newMockReminderRepo(), bot, nil, presentation.NewBotPresenter())
+		svc.ScanAndSendReminders(context.Background())
+
+		if len(bot.sentWhat) != 1 {
+			t.Fatalf("expected one message, got %d", len(bot.sentWhat))
+		}
+		doc, ok := bot.sentWhat[0].(*telebot.Document)
+		if !ok {
+			t.Fatalf("expected a document, got %T", bot.sentWhat[0])
+		}
+		if !strings.Contains(doc.Caption, "Напоминание") || !strings.HasSuffix(doc.FileName, ".ics") || doc.MIME != "text/calendar" {
+			t.Errorf("unexpected document %q %q: %q", doc.FileName, doc.MIME, doc.Caption)
+		}
+	})
+
+	t.Run("Falls back to text", func(t *testing.T) {
+		bot := &mockBotSender{noFiles: true}
+		repo := newMockReminderRepo()
+		svc := NewService(&mockApptService{upcomingAppts: []domain.Appointment{appt}}, repo, bot, nil, presentation.NewBotPresenter())
+		svc.ScanAndSendReminders(context.Background())
+
+		if len(bot.sentWhat) != 2 {
+			t.Fatalf("expected the text retry, got %d sends", len(bot.sentWhat))
+		}
+		if msg, _ := bot.sentWhat[1].(string); !strings.Contains(msg, "Напоминание") {
+			t.Errorf("expected the reminder as text, got %v", bot.sentWhat[1])
+		}
+		if !repo.savedMetadata["24h"] {
+			t.Error("expected the reminder marked as sent")
+		}
+	})
+}
+
 func TestScanAndSendReminders_Sends24hReminder(t *testing.T) {
 	now := time.Now().In(domain.ApptTimeZone)
 	// Appointment is exactly 23.5h away — inside the [23h, 24h] window
@@ -301,6 +434,52 @@ func TestSendReminder_BotSendError_DoesNotPanic(t *testing.T) {
 
 // --- runLoop tests (exercises Start() loop logic without real time.Ticker) ---
 
+func TestScanAndAskOutcomes_PromptsAdminsOnce(t *testing.T) {
+	now := time.Now().In(domain.ApptTimeZone)
+	finished := domain.Appointment{
+		ID: "done", CustomerTgID: "100", CustomerName: "Иван",
+		StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(-time.Hour),
+	}
+	ongoing := domain.Appointment{
+		ID: "ongoing", CustomerTgID: "101",
+		StartTime: now.Add(-30 * time.Minute), EndTime: now.Add(30 * time.Minute),
+	}
+	stale := domain.Appointment{
+		ID: "stale", CustomerTgID: "102",
+		StartTime: now.Add(-30 * time.Hour), EndTime: now.Add(-29 * time.Hour),
+	}
+	marked := domain.Appointment{
+		ID: "marked", CustomerTgID: "103",
+		StartTime: now.Add(-3 * time.Hour), EndTime: now.Add(-2 * time.Hour),
+	}
+	appts := &mockApptService{
+		upcomingAppts: []domain.Appointment{finished, ongoing, stale, marked},
+		statuses:      map[string]domain.AppointmentStatus{"marked": domain.StatusNoShow},
+	}
+	bot := &mockBotSender{}
+	repo := newMockReminderRepo()
+	svc := NewService(appts, repo, bot, []string{"1", "2"}, presentation.NewBotPresenter())
+
+	svc.ScanAndAskOutcomes(context.Background())
+
+	if len(bot.sentTo) != 2 {
+		t.Fatalf("expected prompt to both admins for one visit, got %d messages", len(bot.sentTo))
+	}
+	if msg, _ := bot.sentWhat[0].(string); !strings.Contains(msg, "Иван") {
+		t.Errorf("unexpected prompt: %q", msg)
+	}
+	if !repo.savedMetadata["outcome"] {
+		t.Error("expected outcome prompt to be recorded")
+	}
+
+	// A prompt already sent is not repeated
+	repo.metadataReminderMap["outcome"] = true
+	svc.ScanAndAskOutcomes(context.Background())
+	if len(bot.sentTo) != 2 {
+		t.Errorf("expected no repeated prompts, got %d messages", len(bot.sentTo))
+	}
+}
+
 func TestRunLoop_FiresOnTickAndStopsOnContextDone(t *testing.T) {
 	bot := &mockBotSender{}
 	repo := newMockReminderRepo()
@@ -0,0 +1,382 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// DefaultOfferTTL is how long a patient has to claim a freed slot when no
+// other value is configured.
+const DefaultOfferTTL = 30 * time.Minute
+
+// BotSender is a minimal interface for sending Telegram messages.
+// *telebot.Bot satisfies this interface automatically.
+type BotSender interface {
+	Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error)
+}
+
+// Service keeps the waitlist: patients wait for a date range, and a slot
+// freed by a cancellation is offered to them one at a time, oldest request
+// first. An offer that is declined or not answered in time passes on.
+type Service struct {
+	repo      ports.WaitlistRepository
+	appts     ports.AppointmentService
+	bot       BotSender
+	presenter *presentation.BotPresenter
+	offerTTL  time.Duration
+
+	// NowFunc allows injecting a function to get the current time for testing
+	NowFunc func() time.Time
+
+	// mu serialises offer hand-over so a slot is never offered twice at once
+	mu sync.Mutex
+}
+
+var _ ports.WaitlistService = (*Service)(nil)
+
+func NewService(repo ports.WaitlistRepository, as ports.AppointmentService, bot BotSender, p *presentation.BotPresenter, offerTTL time.Duration) *Service {
+	if offerTTL <= 0 {
+		offerTTL = DefaultOfferTTL
+	}
+	return &Service{
+		repo:      repo,
+		appts:     as,
+		bot:       bot,
+		presenter: p,
+		offerTTL:  offerTTL,
+		NowFunc:   time.Now,
+	}
+}
+
+// Start checks for lapsed offers every minute until ctx is done.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	ticker := time.NewTicker(time.Minute)
+	logging.Infof("Waitlist Service started (offers valid for %s).", s.offerTTL)
+
+	return s.RunLoopForTest(ctx, ticker.C, ticker.Stop)
+}
+
+// RunLoopForTest is the inner goroutine extracted from Start so it can be
+// driven by a manual channel in tests; see reminder.Service.RunLoopForTest.
+func (s *Service) RunLoopForTest(ctx context.Context, ticks <-chan time.Time, stop func()) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer stop()
+		for {
+			select {
+			case <-ticks:
+				s.ExpireOffers(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// Join adds a patient to the waitlist. Asking again for the same service
+// and overlapping dates returns the existing entry.
+func (s *Service) Join(ctx context.Context, entry domain.WaitlistEntry) (*domain.WaitlistEntry, error) {
+	loc := domain.ApptTimeZone
+	entry.DateFrom = time.Date(entry.DateFrom.Year(), entry.DateFrom.Month(), entry.DateFrom.Day(), 0, 0, 0, 0, loc)
+	entry.DateTo = time.Date(entry.DateTo.Year(), entry.DateTo.Month(), entry.DateTo.Day(), 0, 0, 0, 0, loc)
+	now := s.NowFunc().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if entry.PatientID == "" || entry.ServiceID == "" || entry.DurationMinutes <= 0 ||
+		entry.DateTo.Before(entry.DateFrom) || entry.DateTo.Before(today) ||
+		entry.DateTo.Sub(entry.DateFrom) >= domain.MaxWaitlistDays*24*time.Hour {
+		return nil, domain.ErrInvalidWaitlistEntry
+	}
+
+	existing, err := s.repo.ListPatientWaitlist(entry.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		e := existing[i]
+		if e.ServiceID == entry.ServiceID && e.TherapistID == entry.TherapistID &&
+			!e.DateTo.Before(entry.DateFrom) && !entry.DateTo.Before(e.DateFrom) {
+			return &e, nil
+		}
+	}
+
+	entry.Status = domain.WaitlistWaiting
+	if err := s.repo.AddWaitlistEntry(&entry); err != nil {
+		return nil, err
+	}
+	logging.Infof("Waitlist: patient %s waits for %s %s–%s", entry.PatientID, entry.ServiceName,
+		entry.DateFrom.Format("02.01"), entry.DateTo.Format("02.01"))
+	return &entry, nil
+}
+
+// ListForPatient returns the patient's open waitlist entries.
+func (s *Service) ListForPatient(ctx context.Context, patientID string) ([]domain.WaitlistEntry, error) {
+	return s.repo.ListPatientWaitlist(patientID)
+}
+
+// Leave removes one of the patient's entries from the waitlist.
+func (s *Service) Leave(ctx context.Context, patientID string, entryID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.repo.GetWaitlistEntry(entryID)
+	if err != nil {
+		return err
+	}
+	if entry.PatientID != patientID {
+		return domain.ErrWaitlistEntryNotFound
+	}
+	return s.repo.SetWaitlistEntryStatus(entryID, domain.WaitlistCancelled)
+}
+
+// SlotFreed offers a cancelled or moved-away appointment's time to the
+// waitlist. It is meant for appointment.Service.SetSlotFreedHook.
+func (s *Service) SlotFreed(ctx context.Context, appt domain.Appointment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offerSlot(ctx, appt.StartTime, appt.TherapistID)
+}
+
+// AcceptOffer books the offered slot. If it was taken in the meantime the
+// offer is closed, the patient stays on the waitlist and ErrOfferExpired is
+// returned.
+func (s *Service) AcceptOffer(ctx context.Context, patientID string, offerID int64) (*domain.Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offer, entry, err := s.pendingOffer(patientID, offerID)
+	if err != nil {
+		return nil, err
+	}
+
+	service := domain.Service{ID: entry.ServiceID, Name: entry.ServiceName, DurationMinutes: entry.DurationMinutes}
+	if services, err := s.appts.GetAvailableServices(ctx); err == nil {
+		for _, svc := range services {
+			if svc.ID == entry.ServiceID {
+				service = svc
+				service.DurationMinutes = entry.DurationMinutes
+				break
+			}
+		}
+	}
+
+	appt := &domain.Appointment{
+		Service:      service,
+		StartTime:    offer.SlotStart.In(domain.ApptTimeZone),
+		Duration:     entry.DurationMinutes,
+		CustomerName: entry.PatientName,
+		CustomerTgID: entry.PatientID,
+		TherapistID:  offer.TherapistID,
+		Source:       domain.SourceWaitlist,
+	}
+	created, err := s.appts.CreateAppointment(ctx, appt)
+	if err != nil {
+		if errors.Is(err, domain.ErrSlotUnavailable) || errors.Is(err, domain.ErrOutsideWorkingHours) || errors.Is(err, domain.ErrAppointmentInPast) {
+			logging.Infof("Waitlist: offer %d could not be booked: %v", offer.ID, err)
+			s.closeOffer(offer, domain.OfferExpired)
+			return nil, domain.ErrOfferExpired
+		}
+		return nil, err
+	}
+
+	if err := s.repo.SetWaitlistOfferStatus(offer.ID, domain.OfferAccepted); err != nil {
+		logging.Warnf("Waitlist: failed to mark offer %d accepted: %v", offer.ID, err)
+	}
+	if err := s.repo.SetWaitlistEntryStatus(entry.ID, domain.WaitlistBooked); err != nil {
+		logging.Warnf("Waitlist: failed to mark entry %d booked: %v", entry.ID, err)
+	}
+	logging.Infof("Waitlist: offer %d accepted, appointment %s", offer.ID, created.ID)
+	return created, nil
+}
+
+// DeclineOffer passes the slot on to the next patient in line. The patient
+// keeps waiting for other slots.
+func (s *Service) DeclineOffer(ctx context.Context, patientID string, offerID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offer, _, err := s.pendingOffer(patientID, offerID)
+	if err != nil {
+		return err
+	}
+	s.closeOffer(offer, domain.OfferDeclined)
+	s.offerSlot(ctx, offer.SlotStart, offer.TherapistID)
+	return nil
+}
+
+// ExpireOffers closes offers nobody answered in time and passes each slot
+// on to the next patient in line.
+func (s *Service) ExpireOffers(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	offers, err := s.repo.ListExpiredWaitlistOffers(s.NowFunc())
+	if err != nil {
+		logging.Errorf("Waitlist: failed to list expired offers: %v", err)
+		return
+	}
+	for i := range offers {
+		offer := &offers[i]
+		s.closeOffer(offer, domain.OfferExpired)
+		s.send(offer.PatientID, "⌛ Время на ответ истекло, слот предложен следующему. Вы остаётесь в листе ожидания: /waitlist")
+		s.offerSlot(ctx, offer.SlotStart, offer.TherapistID)
+	}
+}
+
+// pendingOffer loads an offer the patient may still answer.
+func (s *Service) pendingOffer(patientID string, offerID int64) (*domain.WaitlistOffer, *domain.WaitlistEntry, error) {
+	offer, err := s.repo.GetWaitlistOffer(offerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offer.PatientID != patientID {
+		return nil, nil, domain.ErrOfferNotFound
+	}
+	if offer.Status != domain.OfferPending || !s.NowFunc().Before(offer.ExpiresAt) {
+		return nil, nil, domain.ErrOfferExpired
+	}
+	entry, err := s.repo.GetWaitlistEntry(offer.EntryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return offer, entry, nil
+}
+
+// closeOffer ends an offer and puts its entry back in line unless the
+// patient left the waitlist meanwhile.
+func (s *Service) closeOffer(offer *domain.WaitlistOffer, status string) {
+	if err := s.repo.SetWaitlistOfferStatus(offer.ID, status); err != nil {
+		logging.Warnf("Waitlist: failed to close offer %d: %v", offer.ID, err)
+	}
+	entry, err := s.repo.GetWaitlistEntry(offer.EntryID)
+	if err != nil || entry.Status != domain.WaitlistOffered {
+		return
+	}
+	if err := s.repo.SetWaitlistEntryStatus(entry.ID, domain.WaitlistWaiting); err != nil {
+		logging.Warnf("Waitlist: failed to requeue entry %d: %v", entry.ID, err)
+	}
+}
+
+// offerSlot offers the time at start to the first waiting patient it still
+// fits. therapistID is the therapist whose time was freed ("" when unknown).
+// Callers hold s.mu.
+func (s *Service) offerSlot(ctx context.Context, start time.Time, therapistID string) {
+	now := s.NowFunc()
+	if !start.After(now) {
+		return
+	}
+
+	candidates, err := s.repo.ListWaitlistCandidates(start)
+	if err != nil {
+		logging.Errorf("Waitlist: failed to list candidates for %s: %v", start.Format(time.RFC3339), err)
+		return
+	}
+
+	for _, entry := range candidates {
+		if entry.TherapistID != "" && therapistID != "" && entry.TherapistID != therapistID {
+			continue
+		}
+		offerTherapist := entry.TherapistID
+		if offerTherapist == "" {
+			offerTherapist = therapistID
+		}
+		if !s.slotOpen(ctx, offerTherapist, start, entry.DurationMinutes) {
+			continue
+		}
+
+		expires := now.Add(s.offerTTL)
+		if expires.After(start) {
+			expires = start
+		}
+		offer := domain.WaitlistOffer{
+			EntryID:     entry.ID,
+			PatientID:   entry.PatientID,
+			TherapistID: offerTherapist,
+			SlotStart:   start,
+			SlotEnd:     start.Add(time.Duration(entry.DurationMinutes) * time.Minute),
+			Status:      domain.OfferPending,
+			ExpiresAt:   expires,
+		}
+		if err := s.repo.CreateWaitlistOffer(&offer); err != nil {
+			logging.Errorf("Waitlist: failed to create offer for entry %d: %v", entry.ID, err)
+			return
+		}
+		if err := s.repo.SetWaitlistEntryStatus(entry.ID, domain.WaitlistOffered); err != nil {
+			logging.Warnf("Waitlist: failed to mark entry %d offered: %v", entry.ID, err)
+		}
+
+		if err := s.sendOffer(entry, offer); err != nil {
+			logging.Warnf("Waitlist: failed to send offer %d to %s, trying next: %v", offer.ID, entry.PatientID, err)
+			s.closeOffer(&offer, domain.OfferExpired)
+			continue
+		}
+		logging.Infof("Waitlist: offered %s to patient %s (offer %d)", start.Format("2006-01-02 15:04"), entry.PatientID, offer.ID)
+		return
+	}
+}
+
+// slotOpen checks the live slot list, so schedules, buffers and bookings
+// made since the cancellation are all respected.
+func (s *Service) slotOpen(ctx context.Context, therapistID string, start time.Time, durationMinutes int) bool {
+	local := start.In(domain.ApptTimeZone)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+
+	var slots []domain.TimeSlot
+	var err error
+	if therapistID != "" {
+		slots, err = s.appts.GetTherapistTimeSlots(ctx, therapistID, day, durationMinutes)
+	} else {
+		slots, err = s.appts.GetAvailableTimeSlots(ctx, day, durationMinutes)
+	}
+	if err != nil {
+		logging.Warnf("Waitlist: failed to check slots for %s: %v", day.Format("2006-01-02"), err)
+		return false
+	}
+	for _, slot := range slots {
+		if slot.Start.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) sendOffer(entry domain.WaitlistEntry, offer domain.WaitlistOffer) error {
+	id, err := strconv.ParseInt(entry.PatientID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid patient id %q: %w", entry.PatientID, err)
+	}
+	offer.SlotStart = offer.SlotStart.In(domain.ApptTimeZone)
+	offer.ExpiresAt = offer.ExpiresAt.In(domain.ApptTimeZone)
+
+	menu := &telebot.ReplyMarkup{}
+	offerID := strconv.FormatInt(offer.ID, 10)
+	menu.Inline(menu.Row(
+		menu.Data("✅ Записаться", "waitlist_accept", offerID),
+		menu.Data("❌ Не подходит", "waitlist_decline", offerID),
+	))
+	_, err = s.bot.Send(&telebot.User{ID: id}, s.presenter.FormatWaitlistOffer(entry, offer), telebot.ModeHTML, menu)
+	return err
+}
+
+func (s *Service) send(patientID string, msg string) {
+	id, err := strconv.ParseInt(patientID, 10, 64)
+	if err != nil {
+		return
+	}
+	if _, err := s.bot.Send(&telebot.User{ID: id}, msg); err != nil {
+		logging.Warnf("Waitlist: failed to notify %s: %v", patientID, err)
+	}
+}
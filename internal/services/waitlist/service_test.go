@@ -0,0 +1,529 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/presentation"
+	"gopkg.in/telebot.v3"
+)
+
+// --- Mocks ---
+
+// mockBotSender captures Send calls without a real Telegram connection.
+type mockBotSender struct {
+	sentTo   []telebot.Recipient
+	sentWhat []interface{}
+	err      error
+}
+
+func (m *mockBotSender) Send(to telebot.Recipient, what interface{}, opts ...interface{}) (*telebot.Message, error) {
+	m.sentTo = append(m.sentTo, to)
+	m.sentWhat = append(m.sentWhat, what)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &telebot.Message{}, nil
+}
+
+// mockRepo is an in-memory WaitlistRepository.
+type mockRepo struct {
+	entries map[int64]*domain.WaitlistEntry
+	offers  map[int64]*domain.WaitlistOffer
+	order   []int64
+	nextID  int64
+}
+
+func newMockRepo() *mockRepo {
+	return &mockRepo{entries: map[int64]*domain.WaitlistEntry{}, offers: map[int64]*domain.WaitlistOffer{}}
+}
+
+func (m *mockRepo) AddWaitlistEntry(e *domain.WaitlistEntry) error {
+	m.nextID++
+	e.ID = m.nextID
+	cp := *e
+	m.entries[e.ID] = &cp
+	m.order = append(m.order, e.ID)
+	return nil
+}
+func (m *mockRepo) GetWaitlistEntry(id int64) (*domain.WaitlistEntry, error) {
+	e, ok := m.entries[id]
+	if !ok {
+		return nil, domain.ErrWaitlistEntryNotFound
+	}
+	cp := *e
+	return &cp, nil
+}
+func (m *mockRepo) ListPatientWaitlist(patientID string) ([]domain.WaitlistEntry, error) {
+	var out []domain.WaitlistEntry
+	for _, id := range m.order {
+		e := m.entries[id]
+		if e.PatientID == patientID && (e.Status == domain.WaitlistWaiting || e.Status == domain.WaitlistOffered) {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) ListWaitlistCandidates(slotStart time.Time) ([]domain.WaitlistEntry, error) {
+	var out []domain.WaitlistEntry
+	for _, id := range m.order {
+		e := m.entries[id]
+		if e.Status != domain.WaitlistWaiting || !e.Covers(slotStart) {
+			continue
+		}
+		offered := false
+		for _, o := range m.offers {
+			if o.EntryID == e.ID && o.SlotStart.Equal(slotStart) {
+				offered = true
+			}
+		}
+		if !offered {
+			out = append(out, *e)
+		}
+	}
+	return out, nil
+}
+func (m *mockRepo) SetWaitlistEntryStatus(id int64, status string) error {
+	e, ok := m.entries[id]
+	if !ok {
+		return domain.ErrWaitlistEntryNotFound
+	}
+	e.Status = status
+	return nil
+}
+func (m *mockRepo) CreateWaitlistOffer(o *domain.WaitlistOffer) error {
+	m.nextID++
+	o.ID = m.nextID
+	cp := *o
+	m.offers[o.ID] = &cp
+	return nil
+}
+func (m *mockRepo) GetWaitlistOffer(id int64) (*domain.WaitlistOffer, error) {
+	o, ok := m.offers[id]
+	if !ok {
+		return nil, domain.ErrOfferNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+func (m *mockRepo) SetWaitlistOfferStatus(id int64, status string) error {
+	o, ok := m.offers[id]
+	if !ok {
+		return domain.ErrOfferNotFound
+	}
+	o.Status = status
+	return nil
+}
+func (m *mockRepo) ListExpiredWaitlistOffers(now time.Time) ([]domain.WaitlistOffer, error) {
+	var out []domain.WaitlistOffer
+	for _, o := range m.offers {
+		if o.Status == domain.OfferPending && !o.ExpiresAt.After(now) {
+			out = append(out, *o)
+		}
+	}
+	return out, nil
+}
+
+// pendingOffers returns the pending offers keyed by patient.
+func (m *mockRepo) pendingOffers() map[string]*domain.WaitlistOffer {
+	out := map[string]*domain.WaitlistOffer{}
+	for _, o := range m.offers {
+		if o.Status == domain.OfferPending {
+			out[o.PatientID] = o
+		}
+	}
+	return out
+}
+
+// mockApptService is a minimal AppointmentService stub for waitlist tests.
+type mockApptService struct {
+	slots     []domain.TimeSlot
+	created   []domain.Appointment
+	createErr error
+}
+
+func (m *mockApptService) GetAvailableServices(ctx context.Context) ([]domain.Service, error) {
+	return []domain.Service{{ID: "svc", Name: "Массаж", DurationMinutes: 60, Price: 2000}}, nil
+}
+func (m *mockApptService) GetAvailableTimeSlots(ctx context.Context, date time.Time, dur int) ([]domain.TimeSlot, error) {
+	return m.slots, nil
+}
+func (m *mockApptService) GetTherapistTimeSlots(ctx context.Context, therapistID string, date time.Time, dur int) ([]domain.TimeSlot, error) {
+	return m.slots, nil
+}
+func (m *mockApptService) CreateAppointment(ctx context.Context, a *domain.Appointment) (*domain.Appointment, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	a.ID = "new-appt"
+	m.created = append(m.created, *a)
+	return a, nil
+}
+func (m *mockApptService) CancelAppointment(ctx context.Context, id string) error { return nil }
+func (m *mockApptService) RescheduleAppointment(ctx context.Context, id string, t time.Time) (*domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) BookSeries(ctx context.Context, series domain.AppointmentSeries) (*domain.AppointmentSeries, error) {
+	return &series, nil
+}
+func (m *mockApptService) GetAppointmentSeries(ctx context.Context, id string) (*domain.AppointmentSeries, error) {
+	return nil, domain.ErrSeriesNotFound
+}
+func (m *mockApptService) CancelSeries(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetAppointmentStatus(ctx context.Context, id string) (domain.AppointmentStatus, error) {
+	return domain.StatusBooked, nil
+}
+func (m *mockApptService) SetAppointmentStatus(ctx context.Context, appt *domain.Appointment, status domain.AppointmentStatus) error {
+	return nil
+}
+func (m *mockApptService) GetPatientStatusCounts(ctx context.Context, id string) (map[domain.AppointmentStatus]int, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCancellationPolicy() domain.CancellationPolicy {
+	return domain.DefaultCancellationPolicy()
+}
+func (m *mockApptService) HoldSlot(ctx context.Context, holderID, therapistID string, start time.Time, durationMinutes int) error {
+	return nil
+}
+func (m *mockApptService) ReleaseSlotHold(ctx context.Context, holderID string) error {
+	return nil
+}
+func (m *mockApptService) FindNextAvailable(ctx context.Context, serviceID string, from time.Time, horizon time.Duration, limit int) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetMonthAvailability(ctx context.Context, therapistID string, month time.Time, durationMinutes int) ([]domain.DayAvailability, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetUpcomingAppointments(ctx context.Context, timeMin, timeMax time.Time) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCustomerAppointments(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetCustomerHistory(ctx context.Context, id string) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) HistorySynced() bool {
+	return false
+}
+func (m *mockApptService) GetAllUpcomingAppointments(ctx context.Context) ([]domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	return nil, nil
+}
+func (m *mockApptService) GetTotalUpcomingCount(ctx context.Context) (int, error) { return 0, nil }
+func (m *mockApptService) GetCalendarAccountInfo(ctx context.Context) (string, error) {
+	return "", nil
+}
+func (m *mockApptService) GetCalendarID() string                               { return "" }
+func (m *mockApptService) ListCalendars(ctx context.Context) ([]string, error) { return nil, nil }
+func (m *mockApptService) GetAllServices(ctx context.Context) ([]domain.Service, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveService(ctx context.Context, svc domain.Service) (*domain.Service, error) {
+	return &svc, nil
+}
+func (m *mockApptService) SetServiceArchived(ctx context.Context, id string, archived bool) error {
+	return nil
+}
+func (m *mockApptService) ReorderServices(ctx context.Context, ids []string) error { return nil }
+func (m *mockApptService) GetServiceCategories(ctx context.Context, includeEmpty bool) ([]domain.ServiceCategory, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveServiceCategory(ctx context.Context, cat domain.ServiceCategory) error {
+	return nil
+}
+func (m *mockApptService) GetSchedule(ctx context.Context) (domain.WeeklySchedule, error) {
+	return domain.WeeklySchedule{}, nil
+}
+func (m *mockApptService) GetWorkingHours(ctx context.Context, therapistID string, date time.Time) ([]domain.TimeSlot, error) {
+	return nil, nil
+}
+func (m *mockApptService) SetWeekdayHours(ctx context.Context, therapistID string, weekday time.Weekday, work, breaks []domain.MinuteRange) error {
+	return nil
+}
+func (m *mockApptService) SaveScheduleException(ctx context.Context, exc domain.ScheduleException) error {
+	return nil
+}
+func (m *mockApptService) DeleteScheduleException(ctx context.Context, therapistID string, date time.Time) error {
+	return nil
+}
+func (m *mockApptService) GetTherapists(ctx context.Context, includeInactive bool) ([]domain.Therapist, error) {
+	return nil, nil
+}
+func (m *mockApptService) SaveTherapist(ctx context.Context, t domain.Therapist) error { return nil }
+
+// --- Helpers ---
+
+var (
+	testNow  = time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC)
+	slotTime = time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)
+)
+
+func newTestService(t *testing.T) (*Service, *mockRepo, *mockApptService, *mockBotSender) {
+	t.Helper()
+	oldTZ := domain.ApptTimeZone
+	domain.ApptTimeZone = time.UTC
+	t.Cleanup(func() { domain.ApptTimeZone = oldTZ })
+
+	repo := newMockRepo()
+	appts := &mockApptService{slots: []domain.TimeSlot{{Start: slotTime, End: slotTime.Add(time.Hour)}}}
+	bot := &mockBotSender{}
+	svc := NewService(repo, appts, bot, presentation.NewBotPresenter(), 30*time.Minute)
+	svc.NowFunc = func() time.Time { return testNow }
+	return svc, repo, appts, bot
+}
+
+func join(t *testing.T, svc *Service, patientID string) *domain.WaitlistEntry {
+	t.Helper()
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	e, err := svc.Join(context.Background(), domain.WaitlistEntry{
+		PatientID: patientID, PatientName: "Patient " + patientID,
+		ServiceID: "svc", ServiceName: "Массаж", DurationMinutes: 60,
+		DateFrom: day, DateTo: day,
+	})
+	if err != nil {
+		t.Fatalf("Join(%s) error = %v", patientID, err)
+	}
+	return e
+}
+
+func freeSlot(svc *Service) {
+	svc.SlotFreed(context.Background(), domain.Appointment{StartTime: slotTime, EndTime: slotTime.Add(time.Hour)})
+}
+
+// --- Tests ---
+
+func TestService_Join(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	base := domain.WaitlistEntry{PatientID: "1", ServiceID: "svc", DurationMinutes: 60, DateFrom: day, DateTo: day}
+
+	first, err := svc.Join(context.Background(), base)
+	if err != nil {
+		t.Fatalf("Join() error = %v", err)
+	}
+	if first.Status != domain.WaitlistWaiting || first.ID == 0 {
+		t.Errorf("Join() = %+v, want waiting entry with ID", first)
+	}
+
+	again, err := svc.Join(context.Background(), base)
+	if err != nil || again.ID != first.ID || len(repo.entries) != 1 {
+		t.Errorf("repeated Join() = %+v, %v; want the existing entry", again, err)
+	}
+
+	invalid := map[string]func(e *domain.WaitlistEntry){
+		"no patient": func(e *domain.WaitlistEntry) { e.PatientID = "" },
+		"no service": func(e *domain.WaitlistEntry) { e.ServiceID = "" },
+		"reversed":   func(e *domain.WaitlistEntry) { e.DateTo = day.AddDate(0, 0, -1) },
+		"past": func(e *domain.WaitlistEntry) {
+			e.DateFrom, e.DateTo = testNow.AddDate(0, 0, -3), testNow.AddDate(0, 0, -2)
+		},
+		"too long":      func(e *domain.WaitlistEntry) { e.DateTo = day.AddDate(0, 0, domain.MaxWaitlistDays) },
+		"zero duration": func(e *domain.WaitlistEntry) { e.DurationMinutes = 0 },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			e := base
+			mutate(&e)
+			if _, err := svc.Join(context.Background(), e); !errors.Is(err, domain.ErrInvalidWaitlistEntry) {
+				t.Errorf("Join() error = %v, want ErrInvalidWaitlistEntry", err)
+			}
+		})
+	}
+}
+
+func TestService_Leave(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	e := join(t, svc, "1")
+
+	if err := svc.Leave(context.Background(), "2", e.ID); !errors.Is(err, domain.ErrWaitlistEntryNotFound) {
+		t.Errorf("Leave() by another patient error = %v, want ErrWaitlistEntryNotFound", err)
+	}
+	if err := svc.Leave(context.Background(), "1", e.ID); err != nil {
+		t.Fatalf("Leave() error = %v", err)
+	}
+	if repo.entries[e.ID].Status != domain.WaitlistCancelled {
+		t.Errorf("status = %q, want cancelled", repo.entries[e.ID].Status)
+	}
+}
+
+func TestService_SlotFreedOffersFirstInLine(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	first := join(t, svc, "1")
+	join(t, svc, "2")
+
+	freeSlot(svc)
+
+	offers := repo.pendingOffers()
+	if len(offers) != 1 || offers["1"] == nil {
+		t.Fatalf("pending offers = %v, want one for patient 1", offers)
+	}
+	if !offers["1"].ExpiresAt.Equal(testNow.Add(30 * time.Minute)) {
+		t.Errorf("ExpiresAt = %v, want now+30m", offers["1"].ExpiresAt)
+	}
+	if repo.entries[first.ID].Status != domain.WaitlistOffered {
+		t.Errorf("entry status = %q, want offered", repo.entries[first.ID].Status)
+	}
+	if len(bot.sentTo) != 1 || bot.sentTo[0].Recipient() != "1" {
+		t.Errorf("sent to %v, want patient 1", bot.sentTo)
+	}
+}
+
+func TestService_SlotFreedSkipsTakenSlot(t *testing.T) {
+	svc, repo, appts, bot := newTestService(t)
+	join(t, svc, "1")
+	appts.slots = nil
+
+	freeSlot(svc)
+
+	if len(repo.offers) != 0 || len(bot.sentTo) != 0 {
+		t.Errorf("offers = %d, sends = %d; want none for a slot that is no longer free", len(repo.offers), len(bot.sentTo))
+	}
+}
+
+func TestService_SlotFreedSkipsUnreachablePatient(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	unreachable := join(t, svc, "not-a-number")
+	join(t, svc, "2")
+
+	freeSlot(svc)
+
+	if repo.entries[unreachable.ID].Status != domain.WaitlistWaiting {
+		t.Errorf("unreachable entry status = %q, want waiting", repo.entries[unreachable.ID].Status)
+	}
+	if offers := repo.pendingOffers(); offers["2"] == nil {
+		t.Errorf("pending offers = %v, want one for patient 2", offers)
+	}
+	if len(bot.sentTo) != 1 {
+		t.Errorf("sends = %d, want 1", len(bot.sentTo))
+	}
+}
+
+func TestService_AcceptOffer(t *testing.T) {
+	svc, repo, appts, _ := newTestService(t)
+	e := join(t, svc, "1")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+
+	if _, err := svc.AcceptOffer(context.Background(), "2", offer.ID); !errors.Is(err, domain.ErrOfferNotFound) {
+		t.Errorf("AcceptOffer() by another patient error = %v, want ErrOfferNotFound", err)
+	}
+
+	appt, err := svc.AcceptOffer(context.Background(), "1", offer.ID)
+	if err != nil {
+		t.Fatalf("AcceptOffer() error = %v", err)
+	}
+	if !appt.StartTime.Equal(slotTime) || appt.CustomerTgID != "1" || appt.CustomerName != "Patient 1" || appt.Service.Price != 2000 {
+		t.Errorf("created appointment = %+v", appt)
+	}
+	if len(appts.created) != 1 {
+		t.Errorf("created = %d, want 1", len(appts.created))
+	}
+	if repo.offers[offer.ID].Status != domain.OfferAccepted || repo.entries[e.ID].Status != domain.WaitlistBooked {
+		t.Errorf("offer = %q, entry = %q; want accepted/booked", repo.offers[offer.ID].Status, repo.entries[e.ID].Status)
+	}
+
+	if _, err := svc.AcceptOffer(context.Background(), "1", offer.ID); !errors.Is(err, domain.ErrOfferExpired) {
+		t.Errorf("second AcceptOffer() error = %v, want ErrOfferExpired", err)
+	}
+}
+
+func TestService_AcceptOfferSlotTaken(t *testing.T) {
+	svc, repo, appts, _ := newTestService(t)
+	e := join(t, svc, "1")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+	appts.createErr = domain.ErrSlotUnavailable
+
+	if _, err := svc.AcceptOffer(context.Background(), "1", offer.ID); !errors.Is(err, domain.ErrOfferExpired) {
+		t.Fatalf("AcceptOffer() error = %v, want ErrOfferExpired", err)
+	}
+	if repo.offers[offer.ID].Status != domain.OfferExpired || repo.entries[e.ID].Status != domain.WaitlistWaiting {
+		t.Errorf("offer = %q, entry = %q; want expired/waiting", repo.offers[offer.ID].Status, repo.entries[e.ID].Status)
+	}
+}
+
+func TestService_AcceptOfferAfterDeadline(t *testing.T) {
+	svc, repo, appts, _ := newTestService(t)
+	join(t, svc, "1")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+
+	svc.NowFunc = func() time.Time { return testNow.Add(31 * time.Minute) }
+	if _, err := svc.AcceptOffer(context.Background(), "1", offer.ID); !errors.Is(err, domain.ErrOfferExpired) {
+		t.Errorf("AcceptOffer() error = %v, want ErrOfferExpired", err)
+	}
+	if len(appts.created) != 0 {
+		t.Error("expected no booking after the deadline")
+	}
+}
+
+func TestService_DeclineOfferPassesOn(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	first := join(t, svc, "1")
+	join(t, svc, "2")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+
+	if err := svc.DeclineOffer(context.Background(), "1", offer.ID); err != nil {
+		t.Fatalf("DeclineOffer() error = %v", err)
+	}
+	if repo.offers[offer.ID].Status != domain.OfferDeclined || repo.entries[first.ID].Status != domain.WaitlistWaiting {
+		t.Errorf("offer = %q, entry = %q; want declined/waiting", repo.offers[offer.ID].Status, repo.entries[first.ID].Status)
+	}
+	offers := repo.pendingOffers()
+	if len(offers) != 1 || offers["2"] == nil {
+		t.Errorf("pending offers = %v, want one for patient 2", offers)
+	}
+	if len(bot.sentTo) != 2 {
+		t.Errorf("sends = %d, want 2", len(bot.sentTo))
+	}
+}
+
+func TestService_ExpireOffersPassesOn(t *testing.T) {
+	svc, repo, _, bot := newTestService(t)
+	first := join(t, svc, "1")
+	join(t, svc, "2")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+
+	svc.NowFunc = func() time.Time { return testNow.Add(30 * time.Minute) }
+	svc.ExpireOffers(context.Background())
+
+	if repo.offers[offer.ID].Status != domain.OfferExpired || repo.entries[first.ID].Status != domain.WaitlistWaiting {
+		t.Errorf("offer = %q, entry = %q; want expired/waiting", repo.offers[offer.ID].Status, repo.entries[first.ID].Status)
+	}
+	if offers := repo.pendingOffers(); offers["2"] == nil {
+		t.Errorf("pending offers = %v, want one for patient 2", offers)
+	}
+	// offer to 1, expiry notice to 1, offer to 2
+	if len(bot.sentTo) != 3 {
+		t.Errorf("sends = %d, want 3", len(bot.sentTo))
+	}
+}
+
+func TestService_RunLoopForTest(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	join(t, svc, "1")
+	freeSlot(svc)
+	offer := repo.pendingOffers()["1"]
+	svc.NowFunc = func() time.Time { return testNow.Add(time.Hour) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	done := svc.RunLoopForTest(ctx, ticks, func() {})
+	ticks <- testNow
+	cancel()
+	<-done
+
+	if repo.offers[offer.ID].Status != domain.OfferExpired {
+		t.Errorf("offer status = %q, want expired", repo.offers[offer.ID].Status)
+	}
+}
@@ -55,6 +55,25 @@ func InitDB() (*sqlx.DB, error) {
 	_, _ = db.Exec("ALTER TABLE patient_media ADD COLUMN IF NOT EXISTS transcript TEXT")
 	_, _ = db.Exec("ALTER TABLE patient_media ADD COLUMN IF NOT EXISTS status TEXT DEFAULT 'approved'")
 
+	// Manual Migration for multi-therapist support
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS therapist_id TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE schedule_rules ADD COLUMN IF NOT EXISTS therapist_id TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE schedule_exceptions ADD COLUMN IF NOT EXISTS therapist_id TEXT NOT NULL DEFAULT ''")
+
+	// Manual Migration for the Postgres-native calendar (CALENDAR_PROVIDER=postgres)
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS end_time TIMESTAMP")
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS calendar_id TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS calendar_event_id TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("CREATE INDEX IF NOT EXISTS idx_appointments_calendar_start ON appointments(calendar_id, start_time)")
+
+	// Manual Migration for pruning events deleted while calendar sync was stale
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS sync_calendar_id TEXT NOT NULL DEFAULT ''")
+
+	// Manual Migration for promo codes
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS promo_code TEXT NOT NULL DEFAULT ''")
+	_, _ = db.Exec("ALTER TABLE appointments ADD COLUMN IF NOT EXISTS discount NUMERIC NOT NULL DEFAULT 0")
+
 	log.Println("DEBUG: Database schema initialized/verified.")
 
 	DB = db
@@ -5,7 +5,10 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -17,6 +20,8 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/kfilin/massage-bot/internal/services/appointment"
 )
 
 type IntegrationTestSuite struct {
@@ -317,6 +322,32 @@ func (s *IntegrationTestSuite) TestDeleteAppointment() {
 	s.Empty(history)
 }
 
+func (s *IntegrationTestSuite) TestCalendarSyncState() {
+	appts := []domain.Appointment{{
+		ID:           "int-sync-1",
+		CustomerTgID: "int-sync",
+		Service:      domain.Service{Name: "Massage", DurationMinutes: 60},
+		StartTime:    time.Now(),
+		Status:       "confirmed",
+	}}
+	s.Require().NoError(s.repo.UpsertAppointments(appts))
+
+	found, err := s.repo.GetAppointmentsByID([]string{"int-sync-1", "int-sync-missing"})
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Equal("int-sync", found[0].CustomerTgID)
+	s.True(found[0].EndTime.Equal(found[0].StartTime.Add(time.Hour)))
+
+	token, err := s.repo.GetCalendarSyncToken("int-cal")
+	s.Require().NoError(err)
+	s.Empty(token)
+	s.Require().NoError(s.repo.SaveCalendarSyncToken("int-cal", "token-1"))
+	s.Require().NoError(s.repo.SaveCalendarSyncToken("int-cal", "token-2"))
+	token, err = s.repo.GetCalendarSyncToken("int-cal")
+	s.Require().NoError(err)
+	s.Equal("token-2", token)
+}
+
 func (s *IntegrationTestSuite) TestSaveAndGetAppointmentMetadata() {
 	now := time.Now()
 	reminders := map[string]bool{"72h": true, "24h": false}
@@ -350,6 +381,111 @@ func (s *IntegrationTestSuite) TestLogEvent() {
 	s.Require().NoError(err)
 }
 
+// sharedCalendar is the calendar every replica books into. Create is slow,
+// like the Google API, to widen the check-then-create window.
+type sharedCalendar struct {
+	ports.AppointmentRepository
+	mu    sync.Mutex
+	appts []domain.Appointment
+}
+
+func (c *sharedCalendar) GetCalendarID() string { return "shared" }
+
+func (c *sharedCalendar) GetFreeBusy(ctx context.Context, timeMin, timeMax time.Time) ([]domain.TimeSlot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var busy []domain.TimeSlot
+	for _, a := range c.appts {
+		if a.StartTime.Before(timeMax) && a.EndTime.After(timeMin) {
+			busy = append(busy, domain.TimeSlot{Start: a.StartTime, End: a.EndTime})
+		}
+	}
+	return busy, nil
+}
+
+func (c *sharedCalendar) Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	time.Sleep(50 * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	created := *appt
+	created.ID = fmt.Sprintf("evt-%d", len(c.appts)+1)
+	c.appts = append(c.appts, created)
+	return &created, nil
+}
+
+// TestBookingLock_Replicas runs several appointment services, each on its
+// own connection pool like separate bot instances, booking the same slot at
+// once. The advisory lock must let exactly one of them through.
+func (s *IntegrationTestSuite) TestBookingLock_Replicas() {
+	domain.ApptTimeZone = time.UTC
+	cal := &sharedCalendar{}
+	day := time.Now().UTC().AddDate(0, 0, 7)
+	for day.Weekday() != time.Wednesday {
+		day = day.AddDate(0, 0, 1)
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 10, 0, 0, 0, time.UTC)
+
+	const replicas = 5
+	services := make([]*appointment.Service, replicas)
+	for i := range services {
+		db, err := sqlx.Connect("postgres", s.connStr)
+		s.Require().NoError(err)
+		s.T().Cleanup(func() { _ = db.Close() })
+		svc := appointment.NewServiceWithMetrics(cal, nil, &appointment.NoOpCollector{})
+		svc.SetBookingLocker(NewPostgresRepository(db, s.T().TempDir()))
+		services[i] = svc
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, replicas)
+	for i, svc := range services {
+		wg.Add(1)
+		go func(i int, svc *appointment.Service) {
+			defer wg.Done()
+			_, errs[i] = svc.CreateAppointment(s.ctx, &domain.Appointment{
+				Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60},
+				StartTime:    start,
+				Duration:     60,
+				CustomerName: fmt.Sprintf("Patient %d", i),
+			})
+		}(i, svc)
+	}
+	wg.Wait()
+
+	booked := 0
+	for _, err := range errs {
+		if err == nil {
+			booked++
+			continue
+		}
+		s.True(errors.Is(err, domain.ErrSlotUnavailable), "unexpected error: %v", err)
+	}
+	s.Equal(1, booked, "exactly one replica may book the slot")
+	s.Len(cal.appts, 1)
+}
+
+// TestBookingLock_Blocks checks the lock is held until released and freed
+// with the connection's transaction.
+func (s *IntegrationTestSuite) TestBookingLock_Blocks() {
+	other, err := sqlx.Connect("postgres", s.connStr)
+	s.Require().NoError(err)
+	defer other.Close()
+	otherRepo := NewPostgresRepository(other, s.T().TempDir())
+
+	unlock, err := s.repo.LockBookings(s.ctx)
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(s.ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = otherRepo.LockBookings(ctx)
+	s.Error(err, "a second instance must wait while the lock is held")
+
+	unlock()
+	unlock2, err := otherRepo.LockBookings(s.ctx)
+	s.Require().NoError(err, "the lock must be free once released")
+	unlock2()
+}
+
 // Session storage integration tests
 func (s *IntegrationTestSuite) TestSessionStorage_CRUD() {
 	sessionStore := NewPostgresSessionStorage(s.db)
@@ -408,3 +544,53 @@ func (s *IntegrationTestSuite) TestInitDB() {
 	s.Require().NoError(err)
 	s.GreaterOrEqual(n, 0)
 }
+
+func (s *IntegrationTestSuite) TestPostgresAppointmentRepository() {
+	repo := NewPostgresAppointmentRepository(s.db, "", nil)
+	start := time.Date(2031, 2, 3, 10, 0, 0, 0, domain.ApptTimeZone)
+
+	created, err := repo.Create(s.ctx, &domain.Appointment{
+		StartTime:    start,
+		EndTime:      start.Add(time.Hour),
+		CustomerTgID: "pg-cal-1",
+		CustomerName: "Postgres Patient",
+		Service:      domain.Service{ID: "1", Name: "Massage", DurationMinutes: 60, Price: 2000},
+	})
+	s.Require().NoError(err)
+
+	// A history row synced from Google belongs to the local calendar
+	s.Require().NoError(s.repo.UpsertAppointments([]domain.Appointment{{
+		ID: "pg-cal-synced", CustomerTgID: "pg-cal-2", StartTime: start.Add(3 * time.Hour),
+		Service: domain.Service{Name: "Massage", DurationMinutes: 30},
+	}}))
+
+	dayStart := time.Date(2031, 2, 3, 0, 0, 0, 0, domain.ApptTimeZone)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	appts, err := repo.FindEvents(s.ctx, &dayStart, &dayEnd)
+	s.Require().NoError(err)
+	s.Require().Len(appts, 2)
+	s.Equal(created.ID, appts[0].ID)
+	s.True(appts[0].StartTime.Equal(start), "times should survive the round trip")
+	s.True(appts[1].EndTime.Equal(start.Add(3*time.Hour+30*time.Minute)))
+
+	busy, err := repo.GetFreeBusy(s.ctx, start.Add(30*time.Minute), dayEnd)
+	s.Require().NoError(err)
+	s.Require().Len(busy, 2)
+	s.True(busy[0].Start.Equal(start.Add(30 * time.Minute)))
+
+	moved := *created
+	moved.StartTime, moved.EndTime = start.Add(time.Hour), start.Add(2*time.Hour)
+	_, err = repo.Update(s.ctx, &moved)
+	s.Require().NoError(err)
+
+	s.Require().NoError(repo.Delete(s.ctx, created.ID))
+	s.Require().NoError(repo.Delete(s.ctx, created.ID), "cancelling twice is not an error")
+	found, err := repo.FindByID(s.ctx, created.ID)
+	s.Require().NoError(err)
+	s.Equal("cancelled", found.Status)
+	s.True(found.StartTime.Equal(moved.StartTime))
+
+	busy, err = repo.GetFreeBusy(s.ctx, dayStart, dayEnd)
+	s.Require().NoError(err)
+	s.Len(busy, 1, "cancelled appointments do not block time")
+}
@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// LocalCalendarID is the default calendar of appointments kept only in
+// Postgres. History rows synced from Google carry no calendar and count as
+// part of it, so switching provider keeps the already booked visits.
+const LocalCalendarID = "local"
+
+var _ ports.AppointmentRepository = (*PostgresAppointmentRepository)(nil)
+
+// PostgresAppointmentRepository keeps one calendar's appointments in the
+// appointments table, without any external calendar. When mirror is set,
+// every change is copied there as well (best effort); Postgres stays the
+// source of truth for free/busy.
+type PostgresAppointmentRepository struct {
+	db         *sqlx.DB
+	calendarID string
+	mirror     ports.AppointmentRepository
+}
+
+// NewPostgresAppointmentRepository creates the repository of calendarID
+// (LocalCalendarID when empty). mirror may be nil.
+func NewPostgresAppointmentRepository(db *sqlx.DB, calendarID string, mirror ports.AppointmentRepository) *PostgresAppointmentRepository {
+	if calendarID == "" {
+		calendarID = LocalCalendarID
+	}
+	return &PostgresAppointmentRepository{db: db, calendarID: calendarID, mirror: mirror}
+}
+
+// appointmentRow is a row of the appointments table. Times are stored as
+// the clinic's wall clock (ApptTimeZone), as the history sync always has.
+type appointmentRow struct {
+	ID              string          `db:"id"`
+	CustomerID      string          `db:"customer_id"`
+	ServiceID       sql.NullString  `db:"service_id"`
+	ServiceName     sql.NullString  `db:"service_name"`
+	ServiceDuration sql.NullInt64   `db:"service_duration"`
+	ServicePrice    sql.NullFloat64 `db:"service_price"`
+	StartTime       time.Time       `db:"start_time"`
+	EndTime         time.Time       `db:"end_time"`
+	Status          sql.NullString  `db:"status"`
+	CustomerName    sql.NullString  `db:"customer_name"`
+	Notes           string          `db:"notes"`
+	TherapistID     string          `db:"therapist_id"`
+	CalendarEventID string          `db:"calendar_event_id"`
+	PromoCode       string          `db:"promo_code"`
+	Discount        float64         `db:"discount"`
+}
+
+// appointmentEnd is the end of a row; history rows synced before end_time
+// existed end after their service duration.
+const appointmentEnd = `COALESCE(end_time, start_time + COALESCE(service_duration, 0) * INTERVAL '1 minute')`
+
+const appointmentColumns = `id, customer_id, service_id, service_name, service_duration, service_price,
+	start_time, ` + appointmentEnd + ` AS end_time, status, customer_name, notes, therapist_id, calendar_event_id,
+	promo_code, discount`
+
+// inCalendar restricts a query to this calendar; it takes two arguments,
+// see calendarArgs.
+const inCalendar = `(calendar_id = $1 OR calendar_id = $2)`
+
+// calendarArgs are the arguments of inCalendar: the calendar, and for the
+// local calendar also no calendar, so that synced history rows belong to it.
+func (r *PostgresAppointmentRepository) calendarArgs() []interface{} {
+	legacy := r.calendarID
+	if r.calendarID == LocalCalendarID {
+		legacy = ""
+	}
+	return []interface{}{r.calendarID, legacy}
+}
+
+// toClinicClock converts t to a wall-clock time for a TIMESTAMP column.
+func toClinicClock(t time.Time) time.Time {
+	l := t.In(domain.ApptTimeZone)
+	return time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), l.Nanosecond(), time.UTC)
+}
+
+// fromClinicClock reads a TIMESTAMP column written by toClinicClock.
+func fromClinicClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), domain.ApptTimeZone)
+}
+
+func (row appointmentRow) toAppointment() domain.Appointment {
+	start, end := fromClinicClock(row.StartTime), fromClinicClock(row.EndTime)
+	duration := int(end.Sub(start).Minutes())
+	return domain.Appointment{
+		ID:              row.ID,
+		ClientID:        row.ID,
+		ServiceID:       row.ServiceID.String,
+		StartTime:       start,
+		EndTime:         end,
+		Duration:        duration,
+		TherapistID:     row.TherapistID,
+		CustomerName:    row.CustomerName.String,
+		CustomerTgID:    row.CustomerID,
+		Notes:           row.Notes,
+		CalendarEventID: row.CalendarEventID,
+		Status:          row.Status.String,
+		PromoCode:       row.PromoCode,
+		Discount:        row.Discount,
+		Service: domain.Service{
+			ID:              row.ServiceID.String,
+			Name:            row.ServiceName.String,
+			DurationMinutes: duration,
+			Price:           row.ServicePrice.Float64,
+		},
+	}
+}
+
+// Create stores a new appointment under a random ID and mirrors it.
+func (r *PostgresAppointmentRepository) Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if appt.StartTime.IsZero() || appt.EndTime.IsZero() {
+		return nil, fmt.Errorf("appointment StartTime or EndTime is zero; ensure set by service layer")
+	}
+	if !appt.EndTime.After(appt.StartTime) {
+		return nil, fmt.Errorf("appointment EndTime must be after StartTime")
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate appointment ID: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+	status := appt.Status
+	if status == "" {
+		status = "confirmed"
+	}
+	duration := appt.Service.DurationMinutes
+	if duration == 0 {
+		duration = int(appt.EndTime.Sub(appt.StartTime).Minutes())
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO appointments (id, customer_id, service_id, service_name, service_duration, service_price,
+		                          start_time, end_time, status, customer_name, notes, therapist_id, calendar_id,
+		                          promo_code, discount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, id, appt.CustomerTgID, appt.Service.ID, appt.Service.Name, duration, appt.Service.Price,
+		toClinicClock(appt.StartTime), toClinicClock(appt.EndTime), status, appt.CustomerName, appt.Notes,
+		appt.TherapistID, r.calendarID, appt.PromoCode, appt.Discount)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("create_appointment").Inc()
+		return nil, fmt.Errorf("failed to create appointment in '%s': %w", r.calendarID, err)
+	}
+	appt.ID = id
+	appt.Status = status
+	logging.Infof("SUCCESS: Appointment created in Postgres calendar '%s' (ID: %s)", r.calendarID, id)
+
+	if r.mirror != nil {
+		mirrored := *appt
+		mirrored.ID = ""
+		created, err := r.mirror.Create(ctx, &mirrored)
+		if err != nil {
+			logging.Warnf("WARNING: Failed to mirror appointment %s to %s: %v", id, r.mirror.GetCalendarID(), err)
+			return appt, nil
+		}
+		if _, err := r.db.ExecContext(ctx, `UPDATE appointments SET calendar_event_id = $2 WHERE id = $1`, id, created.ID); err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("create_appointment").Inc()
+			logging.Warnf("WARNING: Failed to remember mirrored event %s of appointment %s: %v", created.ID, id, err)
+		}
+		appt.CalendarEventID = created.ID
+	}
+	return appt, nil
+}
+
+// FindAll returns appointments starting from 24 hours ago.
+func (r *PostgresAppointmentRepository) FindAll(ctx context.Context) ([]domain.Appointment, error) {
+	timeMin := time.Now().Add(-24 * time.Hour)
+	return r.FindEvents(ctx, &timeMin, nil)
+}
+
+// FindEvents returns the calendar's appointments overlapping the optional
+// range, ordered by start. Cancelled appointments are left out.
+func (r *PostgresAppointmentRepository) FindEvents(ctx context.Context, timeMin, timeMax *time.Time) ([]domain.Appointment, error) {
+	query := `SELECT ` + appointmentColumns + ` FROM appointments
+		WHERE ` + inCalendar + ` AND status IS DISTINCT FROM 'cancelled'`
+	args := r.calendarArgs()
+	if timeMin != nil {
+		args = append(args, toClinicClock(*timeMin))
+		query += fmt.Sprintf(" AND %s > $%d", appointmentEnd, len(args))
+	}
+	if timeMax != nil {
+		args = append(args, toClinicClock(*timeMax))
+		query += fmt.Sprintf(" AND start_time < $%d", len(args))
+	}
+	query += " ORDER BY start_time"
+
+	var rows []appointmentRow
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("find_appointments").Inc()
+		return nil, fmt.Errorf("failed to list appointments from '%s': %w", r.calendarID, err)
+	}
+	appts := make([]domain.Appointment, 0, len(rows))
+	for _, row := range rows {
+		appts = append(appts, row.toAppointment())
+	}
+	return appts, nil
+}
+
+// FindByID returns the appointment, including a cancelled one.
+func (r *PostgresAppointmentRepository) FindByID(ctx context.Context, id string) (*domain.Appointment, error) {
+	var row appointmentRow
+	err := r.db.GetContext(ctx, &row, `SELECT `+appointmentColumns+` FROM appointments
+		WHERE `+inCalendar+` AND id = $3`, append(r.calendarArgs(), id)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("find_appointment").Inc()
+		return nil, fmt.Errorf("failed to get appointment %s: %w", id, err)
+	}
+	appt := row.toAppointment()
+	return &appt, nil
+}
+
+// Delete marks the appointment cancelled, like a deleted calendar event,
+// and removes its mirrored copy. Cancelling it again is not an error.
+func (r *PostgresAppointmentRepository) Delete(ctx context.Context, id string) error {
+	var eventID string
+	err := r.db.GetContext(ctx, &eventID, `
+		UPDATE appointments SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE `+inCalendar+` AND id = $3 AND status IS DISTINCT FROM 'cancelled'
+		RETURNING calendar_event_id
+	`, append(r.calendarArgs(), id)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.FindByID(ctx, id); err != nil {
+			return err
+		}
+		return nil // Already cancelled
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("delete_appointment").Inc()
+		return fmt.Errorf("failed to cancel appointment %s: %w", id, err)
+	}
+
+	if r.mirror != nil && eventID != "" {
+		if err := r.mirror.Delete(ctx, eventID); err != nil && !errors.Is(err, domain.ErrAppointmentNotFound) {
+			logging.Warnf("WARNING: Failed to delete mirrored event %s of appointment %s: %v", eventID, id, err)
+		}
+	}
+	return nil
+}
+
+// Update moves the appointment to its new start and end time.
+func (r *PostgresAppointmentRepository) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if appt.ID == "" || appt.StartTime.IsZero() || appt.EndTime.IsZero() {
+		return nil, fmt.Errorf("appointment ID, StartTime or EndTime is empty; ensure set by service layer")
+	}
+	if !appt.EndTime.After(appt.StartTime) {
+		return nil, fmt.Errorf("appointment EndTime must be after StartTime")
+	}
+
+	var eventID string
+	err := r.db.GetContext(ctx, &eventID, `
+		UPDATE appointments SET start_time = $4, end_time = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE `+inCalendar+` AND id = $3 AND status IS DISTINCT FROM 'cancelled'
+		RETURNING calendar_event_id
+	`, append(r.calendarArgs(), appt.ID, toClinicClock(appt.StartTime), toClinicClock(appt.EndTime))...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrAppointmentNotFound
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_appointment").Inc()
+		return nil, fmt.Errorf("failed to update appointment %s: %w", appt.ID, err)
+	}
+
+	if r.mirror != nil && eventID != "" {
+		mirrored := *appt
+		mirrored.ID = eventID
+		if _, err := r.mirror.Update(ctx, &mirrored); err != nil {
+			logging.Warnf("WARNING: Failed to move mirrored event %s of appointment %s: %v", eventID, appt.ID, err)
+		}
+	}
+	return appt, nil
+}
+
+// GetFreeBusy returns the stored appointments overlapping [timeMin,
+// timeMax) as busy intervals clipped to the range.
+func (r *PostgresAppointmentRepository) GetFreeBusy(ctx context.Context, timeMin, timeMax time.Time) ([]domain.TimeSlot, error) {
+	var rows []struct {
+		Start time.Time `db:"start_time"`
+		End   time.Time `db:"end_time"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT start_time, `+appointmentEnd+` AS end_time FROM appointments
+		WHERE `+inCalendar+` AND status IS DISTINCT FROM 'cancelled'
+		  AND start_time < $4 AND `+appointmentEnd+` > $3
+		ORDER BY start_time
+	`, append(r.calendarArgs(), toClinicClock(timeMin), toClinicClock(timeMax))...)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_free_busy").Inc()
+		return nil, fmt.Errorf("failed to query busy time of '%s': %w", r.calendarID, err)
+	}
+
+	busy := make([]domain.TimeSlot, 0, len(rows))
+	for _, row := range rows {
+		start, end := fromClinicClock(row.Start), fromClinicClock(row.End)
+		if start.Before(timeMin) {
+			start = timeMin
+		}
+		if end.After(timeMax) {
+			end = timeMax
+		}
+		busy = append(busy, domain.TimeSlot{Start: start, End: end})
+	}
+	return busy, nil
+}
+
+// GetAccountInfo describes where the appointments are kept.
+func (r *PostgresAppointmentRepository) GetAccountInfo(ctx context.Context) (string, error) {
+	if r.mirror != nil {
+		return fmt.Sprintf("PostgreSQL (%s), mirrored to %s", r.calendarID, r.mirror.GetCalendarID()), nil
+	}
+	return fmt.Sprintf("PostgreSQL (%s)", r.calendarID), nil
+}
+
+func (r *PostgresAppointmentRepository) GetCalendarID() string {
+	return r.calendarID
+}
+
+// ListCalendars lists the calendars in use: this one, therapists' and
+// those with stored appointments.
+func (r *PostgresAppointmentRepository) ListCalendars(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT calendar_id FROM appointments WHERE calendar_id <> ''
+		UNION SELECT calendar_id FROM therapists WHERE calendar_id <> ''
+		UNION SELECT $1
+		ORDER BY 1
+	`, r.calendarID)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_calendars").Inc()
+		return nil, fmt.Errorf("failed to list calendars: %w", err)
+	}
+	res := make([]string, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, fmt.Sprintf("PostgreSQL (%s)", id))
+	}
+	return res, nil
+}
@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+// mirrorRepo records what is mirrored to the external calendar.
+type mirrorRepo struct {
+	ports.AppointmentRepository
+	created []domain.Appointment
+	updated []domain.Appointment
+	deleted []string
+	err     error
+}
+
+func (m *mirrorRepo) Create(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.created = append(m.created, *appt)
+	appt.ID = "gcal-1"
+	return appt, nil
+}
+
+func (m *mirrorRepo) Update(ctx context.Context, appt *domain.Appointment) (*domain.Appointment, error) {
+	m.updated = append(m.updated, *appt)
+	return appt, m.err
+}
+
+func (m *mirrorRepo) Delete(ctx context.Context, id string) error {
+	m.deleted = append(m.deleted, id)
+	return m.err
+}
+
+func (m *mirrorRepo) GetCalendarID() string { return "primary" }
+
+func newAppointmentTestRepo(t *testing.T, calendarID string, mirror ports.AppointmentRepository) (*PostgresAppointmentRepository, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewPostgresAppointmentRepository(sqlx.NewDb(db, "sqlmock"), calendarID, mirror), mock
+}
+
+var appointmentRowColumns = []string{"id", "customer_id", "service_id", "service_name", "service_duration", "service_price",
+	"start_time", "end_time", "status", "customer_name", "notes", "therapist_id", "calendar_event_id",
+	"promo_code", "discount"}
+
+// clinicTime is 10:00 on 10 January 2030 in the clinic's time zone.
+func clinicTime(hour int) time.Time {
+	return time.Date(2030, 1, 10, hour, 0, 0, 0, domain.ApptTimeZone)
+}
+
+// wallClock is how clinicTime(hour) is stored in a TIMESTAMP column.
+func wallClock(hour int) time.Time {
+	return time.Date(2030, 1, 10, hour, 0, 0, 0, time.UTC)
+}
+
+func TestPostgresAppointments_Create(t *testing.T) {
+	mirror := &mirrorRepo{}
+	repo, mock := newAppointmentTestRepo(t, "", mirror)
+	appt := &domain.Appointment{
+		StartTime:    clinicTime(10).UTC(),
+		EndTime:      clinicTime(11).UTC(),
+		CustomerTgID: "100",
+		CustomerName: "Иван",
+		Notes:        "Первый визит",
+		Service:      domain.Service{ID: "1", Name: "Массаж спины", DurationMinutes: 60, Price: 2000},
+		PromoCode:    "SPRING10",
+		Discount:     200,
+	}
+
+	mock.ExpectExec("INSERT INTO appointments").
+		WithArgs(sqlmock.AnyArg(), "100", "1", "Массаж спины", 60, 2000.0, wallClock(10), wallClock(11),
+			"confirmed", "Иван", "Первый визит", "", LocalCalendarID, "SPRING10", 200.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE appointments SET calendar_event_id").WithArgs(sqlmock.AnyArg(), "gcal-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	created, err := repo.Create(context.Background(), appt)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(created.ID) != 32 || created.CalendarEventID != "gcal-1" || created.Status != "confirmed" {
+		t.Errorf("unexpected appointment %+v", created)
+	}
+	if len(mirror.created) != 1 || mirror.created[0].CustomerName != "Иван" {
+		t.Errorf("expected the appointment mirrored, got %v", mirror.created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_CreateValidation(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "", nil)
+	if _, err := repo.Create(context.Background(), &domain.Appointment{}); err == nil {
+		t.Error("expected an error for an appointment without times")
+	}
+	backwards := &domain.Appointment{StartTime: clinicTime(11), EndTime: clinicTime(10)}
+	if _, err := repo.Create(context.Background(), backwards); err == nil {
+		t.Error("expected an error for an appointment ending before it starts")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("no query should run: %v", err)
+	}
+}
+
+func TestPostgresAppointments_CreateMirrorFailure(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "therapist-cal", &mirrorRepo{err: errors.New("google down")})
+	mock.ExpectExec("INSERT INTO appointments").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	created, err := repo.Create(context.Background(), &domain.Appointment{StartTime: clinicTime(10), EndTime: clinicTime(11)})
+	if err != nil || created.CalendarEventID != "" {
+		t.Errorf("a mirror failure should not fail the booking: %v, %+v", err, created)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_FindEvents(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "", nil)
+	from, to := clinicTime(0), clinicTime(24)
+
+	rows := sqlmock.NewRows(appointmentRowColumns).
+		AddRow("a1", "100", "1", "Массаж спины", 60, 2000.0, wallClock(10), wallClock(11), "confirmed", "Иван", "", "", "", "", 0.0).
+		AddRow("g1", "200", nil, nil, nil, nil, wallClock(14), wallClock(15), nil, nil, "", "", "", "", 0.0)
+	mock.ExpectQuery("SELECT (.+) FROM appointments(.+)calendar_id = \\$1 OR calendar_id = \\$2(.+)cancelled(.+)> \\$3 AND start_time < \\$4 ORDER BY start_time").
+		WithArgs(LocalCalendarID, "", time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(rows)
+
+	appts, err := repo.FindEvents(context.Background(), &from, &to)
+	if err != nil {
+		t.Fatalf("FindEvents failed: %v", err)
+	}
+	if len(appts) != 2 {
+		t.Fatalf("expected 2 appointments, got %d", len(appts))
+	}
+	first := appts[0]
+	if !first.StartTime.Equal(clinicTime(10)) || first.Duration != 60 || first.CustomerTgID != "100" || first.Service.Name != "Массаж спины" {
+		t.Errorf("unexpected appointment %+v", first)
+	}
+	if appts[1].ID != "g1" || !appts[1].EndTime.Equal(clinicTime(15)) {
+		t.Errorf("expected a synced history row read as well, got %+v", appts[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_FindByID(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "therapist-cal", nil)
+
+	mock.ExpectQuery("SELECT (.+) FROM appointments(.+)id = \\$3").WithArgs("therapist-cal", "therapist-cal", "a1").
+		WillReturnRows(sqlmock.NewRows(appointmentRowColumns).
+			AddRow("a1", "100", "1", "Массаж", 60, 2000.0, wallClock(10), wallClock(11), "cancelled", "Иван", "", "t1", "", "SPRING10", 200.0))
+	mock.ExpectQuery("SELECT (.+) FROM appointments").WithArgs("therapist-cal", "therapist-cal", "missing").
+		WillReturnRows(sqlmock.NewRows(appointmentRowColumns))
+
+	appt, err := repo.FindByID(context.Background(), "a1")
+	if err != nil || appt.Status != "cancelled" || appt.TherapistID != "t1" || appt.PromoCode != "SPRING10" || appt.Discount != 200 {
+		t.Errorf("expected the cancelled appointment returned, got %+v, %v", appt, err)
+	}
+	if _, err := repo.FindByID(context.Background(), "missing"); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_Delete(t *testing.T) {
+	mirror := &mirrorRepo{}
+	repo, mock := newAppointmentTestRepo(t, "", mirror)
+	ctx := context.Background()
+
+	mock.ExpectQuery("UPDATE appointments SET status = 'cancelled'(.+)RETURNING calendar_event_id").
+		WithArgs(LocalCalendarID, "", "a1").
+		WillReturnRows(sqlmock.NewRows([]string{"calendar_event_id"}).AddRow("gcal-1"))
+	if err := repo.Delete(ctx, "a1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(mirror.deleted) != 1 || mirror.deleted[0] != "gcal-1" {
+		t.Errorf("expected the mirrored event deleted, got %v", mirror.deleted)
+	}
+
+	// Cancelled before: like a deleted calendar event, not an error
+	mock.ExpectQuery("UPDATE appointments SET status = 'cancelled'").WillReturnRows(sqlmock.NewRows([]string{"calendar_event_id"}))
+	mock.ExpectQuery("SELECT (.+) FROM appointments").
+		WillReturnRows(sqlmock.NewRows(appointmentRowColumns).
+			AddRow("a1", "100", nil, nil, 60, nil, wallClock(10), wallClock(11), "cancelled", nil, "", "", "gcal-1", "", 0.0))
+	if err := repo.Delete(ctx, "a1"); err != nil {
+		t.Errorf("expected no error for a cancelled appointment, got %v", err)
+	}
+
+	mock.ExpectQuery("UPDATE appointments SET status = 'cancelled'").WillReturnRows(sqlmock.NewRows([]string{"calendar_event_id"}))
+	mock.ExpectQuery("SELECT (.+) FROM appointments").WillReturnRows(sqlmock.NewRows(appointmentRowColumns))
+	if err := repo.Delete(ctx, "missing"); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_Update(t *testing.T) {
+	mirror := &mirrorRepo{}
+	repo, mock := newAppointmentTestRepo(t, "", mirror)
+	appt := &domain.Appointment{ID: "a1", StartTime: clinicTime(12), EndTime: clinicTime(13)}
+
+	mock.ExpectQuery("UPDATE appointments SET start_time = \\$4, end_time = \\$5").
+		WithArgs(LocalCalendarID, "", "a1", wallClock(12), wallClock(13)).
+		WillReturnRows(sqlmock.NewRows([]string{"calendar_event_id"}).AddRow("gcal-1"))
+	if _, err := repo.Update(context.Background(), appt); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(mirror.updated) != 1 || mirror.updated[0].ID != "gcal-1" || appt.ID != "a1" {
+		t.Errorf("expected the mirrored event moved under its own ID, got %v", mirror.updated)
+	}
+
+	mock.ExpectQuery("UPDATE appointments SET start_time").WillReturnRows(sqlmock.NewRows([]string{"calendar_event_id"}))
+	if _, err := repo.Update(context.Background(), &domain.Appointment{ID: "missing", StartTime: clinicTime(12), EndTime: clinicTime(13)}); !errors.Is(err, domain.ErrAppointmentNotFound) {
+		t.Errorf("expected ErrAppointmentNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_GetFreeBusy(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "", nil)
+
+	mock.ExpectQuery("SELECT start_time, (.+) FROM appointments(.+)cancelled(.+)start_time < \\$4").
+		WithArgs(LocalCalendarID, "", wallClock(9), wallClock(18)).
+		WillReturnRows(sqlmock.NewRows([]string{"start_time", "end_time"}).
+			AddRow(wallClock(8), wallClock(10)).
+			AddRow(wallClock(12), wallClock(13)))
+
+	busy, err := repo.GetFreeBusy(context.Background(), clinicTime(9), clinicTime(18))
+	if err != nil {
+		t.Fatalf("GetFreeBusy failed: %v", err)
+	}
+	if len(busy) != 2 || !busy[0].Start.Equal(clinicTime(9)) || !busy[1].End.Equal(clinicTime(13)) {
+		t.Errorf("expected busy time clipped to the range, got %v", busy)
+	}
+
+	mock.ExpectQuery("SELECT start_time").WillReturnError(errors.New("db down"))
+	if _, err := repo.GetFreeBusy(context.Background(), clinicTime(9), clinicTime(18)); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgresAppointments_Calendars(t *testing.T) {
+	repo, mock := newAppointmentTestRepo(t, "", nil)
+
+	mock.ExpectQuery("SELECT calendar_id FROM appointments(.+)UNION SELECT calendar_id FROM therapists").WithArgs(LocalCalendarID).
+		WillReturnRows(sqlmock.NewRows([]string{"calendar_id"}).AddRow("local").AddRow("t1"))
+	calendars, err := repo.ListCalendars(context.Background())
+	if err != nil || len(calendars) != 2 || calendars[1] != "PostgreSQL (t1)" {
+		t.Errorf("unexpected calendars %v, %v", calendars, err)
+	}
+	if repo.GetCalendarID() != LocalCalendarID {
+		t.Errorf("GetCalendarID() = %q", repo.GetCalendarID())
+	}
+	if info, _ := repo.GetAccountInfo(context.Background()); info != "PostgreSQL (local)" {
+		t.Errorf("GetAccountInfo() = %q", info)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
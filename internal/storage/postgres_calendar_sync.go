@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/lib/pq"
+)
+
+var _ ports.CalendarSyncRepository = (*PostgresRepository)(nil)
+
+// GetAppointmentsByID returns the stored appointments among ids, so a sync
+// knows where a changed event was before.
+func (r *PostgresRepository) GetAppointmentsByID(ids []string) ([]domain.Appointment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var rows []appointmentRow
+	err := r.db.Select(&rows, `SELECT `+appointmentColumns+` FROM appointments WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_appointments_by_id").Inc()
+		return nil, fmt.Errorf("failed to get appointments by ID: %w", err)
+	}
+	appts := make([]domain.Appointment, 0, len(rows))
+	for _, row := range rows {
+		appts = append(appts, row.toAppointment())
+	}
+	return appts, nil
+}
+
+// ClaimAppointments marks the rows as synced from the calendar. The
+// calendar_id column is left alone: it scopes the Postgres-native calendar,
+// which counts synced history as its own.
+func (r *PostgresRepository) ClaimAppointments(calendarID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(`UPDATE appointments SET sync_calendar_id = $1 WHERE id = ANY($2) AND sync_calendar_id <> $1`,
+		calendarID, pq.Array(ids))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("claim_appointments").Inc()
+		return fmt.Errorf("failed to claim appointments for calendar %s: %w", calendarID, err)
+	}
+	return nil
+}
+
+// PruneAppointments deletes the synced rows of the calendar missing from
+// its full listing. Rows of the Postgres-native calendar are never touched.
+func (r *PostgresRepository) PruneAppointments(calendarID string, keep []string) (int64, error) {
+	if keep == nil {
+		keep = []string{}
+	}
+	res, err := r.db.Exec(`DELETE FROM appointments WHERE sync_calendar_id = $1 AND calendar_id = '' AND id <> ALL($2)`,
+		calendarID, pq.Array(keep))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("prune_appointments").Inc()
+		return 0, fmt.Errorf("failed to prune appointments of calendar %s: %w", calendarID, err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// GetCalendarSyncToken returns the sync token saved for the calendar, or ""
+// before its first full sync.
+func (r *PostgresRepository) GetCalendarSyncToken(calendarID string) (string, error) {
+	var token string
+	err := r.db.Get(&token, `SELECT sync_token FROM calendar_sync_state WHERE calendar_id = $1`, calendarID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_calendar_sync_token").Inc()
+		return "", fmt.Errorf("failed to get sync token of calendar %s: %w", calendarID, err)
+	}
+	return token, nil
+}
+
+// SaveCalendarSyncToken stores the token the next sync of the calendar
+// starts from. An empty token forces a full sync.
+func (r *PostgresRepository) SaveCalendarSyncToken(calendarID, token string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO calendar_sync_state (calendar_id, sync_token, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (calendar_id) DO UPDATE SET sync_token = EXCLUDED.sync_token, updated_at = CURRENT_TIMESTAMP`,
+		calendarID, token)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_calendar_sync_token").Inc()
+		return fmt.Errorf("failed to save sync token of calendar %s: %w", calendarID, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetAppointmentsByID(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	if appts, err := repo.GetAppointmentsByID(nil); err != nil || appts != nil {
+		t.Errorf("expected no query for no IDs, got %v, %v", appts, err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM appointments WHERE id = ANY\\(\\$1\\)").WithArgs(`{"a1","g1"}`).
+		WillReturnRows(sqlmock.NewRows(appointmentRowColumns).
+			AddRow("a1", "100", "1", "Массаж", 60, 2000.0, wallClock(10), wallClock(11), "confirmed", "Иван", "", "", "", "", 0.0))
+	appts, err := repo.GetAppointmentsByID([]string{"a1", "g1"})
+	if err != nil {
+		t.Fatalf("GetAppointmentsByID failed: %v", err)
+	}
+	if len(appts) != 1 || !appts[0].StartTime.Equal(clinicTime(10)) || !appts[0].EndTime.Equal(clinicTime(11)) {
+		t.Errorf("unexpected appointments %+v", appts)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM appointments").WillReturnError(errors.New("db down"))
+	if _, err := repo.GetAppointmentsByID([]string{"a1"}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCalendarSyncToken(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT sync_token FROM calendar_sync_state").WithArgs("primary").
+		WillReturnRows(sqlmock.NewRows([]string{"sync_token"}))
+	if token, err := repo.GetCalendarSyncToken("primary"); err != nil || token != "" {
+		t.Errorf("expected no token before the first sync, got %q, %v", token, err)
+	}
+
+	mock.ExpectExec("INSERT INTO calendar_sync_state").WithArgs("primary", "token-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.SaveCalendarSyncToken("primary", "token-1"); err != nil {
+		t.Fatalf("SaveCalendarSyncToken failed: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT sync_token FROM calendar_sync_state").WithArgs("primary").
+		WillReturnRows(sqlmock.NewRows([]string{"sync_token"}).AddRow("token-1"))
+	if token, err := repo.GetCalendarSyncToken("primary"); err != nil || token != "token-1" {
+		t.Errorf("expected the saved token, got %q, %v", token, err)
+	}
+
+	mock.ExpectQuery("SELECT sync_token FROM calendar_sync_state").WillReturnError(errors.New("db down"))
+	if _, err := repo.GetCalendarSyncToken("primary"); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestClaimAndPruneAppointments(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	if err := repo.ClaimAppointments("primary", nil); err != nil {
+		t.Errorf("expected no query for no IDs, got %v", err)
+	}
+	mock.ExpectExec("UPDATE appointments SET sync_calendar_id = \\$1 WHERE id = ANY\\(\\$2\\)").
+		WithArgs("primary", `{"a1","a2"}`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := repo.ClaimAppointments("primary", []string{"a1", "a2"}); err != nil {
+		t.Fatalf("ClaimAppointments failed: %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM appointments WHERE sync_calendar_id = \\$1 AND calendar_id = '' AND id <> ALL\\(\\$2\\)").
+		WithArgs("primary", `{"a1"}`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	if n, err := repo.PruneAppointments("primary", []string{"a1"}); err != nil || n != 3 {
+		t.Errorf("PruneAppointments = %d, %v; want 3, nil", n, err)
+	}
+
+	// Unclaimed rows, with nothing to keep
+	mock.ExpectExec("DELETE FROM appointments").WithArgs("", "{}").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if n, err := repo.PruneAppointments("", nil); err != nil || n != 1 {
+		t.Errorf("PruneAppointments(unclaimed) = %d, %v; want 1, nil", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/logging"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.ServiceCatalogRepository = (*PostgresRepository)(nil)
+
+const serviceColumns = `id, name, duration, price, description, category_id, sort_order, archived`
+
+// ListServices returns the catalog ordered by sort_order, then name.
+// Archived services are only included when includeArchived is true.
+func (r *PostgresRepository) ListServices(includeArchived bool) ([]domain.Service, error) {
+	query := `SELECT ` + serviceColumns + ` FROM services`
+	if !includeArchived {
+		query += ` WHERE archived = FALSE`
+	}
+	query += ` ORDER BY sort_order ASC, name ASC`
+
+	var services []domain.Service
+	if err := r.db.Select(&services, query); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_services").Inc()
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return services, nil
+}
+
+// GetService returns a single catalog entry (archived or not).
+func (r *PostgresRepository) GetService(id string) (*domain.Service, error) {
+	var svc domain.Service
+	err := r.db.Get(&svc, `SELECT `+serviceColumns+` FROM services WHERE id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrServiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get service %s: %w", id, err)
+	}
+	return &svc, nil
+}
+
+// SaveService inserts a new catalog entry or updates an existing one by ID.
+func (r *PostgresRepository) SaveService(svc domain.Service) error {
+	query := `
+		INSERT INTO services (id, name, duration, price, description, category_id, sort_order, archived, created_at, updated_at)
+		VALUES (:id, :name, :duration, :price, :description, :category_id, :sort_order, :archived, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			duration = EXCLUDED.duration,
+			price = EXCLUDED.price,
+			description = EXCLUDED.description,
+			category_id = EXCLUDED.category_id,
+			sort_order = EXCLUDED.sort_order,
+			archived = EXCLUDED.archived,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	logging.Debugf(": Saving service %s (%s)", svc.ID, svc.Name)
+	if _, err := r.db.NamedExec(query, svc); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_service").Inc()
+		return fmt.Errorf("failed to save service: %w", err)
+	}
+	return nil
+}
+
+// SetServiceArchived hides (or restores) a service without deleting it, so
+// appointments and reports that reference it keep resolving.
+func (r *PostgresRepository) SetServiceArchived(id string, archived bool) error {
+	result, err := r.db.Exec(`UPDATE services SET archived = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, archived, id)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("archive_service").Inc()
+		return fmt.Errorf("failed to update service archive flag: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return domain.ErrServiceNotFound
+	}
+	return nil
+}
+
+// ReorderServices assigns sort_order 1..N following orderedIDs in a single
+// transaction. Services not mentioned keep their current position.
+func (r *PostgresRepository) ReorderServices(orderedIDs []string) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin reorder transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, id := range orderedIDs {
+		result, err := tx.Exec(`UPDATE services SET sort_order = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, i+1, id)
+		if err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("reorder_services").Inc()
+			return fmt.Errorf("failed to reorder service %s: %w", id, err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrServiceNotFound, id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reorder: %w", err)
+	}
+	return nil
+}
+
+// ListCategories returns all service categories ordered for the menu.
+func (r *PostgresRepository) ListCategories() ([]domain.ServiceCategory, error) {
+	var categories []domain.ServiceCategory
+	err := r.db.Select(&categories, `SELECT id, label, icon, sort_order FROM service_categories ORDER BY sort_order ASC, label ASC`)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_categories").Inc()
+		return nil, fmt.Errorf("failed to list service categories: %w", err)
+	}
+	return categories, nil
+}
+
+// SaveCategory inserts or updates a service category by ID.
+func (r *PostgresRepository) SaveCategory(cat domain.ServiceCategory) error {
+	query := `
+		INSERT INTO service_categories (id, label, icon, sort_order)
+		VALUES (:id, :label, :icon, :sort_order)
+		ON CONFLICT (id) DO UPDATE SET
+			label = EXCLUDED.label,
+			icon = EXCLUDED.icon,
+			sort_order = EXCLUDED.sort_order
+	`
+	if _, err := r.db.NamedExec(query, cat); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_category").Inc()
+		return fmt.Errorf("failed to save service category: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func newCatalogTestRepo(t *testing.T) (*PostgresRepository, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+	return NewPostgresRepository(sqlx.NewDb(db, "sqlmock"), t.TempDir()), mock, func() { db.Close() }
+}
+
+var serviceRowColumns = []string{"id", "name", "duration", "price", "description", "category_id", "sort_order", "archived"}
+
+func TestListServices(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	rows := sqlmock.NewRows(serviceRowColumns).
+		AddRow("1", "Массаж", 40, 2000.0, "", "massages", 1, false).
+		AddRow("2", "Консультация", 60, 1500.0, "Онлайн", "consultations", 2, false)
+	mock.ExpectQuery("SELECT (.+) FROM services WHERE archived = FALSE ORDER BY sort_order").WillReturnRows(rows)
+
+	services, err := repo.ListServices(false)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Expected 2 services, got %d", len(services))
+	}
+	if services[1].Description != "Онлайн" || services[1].CategoryID != "consultations" {
+		t.Errorf("Unexpected service mapping: %+v", services[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListServices_IncludeArchived(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	rows := sqlmock.NewRows(serviceRowColumns).AddRow("3", "Старое", 30, 900.0, "", "", 3, true)
+	mock.ExpectQuery(`SELECT (.+) FROM services ORDER BY sort_order`).WillReturnRows(rows)
+
+	services, err := repo.ListServices(true)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(services) != 1 || !services[0].Archived {
+		t.Errorf("Expected archived service, got %+v", services)
+	}
+}
+
+func TestGetService_NotFound(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT (.+) FROM services WHERE id").WithArgs("42").WillReturnError(sql.ErrNoRows)
+
+	if _, err := repo.GetService("42"); !errors.Is(err, domain.ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+}
+
+func TestSaveService(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("INSERT INTO services").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.SaveService(domain.Service{ID: "8", Name: "Новая", DurationMinutes: 30, Price: 1000})
+	if err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSetServiceArchived(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("UPDATE services SET archived").WithArgs(true, "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.SetServiceArchived("1", true); err != nil {
+		t.Errorf("SetServiceArchived failed: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE services SET archived").WithArgs(true, "99").WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.SetServiceArchived("99", true); !errors.Is(err, domain.ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+}
+
+func TestReorderServices(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE services SET sort_order").WithArgs(1, "2").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE services SET sort_order").WithArgs(2, "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.ReorderServices([]string{"2", "1"}); err != nil {
+		t.Fatalf("ReorderServices failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReorderServices_UnknownIDRollsBack(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE services SET sort_order").WithArgs(1, "missing").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err := repo.ReorderServices([]string{"missing"}); !errors.Is(err, domain.ErrServiceNotFound) {
+		t.Errorf("Expected ErrServiceNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListCategories(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	rows := sqlmock.NewRows([]string{"id", "label", "icon", "sort_order"}).
+		AddRow("massages", "Массаж", "💆", 1).
+		AddRow("other", "Другие услуги", "", 2)
+	mock.ExpectQuery("SELECT (.+) FROM service_categories ORDER BY sort_order").WillReturnRows(rows)
+
+	categories, err := repo.ListCategories()
+	if err != nil {
+		t.Fatalf("ListCategories failed: %v", err)
+	}
+	if len(categories) != 2 || categories[0].Icon != "💆" {
+		t.Errorf("Unexpected categories: %+v", categories)
+	}
+}
+
+func TestSaveCategory(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("INSERT INTO service_categories").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.SaveCategory(domain.ServiceCategory{ID: "spa", Label: "SPA", SortOrder: 4}); err != nil {
+		t.Fatalf("SaveCategory failed: %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO service_categories").WillReturnError(errors.New("db down"))
+	if err := repo.SaveCategory(domain.ServiceCategory{ID: "spa", Label: "SPA"}); err == nil {
+		t.Error("Expected error from SaveCategory")
+	}
+}
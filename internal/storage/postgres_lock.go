@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.BookingLocker = (*PostgresRepository)(nil)
+
+// bookingLockKey is the advisory lock every instance takes before checking
+// and reserving a slot. The value is arbitrary but must never change while
+// old and new releases may run side by side.
+const bookingLockKey int64 = 0x6d62626f6f6b // ASCII "mbbook"
+
+// LockBookings takes the booking advisory lock. It is transaction-scoped, so
+// it is released by ending the transaction, or by Postgres itself when the
+// connection of a crashed instance goes away.
+func (r *PostgresRepository) LockBookings(ctx context.Context) (func(), error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("lock_bookings").Inc()
+		return nil, fmt.Errorf("failed to begin booking lock transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, bookingLockKey); err != nil {
+		_ = tx.Rollback()
+		monitoring.DbErrorsTotal.WithLabelValues("lock_bookings").Inc()
+		return nil, fmt.Errorf("failed to take booking lock: %w", err)
+	}
+	return func() { _ = tx.Rollback() }, nil
+}
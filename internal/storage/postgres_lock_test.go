@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLockBookings(t *testing.T) {
+	t.Run("lock and unlock", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(bookingLockKey).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		unlock, err := repo.LockBookings(context.Background())
+		if err != nil {
+			t.Fatalf("LockBookings failed: %v", err)
+		}
+		unlock()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("lock error", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("SELECT pg_advisory_xact_lock").
+			WithArgs(bookingLockKey).
+			WillReturnError(errors.New("canceling statement due to user request"))
+		mock.ExpectRollback()
+
+		if unlock, err := repo.LockBookings(context.Background()); err == nil || unlock != nil {
+			t.Fatalf("expected an error and no unlock func, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.PackageRepository = (*PostgresRepository)(nil)
+
+const packageColumns = `id, patient_id, patient_name, title, service_ids, sessions_total, sessions_used, price, purchased_at, expires_at, low_balance_alerted, expiry_alerted`
+
+// packageRow is the session_packages table; the service scope is stored
+// as a comma-separated list of service IDs.
+type packageRow struct {
+	domain.SessionPackage
+	ServiceIDs string `db:"service_ids"`
+}
+
+func (row packageRow) toDomain() domain.SessionPackage {
+	p := row.SessionPackage
+	p.ServiceIDs = nil
+	for _, id := range strings.Split(row.ServiceIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			p.ServiceIDs = append(p.ServiceIDs, id)
+		}
+	}
+	return p
+}
+
+func (r *PostgresRepository) selectPackages(op, query string, args ...interface{}) ([]domain.SessionPackage, error) {
+	var rows []packageRow
+	if err := r.db.Select(&rows, query, args...); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues(op).Inc()
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+	packages := make([]domain.SessionPackage, len(rows))
+	for i, row := range rows {
+		packages[i] = row.toDomain()
+	}
+	return packages, nil
+}
+
+// CreatePackage inserts a sold package and sets its ID.
+func (r *PostgresRepository) CreatePackage(p *domain.SessionPackage) error {
+	err := r.db.QueryRowx(`
+		INSERT INTO session_packages (patient_id, patient_name, title, service_ids, sessions_total, sessions_used, price, purchased_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, p.PatientID, p.PatientName, p.Title, strings.Join(p.ServiceIDs, ","), p.SessionsTotal, p.SessionsUsed,
+		p.Price, p.PurchasedAt, p.ExpiresAt).Scan(&p.ID)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("create_package").Inc()
+		return fmt.Errorf("failed to create package: %w", err)
+	}
+	return nil
+}
+
+// ListPatientPackages returns all of the patient's packages, newest first.
+func (r *PostgresRepository) ListPatientPackages(patientID string) ([]domain.SessionPackage, error) {
+	return r.selectPackages("list_packages", `
+		SELECT `+packageColumns+`
+		FROM session_packages
+		WHERE patient_id = $1
+		ORDER BY purchased_at DESC, id DESC
+	`, patientID)
+}
+
+// ListOpenPackages returns unexpired packages with an alert still to send.
+func (r *PostgresRepository) ListOpenPackages(now time.Time) ([]domain.SessionPackage, error) {
+	return r.selectPackages("list_packages", `
+		SELECT `+packageColumns+`
+		FROM session_packages
+		WHERE expires_at > $1 AND (NOT low_balance_alerted OR NOT expiry_alerted)
+		ORDER BY expires_at, id
+	`, now)
+}
+
+// ChargePackage records the usage and takes one session in one transaction.
+// The usage table is keyed by appointment, so an appointment is charged once.
+func (r *PostgresRepository) ChargePackage(packageID int64, usage domain.PackageUsage) (bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin package transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(`
+		INSERT INTO package_usages (appointment_id, package_id, service_name, start_time)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (appointment_id) DO NOTHING
+	`, usage.AppointmentID, packageID, usage.ServiceName, usage.StartTime)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("charge_package").Inc()
+		return false, fmt.Errorf("failed to record package usage: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	res, err = tx.Exec(`UPDATE session_packages SET sessions_used = sessions_used + 1 WHERE id = $1 AND sessions_used < sessions_total`, packageID)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("charge_package").Inc()
+		return false, fmt.Errorf("failed to charge package %d: %w", packageID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, domain.ErrPackageExhausted
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit package charge: %w", err)
+	}
+	return true, nil
+}
+
+// RefundPackage removes the usage and gives the session back in one
+// transaction.
+func (r *PostgresRepository) RefundPackage(appointmentID string) (int64, bool, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to begin package transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var packageID int64
+	err = tx.Get(&packageID, `DELETE FROM package_usages WHERE appointment_id = $1 RETURNING package_id`, appointmentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("refund_package").Inc()
+		return 0, false, fmt.Errorf("failed to remove package usage of %s: %w", appointmentID, err)
+	}
+
+	if _, err := tx.Exec(`UPDATE session_packages SET sessions_used = sessions_used - 1 WHERE id = $1 AND sessions_used > 0`, packageID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("refund_package").Inc()
+		return 0, false, fmt.Errorf("failed to refund package %d: %w", packageID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit package refund: %w", err)
+	}
+	return packageID, true, nil
+}
+
+// MarkPackageAlerted flags the low-balance or expiry alert as sent.
+func (r *PostgresRepository) MarkPackageAlerted(packageID int64, alert string) error {
+	var column string
+	switch alert {
+	case domain.PackageAlertLowBalance:
+		column = "low_balance_alerted"
+	case domain.PackageAlertExpiry:
+		column = "expiry_alerted"
+	default:
+		return fmt.Errorf("unknown package alert %q", alert)
+	}
+	if _, err := r.db.Exec(`UPDATE session_packages SET `+column+` = TRUE WHERE id = $1`, packageID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_package").Inc()
+		return fmt.Errorf("failed to mark package %d alerted: %w", packageID, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+var packageRowColumns = []string{"id", "patient_id", "patient_name", "title", "service_ids", "sessions_total", "sessions_used", "price", "purchased_at", "expires_at", "low_balance_alerted", "expiry_alerted"}
+
+func TestCreatePackage(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	bought := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	expires := bought.AddDate(0, 3, 0)
+	mock.ExpectQuery("INSERT INTO session_packages").
+		WithArgs("100", "Иван", "Массаж ×10", "massage,rehab", 10, 0, 9000.0, bought, expires).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+
+	p := &domain.SessionPackage{
+		PatientID: "100", PatientName: "Иван", Title: "Массаж ×10", ServiceIDs: []string{"massage", "rehab"},
+		SessionsTotal: 10, Price: 9000, PurchasedAt: bought, ExpiresAt: expires,
+	}
+	if err := repo.CreatePackage(p); err != nil {
+		t.Fatalf("CreatePackage failed: %v", err)
+	}
+	if p.ID != 4 {
+		t.Errorf("ID = %d, want 4", p.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListPatientPackages(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	bought := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM session_packages WHERE patient_id = \\$1").
+		WithArgs("100").
+		WillReturnRows(sqlmock.NewRows(packageRowColumns).
+			AddRow(4, "100", "Иван", "Массаж ×10", "massage,rehab", 10, 3, 9000.0, bought, bought.AddDate(0, 3, 0), false, false).
+			AddRow(2, "100", "Иван", "Все услуги", "", 5, 5, 5000.0, bought.AddDate(0, -6, 0), bought, true, true))
+
+	packages, err := repo.ListPatientPackages("100")
+	if err != nil {
+		t.Fatalf("ListPatientPackages failed: %v", err)
+	}
+	if len(packages) != 2 {
+		t.Fatalf("expected 2 packages, got %d", len(packages))
+	}
+	if packages[0].ID != 4 || packages[0].Remaining() != 7 || len(packages[0].ServiceIDs) != 2 || packages[0].ServiceIDs[1] != "rehab" {
+		t.Errorf("unexpected first package: %+v", packages[0])
+	}
+	if packages[1].ServiceIDs != nil || !packages[1].LowBalanceAlerted {
+		t.Errorf("unexpected second package: %+v", packages[1])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListOpenPackages(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	now := time.Date(2030, 2, 1, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM session_packages WHERE expires_at > \\$1 AND").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows(packageRowColumns))
+
+	packages, err := repo.ListOpenPackages(now)
+	if err != nil {
+		t.Fatalf("ListOpenPackages failed: %v", err)
+	}
+	if len(packages) != 0 {
+		t.Errorf("expected no packages, got %d", len(packages))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestChargePackage(t *testing.T) {
+	start := time.Date(2030, 2, 1, 10, 0, 0, 0, time.UTC)
+	usage := domain.PackageUsage{AppointmentID: "a1", ServiceName: "Массаж", StartTime: start}
+
+	t.Run("charged", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO package_usages").
+			WithArgs("a1", int64(4), "Массаж", start).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE session_packages SET sessions_used = sessions_used \\+ 1").
+			WithArgs(int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		charged, err := repo.ChargePackage(4, usage)
+		if err != nil || !charged {
+			t.Fatalf("ChargePackage = %v, %v; want true, nil", charged, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("already charged", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO package_usages").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		charged, err := repo.ChargePackage(4, usage)
+		if err != nil || charged {
+			t.Fatalf("ChargePackage = %v, %v; want false, nil", charged, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO package_usages").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE session_packages").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if _, err := repo.ChargePackage(4, usage); !errors.Is(err, domain.ErrPackageExhausted) {
+			t.Fatalf("ChargePackage error = %v, want ErrPackageExhausted", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestRefundPackage(t *testing.T) {
+	t.Run("refunded", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("DELETE FROM package_usages WHERE appointment_id = \\$1 RETURNING package_id").
+			WithArgs("a1").
+			WillReturnRows(sqlmock.NewRows([]string{"package_id"}).AddRow(int64(4)))
+		mock.ExpectExec("UPDATE session_packages SET sessions_used = sessions_used - 1").
+			WithArgs(int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		packageID, refunded, err := repo.RefundPackage("a1")
+		if err != nil || !refunded || packageID != 4 {
+			t.Fatalf("RefundPackage = %d, %v, %v; want 4, true, nil", packageID, refunded, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("not charged", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("DELETE FROM package_usages").
+			WillReturnRows(sqlmock.NewRows([]string{"package_id"}))
+		mock.ExpectRollback()
+
+		if _, refunded, err := repo.RefundPackage("a1"); err != nil || refunded {
+			t.Fatalf("RefundPackage = %v, %v; want false, nil", refunded, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestMarkPackageAlerted(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("UPDATE session_packages SET expiry_alerted = TRUE").
+		WithArgs(int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.MarkPackageAlerted(4, domain.PackageAlertExpiry); err != nil {
+		t.Fatalf("MarkPackageAlerted failed: %v", err)
+	}
+	if err := repo.MarkPackageAlerted(4, "other"); err == nil {
+		t.Error("expected an error for an unknown alert")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
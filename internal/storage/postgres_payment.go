@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+	"github.com/lib/pq"
+)
+
+var _ ports.PaymentRepository = (*PostgresRepository)(nil)
+
+const paymentColumns = `id, appointment_id, patient_id, patient_name, service_id, service_name, list_price, discount, amount, currency, method, visit_time, paid_at, recorded_by`
+
+// SavePayment stores the payment of a visit and sets its ID. A visit has one
+// payment, so recording it again overwrites the earlier record.
+func (r *PostgresRepository) SavePayment(p *domain.Payment) error {
+	err := r.db.QueryRowx(`
+		INSERT INTO payments (appointment_id, patient_id, patient_name, service_id, service_name, list_price, discount, amount, currency, method, visit_time, paid_at, recorded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (appointment_id) DO UPDATE SET
+			patient_id = EXCLUDED.patient_id, patient_name = EXCLUDED.patient_name,
+			service_id = EXCLUDED.service_id, service_name = EXCLUDED.service_name,
+			list_price = EXCLUDED.list_price, discount = EXCLUDED.discount, amount = EXCLUDED.amount,
+			currency = EXCLUDED.currency, method = EXCLUDED.method, visit_time = EXCLUDED.visit_time,
+			paid_at = EXCLUDED.paid_at, recorded_by = EXCLUDED.recorded_by
+		RETURNING id
+	`, p.AppointmentID, p.PatientID, p.PatientName, p.ServiceID, p.ServiceName, p.ListPrice, p.Discount, p.Amount,
+		p.Currency, string(p.Method), p.VisitTime, p.PaidAt, p.RecordedBy).Scan(&p.ID)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_payment").Inc()
+		return fmt.Errorf("failed to save payment for appointment %s: %w", p.AppointmentID, err)
+	}
+	return nil
+}
+
+// ListPayments returns the payments made in [from, to), oldest first.
+func (r *PostgresRepository) ListPayments(from, to time.Time) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	err := r.db.Select(&payments, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE paid_at >= $1 AND paid_at < $2
+		ORDER BY paid_at, id
+	`, from, to)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_payments").Inc()
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, nil
+}
+
+// ListSettledAppointments returns which of ids are paid, charged to a
+// package, or have a no-show or cancellation status recorded.
+func (r *PostgresRepository) ListSettledAppointments(ids []string) (map[string]bool, error) {
+	settled := make(map[string]bool)
+	if len(ids) == 0 {
+		return settled, nil
+	}
+	var rows []string
+	err := r.db.Select(&rows, `
+		SELECT appointment_id FROM payments WHERE appointment_id = ANY($1)
+		UNION
+		SELECT appointment_id FROM package_usages WHERE appointment_id = ANY($1)
+		UNION
+		SELECT appointment_id FROM appointment_statuses WHERE appointment_id = ANY($1) AND status = ANY($2)
+	`, pq.Array(ids), pq.Array([]string{
+		string(domain.StatusNoShow), string(domain.StatusCancelledByPatient),
+		string(domain.StatusCancelledByAdmin), string(domain.StatusLateCancel),
+	}))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_settled_appointments").Inc()
+		return nil, fmt.Errorf("failed to list settled appointments: %w", err)
+	}
+	for _, id := range rows {
+		settled[id] = true
+	}
+	return settled, nil
+}
+
+// ListPackagesSold returns the packages bought in [from, to), oldest first.
+func (r *PostgresRepository) ListPackagesSold(from, to time.Time) ([]domain.SessionPackage, error) {
+	return r.selectPackages("list_packages", `
+		SELECT `+packageColumns+`
+		FROM session_packages
+		WHERE purchased_at >= $1 AND purchased_at < $2
+		ORDER BY purchased_at, id
+	`, from, to)
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/lib/pq"
+)
+
+var paymentRowColumns = []string{"id", "appointment_id", "patient_id", "patient_name", "service_id", "service_name", "list_price", "discount", "amount", "currency", "method", "visit_time", "paid_at", "recorded_by"}
+
+func TestSavePayment(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	visit := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	paid := visit.Add(time.Hour)
+	mock.ExpectQuery("INSERT INTO payments (.+) ON CONFLICT \\(appointment_id\\) DO UPDATE").
+		WithArgs("a1", "100", "Иван", "massage", "Массаж", 2000.0, 200.0, 1800.0, "TRY", "card", visit, paid, "111").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	p := &domain.Payment{
+		AppointmentID: "a1", PatientID: "100", PatientName: "Иван", ServiceID: "massage", ServiceName: "Массаж",
+		ListPrice: 2000, Discount: 200, Amount: 1800, Currency: "TRY", Method: domain.PaymentCard,
+		VisitTime: visit, PaidAt: paid, RecordedBy: "111",
+	}
+	if err := repo.SavePayment(p); err != nil {
+		t.Fatalf("SavePayment failed: %v", err)
+	}
+	if p.ID != 7 {
+		t.Errorf("ID = %d, want 7", p.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListPayments(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	mock.ExpectQuery("SELECT (.+) FROM payments WHERE paid_at >= \\$1 AND paid_at < \\$2").
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows(paymentRowColumns).
+			AddRow(7, "a1", "100", "Иван", "massage", "Массаж", 2000.0, 200.0, 1800.0, "TRY", "card", from, from, "111"))
+
+	payments, err := repo.ListPayments(from, to)
+	if err != nil {
+		t.Fatalf("ListPayments failed: %v", err)
+	}
+	if len(payments) != 1 || payments[0].Method != domain.PaymentCard || payments[0].Amount != 1800 {
+		t.Errorf("unexpected payments: %+v", payments)
+	}
+}
+
+func TestListSettledAppointments(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	ids := []string{"a1", "a2", "a3"}
+	mock.ExpectQuery("SELECT appointment_id FROM payments (.+) UNION (.+) package_usages (.+) UNION (.+) appointment_statuses").
+		WithArgs(pq.Array(ids), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"appointment_id"}).AddRow("a1").AddRow("a3"))
+
+	settled, err := repo.ListSettledAppointments(ids)
+	if err != nil {
+		t.Fatalf("ListSettledAppointments failed: %v", err)
+	}
+	if !settled["a1"] || settled["a2"] || !settled["a3"] {
+		t.Errorf("unexpected settled set: %v", settled)
+	}
+
+	// No IDs, no query
+	if settled, err := repo.ListSettledAppointments(nil); err != nil || len(settled) != 0 {
+		t.Errorf("expected an empty set, got %v, %v", settled, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListPackagesSold(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	mock.ExpectQuery("SELECT (.+) FROM session_packages WHERE purchased_at >= \\$1 AND purchased_at < \\$2").
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows(packageRowColumns).
+			AddRow(4, "100", "Иван", "Массаж ×10", "", 10, 0, 9000.0, from, to, false, false))
+
+	packages, err := repo.ListPackagesSold(from, to)
+	if err != nil {
+		t.Fatalf("ListPackagesSold failed: %v", err)
+	}
+	if len(packages) != 1 || packages[0].Price != 9000 {
+		t.Errorf("unexpected packages: %+v", packages)
+	}
+}
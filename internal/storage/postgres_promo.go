@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.PromoRepository = (*PostgresRepository)(nil)
+
+const promoColumns = `code, kind, value, service_ids, valid_from, valid_until, max_uses, uses, created_by, created_at`
+
+// promoRow is the promo_codes table; the service scope is stored as a
+// comma-separated list of service IDs, like session packages.
+type promoRow struct {
+	domain.PromoCode
+	ServiceIDs string `db:"service_ids"`
+}
+
+func (row promoRow) toDomain() domain.PromoCode {
+	p := row.PromoCode
+	p.ServiceIDs = nil
+	for _, id := range strings.Split(row.ServiceIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			p.ServiceIDs = append(p.ServiceIDs, id)
+		}
+	}
+	return p
+}
+
+// CreatePromoCode inserts a new code; an existing code is left untouched.
+func (r *PostgresRepository) CreatePromoCode(p domain.PromoCode) error {
+	res, err := r.db.Exec(`
+		INSERT INTO promo_codes (code, kind, value, service_ids, valid_from, valid_until, max_uses, uses, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (code) DO NOTHING
+	`, p.Code, string(p.Kind), p.Value, strings.Join(p.ServiceIDs, ","), p.ValidFrom, p.ValidUntil,
+		p.MaxUses, p.Uses, p.CreatedBy, p.CreatedAt)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("create_promo").Inc()
+		return fmt.Errorf("failed to create promo code %s: %w", p.Code, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrPromoExists
+	}
+	return nil
+}
+
+// GetPromoCode returns the code with its current use count.
+func (r *PostgresRepository) GetPromoCode(code string) (*domain.PromoCode, error) {
+	var row promoRow
+	err := r.db.Get(&row, `SELECT `+promoColumns+` FROM promo_codes WHERE code = $1`, code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPromoNotFound
+	}
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_promo").Inc()
+		return nil, fmt.Errorf("failed to get promo code %s: %w", code, err)
+	}
+	p := row.toDomain()
+	return &p, nil
+}
+
+// ListPromoCodes returns every code, newest first.
+func (r *PostgresRepository) ListPromoCodes() ([]domain.PromoCode, error) {
+	var rows []promoRow
+	err := r.db.Select(&rows, `SELECT `+promoColumns+` FROM promo_codes ORDER BY created_at DESC, code`)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_promos").Inc()
+		return nil, fmt.Errorf("failed to list promo codes: %w", err)
+	}
+	promos := make([]domain.PromoCode, len(rows))
+	for i, row := range rows {
+		promos[i] = row.toDomain()
+	}
+	return promos, nil
+}
+
+// ReservePromoCode counts one use of the code in a single statement, so two
+// bookings racing for the last use cannot both get it.
+func (r *PostgresRepository) ReservePromoCode(code string) error {
+	res, err := r.db.Exec(`UPDATE promo_codes SET uses = uses + 1 WHERE code = $1 AND (max_uses = 0 OR uses < max_uses)`, code)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("reserve_promo").Inc()
+		return fmt.Errorf("failed to reserve promo code %s: %w", code, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrPromoUsedUp
+	}
+	return nil
+}
+
+// ReleasePromoCode gives back a use reserved for a booking that was not made.
+func (r *PostgresRepository) ReleasePromoCode(code string) error {
+	_, err := r.db.Exec(`UPDATE promo_codes SET uses = uses - 1 WHERE code = $1 AND uses > 0`, code)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("release_promo").Inc()
+		return fmt.Errorf("failed to release promo code %s: %w", code, err)
+	}
+	return nil
+}
+
+// RecordPromoUsage stores the booking that used a reserved code. The usage
+// table is keyed by appointment, so a booking is recorded once.
+func (r *PostgresRepository) RecordPromoUsage(usage domain.PromoUsage) (bool, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO promo_usages (appointment_id, code, patient_id, patient_name, service_name, discount, used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (appointment_id) DO NOTHING
+	`, usage.AppointmentID, usage.Code, usage.PatientID, usage.PatientName, usage.ServiceName, usage.Discount, usage.UsedAt)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("record_promo_usage").Inc()
+		return false, fmt.Errorf("failed to record promo usage: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ListPromoUsages returns the bookings that used the code, newest first.
+func (r *PostgresRepository) ListPromoUsages(code string) ([]domain.PromoUsage, error) {
+	var usages []domain.PromoUsage
+	err := r.db.Select(&usages, `
+		SELECT code, appointment_id, patient_id, patient_name, service_name, discount, used_at
+		FROM promo_usages
+		WHERE code = $1
+		ORDER BY used_at DESC, appointment_id
+	`, code)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_promo_usages").Inc()
+		return nil, fmt.Errorf("failed to list usages of promo code %s: %w", code, err)
+	}
+	return usages, nil
+}
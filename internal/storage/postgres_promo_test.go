@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+var promoRowColumns = []string{"code", "kind", "value", "service_ids", "valid_from", "valid_until", "max_uses", "uses", "created_by", "created_at"}
+
+func TestCreatePromoCode(t *testing.T) {
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := from.AddDate(0, 1, 0)
+	promo := domain.PromoCode{
+		Code: "SPRING10", Kind: domain.PromoPercent, Value: 10, ServiceIDs: []string{"massage", "rehab"},
+		ValidFrom: from, ValidUntil: until, MaxUses: 20, CreatedBy: "1", CreatedAt: from,
+	}
+
+	t.Run("created", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("INSERT INTO promo_codes").
+			WithArgs("SPRING10", "percent", 10.0, "massage,rehab", from, until, 20, 0, "1", from).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.CreatePromoCode(promo); err != nil {
+			t.Fatalf("CreatePromoCode failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("taken", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("INSERT INTO promo_codes").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.CreatePromoCode(promo); !errors.Is(err, domain.ErrPromoExists) {
+			t.Fatalf("CreatePromoCode error = %v, want ErrPromoExists", err)
+		}
+	})
+}
+
+func TestGetPromoCode(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM promo_codes WHERE code = \\$1").
+		WithArgs("SPRING10").
+		WillReturnRows(sqlmock.NewRows(promoRowColumns).
+			AddRow("SPRING10", "percent", 10.0, "massage", from, from.AddDate(0, 1, 0), 20, 3, "1", from))
+	mock.ExpectQuery("SELECT (.+) FROM promo_codes WHERE code = \\$1").
+		WithArgs("NOPE").
+		WillReturnRows(sqlmock.NewRows(promoRowColumns))
+
+	promo, err := repo.GetPromoCode("SPRING10")
+	if err != nil {
+		t.Fatalf("GetPromoCode failed: %v", err)
+	}
+	if promo.Kind != domain.PromoPercent || promo.Uses != 3 || len(promo.ServiceIDs) != 1 || promo.ServiceIDs[0] != "massage" {
+		t.Errorf("unexpected promo: %+v", promo)
+	}
+	if _, err := repo.GetPromoCode("NOPE"); !errors.Is(err, domain.ErrPromoNotFound) {
+		t.Errorf("GetPromoCode error = %v, want ErrPromoNotFound", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListPromoCodes(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM promo_codes ORDER BY created_at DESC").
+		WillReturnRows(sqlmock.NewRows(promoRowColumns).
+			AddRow("SPRING10", "percent", 10.0, "", from, from.AddDate(0, 1, 0), 0, 3, "1", from).
+			AddRow("MINUS500", "fixed", 500.0, "massage,rehab", from, from.AddDate(0, 2, 0), 5, 5, "1", from.Add(-time.Hour)))
+
+	promos, err := repo.ListPromoCodes()
+	if err != nil {
+		t.Fatalf("ListPromoCodes failed: %v", err)
+	}
+	if len(promos) != 2 {
+		t.Fatalf("expected 2 promo codes, got %d", len(promos))
+	}
+	if promos[0].ServiceIDs != nil || promos[1].Kind != domain.PromoFixed || len(promos[1].ServiceIDs) != 2 {
+		t.Errorf("unexpected promo codes: %+v", promos)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReservePromoCode(t *testing.T) {
+	t.Run("reserved", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("UPDATE promo_codes SET uses = uses \\+ 1 WHERE code = \\$1 AND \\(max_uses = 0 OR uses < max_uses\\)").
+			WithArgs("SPRING10").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		if err := repo.ReservePromoCode("SPRING10"); err != nil {
+			t.Fatalf("ReservePromoCode failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("used up", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("UPDATE promo_codes").
+			WithArgs("SPRING10").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		if err := repo.ReservePromoCode("SPRING10"); !errors.Is(err, domain.ErrPromoUsedUp) {
+			t.Fatalf("ReservePromoCode error = %v, want ErrPromoUsedUp", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestReleasePromoCode(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("UPDATE promo_codes SET uses = uses - 1 WHERE code = \\$1 AND uses > 0").
+		WithArgs("SPRING10").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.ReleasePromoCode("SPRING10"); err != nil {
+		t.Fatalf("ReleasePromoCode failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRecordPromoUsage(t *testing.T) {
+	used := time.Date(2030, 1, 5, 10, 0, 0, 0, time.UTC)
+	usage := domain.PromoUsage{Code: "SPRING10", AppointmentID: "a1", PatientID: "100", PatientName: "Иван", ServiceName: "Массаж", Discount: 200, UsedAt: used}
+
+	t.Run("recorded", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("INSERT INTO promo_usages").
+			WithArgs("a1", "SPRING10", "100", "Иван", "Массаж", 200.0, used).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		recorded, err := repo.RecordPromoUsage(usage)
+		if err != nil || !recorded {
+			t.Fatalf("RecordPromoUsage = %v, %v; want true, nil", recorded, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("already recorded", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectExec("INSERT INTO promo_usages").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		recorded, err := repo.RecordPromoUsage(usage)
+		if err != nil || recorded {
+			t.Fatalf("RecordPromoUsage = %v, %v; want false, nil", recorded, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestListPromoUsages(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	used := time.Date(2030, 1, 5, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM promo_usages WHERE code = \\$1").
+		WithArgs("SPRING10").
+		WillReturnRows(sqlmock.NewRows([]string{"code", "appointment_id", "patient_id", "patient_name", "service_name", "discount", "used_at"}).
+			AddRow("SPRING10", "a1", "100", "Иван", "Массаж", 200.0, used))
+
+	usages, err := repo.ListPromoUsages("SPRING10")
+	if err != nil {
+		t.Fatalf("ListPromoUsages failed: %v", err)
+	}
+	if len(usages) != 1 || usages[0].AppointmentID != "a1" || usages[0].Discount != 200 {
+		t.Errorf("unexpected usages: %+v", usages)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -289,9 +289,9 @@ func (r *PostgresRepository) UpsertAppointments(appts []domain.Appointment) erro
 
 	query := `
 		INSERT INTO appointments (id, customer_id, service_id, start_time, status, customer_name, 
-		                          service_name, service_duration, service_price, created_at, updated_at)
+		                          service_name, service_duration, service_price, therapist_id, promo_code, discount, created_at, updated_at)
 		VALUES (:id, :customer_id, :service_id, :start_time, :status, :customer_name, 
-		        :service.name, :service.duration, :service.price, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		        :service.name, :service.duration, :service.price, :therapist_id, :promo_code, :discount, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT (id) DO UPDATE SET
 			customer_id = EXCLUDED.customer_id,
 			service_id = EXCLUDED.service_id,
@@ -301,6 +301,9 @@ func (r *PostgresRepository) UpsertAppointments(appts []domain.Appointment) erro
 			service_name = EXCLUDED.service_name,
 			service_duration = EXCLUDED.service_duration,
 			service_price = EXCLUDED.service_price,
+			therapist_id = EXCLUDED.therapist_id,
+			promo_code = EXCLUDED.promo_code,
+			discount = EXCLUDED.discount,
 			updated_at = CURRENT_TIMESTAMP;
 	`
 
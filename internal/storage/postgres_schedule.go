@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.ScheduleRepository = (*PostgresRepository)(nil)
+
+// scheduleExceptionRow is one interval of a dated exception. A closed day is
+// stored as a single row with closed = TRUE.
+type scheduleExceptionRow struct {
+	TherapistID string    `db:"therapist_id"`
+	Date        time.Time `db:"date"`
+	Closed      bool      `db:"closed"`
+	StartMinute int       `db:"start_minute"`
+	EndMinute   int       `db:"end_minute"`
+	Note        string    `db:"note"`
+}
+
+// GetWeeklySchedule loads all weekly rules and the exceptions that can still
+// affect booking (yesterday onward, to be safe around midnight).
+func (r *PostgresRepository) GetWeeklySchedule() (domain.WeeklySchedule, error) {
+	var schedule domain.WeeklySchedule
+
+	err := r.db.Select(&schedule.Rules, `SELECT therapist_id, weekday, kind, start_minute, end_minute FROM schedule_rules ORDER BY therapist_id, weekday, start_minute`)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_schedule").Inc()
+		return schedule, fmt.Errorf("failed to load schedule rules: %w", err)
+	}
+
+	var rows []scheduleExceptionRow
+	err = r.db.Select(&rows, `
+		SELECT therapist_id, date, closed, start_minute, end_minute, note
+		FROM schedule_exceptions
+		WHERE date >= CURRENT_DATE - 1
+		ORDER BY therapist_id, date, start_minute
+	`)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_schedule").Inc()
+		return schedule, fmt.Errorf("failed to load schedule exceptions: %w", err)
+	}
+
+	for _, row := range rows {
+		date := time.Date(row.Date.Year(), row.Date.Month(), row.Date.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+		n := len(schedule.Exceptions)
+		if n == 0 || !schedule.Exceptions[n-1].Date.Equal(date) || schedule.Exceptions[n-1].TherapistID != row.TherapistID {
+			schedule.Exceptions = append(schedule.Exceptions, domain.ScheduleException{TherapistID: row.TherapistID, Date: date, Note: row.Note})
+			n++
+		}
+		exc := &schedule.Exceptions[n-1]
+		if row.Closed {
+			exc.Closed = true
+			continue
+		}
+		exc.Hours = append(exc.Hours, domain.MinuteRange{Start: row.StartMinute, End: row.EndMinute})
+	}
+	return schedule, nil
+}
+
+// ReplaceWeekdayRules deletes one therapist's rules for the weekday and
+// inserts the new set. An empty therapistID addresses the shared schedule.
+func (r *PostgresRepository) ReplaceWeekdayRules(therapistID string, weekday time.Weekday, rules []domain.ScheduleRule) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin schedule transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM schedule_rules WHERE therapist_id = $1 AND weekday = $2`, therapistID, int(weekday)); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_schedule").Inc()
+		return fmt.Errorf("failed to clear schedule rules: %w", err)
+	}
+	for _, rule := range rules {
+		_, err := tx.Exec(`INSERT INTO schedule_rules (therapist_id, weekday, kind, start_minute, end_minute) VALUES ($1, $2, $3, $4, $5)`,
+			therapistID, int(weekday), rule.Kind, rule.StartMinute, rule.EndMinute)
+		if err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("save_schedule").Inc()
+			return fmt.Errorf("failed to insert schedule rule: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schedule rules: %w", err)
+	}
+	return nil
+}
+
+// SaveScheduleException replaces any existing exception for the same date
+// and therapist.
+func (r *PostgresRepository) SaveScheduleException(exc domain.ScheduleException) error {
+	date := exc.Date.In(domain.ApptTimeZone).Format("2006-01-02")
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin schedule transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`DELETE FROM schedule_exceptions WHERE therapist_id = $1 AND date = $2`, exc.TherapistID, date); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_schedule").Inc()
+		return fmt.Errorf("failed to clear schedule exception: %w", err)
+	}
+
+	insert := `INSERT INTO schedule_exceptions (therapist_id, date, closed, start_minute, end_minute, note) VALUES ($1, $2, $3, $4, $5, $6)`
+	rows := exc.Hours
+	if exc.Closed {
+		rows = []domain.MinuteRange{{}}
+	}
+	for _, h := range rows {
+		if _, err := tx.Exec(insert, exc.TherapistID, date, exc.Closed, h.Start, h.End, exc.Note); err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("save_schedule").Inc()
+			return fmt.Errorf("failed to insert schedule exception: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit schedule exception: %w", err)
+	}
+	return nil
+}
+
+// DeleteScheduleException restores the regular weekly hours for a date.
+func (r *PostgresRepository) DeleteScheduleException(therapistID string, date time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM schedule_exceptions WHERE therapist_id = $1 AND date = $2`,
+		therapistID, date.In(domain.ApptTimeZone).Format("2006-01-02"))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_schedule").Inc()
+		return fmt.Errorf("failed to delete schedule exception: %w", err)
+	}
+	return nil
+}
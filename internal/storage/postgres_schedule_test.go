@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestGetWeeklySchedule(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+	domain.ApptTimeZone = time.UTC
+
+	mock.ExpectQuery("SELECT therapist_id, weekday, kind, start_minute, end_minute FROM schedule_rules").
+		WillReturnRows(sqlmock.NewRows([]string{"therapist_id", "weekday", "kind", "start_minute", "end_minute"}).
+			AddRow("", 1, "work", 540, 1080).
+			AddRow("", 1, "break", 780, 840).
+			AddRow("anna", 6, "work", 600, 840))
+
+	day1 := time.Date(2026, 12, 27, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT therapist_id, date, closed, start_minute, end_minute, note FROM schedule_exceptions").
+		WillReturnRows(sqlmock.NewRows([]string{"therapist_id", "date", "closed", "start_minute", "end_minute", "note"}).
+			AddRow("", day1, false, 600, 720, "").
+			AddRow("", day1, false, 780, 900, "").
+			AddRow("", day2, true, 0, 0, "Новый год").
+			AddRow("anna", day2, false, 600, 720, ""))
+
+	schedule, err := repo.GetWeeklySchedule()
+	if err != nil {
+		t.Fatalf("GetWeeklySchedule failed: %v", err)
+	}
+	if len(schedule.Rules) != 3 || schedule.Rules[1].Kind != domain.ScheduleKindBreak || schedule.Rules[0].Weekday != time.Monday || schedule.Rules[2].TherapistID != "anna" {
+		t.Errorf("Unexpected rules: %+v", schedule.Rules)
+	}
+	if len(schedule.Exceptions) != 3 {
+		t.Fatalf("Expected 3 exceptions grouped by therapist and date, got %+v", schedule.Exceptions)
+	}
+	if len(schedule.Exceptions[0].Hours) != 2 || schedule.Exceptions[0].Closed {
+		t.Errorf("Unexpected first exception: %+v", schedule.Exceptions[0])
+	}
+	if !schedule.Exceptions[1].Closed || schedule.Exceptions[1].Note != "Новый год" {
+		t.Errorf("Unexpected second exception: %+v", schedule.Exceptions[1])
+	}
+	if schedule.Exceptions[2].TherapistID != "anna" || schedule.Exceptions[2].Closed {
+		t.Errorf("Unexpected therapist exception: %+v", schedule.Exceptions[2])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetWeeklySchedule_Error(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT (.+) FROM schedule_rules").WillReturnError(errors.New("db down"))
+
+	if _, err := repo.GetWeeklySchedule(); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestReplaceWeekdayRules(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schedule_rules WHERE therapist_id = \\$1 AND weekday = \\$2").WithArgs("anna", 6).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schedule_rules").WithArgs("anna", 6, "work", 600, 840).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.ReplaceWeekdayRules("anna", time.Saturday, []domain.ScheduleRule{
+		{Weekday: time.Saturday, Kind: domain.ScheduleKindWork, StartMinute: 600, EndMinute: 840},
+	})
+	if err != nil {
+		t.Fatalf("ReplaceWeekdayRules failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReplaceWeekdayRules_InsertErrorRollsBack(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM schedule_rules").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO schedule_rules").WillReturnError(errors.New("constraint"))
+	mock.ExpectRollback()
+
+	err := repo.ReplaceWeekdayRules("", time.Monday, []domain.ScheduleRule{{Kind: domain.ScheduleKindWork, StartMinute: 1, EndMinute: 2}})
+	if err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSaveScheduleException(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	date := time.Date(2026, 12, 27, 15, 0, 0, 0, time.UTC)
+
+	t.Run("hours", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM schedule_exceptions WHERE therapist_id = \\$1 AND date = \\$2").WithArgs("", "2026-12-27").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schedule_exceptions").WithArgs("", "2026-12-27", false, 600, 720, "").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO schedule_exceptions").WithArgs("", "2026-12-27", false, 780, 900, "").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		err := repo.SaveScheduleException(domain.ScheduleException{
+			Date:  date,
+			Hours: []domain.MinuteRange{{Start: 600, End: 720}, {Start: 780, End: 900}},
+		})
+		if err != nil {
+			t.Fatalf("SaveScheduleException failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM schedule_exceptions").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schedule_exceptions").WithArgs("anna", "2026-12-27", true, 0, 0, "Отпуск").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := repo.SaveScheduleException(domain.ScheduleException{TherapistID: "anna", Date: date, Closed: true, Note: "Отпуск"}); err != nil {
+			t.Fatalf("SaveScheduleException failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteScheduleException(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+	domain.ApptTimeZone = time.UTC
+
+	mock.ExpectExec("DELETE FROM schedule_exceptions WHERE therapist_id = \\$1 AND date = \\$2").WithArgs("", "2026-12-31").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.DeleteScheduleException("", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("DeleteScheduleException failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.SeriesRepository = (*PostgresRepository)(nil)
+
+// seriesRow is the appointment_series table; the service is flattened.
+type seriesRow struct {
+	ID              int64     `db:"id"`
+	ServiceID       string    `db:"service_id"`
+	ServiceName     string    `db:"service_name"`
+	DurationMinutes int       `db:"duration_minutes"`
+	CustomerID      string    `db:"customer_id"`
+	CustomerName    string    `db:"customer_name"`
+	TherapistID     string    `db:"therapist_id"`
+	FirstStart      time.Time `db:"first_start"`
+	Weekdays        string    `db:"weekdays"`
+	Weeks           int       `db:"weeks"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+// formatWeekdays stores weekdays as "1,4" (time.Weekday numbers).
+func formatWeekdays(days []time.Weekday) string {
+	parts := make([]string, len(days))
+	for i, d := range days {
+		parts[i] = strconv.Itoa(int(d))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseWeekdays(s string) []time.Weekday {
+	var days []time.Weekday
+	for _, p := range strings.Split(s, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			days = append(days, time.Weekday(n))
+		}
+	}
+	return days
+}
+
+// CreateSeries inserts the series and its appointment links in one transaction.
+func (r *PostgresRepository) CreateSeries(series *domain.AppointmentSeries) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin series transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowx(`
+		INSERT INTO appointment_series (service_id, service_name, duration_minutes, customer_id, customer_name, therapist_id, first_start, weekdays, weeks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, series.Service.ID, series.Service.Name, series.Service.DurationMinutes, series.CustomerTgID, series.CustomerName,
+		series.TherapistID, series.FirstStart, formatWeekdays(series.Weekdays), series.Weeks).Scan(&series.ID, &series.CreatedAt)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("create_series").Inc()
+		return fmt.Errorf("failed to create series: %w", err)
+	}
+
+	for _, appt := range series.Appointments {
+		_, err := tx.Exec(`INSERT INTO appointment_series_items (appointment_id, series_id, start_time) VALUES ($1, $2, $3)`,
+			appt.ID, series.ID, appt.StartTime)
+		if err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("create_series").Inc()
+			return fmt.Errorf("failed to link appointment %s to series: %w", appt.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit series: %w", err)
+	}
+	return nil
+}
+
+// GetSeriesByAppointment loads the series of an appointment with its
+// remaining occurrences.
+func (r *PostgresRepository) GetSeriesByAppointment(appointmentID string) (*domain.AppointmentSeries, error) {
+	var row seriesRow
+	err := r.db.Get(&row, `
+		SELECT s.id, s.service_id, s.service_name, s.duration_minutes, s.customer_id, s.customer_name, s.therapist_id, s.first_start, s.weekdays, s.weeks, s.created_at
+		FROM appointment_series s
+		JOIN appointment_series_items i ON i.series_id = s.id
+		WHERE i.appointment_id = $1
+	`, appointmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrSeriesNotFound
+		}
+		monitoring.DbErrorsTotal.WithLabelValues("get_series").Inc()
+		return nil, fmt.Errorf("failed to get series of appointment %s: %w", appointmentID, err)
+	}
+
+	var items []struct {
+		AppointmentID string    `db:"appointment_id"`
+		StartTime     time.Time `db:"start_time"`
+	}
+	if err := r.db.Select(&items, `SELECT appointment_id, start_time FROM appointment_series_items WHERE series_id = $1 ORDER BY start_time`, row.ID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_series").Inc()
+		return nil, fmt.Errorf("failed to list series %d appointments: %w", row.ID, err)
+	}
+
+	series := &domain.AppointmentSeries{
+		ID:           row.ID,
+		Service:      domain.Service{ID: row.ServiceID, Name: row.ServiceName, DurationMinutes: row.DurationMinutes},
+		CustomerName: row.CustomerName,
+		CustomerTgID: row.CustomerID,
+		TherapistID:  row.TherapistID,
+		FirstStart:   row.FirstStart,
+		Weekdays:     parseWeekdays(row.Weekdays),
+		Weeks:        row.Weeks,
+		CreatedAt:    row.CreatedAt,
+	}
+	for _, it := range items {
+		series.Appointments = append(series.Appointments, domain.Appointment{ID: it.AppointmentID, StartTime: it.StartTime})
+	}
+	return series, nil
+}
+
+// RemoveSeriesAppointment drops a cancelled occurrence from its series.
+func (r *PostgresRepository) RemoveSeriesAppointment(appointmentID string) error {
+	if _, err := r.db.Exec(`DELETE FROM appointment_series_items WHERE appointment_id = $1`, appointmentID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_series").Inc()
+		return fmt.Errorf("failed to remove appointment %s from series: %w", appointmentID, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestCreateSeries(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	first := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	created := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO appointment_series").
+		WithArgs("rehab", "Реабилитация", 60, "100", "Иван", "", first, "1,4", 6).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, created))
+	mock.ExpectExec("INSERT INTO appointment_series_items").
+		WithArgs("a1", int64(3), first).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO appointment_series_items").
+		WithArgs("a2", int64(3), first.AddDate(0, 0, 4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	series := &domain.AppointmentSeries{
+		Service:      domain.Service{ID: "rehab", Name: "Реабилитация", DurationMinutes: 60},
+		CustomerName: "Иван", CustomerTgID: "100",
+		FirstStart: first, Weekdays: []time.Weekday{time.Monday, time.Thursday}, Weeks: 6,
+		Appointments: []domain.Appointment{{ID: "a1", StartTime: first}, {ID: "a2", StartTime: first.AddDate(0, 0, 4)}},
+	}
+	if err := repo.CreateSeries(series); err != nil {
+		t.Fatalf("CreateSeries failed: %v", err)
+	}
+	if series.ID != 3 || !series.CreatedAt.Equal(created) {
+		t.Errorf("ID/CreatedAt not filled in: %+v", series)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetSeriesByAppointment(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	first := time.Date(2030, 1, 10, 18, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT (.+) FROM appointment_series s JOIN appointment_series_items i").
+		WithArgs("a2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "service_id", "service_name", "duration_minutes", "customer_id", "customer_name", "therapist_id", "first_start", "weekdays", "weeks", "created_at"}).
+			AddRow(3, "rehab", "Реабилитация", 60, "100", "Иван", "anna", first, "1,4", 6, first))
+	mock.ExpectQuery("SELECT appointment_id, start_time FROM appointment_series_items").
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"appointment_id", "start_time"}).
+			AddRow("a1", first).
+			AddRow("a2", first.AddDate(0, 0, 4)))
+
+	series, err := repo.GetSeriesByAppointment("a2")
+	if err != nil {
+		t.Fatalf("GetSeriesByAppointment failed: %v", err)
+	}
+	if series.ID != 3 || series.Service.ID != "rehab" || series.TherapistID != "anna" || series.Weeks != 6 {
+		t.Errorf("unexpected series: %+v", series)
+	}
+	if len(series.Weekdays) != 2 || series.Weekdays[0] != time.Monday || series.Weekdays[1] != time.Thursday {
+		t.Errorf("Weekdays = %v, want [Monday Thursday]", series.Weekdays)
+	}
+	if len(series.Appointments) != 2 || series.Appointments[1].ID != "a2" {
+		t.Errorf("Appointments = %+v", series.Appointments)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetSeriesByAppointment_NotInSeries(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT (.+) FROM appointment_series s").
+		WithArgs("solo").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, err := repo.GetSeriesByAppointment("solo"); !errors.Is(err, domain.ErrSeriesNotFound) {
+		t.Errorf("expected ErrSeriesNotFound, got %v", err)
+	}
+}
+
+func TestRemoveSeriesAppointment(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("DELETE FROM appointment_series_items WHERE appointment_id = \\$1").
+		WithArgs("a1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.RemoveSeriesAppointment("a1"); err != nil {
+		t.Fatalf("RemoveSeriesAppointment failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.SlotHoldRepository = (*PostgresRepository)(nil)
+
+// PlaceSlotHold upserts the holder's hold after clearing expired ones.
+func (r *PostgresRepository) PlaceSlotHold(hold domain.SlotHold, now time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM slot_holds WHERE expires_at <= $1`, now); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("place_slot_hold").Inc()
+		return fmt.Errorf("failed to clear expired slot holds: %w", err)
+	}
+	_, err := r.db.NamedExec(`
+		INSERT INTO slot_holds (holder_id, therapist_id, slot_start, slot_end, expires_at)
+		VALUES (:holder_id, :therapist_id, :slot_start, :slot_end, :expires_at)
+		ON CONFLICT (holder_id) DO UPDATE SET
+			therapist_id = EXCLUDED.therapist_id,
+			slot_start = EXCLUDED.slot_start,
+			slot_end = EXCLUDED.slot_end,
+			expires_at = EXCLUDED.expires_at
+	`, hold)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("place_slot_hold").Inc()
+		return fmt.Errorf("failed to hold slot for %s: %w", hold.HolderID, err)
+	}
+	return nil
+}
+
+// ReleaseSlotHold drops the holder's hold, if any.
+func (r *PostgresRepository) ReleaseSlotHold(holderID string) error {
+	if _, err := r.db.Exec(`DELETE FROM slot_holds WHERE holder_id = $1`, holderID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("release_slot_hold").Inc()
+		return fmt.Errorf("failed to release slot hold of %s: %w", holderID, err)
+	}
+	return nil
+}
+
+// GetSlotHolds returns valid holds on one calendar overlapping [from, to).
+func (r *PostgresRepository) GetSlotHolds(therapistID string, from, to, now time.Time) ([]domain.SlotHold, error) {
+	var holds []domain.SlotHold
+	err := r.db.Select(&holds, `
+		SELECT holder_id, therapist_id, slot_start, slot_end, expires_at
+		FROM slot_holds
+		WHERE therapist_id = $1 AND slot_start < $3 AND slot_end > $2 AND expires_at > $4
+		ORDER BY slot_start
+	`, therapistID, from, to, now)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("get_slot_holds").Inc()
+		return nil, fmt.Errorf("failed to get slot holds: %w", err)
+	}
+	return holds, nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestPlaceSlotHold(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	now := time.Date(2030, 1, 9, 9, 0, 0, 0, time.UTC)
+	start := now.Add(3 * time.Hour)
+	hold := domain.SlotHold{HolderID: "100", Start: start, End: start.Add(time.Hour), ExpiresAt: now.Add(10 * time.Minute)}
+
+	mock.ExpectExec("DELETE FROM slot_holds WHERE expires_at").WithArgs(now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO slot_holds(.+)ON CONFLICT \\(holder_id\\) DO UPDATE").
+		WithArgs("100", "", start, start.Add(time.Hour), now.Add(10*time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repo.PlaceSlotHold(hold, now); err != nil {
+		t.Fatalf("PlaceSlotHold failed: %v", err)
+	}
+
+	mock.ExpectExec("DELETE FROM slot_holds WHERE expires_at").WillReturnError(errors.New("db down"))
+	if err := repo.PlaceSlotHold(hold, now); err == nil {
+		t.Error("Expected error, got nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReleaseSlotHold(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("DELETE FROM slot_holds WHERE holder_id").WithArgs("100").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.ReleaseSlotHold("100"); err != nil {
+		t.Fatalf("ReleaseSlotHold failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetSlotHolds(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	day := time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	now := day.Add(9 * time.Hour)
+	start := day.Add(12 * time.Hour)
+	mock.ExpectQuery("SELECT (.+) FROM slot_holds WHERE therapist_id = \\$1").
+		WithArgs("t1", day, day.Add(24*time.Hour), now).
+		WillReturnRows(sqlmock.NewRows([]string{"holder_id", "therapist_id", "slot_start", "slot_end", "expires_at"}).
+			AddRow("100", "t1", start, start.Add(time.Hour), now.Add(10*time.Minute)))
+
+	holds, err := repo.GetSlotHolds("t1", day, day.Add(24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("GetSlotHolds failed: %v", err)
+	}
+	if len(holds) != 1 || holds[0].HolderID != "100" || !holds[0].Start.Equal(start) {
+		t.Errorf("unexpected holds: %+v", holds)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM slot_holds").WillReturnError(errors.New("db down"))
+	if _, err := repo.GetSlotHolds("t1", day, day.Add(24*time.Hour), now); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
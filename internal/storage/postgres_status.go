@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.AppointmentStatusRepository = (*PostgresRepository)(nil)
+
+// GetAppointmentStatus returns the recorded status, or booked when none is.
+func (r *PostgresRepository) GetAppointmentStatus(appointmentID string) (domain.AppointmentStatus, error) {
+	var status string
+	err := r.db.Get(&status, `SELECT status FROM appointment_statuses WHERE appointment_id = $1`, appointmentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.StatusBooked, nil
+		}
+		monitoring.DbErrorsTotal.WithLabelValues("get_appointment_status").Inc()
+		return "", fmt.Errorf("failed to get status of appointment %s: %w", appointmentID, err)
+	}
+	return domain.AppointmentStatus(status), nil
+}
+
+// UpdateAppointmentStatus stores the new status only if the current one is
+// still from, and logs the change, in one transaction.
+func (r *PostgresRepository) UpdateAppointmentStatus(appt domain.Appointment, from, to domain.AppointmentStatus) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin status transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(`
+		UPDATE appointment_statuses
+		SET customer_id = $2, start_time = $3, status = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE appointment_id = $1 AND status = $5
+	`, appt.ID, appt.CustomerTgID, appt.StartTime, string(to), string(from))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_appointment_status").Inc()
+		return fmt.Errorf("failed to update status of appointment %s: %w", appt.ID, err)
+	}
+	n, _ := res.RowsAffected()
+	// With no row yet the appointment is booked; a concurrent change that
+	// inserted first makes the insert a no-op.
+	if n == 0 && from == domain.StatusBooked {
+		res, err = tx.Exec(`
+			INSERT INTO appointment_statuses (appointment_id, customer_id, start_time, status)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (appointment_id) DO NOTHING
+		`, appt.ID, appt.CustomerTgID, appt.StartTime, string(to))
+		if err != nil {
+			monitoring.DbErrorsTotal.WithLabelValues("update_appointment_status").Inc()
+			return fmt.Errorf("failed to record status of appointment %s: %w", appt.ID, err)
+		}
+		n, _ = res.RowsAffected()
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: appointment %s is no longer %s", domain.ErrInvalidStatusChange, appt.ID, from)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO appointment_status_history (appointment_id, from_status, to_status) VALUES ($1, $2, $3)`,
+		appt.ID, string(from), string(to)); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_appointment_status").Inc()
+		return fmt.Errorf("failed to log status change of appointment %s: %w", appt.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit status change: %w", err)
+	}
+	return nil
+}
+
+// CountPatientStatuses counts the patient's recorded statuses.
+func (r *PostgresRepository) CountPatientStatuses(customerID string) (map[domain.AppointmentStatus]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	if err := r.db.Select(&rows, `SELECT status, COUNT(*) AS count FROM appointment_statuses WHERE customer_id = $1 GROUP BY status`, customerID); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("count_appointment_statuses").Inc()
+		return nil, fmt.Errorf("failed to count statuses of %s: %w", customerID, err)
+	}
+	counts := make(map[domain.AppointmentStatus]int, len(rows))
+	for _, row := range rows {
+		counts[domain.AppointmentStatus(row.Status)] = row.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestGetAppointmentStatus(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT status FROM appointment_statuses").
+		WithArgs("a1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("no_show"))
+	mock.ExpectQuery("SELECT status FROM appointment_statuses").
+		WithArgs("a2").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+
+	if got, err := repo.GetAppointmentStatus("a1"); err != nil || got != domain.StatusNoShow {
+		t.Errorf("GetAppointmentStatus(a1) = %q, %v; want no_show", got, err)
+	}
+	if got, err := repo.GetAppointmentStatus("a2"); err != nil || got != domain.StatusBooked {
+		t.Errorf("GetAppointmentStatus(a2) = %q, %v; want booked", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestUpdateAppointmentStatus(t *testing.T) {
+	start := time.Date(2030, 1, 9, 12, 0, 0, 0, time.UTC)
+	appt := domain.Appointment{ID: "a1", CustomerTgID: "100", StartTime: start}
+
+	t.Run("existing row", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE appointment_statuses").
+			WithArgs("a1", "100", start, "completed", "confirmed").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO appointment_status_history").
+			WithArgs("a1", "confirmed", "completed").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := repo.UpdateAppointmentStatus(appt, domain.StatusConfirmed, domain.StatusCompleted); err != nil {
+			t.Fatalf("UpdateAppointmentStatus failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("first change from booked", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE appointment_statuses").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO appointment_statuses").
+			WithArgs("a1", "100", start, "confirmed").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO appointment_status_history").
+			WithArgs("a1", "booked", "confirmed").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := repo.UpdateAppointmentStatus(appt, domain.StatusBooked, domain.StatusConfirmed); err != nil {
+			t.Fatalf("UpdateAppointmentStatus failed: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("changed concurrently", func(t *testing.T) {
+		repo, mock, done := newCatalogTestRepo(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE appointment_statuses").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.UpdateAppointmentStatus(appt, domain.StatusConfirmed, domain.StatusNoShow)
+		if !errors.Is(err, domain.ErrInvalidStatusChange) {
+			t.Fatalf("UpdateAppointmentStatus error = %v, want ErrInvalidStatusChange", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+}
+
+func TestCountPatientStatuses(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT status, COUNT\\(\\*\\) AS count FROM appointment_statuses").
+		WithArgs("100").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow("completed", 7).
+			AddRow("no_show", 2))
+
+	counts, err := repo.CountPatientStatuses("100")
+	if err != nil {
+		t.Fatalf("CountPatientStatuses failed: %v", err)
+	}
+	if counts[domain.StatusCompleted] != 7 || counts[domain.StatusNoShow] != 2 || counts[domain.StatusLateCancel] != 0 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.TherapistRepository = (*PostgresRepository)(nil)
+
+// ListTherapists returns every therapist, active or not, in display order.
+func (r *PostgresRepository) ListTherapists() ([]domain.Therapist, error) {
+	var therapists []domain.Therapist
+	err := r.db.Select(&therapists, `
+		SELECT id, name, telegram_id, calendar_id, active, sort_order
+		FROM therapists
+		ORDER BY sort_order ASC, name ASC
+	`)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_therapists").Inc()
+		return nil, fmt.Errorf("failed to list therapists: %w", err)
+	}
+	return therapists, nil
+}
+
+// SaveTherapist inserts or updates a therapist by ID.
+func (r *PostgresRepository) SaveTherapist(t domain.Therapist) error {
+	query := `
+		INSERT INTO therapists (id, name, telegram_id, calendar_id, active, sort_order)
+		VALUES (:id, :name, :telegram_id, :calendar_id, :active, :sort_order)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			telegram_id = EXCLUDED.telegram_id,
+			calendar_id = EXCLUDED.calendar_id,
+			active = EXCLUDED.active,
+			sort_order = EXCLUDED.sort_order
+	`
+	if _, err := r.db.NamedExec(query, t); err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("save_therapist").Inc()
+		return fmt.Errorf("failed to save therapist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+func TestListTherapists(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "telegram_id", "calendar_id", "active", "sort_order"}).
+		AddRow("anna", "Анна", "111", "anna@group.calendar.google.com", true, 1).
+		AddRow("boris", "Борис", "", "", false, 2)
+	mock.ExpectQuery("SELECT (.+) FROM therapists ORDER BY sort_order").WillReturnRows(rows)
+
+	therapists, err := repo.ListTherapists()
+	if err != nil {
+		t.Fatalf("ListTherapists failed: %v", err)
+	}
+	if len(therapists) != 2 {
+		t.Fatalf("Expected 2 therapists, got %d", len(therapists))
+	}
+	if therapists[0].CalendarID != "anna@group.calendar.google.com" || !therapists[0].Active || therapists[1].Active {
+		t.Errorf("Unexpected therapists: %+v", therapists)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListTherapists_Error(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT (.+) FROM therapists").WillReturnError(errors.New("db down"))
+	if _, err := repo.ListTherapists(); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestSaveTherapist(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("INSERT INTO therapists").WillReturnResult(sqlmock.NewResult(1, 1))
+	if err := repo.SaveTherapist(domain.Therapist{ID: "anna", Name: "Анна", Active: true}); err != nil {
+		t.Fatalf("SaveTherapist failed: %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO therapists").WillReturnError(errors.New("db down"))
+	if err := repo.SaveTherapist(domain.Therapist{ID: "anna", Name: "Анна"}); err == nil {
+		t.Error("Expected error from SaveTherapist")
+	}
+}
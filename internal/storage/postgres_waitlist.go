@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kfilin/massage-bot/internal/domain"
+	"github.com/kfilin/massage-bot/internal/monitoring"
+	"github.com/kfilin/massage-bot/internal/ports"
+)
+
+var _ ports.WaitlistRepository = (*PostgresRepository)(nil)
+
+const waitlistEntryColumns = `id, patient_id, patient_name, service_id, service_name, duration_minutes, therapist_id, date_from, date_to, status, created_at`
+
+const waitlistOfferColumns = `id, entry_id, patient_id, therapist_id, slot_start, slot_end, status, expires_at, created_at`
+
+// waitlistDate formats a calendar date for a DATE column.
+func waitlistDate(t time.Time) string {
+	return t.In(domain.ApptTimeZone).Format("2006-01-02")
+}
+
+// normalizeWaitlistDates moves DATE values (scanned as UTC midnight) to
+// midnight in ApptTimeZone, like schedule exceptions.
+func normalizeWaitlistDates(e *domain.WaitlistEntry) {
+	e.DateFrom = time.Date(e.DateFrom.Year(), e.DateFrom.Month(), e.DateFrom.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+	e.DateTo = time.Date(e.DateTo.Year(), e.DateTo.Month(), e.DateTo.Day(), 0, 0, 0, 0, domain.ApptTimeZone)
+}
+
+// AddWaitlistEntry inserts a new entry and sets its ID and CreatedAt.
+func (r *PostgresRepository) AddWaitlistEntry(e *domain.WaitlistEntry) error {
+	err := r.db.QueryRowx(`
+		INSERT INTO waitlist_entries (patient_id, patient_name, service_id, service_name, duration_minutes, therapist_id, date_from, date_to, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, e.PatientID, e.PatientName, e.ServiceID, e.ServiceName, e.DurationMinutes, e.TherapistID,
+		e.DateFrom.Format("2006-01-02"), e.DateTo.Format("2006-01-02"), e.Status).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("add_waitlist_entry").Inc()
+		return fmt.Errorf("failed to add waitlist entry: %w", err)
+	}
+	return nil
+}
+
+// GetWaitlistEntry loads one entry by ID.
+func (r *PostgresRepository) GetWaitlistEntry(id int64) (*domain.WaitlistEntry, error) {
+	var e domain.WaitlistEntry
+	if err := r.db.Get(&e, `SELECT `+waitlistEntryColumns+` FROM waitlist_entries WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrWaitlistEntryNotFound
+		}
+		monitoring.DbErrorsTotal.WithLabelValues("get_waitlist_entry").Inc()
+		return nil, fmt.Errorf("failed to get waitlist entry %d: %w", id, err)
+	}
+	normalizeWaitlistDates(&e)
+	return &e, nil
+}
+
+// ListPatientWaitlist returns the patient's open entries that have not ended.
+func (r *PostgresRepository) ListPatientWaitlist(patientID string) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.Select(&entries, `
+		SELECT `+waitlistEntryColumns+`
+		FROM waitlist_entries
+		WHERE patient_id = $1 AND status IN ('waiting', 'offered') AND date_to >= $2
+		ORDER BY date_from, id
+	`, patientID, waitlistDate(time.Now()))
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_waitlist").Inc()
+		return nil, fmt.Errorf("failed to list waitlist for %s: %w", patientID, err)
+	}
+	for i := range entries {
+		normalizeWaitlistDates(&entries[i])
+	}
+	return entries, nil
+}
+
+// ListWaitlistCandidates returns waiting entries covering the slot's date
+// that were not offered this exact slot before, first come first served.
+func (r *PostgresRepository) ListWaitlistCandidates(slotStart time.Time) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.Select(&entries, `
+		SELECT `+waitlistEntryColumns+`
+		FROM waitlist_entries e
+		WHERE e.status = 'waiting' AND e.date_from <= $1 AND e.date_to >= $1
+		  AND NOT EXISTS (SELECT 1 FROM waitlist_offers o WHERE o.entry_id = e.id AND o.slot_start = $2)
+		ORDER BY e.created_at, e.id
+	`, waitlistDate(slotStart), slotStart)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_waitlist").Inc()
+		return nil, fmt.Errorf("failed to list waitlist candidates: %w", err)
+	}
+	for i := range entries {
+		normalizeWaitlistDates(&entries[i])
+	}
+	return entries, nil
+}
+
+// SetWaitlistEntryStatus moves an entry to a new state.
+func (r *PostgresRepository) SetWaitlistEntryStatus(id int64, status string) error {
+	res, err := r.db.Exec(`UPDATE waitlist_entries SET status = $1 WHERE id = $2`, status, id)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_waitlist_entry").Inc()
+		return fmt.Errorf("failed to update waitlist entry %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrWaitlistEntryNotFound
+	}
+	return nil
+}
+
+// CreateWaitlistOffer inserts a new offer and sets its ID and CreatedAt.
+func (r *PostgresRepository) CreateWaitlistOffer(o *domain.WaitlistOffer) error {
+	err := r.db.QueryRowx(`
+		INSERT INTO waitlist_offers (entry_id, patient_id, therapist_id, slot_start, slot_end, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, o.EntryID, o.PatientID, o.TherapistID, o.SlotStart, o.SlotEnd, o.Status, o.ExpiresAt).Scan(&o.ID, &o.CreatedAt)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("create_waitlist_offer").Inc()
+		return fmt.Errorf("failed to create waitlist offer: %w", err)
+	}
+	return nil
+}
+
+// GetWaitlistOffer loads one offer by ID.
+func (r *PostgresRepository) GetWaitlistOffer(id int64) (*domain.WaitlistOffer, error) {
+	var o domain.WaitlistOffer
+	if err := r.db.Get(&o, `SELECT `+waitlistOfferColumns+` FROM waitlist_offers WHERE id = $1`, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrOfferNotFound
+		}
+		monitoring.DbErrorsTotal.WithLabelValues("get_waitlist_offer").Inc()
+		return nil, fmt.Errorf("failed to get waitlist offer %d: %w", id, err)
+	}
+	return &o, nil
+}
+
+// SetWaitlistOfferStatus moves an offer to a new state.
+func (r *PostgresRepository) SetWaitlistOfferStatus(id int64, status string) error {
+	res, err := r.db.Exec(`UPDATE waitlist_offers SET status = $1 WHERE id = $2`, status, id)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("update_waitlist_offer").Inc()
+		return fmt.Errorf("failed to update waitlist offer %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrOfferNotFound
+	}
+	return nil
+}
+
+// ListExpiredWaitlistOffers returns pending offers that ran out by now.
+func (r *PostgresRepository) ListExpiredWaitlistOffers(now time.Time) ([]domain.WaitlistOffer, error) {
+	var offers []domain.WaitlistOffer
+	err := r.db.Select(&offers, `
+		SELECT `+waitlistOfferColumns+`
+		FROM waitlist_offers
+		WHERE status = 'pending' AND expires_at <= $1
+		ORDER BY expires_at, id
+	`, now)
+	if err != nil {
+		monitoring.DbErrorsTotal.WithLabelValues("list_waitlist_offers").Inc()
+		return nil, fmt.Errorf("failed to list expired waitlist offers: %w", err)
+	}
+	return offers, nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kfilin/massage-bot/internal/domain"
+)
+
+var waitlistEntryRowColumns = []string{"id", "patient_id", "patient_name", "service_id", "service_name", "duration_minutes", "therapist_id", "date_from", "date_to", "status", "created_at"}
+
+func TestAddWaitlistEntry(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	created := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO waitlist_entries").
+		WithArgs("100", "Alice", "1", "Massage", 60, "", "2030-01-09", "2030-01-15", domain.WaitlistWaiting).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(7, created))
+
+	e := &domain.WaitlistEntry{
+		PatientID: "100", PatientName: "Alice", ServiceID: "1", ServiceName: "Massage", DurationMinutes: 60,
+		DateFrom: time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC),
+		DateTo:   time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC),
+		Status:   domain.WaitlistWaiting,
+	}
+	if err := repo.AddWaitlistEntry(e); err != nil {
+		t.Fatalf("AddWaitlistEntry failed: %v", err)
+	}
+	if e.ID != 7 || !e.CreatedAt.Equal(created) {
+		t.Errorf("ID/CreatedAt not filled in: %+v", e)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListWaitlistCandidates(t *testing.T) {
+	domain.ApptTimeZone = time.UTC
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	slot := time.Date(2030, 1, 10, 14, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(waitlistEntryRowColumns).
+		AddRow(1, "100", "Alice", "1", "Massage", 60, "", time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 15, 0, 0, 0, 0, time.UTC), "waiting", slot)
+	mock.ExpectQuery("SELECT (.+) FROM waitlist_entries e WHERE e.status = 'waiting'(.+)NOT EXISTS").
+		WithArgs("2030-01-10", slot).
+		WillReturnRows(rows)
+
+	entries, err := repo.ListWaitlistCandidates(slot)
+	if err != nil {
+		t.Fatalf("ListWaitlistCandidates failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].PatientID != "100" || !entries[0].Covers(slot) {
+		t.Errorf("unexpected candidates: %+v", entries)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM waitlist_entries").WillReturnError(errors.New("db down"))
+	if _, err := repo.ListWaitlistCandidates(slot); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
+
+func TestGetWaitlistOffer_NotFound(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectQuery("SELECT (.+) FROM waitlist_offers WHERE id").WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if _, err := repo.GetWaitlistOffer(5); !errors.Is(err, domain.ErrOfferNotFound) {
+		t.Errorf("expected ErrOfferNotFound, got %v", err)
+	}
+}
+
+func TestSetWaitlistStatus(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	mock.ExpectExec("UPDATE waitlist_offers SET status").WithArgs(domain.OfferAccepted, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := repo.SetWaitlistOfferStatus(3, domain.OfferAccepted); err != nil {
+		t.Fatalf("SetWaitlistOfferStatus failed: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE waitlist_entries SET status").WithArgs(domain.WaitlistBooked, int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := repo.SetWaitlistEntryStatus(9, domain.WaitlistBooked); !errors.Is(err, domain.ErrWaitlistEntryNotFound) {
+		t.Errorf("expected ErrWaitlistEntryNotFound, got %v", err)
+	}
+}
+
+func TestListExpiredWaitlistOffers(t *testing.T) {
+	repo, mock, done := newCatalogTestRepo(t)
+	defer done()
+
+	now := time.Date(2030, 1, 9, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "entry_id", "patient_id", "therapist_id", "slot_start", "slot_end", "status", "expires_at", "created_at"}).
+		AddRow(1, 2, "100", "", now.Add(4*time.Hour), now.Add(5*time.Hour), "pending", now.Add(-time.Minute), now.Add(-time.Hour))
+	mock.ExpectQuery("SELECT (.+) FROM waitlist_offers WHERE status = 'pending' AND expires_at").WithArgs(now).WillReturnRows(rows)
+
+	offers, err := repo.ListExpiredWaitlistOffers(now)
+	if err != nil {
+		t.Fatalf("ListExpiredWaitlistOffers failed: %v", err)
+	}
+	if len(offers) != 1 || offers[0].EntryID != 2 {
+		t.Errorf("unexpected offers: %+v", offers)
+	}
+}
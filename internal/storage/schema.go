@@ -50,8 +50,16 @@ CREATE TABLE IF NOT EXISTS appointments (
     service_duration INTEGER,
     service_price NUMERIC,
     start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP,
     status TEXT,
     customer_name TEXT,
+    notes TEXT NOT NULL DEFAULT '',
+    therapist_id TEXT NOT NULL DEFAULT '',
+    calendar_id TEXT NOT NULL DEFAULT '',
+    calendar_event_id TEXT NOT NULL DEFAULT '',
+    sync_calendar_id TEXT NOT NULL DEFAULT '',
+    promo_code TEXT NOT NULL DEFAULT '',
+    discount NUMERIC NOT NULL DEFAULT 0,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -70,4 +78,214 @@ CREATE TABLE IF NOT EXISTS patient_media (
 );
 
 CREATE INDEX IF NOT EXISTS idx_media_patient_id ON patient_media(patient_id);
+
+CREATE TABLE IF NOT EXISTS services (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    duration INTEGER NOT NULL,
+    price NUMERIC NOT NULL DEFAULT 0,
+    description TEXT NOT NULL DEFAULT '',
+    category_id TEXT NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS service_categories (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL,
+    icon TEXT NOT NULL DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS schedule_rules (
+    id SERIAL PRIMARY KEY,
+    therapist_id TEXT NOT NULL DEFAULT '',
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    kind TEXT NOT NULL DEFAULT 'work',
+    start_minute INTEGER NOT NULL,
+    end_minute INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS schedule_exceptions (
+    id SERIAL PRIMARY KEY,
+    therapist_id TEXT NOT NULL DEFAULT '',
+    date DATE NOT NULL,
+    closed BOOLEAN NOT NULL DEFAULT FALSE,
+    start_minute INTEGER NOT NULL DEFAULT 0,
+    end_minute INTEGER NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_exceptions_date ON schedule_exceptions(date);
+
+CREATE TABLE IF NOT EXISTS therapists (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    telegram_id TEXT NOT NULL DEFAULT '',
+    calendar_id TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS waitlist_entries (
+    id SERIAL PRIMARY KEY,
+    patient_id TEXT NOT NULL,
+    patient_name TEXT NOT NULL DEFAULT '',
+    service_id TEXT NOT NULL,
+    service_name TEXT NOT NULL DEFAULT '',
+    duration_minutes INTEGER NOT NULL,
+    therapist_id TEXT NOT NULL DEFAULT '',
+    date_from DATE NOT NULL,
+    date_to DATE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'waiting',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_open ON waitlist_entries(status, date_from, date_to);
+CREATE INDEX IF NOT EXISTS idx_waitlist_entries_patient ON waitlist_entries(patient_id);
+
+CREATE TABLE IF NOT EXISTS waitlist_offers (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES waitlist_entries(id) ON DELETE CASCADE,
+    patient_id TEXT NOT NULL,
+    therapist_id TEXT NOT NULL DEFAULT '',
+    slot_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    slot_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_waitlist_offers_pending ON waitlist_offers(status, expires_at);
+CREATE INDEX IF NOT EXISTS idx_waitlist_offers_entry ON waitlist_offers(entry_id, slot_start);
+
+CREATE TABLE IF NOT EXISTS appointment_series (
+    id SERIAL PRIMARY KEY,
+    service_id TEXT NOT NULL,
+    service_name TEXT NOT NULL DEFAULT '',
+    duration_minutes INTEGER NOT NULL,
+    customer_id TEXT NOT NULL DEFAULT '',
+    customer_name TEXT NOT NULL DEFAULT '',
+    therapist_id TEXT NOT NULL DEFAULT '',
+    first_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    weekdays TEXT NOT NULL,
+    weeks INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS appointment_series_items (
+    appointment_id TEXT PRIMARY KEY,
+    series_id INTEGER NOT NULL REFERENCES appointment_series(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_series_items_series ON appointment_series_items(series_id, start_time);
+
+CREATE TABLE IF NOT EXISTS session_packages (
+    id SERIAL PRIMARY KEY,
+    patient_id TEXT NOT NULL,
+    patient_name TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    service_ids TEXT NOT NULL DEFAULT '',
+    sessions_total INTEGER NOT NULL,
+    sessions_used INTEGER NOT NULL DEFAULT 0,
+    price NUMERIC NOT NULL DEFAULT 0,
+    purchased_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    low_balance_alerted BOOLEAN NOT NULL DEFAULT FALSE,
+    expiry_alerted BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_packages_patient ON session_packages(patient_id);
+
+CREATE TABLE IF NOT EXISTS package_usages (
+    appointment_id TEXT PRIMARY KEY,
+    package_id INTEGER NOT NULL REFERENCES session_packages(id) ON DELETE CASCADE,
+    service_name TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS appointment_statuses (
+    appointment_id TEXT PRIMARY KEY,
+    customer_id TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    status TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_statuses_customer ON appointment_statuses(customer_id, status);
+
+CREATE TABLE IF NOT EXISTS appointment_status_history (
+    id SERIAL PRIMARY KEY,
+    appointment_id TEXT NOT NULL,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_appointment_status_history_appt ON appointment_status_history(appointment_id);
+
+CREATE TABLE IF NOT EXISTS slot_holds (
+    holder_id TEXT PRIMARY KEY,
+    therapist_id TEXT NOT NULL DEFAULT '',
+    slot_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    slot_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_slot_holds_slot ON slot_holds(therapist_id, slot_start);
+
+CREATE TABLE IF NOT EXISTS calendar_sync_state (
+    calendar_id TEXT PRIMARY KEY,
+    sync_token TEXT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    appointment_id TEXT NOT NULL UNIQUE,
+    patient_id TEXT NOT NULL,
+    patient_name TEXT NOT NULL DEFAULT '',
+    service_id TEXT NOT NULL DEFAULT '',
+    service_name TEXT NOT NULL DEFAULT '',
+    list_price NUMERIC NOT NULL DEFAULT 0,
+    discount NUMERIC NOT NULL DEFAULT 0,
+    amount NUMERIC NOT NULL DEFAULT 0,
+    currency TEXT NOT NULL,
+    method TEXT NOT NULL,
+    visit_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    paid_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    recorded_by TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_paid_at ON payments(paid_at);
+CREATE INDEX IF NOT EXISTS idx_payments_patient ON payments(patient_id);
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    code TEXT PRIMARY KEY,
+    kind TEXT NOT NULL,
+    value NUMERIC NOT NULL,
+    service_ids TEXT NOT NULL DEFAULT '',
+    valid_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    valid_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_uses INTEGER NOT NULL DEFAULT 0,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS promo_usages (
+    appointment_id TEXT PRIMARY KEY,
+    code TEXT NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    patient_id TEXT NOT NULL,
+    patient_name TEXT NOT NULL DEFAULT '',
+    service_name TEXT NOT NULL DEFAULT '',
+    discount NUMERIC NOT NULL DEFAULT 0,
+    used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_usages_code ON promo_usages(code);
 `
@@ -12,6 +12,7 @@
 //   auth            Generate OAuth URL to get a token for a Google account
 //   migrate         Migrate appointments from vfilinav@ to veramassagist@
 //   link-patients   Assign TGIDs to patients by linking event names to Telegram IDs
+//   backfill-properties  Store booking metadata in extended properties of old events
 package main
 
 import (
@@ -30,6 +31,8 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
+	"github.com/kfilin/massage-bot/internal/adapters/googlecalendar"
+	"github.com/kfilin/massage-bot/internal/domain"
 	_ "github.com/lib/pq"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -55,6 +58,7 @@ func main() {
 	fmt.Println("  Optional:")
 	fmt.Println("    since_date  ISO date (2006-01-02) to resume from. Default: 2024-01-01")
 	fmt.Println("  link-patients        Assign TGIDs to patients by linking event names to Telegram IDs")
+	fmt.Println("  backfill-properties [calendar_id]  Store booking metadata in extended properties of old events")
 		fmt.Println("")
 		fmt.Println("Environment:")
 		fmt.Println("  Loads .env from project root (DATABASE_URL or DB_* vars)")
@@ -88,6 +92,12 @@ func main() {
 		doMigrate(migrateFromFile, sinceDate)
 	case "link-patients":
 		linkPatients()
+	case "backfill-properties":
+		calendarID := ""
+		if len(os.Args) > 2 {
+			calendarID = os.Args[2]
+		}
+		backfillProperties(calendarID)
 	default:
 		log.Fatalf("Unknown command: %s", cmd)
 	}
@@ -917,6 +927,73 @@ func linkPatients() {
 	fmt.Println("  2. syncPatientStats will recalculate the correct visit count")
 }
 
+// ─── Extended Properties Backfill ────────────────────────────────────────────
+
+// backfillProperties writes service ID, customer TGID, lifecycle status and
+// booking source into the extended properties of events booked before the
+// bot stored them there. Service IDs come from the services table by name,
+// statuses from appointment_statuses. Events that already have properties
+// are left alone, so it is safe to run again.
+func backfillProperties(calendarID string) {
+	fmt.Println("\n=== Backfill Extended Properties ===")
+	svc, defaultID := makeCalendarClient()
+	if calendarID == "" {
+		calendarID = defaultID
+	}
+	getCalendarInfo(svc, calendarID)
+
+	db := connectDB()
+	defer db.Close()
+
+	var services []struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := db.Select(&services, "SELECT id, name FROM services"); err != nil {
+		log.Fatalf("Failed to load services: %v", err)
+	}
+	serviceIDs := make(map[string]string, len(services))
+	for _, s := range services {
+		serviceIDs[strings.ToLower(strings.TrimSpace(s.Name))] = s.ID
+	}
+
+	var statusRows []struct {
+		ID     string `db:"appointment_id"`
+		Status string `db:"status"`
+	}
+	if err := db.Select(&statusRows, "SELECT appointment_id, status FROM appointment_statuses"); err != nil {
+		log.Fatalf("Failed to load appointment statuses: %v", err)
+	}
+	statuses := make(map[string]domain.AppointmentStatus, len(statusRows))
+	for _, r := range statusRows {
+		statuses[r.ID] = domain.AppointmentStatus(r.Status)
+	}
+
+	if !confirm(fmt.Sprintf("Write extended properties to old events in %s?", calendarID)) {
+		fmt.Println("Skipped backfill.")
+		return
+	}
+
+	updated, err := googlecalendar.BackfillProperties(context.Background(), svc, calendarID, func(appt *domain.Appointment) bool {
+		appt.ServiceID = serviceIDs[strings.ToLower(strings.TrimSpace(appt.Service.Name))]
+		appt.LifecycleStatus = statuses[appt.ID]
+		// The bot tagged each kind of booking in its notes
+		switch strings.TrimSpace(appt.Notes) {
+		case "Telegram Bot Booking":
+			appt.Source = domain.SourceBot
+		case "Manual Appointment by Admin":
+			appt.Source = domain.SourceAdmin
+		case "Manual Block by Admin":
+			appt.Source = domain.SourceBlock
+		}
+		fmt.Printf("  → %s | %s | TGID %s\n", appt.StartTime.Format("2006-01-02 15:04"), ellipsis(appt.Service.Name+" - "+appt.CustomerName, 40), appt.CustomerTgID)
+		return true
+	})
+	if err != nil {
+		log.Fatalf("Backfill stopped after %d events: %v", updated, err)
+	}
+	fmt.Printf("\n✅ Updated %d events.\n", updated)
+}
 
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {